@@ -0,0 +1,25 @@
+// Command gendocs writes the OpenAPI 3.0 spec of the API to stdout as YAML.
+// The spec is validated first, so a broken spec fails the build instead of
+// being published.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"SubscriptionAggregator/pkg/docs"
+)
+
+func main() {
+	out, err := docs.YAML(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gendocs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := os.Stdout.Write(out); err != nil {
+		fmt.Fprintf(os.Stderr, "gendocs: %v\n", err)
+		os.Exit(1)
+	}
+}
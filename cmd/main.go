@@ -8,42 +8,67 @@
 // @host localhost:8080
 // @BasePath /
 
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Bearer <admin-token> из admin.token в конфиге
+
+// @securityDefinitions.apikey Tenant
+// @in header
+// @name X-Tenant-ID
+// @description UUID тенанта. Если задан tenant.jwt_secret, тенант берется из claim tenant.jwt_claim токена Authorization: Bearer <JWT>
+
 package main
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
+	"github.com/google/uuid"
 
-	_ "SubscriptionAggregator/docs"
+	"SubscriptionAggregator/pkg/app"
 	"SubscriptionAggregator/pkg/config"
-	"SubscriptionAggregator/pkg/handler"
+	"SubscriptionAggregator/pkg/events"
+	"SubscriptionAggregator/pkg/logger"
 	"SubscriptionAggregator/pkg/repository"
-	"SubscriptionAggregator/pkg/service"
-
-	httpSwagger "github.com/swaggo/http-swagger"
+	"SubscriptionAggregator/pkg/worker"
 )
 
-const (
-	envLocal  = "local"
-	envDocker = "docker"
-)
+// version is stamped at build time with -ldflags "-X main.version=...".
+var version = "dev"
 
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env)
+	log, err := setupLogger(cfg.Log)
+	if err != nil {
+		log.Warn("falling back to stdout logging", slog.String("error", err.Error()))
+	}
+	// logger.FromContext falls back to the default outside a request.
+	slog.SetDefault(log)
+
+	if !cfg.KnownEnv() {
+		log.Warn("unrecognized env, using default logging",
+			slog.String("env", cfg.Env),
+			slog.String("format", cfg.Log.Format),
+			slog.String("level", cfg.Log.Level),
+		)
+	}
 
 	log.Info("starting subscriptionaggregator", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
+	log.Debug("effective config", slog.Any("config", cfg))
 
 	dbURL := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host,
@@ -54,59 +79,262 @@ func main() {
 		cfg.Sslmode,
 	)
 
-	pg, err := repository.New(ctx, dbURL)
+	// dbURL carries the password; only its masked form may be logged.
+	log.Debug("connecting to database", slog.String("conn", repository.MaskedConnString(dbURL)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	pg, err := repository.New(ctx, dbURL, log)
+	cancel()
 	if err != nil {
-		log.Error("failed to initialize database", slog.String("error", err.Error()))
+		log.Error("failed to initialize database",
+			slog.String("conn", repository.MaskedConnString(dbURL)),
+			slog.String("error", err.Error()),
+		)
 		os.Exit(1)
 	}
-	defer pg.Close()
 
-	repo := repository.NewSubscriptionRepository(pg.DB)
+	if err := assignLegacyTenant(log, pg.DB, cfg.Tenant.LegacyID); err != nil {
+		log.Error("failed to assign legacy subscriptions to a tenant", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	router := mux.NewRouter()
-	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	changes, err := events.NewPGNotifyListener(dbURL, repository.ChangesChannel, log)
+	if err != nil {
+		log.Error("failed to listen for subscription changes", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// Background jobs share one context that is cancelled on shutdown.
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	go changes.Run(bgCtx)
 
-	svc := service.NewSubscriptionService(repo)
+	reminders := worker.NewReminderWorker(repository.NewReminderRepository(pg.DB), worker.NewLogNotifier(log), log, worker.DefaultReminderInterval)
+	remindersDone := make(chan struct{})
+	go func() {
+		defer close(remindersDone)
+		reminders.Run(bgCtx)
+	}()
 
-	hlr := handler.NewSubscriptionHandler(svc)
+	renewals := worker.NewRenewalProcessor(repository.NewRenewalRepository(pg.DB), log, cfg.Renewal.Interval)
+	renewalsDone := make(chan struct{})
+	go func() {
+		defer close(renewalsDone)
+		renewals.Run(bgCtx)
+	}()
 
-	hlr.RegisterRoutes(router)
+	reportsDone := make(chan struct{})
+	if cfg.Report.Enabled {
+		var reporter worker.Reporter = worker.NewLogReporter(log)
+		if cfg.Report.WebhookURL != "" {
+			reporter = worker.NewWebhookReporter(cfg.Report.WebhookURL)
+		}
+		reports := worker.NewReportJob(repository.NewReportRepository(pg.DB), reporter, log, cfg.Report.Interval)
+		go func() {
+			defer close(reportsDone)
+			reports.Run(bgCtx)
+		}()
+	} else {
+		close(reportsDone)
+	}
 
-	srv := &http.Server{
-		Addr:         cfg.Adress,
-		Handler:      router,
-		ReadTimeout:  cfg.HTTPServer.TimeOut,
-		WriteTimeout: cfg.HTTPServer.TimeOut,
-		IdleTimeout:  cfg.HTTPServer.IdleTimeOut,
+	api, err := app.New(cfg, pg.DB, changes, newRateProvider(cfg.Currency), log)
+	if err != nil {
+		log.Error("failed to build the API", slog.String("error", err.Error()))
+		os.Exit(1)
 	}
 
-	done := make(chan os.Signal, 1)
+	srv := newServer(cfg.Adress, cfg.HTTPServer, api.Router)
+	// Shutdown waits for active requests; ending the change stream lets
+	// open SSE connections return instead of running into the timeout.
+	srv.RegisterOnShutdown(stopBackground)
+	servers := []*http.Server{srv}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Error("failed to start server", slog.String("error", err.Error()))
+	if cfg.TLS.Enabled() {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			log.Error("failed to configure TLS", slog.String("error", err.Error()))
+			os.Exit(1)
 		}
-	}()
-	log.Info("server started", slog.String("adress", cfg.Adress))
+		srv.TLSConfig = tlsConfig
 
-	<-done
-	log.Info("server stopped")
+		if cfg.TLS.RedirectAddress != "" {
+			servers = append(servers, newServer(cfg.TLS.RedirectAddress, cfg.HTTPServer, redirectToHTTPS(cfg.Adress)))
+		}
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	if err := runServers(log, servers, stop, cfg.ShutdownTimeout); err != nil {
+		log.Error("server stopped with error", slog.String("error", err.Error()))
+	}
+	// Shutdown leaves hijacked WebSocket connections open.
+	wsCtx, cancelWS := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	if err := api.WebSocket.Shutdown(wsCtx); err != nil {
+		log.Warn("websocket connections did not close in time", slog.String("error", err.Error()))
+	}
+	cancelWS()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error("server shutdown failed", slog.String("error", err.Error()))
+	// Close the pool only after in-flight requests and background jobs
+	// have drained.
+	stopBackground()
+	<-remindersDone
+	<-renewalsDone
+	<-reportsDone
+	api.CostAlerts.Wait()
+	if err := pg.Close(); err != nil {
+		log.Error("failed to close database", slog.String("error", err.Error()))
 	}
 	log.Info("server exited properly")
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+// runServers starts every server and blocks until a signal arrives on stop or
+// one of the servers fails. All servers are then shut down gracefully with a
+// fresh context bounded by shutdownTimeout.
+func runServers(log *slog.Logger, servers []*http.Server, stop <-chan os.Signal, shutdownTimeout time.Duration) error {
+	errCh := make(chan error, len(servers))
+
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			var err error
+			if srv.TLSConfig != nil {
+				// Certificates are already loaded into TLSConfig.
+				err = srv.ListenAndServeTLS("", "")
+			} else {
+				err = srv.ListenAndServe()
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("server %s: %w", srv.Addr, err)
+			}
+		}(srv)
+		log.Info("server started", slog.String("adress", srv.Addr), slog.Bool("tls", srv.TLSConfig != nil))
+	}
+
+	var runErr error
+	select {
+	case sig := <-stop:
+		log.Info("shutdown signal received", slog.String("signal", sig.String()))
+	case runErr = <-errCh:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Error("server shutdown failed", slog.String("adress", srv.Addr), slog.String("error", err.Error()))
+		}
+	}
+	log.Info("server stopped")
+
+	return runErr
+}
+
+func newServer(addr string, cfg config.HTTPServer, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.TimeOut,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.TimeOut,
+		IdleTimeout:       cfg.IdleTimeOut,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// assignLegacyTenant moves the subscriptions created before tenants existed
+// to legacyID. Without one configured it only warns when there are any, as
+// no request can reach them.
+func assignLegacyTenant(log *slog.Logger, db *sql.DB, legacyID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if legacyID == "" {
+		orphaned, err := repository.CountUnassignedTenant(ctx, db)
+		if err != nil {
+			return err
+		}
+		if orphaned > 0 {
+			log.Warn("subscriptions without a tenant are unreachable, set tenant.legacy_id to assign them",
+				slog.Int64("count", orphaned))
+		}
+		return nil
+	}
+
+	// Validated with the config.
+	moved, err := repository.AssignLegacyTenant(ctx, db, uuid.MustParse(legacyID))
+	if err != nil {
+		return err
+	}
+	if moved > 0 {
+		log.Info("assigned subscriptions without a tenant", slog.String("tenant_id", legacyID), slog.Int64("count", moved))
+	}
+	return nil
+}
+
+// newTLSConfig loads the key pair eagerly so that missing or unreadable
+// certificate files fail startup instead of the first handshake.
+func newTLSConfig(cfg config.TLS) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate %s / key %s: %w", cfg.CertFile, cfg.KeyFile, err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   config.TLSVersions[cfg.MinVersion],
+	}, nil
+}
+
+// redirectToHTTPS sends plain HTTP clients to the same path on the TLS listener.
+func redirectToHTTPS(tlsAddr string) http.Handler {
+	_, tlsPort, _ := net.SplitHostPort(tlsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if tlsPort != "" && tlsPort != "443" {
+			host = net.JoinHostPort(host, tlsPort)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// setupLogger builds the slog logger described by cfg. It never returns a
+// nil logger: an unparsable level falls back to info and any format other
+// than text to JSON. If the log file cannot be opened it still returns a
+// usable stdout logger along with the error so the caller can report it.
+// Every record carries the service name and build version.
+func setupLogger(cfg config.Log) (*slog.Logger, error) {
+	level, err := logger.ParseLevel(cfg.Level)
+	if err != nil {
+		level = slog.LevelInfo
+	}
 
-	switch env {
-	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envDocker:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	var out io.Writer = os.Stdout
+	var openErr error
+	if cfg.FilePath != "" {
+		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			openErr = fmt.Errorf("failed to open log file %s: %w", cfg.FilePath, err)
+		} else {
+			out = f
+		}
 	}
 
-	return log
+	handler := logger.New(level, func(opts *slog.HandlerOptions) slog.Handler {
+		if cfg.Format == config.LogFormatText {
+			return slog.NewTextHandler(out, opts)
+		}
+		return slog.NewJSONHandler(out, opts)
+	})
+
+	log := slog.New(handler).With(
+		slog.String("service", cfg.Service),
+		slog.String("version", version),
+	)
+
+	return log, openErr
 }
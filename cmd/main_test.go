@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/config"
+)
+
+func startTestServer(t *testing.T, cfg config.HTTPServer) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := newServer(ln.Addr().String(), cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	return ln.Addr().String()
+}
+
+func testServerConfig() config.HTTPServer {
+	return config.HTTPServer{
+		TimeOut:           time.Second,
+		IdleTimeOut:       time.Second,
+		ReadHeaderTimeout: 100 * time.Millisecond,
+		MaxHeaderBytes:    1 << 10,
+	}
+}
+
+func TestServer_SlowHeaderClientDisconnected(t *testing.T) {
+	addr := startTestServer(t, testServerConfig())
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Start a request but never finish the header block.
+	_, err = conn.Write([]byte("GET /subscriptions HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = bufio.NewReader(conn).ReadString('\n')
+
+	assert.Error(t, err, "server should close the connection")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestServer_OversizedHeadersRejected(t *testing.T) {
+	addr := startTestServer(t, testServerConfig())
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/subscriptions", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Padding", strings.Repeat("a", 8<<10))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestRunServers_GracefulShutdownOnSignal(t *testing.T) {
+	addr := freeAddr(t)
+
+	started := make(chan struct{})
+	srv := newServer(addr, testServerConfig(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	done := make(chan error, 1)
+	go func() { done <- runServers(log, []*http.Server{srv}, stop, 2*time.Second) }()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	respCh := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/subscriptions")
+		if err != nil {
+			respCh <- 0
+			return
+		}
+		resp.Body.Close()
+		respCh <- resp.StatusCode
+	}()
+
+	<-started
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("runServers did not return after SIGTERM")
+	}
+
+	assert.Equal(t, http.StatusOK, <-respCh, "in-flight request should drain before shutdown")
+
+	_, err := net.Dial("tcp", addr)
+	assert.Error(t, err, "server should no longer accept connections")
+}
+
+func TestSetupLogger_FallsBackToJSONInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	log, err := setupLogger(config.Log{Format: "", Level: "bogus", FilePath: path, Service: "subs"})
+	require.NoError(t, err)
+	require.NotNil(t, log)
+
+	log.Debug("hidden")
+	log.Info("visible")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hidden")
+	assert.Contains(t, string(data), `"msg":"visible"`)
+	assert.Contains(t, string(data), `"service":"subs"`)
+	assert.Contains(t, string(data), `"version":"dev"`)
+}
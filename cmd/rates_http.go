@@ -0,0 +1,17 @@
+//go:build httprates
+
+package main
+
+import (
+	"SubscriptionAggregator/pkg/config"
+	"SubscriptionAggregator/pkg/currency"
+)
+
+// newRateProvider fetches live rates from currency.rates_url, falling back
+// to the static rates from config when no URL is set.
+func newRateProvider(cfg config.Currency) currency.ExchangeRateProvider {
+	if cfg.RatesURL == "" {
+		return currency.NewStaticProvider(cfg.Base, cfg.Rates)
+	}
+	return currency.NewHTTPProvider(cfg.RatesURL, cfg.Base, cfg.RatesTTL)
+}
@@ -0,0 +1,14 @@
+//go:build !httprates
+
+package main
+
+import (
+	"SubscriptionAggregator/pkg/config"
+	"SubscriptionAggregator/pkg/currency"
+)
+
+// newRateProvider serves the rates listed in the config file. Build with
+// -tags httprates to fetch them from currency.rates_url instead.
+func newRateProvider(cfg config.Currency) currency.ExchangeRateProvider {
+	return currency.NewStaticProvider(cfg.Base, cfg.Rates)
+}
@@ -0,0 +1,309 @@
+// Command smoketest boots the API against a real PostgreSQL database and
+// drives a subscription through its whole life over HTTP: create, read,
+// update, list, total and delete. Unlike the handler tests nothing is
+// mocked, so it catches wiring, SQL and encoding mistakes that only show
+// up end to end. It exits with status 1 on the first failed step.
+//
+// The database is a throwaway postgres container started with
+// testcontainers-go, so the smoke test needs a running Docker daemon and
+// nothing else. The router is the one cmd/main.go serves, built by
+// app.New from the default config with every middleware in place:
+//
+//	make smoke
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"SubscriptionAggregator/pkg/app"
+	"SubscriptionAggregator/pkg/config"
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/events"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+const (
+	// postgresImage matches the db service in docker-compose.yml.
+	postgresImage = "postgres:15-alpine"
+	// containerTimeout covers pulling the image on the first run.
+	containerTimeout = 2 * time.Minute
+	startTimeout     = 5 * time.Second
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "smoketest: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("smoketest: ok")
+}
+
+func run() error {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), containerTimeout)
+	defer cancel()
+	container, err := startPostgres(ctx)
+	// Run may return a container along with the error, e.g. when the wait
+	// strategy times out.
+	defer func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			fmt.Fprintf(os.Stderr, "smoketest: terminate postgres: %v\n", err)
+		}
+	}()
+	if err != nil {
+		return fmt.Errorf("start postgres: %w", err)
+	}
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		return fmt.Errorf("postgres connection string: %w", err)
+	}
+
+	pg, err := repository.New(ctx, dsn, log)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer pg.Close()
+
+	changes, err := events.NewPGNotifyListener(dsn, repository.ChangesChannel, log)
+	if err != nil {
+		return fmt.Errorf("listen for changes: %w", err)
+	}
+	go changes.Run(ctx)
+
+	cfg, err := config.Default()
+	if err != nil {
+		return err
+	}
+	api, err := app.New(cfg, pg.DB, changes, currency.NewStaticProvider(cfg.Currency.Base, cfg.Currency.Rates), log)
+	if err != nil {
+		return err
+	}
+	defer api.CostAlerts.Wait()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	srv := &http.Server{Handler: api.Router, ReadHeaderTimeout: startTimeout}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	c := &client{base: "http://" + ln.Addr().String(), tenantHeader: cfg.Tenant.Header, tenant: uuid.New()}
+	if err := c.waitLive(startTimeout); err != nil {
+		return err
+	}
+	return exercise(c)
+}
+
+// startPostgres starts an empty database. testcontainers-go panics when it
+// finds no Docker daemon; that is reported as an error like any other.
+func startPostgres(ctx context.Context) (container *postgres.PostgresContainer, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("docker is not available: %v", r)
+		}
+	}()
+	return postgres.Run(ctx, postgresImage,
+		postgres.WithDatabase("subscriptions"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		postgres.BasicWaitStrategies(),
+	)
+}
+
+// exercise runs the steps in order; each one depends on the previous.
+func exercise(c *client) error {
+	if err := checkMiddleware(c); err != nil {
+		return step("middleware", err)
+	}
+	c.logf("middleware", "request id and tenant enforced")
+
+	userID := uuid.New()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var created model.Subscription
+	loc, err := c.do(http.MethodPost, "/subscriptions", map[string]any{
+		"service_name": "Smoke Test",
+		"price":        599,
+		"user_id":      userID,
+		"start_date":   start,
+	}, http.StatusCreated, &created)
+	if err != nil {
+		return step("create", err)
+	}
+	switch {
+	case created.ID == uuid.Nil:
+		return step("create", errors.New("no id in the response"))
+	case created.ServiceName != "smoke test" || created.Price != 599 || created.UserID != userID:
+		return step("create", fmt.Errorf("unexpected subscription %+v", created))
+	case loc != "/subscriptions/"+created.ID.String():
+		return step("create", fmt.Errorf("Location is %q", loc))
+	}
+	path := "/subscriptions/" + created.ID.String()
+	c.logf("create", "id %s", created.ID)
+
+	var got model.Subscription
+	if _, err := c.do(http.MethodGet, path, nil, http.StatusOK, &got); err != nil {
+		return step("get", err)
+	}
+	if got.ID != created.ID || got.Price != created.Price {
+		return step("get", fmt.Errorf("got %+v, created %+v", got, created))
+	}
+	c.logf("get", "price %d", got.Price)
+
+	var updated model.Subscription
+	if _, err := c.do(http.MethodPut, path, map[string]any{
+		"service_name": "Smoke Test",
+		"price":        699,
+		"user_id":      userID,
+		"start_date":   start,
+		"version":      created.Version,
+	}, http.StatusOK, &updated); err != nil {
+		return step("update", err)
+	}
+	if updated.ID != created.ID || updated.Price != 699 || updated.Version != created.Version+1 {
+		return step("update", fmt.Errorf("unexpected subscription %+v", updated))
+	}
+	c.logf("update", "price %d", updated.Price)
+
+	var list []model.Subscription
+	if _, err := c.do(http.MethodGet, "/subscriptions?user_id="+userID.String(), nil, http.StatusOK, &list); err != nil {
+		return step("list", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID || list[0].Price != 699 {
+		return step("list", fmt.Errorf("got %+v", list))
+	}
+	c.logf("list", "%d subscription", len(list))
+
+	var total model.TotalCostResponse
+	if _, err := c.do(http.MethodGet, "/subscriptions/total?mode=flat&user_id="+userID.String(), nil, http.StatusOK, &total); err != nil {
+		return step("total", err)
+	}
+	if total.Total != 699 {
+		return step("total", fmt.Errorf("total is %d, want 699", total.Total))
+	}
+	c.logf("total", "%d %s", total.Total, total.Currency)
+
+	if _, err := c.do(http.MethodDelete, path, nil, http.StatusNoContent, nil); err != nil {
+		return step("delete", err)
+	}
+	if _, err := c.do(http.MethodGet, path, nil, http.StatusNotFound, nil); err != nil {
+		return step("delete", fmt.Errorf("still readable: %w", err))
+	}
+	c.logf("delete", "gone")
+	return nil
+}
+
+// checkMiddleware makes sure the requests below went through the chain
+// cmd/main.go serves: a request ID is assigned and a request without a
+// tenant never reaches the handler.
+func checkMiddleware(c *client) error {
+	resp, err := c.http.Get(c.base + "/subscriptions")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("GET /subscriptions without a tenant: status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp.Header.Get(middleware.RequestIDHeader) == "" {
+		return fmt.Errorf("no %s in the response", middleware.RequestIDHeader)
+	}
+	return nil
+}
+
+func step(name string, err error) error {
+	return fmt.Errorf("%s: %w", name, err)
+}
+
+// client calls the API as a single tenant, sent in tenantHeader.
+type client struct {
+	base         string
+	tenantHeader string
+	tenant       uuid.UUID
+	http         http.Client
+}
+
+func (c *client) logf(step, format string, args ...any) {
+	fmt.Printf("ok  %-10s "+format+"\n", append([]any{step}, args...)...)
+}
+
+// waitLive polls /live until the server answers 200 or timeout passes.
+func (c *client) waitLive(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := c.http.Get(c.base + "/live")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("server not live after %s", timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// do sends body as JSON, checks the status and decodes the response into
+// out unless it is nil. It returns the Location header.
+func (c *client) do(method, path string, body any, wantStatus int, out any) (string, error) {
+	var reqBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		reqBody = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, c.base+path, reqBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(c.tenantHeader, c.tenant.String())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != wantStatus {
+		return "", fmt.Errorf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, wantStatus, bytes.TrimSpace(raw))
+	}
+	if out != nil {
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			return "", fmt.Errorf("%s %s: Content-Type %q", method, path, ct)
+		}
+		if err := json.Unmarshal(raw, out); err != nil {
+			return "", fmt.Errorf("%s %s: decode %s: %w", method, path, bytes.TrimSpace(raw), err)
+		}
+	}
+	return resp.Header.Get("Location"), nil
+}
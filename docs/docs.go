@@ -18,54 +18,73 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
-            "get": {
-                "description": "Возвращает подписки с возможностью фильтрации",
+        "/admin/services/{name}/price": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Когда провайдер меняет цены, в одной транзакции устанавливает новую цену всем подпискам на сервис: либо new_price, либо текущую цену, измененную на percent_change процентов (с округлением по каждой подписке, не меньше 1 и меньше максимальной цены). С tenant_id меняются только подписки этого тенанта, с active_only=true — только активные сегодня. effective_date записывает в историю цен, что новая цена действует с этой даты, но не раньше предыдущего изменения цены подписки. Подписки, цена которых не меняется, не учитываются; по каждой измененной в /subscriptions/stream публикуется событие price_changed. В ответе количество измененных подписок и их сумма до и после изменения. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Список подписок",
+                "summary": "Изменить цену сервиса (администратор)",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "example": "Yandex Plus",
-                        "description": "Название сервиса",
-                        "name": "service_name",
-                        "in": "query"
+                        "example": "Netflix",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям)",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "example": "2025-01-01T00:00:00Z",
-                        "description": "Начальная дата (RFC3339)",
-                        "name": "from_date",
+                        "example": "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d",
+                        "description": "Изменить цену только в этом тенанте",
+                        "name": "tenant_id",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "example": "2025-12-31T00:00:00Z",
-                        "description": "Конечная дата (RFC3339)",
-                        "name": "to_date",
-                        "in": "query"
+                        "description": "Новая цена или изменение в процентах",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateServicePriceRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Subscription"
-                            }
+                            "$ref": "#/definitions/model.ServicePriceUpdateResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный tenant_id или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Не указаны или указаны оба new_price и percent_change, неверная цена или процент, effective_date в будущем",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
                         }
                     },
                     "500": {
@@ -77,51 +96,135 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/total": {
-            "get": {
-                "description": "Возвращает общую стоимость подписок за период",
+        "/admin/subscriptions/bulk-price": {
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Устанавливает новую цену всем подпискам тенанта tenant_id на сервис, например когда провайдер поднял цены, и возвращает количество измененных подписок. Подписки, у которых цена уже такая, не меняются. По каждой измененной подписке в /subscriptions/stream публикуется событие price_changed со старой и новой ценой. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Сумма подписок",
+                "summary": "Изменить цену всех подписок тенанта на сервис",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "query"
+                        "example": "Netflix",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "example": "Yandex Plus",
-                        "description": "Название сервиса",
-                        "name": "service_name",
-                        "in": "query"
+                        "example": "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d",
+                        "description": "ID тенанта",
+                        "name": "tenant_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BulkUpdatePriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BulkUpdatePriceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Не указано название сервиса или tenant_id либо неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Цена не положительная или не меньше максимальной",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/subscriptions/creation-rate": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Считает подписки, созданные в интервале [from, to), включая удаленные, и среднее количество в день. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Скорость создания подписок",
+                "parameters": [
                     {
                         "type": "string",
-                        "example": "2025-01-01T00:00:00Z",
-                        "description": "Начальная дата (RFC3339)",
-                        "name": "from_date",
-                        "in": "query"
+                        "example": "2025-01-01",
+                        "description": "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "example": "2025-12-31T00:00:00Z",
-                        "description": "Конечная дата (RFC3339)",
-                        "name": "to_date",
-                        "in": "query"
+                        "example": "2025-02-01",
+                        "description": "Конец периода, не включается (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.TotalCostResponse"
+                            "$ref": "#/definitions/model.CreationRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Не указан или неверный период",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
                     "500": {
@@ -133,41 +236,82 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/admin/subscriptions/total/by-user": {
             "get": {
-                "description": "Возвращает информацию о конкретной подписке",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Суммирует расходы каждого пользователя по всем тенантам и сортирует по убыванию суммы. Сумма считается так же, как в /subscriptions/total с mode=prorated. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Получить подписку",
+                "summary": "Расходы по пользователям",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "550e8400-e29b-41d4-a716-446655440000",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (1-500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Сколько пользователей пропустить",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.UserCost"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Общее количество пользователей, подходящих под фильтр"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Некорректные параметры запроса, from_date позже to_date, слишком большой период или неверные limit/offset",
                         "schema": {
-                            "$ref": "#/definitions/model.ErrorInput"
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -179,9 +323,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Изменяет данные существующей подписки",
+            }
+        },
+        "/admin/users/{target_id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Для пользователя, заведенного дважды: в одной транзакции переносит все подписки source_user_id, включая удаленные, на target_id. При deduplicate=true действующие подписки источника с тем же сервисом и датой начала, что у действующей подписки цели, удаляются и считаются пропущенными. Закрепления источника и доступы, выданные источнику, переходят к цели, если у нее таких еще нет; лимиты остаются как были. У источника ничего не остается, поэтому повторный вызов ничего не меняет и возвращает нули. В журнал обоих пользователей пишется запись об объединении как действие администратора. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
                 "consumes": [
                     "application/json"
                 ],
@@ -189,47 +340,61 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Обновить подписку",
+                "summary": "Объединить пользователей",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "550e8400-e29b-41d4-a716-446655440000",
-                        "description": "ID подписки",
-                        "name": "id",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя, которому переходят подписки",
+                        "name": "target_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новые данные подписки",
+                        "type": "string",
+                        "example": "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d",
+                        "description": "ID тенанта пользователей",
+                        "name": "tenant_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Дубликат и режим объединения",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/service.UpdateSubscriptionRequest"
+                            "$ref": "#/definitions/model.MergeUsersRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Подписка успешно обновлена",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "$ref": "#/definitions/model.MergeUsersResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат данных",
+                        "description": "Неверный target_id или формат данных либо нет tenant_id",
                         "schema": {
-                            "$ref": "#/definitions/model.ErrorInput"
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Подписка не найдена",
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
                     },
+                    "422": {
+                        "description": "Не указан source_user_id или он совпадает с target_id",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -237,35 +402,59 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет подписку по ID",
+            }
+        },
+        "/admin/users/{user_id}/anonymizations": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Возвращает, на какие анонимные ID и когда переводились подписки пользователя, от старых к новым. Необратимые обезличивания здесь не видны. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Subscriptions"
+                    "Admin"
                 ],
-                "summary": "Удалить подписку",
+                "summary": "Анонимные ID пользователя",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "550e8400-e29b-41d4-a716-446655440000",
-                        "description": "ID подписки",
-                        "name": "id",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "Исходный ID пользователя",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d",
+                        "description": "ID тенанта пользователя",
+                        "name": "tenant_id",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Подписка успешно удалена"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Anonymization"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "Неверный ID подписки",
+                        "description": "Неверный user_id или нет tenant_id",
                         "schema": {
-                            "$ref": "#/definitions/model.ErrorInput"
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Подписка не найдена",
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponse"
                         }
@@ -278,92 +467,5281 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.ErrorInput": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "error": {
-                    "type": "string",
-                    "example": "invalid input"
-                }
-            }
         },
-        "model.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 404
-                },
-                "error": {
-                    "type": "string",
-                    "example": "invalid subscription ID"
+        "/admin/users/{user_id}/anonymize": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "То же, что POST /users/{user_id}/anonymize, для любого пользователя тенанта tenant_id. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Обезличить пользователя (администратор)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d",
+                        "description": "ID тенанта пользователя",
+                        "name": "tenant_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Не сохранять соответствие старого и нового ID",
+                        "name": "irreversible",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AnonymizeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id или irreversible либо нет tenant_id",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{user_id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "То же, что GET /users/{user_id}/export, для любого пользователя тенанта tenant_id. Требует заголовок Authorization: Bearer \u003cadmin-token\u003e. Выгрузка записывается в журнал пользователя как действие администратора",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Выгрузка данных пользователя администратором",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d",
+                        "description": "ID тенанта пользователя",
+                        "name": "tenant_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserDataExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id, нет tenant_id или неизвестный format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный admin-токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/services": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает сервисы каталога тенанта по алфавиту, с category - только сервисы этой категории",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Каталог сервисов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "streaming",
+                        "description": "Категория, без учета регистра",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.CatalogEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Добавляет сервис в каталог тенанта. Название нормализуется так же, как service_name подписки, и должно быть уникальным в каталоге",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Добавить сервис в каталог",
+                "parameters": [
+                    {
+                        "description": "Название, описание, категория, логотип и цена по умолчанию",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CatalogEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Сервис добавлен",
+                        "schema": {
+                            "$ref": "#/definitions/model.CatalogEntry"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL сервиса, /catalog/services/{id}"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Сервис с таким названием уже есть в каталоге",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/services/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Сервис каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CatalogEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сервиса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Заменяет все поля сервиса. Подписки, уже созданные из него, не меняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Изменить сервис каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Название, описание, категория, логотип и цена по умолчанию",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CatalogEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CatalogEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID сервиса или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Сервис с таким названием уже есть в каталоге",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Подписки, созданные из сервиса, остаются, но теряют ссылку на него",
+                "tags": [
+                    "Catalog"
+                ],
+                "summary": "Удалить сервис из каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сервис удален"
+                    },
+                    "400": {
+                        "description": "Неверный ID сервиса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/live": {
+            "get": {
+                "description": "Всегда возвращает 200, если процесс способен ответить. База данных не проверяется",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness-проба",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Проверяет соединение с базой данных (таймаут 1 секунда). Также доступна по /health",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness-проба",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает названия сервисов и количество подписок на каждый из них",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Список сервисов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ServiceSummary"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Количество сервисов"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает страницу подписок, подходящих под фильтр, в порядке даты начала. Без limit страница содержит 50 подписок. С envelope=true вместо массива возвращается model.SubscriptionListResponse: страница, общее количество совпадений (count), limit и offset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Список подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-03-01",
+                        "description": "Только подписки, активные в этот момент (RFC3339, YYYY-MM-DD или MM-YYYY); нельзя сочетать с from_date и to_date",
+                        "name": "active_on",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Скрыть подписки, у которых end_date уже прошла; бессрочные и еще не начавшиеся остаются",
+                        "name": "only_active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Обратный к only_active: false скрывает закончившиеся подписки",
+                        "name": "include_ended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включить подписки, к которым пользователю user_id открыт доступ",
+                        "name": "shared_with_me",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только подписки, закрепленные пользователем user_id (требует user_id)",
+                        "name": "pinned_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "netfl",
+                        "description": "Часть названия сервиса, без учета регистра; service_name по-прежнему ищет точное совпадение",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы; больше максимума (500 по умолчанию) уменьшается до него",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Сколько подписок пропустить",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Вернуть страницу в конверте model.SubscriptionListResponse вместе с общим количеством",
+                        "name": "envelope",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Subscription"
+                            }
+                        },
+                        "headers": {
+                            "X-Max-Page-Size-Applied": {
+                                "type": "string",
+                                "description": "true, если limit уменьшен до максимального размера страницы"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Общее количество подписок, подходящих под фильтр"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса, from_date позже to_date, нулевой user_id, active_on вместе с from_date или to_date, only_active противоречит include_ended, pinned_only без user_id, limit меньше 1 или отрицательный offset",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/batch-get": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает найденные подписки в порядке переданных ids и список ID, которых нет у тенанта. За один запрос можно передать не больше 200 ID, повторяющиеся ID учитываются один раз",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Получить подписки по списку ID",
+                "parameters": [
+                    {
+                        "description": "Список ID подписок",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BatchGetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchGetResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type должен быть application/json",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Пустой список ids или больше 200 ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/create-and-share": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Создает подписку и открывает доступ перечисленным пользователям в одной транзакции: если не удалось открыть хотя бы один доступ, подписка не создается",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shares"
+                ],
+                "summary": "Создать подписку и поделиться ей",
+                "parameters": [
+                    {
+                        "description": "Данные подписки и пользователи с уровнем доступа (read по умолчанию)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateAndShareRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Создать подписку, даже если у пользователя уже есть подписка на этот сервис с пересекающимся периодом",
+                        "name": "allow_duplicate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Подписка создана и доступы открыты",
+                        "schema": {
+                            "$ref": "#/definitions/model.SharedSubscription"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL созданной подписки, /subscriptions/{id}"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Период пересекается с активной подпиской на этот сервис или иной конфликт",
+                        "schema": {
+                            "$ref": "#/definitions/model.DuplicateErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type должен быть application/json",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей подписки или share_with, либо у пользователя уже предельное число подписок",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/expired": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает подписки, у которых end_date уже прошла, с количеством дней с момента окончания",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Истекшие подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ExpiredSubscription"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Количество истекших подписок"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса или from_date позже to_date",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/expired/cleanup": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Помечает удаленными все истекшие подписки пользователя и возвращает их количество",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Очистить истекшие подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CleanupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Не указан или неверный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/expiring-soon/by-service": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Для каждого сервиса возвращает количество подписок, у которых end_date наступит в ближайшие days дней, и самую раннюю дату окончания",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Скоро истекающие подписки по сервисам",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 7,
+                        "description": "Горизонт в днях (1-365)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ExpiringServiceSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное значение days или user_id",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/export": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает файл .ics с событием на весь день в дату окончания (продления) каждой подписки пользователя; подписки без end_date пропускаются. Файл можно импортировать или подписаться на него в приложении календаря",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Экспорт продлений в iCalendar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "ical"
+                        ],
+                        "type": "string",
+                        "default": "ical",
+                        "description": "Формат файла",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Календарь в формате iCalendar (RFC 5545)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Нет или некорректный user_id либо неизвестный format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/import": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Принимает CSV-файл с заголовком service_name,price,user_id,start_date,end_date (не больше 5 МБ). Корректные строки сохраняются; строки с ошибками, пересекающиеся с активной подпиской на тот же сервис или сверх лимита подписок пользователя возвращаются с номером строки (заголовок — строка 1)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Импорт подписок из CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV-файл с подписками",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Сохранять строки, пересекающиеся с активной подпиской пользователя на тот же сервис",
+                        "name": "allow_duplicate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Нет файла или некорректный CSV",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Конфликт с существующей записью",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл больше 5 МБ",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type должен быть multipart/form-data",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/project": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Ожидаемые расходы пользователя на каждый из следующих месяцев, начиная со следующего за текущим. Подписка учитывается в месяце, только если действует весь месяц: началась не позже первого дня и заканчивается не раньше последнего. Сумма месяца складывается из месячного эквивалента цены этих подписок",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Прогноз расходов по месяцам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "На сколько месяцев вперед (1-36)",
+                        "name": "months",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ProjectedCost"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Нет или неверный user_id, неверный months",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/rank": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Стоимость дня подписки с end_date - все ее списания до end_date, деленные на число дней между start_date и end_date; бессрочной - цена, деленная на среднюю длину периода оплаты (365 дней, деленные на число периодов в году). При равной стоимости подписки идут по названию сервиса. Подписки, стоимость дня которых не посчитать (короче дня), идут последними без cost_per_day и rank",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Рейтинг подписок по стоимости дня",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cost_per_day"
+                        ],
+                        "type": "string",
+                        "default": "cost_per_day",
+                        "description": "По чему ранжировать",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "asc - сначала самые выгодные, desc - самые дорогие",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RankedSubscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id, sort или order",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/simulate": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Сравнивает стоимость подписок пользователя в текущем календарном месяце, посчитанную так же, как /subscriptions/total с mode=prorated за этот месяц, со стоимостью после воображаемых изменений: подписок из add, как будто они созданы, и без подписок из exclude_ids, как будто они отменены. Ничего не сохраняется. Подписки из add проверяются так же, как при создании, user_id в них можно не указывать. В exclude_ids можно передать только подписки этого пользователя. За один запрос можно передать не больше 50 подписок в add и 50 ID в exclude_ids",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Расчет расходов \"что если\"",
+                "parameters": [
+                    {
+                        "description": "Пользователь и воображаемые изменения",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SimulateCostRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.CostSimulation"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Слишком большое тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type должен быть application/json",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Нет user_id, неверная подписка в add или чужая подписка в exclude_ids",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stats": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает количество подписок, минимальную, максимальную, среднюю и медианную цену среди подписок, подходящих под фильтр. Если подписок нет, все значения равны 0",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Статистика цен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PriceStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса или from_date позже to_date",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stream": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Server-Sent Events: каждое создание, изменение или удаление подписки приходит строкой \"data: {...}\". Изменение цены дополнительно приходит событием price_changed с полями old_price и new_price. Превышение лимита расходов приходит событием spending_limit_breached без id, пользователь указан в user_id",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Поток изменений подписок",
+                "responses": {
+                    "200": {
+                        "description": "Событие об изменении подписки",
+                        "schema": {
+                            "$ref": "#/definitions/model.SubscriptionEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    },
+                    "503": {
+                        "description": "Поток изменений не настроен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/summary/by-cycle": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Суммирует стоимость подписок отдельно для каждого периода оплаты (weekly, monthly, quarterly, annual) и пересчитывает каждую сумму в месячный эквивалент",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Расходы по периодам оплаты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BillingCycleSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса или from_date позже to_date",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/team-total": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Сумма подписок каждого из перечисленных пользователей за период, считается так же, как /subscriptions/total с mode=prorated. Пользователи без расходов возвращаются с нулем",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Расходы команды по пользователям",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите ID через запятую, до 100 участников",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода, по умолчанию текущий момент (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TeamTotalCost"
+                        }
+                    },
+                    "400": {
+                        "description": "Нет user_id, слишком много пользователей или некорректный период",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает общую стоимость подписок за период. По умолчанию (mode=prorated) цена подписки учитывается за каждый месяц, в котором она активна внутри периода; месяц считается целиком, даже если подписка активна в нем один день. Без to_date период заканчивается текущим моментом. mode=flat учитывает цену каждой подписки один раз. source=billing_history суммирует вместо цен платежи подходящих подписок, проведенные с from_date по to_date включительно. С параметром currency сумма дополнительно пересчитывается в указанную валюту",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Сумма подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-03-01",
+                        "description": "Только подписки, активные в этот момент (RFC3339, YYYY-MM-DD или MM-YYYY); нельзя сочетать с from_date и to_date",
+                        "name": "active_on",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Скрыть подписки, у которых end_date уже прошла; бессрочные и еще не начавшиеся остаются",
+                        "name": "only_active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Обратный к only_active: false скрывает закончившиеся подписки",
+                        "name": "include_ended",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "prorated",
+                            "flat"
+                        ],
+                        "type": "string",
+                        "default": "prorated",
+                        "description": "Способ подсчета цен; не сочетается с source=billing_history",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "prices",
+                            "billing_history"
+                        ],
+                        "type": "string",
+                        "default": "prices",
+                        "description": "Что суммировать: цены подписок или платежи из истории оплат, проведенные за период",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "USD",
+                        "description": "Валюта для пересчета (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TotalCostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса, from_date позже to_date, нулевой user_id, active_on вместе с from_date или to_date, only_active противоречит include_ended, слишком большой период, неизвестный mode или source, mode вместе с source=billing_history или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total/compare": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Считает расходы за периоды A и B так же, как /subscriptions/total с mode=prorated, и возвращает обе суммы, разницу B минус A, ее процент от суммы A (null, если за период A расходов не было) и до 5 сервисов, расходы на которые изменились сильнее всего в любую сторону. Границы периодов входят в них. Так как месяц учитывается целиком, периоды не должны пересекаться и не должны задевать один и тот же календарный месяц; длина каждого периода ограничена так же, как для /subscriptions/total",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Сравнение расходов за два периода",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода A (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "period_a_from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-03-31",
+                        "description": "Конец периода A (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "period_a_to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-04-01",
+                        "description": "Начало периода B (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "period_b_from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-06-30",
+                        "description": "Конец периода B (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "period_b_to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; без него считаются расходы всего тенанта",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PeriodComparison"
+                        }
+                    },
+                    "400": {
+                        "description": "Нет или неверная граница периода, период задан наоборот, слишком длинный период или периоды пересекаются",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total/monthly": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает по одной записи на каждый календарный месяц периода, включая месяцы без расходов. Сумма месяца складывается из месячного эквивалента цены всех подписок, активных в этом месяце хотя бы один день. Без to_date период заканчивается текущим месяцем; длина периода ограничена так же, как для /subscriptions/total",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Расходы по месяцам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex Plus",
+                        "description": "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "01-2025",
+                        "description": "Первый месяц ряда (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Последний месяц ряда (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MonthlyCost"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса, нет from_date, from_date позже to_date или слишком большой период",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/upcoming": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Подписки, следующее продление которых наступит в ближайшие days дней (сегодня включительно), по возрастанию даты продления. Продление наступает через каждый период оплаты от start_date в тот же день месяца; если в месяце нет такого дня, то в последний день месяца (подписка от 31 января продлевается 28 февраля и 31 марта). Подписка, которая заканчивается раньше продления или в его день, не продлевается",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Ближайшие продления",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 14,
+                        "description": "Горизонт в днях (1-365)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное значение days или user_id",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает информацию о конкретной подписке",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Получить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки, ее можно передать в If-Match при изменении"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Заменяет данные подписки целиком; если у тенанта нет подписки с таким ID, создает ее под этим ID, так что повтор того же запроса безопасен. Для существующей подписки нужна версия, с которой сделано изменение, в поле version или в заголовке If-Match; если подписку успели изменить, возвращается 409 с текущей версией",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Создать или обновить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "При создании не проверять пересечение с активной подпиской на этот сервис",
+                        "name": "allow_duplicate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3\"",
+                        "description": "ETag подписки, то есть ее версия в кавычках; заменяет поле version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Новые данные подписки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка успешно обновлена",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Подписки не было, она создана",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL созданной подписки, /subscriptions/{id}"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных или заголовок If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписку изменили после указанной версии (current_version), ID занят удаленной подпиской или другим тенантом, либо новая подписка пересекается с активной (model.DuplicateErrorResponse с existing_id)",
+                        "schema": {
+                            "$ref": "#/definitions/model.VersionConflictResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей, нет версии, ссылка на несуществующую запись или у пользователя уже предельное число подписок",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Удаляет подписку по ID. Запись остается с отметкой deleted_at, чтобы ее учитывал ежедневный отчет, но больше нигде не видна; повторное удаление возвращает 404",
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Удалить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка успешно удалена"
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка используется другими записями",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/anomaly": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Сравнивает текущую цену подписки со всеми ее прежними ценами: z_score показывает, на сколько стандартных отклонений текущая цена отличается от среднего. is_anomaly равно true, если |z_score| больше порога (anomaly.threshold, 2 по умолчанию). Если прежние цены не менялись, z_score равен null, а любая другая цена считается аномалией. Пока прежних цен меньше трех, is_anomaly всегда false",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Аномалия цены подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PriceAnomalyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/payments": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает платежи подписки, начиная с самого раннего",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "История оплат подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Payment"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Количество платежей"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Добавляет платеж в историю оплат подписки с текущей датой. Валюта по умолчанию - валюта цен, другие валюты не принимаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Записать платеж",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Сумма и валюта платежа",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.RecordPaymentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Платеж записан",
+                        "schema": {
+                            "$ref": "#/definitions/model.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pin": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Добавляет подписку в избранное пользователя user_id. Повторное закрепление не ошибка и сохраняет исходное время",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Pins"
+                ],
+                "summary": "Закрепить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка закреплена",
+                        "schema": {
+                            "$ref": "#/definitions/model.Pin"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки, не указан или неверный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "tags": [
+                    "Pins"
+                ],
+                "summary": "Открепить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка откреплена"
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки, не указан или неверный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не закреплена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/price": {
+            "patch": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Меняет цену, оставляя остальные поля как есть, поэтому не затирает параллельные изменения других полей. После изменения в /subscriptions/stream публикуется событие price_changed со старой и новой ценой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Изменить цену подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdatePriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Цена изменена",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Цена не положительная или не меньше максимальной",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/reminders": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает все напоминания подписки, начиная с самого раннего",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reminders"
+                ],
+                "summary": "Напоминания подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Reminder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Добавляет напоминание за remind_days_before дней до end_date подписки",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reminders"
+                ],
+                "summary": "Создать напоминание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "За сколько дней напомнить (0-365)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateReminderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Напоминание создано",
+                        "schema": {
+                            "$ref": "#/definitions/model.Reminder"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Такое напоминание уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/reminders/{reminder_id}": {
+            "put": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Меняет, за сколько дней до end_date придет напоминание. Отметка о последней отправке сбрасывается",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reminders"
+                ],
+                "summary": "Изменить напоминание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8",
+                        "description": "ID напоминания",
+                        "name": "reminder_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "За сколько дней напомнить (0-365)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.UpdateReminderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Reminder"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Напоминание не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Такое напоминание уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "tags": [
+                    "Reminders"
+                ],
+                "summary": "Удалить напоминание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8",
+                        "description": "ID напоминания",
+                        "name": "reminder_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Напоминание удалено"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Напоминание не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/shares": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает пользователей, с которыми поделились подпиской",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shares"
+                ],
+                "summary": "Список доступов к подписке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ShareEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID подписки",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Открывает пользователю доступ к подписке (например, для семейного тарифа)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shares"
+                ],
+                "summary": "Поделиться подпиской",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пользователь и уровень доступа (read или write)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ShareSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Доступ открыт",
+                        "schema": {
+                            "$ref": "#/definitions/model.ShareEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/shares/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Удаляет доступ пользователя к подписке",
+                "tags": [
+                    "Shares"
+                ],
+                "summary": "Закрыть доступ к подписке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "550e8400-e29b-41d4-a716-446655440000",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Доступ закрыт"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Доступ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/anonymize": {
+            "post": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "В одной транзакции переводит все подписки пользователя, включая удаленные, на новый случайный ID и очищает их metadata; напоминания этих подписок, закрепления, доступы к чужим подпискам, лимиты и оповещения о расходах пользователя удаляются, журнал переходит на новый ID, туда же пишется запись об обезличивании. Суммы расходов сохраняются, а запросы по старому user_id возвращают пустые результаты. Соответствие старого и нового ID доступно только администраторам, а при irreversible=true не сохраняется вовсе. Доступно только самому пользователю: ID из заголовка X-User-ID (или claim sub JWT) должен совпадать с user_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Обезличить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID вызывающего пользователя, если тенант передается заголовком",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Не сохранять соответствие старого и нового ID",
+                        "name": "irreversible",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AnonymizeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id или irreversible",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант либо не указан вызывающий пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Обезличивание другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Возвращает все подписки пользователя, историю их цен и журнал доступа к его данным одним JSON-документом или, при format=zip, архивом с файлами subscriptions.csv, price_history.csv и audit_log.csv. Доступно только самому пользователю: ID из заголовка X-User-ID (или claim sub JWT) должен совпадать с user_id. Данные передаются по мере чтения из базы, поэтому прерванная выгрузка обрывается на середине файла. Каждая выгрузка записывается в журнал после завершения со статусом completed или failed",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Выгрузка данных пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID вызывающего пользователя, если тенант передается заголовком",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserDataExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id или неизвестный format",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант либо не указан вызывающий пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Выгрузка чужих данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/spending-limit": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Лимит расходов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SpendingLimit"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Лимит не задан",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Создает или заменяет лимит расходов пользователя на подписки. Валюта по умолчанию - валюта цен, порог предупреждения по умолчанию - 80%",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Задать лимит расходов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Лимит, валюта и порог предупреждения в процентах (1-100)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SetSpendingLimitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Лимит изменен",
+                        "schema": {
+                            "$ref": "#/definitions/model.SpendingLimit"
+                        }
+                    },
+                    "201": {
+                        "description": "Лимита не было, он создан",
+                        "schema": {
+                            "$ref": "#/definitions/model.SpendingLimit"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL лимита, /users/{user_id}/spending-limit"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя или формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Удалить лимит расходов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Лимит удален"
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Лимит не задан",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/spending-limit/status": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Суммирует цены подписок пользователя, активных сегодня, в валюте лимита. alert становится true, когда used_pct достигает порога предупреждения. Только читает: превышение лимита проверяется при каждой записи подписок пользователя и при установке лимита, и при первом превышении в поток изменений публикуется событие spending_limit_breached",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Состояние лимита расходов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SpendingLimitStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя или валюта лимита больше не поддерживается",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Лимит не задан",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions/forecast": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Расходы пользователя по месяцам, начиная с текущего, если ничего не изменится. Месяц считается так же, как в /subscriptions/total/monthly: месячный эквивалент цены каждой подписки, активной в этом месяце. Подписка с end_date перестает учитываться после месяца окончания",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Прогноз расходов пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 12,
+                        "description": "На сколько месяцев вперед (1-36)",
+                        "name": "months",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя или months",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions/top": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Сервисы пользователя по убыванию расходов за период (с учетом периода оплаты и числа оплаченных месяцев) и доля каждого в процентах. Все остальные сервисы суммируются в other, так что проценты в сумме дают 100",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Самые дорогие сервисы пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Сколько сервисов вернуть (1-50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "from_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "12-2025",
+                        "description": "Конец периода, по умолчанию текущий месяц (RFC3339, YYYY-MM-DD или MM-YYYY)",
+                        "name": "to_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TopServices"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя, limit или период",
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/summary": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Количество активных и истекших подписок, месячная стоимость активных подписок, самый дорогой сервис и ближайшая дата окончания. Для пользователя без подписок возвращаются нули",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Сводка по подпискам пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorInput"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "security": [
+                    {
+                        "Tenant": []
+                    }
+                ],
+                "description": "Те же события, что и /subscriptions/stream, но через WebSocket: каждое событие приходит текстовым фреймом с JSON. Чтобы получать события одного пользователя, отправьте {\"user_id\":\"\u003cuuid\u003e\"}, чтобы снова получать все - {\"user_id\":null}; на другое сообщение придет {\"error\":\"...\"}. Сервер отправляет ping каждые 54 секунды и закрывает соединение, если за 60 секунд не пришло ни одного фрейма; при остановке сервера соединение закрывается с кодом 1001",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Изменения подписок через WebSocket",
+                "responses": {
+                    "101": {
+                        "description": "Соединение переключено на WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/model.SubscriptionEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Запрос не является WebSocket handshake",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет или неверный тенант",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Origin не совпадает с Host",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ServerError"
+                        }
+                    },
+                    "503": {
+                        "description": "Поток изменений не настроен или сервер останавливается",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "importer.ImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "start_date: invalid date \"soon\", use RFC3339, YYYY-MM-DD or MM-YYYY"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "importer.Result": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.ImportError"
+                    }
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 45
+                }
+            }
+        },
+        "model.Anonymization": {
+            "type": "object",
+            "properties": {
+                "anonymized_at": {
+                    "type": "string",
+                    "example": "2025-08-12T10:30:00Z"
+                },
+                "anonymous_id": {
+                    "type": "string",
+                    "example": "d4c3b2a1-9f8e-4d7c-b6a5-0f1e2d3c4b5a"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.AnonymizeResponse": {
+            "type": "object",
+            "properties": {
+                "irreversible": {
+                    "description": "Irreversible says no mapping to the anonymous ID was kept.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "subscriptions": {
+                    "description": "Subscriptions is how many live subscriptions were detached.",
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "model.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "user_data_export"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-08-12T10:30:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 17
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "c0ffee00-1234-4abc-9def-0123456789ab"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "completed"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.BatchGetResult": {
+            "type": "object",
+            "properties": {
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Subscription"
+                    }
+                }
+            }
+        },
+        "model.BillingCycle": {
+            "type": "string",
+            "enum": [
+                "weekly",
+                "monthly",
+                "quarterly",
+                "annual",
+                "monthly"
+            ],
+            "x-enum-varnames": [
+                "CycleWeekly",
+                "CycleMonthly",
+                "CycleQuarterly",
+                "CycleAnnual",
+                "DefaultBillingCycle"
+            ]
+        },
+        "model.BillingCycleSummary": {
+            "type": "object",
+            "properties": {
+                "billing_cycle": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.BillingCycle"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "monthly_equivalent": {
+                    "type": "number",
+                    "example": 1200
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "model.BulkUpdatePriceResponse": {
+            "type": "object",
+            "properties": {
+                "updated": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "model.CatalogEntry": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "default_price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Музыка, фильмы и кешбэк баллами"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"
+                },
+                "logo_url": {
+                    "type": "string",
+                    "example": "https://example.com/logos/yandex-plus.png"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "yandex plus"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                }
+            }
+        },
+        "model.CleanupResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.CostSimulation": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "current_total": {
+                    "type": "integer",
+                    "example": 1798
+                },
+                "difference": {
+                    "type": "integer",
+                    "example": -400
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "simulated_total": {
+                    "type": "integer",
+                    "example": 1398
+                }
+            }
+        },
+        "model.CreationRate": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 310
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "per_day": {
+                    "type": "number",
+                    "example": 10
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-02-01T00:00:00Z"
+                }
+            }
+        },
+        "model.DuplicateErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 409
+                },
+                "error": {
+                    "type": "string",
+                    "example": "subscription overlaps an existing one"
+                },
+                "existing_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "model.ErrorInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "error": {
+                    "type": "string",
+                    "example": "invalid input"
+                }
+            }
+        },
+        "model.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "error": {
+                    "type": "string",
+                    "example": "invalid subscription ID"
+                }
+            }
+        },
+        "model.ExpiredSubscription": {
+            "type": "object",
+            "properties": {
+                "billing_cycle": {
+                    "description": "BillingCycle is how often Price is charged.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.BillingCycle"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "catalog_service_id": {
+                    "description": "CatalogServiceID is the catalog entry the subscription was created\nfrom, if any.",
+                    "type": "string",
+                    "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "expired_for_days": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "metadata": {
+                    "description": "Metadata is arbitrary client data stored as given, e.g. an invoice\nnumber or the card used.",
+                    "type": "object"
+                },
+                "next_renewal_date": {
+                    "description": "NextRenewalDate is only filled in by the upcoming renewals listing.",
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "pinned": {
+                    "description": "Pinned is only filled in by the listing filtered by user_id and says\nwhether that user pinned the subscription.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "yandex plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "version": {
+                    "description": "Version starts at 1 and goes up with every update. An update must\nname the version it was made from, see ErrVersionConflict.",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.ExpiringServiceSummary": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "earliest_expiry": {
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "netflix"
+                }
+            }
+        },
+        "model.Forecast": {
+            "type": "object",
+            "properties": {
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MonthlyCost"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 18000
+                }
+            }
+        },
+        "model.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "model.MergeUsersRequest": {
+            "type": "object",
+            "properties": {
+                "deduplicate": {
+                    "description": "Deduplicate drops the source's live subscriptions that the target\nalready has, same service and start date, instead of keeping both.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "source_user_id": {
+                    "description": "SourceUserID is the duplicate account whose subscriptions move.",
+                    "type": "string",
+                    "example": "7a1d4c2e-5b3f-4e8a-9c6d-1f2e3a4b5c6d"
+                }
+            }
+        },
+        "model.MergeUsersResponse": {
+            "type": "object",
+            "properties": {
+                "moved": {
+                    "description": "Moved is how many now belong to the target.",
+                    "type": "integer",
+                    "example": 3
+                },
+                "skipped": {
+                    "description": "Skipped is how many duplicated one of the target's and were deleted.",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.MonthlyCost": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1500
+                }
+            }
+        },
+        "model.OtherServicesCost": {
+            "type": "object",
+            "properties": {
+                "percent": {
+                    "type": "number",
+                    "example": 12.48
+                },
+                "service_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1797
+                }
+            }
+        },
+        "model.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "billed_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9b2d7c4e-1f3a-4b5c-8d6e-7f8091a2b3c4"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.PaymentStatus"
+                        }
+                    ],
+                    "example": "paid"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "model.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "paid"
+            ],
+            "x-enum-varnames": [
+                "PaymentPaid"
+            ]
+        },
+        "model.PeriodComparison": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "delta": {
+                    "type": "integer",
+                    "example": 1800
+                },
+                "percent_change": {
+                    "type": "number",
+                    "example": 12.5
+                },
+                "period_a": {
+                    "$ref": "#/definitions/model.PeriodTotal"
+                },
+                "period_b": {
+                    "$ref": "#/definitions/model.PeriodTotal"
+                },
+                "top_movers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ServiceCostChange"
+                    }
+                }
+            }
+        },
+        "model.PeriodTotal": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-03-31T00:00:00Z"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 14400
+                }
+            }
+        },
+        "model.Pin": {
+            "type": "object",
+            "properties": {
+                "pinned_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.PriceAnomalyReport": {
+            "type": "object",
+            "properties": {
+                "current_price": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "history_size": {
+                    "description": "HistorySize is the number of earlier prices compared against.",
+                    "type": "integer",
+                    "example": 4
+                },
+                "is_anomaly": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mean": {
+                    "type": "number",
+                    "example": 700
+                },
+                "std_dev": {
+                    "type": "number",
+                    "example": 50
+                },
+                "z_score": {
+                    "type": "number",
+                    "example": 10
+                }
+            }
+        },
+        "model.PriceChange": {
+            "type": "object",
+            "properties": {
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "recorded_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "model.PriceStats": {
+            "type": "object",
+            "properties": {
+                "avg_price": {
+                    "type": "number",
+                    "example": 574.5
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "max_price": {
+                    "type": "integer",
+                    "example": 1299
+                },
+                "median_price": {
+                    "type": "number",
+                    "example": 499
+                },
+                "min_price": {
+                    "type": "integer",
+                    "example": 199
+                }
+            }
+        },
+        "model.ProjectedCost": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string",
+                    "example": "2025-08"
+                },
+                "projected_cost": {
+                    "type": "number",
+                    "example": 1500
+                }
+            }
+        },
+        "model.RankedSubscription": {
+            "type": "object",
+            "properties": {
+                "billing_cycle": {
+                    "description": "BillingCycle is how often Price is charged.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.BillingCycle"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "catalog_service_id": {
+                    "description": "CatalogServiceID is the catalog entry the subscription was created\nfrom, if any.",
+                    "type": "string",
+                    "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"
+                },
+                "cost_per_day": {
+                    "type": "number",
+                    "example": 19.32
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "metadata": {
+                    "description": "Metadata is arbitrary client data stored as given, e.g. an invoice\nnumber or the card used.",
+                    "type": "object"
+                },
+                "next_renewal_date": {
+                    "description": "NextRenewalDate is only filled in by the upcoming renewals listing.",
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "pinned": {
+                    "description": "Pinned is only filled in by the listing filtered by user_id and says\nwhether that user pinned the subscription.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "rank": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "yandex plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "version": {
+                    "description": "Version starts at 1 and goes up with every update. An update must\nname the version it was made from, see ErrVersionConflict.",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.Reminder": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8"
+                },
+                "last_reminded_at": {
+                    "type": "string",
+                    "example": "2025-09-09T09:00:00Z"
+                },
+                "remind_days_before": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "model.ServerError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "internal server error"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c1e-5d4a-4b6f-9e7d-2a1c0b9f8e7d"
+                }
+            }
+        },
+        "model.ServiceCost": {
+            "type": "object",
+            "properties": {
+                "percent": {
+                    "type": "number",
+                    "example": 41.63
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "netflix"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 5994
+                }
+            }
+        },
+        "model.ServiceCostChange": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "netflix"
+                },
+                "total_a": {
+                    "type": "integer",
+                    "example": 2997
+                },
+                "total_b": {
+                    "type": "integer",
+                    "example": 3597
+                }
+            }
+        },
+        "model.ServicePriceUpdateResult": {
+            "type": "object",
+            "properties": {
+                "total_after": {
+                    "type": "integer",
+                    "example": 50358
+                },
+                "total_before": {
+                    "type": "integer",
+                    "example": 41958
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "model.ServiceSummary": {
+            "type": "object",
+            "properties": {
+                "service_name": {
+                    "type": "string",
+                    "example": "netflix"
+                },
+                "subscription_count": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.ShareEntry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "permission": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.SharePermission"
+                        }
+                    ],
+                    "example": "read"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b"
+                }
+            }
+        },
+        "model.SharePermission": {
+            "type": "string",
+            "enum": [
+                "read",
+                "write"
+            ],
+            "x-enum-varnames": [
+                "PermissionRead",
+                "PermissionWrite"
+            ]
+        },
+        "model.SharedSubscription": {
+            "type": "object",
+            "properties": {
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ShareEntry"
+                    }
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.Subscription"
+                }
+            }
+        },
+        "model.SpendingLimit": {
+            "type": "object",
+            "properties": {
+                "alert_threshold_pct": {
+                    "type": "integer",
+                    "example": 80
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "limit_amount": {
+                    "type": "integer",
+                    "example": 2000
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.SpendingLimitStatus": {
+            "type": "object",
+            "properties": {
+                "alert": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "current_total": {
+                    "type": "number",
+                    "example": 1750
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 2000
+                },
+                "over_limit": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "used_pct": {
+                    "type": "number",
+                    "example": 87.5
+                }
+            }
+        },
+        "model.Subscription": {
+            "type": "object",
+            "properties": {
+                "billing_cycle": {
+                    "description": "BillingCycle is how often Price is charged.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.BillingCycle"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "catalog_service_id": {
+                    "description": "CatalogServiceID is the catalog entry the subscription was created\nfrom, if any.",
+                    "type": "string",
+                    "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "metadata": {
+                    "description": "Metadata is arbitrary client data stored as given, e.g. an invoice\nnumber or the card used.",
+                    "type": "object"
+                },
+                "next_renewal_date": {
+                    "description": "NextRenewalDate is only filled in by the upcoming renewals listing.",
+                    "type": "string",
+                    "example": "2025-09-12T00:00:00Z"
+                },
+                "pinned": {
+                    "description": "Pinned is only filled in by the listing filtered by user_id and says\nwhether that user pinned the subscription.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "yandex plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-08-12T00:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "version": {
+                    "description": "Version starts at 1 and goes up with every update. An update must\nname the version it was made from, see ErrVersionConflict.",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "model.ServerError": {
+        "model.SubscriptionEvent": {
             "type": "object",
             "properties": {
-                "code": {
+                "event": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.SubscriptionEventType"
+                        }
+                    ],
+                    "example": "created"
+                },
+                "id": {
+                    "description": "ID is the subscription's; events about a user rather than one of\ntheir subscriptions leave it out.",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "new_price": {
+                    "type": "integer",
+                    "example": 799
+                },
+                "old_price": {
+                    "description": "OldPrice and NewPrice are set on EventPriceChanged only.",
+                    "type": "integer",
+                    "example": 599
+                },
+                "user_id": {
+                    "description": "UserID is the user the subscription or spending limit belongs to.\nEvents published before it was added lack it.",
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.SubscriptionEventType": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "deleted",
+                "price_changed",
+                "spending_limit_breached",
+                "renewed"
+            ],
+            "x-enum-varnames": [
+                "EventCreated",
+                "EventUpdated",
+                "EventDeleted",
+                "EventPriceChanged",
+                "EventSpendingLimitBreached",
+                "EventRenewed"
+            ]
+        },
+        "model.TeamTotalCost": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer",
+                    "example": 3000
+                },
+                "totals": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "model.TopServices": {
+            "type": "object",
+            "properties": {
+                "other": {
+                    "$ref": "#/definitions/model.OtherServicesCost"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ServiceCost"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 14400
+                }
+            }
+        },
+        "model.TotalCostResponse": {
+            "type": "object",
+            "properties": {
+                "converted_total": {
+                    "type": "number",
+                    "example": 18.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.TotalMode"
+                        }
+                    ],
+                    "example": "prorated"
+                },
+                "source": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.TotalSource"
+                        }
+                    ],
+                    "example": "prices"
+                },
+                "target_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1500
+                }
+            }
+        },
+        "model.TotalMode": {
+            "type": "string",
+            "enum": [
+                "prorated",
+                "flat"
+            ],
+            "x-enum-varnames": [
+                "TotalProrated",
+                "TotalFlat"
+            ]
+        },
+        "model.TotalSource": {
+            "type": "string",
+            "enum": [
+                "prices",
+                "billing_history"
+            ],
+            "x-enum-varnames": [
+                "TotalFromPrices",
+                "TotalFromBillingHistory"
+            ]
+        },
+        "model.UserCost": {
+            "type": "object",
+            "properties": {
+                "subscription_count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 14376
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.UserDataExport": {
+            "type": "object",
+            "properties": {
+                "audit_log": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditEntry"
+                    }
+                },
+                "exported_at": {
+                    "type": "string",
+                    "example": "2025-08-12T10:30:00Z"
+                },
+                "price_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PriceChange"
+                    }
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Subscription"
+                    }
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.UserSummary": {
+            "type": "object",
+            "properties": {
+                "active_count": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "expired_count": {
                     "type": "integer",
-                    "example": 500
+                    "example": 2
+                },
+                "most_expensive_service": {
+                    "type": "string",
+                    "example": "netflix"
+                },
+                "next_expiry": {
+                    "type": "string",
+                    "example": "2025-09-01T00:00:00Z"
                 },
+                "total_monthly_cost": {
+                    "description": "TotalMonthlyCost is the monthly equivalent of all active\nsubscriptions, whatever their billing cycle.",
+                    "type": "number",
+                    "example": 1500
+                }
+            }
+        },
+        "model.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
                 "error": {
                     "type": "string",
-                    "example": "server does not respond"
+                    "example": "validation failed"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "model.Subscription": {
+        "model.VersionConflictResponse": {
             "type": "object",
             "properties": {
-                "end_date": {
+                "current_version": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "error": {
                     "type": "string",
-                    "example": "2025-09-12T00:00:00Z"
+                    "example": "subscription was changed meanwhile, fetch it again and retry"
+                }
+            }
+        },
+        "service.BatchGetRequest": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.BulkUpdatePriceRequest": {
+            "type": "object",
+            "properties": {
+                "new_price": {
+                    "type": "integer",
+                    "example": 1199
+                }
+            }
+        },
+        "service.CatalogEntryRequest": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "default_price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Музыка, фильмы и кешбэк баллами"
+                },
+                "logo_url": {
+                    "type": "string",
+                    "example": "https://example.com/logos/yandex-plus.png"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "yandex plus"
+                }
+            }
+        },
+        "service.CreateAndShareRequest": {
+            "type": "object",
+            "properties": {
+                "share_with": {
+                    "description": "ShareWith lists the users to share with; permission defaults to read.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ShareSubscriptionRequest"
+                    }
+                },
+                "subscription": {
+                    "$ref": "#/definitions/service.CreateSubscriptionRequest"
+                }
+            }
+        },
+        "service.CreateReminderRequest": {
+            "type": "object",
+            "properties": {
+                "remind_days_before": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "service.CreateSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "billing_cycle": {
+                    "description": "BillingCycle defaults to monthly when empty.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.BillingCycle"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "catalog_service_id": {
+                    "description": "CatalogServiceID creates the subscription from a catalog entry:\nservice_name and price default to the entry's name and default\nprice when they are missing.",
+                    "type": "string",
+                    "example": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"
+                },
+                "end_date": {
+                    "description": "EndDate, when missing, leaves the subscription open-ended unless the\nserver is configured with a default duration.",
+                    "type": "string"
                 },
                 "id": {
+                    "description": "ID lets a client choose the subscription's ID; one is generated when\nit is missing. An ID that is already taken is a conflict.",
                     "type": "string",
                     "example": "550e8400-e29b-41d4-a716-446655440000"
                 },
+                "metadata": {
+                    "description": "Metadata is any JSON value; null or a missing field stores none.",
+                    "type": "object"
+                },
                 "price": {
+                    "type": "integer"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.RecordPaymentRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
                     "type": "integer",
                     "example": 599
                 },
-                "service_name": {
+                "currency": {
+                    "description": "Currency defaults to the one prices are stored in, which is also\nthe only one accepted: totals add payments up without converting.",
                     "type": "string",
-                    "example": "Yandex Plus"
+                    "example": "RUB"
+                }
+            }
+        },
+        "service.SetSpendingLimitRequest": {
+            "type": "object",
+            "properties": {
+                "alert_threshold_pct": {
+                    "description": "AlertThresholdPct defaults to 80.",
+                    "type": "integer",
+                    "example": 80
                 },
-                "start_date": {
+                "currency": {
+                    "description": "Currency defaults to the one prices are stored in.",
                     "type": "string",
-                    "example": "2025-08-12T00:00:00Z"
+                    "example": "RUB"
+                },
+                "limit_amount": {
+                    "type": "integer",
+                    "example": 2000
+                }
+            }
+        },
+        "service.ShareSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "permission": {
+                    "$ref": "#/definitions/model.SharePermission"
                 },
                 "user_id": {
-                    "type": "string",
-                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                    "type": "string"
                 }
             }
         },
-        "model.TotalCostResponse": {
+        "service.SimulateCostRequest": {
             "type": "object",
             "properties": {
-                "total": {
+                "add": {
+                    "description": "Add lists subscriptions to pretend were created, checked like\nCreateSubscription checks them; user_id defaults to UserID.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.CreateSubscriptionRequest"
+                    }
+                },
+                "exclude_ids": {
+                    "description": "ExcludeIDs lists subscriptions of UserID to pretend were cancelled.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.UpdatePriceRequest": {
+            "type": "object",
+            "properties": {
+                "price": {
                     "type": "integer",
-                    "example": 1500
+                    "example": 799
+                }
+            }
+        },
+        "service.UpdateReminderRequest": {
+            "type": "object",
+            "properties": {
+                "remind_days_before": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "service.UpdateServicePriceRequest": {
+            "type": "object",
+            "properties": {
+                "active_only": {
+                    "description": "ActiveOnly leaves alone the subscriptions not active today.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "effective_date": {
+                    "description": "EffectiveDate is when the new price applies from in the price\nhistory; it defaults to now and may not be in the future.",
+                    "type": "string",
+                    "example": "2025-09-01T00:00:00Z"
+                },
+                "new_price": {
+                    "type": "integer",
+                    "example": 1199
+                },
+                "percent_change": {
+                    "type": "number",
+                    "example": 10
                 }
             }
         },
         "service.UpdateSubscriptionRequest": {
             "type": "object",
             "properties": {
+                "billing_cycle": {
+                    "description": "BillingCycle defaults to monthly when empty.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.BillingCycle"
+                        }
+                    ],
+                    "example": "monthly"
+                },
                 "end_date": {
                     "type": "string"
                 },
+                "metadata": {
+                    "description": "Metadata is any JSON value; null or a missing field stores none.",
+                    "type": "object"
+                },
                 "price": {
                     "type": "integer"
                 },
@@ -375,9 +5753,28 @@ const docTemplate = `{
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "Version is the version of the subscription the update was made\nfrom. It is required unless UpsertSubscription creates the\nsubscription; an older one fails with a *model.VersionConflictError.",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Bearer \u003cadmin-token\u003e из admin.token в конфиге",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "Tenant": {
+            "description": "UUID тенанта. Если задан tenant.jwt_secret, тенант берется из claim tenant.jwt_claim токена Authorization: Bearer \u003cJWT\u003e",
+            "type": "apiKey",
+            "name": "X-Tenant-ID",
+            "in": "header"
+        }
     }
 }`
 
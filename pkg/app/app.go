@@ -0,0 +1,159 @@
+// Package app wires the HTTP API: the repositories, services and handlers
+// and the middleware chain in front of them. cmd/main.go serves it and
+// cmd/smoketest drives it, so both run the same router.
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	_ "SubscriptionAggregator/docs"
+	"SubscriptionAggregator/pkg/circuitbreaker"
+	"SubscriptionAggregator/pkg/config"
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/handler"
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/metrics"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/repository"
+	"SubscriptionAggregator/pkg/service"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+const (
+	swaggerRoute = "/swagger/"
+	metricsRoute = "/metrics"
+)
+
+// App is the wired API. What it starts in the background has to be
+// stopped by the caller once the server is shut down.
+type App struct {
+	// Router serves every route behind the full middleware chain.
+	Router http.Handler
+	// WebSocket holds the hijacked connections, which
+	// http.Server.Shutdown leaves open; shut it down separately.
+	WebSocket *handler.WebSocketHandler
+	// CostAlerts calls webhooks in the background; wait for it before
+	// closing db.
+	CostAlerts *service.WebhookCostAlertChecker
+}
+
+// New wires the API to db, which must be migrated already. changes feeds
+// the change streams and rates the currency conversions.
+func New(cfg *config.Config, db *sql.DB, changes service.ChangeNotifier, rates currency.ExchangeRateProvider, log *slog.Logger) (*App, error) {
+	// The breaker makes requests fail fast while the database is down
+	// instead of piling up behind the exhausted connection pool. Calls it
+	// turns away never reach the database and are not timed.
+	breaker := circuitbreaker.New(cfg.DB.BreakerThreshold, cfg.DB.BreakerCooldown,
+		circuitbreaker.WithOnStateChange(func(from, to circuitbreaker.State) {
+			log.Warn("database circuit breaker changed state",
+				slog.String("from", from.String()), slog.String("to", to.String()))
+		}))
+	repo := repository.NewCircuitBreakerRepository(
+		repository.NewLoggingRepository(
+			repository.NewSubscriptionRepository(db, repository.WithQueryTimeout(cfg.DB.QueryTimeout)),
+			cfg.DB.SlowQueryThreshold,
+		),
+		breaker,
+	)
+
+	router := mux.NewRouter()
+	router.Use(
+		middleware.RequestIDMiddleware(),
+		middleware.LoggingMiddleware(log),
+		middleware.RecoveryMiddleware(log),
+		// Probes must answer however busy the server is, and a stream holds
+		// its connection for as long as the client listens.
+		middleware.ConcurrencyMiddleware(cfg.MaxConcurrentRequests, cfg.ConcurrencyWait,
+			handler.StreamRoute, handler.WebSocketRoute, "/live", "/ready", "/health", metricsRoute),
+		// Outside the timeout, so the buffered response is compressed as a
+		// whole; the streams are read as they are written.
+		middleware.CompressionMiddleware(handler.StreamRoute, handler.WebSocketRoute),
+		// The streams are open for as long as the client listens, whatever it
+		// sends in Accept.
+		middleware.TimeoutMiddleware(cfg.RequestTimeout, handler.ExportRoute, handler.UserExportRoute, handler.AdminUserExportRoute,
+			handler.StreamRoute, handler.WebSocketRoute),
+		// Before the body limit, which then counts inflated bytes.
+		middleware.DecompressionMiddleware(),
+		middleware.BodyLimitMiddleware(cfg.MaxBodyBytes, map[string]int64{
+			handler.ImportRoute: cfg.MaxImportBodyBytes,
+		}),
+		// Uploads are multipart, the streams are text/event-stream and
+		// WebSocket frames, the exports are text/calendar or zip archives and
+		// the Swagger UI serves HTML and /metrics the Prometheus text format.
+		middleware.ContentTypeMiddleware(handler.ImportRoute, handler.StreamRoute, handler.WebSocketRoute,
+			handler.ExportRoute, handler.UserExportRoute, handler.AdminUserExportRoute, swaggerRoute, metricsRoute),
+		// The same routes write their own media types; uploads answer with a
+		// JSON or CSV result like any other route.
+		middleware.NegotiateMiddleware(handler.StreamRoute, handler.WebSocketRoute,
+			handler.ExportRoute, handler.UserExportRoute, handler.AdminUserExportRoute, swaggerRoute, metricsRoute),
+		// Probes, metrics, docs and the token-protected admin API are not tenant data.
+		middleware.TenantMiddleware(middleware.TenantSource{
+			Header:       cfg.Tenant.Header,
+			UserHeader:   cfg.Tenant.UserHeader,
+			JWTSecret:    []byte(cfg.Tenant.JWTSecret),
+			JWTClaim:     cfg.Tenant.JWTClaim,
+			JWTUserClaim: cfg.Tenant.JWTUserClaim,
+		}, swaggerRoute, "/admin/", "/live", "/ready", "/health", metricsRoute),
+	)
+	router.PathPrefix(swaggerRoute).Handler(httpSwagger.WrapHandler)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	telemetry, err := metrics.NewPrometheus(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register metrics: %w", err)
+	}
+	router.Handle(metricsRoute, metrics.Handler(registry)).Methods(http.MethodGet)
+
+	converter := currency.NewConverter(cfg.Currency.Base, rates)
+
+	catalogRepo := repository.NewCatalogRepository(db)
+	costAlerts := service.NewCostAlertChecker(repo, repository.NewCostAlertRepository(db), log)
+	limitSvc := service.NewSpendingLimitService(repository.NewSpendingLimitRepository(db), repo, converter)
+	svc := service.NewSubscriptionService(repo, log,
+		service.WithLoggerFactory(logger.FromContext),
+		service.WithChangeNotifier(changes),
+		service.WithCatalog(catalogRepo),
+		service.WithConverter(converter),
+		service.WithMaxPrice(cfg.Limits.MaxPrice),
+		service.WithMaxTotalRange(cfg.Limits.MaxTotalRangeYears),
+		service.WithUserLimits(cfg.Limits.DefaultMaxSubscriptionsPerUser),
+		service.WithAnomalyThreshold(cfg.Anomaly.Threshold),
+		service.WithDefaultDuration(cfg.DefaultSubscriptionDurationDays),
+		service.WithCostAlertChecker(costAlerts),
+		service.WithBreachChecker(limitSvc),
+		service.WithMetrics(telemetry),
+	)
+
+	handler.NewSubscriptionHandler(svc, cfg.MaxPageSize, log).RegisterRoutes(router)
+	handler.NewReminderHandler(service.NewReminderService(repository.NewReminderRepository(db)), log).RegisterRoutes(router)
+	handler.NewSpendingLimitHandler(limitSvc, log).RegisterRoutes(router)
+	handler.NewCatalogHandler(service.NewCatalogService(catalogRepo), log).RegisterRoutes(router)
+	ws := handler.NewWebSocketHandler(svc, log)
+	ws.RegisterRoutes(router)
+	handler.NewHealthHandler(db, log).RegisterRoutes(router)
+	if cfg.Admin.Token == "" {
+		log.Warn("admin.token is not set, /admin endpoints will reject every request")
+	}
+	handler.NewUserExportHandler(
+		service.NewUserExportService(repo, repository.NewAuditRepository(db)), cfg.Admin.Token, log,
+	).RegisterRoutes(router)
+	handler.NewAnonymizeHandler(
+		service.NewAnonymizeService(repository.NewAnonymizationRepository(db)), cfg.Admin.Token, log,
+	).RegisterRoutes(router)
+	handler.NewUserMergeHandler(
+		service.NewUserMergeService(repository.NewUserMergeRepository(db)), cfg.Admin.Token, log,
+	).RegisterRoutes(router)
+	handler.NewAdminHandler(svc, cfg.Admin.Token, log).RegisterRoutes(router)
+	handler.RegisterFallbacks(router, log)
+
+	return &App{Router: router, WebSocket: ws, CostAlerts: costAlerts}, nil
+}
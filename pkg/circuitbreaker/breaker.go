@@ -0,0 +1,142 @@
+// Package circuitbreaker stops calling a dependency that keeps failing, so
+// that callers get an error at once instead of queueing up behind it.
+package circuitbreaker
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling through while the circuit
+// is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type State int32
+
+const (
+	// StateClosed lets every call through and counts consecutive failures.
+	StateClosed State = iota
+	// StateOpen rejects every call until the cooldown has passed.
+	StateOpen
+	// StateHalfOpen lets a single probe through; its outcome closes or
+	// reopens the circuit.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker is safe for concurrent use. Callers ask Allow before a call and
+// report its outcome with Success or Failure; a call that says nothing
+// about the dependency, such as one cancelled by its caller, reports
+// neither.
+type Breaker struct {
+	threshold int32
+	cooldown  time.Duration
+	onChange  func(from, to State)
+	now       func() time.Time
+
+	state    atomic.Int32
+	failures atomic.Int32
+	// since is when the circuit opened or, while half-open, when the
+	// current probe was let through, in Unix nanoseconds.
+	since atomic.Int64
+}
+
+type Option func(*Breaker)
+
+// WithOnStateChange calls fn on every transition, e.g. to log outages.
+// fn runs on the caller's goroutine and must not block.
+func WithOnStateChange(fn func(from, to State)) Option {
+	return func(b *Breaker) {
+		b.onChange = fn
+	}
+}
+
+// New returns a closed Breaker that opens after threshold consecutive
+// failures and lets a probe through once cooldown has passed. A threshold
+// below 1 is treated as 1.
+func New(threshold int, cooldown time.Duration, opts ...Option) *Breaker {
+	b := &Breaker{
+		threshold: int32(max(threshold, 1)),
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (b *Breaker) State() State {
+	return State(b.state.Load())
+}
+
+// Allow returns ErrCircuitOpen if the call must not be made. Once the
+// cooldown has passed, exactly one caller is let through as the probe. A
+// probe that never reports back is replaced after another cooldown, so a
+// lost outcome cannot keep the circuit half-open forever.
+func (b *Breaker) Allow() error {
+	state := b.State()
+	if state == StateClosed {
+		return nil
+	}
+
+	since := b.since.Load()
+	now := b.now().UnixNano()
+	if now-since < int64(b.cooldown) || !b.since.CompareAndSwap(since, now) {
+		return ErrCircuitOpen
+	}
+
+	if state == StateOpen && b.state.CompareAndSwap(int32(StateOpen), int32(StateHalfOpen)) {
+		b.notify(StateOpen, StateHalfOpen)
+	}
+	return nil
+}
+
+// Success resets the failure count and closes a half-open circuit.
+func (b *Breaker) Success() {
+	b.failures.Store(0)
+	if b.state.CompareAndSwap(int32(StateHalfOpen), int32(StateClosed)) {
+		b.notify(StateHalfOpen, StateClosed)
+	}
+}
+
+// Failure counts a failed call. The threshold-th consecutive failure opens
+// a closed circuit, and a failed probe reopens a half-open one for another
+// cooldown. Failures reported while the circuit is open come from calls let
+// through before it opened and are ignored.
+func (b *Breaker) Failure() {
+	switch b.State() {
+	case StateClosed:
+		if b.failures.Add(1) < b.threshold {
+			return
+		}
+		b.since.Store(b.now().UnixNano())
+		if b.state.CompareAndSwap(int32(StateClosed), int32(StateOpen)) {
+			b.failures.Store(0)
+			b.notify(StateClosed, StateOpen)
+		}
+	case StateHalfOpen:
+		b.since.Store(b.now().UnixNano())
+		if b.state.CompareAndSwap(int32(StateHalfOpen), int32(StateOpen)) {
+			b.notify(StateHalfOpen, StateOpen)
+		}
+	}
+}
+
+func (b *Breaker) notify(from, to State) {
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
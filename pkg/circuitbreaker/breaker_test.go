@@ -0,0 +1,148 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBreaker returns a breaker with a hand-driven clock.
+func newTestBreaker(threshold int, cooldown time.Duration, opts ...Option) (*Breaker, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New(threshold, cooldown, opts...)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Second)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+}
+
+func TestBreaker_SuccessResetsFailureCount(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Second)
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		report func(*Breaker)
+		want   State
+	}{
+		{"probe succeeds", (*Breaker).Success, StateClosed},
+		{"probe fails", (*Breaker).Failure, StateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := newTestBreaker(1, time.Second)
+			b.Failure()
+
+			*now = now.Add(999 * time.Millisecond)
+			assert.ErrorIs(t, b.Allow(), ErrCircuitOpen, "still cooling down")
+
+			*now = now.Add(time.Millisecond)
+			require.NoError(t, b.Allow())
+			assert.Equal(t, StateHalfOpen, b.State())
+			assert.ErrorIs(t, b.Allow(), ErrCircuitOpen, "only one probe at a time")
+
+			tt.report(b)
+
+			assert.Equal(t, tt.want, b.State())
+		})
+	}
+}
+
+func TestBreaker_FailedProbeRestartsCooldown(t *testing.T) {
+	b, now := newTestBreaker(1, time.Second)
+	b.Failure()
+	*now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+
+	*now = now.Add(500 * time.Millisecond)
+	b.Failure()
+
+	*now = now.Add(900 * time.Millisecond)
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	*now = now.Add(100 * time.Millisecond)
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreaker_LostProbeIsReplaced(t *testing.T) {
+	b, now := newTestBreaker(1, time.Second)
+	b.Failure()
+	*now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+
+	*now = now.Add(time.Second)
+
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, StateHalfOpen, b.State())
+}
+
+func TestBreaker_LateFailureWhileOpenIsIgnored(t *testing.T) {
+	b, now := newTestBreaker(1, time.Second)
+	b.Failure()
+
+	*now = now.Add(900 * time.Millisecond)
+	b.Failure()
+	*now = now.Add(100 * time.Millisecond)
+
+	assert.NoError(t, b.Allow(), "the cooldown runs from the first failure")
+}
+
+func TestBreaker_OnlyOneConcurrentProbe(t *testing.T) {
+	b, now := newTestBreaker(1, time.Second)
+	b.Failure()
+	*now = now.Add(time.Second)
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b.Allow() == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), allowed.Load())
+}
+
+func TestBreaker_ReportsTransitions(t *testing.T) {
+	var got []string
+	b, now := newTestBreaker(1, time.Second, WithOnStateChange(func(from, to State) {
+		got = append(got, from.String()+"->"+to.String())
+	}))
+
+	b.Failure()
+	*now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+	b.Success()
+
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, got)
+}
@@ -1,33 +1,218 @@
 package config
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+
+	"SubscriptionAggregator/pkg/logger"
+)
+
+const (
+	EnvLocal  = "local"
+	EnvDocker = "docker"
+	EnvProd   = "prod"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
 )
 
+var currencyCode = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+// TLSVersions maps the accepted http_server.tls.min_version values to
+// crypto/tls constants. Anything older than TLS 1.2 is refused.
+var TLSVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 type Config struct {
-	Env        string `yaml:"env"`
+	Env        string `yaml:"env" env-default:"local"`
 	HTTPServer `yaml:"http_server"`
 	DB         `yaml:"db"`
+	Log        `yaml:"log"`
+	Currency   `yaml:"currency"`
+	Limits     `yaml:"limits"`
+	Defaults   `yaml:"defaults"`
+	Anomaly    `yaml:"anomaly"`
+	Renewal    `yaml:"renewal"`
+	Report     `yaml:"report"`
+	Admin      `yaml:"admin"`
+	Tenant     `yaml:"tenant"`
 }
 
 type HTTPServer struct {
-	Adress      string        `yaml:"adress"`
-	TimeOut     time.Duration `yaml:"timeout"`
-	IdleTimeOut time.Duration `yaml:"iddle_timeout"`
+	Adress      string        `yaml:"adress" env-default:":8080"`
+	TimeOut     time.Duration `yaml:"timeout" env-default:"5s"`
+	IdleTimeOut time.Duration `yaml:"iddle_timeout" env-default:"60s"`
+	// ReadHeaderTimeout and MaxHeaderBytes bound how long and how much a
+	// client may take to send request headers.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"2s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env-default:"65536"`
+	// MaxBodyBytes caps request bodies; MaxImportBodyBytes applies instead to
+	// file upload endpoints.
+	MaxBodyBytes       int64 `yaml:"max_body_bytes" env-default:"1048576"`
+	MaxImportBodyBytes int64 `yaml:"max_import_body_bytes" env-default:"10485760"`
+	// MaxPageSize caps the limit of GET /subscriptions; larger limits are
+	// lowered to it.
+	MaxPageSize int `yaml:"max_page_size" env-default:"500"`
+	// RequestTimeout caps handler execution; it must not exceed TimeOut so
+	// the timeout response can still be written.
+	RequestTimeout time.Duration `yaml:"request_timeout" env-default:"4s"`
+	// MaxConcurrentRequests caps the requests served at once; a request
+	// that finds no free slot within ConcurrencyWait gets a 503. Zero
+	// disables the cap.
+	MaxConcurrentRequests int           `yaml:"max_concurrent_requests" env-default:"0"`
+	ConcurrencyWait       time.Duration `yaml:"concurrency_wait" env-default:"100ms"`
+	// ShutdownTimeout bounds how long in-flight requests may drain on stop.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	TLS             TLS           `yaml:"tls"`
+}
+
+// TLS enables HTTPS when both CertFile and KeyFile are set. RedirectAddress,
+// when set, starts a plain HTTP listener that redirects to HTTPS.
+type TLS struct {
+	CertFile        string `yaml:"cert_file"`
+	KeyFile         string `yaml:"key_file"`
+	MinVersion      string `yaml:"min_version" env-default:"1.2"`
+	RedirectAddress string `yaml:"redirect_address"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Log controls slog output. An empty FilePath means stdout. Format and
+// Level default per env, see applyDefaults. Service is attached to every
+// record so aggregated logs can be told apart.
+type Log struct {
+	Format   string `yaml:"format"`
+	Level    string `yaml:"level"`
+	FilePath string `yaml:"file_path"`
+	Service  string `yaml:"service" env-default:"subscriptionaggregator"`
+}
+
+// applyDefaults fills unset fields: human-readable debug output for local
+// development, JSON at info everywhere else so the log pipeline can parse it.
+func (l *Log) applyDefaults(env string) {
+	format, level := LogFormatJSON, "info"
+	if env == EnvLocal {
+		format, level = LogFormatText, "debug"
+	}
+
+	if l.Format == "" {
+		l.Format = format
+	}
+	if l.Level == "" {
+		l.Level = level
+	}
+}
+
+// Currency describes the currency prices are stored in and how totals are
+// converted. Rates holds the price of one unit of each currency in Base,
+// e.g. USD: 81.08 for a RUB base. RatesURL and RatesTTL are only used by
+// builds with the httprates tag.
+type Currency struct {
+	Base     string             `yaml:"base" env-default:"RUB"`
+	Rates    map[string]float64 `yaml:"rates"`
+	RatesURL string             `yaml:"rates_url"`
+	RatesTTL time.Duration      `yaml:"rates_ttl" env-default:"1h"`
+}
+
+// Limits bounds what a subscription may contain.
+type Limits struct {
+	MaxPrice int `yaml:"max_price" env-default:"1000000"`
+	// MaxTotalRangeYears bounds the from_date to to_date span of
+	// /subscriptions/total.
+	MaxTotalRangeYears int `yaml:"max_total_range_years" env-default:"5"`
+	// DefaultMaxSubscriptionsPerUser caps the subscriptions of a user
+	// without a row in user_limits; zero means unlimited.
+	DefaultMaxSubscriptionsPerUser int `yaml:"default_max_subscriptions_per_user" env-default:"0"`
+}
+
+// Defaults fills in what a new subscription leaves out.
+// DefaultSubscriptionDurationDays ends a subscription created without an
+// end date that many days after its start; 0 keeps it open-ended.
+type Defaults struct {
+	DefaultSubscriptionDurationDays int `yaml:"subscription_duration_days" env-default:"0"`
+}
+
+// Anomaly tunes GET /subscriptions/{id}/anomaly: a price whose z-score
+// against the subscription's earlier prices exceeds Threshold in absolute
+// value is reported as an anomaly.
+type Anomaly struct {
+	Threshold float64 `yaml:"threshold" env-default:"2.0"`
+}
+
+// Renewal schedules the processor that renews subscriptions with
+// auto-renewal enabled.
+type Renewal struct {
+	Interval time.Duration `yaml:"interval" env-default:"6h"`
+}
+
+// Report schedules the summary report of new subscriptions,
+// cancellations and active spend. Each report covers one Interval, aligned
+// to UTC midnight for the default 24h. It is POSTed as JSON to WebhookURL
+// when set and logged otherwise; Enabled false turns it off.
+type Report struct {
+	Enabled    bool          `yaml:"enabled" env-default:"true"`
+	Interval   time.Duration `yaml:"interval" env-default:"24h"`
+	WebhookURL string        `yaml:"webhook_url"`
+}
+
+// Admin protects the /admin endpoints. They answer 401 to everyone while
+// Token is empty.
+type Admin struct {
+	Token string `yaml:"token"`
+}
+
+// Tenant says how a request's tenant and calling user are identified.
+// With JWTSecret set they are read from the JWTClaim and JWTUserClaim
+// claims of an HS256 bearer token; otherwise they are taken from Header
+// and UserHeader, which a trusted gateway must set.
+type Tenant struct {
+	Header       string `yaml:"header" env-default:"X-Tenant-ID"`
+	UserHeader   string `yaml:"user_header" env-default:"X-User-ID"`
+	JWTSecret    string `yaml:"jwt_secret"`
+	JWTClaim     string `yaml:"jwt_claim" env-default:"tenant_id"`
+	JWTUserClaim string `yaml:"jwt_user_claim" env-default:"sub"`
+	// LegacyID, when set, is the tenant that subscriptions created before
+	// tenants existed are moved to on startup.
+	LegacyID string `yaml:"legacy_id"`
 }
 
 type DB struct {
 	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
+	Port     string `yaml:"port" env-default:"5432"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	Name     string `yaml:"name"`
-	Sslmode  string `yaml:"sslmode"`
+	Sslmode  string `yaml:"sslmode" env-default:"disable"`
+	// QueryTimeout bounds each repository call; zero means the
+	// repository's default.
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"5s"`
+	// BreakerThreshold consecutive failed repository calls open the
+	// circuit; BreakerCooldown later a single probe call is let through.
+	BreakerThreshold int           `yaml:"breaker_threshold" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env-default:"10s"`
+	// SlowQueryThreshold is how long a repository call may take before it
+	// is logged as a warning; zero turns the warnings off.
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" env-default:"500ms"`
 }
 
 func MustLoad() *Config {
@@ -41,12 +226,296 @@ func MustLoad() *Config {
 		log.Fatal("CONFIG_PATH is not found while MustLoad()")
 	}
 
+	cfg, err := Load(configPath)
+	if err != nil {
+		log.Fatalf("cannot load config: %s", err)
+	}
+
+	return cfg
+}
+
+// Load reads the config at configPath, fills defaults and validates the result.
+func Load(configPath string) (*Config, error) {
 	var cfg Config
 
-	err = cleanenv.ReadConfig(configPath, &cfg)
+	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot read config: %w", err)
+	}
+
+	cfg.Log.applyDefaults(cfg.Env)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// Default returns the config Load would read from a file that sets
+// nothing. It is not validated: db.host, for one, has no default.
+func Default() (*Config, error) {
+	var cfg Config
+
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("cannot fill defaults: %w", err)
+	}
+
+	cfg.Log.applyDefaults(cfg.Env)
+
+	return &cfg, nil
+}
+
+// KnownEnv reports whether Env is one of the recognized values. Unknown
+// envs are not an error: they get prod logging defaults and a warning.
+func (c *Config) KnownEnv() bool {
+	switch c.Env {
+	case EnvLocal, EnvDocker, EnvProd:
+		return true
+	}
+	return false
+}
+
+// Validate reports every impossible value at once so a broken config
+// can be fixed in a single pass.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if err := validateHostPort(c.HTTPServer.Adress); err != nil {
+		errs = append(errs, fmt.Errorf("http_server.adress: %w", err))
+	}
+	if c.HTTPServer.TimeOut <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.timeout: must be positive, got %s", c.HTTPServer.TimeOut))
+	}
+	if c.HTTPServer.IdleTimeOut <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.iddle_timeout: must be positive, got %s", c.HTTPServer.IdleTimeOut))
+	}
+
+	if c.HTTPServer.ReadHeaderTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.read_header_timeout: must be positive, got %s", c.HTTPServer.ReadHeaderTimeout))
+	}
+	if c.HTTPServer.MaxHeaderBytes <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.max_header_bytes: must be positive, got %d", c.HTTPServer.MaxHeaderBytes))
+	}
+	if c.HTTPServer.RequestTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.request_timeout: must be positive, got %s", c.HTTPServer.RequestTimeout))
+	} else if c.HTTPServer.TimeOut > 0 && c.HTTPServer.RequestTimeout > c.HTTPServer.TimeOut {
+		errs = append(errs, fmt.Errorf("http_server.request_timeout: %s exceeds timeout %s", c.HTTPServer.RequestTimeout, c.HTTPServer.TimeOut))
+	}
+
+	if c.HTTPServer.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.shutdown_timeout: must be positive, got %s", c.HTTPServer.ShutdownTimeout))
+	}
+
+	tlsCfg := c.HTTPServer.TLS
+	if (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		errs = append(errs, errors.New("http_server.tls: cert_file and key_file must be set together"))
+	}
+	if tlsCfg.Enabled() {
+		if _, ok := TLSVersions[tlsCfg.MinVersion]; !ok {
+			errs = append(errs, fmt.Errorf("http_server.tls.min_version: unsupported value %q", tlsCfg.MinVersion))
+		}
+	}
+	if tlsCfg.RedirectAddress != "" {
+		if !tlsCfg.Enabled() {
+			errs = append(errs, errors.New("http_server.tls.redirect_address: requires cert_file and key_file"))
+		}
+		if err := validateHostPort(tlsCfg.RedirectAddress); err != nil {
+			errs = append(errs, fmt.Errorf("http_server.tls.redirect_address: %w", err))
+		}
+	}
+
+	if strings.TrimSpace(c.DB.Host) == "" {
+		errs = append(errs, errors.New("db.host: must not be empty"))
+	}
+	if port, err := strconv.Atoi(c.DB.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("db.port: must be a number in 1-65535, got %q", c.DB.Port))
+	}
+	if c.DB.QueryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("db.query_timeout: must not be negative, got %s", c.DB.QueryTimeout))
+	}
+	if c.DB.BreakerThreshold < 1 {
+		errs = append(errs, fmt.Errorf("db.breaker_threshold: must be positive, got %d", c.DB.BreakerThreshold))
+	}
+	if c.DB.BreakerCooldown <= 0 {
+		errs = append(errs, fmt.Errorf("db.breaker_cooldown: must be positive, got %s", c.DB.BreakerCooldown))
+	}
+	if c.DB.SlowQueryThreshold < 0 {
+		errs = append(errs, fmt.Errorf("db.slow_query_threshold: must not be negative, got %s", c.DB.SlowQueryThreshold))
+	}
+
+	if c.MaxBodyBytes <= 0 {
+		errs = append(errs, fmt.Errorf("http_server.max_body_bytes: must be positive, got %d", c.MaxBodyBytes))
+	}
+	if c.MaxImportBodyBytes < c.MaxBodyBytes {
+		errs = append(errs, fmt.Errorf("http_server.max_import_body_bytes: must be at least max_body_bytes (%d), got %d", c.MaxBodyBytes, c.MaxImportBodyBytes))
+	}
+
+	if c.MaxPageSize < 1 {
+		errs = append(errs, fmt.Errorf("http_server.max_page_size: must be positive, got %d", c.MaxPageSize))
+	}
+	if c.MaxConcurrentRequests < 0 {
+		errs = append(errs, fmt.Errorf("http_server.max_concurrent_requests: must not be negative, got %d", c.MaxConcurrentRequests))
+	}
+	if c.ConcurrencyWait < 0 {
+		errs = append(errs, fmt.Errorf("http_server.concurrency_wait: must not be negative, got %s", c.ConcurrencyWait))
+	}
+
+	if c.Limits.MaxPrice <= 1 {
+		errs = append(errs, fmt.Errorf("limits.max_price: must be greater than 1, got %d", c.Limits.MaxPrice))
+	}
+	if c.Limits.MaxTotalRangeYears < 1 {
+		errs = append(errs, fmt.Errorf("limits.max_total_range_years: must be positive, got %d", c.Limits.MaxTotalRangeYears))
+	}
+	if c.Limits.DefaultMaxSubscriptionsPerUser < 0 {
+		errs = append(errs, fmt.Errorf("limits.default_max_subscriptions_per_user: must not be negative, got %d", c.Limits.DefaultMaxSubscriptionsPerUser))
+	}
+
+	if c.DefaultSubscriptionDurationDays < 0 {
+		errs = append(errs, fmt.Errorf("defaults.subscription_duration_days: must not be negative, got %d", c.DefaultSubscriptionDurationDays))
+	}
+
+	if c.Anomaly.Threshold <= 0 {
+		errs = append(errs, fmt.Errorf("anomaly.threshold: must be positive, got %g", c.Anomaly.Threshold))
+	}
+
+	if c.Renewal.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("renewal.interval: must be positive, got %s", c.Renewal.Interval))
+	}
+
+	if c.Report.Enabled {
+		if c.Report.Interval <= 0 {
+			errs = append(errs, fmt.Errorf("report.interval: must be positive, got %s", c.Report.Interval))
+		}
+		if c.Report.WebhookURL != "" {
+			if u, err := url.Parse(c.Report.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, errors.New("report.webhook_url: must be an http or https URL"))
+			}
+		}
+	}
+
+	if !currencyCode.MatchString(c.Currency.Base) {
+		errs = append(errs, fmt.Errorf("currency.base: must be a 3-letter ISO 4217 code, got %q", c.Currency.Base))
+	}
+	for code, rate := range c.Currency.Rates {
+		if !currencyCode.MatchString(code) {
+			errs = append(errs, fmt.Errorf("currency.rates: %q is not a 3-letter ISO 4217 code", code))
+		}
+		if rate <= 0 {
+			errs = append(errs, fmt.Errorf("currency.rates.%s: must be positive, got %g", code, rate))
+		}
+	}
+
+	if strings.TrimSpace(c.Tenant.Header) == "" {
+		errs = append(errs, errors.New("tenant.header: must not be empty"))
+	}
+	if strings.TrimSpace(c.Tenant.UserHeader) == "" {
+		errs = append(errs, errors.New("tenant.user_header: must not be empty"))
+	}
+	if c.Tenant.JWTSecret != "" && strings.TrimSpace(c.Tenant.JWTClaim) == "" {
+		errs = append(errs, errors.New("tenant.jwt_claim: must not be empty when jwt_secret is set"))
+	}
+	if c.Tenant.JWTSecret != "" && strings.TrimSpace(c.Tenant.JWTUserClaim) == "" {
+		errs = append(errs, errors.New("tenant.jwt_user_claim: must not be empty when jwt_secret is set"))
+	}
+	if c.Tenant.LegacyID != "" {
+		if id, err := uuid.Parse(c.Tenant.LegacyID); err != nil || id == uuid.Nil {
+			errs = append(errs, fmt.Errorf("tenant.legacy_id: must be a non-nil UUID, got %q", c.Tenant.LegacyID))
+		}
+	}
+
+	switch c.Log.Format {
+	case LogFormatText, LogFormatJSON:
+	default:
+		errs = append(errs, fmt.Errorf("log.format: unknown value %q", c.Log.Format))
+	}
+	if _, err := logger.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateHostPort accepts "host:port" and ":port" listen addresses.
+func validateHostPort(addr string) error {
+	if addr == "" {
+		return errors.New("must not be empty")
+	}
+
+	_, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
-		log.Fatalf("cannot read config: %s", err)
+		return fmt.Errorf("must be host:port, got %q", addr)
 	}
 
-	return &cfg
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 65535 {
+		return fmt.Errorf("invalid port in %q", addr)
+	}
+
+	return nil
+}
+
+// String describes the database settings with the password masked, so a
+// DB printed with %v or %s does not leak it.
+func (d DB) String() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=*** dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Name, d.Sslmode)
+}
+
+// LogValue logs the database settings with the password masked.
+func (d DB) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("host", d.Host),
+		slog.String("port", d.Port),
+		slog.String("user", d.User),
+		slog.String("password", "***"),
+		slog.String("name", d.Name),
+		slog.String("sslmode", d.Sslmode),
+		slog.Duration("query_timeout", d.QueryTimeout),
+		slog.Int("breaker_threshold", d.BreakerThreshold),
+		slog.Duration("breaker_cooldown", d.BreakerCooldown),
+		slog.Duration("slow_query_threshold", d.SlowQueryThreshold),
+	)
+}
+
+// LogValue prints the effective config with the DB password, the admin
+// token and the tenant JWT secret redacted.
+func (c *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("env", c.Env),
+		slog.Group("http_server",
+			slog.String("adress", c.HTTPServer.Adress),
+			slog.Duration("timeout", c.HTTPServer.TimeOut),
+			slog.Duration("iddle_timeout", c.HTTPServer.IdleTimeOut),
+			slog.Duration("read_header_timeout", c.HTTPServer.ReadHeaderTimeout),
+			slog.Int("max_header_bytes", c.HTTPServer.MaxHeaderBytes),
+			slog.Int64("max_body_bytes", c.MaxBodyBytes),
+			slog.Int64("max_import_body_bytes", c.MaxImportBodyBytes),
+			slog.Duration("request_timeout", c.HTTPServer.RequestTimeout),
+			slog.Int("max_concurrent_requests", c.MaxConcurrentRequests),
+			slog.Duration("concurrency_wait", c.ConcurrencyWait),
+			slog.Duration("shutdown_timeout", c.HTTPServer.ShutdownTimeout),
+			slog.Bool("tls", c.HTTPServer.TLS.Enabled()),
+		),
+		slog.Any("db", c.DB),
+		slog.Group("log",
+			slog.String("format", c.Log.Format),
+			slog.String("level", c.Log.Level),
+			slog.String("file_path", c.Log.FilePath),
+			slog.String("service", c.Log.Service),
+		),
+		slog.Group("currency",
+			slog.String("base", c.Currency.Base),
+			slog.Int("rates", len(c.Currency.Rates)),
+		),
+		slog.Group("admin",
+			slog.Bool("enabled", c.Admin.Token != ""),
+		),
+		slog.Group("tenant",
+			slog.String("header", c.Tenant.Header),
+			slog.Bool("jwt", c.Tenant.JWTSecret != ""),
+			slog.String("jwt_claim", c.Tenant.JWTClaim),
+			slog.String("user_header", c.Tenant.UserHeader),
+			slog.String("jwt_user_claim", c.Tenant.JWTUserClaim),
+		),
+	)
 }
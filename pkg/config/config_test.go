@@ -0,0 +1,410 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_FillsDefaults(t *testing.T) {
+	path := writeConfig(t, `
+env: "local"
+db:
+  host: "localhost"
+`)
+
+	cfg, err := Load(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.HTTPServer.Adress)
+	assert.Equal(t, 5*time.Second, cfg.HTTPServer.TimeOut)
+	assert.Equal(t, 60*time.Second, cfg.HTTPServer.IdleTimeOut)
+	assert.Equal(t, 0, cfg.HTTPServer.MaxConcurrentRequests)
+	assert.Equal(t, 100*time.Millisecond, cfg.HTTPServer.ConcurrencyWait)
+	assert.Equal(t, "disable", cfg.DB.Sslmode)
+	assert.Equal(t, 5*time.Second, cfg.DB.QueryTimeout)
+	assert.Equal(t, 5, cfg.DB.BreakerThreshold)
+	assert.Equal(t, 10*time.Second, cfg.DB.BreakerCooldown)
+	assert.Equal(t, 500*time.Millisecond, cfg.DB.SlowQueryThreshold)
+	assert.Equal(t, LogFormatText, cfg.Log.Format)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "subscriptionaggregator", cfg.Log.Service)
+	assert.Equal(t, "X-Tenant-ID", cfg.Tenant.Header)
+	assert.Equal(t, "tenant_id", cfg.Tenant.JWTClaim)
+	assert.Equal(t, "X-User-ID", cfg.Tenant.UserHeader)
+	assert.Equal(t, "sub", cfg.Tenant.JWTUserClaim)
+	assert.Equal(t, 6*time.Hour, cfg.Renewal.Interval)
+	assert.True(t, cfg.Report.Enabled)
+	assert.Equal(t, 24*time.Hour, cfg.Report.Interval)
+}
+
+func TestDefault_MatchesEmptyFile(t *testing.T) {
+	loaded, err := Load(writeConfig(t, `
+db:
+  host: "localhost"
+`))
+	require.NoError(t, err)
+
+	cfg, err := Default()
+
+	require.NoError(t, err)
+	cfg.DB.Host = "localhost"
+	assert.Equal(t, loaded, cfg)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoad_InvalidConfig(t *testing.T) {
+	path := writeConfig(t, `
+env: "staging"
+http_server:
+  timeout: -1s
+log:
+  format: "xml"
+  level: "verbose"
+`)
+
+	cfg, err := Load(path)
+
+	assert.Nil(t, cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http_server.timeout: must be positive")
+	assert.Contains(t, err.Error(), "db.host: must not be empty")
+	assert.Contains(t, err.Error(), `log.format: unknown value "xml"`)
+	assert.Contains(t, err.Error(), `log.level: unknown log level "verbose"`)
+}
+
+func TestLoad_LogDefaultsByEnv(t *testing.T) {
+	tests := []struct {
+		env    string
+		format string
+		level  string
+		known  bool
+	}{
+		{env: EnvLocal, format: LogFormatText, level: "debug", known: true},
+		{env: EnvDocker, format: LogFormatJSON, level: "info", known: true},
+		{env: EnvProd, format: LogFormatJSON, level: "info", known: true},
+		{env: "staging", format: LogFormatJSON, level: "info", known: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			path := writeConfig(t, `
+env: "`+tt.env+`"
+db:
+  host: "localhost"
+`)
+
+			cfg, err := Load(path)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, cfg.Log.Format)
+			assert.Equal(t, tt.level, cfg.Log.Level)
+			assert.Equal(t, tt.known, cfg.KnownEnv())
+		})
+	}
+}
+
+func TestLoad_LogOverridesEnvDefaults(t *testing.T) {
+	path := writeConfig(t, `
+env: "prod"
+db:
+  host: "localhost"
+log:
+  format: "text"
+  level: "warn"
+`)
+
+	cfg, err := Load(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, LogFormatText, cfg.Log.Format)
+	assert.Equal(t, "warn", cfg.Log.Level)
+}
+
+func validConfig() Config {
+	return Config{
+		Env: EnvLocal,
+		HTTPServer: HTTPServer{
+			Adress:             "localhost:8080",
+			TimeOut:            5 * time.Second,
+			IdleTimeOut:        60 * time.Second,
+			ReadHeaderTimeout:  2 * time.Second,
+			MaxHeaderBytes:     1 << 16,
+			MaxBodyBytes:       1 << 20,
+			MaxImportBodyBytes: 10 << 20,
+			MaxPageSize:        500,
+			RequestTimeout:     4 * time.Second,
+			ShutdownTimeout:    10 * time.Second,
+		},
+		DB:       DB{Host: "localhost", Port: "5432", BreakerThreshold: 5, BreakerCooldown: 10 * time.Second},
+		Log:      Log{Format: LogFormatText, Level: "debug"},
+		Currency: Currency{Base: "RUB"},
+		Limits:   Limits{MaxPrice: 1000000, MaxTotalRangeYears: 5},
+		Anomaly:  Anomaly{Threshold: 2},
+		Renewal:  Renewal{Interval: 6 * time.Hour},
+		Report:   Report{Enabled: true, Interval: 24 * time.Hour},
+		Tenant:   Tenant{Header: "X-Tenant-ID", UserHeader: "X-User-ID", JWTClaim: "tenant_id", JWTUserClaim: "sub"},
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	cfg := validConfig()
+
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_ReportsAllViolations(t *testing.T) {
+	cfg := validConfig()
+	cfg.HTTPServer.Adress = "localhost"
+	cfg.HTTPServer.IdleTimeOut = -time.Second
+	cfg.DB.Host = "  "
+	cfg.DB.Port = "0"
+	cfg.DB.QueryTimeout = -time.Second
+	cfg.DB.BreakerThreshold = 0
+	cfg.DB.BreakerCooldown = 0
+	cfg.DB.SlowQueryThreshold = -time.Second
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `http_server.adress: must be host:port, got "localhost"`)
+	assert.Contains(t, err.Error(), "http_server.iddle_timeout: must be positive")
+	assert.Contains(t, err.Error(), "db.host: must not be empty")
+	assert.Contains(t, err.Error(), `db.port: must be a number in 1-65535, got "0"`)
+	assert.Contains(t, err.Error(), "db.query_timeout: must not be negative")
+	assert.Contains(t, err.Error(), "db.breaker_threshold: must be positive")
+	assert.Contains(t, err.Error(), "db.breaker_cooldown: must be positive")
+	assert.Contains(t, err.Error(), "db.slow_query_threshold: must not be negative")
+}
+
+func TestValidate_DBPortRange(t *testing.T) {
+	for _, port := range []string{"0", "65536", "-1", "pg"} {
+		t.Run(port, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.DB.Port = port
+
+			err := cfg.Validate()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "db.port")
+		})
+	}
+}
+
+func TestValidate_TLS(t *testing.T) {
+	path := writeConfig(t, `
+db:
+  host: "localhost"
+http_server:
+  tls:
+    cert_file: "cert.pem"
+    min_version: "1.0"
+    redirect_address: ":8081"
+`)
+
+	cfg, err := Load(path)
+
+	assert.Nil(t, cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cert_file and key_file must be set together")
+	assert.Contains(t, err.Error(), "redirect_address: requires cert_file and key_file")
+}
+
+func TestLoad_CurrencyRates(t *testing.T) {
+	path := writeConfig(t, `
+db:
+  host: "localhost"
+currency:
+  rates:
+    USD: 81.08
+    EUR: 94.5
+`)
+
+	cfg, err := Load(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "RUB", cfg.Currency.Base)
+	assert.Equal(t, map[string]float64{"USD": 81.08, "EUR": 94.5}, cfg.Currency.Rates)
+}
+
+func TestValidate_CurrencyRates(t *testing.T) {
+	cfg := validConfig()
+	cfg.Currency.Base = "rubles"
+	cfg.Currency.Rates = map[string]float64{"USD": 0, "DOLLAR": 1}
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "currency.base: must be a 3-letter ISO 4217 code")
+	assert.Contains(t, err.Error(), "currency.rates.USD: must be positive")
+	assert.Contains(t, err.Error(), `currency.rates: "DOLLAR" is not a 3-letter ISO 4217 code`)
+}
+
+func TestValidate_MaxPrice(t *testing.T) {
+	cfg := validConfig()
+	cfg.Limits.MaxPrice = 0
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limits.max_price: must be greater than 1")
+}
+
+func TestValidate_MaxTotalRange(t *testing.T) {
+	cfg := validConfig()
+	cfg.Limits.MaxTotalRangeYears = 0
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limits.max_total_range_years: must be positive")
+}
+
+func TestValidate_DefaultMaxSubscriptionsPerUser(t *testing.T) {
+	cfg := validConfig()
+	cfg.Limits.DefaultMaxSubscriptionsPerUser = -1
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limits.default_max_subscriptions_per_user: must not be negative")
+}
+
+func TestValidate_DefaultSubscriptionDuration(t *testing.T) {
+	cfg := validConfig()
+	cfg.DefaultSubscriptionDurationDays = -30
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "defaults.subscription_duration_days: must not be negative")
+}
+
+func TestValidate_AnomalyThreshold(t *testing.T) {
+	cfg := validConfig()
+	cfg.Anomaly.Threshold = 0
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "anomaly.threshold: must be positive")
+}
+
+func TestValidate_RenewalInterval(t *testing.T) {
+	cfg := validConfig()
+	cfg.Renewal.Interval = 0
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "renewal.interval: must be positive")
+}
+
+func TestValidate_Report(t *testing.T) {
+	tests := []struct {
+		name    string
+		report  Report
+		wantErr string
+	}{
+		{"webhook", Report{Enabled: true, Interval: time.Hour, WebhookURL: "https://hooks.example.com/digest"}, ""},
+		{"zero interval", Report{Enabled: true}, "report.interval: must be positive"},
+		{"webhook without scheme", Report{Enabled: true, Interval: time.Hour, WebhookURL: "hooks.example.com"}, "report.webhook_url"},
+		{"disabled", Report{WebhookURL: "hooks.example.com"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Report = tt.report
+
+			err := cfg.Validate()
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_MaxPageSize(t *testing.T) {
+	cfg := validConfig()
+	cfg.MaxPageSize = 0
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http_server.max_page_size: must be positive")
+}
+
+func TestValidate_Concurrency(t *testing.T) {
+	cfg := validConfig()
+	cfg.MaxConcurrentRequests = -1
+	cfg.ConcurrencyWait = -time.Millisecond
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http_server.max_concurrent_requests: must not be negative")
+	assert.Contains(t, err.Error(), "http_server.concurrency_wait: must not be negative")
+}
+
+func TestValidate_BodyLimits(t *testing.T) {
+	cfg := validConfig()
+	cfg.MaxBodyBytes = 2 << 20
+	cfg.MaxImportBodyBytes = 1 << 20
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http_server.max_import_body_bytes: must be at least max_body_bytes")
+}
+
+func TestValidate_Tenant(t *testing.T) {
+	cfg := validConfig()
+	cfg.Tenant.Header = ""
+	cfg.Tenant.JWTSecret = "secret"
+	cfg.Tenant.JWTClaim = " "
+	cfg.Tenant.UserHeader = ""
+	cfg.Tenant.JWTUserClaim = ""
+	cfg.Tenant.LegacyID = "00000000-0000-0000-0000-000000000000"
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenant.header: must not be empty")
+	assert.Contains(t, err.Error(), "tenant.jwt_claim: must not be empty when jwt_secret is set")
+	assert.Contains(t, err.Error(), "tenant.user_header: must not be empty")
+	assert.Contains(t, err.Error(), "tenant.jwt_user_claim: must not be empty when jwt_secret is set")
+	assert.Contains(t, err.Error(), "tenant.legacy_id: must be a non-nil UUID")
+}
+
+func TestDB_MasksPassword(t *testing.T) {
+	db := DB{Host: "db", Port: "5432", User: "app", Password: "s3cr3t p@ss", Name: "subs", Sslmode: "disable"}
+
+	assert.Equal(t, "host=db port=5432 user=app password=*** dbname=subs sslmode=disable", db.String())
+	assert.NotContains(t, fmt.Sprintf("%v", db), db.Password)
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	log.Info("config", slog.Any("db", db), slog.Any("config", &Config{DB: db}))
+
+	assert.NotContains(t, buf.String(), "s3cr3t")
+	assert.Contains(t, buf.String(), `"password":"***"`)
+}
@@ -0,0 +1,40 @@
+// Package ctxkey holds the request-scoped values that middleware puts into
+// a context for the service layer to read.
+package ctxkey
+
+import "context"
+
+// Key identifies one value. Keys are compared by identity, so two packages
+// can never collide on the same name.
+type Key struct {
+	name string
+}
+
+func (k *Key) String() string {
+	return "ctxkey." + k.name
+}
+
+// KeyTenantID carries the uuid.UUID of the tenant a request belongs to.
+var KeyTenantID = &Key{name: "tenant_id"}
+
+// KeyUserID carries the uuid.UUID of the user making a request, when the
+// gateway or token names one.
+var KeyUserID = &Key{name: "user_id"}
+
+// KeyLogger carries the request's *slog.Logger, see logger.FromContext.
+var KeyLogger = &Key{name: "logger"}
+
+// KeyRenderer carries the render.Renderer negotiated for a request.
+var KeyRenderer = &Key{name: "renderer"}
+
+// With returns a copy of ctx carrying v under key.
+func With[T any](ctx context.Context, key *Key, v T) context.Context {
+	return context.WithValue(ctx, key, v)
+}
+
+// Get returns the value stored under key. ok is false when there is none
+// or it is not a T.
+func Get[T any](ctx context.Context, key *Key) (v T, ok bool) {
+	v, ok = ctx.Value(key).(T)
+	return v, ok
+}
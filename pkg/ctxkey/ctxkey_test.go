@@ -0,0 +1,28 @@
+package ctxkey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	tenant := uuid.New()
+	ctx := With(context.Background(), KeyTenantID, tenant)
+
+	got, ok := Get[uuid.UUID](ctx, KeyTenantID)
+	assert.True(t, ok)
+	assert.Equal(t, tenant, got)
+
+	_, ok = Get[uuid.UUID](context.Background(), KeyTenantID)
+	assert.False(t, ok, "missing value")
+
+	_, ok = Get[string](ctx, KeyTenantID)
+	assert.False(t, ok, "wrong type")
+
+	other := &Key{name: "tenant_id"}
+	_, ok = Get[uuid.UUID](ctx, other)
+	assert.False(t, ok, "keys with the same name do not collide")
+}
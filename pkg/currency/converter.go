@@ -0,0 +1,78 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrUnsupportedCurrency is returned when no rate is known for a currency.
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// ExchangeRateProvider returns how many units of to one unit of from buys.
+type ExchangeRateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// StaticProvider serves fixed rates from config. rates holds the price of
+// one unit of each currency in base, e.g. {"USD": 81.08} with base "RUB".
+type StaticProvider struct {
+	base  string
+	rates map[string]float64
+}
+
+func NewStaticProvider(base string, rates map[string]float64) *StaticProvider {
+	normalized := make(map[string]float64, len(rates)+1)
+	for code, rate := range rates {
+		normalized[Normalize(code)] = rate
+	}
+	normalized[Normalize(base)] = 1
+
+	return &StaticProvider{base: Normalize(base), rates: normalized}
+}
+
+func (p *StaticProvider) Rate(_ context.Context, from, to string) (float64, error) {
+	fromRate, ok := p.rates[Normalize(from)]
+	if !ok || fromRate <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	toRate, ok := p.rates[Normalize(to)]
+	if !ok || toRate <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+
+	return fromRate / toRate, nil
+}
+
+// Converter turns amounts stored in the base currency into other currencies.
+type Converter struct {
+	base     string
+	provider ExchangeRateProvider
+}
+
+func NewConverter(base string, provider ExchangeRateProvider) *Converter {
+	return &Converter{base: Normalize(base), provider: provider}
+}
+
+// Base is the currency subscription prices are stored in.
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Convert returns amount, given in the base currency, in currency to,
+// rounded to cents.
+func (c *Converter) Convert(ctx context.Context, amount int, to string) (float64, error) {
+	rate, err := c.provider.Rate(ctx, c.base, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Round(float64(amount)*rate*100) / 100, nil
+}
+
+// Normalize upper-cases an ISO 4217 code so "usd" and "USD" match.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,60 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRateProvider struct {
+	mock.Mock
+}
+
+func (m *MockRateProvider) Rate(ctx context.Context, from, to string) (float64, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func TestConverter_Convert(t *testing.T) {
+	provider := &MockRateProvider{}
+	provider.On("Rate", mock.Anything, "RUB", "USD").Return(1/81.08, nil)
+
+	converted, err := NewConverter("rub", provider).Convert(context.Background(), 1500, "USD")
+
+	require.NoError(t, err)
+	assert.Equal(t, 18.50, converted)
+	provider.AssertExpectations(t)
+}
+
+func TestConverter_ProviderError(t *testing.T) {
+	provider := &MockRateProvider{}
+	provider.On("Rate", mock.Anything, "RUB", "XYZ").Return(0.0, ErrUnsupportedCurrency)
+
+	_, err := NewConverter("RUB", provider).Convert(context.Background(), 1500, "XYZ")
+
+	assert.True(t, errors.Is(err, ErrUnsupportedCurrency))
+}
+
+func TestStaticProvider_Rate(t *testing.T) {
+	p := NewStaticProvider("RUB", map[string]float64{"usd": 80, "EUR": 100})
+	ctx := context.Background()
+
+	rate, err := p.Rate(ctx, "RUB", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0/80, rate, 1e-12)
+
+	rate, err = p.Rate(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.25, rate, 1e-12)
+
+	rate, err = p.Rate(ctx, "RUB", "rub")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	_, err = p.Rate(ctx, "RUB", "GBP")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
@@ -0,0 +1,113 @@
+//go:build httprates
+
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPProvider fetches rates from a JSON endpoint that answers with
+// {"rates": {"USD": 0.0123, ...}}, quoted per one unit of base, and caches
+// them for ttl. A failed refresh keeps serving the previous rates, and so
+// does everyone else while one caller refreshes them.
+type HTTPProvider struct {
+	url    string
+	base   string
+	ttl    time.Duration
+	client *http.Client
+
+	// mu guards the fields below; it is never held during a fetch.
+	mu         sync.Mutex
+	rates      *StaticProvider
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func NewHTTPProvider(url, base string, ttl time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		base:   Normalize(base),
+		ttl:    ttl,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *HTTPProvider) Rate(ctx context.Context, from, to string) (float64, error) {
+	rates, err := p.current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return rates.Rate(ctx, from, to)
+}
+
+// current returns the cached rates, refreshing them first once they are
+// older than ttl. Only one caller refreshes stale rates and the others
+// use them meanwhile; until the first fetch succeeds there is nothing to
+// fall back on, so every caller fetches.
+func (p *HTTPProvider) current(ctx context.Context) (*StaticProvider, error) {
+	p.mu.Lock()
+	rates := p.rates
+	stale := rates == nil || time.Since(p.fetchedAt) > p.ttl
+	if !stale || (rates != nil && p.refreshing) {
+		p.mu.Unlock()
+		return rates, nil
+	}
+	p.refreshing = true
+	p.mu.Unlock()
+
+	fetched, err := p.fetch(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshing = false
+	if err != nil {
+		if p.rates == nil {
+			return nil, err
+		}
+		return p.rates, nil
+	}
+	p.rates = fetched
+	p.fetchedAt = time.Now()
+	return fetched, nil
+}
+
+func (p *HTTPProvider) fetch(ctx context.Context) (*StaticProvider, error) {
+	const op = "currency.HTTPProvider.fetch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode rates: %w", op, err)
+	}
+
+	// The endpoint quotes currency per base; StaticProvider wants base per currency.
+	inBase := make(map[string]float64, len(body.Rates))
+	for code, perBase := range body.Rates {
+		if perBase > 0 {
+			inBase[code] = 1 / perBase
+		}
+	}
+
+	return NewStaticProvider(p.base, inBase), nil
+}
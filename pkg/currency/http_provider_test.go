@@ -0,0 +1,90 @@
+//go:build httprates
+
+package currency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider_FetchesAndCaches(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"rates":{"USD":0.0125}}`))
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL, "RUB", time.Hour)
+
+	for i := 0; i < 2; i++ {
+		rate, err := p.Rate(context.Background(), "RUB", "USD")
+		require.NoError(t, err)
+		assert.InDelta(t, 0.0125, rate, 1e-12)
+	}
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestHTTPProvider_KeepsStaleRatesOnFailure(t *testing.T) {
+	healthy := atomic.Bool{}
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"rates":{"USD":0.0125}}`))
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL, "RUB", 0)
+	_, err := p.Rate(context.Background(), "RUB", "USD")
+	require.NoError(t, err)
+
+	healthy.Store(false)
+	rate, err := p.Rate(context.Background(), "RUB", "USD")
+
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0125, rate, 1e-12)
+}
+
+func TestHTTPProvider_SlowRefreshDoesNotBlock(t *testing.T) {
+	var calls atomic.Int32
+	refreshing, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			close(refreshing)
+			<-release
+		}
+		w.Write([]byte(`{"rates":{"USD":0.0125}}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p := NewHTTPProvider(srv.URL, "RUB", 0)
+	_, err := p.Rate(context.Background(), "RUB", "USD")
+	require.NoError(t, err)
+
+	go p.Rate(context.Background(), "RUB", "USD")
+	<-refreshing
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Rate(context.Background(), "RUB", "USD")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Rate waited for another caller's refresh")
+	}
+	assert.EqualValues(t, 2, calls.Load())
+}
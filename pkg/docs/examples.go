@@ -0,0 +1,385 @@
+package docs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/importer"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// component is a schema published under components/schemas. The schema is
+// generated from the example's type and the example itself is attached to
+// it, so every schema is documented with a realistic value.
+type component struct {
+	name    string
+	example any
+}
+
+var (
+	exampleSubscriptionID = uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	exampleUserID         = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	exampleSharedUserID   = uuid.MustParse("7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b")
+	exampleReminderID     = uuid.MustParse("3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8")
+	examplePaymentID      = uuid.MustParse("9b2d7c4e-1f3a-4b5c-8d6e-7f8091a2b3c4")
+	exampleCatalogID      = uuid.MustParse("2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c")
+
+	exampleStart = time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC)
+	exampleEnd   = time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC)
+
+	exampleExportedAt = time.Date(2025, 8, 22, 10, 30, 0, 0, time.UTC)
+
+	exampleServiceName   = "yandex plus"
+	exampleConverted     = 18.5
+	exampleZScore        = 10.0
+	examplePctChange     = 20.0
+	exampleComparisonPct = 12.5
+	exampleMetadata      = json.RawMessage(`{"invoice":"INV-42","tags":["work"]}`)
+)
+
+var components = []component{
+	{"model.Subscription", model.Subscription{
+		ID:           exampleSubscriptionID,
+		ServiceName:  exampleServiceName,
+		Price:        599,
+		UserID:       exampleUserID,
+		StartDate:    exampleStart,
+		EndDate:      &exampleEnd,
+		BillingCycle: model.CycleMonthly,
+		Metadata:     exampleMetadata,
+	}},
+	{"model.SubscriptionFilter", model.SubscriptionFilter{
+		UserID:      &exampleUserID,
+		ServiceName: &exampleServiceName,
+		FromDate:    &exampleStart,
+		ToDate:      &exampleEnd,
+	}},
+	{"service.CreateSubscriptionRequest", service.CreateSubscriptionRequest{
+		ServiceName:  exampleServiceName,
+		Price:        599,
+		UserID:       exampleUserID,
+		StartDate:    exampleStart,
+		EndDate:      &exampleEnd,
+		BillingCycle: model.CycleMonthly,
+		Metadata:     exampleMetadata,
+	}},
+	{"service.UpdateSubscriptionRequest", service.UpdateSubscriptionRequest{
+		ServiceName:  exampleServiceName,
+		Price:        699,
+		UserID:       exampleUserID,
+		StartDate:    exampleStart,
+		BillingCycle: model.CycleMonthly,
+	}},
+	{"service.UpdatePriceRequest", service.UpdatePriceRequest{Price: 799}},
+	{"service.BulkUpdatePriceRequest", service.BulkUpdatePriceRequest{NewPrice: 1199}},
+	{"service.UpdateServicePriceRequest", service.UpdateServicePriceRequest{
+		PercentChange: &examplePctChange,
+		ActiveOnly:    true,
+		EffectiveDate: &exampleEnd,
+	}},
+	{"service.BatchGetRequest", service.BatchGetRequest{IDs: []uuid.UUID{exampleSubscriptionID, exampleReminderID}}},
+	{"service.SimulateCostRequest", service.SimulateCostRequest{
+		UserID: exampleUserID,
+		Add: []service.CreateSubscriptionRequest{{
+			ServiceName:  "kinopoisk",
+			Price:        399,
+			StartDate:    exampleStart,
+			BillingCycle: model.CycleMonthly,
+		}},
+		ExcludeIDs: []uuid.UUID{exampleSubscriptionID},
+	}},
+	{"service.ShareSubscriptionRequest", service.ShareSubscriptionRequest{
+		UserID:     exampleSharedUserID,
+		Permission: model.PermissionRead,
+	}},
+	{"service.CreateAndShareRequest", service.CreateAndShareRequest{
+		Subscription: service.CreateSubscriptionRequest{
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			BillingCycle: model.CycleMonthly,
+		},
+		ShareWith: []service.ShareSubscriptionRequest{{UserID: exampleSharedUserID, Permission: model.PermissionRead}},
+	}},
+	{"service.CreateReminderRequest", service.CreateReminderRequest{RemindDaysBefore: 3}},
+	{"service.UpdateReminderRequest", service.UpdateReminderRequest{RemindDaysBefore: 7}},
+	{"model.SubscriptionListResponse", model.SubscriptionListResponse{
+		Subscriptions: []*model.Subscription{{
+			ID:           exampleSubscriptionID,
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			BillingCycle: model.CycleMonthly,
+		}},
+		Count:  120,
+		Limit:  50,
+		Offset: 50,
+	}},
+	{"model.BatchGetResult", model.BatchGetResult{
+		Subscriptions: []*model.Subscription{{
+			ID:           exampleSubscriptionID,
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			BillingCycle: model.CycleMonthly,
+		}},
+		Missing: []uuid.UUID{exampleReminderID},
+	}},
+	{"model.PriceAnomalyReport", model.PriceAnomalyReport{
+		CurrentPrice: 1200,
+		Mean:         700,
+		StdDev:       50,
+		ZScore:       &exampleZScore,
+		IsAnomaly:    true,
+		HistorySize:  4,
+	}},
+	{"service.RecordPaymentRequest", service.RecordPaymentRequest{Amount: 599, Currency: "RUB"}},
+	{"model.Payment", model.Payment{
+		ID:             examplePaymentID,
+		SubscriptionID: exampleSubscriptionID,
+		Amount:         599,
+		Currency:       "RUB",
+		BilledAt:       exampleStart,
+		Status:         model.PaymentPaid,
+	}},
+	{"model.ShareEntry", model.ShareEntry{
+		SubscriptionID: exampleSubscriptionID,
+		UserID:         exampleSharedUserID,
+		Permission:     model.PermissionRead,
+		CreatedAt:      exampleStart,
+	}},
+	{"model.SharedSubscription", model.SharedSubscription{
+		Subscription: &model.Subscription{
+			ID:           exampleSubscriptionID,
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			BillingCycle: model.CycleMonthly,
+		},
+		Shares: []model.ShareEntry{{
+			SubscriptionID: exampleSubscriptionID,
+			UserID:         exampleSharedUserID,
+			Permission:     model.PermissionRead,
+			CreatedAt:      exampleStart,
+		}},
+	}},
+	{"model.Pin", model.Pin{
+		SubscriptionID: exampleSubscriptionID,
+		UserID:         exampleUserID,
+		PinnedAt:       exampleStart,
+	}},
+	{"model.Reminder", model.Reminder{
+		ID:               exampleReminderID,
+		SubscriptionID:   exampleSubscriptionID,
+		RemindDaysBefore: 3,
+		CreatedAt:        exampleStart,
+	}},
+	{"model.SubscriptionEvent", model.SubscriptionEvent{Event: model.EventCreated, ID: exampleSubscriptionID}},
+	{"model.TotalCostResponse", model.TotalCostResponse{
+		Total:          1500,
+		Mode:           model.TotalProrated,
+		Currency:       "RUB",
+		ConvertedTotal: &exampleConverted,
+		TargetCurrency: "USD",
+	}},
+	{"model.TeamTotalCost", model.TeamTotalCost{
+		Totals: map[uuid.UUID]int{exampleUserID: 1800, exampleSharedUserID: 1200},
+		Total:  3000,
+	}},
+	{"model.MonthlyCost", model.MonthlyCost{Month: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Total: 1500}},
+	{"model.PeriodComparison", model.PeriodComparison{
+		PeriodA:       model.PeriodTotal{From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), Total: 14400},
+		PeriodB:       model.PeriodTotal{From: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), Total: 16200},
+		Delta:         1800,
+		PercentChange: &exampleComparisonPct,
+		TopMovers: []model.ServiceCostChange{
+			{ServiceName: "netflix", TotalA: 2997, TotalB: 3597, Delta: 600},
+			{ServiceName: "gym", TotalA: 600, TotalB: 0, Delta: -600},
+		},
+		Currency: "RUB",
+	}},
+	{"model.CostSimulation", model.CostSimulation{
+		Month:          time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+		CurrentTotal:   1798,
+		SimulatedTotal: 1598,
+		Difference:     -200,
+		Currency:       "RUB",
+	}},
+	{"model.ProjectedCost", model.ProjectedCost{Month: "2025-08", ProjectedCost: 1500}},
+	{"model.PriceStats", model.PriceStats{Count: 12, MinPrice: 199, MaxPrice: 1299, AvgPrice: 574.5, MedianPrice: 499}},
+	{"model.RankedSubscription", model.RankedSubscription{
+		Subscription: model.Subscription{
+			ID:           exampleSubscriptionID,
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			EndDate:      &exampleEnd,
+			BillingCycle: model.CycleMonthly,
+		},
+		CostPerDay: 19.32,
+		Rank:       1,
+	}},
+	{"model.ExpiredSubscription", model.ExpiredSubscription{
+		Subscription: model.Subscription{
+			ID:           exampleSubscriptionID,
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			EndDate:      &exampleEnd,
+			BillingCycle: model.CycleMonthly,
+		},
+		ExpiredForDays: 14,
+	}},
+	{"model.ServiceSummary", model.ServiceSummary{ServiceName: "netflix", SubscriptionCount: 3}},
+	{"model.ExpiringServiceSummary", model.ExpiringServiceSummary{
+		ServiceName:    "netflix",
+		Count:          2,
+		EarliestExpiry: exampleEnd,
+	}},
+	{"model.BillingCycleSummary", model.BillingCycleSummary{
+		BillingCycle:      model.CycleAnnual,
+		Total:             2400,
+		Count:             1,
+		MonthlyEquivalent: model.CycleAnnual.MonthlyEquivalent(2400),
+	}},
+	{"model.UserSummary", model.UserSummary{
+		ActiveCount:          5,
+		TotalMonthlyCost:     1500,
+		MostExpensiveService: "netflix",
+		NextExpiry:           &exampleEnd,
+		ExpiredCount:         2,
+	}},
+	{"service.SetSpendingLimitRequest", service.SetSpendingLimitRequest{LimitAmount: 2000, Currency: "RUB", AlertThresholdPct: 80}},
+	{"model.SpendingLimit", model.SpendingLimit{
+		UserID:            exampleUserID,
+		LimitAmount:       2000,
+		Currency:          "RUB",
+		AlertThresholdPct: 80,
+		CreatedAt:         exampleStart,
+		UpdatedAt:         exampleStart,
+	}},
+	{"model.SpendingLimitStatus", model.SpendingLimitStatus{
+		Limit:        2000,
+		Currency:     "RUB",
+		CurrentTotal: 1750,
+		UsedPct:      87.5,
+		Alert:        true,
+	}},
+	{"model.CreationRate", model.CreationRate{
+		From:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		Count:  310,
+		PerDay: 10,
+	}},
+	{"model.TopServices", model.TopServices{
+		Services: []model.ServiceCost{
+			{ServiceName: "netflix", Total: 5994, Percent: 41.63},
+			{ServiceName: exampleServiceName, Total: 3594, Percent: 24.96},
+		},
+		Other: model.OtherServicesCost{ServiceCount: 3, Total: 4812, Percent: 33.41},
+		Total: 14400,
+	}},
+	{"model.Forecast", model.Forecast{
+		Months: []model.MonthlyCost{
+			{Month: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Total: 1500},
+			{Month: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Total: 901},
+		},
+		Total: 2401,
+	}},
+	{"model.UserCost", model.UserCost{UserID: exampleUserID, SubscriptionCount: 4, Total: 14376}},
+	{"model.CleanupResponse", model.CleanupResponse{Deleted: 3}},
+	{"model.BulkUpdatePriceResponse", model.BulkUpdatePriceResponse{Updated: 42}},
+	{"model.ServicePriceUpdateResult", model.ServicePriceUpdateResult{Updated: 42, TotalBefore: 41958, TotalAfter: 50358}},
+	{"model.UserDataExport", model.UserDataExport{
+		UserID:     exampleUserID,
+		ExportedAt: exampleExportedAt,
+		Subscriptions: []*model.Subscription{{
+			ID:           exampleSubscriptionID,
+			ServiceName:  exampleServiceName,
+			Price:        599,
+			UserID:       exampleUserID,
+			StartDate:    exampleStart,
+			EndDate:      &exampleEnd,
+			BillingCycle: model.CycleMonthly,
+			Version:      2,
+		}},
+		PriceHistory: []model.PriceChange{
+			{SubscriptionID: exampleSubscriptionID, Price: 499, RecordedAt: exampleStart},
+			{SubscriptionID: exampleSubscriptionID, Price: 599, RecordedAt: exampleStart.AddDate(0, 0, 10)},
+		},
+		AuditLog: []model.AuditEntry{{
+			ID:        17,
+			UserID:    exampleUserID,
+			Actor:     model.AuditActorUser,
+			Action:    model.AuditActionExport,
+			RequestID: "c0ffee00-1234-4abc-9def-0123456789ab",
+			Status:    model.AuditStatusCompleted,
+			CreatedAt: exampleExportedAt.AddDate(0, -1, 0),
+		}},
+	}},
+	{"model.AnonymizeResponse", model.AnonymizeResponse{Subscriptions: 4}},
+	{"model.Anonymization", model.Anonymization{
+		UserID:       exampleUserID,
+		AnonymousID:  uuid.MustParse("d4c3b2a1-9f8e-4d7c-b6a5-0f1e2d3c4b5a"),
+		AnonymizedAt: exampleExportedAt,
+	}},
+	{"model.MergeUsersRequest", model.MergeUsersRequest{
+		SourceUserID: uuid.MustParse("7a1d4c2e-5b3f-4e8a-9c6d-1f2e3a4b5c6d"),
+		Deduplicate:  true,
+	}},
+	{"model.MergeUsersResponse", model.MergeUsersResponse{Moved: 3, Skipped: 1}},
+	{"importer.Result", importer.Result{
+		Imported: 45,
+		Failed:   1,
+		Errors: []importer.ImportError{{
+			Row:   7,
+			Error: `start_date: invalid date "soon", use RFC3339, YYYY-MM-DD or MM-YYYY`,
+		}},
+	}},
+	{"service.CatalogEntryRequest", service.CatalogEntryRequest{
+		Name:         exampleServiceName,
+		Description:  "Музыка, фильмы и кешбэк баллами",
+		Category:     "streaming",
+		LogoURL:      "https://example.com/logos/yandex-plus.png",
+		DefaultPrice: 599,
+	}},
+	{"model.CatalogEntry", model.CatalogEntry{
+		ID:           exampleCatalogID,
+		Name:         exampleServiceName,
+		Description:  "Музыка, фильмы и кешбэк баллами",
+		Category:     "streaming",
+		LogoURL:      "https://example.com/logos/yandex-plus.png",
+		DefaultPrice: 599,
+		CreatedAt:    exampleStart,
+		UpdatedAt:    exampleStart,
+	}},
+	{"model.HealthResponse", model.HealthResponse{Status: "ok"}},
+	{"model.ErrorResponse", model.ErrorResponse{Error: "subscription not found", Code: 404}},
+	{"model.DuplicateErrorResponse", model.DuplicateErrorResponse{
+		Error:      "subscription overlaps an existing one, pass allow_duplicate=true to store it anyway",
+		Code:       409,
+		ExistingID: exampleSubscriptionID,
+	}},
+	{"model.VersionConflictResponse", model.VersionConflictResponse{
+		Error:          "subscription was changed meanwhile, fetch it again and retry",
+		CurrentVersion: 4,
+	}},
+	{"model.ErrorInput", model.ErrorInput{Error: "invalid input", Code: 400}},
+	{"model.ValidationErrorResponse", model.ValidationErrorResponse{
+		Error:  "validation failed",
+		Fields: map[string]string{"price": "must be greater than 0"},
+	}},
+	{"model.ServerError", model.ServerError{
+		Error:     "internal server error",
+		RequestID: "3f2b8c1e-5d4a-4b6f-9e7d-2a1c0b9f8e7d",
+	}},
+}
@@ -0,0 +1,1102 @@
+// Package docs builds the OpenAPI 3.0 description of the HTTP API. The
+// Swagger 2.0 spec served by the UI is still generated by swag from the
+// handler annotations; this one is produced from the Go types so that
+// clients needing OpenAPI 3 get the same schemas.
+package docs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+const (
+	adminSecurity  = "AdminToken"
+	tenantSecurity = "Tenant"
+)
+
+var (
+	uuidType         = reflect.TypeOf(uuid.UUID{})
+	billingCycleType = reflect.TypeOf(model.BillingCycle(""))
+	permissionType   = reflect.TypeOf(model.SharePermission(""))
+	totalModeType    = reflect.TypeOf(model.TotalMode(""))
+)
+
+// OpenAPI3 returns the spec for every route registered by the handler
+// package. Every component schema carries an example.
+func OpenAPI3() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       "Subscription Aggregator API",
+			Version:     "1.0",
+			Description: "API для управления подписками пользователей. Ответы в JSON или, при Accept: text/csv, в CSV; ошибки всегда в JSON, Accept без application/json и text/csv получает 406",
+			Contact: &openapi3.Contact{
+				Name:  "Kuzmin Anton",
+				Email: "kuzmin1a.a@gmail.com",
+			},
+		},
+		Servers: openapi3.Servers{{URL: "http://localhost:8080"}},
+		Paths:   openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			Schemas: openapi3.Schemas{},
+			SecuritySchemes: openapi3.SecuritySchemes{
+				adminSecurity: &openapi3.SecuritySchemeRef{
+					Value: openapi3.NewSecurityScheme().
+						WithType("http").
+						WithScheme("bearer").
+						WithDescription("admin.token из конфига"),
+				},
+				tenantSecurity: &openapi3.SecuritySchemeRef{
+					Value: openapi3.NewSecurityScheme().
+						WithType("apiKey").
+						WithIn(openapi3.ParameterInHeader).
+						WithName("X-Tenant-ID").
+						WithDescription("UUID тенанта. Если задан tenant.jwt_secret, тенант берется из claim tenant.jwt_claim токена Authorization: Bearer <JWT>"),
+				},
+			},
+		},
+	}
+
+	for _, c := range components {
+		schema, err := schemaFor(c.example)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", c.name, err)
+		}
+		doc.Components.Schemas[c.name] = &openapi3.SchemaRef{Value: schema}
+	}
+
+	for _, op := range operations {
+		doc.AddOperation(op.path, op.method, op.build())
+	}
+
+	return doc, nil
+}
+
+// YAML renders the spec and checks it the way a consumer would: the output
+// is loaded back, which resolves every $ref, and then validated, examples
+// included.
+func YAML(ctx context.Context) ([]byte, error) {
+	doc, err := OpenAPI3()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal spec: %w", err)
+	}
+	out := buf.Bytes()
+
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	loaded, err := loader.LoadFromData(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load generated spec: %w", err)
+	}
+	if err := loaded.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("generated spec is invalid: %w", err)
+	}
+
+	return out, nil
+}
+
+// schemaFor generates the schema of v's type. Field examples come from the
+// example struct tags, the example of the whole object is v itself.
+func schemaFor(v any) (*openapi3.Schema, error) {
+	ref, err := openapi3gen.NewSchemaRefForValue(v, nil, openapi3gen.SchemaCustomizer(customizeSchema))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var example any
+	if err := json.Unmarshal(raw, &example); err != nil {
+		return nil, err
+	}
+	ref.Value.Example = example
+
+	return ref.Value, nil
+}
+
+// customizeSchema fills in what reflection alone cannot know: UUIDs are
+// strings, enums list their values, fields without omitempty are always
+// present and swag's example tags become examples.
+func customizeSchema(_ string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) error {
+	switch t {
+	case uuidType:
+		schema.Type = &openapi3.Types{openapi3.TypeString}
+		schema.Format = "uuid"
+	case billingCycleType:
+		schema.Enum = []any{
+			string(model.CycleWeekly),
+			string(model.CycleMonthly),
+			string(model.CycleQuarterly),
+			string(model.CycleAnnual),
+		}
+	case permissionType:
+		schema.Enum = []any{string(model.PermissionRead), string(model.PermissionWrite)}
+	case totalModeType:
+		schema.Enum = []any{string(model.TotalProrated), string(model.TotalFlat)}
+	}
+
+	if t.Kind() == reflect.Struct && schema.Properties != nil {
+		schema.Required = requiredFields(t)
+	}
+
+	if example, ok := tag.Lookup("example"); ok {
+		v, err := parseExample(schema, example)
+		if err != nil {
+			return fmt.Errorf("example %q: %w", example, err)
+		}
+		schema.Example = v
+	}
+
+	return nil
+}
+
+// requiredFields lists the JSON names of t's fields that are always
+// encoded, including those of embedded structs.
+func requiredFields(t reflect.Type) []string {
+	var required []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("json")
+		if !ok && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			required = append(required, requiredFields(field.Type)...)
+			continue
+		}
+		if !ok || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+	return required
+}
+
+// parseExample converts a swag example tag to the schema's type.
+func parseExample(schema *openapi3.Schema, example string) (any, error) {
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger):
+		return strconv.Atoi(example)
+	case schema.Type.Is(openapi3.TypeNumber):
+		return strconv.ParseFloat(example, 64)
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return strconv.ParseBool(example)
+	}
+	return example, nil
+}
+
+// operation is one route of the API.
+type operation struct {
+	method    string
+	path      string
+	tag       string
+	summary   string
+	params    []*openapi3.Parameter
+	body      *openapi3.RequestBody
+	responses []response
+	// location describes the Location header of the 201 response: the
+	// URL of the created resource.
+	location string
+	// etag describes the ETag header of the 200 response.
+	etag  string
+	admin bool
+	// public routes are served without a tenant, like the admin ones.
+	public bool
+}
+
+// response is one possible answer of an operation. Several responses with
+// the same status document a body that is one of their schemas.
+type response struct {
+	status      int
+	description string
+	// schema names a component, or is textBody for a plain string body;
+	// empty means the response has no body.
+	schema string
+	array  bool
+	// contentType defaults to application/json.
+	contentType string
+}
+
+func (op operation) build() *openapi3.Operation {
+	o := openapi3.NewOperation()
+	o.Tags = []string{op.tag}
+	o.Summary = op.summary
+	o.Responses = openapi3.NewResponses()
+	for _, p := range op.params {
+		o.AddParameter(p)
+	}
+	if op.body != nil {
+		o.RequestBody = &openapi3.RequestBodyRef{Value: op.body}
+	}
+	responses := op.responses
+	switch {
+	case op.admin:
+		o.Security = &openapi3.SecurityRequirements{{adminSecurity: []string{}}}
+	case !op.public:
+		o.Security = &openapi3.SecurityRequirements{{tenantSecurity: []string{}}}
+		responses = append(responses, noTenant)
+	}
+
+	for _, r := range responses {
+		var schema *openapi3.SchemaRef
+		if r.schema != "" {
+			schema = schemaRef(r.schema)
+			if r.array {
+				list := openapi3.NewArraySchema()
+				list.Items = schema
+				schema = &openapi3.SchemaRef{Value: list}
+			}
+		}
+		contentType := r.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		if prev := o.Responses.Status(r.status); prev != nil && schema != nil {
+			mergeResponse(prev.Value, r.description, contentType, schema)
+			continue
+		}
+
+		resp := openapi3.NewResponse().WithDescription(r.description)
+		if schema != nil {
+			resp.WithContent(openapi3.NewContentWithSchemaRef(schema, []string{contentType}))
+		}
+		switch {
+		case r.status == http.StatusCreated && op.location != "":
+			resp.Headers = stringHeader("Location", op.location)
+		case r.status == http.StatusOK && op.etag != "":
+			resp.Headers = stringHeader("ETag", op.etag)
+		}
+		o.AddResponse(r.status, resp)
+	}
+
+	return o
+}
+
+// mergeResponse adds another body to a response. In a media type the
+// response already has, the body is then one of its schemas; a new media
+// type is an alternative representation the client picks with a parameter.
+func mergeResponse(resp *openapi3.Response, description, contentType string, schema *openapi3.SchemaRef) {
+	merged := *resp.Description + "; " + description
+	resp.Description = &merged
+
+	media := resp.Content.Get(contentType)
+	if media == nil {
+		resp.Content[contentType] = openapi3.NewMediaType().WithSchemaRef(schema)
+		return
+	}
+	if first := media.Schema; first.Ref != "" || len(first.Value.OneOf) == 0 {
+		media.Schema = &openapi3.SchemaRef{Value: &openapi3.Schema{OneOf: openapi3.SchemaRefs{first}}}
+	}
+	media.Schema.Value.OneOf = append(media.Schema.Value.OneOf, schema)
+}
+
+func stringHeader(name, description string) openapi3.Headers {
+	return openapi3.Headers{name: &openapi3.HeaderRef{Value: &openapi3.Header{
+		Parameter: openapi3.Parameter{Description: description, Schema: openapi3.NewStringSchema().NewRef()},
+	}}}
+}
+
+// textBody is the schema of a response that is a document of its own, such
+// as a calendar file, rather than a component; binaryBody is the same for a
+// file that is not text, such as a zip archive.
+const (
+	textBody   = "string"
+	binaryBody = "binary"
+)
+
+func schemaRef(name string) *openapi3.SchemaRef {
+	switch name {
+	case textBody:
+		return openapi3.NewStringSchema().NewRef()
+	case binaryBody:
+		return openapi3.NewStringSchema().WithFormat("binary").NewRef()
+	}
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, nil)
+}
+
+func jsonBody(schema, description string) *openapi3.RequestBody {
+	return openapi3.NewRequestBody().
+		WithDescription(description).
+		WithRequired(true).
+		WithJSONSchemaRef(schemaRef(schema))
+}
+
+func pathParam(name, description string) *openapi3.Parameter {
+	return openapi3.NewPathParameter(name).
+		WithDescription(description).
+		WithSchema(openapi3.NewUUIDSchema())
+}
+
+func queryParam(name, description string, schema *openapi3.Schema, example any) *openapi3.Parameter {
+	p := openapi3.NewQueryParameter(name).WithDescription(description).WithSchema(schema)
+	p.Example = example
+	return p
+}
+
+func headerParam(name, description string, example any) *openapi3.Parameter {
+	p := openapi3.NewHeaderParameter(name).WithDescription(description).WithSchema(openapi3.NewStringSchema())
+	p.Example = example
+	return p
+}
+
+func required(p *openapi3.Parameter) *openapi3.Parameter {
+	return p.WithRequired(true)
+}
+
+// userExportFormat and userExportResponses are shared by the user's and
+// the administrator's data export.
+var userExportFormat = queryParam("format", "Формат выгрузки", openapi3.NewStringSchema().WithEnum("json", "zip").WithDefault("json"), "json")
+
+func userExportResponses(errors ...response) []response {
+	return append([]response{
+		{http.StatusOK, "Подписки пользователя, история их цен и журнал доступа к его данным; выгрузка записывается в журнал", "model.UserDataExport", false, ""},
+		{http.StatusOK, "при format=zip архив с subscriptions.csv, price_history.csv и audit_log.csv", binaryBody, false, "application/zip"},
+	}, append(errors, serverError)...)
+}
+
+// irreversibleParam and anonymized are shared by the user's and the
+// administrator's anonymization.
+var (
+	irreversibleParam = queryParam("irreversible", "Не сохранять соответствие старого и нового ID", openapi3.NewBoolSchema().WithDefault(false), false)
+	anonymized        = ok("Подписки переведены на новый случайный ID, данные о самом пользователе удалены; запросы по старому user_id возвращают пустые результаты", "model.AnonymizeResponse")
+)
+
+// Error responses shared by most routes.
+var (
+	serverError    = response{http.StatusInternalServerError, "Ошибка сервера", "model.ServerError", false, ""}
+	noTenant       = response{http.StatusUnauthorized, "Нет или неверный тенант", "model.ErrorResponse", false, ""}
+	invalidID      = response{http.StatusBadRequest, "Неверный ID", "model.ErrorInput", false, ""}
+	invalidInput   = response{http.StatusBadRequest, "Неверный формат данных", "model.ErrorInput", false, ""}
+	invalidQuery   = response{http.StatusBadRequest, "Некорректные параметры запроса", "model.ValidationErrorResponse", false, ""}
+	notFound       = response{http.StatusNotFound, "Запись не найдена", "model.ErrorResponse", false, ""}
+	conflict       = response{http.StatusConflict, "Конфликт с существующей записью", "model.ErrorResponse", false, ""}
+	duplicate      = response{http.StatusConflict, "Период пересекается с активной подпиской на этот сервис (existing_id) или иной конфликт", "model.DuplicateErrorResponse", false, ""}
+	staleVersion   = response{http.StatusConflict, "Подписку изменили после указанной версии, нужно получить ее заново (current_version)", "model.VersionConflictResponse", false, ""}
+	invalidFields  = response{http.StatusUnprocessableEntity, "Ошибка валидации полей", "model.ValidationErrorResponse", false, ""}
+	limitReached   = response{http.StatusUnprocessableEntity, "У пользователя уже предельное число подписок", "model.ErrorResponse", false, ""}
+	tooLarge       = response{http.StatusRequestEntityTooLarge, "Слишком большое тело запроса", "model.ErrorResponse", false, ""}
+	wrongMediaType = response{http.StatusUnsupportedMediaType, "Неподдерживаемый Content-Type", "model.ErrorResponse", false, ""}
+)
+
+func ok(description, schema string) response {
+	return response{http.StatusOK, description, schema, false, ""}
+}
+
+func okList(description, schema string) response {
+	return response{http.StatusOK, description, schema, true, ""}
+}
+
+// Query parameters shared by the filtered listings.
+func filterParams() []*openapi3.Parameter {
+	return []*openapi3.Parameter{
+		queryParam("user_id", "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		queryParam("service_name", "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько", openapi3.NewStringSchema(), "Yandex Plus"),
+		queryParam("from_date", "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-01-01"),
+		queryParam("to_date", "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "12-2025"),
+	}
+}
+
+// activeOnParam, onlyActiveParam and includeEndedParam are honoured by the
+// list and the total only.
+func activeOnParam() *openapi3.Parameter {
+	return queryParam("active_on", "Только подписки, активные в этот момент (RFC3339, YYYY-MM-DD или MM-YYYY); нельзя сочетать с from_date и to_date", openapi3.NewStringSchema(), "2025-03-01")
+}
+
+func onlyActiveParam() *openapi3.Parameter {
+	return queryParam("only_active", "Скрыть подписки, у которых end_date уже прошла; бессрочные и еще не начавшиеся остаются. По умолчанию закончившиеся подписки включены", openapi3.NewBoolSchema().WithDefault(false), true)
+}
+
+func includeEndedParam() *openapi3.Parameter {
+	return queryParam("include_ended", "Обратный к only_active: false скрывает закончившиеся подписки; противоречащие друг другу значения дают 400", openapi3.NewBoolSchema().WithDefault(true), false)
+}
+
+var operations = []operation{
+	{
+		method: http.MethodPost, path: "/subscriptions", tag: "Subscriptions",
+		summary: "Создать подписку",
+		params: []*openapi3.Parameter{
+			queryParam("idempotent", "Вернуть существующую подписку пользователя на этот сервис вместо создания новой", openapi3.NewBoolSchema(), false),
+			queryParam("allow_duplicate", "Создать подписку, даже если у пользователя уже есть подписка на этот сервис с пересекающимся периодом", openapi3.NewBoolSchema(), false),
+		},
+		body: jsonBody("service.CreateSubscriptionRequest", "Данные подписки"),
+		responses: []response{
+			ok("Подписка уже существует (idempotent=true)", "model.Subscription"),
+			{http.StatusCreated, "Подписка успешно создана", "model.Subscription", false, ""},
+			invalidInput, duplicate, tooLarge, wrongMediaType, invalidFields, limitReached, serverError,
+		},
+		location: "URL созданной подписки, /subscriptions/{id}",
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions", tag: "Subscriptions",
+		summary: "Список подписок",
+		params: append(filterParams(),
+			activeOnParam(),
+			onlyActiveParam(),
+			includeEndedParam(),
+			queryParam("shared_with_me", "Включить подписки, к которым пользователю user_id открыт доступ", openapi3.NewBoolSchema(), false),
+			queryParam("pinned_only", "Только подписки, закрепленные пользователем user_id (требует user_id)", openapi3.NewBoolSchema(), false),
+			queryParam("q", "Часть названия сервиса, без учета регистра; service_name по-прежнему ищет точное совпадение", openapi3.NewStringSchema(), "netfl"),
+			queryParam("limit", "Размер страницы; больше максимума (500 по умолчанию) уменьшается до него с заголовком X-Max-Page-Size-Applied", openapi3.NewIntegerSchema().WithMin(1).WithDefault(50), 50),
+			queryParam("offset", "Сколько подписок пропустить", openapi3.NewIntegerSchema().WithMin(0).WithDefault(0), 0),
+			queryParam("envelope", "Вернуть вместо массива model.SubscriptionListResponse: страницу, общее количество совпадений (count), limit и offset", openapi3.NewBoolSchema().WithDefault(false), true),
+		),
+		responses: []response{okList("Страница подписок, подходящих под фильтр, по дате начала; общее количество в X-Total-Count", "model.Subscription"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}", tag: "Subscriptions",
+		summary:   "Получить подписку по ID",
+		params:    []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		responses: []response{ok("Подписка", "model.Subscription"), invalidID, notFound, serverError},
+		etag:      "Версия подписки, ее можно передать в If-Match при изменении",
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/batch-get", tag: "Subscriptions",
+		summary: "Получить подписки по списку ID",
+		body:    jsonBody("service.BatchGetRequest", "Список ID подписок, не больше 200"),
+		responses: []response{
+			ok("Найденные подписки в порядке ids и ID, которых нет", "model.BatchGetResult"),
+			invalidInput, tooLarge, wrongMediaType, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodPut, path: "/subscriptions/{id}", tag: "Subscriptions",
+		summary: "Создать или обновить подписку",
+		params: []*openapi3.Parameter{
+			pathParam("id", "ID подписки; если у тенанта ее нет, она создается под этим ID"),
+			queryParam("allow_duplicate", "При создании не проверять пересечение с активной подпиской на этот сервис", openapi3.NewBoolSchema(), false),
+			headerParam("If-Match", "ETag подписки, то есть ее версия в кавычках; заменяет поле version", `"3"`),
+		},
+		body: jsonBody("service.UpdateSubscriptionRequest", "Новые данные подписки; для существующей подписки version или If-Match обязательны"),
+		responses: []response{
+			ok("Подписка успешно обновлена", "model.Subscription"),
+			{http.StatusCreated, "Подписки не было, она создана", "model.Subscription", false, ""},
+			invalidInput, duplicate, staleVersion, tooLarge, wrongMediaType, invalidFields, limitReached, serverError,
+		},
+		location: "URL созданной подписки, /subscriptions/{id}",
+		etag:     "Новая версия подписки",
+	},
+	{
+		method: http.MethodPatch, path: "/subscriptions/{id}/price", tag: "Subscriptions",
+		summary: "Изменить цену подписки",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		body:    jsonBody("service.UpdatePriceRequest", "Новая цена; остальные поля подписки не меняются"),
+		responses: []response{
+			ok("Цена изменена", "model.Subscription"),
+			invalidInput, notFound, tooLarge, wrongMediaType, invalidFields, serverError,
+		},
+		etag: "Новая версия подписки",
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}", tag: "Subscriptions",
+		summary: "Удалить подписку",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		responses: []response{
+			{status: http.StatusNoContent, description: "Подписка успешно удалена"},
+			invalidID, notFound, conflict, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/total", tag: "Subscriptions",
+		summary: "Суммарная стоимость подписок",
+		params: append(filterParams(),
+			activeOnParam(),
+			onlyActiveParam(),
+			includeEndedParam(),
+			queryParam("mode", "prorated: цена за каждый месяц подписки внутри периода, flat: цена каждой подписки один раз",
+				openapi3.NewStringSchema().WithEnum(string(model.TotalProrated), string(model.TotalFlat)).WithDefault(string(model.TotalProrated)),
+				string(model.TotalProrated)),
+			queryParam("source", "prices: цены подписок, billing_history: платежи из истории оплат, проведенные за период; mode при этом не указывается",
+				openapi3.NewStringSchema().WithEnum(string(model.TotalFromPrices), string(model.TotalFromBillingHistory)).WithDefault(string(model.TotalFromPrices)),
+				string(model.TotalFromBillingHistory)),
+			queryParam("currency", "Валюта для пересчета (ISO 4217)", openapi3.NewStringSchema(), "USD"),
+		),
+		responses: []response{ok("Сумма", "model.TotalCostResponse"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/team-total", tag: "Subscriptions",
+		summary: "Расходы команды по пользователям",
+		params: []*openapi3.Parameter{
+			required(queryParam("user_id", "ID пользователя; повторите параметр или перечислите ID через запятую, до 100 участников", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+			queryParam("service_name", "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько", openapi3.NewStringSchema(), "Yandex Plus"),
+			queryParam("from_date", "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-01-01"),
+			queryParam("to_date", "Конец периода, по умолчанию текущий момент (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "12-2025"),
+		},
+		responses: []response{ok("Сумма каждого пользователя и общая", "model.TeamTotalCost"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/total/monthly", tag: "Subscriptions",
+		summary: "Расходы по месяцам",
+		params: []*openapi3.Parameter{
+			queryParam("user_id", "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			queryParam("service_name", "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько", openapi3.NewStringSchema(), "Yandex Plus"),
+			required(queryParam("from_date", "Первый месяц ряда (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "01-2025")),
+			queryParam("to_date", "Последний месяц ряда (RFC3339, YYYY-MM-DD или MM-YYYY), по умолчанию текущий", openapi3.NewStringSchema(), "12-2025"),
+		},
+		responses: []response{okList("Расходы за каждый месяц периода", "model.MonthlyCost"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/total/compare", tag: "Subscriptions",
+		summary: "Сравнение расходов за два периода",
+		params: []*openapi3.Parameter{
+			required(queryParam("period_a_from", "Начало периода A (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-01-01")),
+			required(queryParam("period_a_to", "Конец периода A (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-03-31")),
+			required(queryParam("period_b_from", "Начало периода B (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-04-01")),
+			required(queryParam("period_b_to", "Конец периода B (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-06-30")),
+			queryParam("user_id", "ID пользователя; без него считаются расходы всего тенанта", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		},
+		responses: []response{ok("Суммы за оба периода, разница и сервисы, расходы на которые изменились сильнее всего", "model.PeriodComparison"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/expiring-soon/by-service", tag: "Subscriptions",
+		summary: "Истекающие подписки по сервисам",
+		params: []*openapi3.Parameter{
+			queryParam("days", "Горизонт в днях (1-365)", openapi3.NewIntegerSchema().WithMin(1).WithMax(365).WithDefault(7), 7),
+			queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		},
+		responses: []response{okList("Сервисы с истекающими подписками", "model.ExpiringServiceSummary"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/upcoming", tag: "Subscriptions",
+		summary: "Ближайшие продления",
+		params: []*openapi3.Parameter{
+			queryParam("days", "Горизонт в днях (1-365)", openapi3.NewIntegerSchema().WithMin(1).WithMax(365).WithDefault(14), 14),
+			queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		},
+		responses: []response{okList("Подписки с next_renewal_date, по возрастанию даты продления", "model.Subscription"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/rank", tag: "Subscriptions",
+		summary: "Рейтинг подписок по стоимости дня",
+		params: []*openapi3.Parameter{
+			queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			queryParam("sort", "По чему ранжировать", openapi3.NewStringSchema().WithEnum("cost_per_day").WithDefault("cost_per_day"), "cost_per_day"),
+			queryParam("order", "asc - сначала самые выгодные, desc - самые дорогие", openapi3.NewStringSchema().WithEnum("asc", "desc").WithDefault("asc"), "asc"),
+		},
+		responses: []response{okList("Подписки по стоимости дня; те, чью стоимость не посчитать, в конце без cost_per_day и rank", "model.RankedSubscription"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/export", tag: "Subscriptions",
+		summary: "Экспорт продлений в iCalendar",
+		params: []*openapi3.Parameter{
+			required(queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+			queryParam("format", "Формат файла", openapi3.NewStringSchema().WithEnum("ical").WithDefault("ical"), "ical"),
+		},
+		responses: []response{
+			{http.StatusOK, "Файл subscriptions.ics: событие на весь день в дату окончания каждой подписки, подписки без end_date пропускаются", textBody, false, "text/calendar"},
+			invalidQuery, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/expired", tag: "Subscriptions",
+		summary:   "Истекшие подписки",
+		params:    filterParams(),
+		responses: []response{okList("Истекшие подписки", "model.ExpiredSubscription"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/expired/cleanup", tag: "Subscriptions",
+		summary: "Очистить истекшие подписки",
+		params: []*openapi3.Parameter{
+			required(queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+		},
+		responses: []response{ok("Количество удаленных подписок", "model.CleanupResponse"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/stream", tag: "Subscriptions",
+		summary: "Поток изменений подписок",
+		responses: []response{
+			{http.StatusOK, "Server-Sent Events, по одному событию на изменение подписки, изменение ее цены (price_changed) или превышение лимита расходов", "model.SubscriptionEvent", false, "text/event-stream"},
+			{http.StatusServiceUnavailable, "Поток изменений не настроен", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/ws", tag: "Subscriptions",
+		summary: "Изменения подписок через WebSocket",
+		responses: []response{
+			{http.StatusSwitchingProtocols, "Соединение переключено на WebSocket: события /subscriptions/stream приходят текстовыми фреймами с JSON. Сообщение {\"user_id\":\"<uuid>\"} оставляет события одного пользователя, {\"user_id\":null} - снова все. Ping каждые 54 секунды, соединение без фреймов 60 секунд закрывается; при остановке сервера - код 1001", "model.SubscriptionEvent", false, ""},
+			{http.StatusBadRequest, "Запрос не является WebSocket handshake", "model.ErrorResponse", false, ""},
+			{http.StatusForbidden, "Origin не совпадает с Host", "model.ErrorResponse", false, ""},
+			{http.StatusServiceUnavailable, "Поток изменений не настроен или сервер останавливается", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/summary/by-cycle", tag: "Subscriptions",
+		summary:   "Расходы по периодам оплаты",
+		params:    filterParams(),
+		responses: []response{okList("Расходы по каждому периоду оплаты", "model.BillingCycleSummary"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/stats", tag: "Subscriptions",
+		summary:   "Статистика цен",
+		params:    filterParams(),
+		responses: []response{ok("Количество, минимальная, максимальная, средняя и медианная цена", "model.PriceStats"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/project", tag: "Subscriptions",
+		summary: "Прогноз расходов по месяцам",
+		params: []*openapi3.Parameter{
+			required(queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+			queryParam("months", "На сколько месяцев вперед (1-36)", openapi3.NewIntegerSchema().WithMin(1).WithMax(36).WithDefault(12), 12),
+		},
+		responses: []response{okList("Ожидаемые расходы на каждый месяц, начиная со следующего", "model.ProjectedCost"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/simulate", tag: "Subscriptions",
+		summary: "Расчет расходов \"что если\"",
+		body:    jsonBody("service.SimulateCostRequest", "Пользователь, подписки, которые нужно добавить (не больше 50), и ID подписок, которые нужно исключить (не больше 50); ничего не сохраняется"),
+		responses: []response{
+			ok("Стоимость подписок в текущем месяце сейчас и после изменений, как /subscriptions/total с mode=prorated", "model.CostSimulation"),
+			invalidInput, tooLarge, wrongMediaType, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/import", tag: "Subscriptions",
+		summary: "Импорт подписок из CSV",
+		params: []*openapi3.Parameter{
+			queryParam("allow_duplicate", "Сохранять строки, пересекающиеся с активной подпиской пользователя на тот же сервис", openapi3.NewBoolSchema(), false),
+		},
+		body: openapi3.NewRequestBody().
+			WithRequired(true).
+			WithDescription("CSV-файл с заголовком service_name,price,user_id,start_date,end_date").
+			WithFormDataSchema(openapi3.NewObjectSchema().
+				WithProperty("file", openapi3.NewStringSchema().WithFormat("binary")).
+				WithRequired([]string{"file"})),
+		responses: []response{
+			ok("Результат импорта", "importer.Result"),
+			{http.StatusBadRequest, "Нет файла или некорректный CSV", "model.ErrorInput", false, ""},
+			conflict, tooLarge, wrongMediaType, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/services", tag: "Services",
+		summary: "Список сервисов",
+		params: []*openapi3.Parameter{
+			queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		},
+		responses: []response{okList("Сервисы и количество подписок", "model.ServiceSummary"), invalidQuery, serverError},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/shares", tag: "Shares",
+		summary: "Поделиться подпиской",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		body:    jsonBody("service.ShareSubscriptionRequest", "Пользователь и уровень доступа"),
+		responses: []response{
+			{http.StatusCreated, "Доступ открыт", "model.ShareEntry", false, ""},
+			invalidInput, notFound, serverError,
+		},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/create-and-share", tag: "Shares",
+		summary: "Создать подписку и поделиться ей",
+		params: []*openapi3.Parameter{
+			queryParam("allow_duplicate", "Создать подписку, даже если у пользователя уже есть подписка на этот сервис с пересекающимся периодом", openapi3.NewBoolSchema(), false),
+		},
+		body: jsonBody("service.CreateAndShareRequest", "Данные подписки и пользователи с уровнем доступа"),
+		responses: []response{
+			{http.StatusCreated, "Подписка создана и доступы открыты", "model.SharedSubscription", false, ""},
+			invalidInput, duplicate, tooLarge, wrongMediaType, invalidFields, limitReached, serverError,
+		},
+		location: "URL созданной подписки, /subscriptions/{id}",
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/shares", tag: "Shares",
+		summary:   "Список доступов к подписке",
+		params:    []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		responses: []response{okList("Доступы к подписке", "model.ShareEntry"), invalidID, serverError},
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}/shares/{user_id}", tag: "Shares",
+		summary: "Закрыть доступ к подписке",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки"), pathParam("user_id", "ID пользователя")},
+		responses: []response{
+			{status: http.StatusNoContent, description: "Доступ закрыт"},
+			invalidID, notFound, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/anomaly", tag: "Subscriptions",
+		summary:   "Аномалия цены подписки",
+		params:    []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		responses: []response{ok("Сравнение текущей цены с прежними", "model.PriceAnomalyReport"), invalidID, notFound, serverError},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/payments", tag: "Subscriptions",
+		summary: "Записать платеж",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		body:    jsonBody("service.RecordPaymentRequest", "Сумма и валюта платежа; валюта по умолчанию и единственная допустимая - валюта цен"),
+		responses: []response{
+			{http.StatusCreated, "Платеж записан", "model.Payment", false, ""},
+			invalidInput, notFound, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/payments", tag: "Subscriptions",
+		summary:   "История оплат подписки",
+		params:    []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		responses: []response{okList("Платежи, начиная с самого раннего", "model.Payment"), invalidID, serverError},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/pin", tag: "Pins",
+		summary: "Закрепить подписку",
+		params: []*openapi3.Parameter{
+			pathParam("id", "ID подписки"),
+			required(queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+		},
+		responses: []response{ok("Подписка закреплена", "model.Pin"), invalidQuery, notFound, serverError},
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}/pin", tag: "Pins",
+		summary: "Открепить подписку",
+		params: []*openapi3.Parameter{
+			pathParam("id", "ID подписки"),
+			required(queryParam("user_id", "ID пользователя", openapi3.NewUUIDSchema(), "60601fee-2bf1-4721-ae6f-7636e79a0cba")),
+		},
+		responses: []response{
+			{status: http.StatusNoContent, description: "Подписка откреплена"},
+			invalidQuery, notFound, serverError,
+		},
+	},
+	{
+		method: http.MethodPost, path: "/subscriptions/{id}/reminders", tag: "Reminders",
+		summary: "Создать напоминание",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		body:    jsonBody("service.CreateReminderRequest", "За сколько дней напомнить (0-365)"),
+		responses: []response{
+			{http.StatusCreated, "Напоминание создано", "model.Reminder", false, ""},
+			invalidInput, notFound, conflict, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/subscriptions/{id}/reminders", tag: "Reminders",
+		summary:   "Список напоминаний",
+		params:    []*openapi3.Parameter{pathParam("id", "ID подписки")},
+		responses: []response{okList("Напоминания подписки", "model.Reminder"), invalidID, serverError},
+	},
+	{
+		method: http.MethodPut, path: "/subscriptions/{id}/reminders/{reminder_id}", tag: "Reminders",
+		summary: "Обновить напоминание",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки"), pathParam("reminder_id", "ID напоминания")},
+		body:    jsonBody("service.UpdateReminderRequest", "За сколько дней напомнить (0-365)"),
+		responses: []response{
+			ok("Напоминание обновлено", "model.Reminder"),
+			invalidInput, notFound, conflict, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodDelete, path: "/subscriptions/{id}/reminders/{reminder_id}", tag: "Reminders",
+		summary: "Удалить напоминание",
+		params:  []*openapi3.Parameter{pathParam("id", "ID подписки"), pathParam("reminder_id", "ID напоминания")},
+		responses: []response{
+			{status: http.StatusNoContent, description: "Напоминание удалено"},
+			invalidID, notFound, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/users/{user_id}/summary", tag: "Users",
+		summary:   "Сводка по подпискам пользователя",
+		params:    []*openapi3.Parameter{pathParam("user_id", "ID пользователя")},
+		responses: []response{ok("Сводка", "model.UserSummary"), invalidID, serverError},
+	},
+	{
+		method: http.MethodGet, path: "/users/{user_id}/subscriptions/top", tag: "Users",
+		summary: "Самые дорогие сервисы пользователя",
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "ID пользователя"),
+			queryParam("limit", "Сколько сервисов вернуть (1-50)", openapi3.NewIntegerSchema().WithMin(1).WithMax(50).WithDefault(5), 5),
+			queryParam("from_date", "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-01-01"),
+			queryParam("to_date", "Конец периода, по умолчанию текущий месяц (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "12-2025"),
+		},
+		responses: []response{
+			ok("Сервисы по убыванию расходов и остальные в other", "model.TopServices"),
+			{http.StatusBadRequest, "Неверный ID пользователя, limit или период", "model.ValidationErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/users/{user_id}/subscriptions/forecast", tag: "Users",
+		summary: "Прогноз расходов пользователя",
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "ID пользователя"),
+			queryParam("months", "На сколько месяцев вперед (1-36)", openapi3.NewIntegerSchema().WithMin(1).WithMax(36).WithDefault(12), 12),
+		},
+		responses: []response{
+			ok("Расходы по месяцам, начиная с текущего, и их сумма", "model.Forecast"),
+			{http.StatusBadRequest, "Неверный ID пользователя или months", "model.ValidationErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodPut, path: "/users/{user_id}/spending-limit", tag: "Users",
+		summary: "Задать лимит расходов",
+		params:  []*openapi3.Parameter{pathParam("user_id", "ID пользователя")},
+		body:    jsonBody("service.SetSpendingLimitRequest", "Лимит, валюта (по умолчанию валюта цен) и порог предупреждения в процентах (1-100, по умолчанию 80)"),
+		responses: []response{
+			ok("Лимит изменен", "model.SpendingLimit"),
+			{http.StatusCreated, "Лимита не было, он создан", "model.SpendingLimit", false, ""},
+			invalidInput, invalidFields, serverError,
+		},
+		location: "URL лимита, /users/{user_id}/spending-limit",
+	},
+	{
+		method: http.MethodGet, path: "/users/{user_id}/spending-limit", tag: "Users",
+		summary:   "Лимит расходов",
+		params:    []*openapi3.Parameter{pathParam("user_id", "ID пользователя")},
+		responses: []response{ok("Лимит расходов пользователя", "model.SpendingLimit"), invalidID, notFound, serverError},
+	},
+	{
+		method: http.MethodDelete, path: "/users/{user_id}/spending-limit", tag: "Users",
+		summary: "Удалить лимит расходов",
+		params:  []*openapi3.Parameter{pathParam("user_id", "ID пользователя")},
+		responses: []response{
+			{status: http.StatusNoContent, description: "Лимит удален"},
+			invalidID, notFound, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/users/{user_id}/spending-limit/status", tag: "Users",
+		summary:   "Состояние лимита расходов",
+		params:    []*openapi3.Parameter{pathParam("user_id", "ID пользователя")},
+		responses: []response{ok("Расходы на активные сегодня подписки в валюте лимита; alert при достижении порога", "model.SpendingLimitStatus"), invalidID, notFound, serverError},
+	},
+	{
+		method: http.MethodPost, path: "/catalog/services", tag: "Catalog",
+		summary: "Добавить сервис в каталог",
+		body:    jsonBody("service.CatalogEntryRequest", "Название (уникально в каталоге), описание, категория, логотип и цена по умолчанию"),
+		responses: []response{
+			{http.StatusCreated, "Сервис добавлен", "model.CatalogEntry", false, ""},
+			invalidInput, conflict, invalidFields, serverError,
+		},
+		location: "URL сервиса, /catalog/services/{id}",
+	},
+	{
+		method: http.MethodGet, path: "/catalog/services", tag: "Catalog",
+		summary: "Каталог сервисов",
+		params: []*openapi3.Parameter{
+			queryParam("category", "Категория, без учета регистра", openapi3.NewStringSchema(), "streaming"),
+		},
+		responses: []response{okList("Сервисы каталога по алфавиту", "model.CatalogEntry"), serverError},
+	},
+	{
+		method: http.MethodGet, path: "/catalog/services/{id}", tag: "Catalog",
+		summary:   "Сервис каталога",
+		params:    []*openapi3.Parameter{pathParam("id", "ID сервиса")},
+		responses: []response{ok("Сервис каталога", "model.CatalogEntry"), invalidID, notFound, serverError},
+	},
+	{
+		method: http.MethodPut, path: "/catalog/services/{id}", tag: "Catalog",
+		summary: "Изменить сервис каталога",
+		params:  []*openapi3.Parameter{pathParam("id", "ID сервиса")},
+		body:    jsonBody("service.CatalogEntryRequest", "Все поля сервиса; подписки, уже созданные из него, не меняются"),
+		responses: []response{
+			ok("Сервис изменен", "model.CatalogEntry"),
+			invalidInput, notFound, conflict, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodDelete, path: "/catalog/services/{id}", tag: "Catalog",
+		summary: "Удалить сервис из каталога",
+		params:  []*openapi3.Parameter{pathParam("id", "ID сервиса")},
+		responses: []response{
+			{status: http.StatusNoContent, description: "Сервис удален, созданные из него подписки теряют ссылку на него"},
+			invalidID, notFound, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/users/{user_id}/export", tag: "Users",
+		summary: "Выгрузка данных пользователя",
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "ID пользователя; должен совпадать с вызывающим пользователем"),
+			headerParam("X-User-ID", "ID вызывающего пользователя, если тенант передается заголовком; с JWT берется из claim sub", "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			userExportFormat,
+		},
+		responses: userExportResponses(
+			response{http.StatusBadRequest, "Неверный user_id или неизвестный format", "model.ValidationErrorResponse", false, ""},
+			response{http.StatusForbidden, "Выгрузка чужих данных", "model.ErrorResponse", false, ""},
+		),
+	},
+	{
+		method: http.MethodPost, path: "/users/{user_id}/anonymize", tag: "Users",
+		summary: "Обезличить пользователя",
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "ID пользователя; должен совпадать с вызывающим пользователем"),
+			headerParam("X-User-ID", "ID вызывающего пользователя, если тенант передается заголовком; с JWT берется из claim sub", "60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+			irreversibleParam,
+		},
+		responses: []response{
+			anonymized,
+			{http.StatusBadRequest, "Неверный user_id или irreversible", "model.ValidationErrorResponse", false, ""},
+			{http.StatusForbidden, "Обезличивание другого пользователя", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/admin/subscriptions/creation-rate", tag: "Admin",
+		summary: "Скорость создания подписок",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			required(queryParam("from", "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-01-01")),
+			required(queryParam("to", "Конец периода, не включается (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-02-01")),
+		},
+		responses: []response{
+			ok("Количество созданных подписок", "model.CreationRate"),
+			{http.StatusBadRequest, "Не указан или неверный период", "model.ValidationErrorResponse", false, ""},
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/admin/subscriptions/total/by-user", tag: "Admin",
+		summary: "Расходы по пользователям",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			queryParam("service_name", "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько", openapi3.NewStringSchema(), "Yandex Plus"),
+			queryParam("from_date", "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "2025-01-01"),
+			queryParam("to_date", "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)", openapi3.NewStringSchema(), "12-2025"),
+			queryParam("limit", "Размер страницы (1-500)", openapi3.NewIntegerSchema().WithMin(1).WithMax(500).WithDefault(50), 50),
+			queryParam("offset", "Сколько пользователей пропустить", openapi3.NewIntegerSchema().WithMin(0).WithDefault(0), 0),
+		},
+		responses: []response{
+			okList("Пользователи по убыванию расходов", "model.UserCost"),
+			invalidQuery,
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodPost, path: "/admin/services/{name}/price", tag: "Admin",
+		summary: "Изменить цену сервиса (администратор)",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			openapi3.NewPathParameter("name").
+				WithDescription("Название сервиса (без учета регистра и пробелов по краям)").
+				WithSchema(openapi3.NewStringSchema()),
+			queryParam("tenant_id", "Изменить цену только в этом тенанте; без него меняются подписки всех тенантов", openapi3.NewUUIDSchema(), "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d"),
+		},
+		body: jsonBody("service.UpdateServicePriceRequest", "new_price либо percent_change; active_only ограничивает изменение подписками, активными сегодня, а effective_date записывает в историю цен дату, с которой действует новая цена"),
+		responses: []response{
+			ok("Количество подписок с измененной ценой и их сумма до и после изменения", "model.ServicePriceUpdateResult"),
+			{http.StatusBadRequest, "Неверный tenant_id или формат данных", "model.ValidationErrorResponse", false, ""},
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			tooLarge, wrongMediaType,
+			{http.StatusUnprocessableEntity, "Не указаны или указаны оба new_price и percent_change, неверная цена или процент, effective_date в будущем", "model.ValidationErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodPatch, path: "/admin/subscriptions/bulk-price", tag: "Admin",
+		summary: "Изменить цену всех подписок тенанта на сервис",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			required(queryParam("service_name", "Название сервиса (без учета регистра и пробелов по краям)", openapi3.NewStringSchema(), "Netflix")),
+			required(queryParam("tenant_id", "ID тенанта", openapi3.NewUUIDSchema(), "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d")),
+		},
+		body: jsonBody("service.BulkUpdatePriceRequest", "Новая цена для всех подписок тенанта на сервис; по каждой измененной подписке публикуется событие price_changed"),
+		responses: []response{
+			ok("Количество подписок с измененной ценой", "model.BulkUpdatePriceResponse"),
+			invalidQuery, invalidInput,
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			tooLarge, wrongMediaType, invalidFields, serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/admin/users/{user_id}/export", tag: "Admin",
+		summary: "Выгрузка данных пользователя администратором",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "ID пользователя"),
+			required(queryParam("tenant_id", "ID тенанта пользователя", openapi3.NewUUIDSchema(), "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d")),
+			userExportFormat,
+		},
+		responses: userExportResponses(
+			response{http.StatusBadRequest, "Неверный user_id, нет tenant_id или неизвестный format", "model.ValidationErrorResponse", false, ""},
+			response{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+		),
+	},
+	{
+		method: http.MethodPost, path: "/admin/users/{user_id}/anonymize", tag: "Admin",
+		summary: "Обезличить пользователя (администратор)",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "ID пользователя"),
+			required(queryParam("tenant_id", "ID тенанта пользователя", openapi3.NewUUIDSchema(), "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d")),
+			irreversibleParam,
+		},
+		responses: []response{
+			anonymized,
+			{http.StatusBadRequest, "Неверный user_id или irreversible либо нет tenant_id", "model.ValidationErrorResponse", false, ""},
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/admin/users/{user_id}/anonymizations", tag: "Admin",
+		summary: "Анонимные ID пользователя",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			pathParam("user_id", "Исходный ID пользователя"),
+			required(queryParam("tenant_id", "ID тенанта пользователя", openapi3.NewUUIDSchema(), "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d")),
+		},
+		responses: []response{
+			okList("Анонимные ID пользователя от старых к новым; необратимые обезличивания не видны", "model.Anonymization"),
+			{http.StatusBadRequest, "Неверный user_id или нет tenant_id", "model.ValidationErrorResponse", false, ""},
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodPost, path: "/admin/users/{target_id}/merge", tag: "Admin",
+		summary: "Объединить пользователей",
+		admin:   true,
+		params: []*openapi3.Parameter{
+			pathParam("target_id", "ID пользователя, которому переходят подписки"),
+			required(queryParam("tenant_id", "ID тенанта пользователей", openapi3.NewUUIDSchema(), "0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d")),
+		},
+		body: jsonBody("model.MergeUsersRequest", "Дубликат, чьи подписки переходят к target_id, и нужно ли пропускать подписки, которые у цели уже есть"),
+		responses: []response{
+			ok("Сколько действующих подписок перенесено и сколько пропущено как дубликаты; повторный вызов возвращает нули", "model.MergeUsersResponse"),
+			{http.StatusBadRequest, "Неверный target_id или формат данных либо нет tenant_id", "model.ValidationErrorResponse", false, ""},
+			{http.StatusUnauthorized, "Нет или неверный admin-токен", "model.ErrorResponse", false, ""},
+			tooLarge, wrongMediaType,
+			{http.StatusUnprocessableEntity, "Не указан source_user_id или он совпадает с target_id", "model.ValidationErrorResponse", false, ""},
+			serverError,
+		},
+	},
+	{
+		method: http.MethodGet, path: "/live", tag: "Health",
+		summary:   "Liveness-проба",
+		public:    true,
+		responses: []response{ok("Процесс отвечает", "model.HealthResponse")},
+	},
+	{
+		method: http.MethodGet, path: "/ready", tag: "Health",
+		summary: "Readiness-проба",
+		public:  true,
+		responses: []response{
+			ok("База данных доступна", "model.HealthResponse"),
+			{http.StatusServiceUnavailable, "База данных недоступна", "model.HealthResponse", false, ""},
+		},
+	},
+}
@@ -0,0 +1,118 @@
+package docs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAML_GeneratesValidSpec(t *testing.T) {
+	ctx := context.Background()
+
+	out, err := YAML(ctx)
+	require.NoError(t, err)
+
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(out)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(ctx))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/subscriptions"},
+		{http.MethodGet, "/subscriptions"},
+		{http.MethodGet, "/subscriptions/{id}"},
+		{http.MethodPut, "/subscriptions/{id}"},
+		{http.MethodPatch, "/subscriptions/{id}/price"},
+		{http.MethodDelete, "/subscriptions/{id}"},
+		{http.MethodGet, "/subscriptions/total"},
+		{http.MethodGet, "/subscriptions/total/monthly"},
+		{http.MethodGet, "/subscriptions/total/compare"},
+		{http.MethodGet, "/subscriptions/stats"},
+		{http.MethodGet, "/subscriptions/upcoming"},
+		{http.MethodGet, "/ws"},
+		{http.MethodGet, "/subscriptions/rank"},
+		{http.MethodGet, "/subscriptions/export"},
+		{http.MethodGet, "/subscriptions/team-total"},
+		{http.MethodGet, "/subscriptions/project"},
+		{http.MethodPost, "/subscriptions/simulate"},
+		{http.MethodPost, "/subscriptions/create-and-share"},
+		{http.MethodPost, "/subscriptions/batch-get"},
+		{http.MethodGet, "/subscriptions/{id}/anomaly"},
+		{http.MethodPost, "/subscriptions/{id}/payments"},
+		{http.MethodGet, "/subscriptions/{id}/payments"},
+		{http.MethodGet, "/users/{user_id}/subscriptions/top"},
+		{http.MethodGet, "/users/{user_id}/subscriptions/forecast"},
+		{http.MethodPut, "/users/{user_id}/spending-limit"},
+		{http.MethodGet, "/users/{user_id}/spending-limit/status"},
+		{http.MethodGet, "/users/{user_id}/export"},
+		{http.MethodPost, "/users/{user_id}/anonymize"},
+		{http.MethodPost, "/catalog/services"},
+		{http.MethodGet, "/catalog/services"},
+		{http.MethodPut, "/catalog/services/{id}"},
+		{http.MethodDelete, "/catalog/services/{id}"},
+		{http.MethodGet, "/admin/subscriptions/creation-rate"},
+		{http.MethodGet, "/admin/subscriptions/total/by-user"},
+		{http.MethodPost, "/admin/services/{name}/price"},
+		{http.MethodPatch, "/admin/subscriptions/bulk-price"},
+		{http.MethodGet, "/admin/users/{user_id}/export"},
+		{http.MethodPost, "/admin/users/{user_id}/anonymize"},
+		{http.MethodGet, "/admin/users/{user_id}/anonymizations"},
+		{http.MethodPost, "/admin/users/{target_id}/merge"},
+	} {
+		item := doc.Paths.Find(route.path)
+		require.NotNil(t, item, route.path)
+		assert.NotNil(t, item.GetOperation(route.method), "%s %s", route.method, route.path)
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		assert.NotNil(t, schema.Value.Example, "schema %s has no example", name)
+	}
+	assert.Contains(t, doc.Components.Schemas, "model.SubscriptionFilter")
+}
+
+func TestOpenAPI3_SchemasFollowJSONTags(t *testing.T) {
+	doc, err := OpenAPI3()
+	require.NoError(t, err)
+
+	sub := doc.Components.Schemas["model.Subscription"].Value
+	assert.Equal(t, "uuid", sub.Properties["id"].Value.Format)
+	assert.True(t, sub.Properties["end_date"].Value.Nullable)
+	assert.Contains(t, sub.Required, "start_date")
+	assert.NotContains(t, sub.Required, "end_date")
+	assert.Len(t, sub.Properties["billing_cycle"].Value.Enum, 4)
+
+	update := doc.Components.Schemas["service.UpdateSubscriptionRequest"].Value
+	assert.NotContains(t, update.Properties, "ID", "json:\"-\" fields are not part of the body")
+	assert.Len(t, update.Properties, 8)
+
+	admin := doc.Paths.Find("/admin/subscriptions/creation-rate").Get
+	require.NotNil(t, admin.Security)
+	assert.Contains(t, (*admin.Security)[0], adminSecurity)
+
+	list := doc.Paths.Find("/subscriptions").Get
+	require.NotNil(t, list.Security)
+	assert.Contains(t, (*list.Security)[0], tenantSecurity)
+	assert.NotNil(t, list.Responses.Status(http.StatusUnauthorized))
+
+	live := doc.Paths.Find("/live").Get
+	assert.Nil(t, live.Security, "probes run without a tenant")
+}
+
+func TestOpenAPI3_PutDocumentsVersioning(t *testing.T) {
+	doc, err := OpenAPI3()
+	require.NoError(t, err)
+
+	put := doc.Paths.Find("/subscriptions/{id}").Put
+	assert.NotNil(t, put.Parameters.GetByInAndName("header", "If-Match"))
+	assert.Contains(t, put.Responses.Status(http.StatusOK).Value.Headers, "ETag")
+
+	conflict := put.Responses.Status(http.StatusConflict).Value.Content.Get("application/json").Schema.Value
+	require.Len(t, conflict.OneOf, 2)
+	assert.Equal(t, "#/components/schemas/model.DuplicateErrorResponse", conflict.OneOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/model.VersionConflictResponse", conflict.OneOf[1].Ref)
+}
@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+const (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+	pingInterval         = 90 * time.Second
+
+	// subscriberBuffer is how many events a slow subscriber may lag behind
+	// before further events are dropped for it.
+	subscriberBuffer = 16
+)
+
+// PGNotifyListener holds a dedicated LISTEN connection and fans decoded
+// model.SubscriptionEvent payloads out to every subscriber.
+type PGNotifyListener struct {
+	listener *pq.Listener
+	notify   <-chan *pq.Notification
+	log      *slog.Logger
+
+	mu     sync.Mutex
+	subs   map[chan model.SubscriptionEvent]struct{}
+	closed bool
+}
+
+// NewPGNotifyListener opens a connection outside the pool and starts
+// listening on channel. Call Run to start delivering events.
+func NewPGNotifyListener(connString, channel string, log *slog.Logger) (*PGNotifyListener, error) {
+	const op = "events.NewPGNotifyListener"
+
+	listener := pq.NewListener(connString, minReconnectInterval, maxReconnectInterval,
+		func(ev pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Warn("listener connection event", slog.Int("event", int(ev)), slog.String("error", err.Error()))
+			}
+		})
+
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	l := newListener(listener.Notify, log)
+	l.listener = listener
+	return l, nil
+}
+
+func newListener(notify <-chan *pq.Notification, log *slog.Logger) *PGNotifyListener {
+	return &PGNotifyListener{
+		notify: notify,
+		log:    log,
+		subs:   make(map[chan model.SubscriptionEvent]struct{}),
+	}
+}
+
+// Run delivers notifications until ctx is done, then closes every
+// subscriber channel and the underlying connection.
+func (l *PGNotifyListener) Run(ctx context.Context) {
+	defer l.close()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.notify:
+			if n == nil {
+				// pq sends nil after re-establishing the connection.
+				l.log.Warn("listener reconnected, subscription events may have been missed")
+				continue
+			}
+
+			var event struct {
+				model.SubscriptionEvent
+				TenantID uuid.UUID `json:"tenant_id"`
+			}
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				l.log.Warn("failed to decode subscription event",
+					slog.String("payload", n.Extra),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+			event.SubscriptionEvent.TenantID = event.TenantID
+			l.broadcast(event.SubscriptionEvent)
+		case <-ticker.C:
+			if l.listener != nil {
+				go l.listener.Ping()
+			}
+		}
+	}
+}
+
+// Subscribe registers a channel that receives events until ctx is done or
+// the listener stops, after which it is closed.
+func (l *PGNotifyListener) Subscribe(ctx context.Context) <-chan model.SubscriptionEvent {
+	ch := make(chan model.SubscriptionEvent, subscriberBuffer)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		close(ch)
+		return ch
+	}
+	l.subs[ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		l.unsubscribe(ch)
+	}()
+
+	return ch
+}
+
+func (l *PGNotifyListener) unsubscribe(ch chan model.SubscriptionEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.subs[ch]; ok {
+		delete(l.subs, ch)
+		close(ch)
+	}
+}
+
+func (l *PGNotifyListener) broadcast(event model.SubscriptionEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.subs {
+		select {
+		case ch <- event:
+		default:
+			l.log.Warn("subscriber is too slow, dropping subscription event",
+				slog.String("event", string(event.Event)),
+				slog.String("id", event.ID.String()),
+			)
+		}
+	}
+}
+
+func (l *PGNotifyListener) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	for ch := range l.subs {
+		delete(l.subs, ch)
+		close(ch)
+	}
+
+	if l.listener != nil {
+		if err := l.listener.Close(); err != nil {
+			l.log.Warn("failed to close listener", slog.String("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestListener(t *testing.T) (*PGNotifyListener, chan *pq.Notification, context.CancelFunc) {
+	t.Helper()
+	notify := make(chan *pq.Notification)
+	l := newListener(notify, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go l.Run(ctx)
+	t.Cleanup(cancel)
+
+	return l, notify, cancel
+}
+
+func receive(t *testing.T, ch <-chan model.SubscriptionEvent) (model.SubscriptionEvent, bool) {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		return ev, ok
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return model.SubscriptionEvent{}, false
+	}
+}
+
+func TestPGNotifyListener_FansOutDecodedEvents(t *testing.T) {
+	l, notify, _ := newTestListener(t)
+	first := l.Subscribe(context.Background())
+	second := l.Subscribe(context.Background())
+
+	id, tenantID := uuid.New(), uuid.New()
+	notify <- &pq.Notification{Channel: "subscriptions_changed",
+		Extra: `{"event":"updated","id":"` + id.String() + `","tenant_id":"` + tenantID.String() + `"}`}
+
+	want := model.SubscriptionEvent{Event: model.EventUpdated, ID: id, TenantID: tenantID}
+	for _, ch := range []<-chan model.SubscriptionEvent{first, second} {
+		ev, ok := receive(t, ch)
+		require.True(t, ok)
+		assert.Equal(t, want, ev)
+	}
+}
+
+func TestPGNotifyListener_DecodesPriceChange(t *testing.T) {
+	l, notify, _ := newTestListener(t)
+	ch := l.Subscribe(context.Background())
+
+	id, tenantID, userID := uuid.New(), uuid.New(), uuid.New()
+	notify <- &pq.Notification{Channel: "subscriptions_changed",
+		Extra: `{"event":"price_changed","id":"` + id.String() + `","tenant_id":"` + tenantID.String() +
+			`","user_id":"` + userID.String() + `","old_price":599,"new_price":799}`}
+
+	ev, ok := receive(t, ch)
+	require.True(t, ok)
+	assert.Equal(t, model.EventPriceChanged, ev.Event)
+	require.NotNil(t, ev.UserID)
+	assert.Equal(t, userID, *ev.UserID)
+	require.NotNil(t, ev.OldPrice)
+	require.NotNil(t, ev.NewPrice)
+	assert.Equal(t, 599, *ev.OldPrice)
+	assert.Equal(t, 799, *ev.NewPrice)
+}
+
+func TestPGNotifyListener_DecodesBreachWithoutID(t *testing.T) {
+	l, notify, _ := newTestListener(t)
+	ch := l.Subscribe(context.Background())
+
+	tenantID, userID := uuid.New(), uuid.New()
+	notify <- &pq.Notification{Channel: "subscriptions_changed",
+		Extra: `{"event":"spending_limit_breached","id":null,"tenant_id":"` + tenantID.String() +
+			`","user_id":"` + userID.String() + `"}`}
+
+	ev, ok := receive(t, ch)
+	require.True(t, ok)
+	assert.Equal(t, model.EventSpendingLimitBreached, ev.Event)
+	assert.Equal(t, uuid.Nil, ev.ID)
+	require.NotNil(t, ev.UserID)
+	assert.Equal(t, userID, *ev.UserID)
+
+	body, err := json.Marshal(ev)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event":"spending_limit_breached","user_id":"`+userID.String()+`"}`, string(body))
+}
+
+func TestPGNotifyListener_SkipsBadPayloadsAndReconnects(t *testing.T) {
+	l, notify, _ := newTestListener(t)
+	ch := l.Subscribe(context.Background())
+
+	id := uuid.New()
+	notify <- nil
+	notify <- &pq.Notification{Extra: "not json"}
+	notify <- &pq.Notification{Extra: `{"event":"created","id":"` + id.String() + `"}`}
+
+	ev, ok := receive(t, ch)
+	require.True(t, ok)
+	assert.Equal(t, id, ev.ID)
+}
+
+func TestPGNotifyListener_ClosesSubscribers(t *testing.T) {
+	l, _, cancel := newTestListener(t)
+
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	left := l.Subscribe(subCtx)
+	stayed := l.Subscribe(context.Background())
+
+	unsubscribe()
+	_, ok := receive(t, left)
+	assert.False(t, ok)
+
+	cancel()
+	_, ok = receive(t, stayed)
+	assert.False(t, ok)
+
+	_, ok = receive(t, l.Subscribe(context.Background()))
+	assert.False(t, ok)
+}
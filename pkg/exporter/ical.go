@@ -0,0 +1,96 @@
+// Package exporter writes subscriptions out in formats other programs read.
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// ICalContentType is the media type of WriteICal's output.
+const ICalContentType = "text/calendar; charset=utf-8"
+
+const (
+	icalProdID = "-//SubscriptionAggregator//Renewals//EN"
+	// icalLineOctets is the longest content line RFC 5545 allows before it
+	// has to be folded, not counting the CRLF.
+	icalLineOctets = 75
+)
+
+// WriteICal writes an iCalendar (RFC 5545) with one all-day VEVENT per
+// subscription on its end_date, the day it has to be renewed. Subscriptions
+// without an end_date have nothing to remind of and are skipped. Every line
+// goes to w as soon as it is encoded, so a large calendar is never held in
+// memory. stamp is the DTSTAMP of every event. It returns the number of
+// events written.
+func WriteICal(w io.Writer, subs []*model.Subscription, stamp time.Time) (int, error) {
+	iw := &icalWriter{w: w}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:" + icalProdID)
+	iw.line("CALSCALE:GREGORIAN")
+
+	events := 0
+	for _, sub := range subs {
+		if sub.EndDate == nil {
+			continue
+		}
+		iw.line("BEGIN:VEVENT")
+		iw.line("UID:" + sub.ID.String() + "@subscription-aggregator")
+		iw.line("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		iw.line("DTSTART;VALUE=DATE:" + sub.EndDate.UTC().Format("20060102"))
+		iw.line("SUMMARY:" + escapeText(sub.ServiceName))
+		iw.line("END:VEVENT")
+		events++
+	}
+
+	iw.line("END:VCALENDAR")
+	if iw.err != nil {
+		return events, fmt.Errorf("exporter.WriteICal: %w", iw.err)
+	}
+	return events, nil
+}
+
+// icalWriter keeps the first write error so that WriteICal can encode the
+// whole calendar and check once at the end.
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+// line writes one content line, folded after every 75 octets without
+// splitting a UTF-8 sequence.
+func (iw *icalWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := icalLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of a continuation line counts too.
+		limit = icalLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+
+	_, iw.err = io.WriteString(iw.w, b.String())
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText encodes a TEXT value as RFC 5545 section 3.3.11 requires.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
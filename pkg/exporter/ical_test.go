@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/emersion/go-ical"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// decodeICal decodes data with go-ical, an independent RFC 5545 parser,
+// and fails unless it is exactly one calendar. go-ical unfolds lines but
+// does not check how they were folded, so the line rules are checked
+// first: CRLF ends and at most 75 octets without splitting a UTF-8
+// sequence.
+func decodeICal(t *testing.T, data string) *ical.Calendar {
+	t.Helper()
+	require.True(t, strings.HasSuffix(data, "\r\n"), "the last line ends with CRLF")
+	for _, raw := range strings.Split(strings.TrimSuffix(data, "\r\n"), "\r\n") {
+		require.NotContains(t, raw, "\n", "bare LF")
+		require.LessOrEqual(t, len(raw), icalLineOctets, "line longer than 75 octets: %q", raw)
+		require.True(t, utf8.ValidString(raw), "fold split a UTF-8 sequence: %q", raw)
+	}
+
+	dec := ical.NewDecoder(strings.NewReader(data))
+	cal, err := dec.Decode()
+	require.NoError(t, err)
+	_, err = dec.Decode()
+	require.ErrorIs(t, err, io.EOF, "data after the calendar")
+
+	require.Equal(t, ical.CompCalendar, cal.Name)
+	version, err := cal.Props.Text(ical.PropVersion)
+	require.NoError(t, err)
+	require.Equal(t, "2.0", version)
+	prodID, err := cal.Props.Text(ical.PropProductID)
+	require.NoError(t, err)
+	require.Equal(t, icalProdID, prodID)
+	return cal
+}
+
+// eventText returns the unescaped TEXT value of the property name of ev.
+func eventText(t *testing.T, ev ical.Event, name string) string {
+	t.Helper()
+	text, err := ev.Props.Text(name)
+	require.NoError(t, err)
+	return text
+}
+
+func TestWriteICal_OneEventPerRenewal(t *testing.T) {
+	end1 := time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC)
+	end2 := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	subs := []*model.Subscription{
+		{ID: uuid.New(), ServiceName: "netflix", EndDate: &end1},
+		{ID: uuid.New(), ServiceName: "yandex plus"},
+		{ID: uuid.New(), ServiceName: "spotify", EndDate: &end2},
+	}
+	stamp := time.Date(2025, 8, 1, 10, 30, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	n, err := WriteICal(&buf, subs, stamp)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	events := decodeICal(t, buf.String()).Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, subs[0].ID.String()+"@subscription-aggregator", eventText(t, events[0], ical.PropUID))
+	assert.Equal(t, "netflix", eventText(t, events[0], ical.PropSummary))
+	dtstamp, err := events[0].Props.DateTime(ical.PropDateTimeStamp, nil)
+	require.NoError(t, err)
+	assert.Equal(t, stamp, dtstamp)
+	// An all-day event: DTSTART is a DATE, not a DATE-TIME.
+	assert.Equal(t, ical.ValueDate, events[0].Props.Get(ical.PropDateTimeStart).ValueType())
+	start, err := events[0].DateTimeStart(nil)
+	require.NoError(t, err)
+	assert.Equal(t, end1, start)
+	start, err = events[1].DateTimeStart(nil)
+	require.NoError(t, err)
+	assert.Equal(t, end2, start)
+}
+
+func TestWriteICal_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := WriteICal(&buf, nil, time.Now())
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, decodeICal(t, buf.String()).Events())
+}
+
+func TestWriteICal_EscapesAndFoldsSummary(t *testing.T) {
+	end := time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC)
+	// go-ical's TEXT decoding stops at an unescaped comma and rejects an
+	// unknown escape, so the round trip checks the escaping too.
+	name := strings.Repeat("кинопоиск; hd, семейный ", 5) + `c:\plans` + "\nend"
+
+	var buf bytes.Buffer
+	_, err := WriteICal(&buf, []*model.Subscription{{ID: uuid.New(), ServiceName: name, EndDate: &end}}, end)
+
+	require.NoError(t, err)
+	events := decodeICal(t, buf.String()).Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, name, eventText(t, events[0], ical.PropSummary))
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestWriteICal_WriteError(t *testing.T) {
+	_, err := WriteICal(failingWriter{}, nil, time.Now())
+
+	assert.ErrorContains(t, err, "broken pipe")
+}
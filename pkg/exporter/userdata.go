@@ -0,0 +1,241 @@
+package exporter
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// ZipContentType is the media type of UserDataZipWriter's output.
+const ZipContentType = "application/zip"
+
+// The sections of a user data export, in the order they are written.
+const (
+	sectionNone = iota
+	sectionSubscriptions
+	sectionPriceHistory
+	sectionAuditLog
+	sectionDone
+)
+
+// errSectionOrder is returned for a row of a section that has already
+// been closed.
+var errSectionOrder = errors.New("user data sections must be written in order")
+
+// sections moves an export through its sections. open is called for every
+// section from the current one up to the one asked for, so sections that
+// get no rows are still written.
+type sections struct {
+	current int
+	open    func(section int) error
+}
+
+func (s *sections) advance(section int) error {
+	if section < s.current {
+		return errSectionOrder
+	}
+	for s.current < section {
+		s.current++
+		if err := s.open(s.current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UserDataJSONWriter streams a user's data to w as the JSON document
+// model.UserDataExport describes, one row at a time. Rows must come
+// section by section: subscriptions, then price history, then the audit
+// log. Nothing is written before the first row or Close, and a document
+// that was not closed is cut short.
+type UserDataJSONWriter struct {
+	w        io.Writer
+	userID   uuid.UUID
+	at       time.Time
+	sections sections
+	rows     int
+}
+
+// NewUserDataJSONWriter returns a writer of the export of userID made at.
+func NewUserDataJSONWriter(w io.Writer, userID uuid.UUID, at time.Time) *UserDataJSONWriter {
+	jw := &UserDataJSONWriter{w: w, userID: userID, at: at}
+	jw.sections.open = jw.open
+	return jw
+}
+
+func (jw *UserDataJSONWriter) WriteSubscription(sub *model.Subscription) error {
+	return jw.row(sectionSubscriptions, sub)
+}
+
+func (jw *UserDataJSONWriter) WritePriceChange(change model.PriceChange) error {
+	return jw.row(sectionPriceHistory, change)
+}
+
+func (jw *UserDataJSONWriter) WriteAuditEntry(entry model.AuditEntry) error {
+	return jw.row(sectionAuditLog, entry)
+}
+
+// Close writes the sections that got no rows and ends the document.
+func (jw *UserDataJSONWriter) Close() error {
+	if err := jw.sections.advance(sectionDone); err != nil {
+		return fmt.Errorf("exporter.UserDataJSONWriter: %w", err)
+	}
+	return nil
+}
+
+func (jw *UserDataJSONWriter) row(section int, v any) error {
+	if err := jw.sections.advance(section); err != nil {
+		return fmt.Errorf("exporter.UserDataJSONWriter: %w", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("exporter.UserDataJSONWriter: %w", err)
+	}
+	if jw.rows > 0 {
+		data = append([]byte{','}, data...)
+	}
+	jw.rows++
+	if _, err := jw.w.Write(data); err != nil {
+		return fmt.Errorf("exporter.UserDataJSONWriter: %w", err)
+	}
+	return nil
+}
+
+func (jw *UserDataJSONWriter) open(section int) error {
+	var out []byte
+	switch section {
+	case sectionSubscriptions:
+		head, err := json.Marshal(struct {
+			UserID     uuid.UUID `json:"user_id"`
+			ExportedAt time.Time `json:"exported_at"`
+		}{jw.userID, jw.at})
+		if err != nil {
+			return err
+		}
+		// The array opens inside the object head ends.
+		out = append(head[:len(head)-1], `,"subscriptions":[`...)
+	case sectionPriceHistory:
+		out = []byte(`],"price_history":[`)
+	case sectionAuditLog:
+		out = []byte(`],"audit_log":[`)
+	case sectionDone:
+		out = []byte("]}\n")
+	}
+	jw.rows = 0
+	_, err := jw.w.Write(out)
+	return err
+}
+
+// UserDataZipWriter streams a user's data to w as a zip archive of three
+// CSV files with a header row each: subscriptions.csv, price_history.csv
+// and audit_log.csv. Times are RFC 3339 in UTC and absent values are empty
+// cells. Rows must come section by section like UserDataJSONWriter's, and
+// an archive that was not closed lacks its directory. w need not be
+// seekable.
+type UserDataZipWriter struct {
+	zw       *zip.Writer
+	cw       *csv.Writer
+	modified time.Time
+	sections sections
+}
+
+// NewUserDataZipWriter returns a writer of an export made at.
+func NewUserDataZipWriter(w io.Writer, at time.Time) *UserDataZipWriter {
+	zw := &UserDataZipWriter{zw: zip.NewWriter(w), modified: at.UTC()}
+	zw.sections.open = zw.open
+	return zw
+}
+
+func (zw *UserDataZipWriter) WriteSubscription(s *model.Subscription) error {
+	var endDate, catalogID string
+	if s.EndDate != nil {
+		endDate = formatTime(*s.EndDate)
+	}
+	if s.CatalogServiceID != nil {
+		catalogID = s.CatalogServiceID.String()
+	}
+	return zw.row(sectionSubscriptions, []string{
+		s.ID.String(),
+		s.ServiceName,
+		strconv.Itoa(s.Price),
+		string(s.BillingCycle),
+		formatTime(s.StartDate),
+		endDate,
+		catalogID,
+		strconv.Itoa(s.Version),
+		string(s.Metadata),
+	})
+}
+
+func (zw *UserDataZipWriter) WritePriceChange(c model.PriceChange) error {
+	return zw.row(sectionPriceHistory, []string{c.SubscriptionID.String(), strconv.Itoa(c.Price), formatTime(c.RecordedAt)})
+}
+
+func (zw *UserDataZipWriter) WriteAuditEntry(e model.AuditEntry) error {
+	return zw.row(sectionAuditLog, []string{strconv.FormatInt(e.ID, 10), e.Actor, e.Action, e.Status, e.RequestID, formatTime(e.CreatedAt)})
+}
+
+// Close writes the files that got no rows and the archive's directory.
+func (zw *UserDataZipWriter) Close() error {
+	if err := zw.sections.advance(sectionDone); err != nil {
+		return fmt.Errorf("exporter.UserDataZipWriter: %w", err)
+	}
+	if err := zw.zw.Close(); err != nil {
+		return fmt.Errorf("exporter.UserDataZipWriter: %w", err)
+	}
+	return nil
+}
+
+func (zw *UserDataZipWriter) row(section int, row []string) error {
+	if err := zw.sections.advance(section); err != nil {
+		return fmt.Errorf("exporter.UserDataZipWriter: %w", err)
+	}
+	if err := zw.cw.Write(row); err != nil {
+		return fmt.Errorf("exporter.UserDataZipWriter: %w", err)
+	}
+	return nil
+}
+
+// zipFiles are the CSV files of the sections by section, with their
+// header rows.
+var zipFiles = map[int]struct {
+	name   string
+	header []string
+}{
+	sectionSubscriptions: {"subscriptions.csv", []string{"id", "service_name", "price", "billing_cycle", "start_date", "end_date", "catalog_service_id", "version", "metadata"}},
+	sectionPriceHistory:  {"price_history.csv", []string{"subscription_id", "price", "recorded_at"}},
+	sectionAuditLog:      {"audit_log.csv", []string{"id", "actor", "action", "status", "request_id", "created_at"}},
+}
+
+// open ends the previous file and starts the one of section.
+func (zw *UserDataZipWriter) open(section int) error {
+	if zw.cw != nil {
+		zw.cw.Flush()
+		if err := zw.cw.Error(); err != nil {
+			return err
+		}
+	}
+	f, ok := zipFiles[section]
+	if !ok {
+		return nil
+	}
+	fw, err := zw.zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: zw.modified})
+	if err != nil {
+		return err
+	}
+	zw.cw = csv.NewWriter(fw)
+	return zw.cw.Write(f.header)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
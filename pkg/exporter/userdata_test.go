@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// readZipCSVs returns every CSV file in a zip archive by name.
+func readZipCSVs(t *testing.T, data []byte) map[string][][]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string][][]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		rows, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		require.NoError(t, err, f.Name)
+		files[f.Name] = rows
+	}
+	return files
+}
+
+// testExport is a user's data with a row in every section.
+func testExport() *model.UserDataExport {
+	subID, userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	end := time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2025, 8, 12, 10, 30, 0, 0, time.UTC)
+	return &model.UserDataExport{
+		UserID:     userID,
+		ExportedAt: at,
+		Subscriptions: []*model.Subscription{
+			{ID: subID, ServiceName: "yandex, plus", Price: 599, UserID: userID, BillingCycle: model.CycleMonthly, StartDate: end.AddDate(0, -1, 0), EndDate: &end, Version: 2, Metadata: json.RawMessage(`{"card":"*1234"}`)},
+		},
+		PriceHistory: []model.PriceChange{{SubscriptionID: subID, Price: 499, RecordedAt: end.AddDate(0, -1, 0)}},
+		AuditLog:     []model.AuditEntry{{ID: 17, UserID: userID, Actor: model.AuditActorAdmin, Action: model.AuditActionExport, Status: model.AuditStatusFailed, CreatedAt: at}},
+	}
+}
+
+// userDataWriter is what UserDataJSONWriter and UserDataZipWriter share.
+type userDataWriter interface {
+	WriteSubscription(*model.Subscription) error
+	WritePriceChange(model.PriceChange) error
+	WriteAuditEntry(model.AuditEntry) error
+	Close() error
+}
+
+// writeAll writes every row of export to w in order and closes it.
+func writeAll(t *testing.T, w userDataWriter, export *model.UserDataExport) {
+	t.Helper()
+	for _, sub := range export.Subscriptions {
+		require.NoError(t, w.WriteSubscription(sub))
+	}
+	for _, c := range export.PriceHistory {
+		require.NoError(t, w.WritePriceChange(c))
+	}
+	for _, e := range export.AuditLog {
+		require.NoError(t, w.WriteAuditEntry(e))
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestUserDataJSONWriter(t *testing.T) {
+	export := testExport()
+	export.PriceHistory = append(export.PriceHistory, model.PriceChange{SubscriptionID: uuid.New(), Price: 599, RecordedAt: export.ExportedAt})
+
+	var buf bytes.Buffer
+	writeAll(t, NewUserDataJSONWriter(&buf, export.UserID, export.ExportedAt), export)
+
+	var got model.UserDataExport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, export, &got)
+}
+
+func TestUserDataJSONWriter_EmptySections(t *testing.T) {
+	export := testExport()
+	var buf bytes.Buffer
+	w := NewUserDataJSONWriter(&buf, export.UserID, export.ExportedAt)
+	assert.Zero(t, buf.Len(), "nothing is written before the first row")
+
+	require.NoError(t, w.WriteAuditEntry(export.AuditLog[0]))
+	require.NoError(t, w.Close())
+
+	var got map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.JSONEq(t, `[]`, string(got["subscriptions"]))
+	assert.JSONEq(t, `[]`, string(got["price_history"]))
+	var trail []model.AuditEntry
+	require.NoError(t, json.Unmarshal(got["audit_log"], &trail))
+	assert.Equal(t, export.AuditLog, trail)
+}
+
+func TestUserDataJSONWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewUserDataJSONWriter(&buf, uuid.Nil, time.Date(2025, 8, 12, 10, 30, 0, 0, time.UTC)).Close())
+
+	assert.JSONEq(t, `{
+		"user_id": "00000000-0000-0000-0000-000000000000",
+		"exported_at": "2025-08-12T10:30:00Z",
+		"subscriptions": [],
+		"price_history": [],
+		"audit_log": []
+	}`, buf.String())
+}
+
+func TestUserDataWriters_SectionsInOrder(t *testing.T) {
+	export := testExport()
+	writers := map[string]userDataWriter{
+		"json": NewUserDataJSONWriter(io.Discard, export.UserID, export.ExportedAt),
+		"zip":  NewUserDataZipWriter(io.Discard, export.ExportedAt),
+	}
+
+	for name, w := range writers {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, w.WritePriceChange(export.PriceHistory[0]))
+
+			assert.ErrorIs(t, w.WriteSubscription(export.Subscriptions[0]), errSectionOrder)
+		})
+	}
+}
+
+func TestUserDataZipWriter(t *testing.T) {
+	export := testExport()
+	subID := export.Subscriptions[0].ID
+
+	var buf bytes.Buffer
+	writeAll(t, NewUserDataZipWriter(&buf, export.ExportedAt), export)
+
+	assert.Equal(t, map[string][][]string{
+		"subscriptions.csv": {
+			{"id", "service_name", "price", "billing_cycle", "start_date", "end_date", "catalog_service_id", "version", "metadata"},
+			{subID.String(), "yandex, plus", "599", "monthly", "2025-08-12T00:00:00Z", "2025-09-12T00:00:00Z", "", "2", `{"card":"*1234"}`},
+		},
+		"price_history.csv": {
+			{"subscription_id", "price", "recorded_at"},
+			{subID.String(), "499", "2025-08-12T00:00:00Z"},
+		},
+		"audit_log.csv": {
+			{"id", "actor", "action", "status", "request_id", "created_at"},
+			{"17", "admin", "user_data_export", "failed", "", "2025-08-12T10:30:00Z"},
+		},
+	}, readZipCSVs(t, buf.Bytes()))
+}
+
+func TestUserDataZipWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewUserDataZipWriter(&buf, time.Now()).Close())
+
+	files := readZipCSVs(t, buf.Bytes())
+	require.Len(t, files, 3)
+	for name, rows := range files {
+		assert.Len(t, rows, 1, "%s holds only its header", name)
+	}
+}
+
+func TestUserDataZipWriter_WriteError(t *testing.T) {
+	err := NewUserDataZipWriter(failingWriter{}, time.Now()).Close()
+
+	assert.ErrorContains(t, err, "broken pipe")
+}
+
+func TestUserDataJSONWriter_WriteError(t *testing.T) {
+	err := NewUserDataJSONWriter(failingWriter{}, uuid.New(), time.Now()).WriteSubscription(&model.Subscription{})
+
+	assert.ErrorContains(t, err, "broken pipe")
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// AdminHandler serves operational endpoints under /admin. Every request must
+// carry "Authorization: Bearer <token>" with the configured admin token.
+type AdminHandler struct {
+	responder
+	service service.SubscriptionService
+	token   string
+}
+
+// NewAdminHandler builds the handler; with an empty token every request is
+// rejected.
+func NewAdminHandler(service service.SubscriptionService, token string, log *slog.Logger) *AdminHandler {
+	return &AdminHandler{responder: responder{log: log}, service: service, token: token}
+}
+
+func (h *AdminHandler) RegisterRoutes(router *mux.Router) {
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(h.requireAdminToken(h.token))
+	admin.HandleFunc("/subscriptions/creation-rate", h.GetCreationRate).Methods("GET")
+	admin.HandleFunc("/subscriptions/total/by-user", h.GetTotalCostByUser).Methods("GET")
+	admin.HandleFunc("/services/{name}/price", h.UpdateServicePrice).Methods("POST")
+	admin.HandleFunc("/subscriptions/bulk-price", h.BulkUpdatePrice).Methods("PATCH")
+}
+
+// defaultUserCostLimit is the leaderboard page size when limit is not given.
+const defaultUserCostLimit = 50
+
+// requireAdminToken answers 401 unless the request presents token as
+// "Authorization: Bearer <token>"; with an empty token every request is
+// rejected.
+func (h *responder) requireAdminToken(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				h.respondWithError(w, http.StatusUnauthorized, "admin token required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetCreationRate возвращает количество подписок, созданных за период
+// @Summary Скорость создания подписок
+// @Description Считает подписки, созданные в интервале [from, to), включая удаленные, и среднее количество в день. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param from query string true "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to query string true "Конец периода, не включается (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-02-01)
+// @Success 200 {object} model.CreationRate
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "from": "2025-01-01T00:00:00Z",
+//	    "to": "2025-02-01T00:00:00Z",
+//	    "count": 310,
+//	    "per_day": 10
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Не указан или неверный период"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/subscriptions/creation-rate [get]
+func (h *AdminHandler) GetCreationRate(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	q.Require("from")
+	q.Require("to")
+	from, to := q.Date("from"), q.Date("to")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	rate, err := h.service.GetCreationRate(r.Context(), *from, *to)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, rate)
+}
+
+// GetTotalCostByUser возвращает рейтинг пользователей по расходам на подписки
+// @Summary Расходы по пользователям
+// @Description Суммирует расходы каждого пользователя по всем тенантам и сортирует по убыванию суммы. Сумма считается так же, как в /subscriptions/total с mode=prorated. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param offset query int false "Сколько пользователей пропустить" default(0)
+// @Success 200 {array} model.UserCost
+// @Header 200 {integer} X-Total-Count "Общее количество пользователей, подходящих под фильтр"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	        "subscription_count": 4,
+//	        "total": 14376
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса, from_date позже to_date, слишком большой период или неверные limit/offset"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/subscriptions/total/by-user [get]
+func (h *AdminHandler) GetTotalCostByUser(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	filter := model.SubscriptionFilter{
+		FromDate: q.Date("from_date"),
+		ToDate:   q.Date("to_date"),
+	}
+	setServiceNames(&filter, q)
+	limit := q.Int("limit", defaultUserCostLimit)
+	offset := q.Int("offset", 0)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	result, err := h.service.GetTotalCostByUser(r.Context(), filter, limit, offset)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, result.TotalCount)
+	h.render(w, r, http.StatusOK, result.Items)
+}
+
+// UpdateServicePrice меняет цену всех подписок на сервис во всех тенантах
+// @Summary Изменить цену сервиса (администратор)
+// @Description Когда провайдер меняет цены, в одной транзакции устанавливает новую цену всем подпискам на сервис: либо new_price, либо текущую цену, измененную на percent_change процентов (с округлением по каждой подписке, не меньше 1 и меньше максимальной цены). С tenant_id меняются только подписки этого тенанта, с active_only=true — только активные сегодня. effective_date записывает в историю цен, что новая цена действует с этой даты, но не раньше предыдущего изменения цены подписки. Подписки, цена которых не меняется, не учитываются; по каждой измененной в /subscriptions/stream публикуется событие price_changed. В ответе количество измененных подписок и их сумма до и после изменения. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Название сервиса (без учета регистра и пробелов по краям)" example(Netflix)
+// @Param tenant_id query string false "Изменить цену только в этом тенанте" example(0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d)
+// @Param input body service.UpdateServicePriceRequest true "Новая цена или изменение в процентах"
+// @Success 200 {object} model.ServicePriceUpdateResult
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "updated": 42,
+//	    "total_before": 41958,
+//	    "total_after": 50358
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный tenant_id или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 422 {object} model.ValidationErrorResponse "Не указаны или указаны оба new_price и percent_change, неверная цена или процент, effective_date в будущем"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/services/{name}/price [post]
+func (h *AdminHandler) UpdateServicePrice(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	tenantID := q.UUID("tenant_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	var req service.UpdateServicePriceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.ServiceName = mux.Vars(r)["name"]
+	req.TenantID = tenantID
+
+	result, err := h.service.UpdateServicePrice(r.Context(), req)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, result)
+}
+
+// BulkUpdatePrice меняет цену всех подписок тенанта на сервис
+// @Summary Изменить цену всех подписок тенанта на сервис
+// @Description Устанавливает новую цену всем подпискам тенанта tenant_id на сервис, например когда провайдер поднял цены, и возвращает количество измененных подписок. Подписки, у которых цена уже такая, не меняются. По каждой измененной подписке в /subscriptions/stream публикуется событие price_changed со старой и новой ценой. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param service_name query string true "Название сервиса" example(Netflix)
+// @Param tenant_id query string true "ID тенанта" example(0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d)
+// @Param input body service.BulkUpdatePriceRequest true "Новая цена"
+// @Success 200 {object} model.BulkUpdatePriceResponse
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "updated": 42
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Не указано название сервиса или tenant_id либо неверный формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 422 {object} model.ValidationErrorResponse "Цена не положительная или не меньше максимальной"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/subscriptions/bulk-price [patch]
+func (h *AdminHandler) BulkUpdatePrice(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	q.Require("service_name")
+	serviceName := q.get("service_name")
+	q.Require("tenant_id")
+	tenantID := q.UUID("tenant_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	var req service.BulkUpdatePriceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+
+	updated, err := h.service.BulkUpdatePrice(withAdminTenant(r, *tenantID).Context(), serviceName, req.NewPrice)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, model.BulkUpdatePriceResponse{Updated: updated})
+}
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+const testAdminToken = "s3cret"
+
+func newTestAdminRouter(token string) (*mux.Router, *MockSubscriptionService) {
+	mockSvc := &MockSubscriptionService{}
+	router := mux.NewRouter()
+	NewAdminHandler(mockSvc, token, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestGetCreationRate_Success(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mockSvc.On("GetCreationRate", mock.Anything, from, to).
+		Return(&model.CreationRate{From: from, To: to, Count: 310, PerDay: 10}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/subscriptions/creation-rate?from=2025-01-01&to=02-2025", nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"from":"2025-01-01T00:00:00Z","to":"2025-02-01T00:00:00Z","count":310,"per_day":10}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetCreationRate_RequiresToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		header     string
+	}{
+		{"missing header", testAdminToken, ""},
+		{"wrong token", testAdminToken, "Bearer nope"},
+		{"not bearer", testAdminToken, "Basic " + testAdminToken},
+		{"admin disabled", "", "Bearer "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestAdminRouter(tt.configured)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/admin/subscriptions/creation-rate?from=2025-01-01&to=2025-02-01", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, `Bearer realm="admin"`, w.Header().Get("WWW-Authenticate"))
+			assert.Empty(t, mockSvc.Calls)
+		})
+	}
+}
+
+func TestGetCreationRate_MissingRange(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/subscriptions/creation-rate?from=yesterday", nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	parseResponse(t, w, &resp)
+	assert.Contains(t, resp.Fields, "from")
+	assert.Equal(t, "is required", resp.Fields["to"])
+	assert.Empty(t, mockSvc.Calls)
+}
+
+func TestGetTotalCostByUser_Success(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := "netflix"
+
+	mockSvc.On("GetTotalCostByUser", mock.Anything, model.SubscriptionFilter{ServiceName: &service, FromDate: &from}, 10, 20).
+		Return(&model.UserCostResult{
+			Items:      []model.UserCost{{UserID: userID, SubscriptionCount: 4, Total: 14376}},
+			TotalCount: 21,
+		}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/subscriptions/total/by-user?service_name=Netflix&from_date=2025-01-01&limit=10&offset=20", nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "21", w.Header().Get("X-Total-Count"))
+	assert.JSONEq(t, `[{"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","subscription_count":4,"total":14376}]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTotalCostByUser_DefaultPage(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+
+	mockSvc.On("GetTotalCostByUser", mock.Anything, model.SubscriptionFilter{}, defaultUserCostLimit, 0).
+		Return(&model.UserCostResult{Items: []model.UserCost{}}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/subscriptions/total/by-user", nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTotalCostByUser_RequiresToken(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/subscriptions/total/by-user", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSvc.AssertNotCalled(t, "GetTotalCostByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func adminPriceRequest(path, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestUpdateServicePrice_Success(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+	tenantID := uuid.New()
+	pct := 10.0
+	effective := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	mockSvc.On("UpdateServicePrice", mock.Anything, service.UpdateServicePriceRequest{
+		ServiceName:   "Yandex Plus",
+		TenantID:      &tenantID,
+		PercentChange: &pct,
+		ActiveOnly:    true,
+		EffectiveDate: &effective,
+	}).Return(&model.ServicePriceUpdateResult{Updated: 2, TotalBefore: 1598, TotalAfter: 1758}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, adminPriceRequest("/admin/services/Yandex%20Plus/price?tenant_id="+tenantID.String(),
+		`{"percent_change":10,"active_only":true,"effective_date":"2025-09-01"}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"updated":2,"total_before":1598,"total_after":1758}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUpdateServicePrice_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		svcErr error
+		want   int
+	}{
+		{"bad tenant", "/admin/services/netflix/price?tenant_id=acme", `{"new_price":1199}`, nil, http.StatusBadRequest},
+		{"unknown field", "/admin/services/netflix/price", `{"price":1199}`, nil, http.StatusBadRequest},
+		{"bad date", "/admin/services/netflix/price", `{"new_price":1199,"effective_date":"soon"}`, nil, http.StatusBadRequest},
+		{"invalid", "/admin/services/netflix/price", `{}`, &model.ValidationError{Fields: map[string]string{"new_price": "either new_price or percent_change is required"}}, http.StatusUnprocessableEntity},
+		{"conflict", "/admin/services/netflix/price", `{"new_price":1199}`, fmt.Errorf("update: %w", model.ErrConflict), http.StatusConflict},
+		{"store failure", "/admin/services/netflix/price", `{"new_price":1199}`, errors.New("db error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestAdminRouter(testAdminToken)
+			if tt.svcErr != nil {
+				mockSvc.On("UpdateServicePrice", mock.Anything, mock.Anything).Return((*model.ServicePriceUpdateResult)(nil), tt.svcErr)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, adminPriceRequest(tt.path, tt.body))
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.svcErr == nil {
+				assert.Empty(t, mockSvc.Calls)
+			}
+		})
+	}
+}
+
+func TestBulkUpdatePrice(t *testing.T) {
+	invalid := &model.ValidationError{}
+	invalid.Add("price", "must be positive")
+	tenantID := uuid.New()
+	query := "?service_name=Netflix&tenant_id=" + tenantID.String()
+
+	tests := []struct {
+		name     string
+		query    string
+		body     string
+		price    int
+		updated  int
+		svcErr   error
+		wantCode int
+		wantBody string
+	}{
+		{"updated", query, `{"new_price":1199}`, 1199, 3, nil, http.StatusOK, `{"updated":3}`},
+		{"none matched", query, `{"new_price":1199}`, 1199, 0, nil, http.StatusOK, `{"updated":0}`},
+		{"invalid price", query, `{"new_price":0}`, 0, 0, invalid, http.StatusUnprocessableEntity, ""},
+		{"store error", query, `{"new_price":1199}`, 1199, 0, errors.New("db error"), http.StatusInternalServerError, ""},
+		{"missing service name", "?tenant_id=" + tenantID.String(), `{"new_price":1199}`, 0, 0, nil, http.StatusBadRequest, ""},
+		{"missing tenant", "?service_name=Netflix", `{"new_price":1199}`, 0, 0, nil, http.StatusBadRequest, ""},
+		{"malformed body", query, `{"new_price":"more"}`, 0, 0, nil, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestAdminRouter(testAdminToken)
+			if tt.wantCode != http.StatusBadRequest {
+				mockSvc.On("BulkUpdatePrice", tenantIs(tenantID), "Netflix", tt.price).Return(tt.updated, tt.svcErr)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPatch, "/admin/subscriptions/bulk-price"+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("Authorization", "Bearer "+testAdminToken)
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBulkUpdatePrice_RequiresToken(t *testing.T) {
+	router, mockSvc := newTestAdminRouter(testAdminToken)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPatch, "/admin/subscriptions/bulk-price?service_name=Netflix&tenant_id="+uuid.NewString(), strings.NewReader(`{"new_price":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Tenant-ID", uuid.NewString())
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSvc.AssertNotCalled(t, "BulkUpdatePrice", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// GetPriceAnomaly проверяет, не выбивается ли текущая цена подписки из истории
+// @Summary Аномалия цены подписки
+// @Description Сравнивает текущую цену подписки со всеми ее прежними ценами: z_score показывает, на сколько стандартных отклонений текущая цена отличается от среднего. is_anomaly равно true, если |z_score| больше порога (anomaly.threshold, 2 по умолчанию). Если прежние цены не менялись, z_score равен null, а любая другая цена считается аномалией. Пока прежних цен меньше трех, is_anomaly всегда false
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Success 200 {object} model.PriceAnomalyReport
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "current_price": 1200,
+//	    "mean": 700,
+//	    "std_dev": 50,
+//	    "z_score": 10,
+//	    "is_anomaly": true,
+//	    "history_size": 4
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/anomaly [get]
+func (h *SubscriptionHandler) GetPriceAnomaly(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	report, err := h.service.DetectPriceAnomaly(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, report)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestGetPriceAnomaly(t *testing.T) {
+	z := 10.0
+	tests := []struct {
+		name     string
+		path     string
+		report   *model.PriceAnomalyReport
+		svcErr   error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "anomaly",
+			path:     "/subscriptions/" + uuid.NewString() + "/anomaly",
+			report:   &model.PriceAnomalyReport{CurrentPrice: 1200, Mean: 700, StdDev: 50, ZScore: &z, IsAnomaly: true, HistorySize: 2},
+			wantCode: http.StatusOK,
+			wantBody: `{"current_price":1200,"mean":700,"std_dev":50,"z_score":10,"is_anomaly":true,"history_size":2}`,
+		},
+		{
+			name:     "never varied",
+			path:     "/subscriptions/" + uuid.NewString() + "/anomaly",
+			report:   &model.PriceAnomalyReport{CurrentPrice: 800, Mean: 500, IsAnomaly: true, HistorySize: 2},
+			wantCode: http.StatusOK,
+			wantBody: `{"current_price":800,"mean":500,"std_dev":0,"z_score":null,"is_anomaly":true,"history_size":2}`,
+		},
+		{
+			name:     "not found",
+			path:     "/subscriptions/" + uuid.NewString() + "/anomaly",
+			svcErr:   model.ErrNotFound,
+			wantCode: http.StatusNotFound,
+			wantBody: `"subscription not found"`,
+		},
+		{
+			name:     "invalid id",
+			path:     "/subscriptions/nope/anomaly",
+			wantCode: http.StatusBadRequest,
+			wantBody: `"invalid subscription ID"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			if tt.report != nil || tt.svcErr != nil {
+				mockSvc.On("DetectPriceAnomaly", mock.Anything, mock.Anything).Return(tt.report, tt.svcErr)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+const (
+	// AnonymizeRoute lets a user detach themselves from their data.
+	AnonymizeRoute = "/users/{user_id}/anonymize"
+	// AdminAnonymizeRoute does the same for an administrator and any user.
+	AdminAnonymizeRoute = "/admin/users/{user_id}/anonymize"
+	// AdminAnonymizationsRoute lists the anonymous IDs a user was given.
+	AdminAnonymizationsRoute = "/admin/users/{user_id}/anonymizations"
+)
+
+// AnonymizeHandler serves user anonymization.
+type AnonymizeHandler struct {
+	responder
+	service    service.AnonymizeService
+	adminToken string
+}
+
+// NewAnonymizeHandler builds the handler; with an empty adminToken only
+// users can anonymize, each themselves.
+func NewAnonymizeHandler(service service.AnonymizeService, adminToken string, log *slog.Logger) *AnonymizeHandler {
+	return &AnonymizeHandler{responder: responder{log: log}, service: service, adminToken: adminToken}
+}
+
+// RegisterRoutes adds the routes. The /admin ones carry their own token
+// check since they are not part of AdminHandler's subrouter.
+func (h *AnonymizeHandler) RegisterRoutes(router *mux.Router) {
+	admin := h.requireAdminToken(h.adminToken)
+	router.HandleFunc(AnonymizeRoute, h.AnonymizeSelf).Methods("POST")
+	router.Handle(AdminAnonymizeRoute, admin(http.HandlerFunc(h.AnonymizeUser))).Methods("POST")
+	router.Handle(AdminAnonymizationsRoute, admin(http.HandlerFunc(h.ListAnonymizations))).Methods("GET")
+}
+
+// AnonymizeSelf обезличивает пользователя
+// @Summary Обезличить пользователя
+// @Description В одной транзакции переводит все подписки пользователя, включая удаленные, на новый случайный ID и очищает их metadata; напоминания этих подписок, закрепления, доступы к чужим подпискам, лимиты и оповещения о расходах пользователя удаляются, журнал переходит на новый ID, туда же пишется запись об обезличивании. Суммы расходов сохраняются, а запросы по старому user_id возвращают пустые результаты. Соответствие старого и нового ID доступно только администраторам, а при irreversible=true не сохраняется вовсе. Доступно только самому пользователю: ID из заголовка X-User-ID (или claim sub JWT) должен совпадать с user_id
+// @Tags Users
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param X-User-ID header string false "ID вызывающего пользователя, если тенант передается заголовком" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param irreversible query bool false "Не сохранять соответствие старого и нового ID" default(false)
+// @Success 200 {object} model.AnonymizeResponse
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "subscriptions": 4,
+//	    "irreversible": false
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный user_id или irreversible"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант либо не указан вызывающий пользователь"
+// @Failure 403 {object} model.ErrorResponse "Обезличивание другого пользователя"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/anonymize [post]
+func (h *AnonymizeHandler) AnonymizeSelf(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := pathUserID(r, q)
+	irreversible := q.Bool("irreversible")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+	if !h.checkCaller(w, r, userID) {
+		return
+	}
+
+	h.anonymize(w, r, service.AnonymizeRequest{UserID: userID, Irreversible: irreversible, Actor: model.AuditActorUser})
+}
+
+// AnonymizeUser обезличивает любого пользователя тенанта
+// @Summary Обезличить пользователя (администратор)
+// @Description То же, что POST /users/{user_id}/anonymize, для любого пользователя тенанта tenant_id. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param tenant_id query string true "ID тенанта пользователя" example(0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d)
+// @Param irreversible query bool false "Не сохранять соответствие старого и нового ID" default(false)
+// @Success 200 {object} model.AnonymizeResponse
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный user_id или irreversible либо нет tenant_id"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/users/{user_id}/anonymize [post]
+func (h *AnonymizeHandler) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := pathUserID(r, q)
+	q.Require("tenant_id")
+	tenantID := q.UUID("tenant_id")
+	irreversible := q.Bool("irreversible")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	h.anonymize(w, withAdminTenant(r, *tenantID), service.AnonymizeRequest{UserID: userID, Irreversible: irreversible, Actor: model.AuditActorAdmin})
+}
+
+// ListAnonymizations возвращает анонимные ID, выданные пользователю
+// @Summary Анонимные ID пользователя
+// @Description Возвращает, на какие анонимные ID и когда переводились подписки пользователя, от старых к новым. Необратимые обезличивания здесь не видны. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param user_id path string true "Исходный ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param tenant_id query string true "ID тенанта пользователя" example(0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d)
+// @Success 200 {array} model.Anonymization
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	        "anonymous_id": "d4c3b2a1-9f8e-4d7c-b6a5-0f1e2d3c4b5a",
+//	        "anonymized_at": "2025-08-12T10:30:00Z"
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный user_id или нет tenant_id"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/users/{user_id}/anonymizations [get]
+func (h *AnonymizeHandler) ListAnonymizations(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := pathUserID(r, q)
+	q.Require("tenant_id")
+	tenantID := q.UUID("tenant_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	list, err := h.service.ListAnonymizations(withAdminTenant(r, *tenantID).Context(), userID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, list)
+}
+
+func (h *AnonymizeHandler) anonymize(w http.ResponseWriter, r *http.Request, req service.AnonymizeRequest) {
+	req.RequestID = middleware.RequestIDFromContext(r.Context())
+	resp, err := h.service.AnonymizeUser(r.Context(), req)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type MockAnonymizeService struct {
+	mock.Mock
+}
+
+func (m *MockAnonymizeService) AnonymizeUser(ctx context.Context, req service.AnonymizeRequest) (*model.AnonymizeResponse, error) {
+	args := m.Called(ctx, req)
+	resp, _ := args.Get(0).(*model.AnonymizeResponse)
+	return resp, args.Error(1)
+}
+
+func (m *MockAnonymizeService) ListAnonymizations(ctx context.Context, userID uuid.UUID) ([]model.Anonymization, error) {
+	args := m.Called(ctx, userID)
+	list, _ := args.Get(0).([]model.Anonymization)
+	return list, args.Error(1)
+}
+
+func newTestAnonymizeRouter() (*mux.Router, *MockAnonymizeService) {
+	mockSvc := &MockAnonymizeService{}
+	router := mux.NewRouter()
+	router.Use(middleware.TenantMiddleware(middleware.TenantSource{}, "/admin/"))
+	NewAnonymizeHandler(mockSvc, testAdminToken, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func tenantIs(tenantID uuid.UUID) any {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		got, _ := ctxkey.Get[uuid.UUID](ctx, ctxkey.KeyTenantID)
+		return got == tenantID
+	})
+}
+
+func TestAnonymizeSelf(t *testing.T) {
+	router, mockSvc := newTestAnonymizeRouter()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mockSvc.On("AnonymizeUser", tenantIs(tenantID), service.AnonymizeRequest{UserID: userID, Irreversible: true, Actor: model.AuditActorUser}).
+		Return(&model.AnonymizeResponse{Subscriptions: 4, Irreversible: true}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest(http.MethodPost, "/users/"+userID.String()+"/anonymize?irreversible=true", tenantID.String(), userID.String()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"subscriptions":4,"irreversible":true}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAnonymizeSelf_Refused(t *testing.T) {
+	userID := uuid.New()
+	path := "/users/" + userID.String() + "/anonymize"
+	tests := []struct {
+		name   string
+		path   string
+		tenant string
+		user   string
+		want   int
+	}{
+		{"no tenant", path, "", userID.String(), http.StatusUnauthorized},
+		{"no user", path, uuid.NewString(), "", http.StatusUnauthorized},
+		{"someone else", path, uuid.NewString(), uuid.NewString(), http.StatusForbidden},
+		{"bad irreversible", path + "?irreversible=maybe", uuid.NewString(), userID.String(), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestAnonymizeRouter()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, userRequest(http.MethodPost, tt.path, tt.tenant, tt.user))
+
+			assert.Equal(t, tt.want, w.Code)
+			mockSvc.AssertNotCalled(t, "AnonymizeUser", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAnonymizeSelf_ServiceError(t *testing.T) {
+	router, mockSvc := newTestAnonymizeRouter()
+	userID := uuid.New()
+	mockSvc.On("AnonymizeUser", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest(http.MethodPost, "/users/"+userID.String()+"/anonymize", uuid.NewString(), userID.String()))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAdminAnonymizeUser(t *testing.T) {
+	router, mockSvc := newTestAnonymizeRouter()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mockSvc.On("AnonymizeUser", tenantIs(tenantID), service.AnonymizeRequest{UserID: userID, Actor: model.AuditActorAdmin}).
+		Return(&model.AnonymizeResponse{Subscriptions: 2}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/admin/users/"+userID.String()+"/anonymize?tenant_id="+tenantID.String(), nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"subscriptions":2,"irreversible":false}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAdminListAnonymizations(t *testing.T) {
+	router, mockSvc := newTestAnonymizeRouter()
+	tenantID, userID, anonID := uuid.New(), uuid.New(), uuid.New()
+	at := time.Date(2025, 8, 12, 10, 30, 0, 0, time.UTC)
+
+	mockSvc.On("ListAnonymizations", tenantIs(tenantID), userID).
+		Return([]model.Anonymization{{UserID: userID, AnonymousID: anonID, AnonymizedAt: at}}, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/users/"+userID.String()+"/anonymizations?tenant_id="+tenantID.String(), nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"user_id":"`+userID.String()+`","anonymous_id":"`+anonID.String()+`","anonymized_at":"2025-08-12T10:30:00Z"}]`, w.Body.String())
+}
+
+func TestAdminAnonymize_RequiresToken(t *testing.T) {
+	userID := uuid.New()
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/admin/users/" + userID.String() + "/anonymize"},
+		{http.MethodGet, "/admin/users/" + userID.String() + "/anonymizations"},
+	} {
+		t.Run(route.path, func(t *testing.T) {
+			router, mockSvc := newTestAnonymizeRouter()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(route.method, route.path+"?tenant_id="+uuid.NewString(), nil)
+			r.Header.Set(middleware.DefaultTenantHeader, uuid.NewString())
+			r.Header.Set(middleware.DefaultUserHeader, userID.String())
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "a tenant and user do not stand in for the token")
+			assert.Empty(t, mockSvc.Calls)
+		})
+	}
+}
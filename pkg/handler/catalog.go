@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type CatalogHandler struct {
+	responder
+	service service.CatalogService
+}
+
+func NewCatalogHandler(service service.CatalogService, log *slog.Logger) *CatalogHandler {
+	return &CatalogHandler{responder: responder{log: log}, service: service}
+}
+
+func (h *CatalogHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/catalog/services", h.CreateCatalogEntry).Methods("POST")
+	router.HandleFunc("/catalog/services", h.ListCatalogEntries).Methods("GET")
+	router.HandleFunc("/catalog/services/{id}", h.GetCatalogEntry).Methods("GET")
+	router.HandleFunc("/catalog/services/{id}", h.UpdateCatalogEntry).Methods("PUT")
+	router.HandleFunc("/catalog/services/{id}", h.DeleteCatalogEntry).Methods("DELETE")
+}
+
+func catalogLocation(id uuid.UUID) string {
+	return "/catalog/services/" + id.String()
+}
+
+// CreateCatalogEntry добавляет сервис в каталог
+// @Summary Добавить сервис в каталог
+// @Description Добавляет сервис в каталог тенанта. Название нормализуется так же, как service_name подписки, и должно быть уникальным в каталоге
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param input body service.CatalogEntryRequest true "Название, описание, категория, логотип и цена по умолчанию"
+// @Success 201 {object} model.CatalogEntry "Сервис добавлен"
+// @Header 201 {string} Location "URL сервиса, /catalog/services/{id}"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 201 Created
+//	{
+//	    "id": "2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c",
+//	    "name": "yandex plus",
+//	    "category": "streaming",
+//	    "default_price": 599,
+//	    "created_at": "2025-08-12T00:00:00Z",
+//	    "updated_at": "2025-08-12T00:00:00Z"
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 409 {object} model.ErrorResponse "Сервис с таким названием уже есть в каталоге"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /catalog/services [post]
+func (h *CatalogHandler) CreateCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	var req service.CatalogEntryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+
+	entry, err := h.service.CreateCatalogEntry(r.Context(), req)
+	if err != nil {
+		h.catalogError(w, r, err)
+		return
+	}
+
+	h.respondCreated(w, r, catalogLocation(entry.ID), entry)
+}
+
+// ListCatalogEntries возвращает каталог сервисов
+// @Summary Каталог сервисов
+// @Description Возвращает сервисы каталога тенанта по алфавиту, с category - только сервисы этой категории
+// @Tags Catalog
+// @Produce json
+// @Security Tenant
+// @Param category query string false "Категория, без учета регистра" example(streaming)
+// @Success 200 {array} model.CatalogEntry
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /catalog/services [get]
+func (h *CatalogHandler) ListCatalogEntries(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	category := q.get("category")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	entries, err := h.service.ListCatalogEntries(r.Context(), category)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, len(entries))
+	h.render(w, r, http.StatusOK, entries)
+}
+
+// GetCatalogEntry возвращает сервис каталога
+// @Summary Сервис каталога
+// @Tags Catalog
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID сервиса" example(2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c)
+// @Success 200 {object} model.CatalogEntry
+// @Failure 400 {object} model.ErrorInput "Неверный ID сервиса"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Сервис не найден"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /catalog/services/{id} [get]
+func (h *CatalogHandler) GetCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.entryID(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.GetCatalogEntry(r.Context(), id)
+	if err != nil {
+		h.catalogError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, entry)
+}
+
+// UpdateCatalogEntry изменяет сервис каталога
+// @Summary Изменить сервис каталога
+// @Description Заменяет все поля сервиса. Подписки, уже созданные из него, не меняются
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID сервиса" example(2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c)
+// @Param input body service.CatalogEntryRequest true "Название, описание, категория, логотип и цена по умолчанию"
+// @Success 200 {object} model.CatalogEntry
+// @Failure 400 {object} model.ErrorInput "Неверный ID сервиса или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Сервис не найден"
+// @Failure 409 {object} model.ErrorResponse "Сервис с таким названием уже есть в каталоге"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /catalog/services/{id} [put]
+func (h *CatalogHandler) UpdateCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.entryID(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.CatalogEntryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.ID = id
+
+	entry, err := h.service.UpdateCatalogEntry(r.Context(), req)
+	if err != nil {
+		h.catalogError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, entry)
+}
+
+// DeleteCatalogEntry удаляет сервис из каталога
+// @Summary Удалить сервис из каталога
+// @Description Подписки, созданные из сервиса, остаются, но теряют ссылку на него
+// @Tags Catalog
+// @Security Tenant
+// @Param id path string true "ID сервиса" example(2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c)
+// @Success 204 "Сервис удален"
+// @Failure 400 {object} model.ErrorInput "Неверный ID сервиса"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Сервис не найден"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /catalog/services/{id} [delete]
+func (h *CatalogHandler) DeleteCatalogEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.entryID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCatalogEntry(r.Context(), id); err != nil {
+		h.catalogError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusNoContent, nil)
+}
+
+func (h *CatalogHandler) entryID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid catalog service ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *CatalogHandler) catalogError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *model.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		h.respondWithJSON(w, http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+			Error:  model.ErrValidation.Error(),
+			Fields: verr.Fields,
+		})
+	case errors.Is(err, model.ErrNotFound):
+		h.respondWithError(w, http.StatusNotFound, "catalog service not found")
+	case errors.Is(err, model.ErrConflict):
+		h.respondWithError(w, http.StatusConflict, "catalog already has a service with this name")
+	default:
+		h.internalError(w, r, err)
+	}
+}
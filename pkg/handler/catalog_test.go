@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type MockCatalogService struct {
+	mock.Mock
+}
+
+func (m *MockCatalogService) CreateCatalogEntry(ctx context.Context, req service.CatalogEntryRequest) (*model.CatalogEntry, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogService) GetCatalogEntry(ctx context.Context, id uuid.UUID) (*model.CatalogEntry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*model.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogService) ListCatalogEntries(ctx context.Context, category string) ([]model.CatalogEntry, error) {
+	args := m.Called(ctx, category)
+	return args.Get(0).([]model.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogService) UpdateCatalogEntry(ctx context.Context, req service.CatalogEntryRequest) (*model.CatalogEntry, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogService) DeleteCatalogEntry(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newTestCatalogRouter() (*mux.Router, *MockCatalogService) {
+	mockSvc := &MockCatalogService{}
+	router := mux.NewRouter()
+	NewCatalogHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestCreateCatalogEntry_Success(t *testing.T) {
+	router, mockSvc := newTestCatalogRouter()
+	w := httptest.NewRecorder()
+
+	entry := &model.CatalogEntry{ID: uuid.New(), Name: "netflix", Category: "streaming", DefaultPrice: 999}
+	mockSvc.On("CreateCatalogEntry", mock.Anything, service.CatalogEntryRequest{Name: "Netflix", Category: "streaming", DefaultPrice: 999}).
+		Return(entry, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/catalog/services",
+		bytes.NewBufferString(`{"name":"Netflix","category":"streaming","default_price":999}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/catalog/services/"+entry.ID.String(), w.Header().Get("Location"))
+	var response model.CatalogEntry
+	parseResponse(t, w, &response)
+	assert.Equal(t, *entry, response)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateCatalogEntry_Errors(t *testing.T) {
+	verr := &model.ValidationError{}
+	verr.Add("default_price", "must be greater than 0")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"validation", verr, http.StatusUnprocessableEntity},
+		{"duplicate name", fmt.Errorf("create: %w", model.ErrConflict), http.StatusConflict},
+		{"storage", fmt.Errorf("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestCatalogRouter()
+			w := httptest.NewRecorder()
+
+			mockSvc.On("CreateCatalogEntry", mock.Anything, mock.Anything).Return((*model.CatalogEntry)(nil), tt.err)
+
+			r := httptest.NewRequest(http.MethodPost, "/catalog/services", bytes.NewBufferString(`{"name":"netflix"}`))
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Empty(t, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestListCatalogEntries_PassesCategory(t *testing.T) {
+	router, mockSvc := newTestCatalogRouter()
+	w := httptest.NewRecorder()
+
+	entries := []model.CatalogEntry{{ID: uuid.New(), Name: "netflix", Category: "streaming", DefaultPrice: 999}}
+	mockSvc.On("ListCatalogEntries", mock.Anything, "streaming").Return(entries, nil)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog/services?category=streaming", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	var response []model.CatalogEntry
+	parseResponse(t, w, &response)
+	assert.Equal(t, entries, response)
+}
+
+func TestListCatalogEntries_EmptyIsArray(t *testing.T) {
+	router, mockSvc := newTestCatalogRouter()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("ListCatalogEntries", mock.Anything, "").Return([]model.CatalogEntry(nil), nil)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog/services", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]\n", w.Body.String())
+}
+
+func TestGetCatalogEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		err  error
+		want int
+	}{
+		{"found", uuid.NewString(), nil, http.StatusOK},
+		{"not found", uuid.NewString(), model.ErrNotFound, http.StatusNotFound},
+		{"invalid id", "abc", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestCatalogRouter()
+			w := httptest.NewRecorder()
+
+			if id, err := uuid.Parse(tt.id); err == nil {
+				var entry *model.CatalogEntry
+				if tt.err == nil {
+					entry = &model.CatalogEntry{ID: id, Name: "netflix", DefaultPrice: 999}
+				}
+				mockSvc.On("GetCatalogEntry", mock.Anything, id).Return(entry, tt.err)
+			}
+
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog/services/"+tt.id, nil))
+
+			assert.Equal(t, tt.want, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateCatalogEntry_SetsID(t *testing.T) {
+	router, mockSvc := newTestCatalogRouter()
+	w := httptest.NewRecorder()
+	id := uuid.New()
+
+	entry := &model.CatalogEntry{ID: id, Name: "netflix", DefaultPrice: 1199}
+	mockSvc.On("UpdateCatalogEntry", mock.Anything, service.CatalogEntryRequest{ID: id, Name: "netflix", DefaultPrice: 1199}).
+		Return(entry, nil)
+
+	r := httptest.NewRequest(http.MethodPut, "/catalog/services/"+id.String(),
+		bytes.NewBufferString(`{"name":"netflix","default_price":1199}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestDeleteCatalogEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"deleted", nil, http.StatusNoContent},
+		{"not found", model.ErrNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestCatalogRouter()
+			w := httptest.NewRecorder()
+			id := uuid.New()
+
+			mockSvc.On("DeleteCatalogEntry", mock.Anything, id).Return(tt.err)
+
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/catalog/services/"+id.String(), nil))
+
+			assert.Equal(t, tt.want, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+const invalidPayload = "invalid request payload"
+
+// decodeJSON strictly decodes the request body into dst: unknown fields and
+// anything after the first JSON value are rejected. The returned error's
+// message is safe to send to the client and names the offending field when
+// there is one.
+func decodeJSON(r *http.Request, dst any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return describeDecodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return errBodyTooLarge
+		}
+		return errors.New(invalidPayload + ": body must contain a single JSON value")
+	}
+
+	return nil
+}
+
+// errBodyTooLarge is returned by decodeJSON once the body exceeds the limit
+// set by middleware.BodyLimitMiddleware.
+var errBodyTooLarge = errors.New("request body too large")
+
+func describeDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var maxErr *http.MaxBytesError
+	var dateErr *model.DateFieldError
+
+	switch {
+	case errors.As(err, &maxErr):
+		return errBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return errors.New(invalidPayload + ": body must not be empty")
+	case errors.As(err, &dateErr):
+		return fmt.Errorf("%s: %w", invalidPayload, dateErr)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Errorf("%s: field %q must be %s", invalidPayload, typeErr.Field, jsonKind(typeErr.Type))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for this case.
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return fmt.Errorf("%s: unknown field %s", invalidPayload, field)
+	default:
+		return errors.New(invalidPayload)
+	}
+}
+
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a string"
+	}
+}
+
+// payloadError answers a failed decodeJSON with 413 or 400.
+func (h *responder) payloadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		h.respondWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	h.respondWithError(w, http.StatusBadRequest, err.Error())
+}
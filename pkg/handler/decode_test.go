@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON_RejectsMalformedBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"unknown field", `{"servicename":"Netflix"}`, `invalid request payload: unknown field "servicename"`},
+		{"trailing data", `{"service_name":"Netflix"} garbage`, "invalid request payload: body must contain a single JSON value"},
+		{"second object", `{"service_name":"Netflix"}{"price":1}`, "invalid request payload: body must contain a single JSON value"},
+		{"wrong type", `{"price":"cheap"}`, `invalid request payload: field "price" must be an integer`},
+		{"empty body", ``, "invalid request payload: body must not be empty"},
+		{"syntax error", `{invalid}`, "invalid request payload"},
+	}
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/subscriptions"},
+		{http.MethodPut, "/subscriptions/550e8400-e29b-41d4-a716-446655440000"},
+	}
+
+	for _, route := range routes {
+		for _, tt := range tests {
+			t.Run(route.method+" "+tt.name, func(t *testing.T) {
+				h, mockSvc := newTestHandler()
+				router := mux.NewRouter()
+				h.RegisterRoutes(router)
+				w := httptest.NewRecorder()
+
+				r := httptest.NewRequest(route.method, route.path, bytes.NewBufferString(tt.body))
+				router.ServeHTTP(w, r)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				var response map[string]string
+				parseResponse(t, w, &response)
+				assert.Equal(t, tt.message, response["error"])
+				mockSvc.AssertExpectations(t)
+			})
+		}
+	}
+}
+
+func TestDecodeJSON_ShareRejectsUnknownField(t *testing.T) {
+	h, _ := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	w := httptest.NewRecorder()
+
+	body := `{"user_id":"7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b","permision":"write"}`
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/550e8400-e29b-41d4-a716-446655440000/shares", bytes.NewBufferString(body))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response map[string]string
+	parseResponse(t, w, &response)
+	assert.Equal(t, `invalid request payload: unknown field "permision"`, response["error"])
+}
+
+func TestDecodeJSON_BodyTooLarge(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	body := `{"service_name":"` + strings.Repeat("a", 100) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBufferString(body))
+	r.Body = http.MaxBytesReader(w, r.Body, 32)
+	h.CreateSubscription(w, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var response map[string]string
+	parseResponse(t, w, &response)
+	assert.Equal(t, "request body too large", response["error"])
+	mockSvc.AssertExpectations(t)
+}
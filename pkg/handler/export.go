@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"SubscriptionAggregator/pkg/exporter"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// exportFormatICal is the only export format so far and the default.
+const exportFormatICal = "ical"
+
+// ExportSubscriptions выгружает даты продления подписок в календарь
+// @Summary Экспорт продлений в iCalendar
+// @Description Возвращает файл .ics с событием на весь день в дату окончания (продления) каждой подписки пользователя; подписки без end_date пропускаются. Файл можно импортировать или подписаться на него в приложении календаря
+// @Tags Subscriptions
+// @Produce text/calendar
+// @Security Tenant
+// @Param user_id query string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param format query string false "Формат файла" Enums(ical) default(ical)
+// @Success 200 {string} string "Календарь в формате iCalendar (RFC 5545)"
+// @SuccessExample {text} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Type: text/calendar; charset=utf-8
+//	Content-Disposition: attachment; filename="subscriptions.ics"
+//
+//	BEGIN:VCALENDAR
+//	VERSION:2.0
+//	PRODID:-//SubscriptionAggregator//Renewals//EN
+//	CALSCALE:GREGORIAN
+//	BEGIN:VEVENT
+//	UID:550e8400-e29b-41d4-a716-446655440000@subscription-aggregator
+//	DTSTAMP:20250801T103000Z
+//	DTSTART;VALUE=DATE:20250912
+//	SUMMARY:yandex plus
+//	END:VEVENT
+//	END:VCALENDAR
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Нет или некорректный user_id либо неизвестный format"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	q.Require("user_id")
+	userID := q.UUID("user_id")
+	if format := q.String("format"); format != nil && *format != exportFormatICal {
+		q.errs.Add("format", "must be "+exportFormatICal)
+	}
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	result, err := h.service.ListSubscriptions(r.Context(), model.SubscriptionFilter{UserID: userID})
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	// The calendar is written straight to the client; once the first line
+	// is out a failure can only be logged.
+	w.Header().Set("Content-Type", exporter.ICalContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.ics"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := exporter.WriteICal(w, result.Items, time.Now()); err != nil {
+		h.log.Warn("calendar export interrupted",
+			slog.String("request_id", middleware.RequestIDFromContext(r.Context())),
+			slog.String("error", err.Error()),
+		)
+	}
+}
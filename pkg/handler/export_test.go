@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestExportSubscriptions_ICal(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	end := time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{UserID: &userID}).Return(&model.ListResult{
+		Items: []*model.Subscription{
+			{ID: uuid.New(), ServiceName: "netflix", EndDate: &end},
+			{ID: uuid.New(), ServiceName: "yandex plus"},
+			{ID: uuid.New(), ServiceName: "spotify", EndDate: &end},
+		},
+		TotalCount: 3,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/export?format=ical&user_id="+userID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="subscriptions.ics"`, w.Header().Get("Content-Disposition"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT\r\n"))
+	assert.Contains(t, body, "DTSTART;VALUE=DATE:20250912\r\nSUMMARY:netflix\r\n")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestExportSubscriptions_InvalidQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		fields map[string]string
+	}{
+		{"missing user", "?format=ical", map[string]string{"user_id": "is required"}},
+		{"unknown format", "?format=csv&user_id=" + uuid.NewString(), map[string]string{"format": "must be ical"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/export"+tt.query, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp model.ValidationErrorResponse
+			parseResponse(t, w, &resp)
+			assert.Equal(t, tt.fields, resp.Fields)
+			assert.Empty(t, mockSvc.Calls)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// probeMethods are tried against the router to work out which methods a
+// path supports.
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// RegisterFallbacks makes unmatched requests answer with the JSON error
+// envelope: 404 for unknown paths, 405 with an Allow header for known paths
+// used with the wrong method, and 204 with Allow for OPTIONS. Call it after
+// all routes are registered.
+func RegisterFallbacks(router *mux.Router, log *slog.Logger) {
+	h := &responder{log: log}
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.respondWithError(w, http.StatusNotFound, "resource not found")
+	})
+
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allowedMethods(router, r), ", "))
+		if r.Method == http.MethodOptions {
+			h.render(w, r, http.StatusNoContent, nil)
+			return
+		}
+		h.respondWithError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+	})
+}
+
+// allowedMethods lists the methods some route accepts for r's path, plus
+// OPTIONS, which is always answered.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range probeMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return append(allowed, http.MethodOptions)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFullRouter registers every handler the way main does.
+func newFullRouter() *mux.Router {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := mux.NewRouter()
+	NewSubscriptionHandler(&MockSubscriptionService{}, testMaxPageSize, log).RegisterRoutes(router)
+	NewReminderHandler(&MockReminderService{}, log).RegisterRoutes(router)
+	NewHealthHandler(&fakePinger{}, log).RegisterRoutes(router)
+	RegisterFallbacks(router, log)
+	return router
+}
+
+// concretePath fills path variables with a valid UUID.
+func concretePath(tpl string) string {
+	for _, v := range []string{"{id}", "{user_id}", "{reminder_id}"} {
+		tpl = strings.ReplaceAll(tpl, v, "550e8400-e29b-41d4-a716-446655440000")
+	}
+	return tpl
+}
+
+// routeMethods collects, per path template, the methods registered for it.
+func routeMethods(t *testing.T, router *mux.Router) map[string][]string {
+	t.Helper()
+	methods := make(map[string][]string)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		require.NoError(t, err)
+		ms, err := route.GetMethods()
+		require.NoError(t, err)
+		methods[tpl] = append(methods[tpl], ms...)
+		return nil
+	})
+	require.NoError(t, err)
+	return methods
+}
+
+func TestFallbacks_EveryRouteAnswersOptionsAndMethodNotAllowed(t *testing.T) {
+	router := newFullRouter()
+
+	for tpl, methods := range routeMethods(t, router) {
+		path := concretePath(tpl)
+
+		t.Run("OPTIONS "+tpl, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, path, nil))
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			allow := strings.Split(w.Header().Get("Allow"), ", ")
+			assert.Subset(t, allow, append(methods, http.MethodOptions))
+		})
+
+		t.Run("PATCH "+tpl, func(t *testing.T) {
+			for _, m := range methods {
+				if m == http.MethodPatch {
+					t.Skip("route supports PATCH")
+				}
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, path, nil))
+
+			assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, `{"error":"method PATCH not allowed"}`, w.Body.String())
+			assert.Subset(t, strings.Split(w.Header().Get("Allow"), ", "), append(methods, http.MethodOptions))
+		})
+	}
+}
+
+func TestFallbacks_AllowListsEveryMethodForSharedPath(t *testing.T) {
+	router := newFullRouter()
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/expired/cleanup", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/subscriptions/550e8400-e29b-41d4-a716-446655440000", nil))
+
+	assert.Equal(t, "GET, PUT, DELETE, OPTIONS", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/subscriptions", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, POST, OPTIONS", w.Header().Get("Allow"))
+}
+
+func TestFallbacks_NotFound(t *testing.T) {
+	router := newFullRouter()
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"resource not found"}`, w.Body.String())
+}
@@ -3,41 +3,107 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 
+	"SubscriptionAggregator/pkg/currency"
 	"SubscriptionAggregator/pkg/model"
 	"SubscriptionAggregator/pkg/service"
 )
 
 type SubscriptionHandler struct {
-	service service.SubscriptionService
+	responder
+	service     service.SubscriptionService
+	maxPageSize int
 }
 
-func NewSubscriptionHandler(service service.SubscriptionService) *SubscriptionHandler {
-	return &SubscriptionHandler{service: service}
+// NewSubscriptionHandler serves list pages of at most maxPageSize
+// subscriptions.
+func NewSubscriptionHandler(service service.SubscriptionService, maxPageSize int, log *slog.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{responder: responder{log: log}, service: service, maxPageSize: maxPageSize}
+}
+
+// defaultExpiringDays is the look-ahead when days is not given.
+const defaultExpiringDays = 7
+
+// defaultUpcomingDays is how far ahead renewals are listed when days is not
+// given.
+const defaultUpcomingDays = 14
+
+const (
+	// ImportRoute is the file upload endpoint; it gets its own, larger body
+	// limit and accepts multipart bodies.
+	ImportRoute = "/subscriptions/import"
+	// StreamRoute serves text/event-stream instead of JSON.
+	StreamRoute = "/subscriptions/stream"
+	// ExportRoute streams a file instead of JSON and must not be buffered.
+	ExportRoute = "/subscriptions/export"
+)
+
+// subscriptionLocation is the URL of a subscription, for Location headers.
+func subscriptionLocation(id uuid.UUID) string {
+	return "/subscriptions/" + id.String()
 }
 
 func (h *SubscriptionHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/subscriptions", h.CreateSubscription).Methods("POST")
+	router.HandleFunc(ImportRoute, h.ImportSubscriptions).Methods("POST")
+	router.HandleFunc("/subscriptions/create-and-share", h.CreateAndShareSubscription).Methods("POST")
+	router.HandleFunc("/subscriptions/batch-get", h.BatchGetSubscriptions).Methods("POST")
+	router.HandleFunc("/subscriptions/simulate", h.SimulateCost).Methods("POST")
 	router.HandleFunc("/subscriptions/total", h.GetTotalCost).Methods("GET")
+	router.HandleFunc("/subscriptions/total/monthly", h.GetMonthlyCost).Methods("GET")
+	router.HandleFunc("/subscriptions/total/compare", h.ComparePeriods).Methods("GET")
+	router.HandleFunc("/subscriptions/team-total", h.GetTeamTotalCost).Methods("GET")
+	router.HandleFunc("/subscriptions/summary/by-cycle", h.GetCostByCycle).Methods("GET")
+	router.HandleFunc("/subscriptions/stats", h.GetPriceStats).Methods("GET")
+	router.HandleFunc("/subscriptions/project", h.ProjectCosts).Methods("GET")
+	router.HandleFunc("/subscriptions/expired", h.ListExpiredSubscriptions).Methods("GET")
+	router.HandleFunc("/subscriptions/expiring-soon/by-service", h.ListExpiringSoonByService).Methods("GET")
+	router.HandleFunc("/subscriptions/upcoming", h.ListUpcomingRenewals).Methods("GET")
+	router.HandleFunc("/subscriptions/rank", h.RankSubscriptions).Methods("GET")
+	router.HandleFunc(ExportRoute, h.ExportSubscriptions).Methods("GET")
+	router.HandleFunc(StreamRoute, h.StreamSubscriptionChanges).Methods("GET")
+	router.HandleFunc("/subscriptions/expired/cleanup", h.CleanupExpiredSubscriptions).Methods("POST")
 	router.HandleFunc("/subscriptions/{id}", h.GetSubscription).Methods("GET")
 	router.HandleFunc("/subscriptions/{id}", h.UpdateSubscription).Methods("PUT")
+	router.HandleFunc("/subscriptions/{id}/price", h.UpdatePrice).Methods("PATCH")
 	router.HandleFunc("/subscriptions/{id}", h.DeleteSubscription).Methods("DELETE")
 	router.HandleFunc("/subscriptions", h.ListSubscriptions).Methods("GET")
+	router.HandleFunc("/subscriptions/{id}/shares", h.ShareSubscription).Methods("POST")
+	router.HandleFunc("/subscriptions/{id}/shares", h.GetSharedUsers).Methods("GET")
+	router.HandleFunc("/subscriptions/{id}/shares/{user_id}", h.UnshareSubscription).Methods("DELETE")
+	router.HandleFunc("/subscriptions/{id}/pin", h.PinSubscription).Methods("POST")
+	router.HandleFunc("/subscriptions/{id}/pin", h.UnpinSubscription).Methods("DELETE")
+	router.HandleFunc("/subscriptions/{id}/anomaly", h.GetPriceAnomaly).Methods("GET")
+	router.HandleFunc("/subscriptions/{id}/payments", h.RecordPayment).Methods("POST")
+	router.HandleFunc("/subscriptions/{id}/payments", h.ListPayments).Methods("GET")
+	router.HandleFunc("/services", h.ListServices).Methods("GET")
+	router.HandleFunc("/users/{user_id}/summary", h.GetUserSummary).Methods("GET")
+	router.HandleFunc("/users/{user_id}/subscriptions/top", h.GetTopServices).Methods("GET")
+	router.HandleFunc("/users/{user_id}/subscriptions/forecast", h.GetForecast).Methods("GET")
 }
 
 // CreateSubscription создает новую подписку
 // @Summary Создать подписку
-// @Description Добавляет новую подписку для пользователя
+// @Description Добавляет новую подписку для пользователя. С catalog_service_id недостающие service_name и price берутся из записи каталога сервисов
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
+// @Security Tenant
 // @Param input body service.CreateSubscriptionRequest true "Данные подписки"
+// @Param idempotent query bool false "Вернуть существующую подписку пользователя на этот сервис вместо создания новой"
+// @Param allow_duplicate query bool false "Создать подписку, даже если у пользователя уже есть подписка на этот сервис с пересекающимся периодом"
+// @Success 200 {object} model.Subscription "Подписка уже существует (idempotent=true)"
 // @Success 201 {object} model.Subscription "Подписка успешно создана"
+// @Header 201 {string} Location "URL созданной подписки, /subscriptions/{id}"
 // @SuccessExample {json} Success-Response:
 //     HTTP/1.1 201 Created
 //     {
@@ -54,23 +120,101 @@ func (h *SubscriptionHandler) RegisterRoutes(router *mux.Router) {
 //         "error": "invalid request payload",
 //         "code": 400
 //     }
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 409 {object} model.DuplicateErrorResponse "Период пересекается с активной подпиской на этот сервис, несколько подходящих подписок (idempotent=true) или иной конфликт"
+// @Failure 413 {object} model.ErrorResponse "Слишком большое тело запроса"
+// @Failure 415 {object} model.ErrorResponse "Content-Type должен быть application/json"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей, ссылка на несуществующую запись или у пользователя уже предельное число подписок"
 // @Failure 500 {object} model.ServerError "Ошибка сервера"
 // @Router /subscriptions [post]
 
 func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	var req service.CreateSubscriptionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.AllowDuplicate = r.URL.Query().Get("allow_duplicate") == "true"
+
+	if r.URL.Query().Get("idempotent") == "true" {
+		sub, created, err := h.service.FindOrCreateSubscription(r.Context(), req)
+		if err != nil {
+			h.storeError(w, r, err)
+			return
+		}
+		if created {
+			h.respondCreated(w, r, subscriptionLocation(sub.ID), sub)
+			return
+		}
+		h.render(w, r, http.StatusOK, sub)
 		return
 	}
 
 	sub, err := h.service.CreateSubscription(r.Context(), req)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.respondCreated(w, r, subscriptionLocation(sub.ID), sub)
+}
+
+// CreateAndShareSubscription создает подписку и сразу открывает к ней доступ
+// @Summary Создать подписку и поделиться ей
+// @Description Создает подписку и открывает доступ перечисленным пользователям в одной транзакции: если не удалось открыть хотя бы один доступ, подписка не создается
+// @Tags Shares
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param input body service.CreateAndShareRequest true "Данные подписки и пользователи с уровнем доступа (read по умолчанию)"
+// @Param allow_duplicate query bool false "Создать подписку, даже если у пользователя уже есть подписка на этот сервис с пересекающимся периодом"
+// @Success 201 {object} model.SharedSubscription "Подписка создана и доступы открыты"
+// @Header 201 {string} Location "URL созданной подписки, /subscriptions/{id}"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 201 Created
+//	{
+//	    "subscription": {
+//	        "id": "550e8400-e29b-41d4-a716-446655440000",
+//	        "service_name": "Yandex Plus",
+//	        "price": 599,
+//	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	        "start_date": "2025-08-12T00:00:00Z",
+//	        "billing_cycle": "monthly"
+//	    },
+//	    "shares": [
+//	        {
+//	            "subscription_id": "550e8400-e29b-41d4-a716-446655440000",
+//	            "user_id": "7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b",
+//	            "permission": "read",
+//	            "created_at": "2025-08-12T00:00:00Z"
+//	        }
+//	    ]
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 409 {object} model.DuplicateErrorResponse "Период пересекается с активной подпиской на этот сервис или иной конфликт"
+// @Failure 413 {object} model.ErrorResponse "Слишком большое тело запроса"
+// @Failure 415 {object} model.ErrorResponse "Content-Type должен быть application/json"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей подписки или share_with, либо у пользователя уже предельное число подписок"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/create-and-share [post]
+func (h *SubscriptionHandler) CreateAndShareSubscription(w http.ResponseWriter, r *http.Request) {
+	var req service.CreateAndShareRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.Subscription.AllowDuplicate = r.URL.Query().Get("allow_duplicate") == "true"
+
+	result, err := h.service.CreateSubscriptionWithShares(r.Context(), req)
+	if err != nil {
+		h.storeError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, sub)
+	h.respondCreated(w, r, subscriptionLocation(result.Subscription.ID), result)
 }
 
 // GetSubscription возвращает подписку по ID
@@ -78,8 +222,10 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 // @Description Возвращает информацию о конкретной подписке
 // @Tags Subscriptions
 // @Produce json
+// @Security Tenant
 // @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
 // @Success 200 {object} model.Subscription
+// @Header 200 {string} ETag "Версия подписки, ее можно передать в If-Match при изменении"
 // @SuccessExample {json} Success-Response:
 //
 //	HTTP/1.1 200 OK
@@ -100,6 +246,7 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 //	    "code": 400
 //	}
 //
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
 // @Failure 404 {object} model.ErrorResponse
 // @FailureExample {json} Error-Response:
 //
@@ -115,32 +262,88 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
 		return
 	}
 
 	sub, err := h.service.GetSubscription(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, model.ErrNotFound) {
-			respondWithError(w, http.StatusNotFound, "subscription not found")
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.internalError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, sub)
+	setETag(w, sub)
+	h.render(w, r, http.StatusOK, sub)
 }
 
-// UpdateSubscription обновляет существующую подписку
-// @Summary Обновить подписку
-// @Description Изменяет данные существующей подписки
+// BatchGetSubscriptions возвращает несколько подписок по списку ID
+// @Summary Получить подписки по списку ID
+// @Description Возвращает найденные подписки в порядке переданных ids и список ID, которых нет у тенанта. За один запрос можно передать не больше 200 ID, повторяющиеся ID учитываются один раз
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
+// @Security Tenant
+// @Param input body service.BatchGetRequest true "Список ID подписок"
+// @Success 200 {object} model.BatchGetResult
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "subscriptions": [
+//	        {
+//	            "id": "550e8400-e29b-41d4-a716-446655440000",
+//	            "service_name": "yandex plus",
+//	            "price": 599,
+//	            "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	            "start_date": "2025-08-12T00:00:00Z",
+//	            "billing_cycle": "monthly"
+//	        }
+//	    ],
+//	    "missing": ["3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8"]
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 413 {object} model.ErrorResponse "Слишком большое тело запроса"
+// @Failure 415 {object} model.ErrorResponse "Content-Type должен быть application/json"
+// @Failure 422 {object} model.ValidationErrorResponse "Пустой список ids или больше 200 ID"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/batch-get [post]
+func (h *SubscriptionHandler) BatchGetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req service.BatchGetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+
+	result, err := h.service.BatchGetSubscriptions(r.Context(), req.IDs)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, result)
+}
+
+// UpdateSubscription обновляет подписку или создает ее с указанным ID
+// @Summary Создать или обновить подписку
+// @Description Заменяет данные подписки целиком; если у тенанта нет подписки с таким ID, создает ее под этим ID, так что повтор того же запроса безопасен. Для существующей подписки нужна версия, с которой сделано изменение, в поле version или в заголовке If-Match; если подписку успели изменить, возвращается 409 с текущей версией
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Security Tenant
 // @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param allow_duplicate query bool false "При создании не проверять пересечение с активной подпиской на этот сервис"
+// @Param If-Match header string false "ETag подписки, то есть ее версия в кавычках; заменяет поле version" example("3")
 // @Param input body service.UpdateSubscriptionRequest true "Новые данные подписки"
 // @Success 200 {object} model.Subscription "Подписка успешно обновлена"
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Success 201 {object} model.Subscription "Подписки не было, она создана"
+// @Header 201 {string} Location "URL созданной подписки, /subscriptions/{id}"
 // @SuccessExample {json} Success-Response:
 //
 //	HTTP/1.1 200 OK
@@ -153,7 +356,7 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 //	    "end_date": "2025-12-31T00:00:00Z"
 //	}
 //
-// @Failure 400 {object} model.ErrorInput "Неверный формат данных"
+// @Failure 400 {object} model.ErrorInput "Неверный формат данных или заголовок If-Match"
 // @FailureExample {json} Error-Response:
 //
 //	HTTP/1.1 400 Bad Request
@@ -162,49 +365,102 @@ func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Req
 //	    "code": 400
 //	}
 //
-// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
-// @FailureExample {json} Error-Response:
-//
-//	HTTP/1.1 404 Not Found
-//	{
-//	    "error": "subscription not found",
-//	    "code": 404
-//	}
-//
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 409 {object} model.VersionConflictResponse "Подписку изменили после указанной версии (current_version), ID занят удаленной подпиской или другим тенантом, либо новая подписка пересекается с активной (model.DuplicateErrorResponse с existing_id)"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей, нет версии, ссылка на несуществующую запись или у пользователя уже предельное число подписок"
 // @Failure 500 {object} model.ServerError "Ошибка сервера"
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
 		return
 	}
 
 	var req service.UpdateSubscriptionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
 		return
 	}
 	req.ID = id
+	req.AllowDuplicate = r.URL.Query().Get("allow_duplicate") == "true"
 
-	sub, err := h.service.UpdateSubscription(r.Context(), req)
+	version, ok := ifMatchVersion(r)
+	switch {
+	case !ok:
+		h.respondWithError(w, http.StatusBadRequest, "invalid If-Match header")
+		return
+	case version != 0 && req.Version != 0 && version != req.Version:
+		h.respondWithError(w, http.StatusBadRequest, "If-Match header and version differ")
+		return
+	case version != 0:
+		req.Version = version
+	}
+
+	sub, created, err := h.service.UpsertSubscription(r.Context(), req)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	setETag(w, sub)
+	if created {
+		h.respondCreated(w, r, subscriptionLocation(sub.ID), sub)
+		return
+	}
+	h.render(w, r, http.StatusOK, sub)
+}
+
+// UpdatePrice меняет только цену подписки
+// @Summary Изменить цену подписки
+// @Description Меняет цену, оставляя остальные поля как есть, поэтому не затирает параллельные изменения других полей. После изменения в /subscriptions/stream публикуется событие price_changed со старой и новой ценой
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param input body service.UpdatePriceRequest true "Новая цена"
+// @Success 200 {object} model.Subscription "Цена изменена"
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
+// @Failure 422 {object} model.ValidationErrorResponse "Цена не положительная или не меньше максимальной"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/price [patch]
+func (h *SubscriptionHandler) UpdatePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	var req service.UpdatePriceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+
+	sub, err := h.service.UpdatePrice(r.Context(), id, req.Price)
 	if err != nil {
 		if errors.Is(err, model.ErrNotFound) {
-			respondWithError(w, http.StatusNotFound, "subscription not found")
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.storeError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, sub)
+	setETag(w, sub)
+	h.render(w, r, http.StatusOK, sub)
 }
 
 // DeleteSubscription удаляет подписку
 // @Summary Удалить подписку
-// @Description Удаляет подписку по ID
+// @Description Удаляет подписку по ID. Запись остается с отметкой deleted_at, чтобы ее учитывал ежедневный отчет, но больше нигде не видна; повторное удаление возвращает 404
 // @Tags Subscriptions
+// @Security Tenant
 // @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
 // @Success 204 "Подписка успешно удалена"
 // @Failure 400 {object} model.ErrorInput "Неверный ID подписки"
@@ -216,6 +472,7 @@ func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.
 //	    "code": 400
 //	}
 //
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
 // @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
 // @FailureExample {json} Error-Response:
 //
@@ -225,38 +482,56 @@ func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.
 //	    "code": 404
 //	}
 //
+// @Failure 409 {object} model.ErrorResponse "Подписка используется другими записями"
 // @Failure 500 {object} model.ServerError "Ошибка сервера"
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
 		return
 	}
 
 	if err := h.service.DeleteSubscription(r.Context(), id); err != nil {
 		if errors.Is(err, model.ErrNotFound) {
-			respondWithError(w, http.StatusNotFound, "subscription not found")
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
+			return
+		}
+		// On delete a foreign key fires because other rows still point here.
+		if errors.Is(err, model.ErrInvalidReference) {
+			h.respondWithError(w, http.StatusConflict, "subscription is still referenced by other records")
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.internalError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusNoContent, nil)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // ListSubscriptions возвращает список подписок с фильтрацией
 // @Summary Список подписок
-// @Description Возвращает подписки с возможностью фильтрации
+// @Description Возвращает страницу подписок, подходящих под фильтр, в порядке даты начала. Без limit страница содержит 50 подписок. С envelope=true вместо массива возвращается model.SubscriptionListResponse: страница, общее количество совпадений (count), limit и offset
 // @Tags Subscriptions
 // @Produce json
-// @Param user_id query string false "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
-// @Param service_name query string false "Название сервиса" example(Yandex Plus)
-// @Param from_date query string false "Начальная дата (RFC3339)" example(2025-01-01T00:00:00Z)
-// @Param to_date query string false "Конечная дата (RFC3339)" example(2025-12-31T00:00:00Z)
+// @Security Tenant
+// @Param user_id query string false "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Param active_on query string false "Только подписки, активные в этот момент (RFC3339, YYYY-MM-DD или MM-YYYY); нельзя сочетать с from_date и to_date" example(2025-03-01)
+// @Param only_active query bool false "Скрыть подписки, у которых end_date уже прошла; бессрочные и еще не начавшиеся остаются" default(false)
+// @Param include_ended query bool false "Обратный к only_active: false скрывает закончившиеся подписки" default(true)
+// @Param shared_with_me query bool false "Включить подписки, к которым пользователю user_id открыт доступ"
+// @Param pinned_only query bool false "Только подписки, закрепленные пользователем user_id (требует user_id)"
+// @Param q query string false "Часть названия сервиса, без учета регистра; service_name по-прежнему ищет точное совпадение" example(netfl)
+// @Param limit query int false "Размер страницы; больше максимума (500 по умолчанию) уменьшается до него" default(50)
+// @Param offset query int false "Сколько подписок пропустить" default(0)
+// @Param envelope query bool false "Вернуть страницу в конверте model.SubscriptionListResponse вместе с общим количеством" default(false)
 // @Success 200 {array} model.Subscription
+// @Header 200 {integer} X-Total-Count "Общее количество подписок, подходящих под фильтр"
+// @Header 200 {string} X-Max-Page-Size-Applied "true, если limit уменьшен до максимального размера страницы"
 // @SuccessExample {json} Success-Response:
 //
 //	HTTP/1.1 200 OK
@@ -266,107 +541,863 @@ func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.
 //	        "service_name": "Yandex Plus",
 //	        "price": 599,
 //	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
-//	        "start_date": "2025-01-01T00:00:00Z"
+//	        "start_date": "2025-01-01T00:00:00Z",
+//	        "billing_cycle": "monthly",
+//	        "pinned": true
 //	    }
 //	]
 //
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса, from_date позже to_date, нулевой user_id, active_on вместе с from_date или to_date, only_active противоречит include_ended, pinned_only без user_id, limit меньше 1 или отрицательный offset"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
 // @Failure 500 {object} model.ServerError "Ошибка сервера"
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
-	filter := model.SubscriptionFilter{
-		UserID:      getUUIDQueryParam(r, "user_id"),
-		ServiceName: getStringQueryParam(r, "service_name"),
-		FromDate:    getTimeQueryParam(r, "from_date"),
-		ToDate:      getTimeQueryParam(r, "to_date"),
+	q := newQueryParams(r)
+	filter := filterFromQuery(q)
+	setActiveOn(&filter, q)
+	setOnlyActive(&filter, q)
+	filter.SharedWithMe = q.Bool("shared_with_me")
+	filter.PinnedOnly = q.Bool("pinned_only")
+	filter.Search = q.ServiceName("q")
+	page := parsePagination(q, h.maxPageSize)
+	filter.Limit, filter.Offset = page.Limit, page.Offset
+	envelope := q.Bool("envelope")
+	checkFilter(filter, q)
+	if !h.checkQuery(w, r, q) {
+		return
 	}
 
-	subs, err := h.service.ListSubscriptions(r.Context(), filter)
+	result, err := h.service.ListSubscriptions(r.Context(), filter)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.filterError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, subs)
+	setTotalCount(w, result.TotalCount)
+	setPageHeaders(w, page)
+	if !envelope {
+		h.render(w, r, http.StatusOK, result.Items)
+		return
+	}
+	items := result.Items
+	if items == nil {
+		items = []*model.Subscription{}
+	}
+	h.render(w, r, http.StatusOK, model.SubscriptionListResponse{
+		Subscriptions: items,
+		Count:         result.TotalCount,
+		Limit:         page.Limit,
+		Offset:        page.Offset,
+	})
 }
 
 // GetTotalCost возвращает суммарную стоимость подписок
 // @Summary Сумма подписок
-// @Description Возвращает общую стоимость подписок за период
+// @Description Возвращает общую стоимость подписок за период. По умолчанию (mode=prorated) цена подписки учитывается за каждый месяц, в котором она активна внутри периода; месяц считается целиком, даже если подписка активна в нем один день. Без to_date период заканчивается текущим моментом. mode=flat учитывает цену каждой подписки один раз. source=billing_history суммирует вместо цен платежи подходящих подписок, проведенные с from_date по to_date включительно. С параметром currency сумма дополнительно пересчитывается в указанную валюту
 // @Tags Subscriptions
 // @Produce json
-// @Param user_id query string false "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
-// @Param service_name query string false "Название сервиса" example(Yandex Plus)
-// @Param from_date query string false "Начальная дата (RFC3339)" example(2025-01-01T00:00:00Z)
-// @Param to_date query string false "Конечная дата (RFC3339)" example(2025-12-31T00:00:00Z)
+// @Security Tenant
+// @Param user_id query string false "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Param active_on query string false "Только подписки, активные в этот момент (RFC3339, YYYY-MM-DD или MM-YYYY); нельзя сочетать с from_date и to_date" example(2025-03-01)
+// @Param only_active query bool false "Скрыть подписки, у которых end_date уже прошла; бессрочные и еще не начавшиеся остаются" default(false)
+// @Param include_ended query bool false "Обратный к only_active: false скрывает закончившиеся подписки" default(true)
+// @Param mode query string false "Способ подсчета цен; не сочетается с source=billing_history" Enums(prorated, flat) default(prorated)
+// @Param source query string false "Что суммировать: цены подписок или платежи из истории оплат, проведенные за период" Enums(prices, billing_history) default(prices)
+// @Param currency query string false "Валюта для пересчета (ISO 4217)" example(USD)
 // @Success 200 {object} model.TotalCostResponse
 // @SuccessExample {json} Success-Response:
 //
 //	HTTP/1.1 200 OK
 //	{
-//	    "total": 1500
+//	    "total": 1500,
+//	    "mode": "prorated",
+//	    "currency": "RUB",
+//	    "converted_total": 18.5,
+//	    "target_currency": "USD"
 //	}
 //
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса, from_date позже to_date, нулевой user_id, active_on вместе с from_date или to_date, only_active противоречит include_ended, слишком большой период, неизвестный mode или source, mode вместе с source=billing_history или неподдерживаемая валюта"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
 // @Failure 500 {object} model.ServerError "Ошибка сервера"
 // @Router /subscriptions/total [get]
 func (h *SubscriptionHandler) GetTotalCost(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	req := service.TotalCostRequest{Filter: filterFromQuery(q)}
+	setActiveOn(&req.Filter, q)
+	setOnlyActive(&req.Filter, q)
+	if m := q.String("mode"); m != nil {
+		req.Mode = model.TotalMode(*m)
+	}
+	if src := q.String("source"); src != nil {
+		req.Source = model.TotalSource(*src)
+	}
+	if c := q.String("currency"); c != nil {
+		req.Currency = *c
+	}
+	checkFilter(req.Filter, q)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	total, err := h.service.GetTotalCost(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, currency.ErrUnsupportedCurrency) {
+			h.respondWithError(w, http.StatusBadRequest, "unsupported currency")
+			return
+		}
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, total)
+}
+
+// GetTeamTotalCost возвращает расходы каждого пользователя команды
+// @Summary Расходы команды по пользователям
+// @Description Сумма подписок каждого из перечисленных пользователей за период, считается так же, как /subscriptions/total с mode=prorated. Пользователи без расходов возвращаются с нулем
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string true "ID пользователя; повторите параметр или перечислите ID через запятую, до 100 участников" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода, по умолчанию текущий момент (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Success 200 {object} model.TeamTotalCost
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "totals": {
+//	        "60601fee-2bf1-4721-ae6f-7636e79a0cba": 1800,
+//	        "7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b": 1200
+//	    },
+//	    "total": 3000
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Нет user_id, слишком много пользователей или некорректный период"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/team-total [get]
+func (h *SubscriptionHandler) GetTeamTotalCost(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	q.Require("user_id")
+	userIDs := q.UUIDs("user_id")
 	filter := model.SubscriptionFilter{
-		UserID:      getUUIDQueryParam(r, "user_id"),
-		ServiceName: getStringQueryParam(r, "service_name"),
-		FromDate:    getTimeQueryParam(r, "from_date"),
-		ToDate:      getTimeQueryParam(r, "to_date"),
+		FromDate: q.Date("from_date"),
+		ToDate:   q.Date("to_date"),
+	}
+	setServiceNames(&filter, q)
+	if !h.checkQuery(w, r, q) {
+		return
 	}
 
-	total, err := h.service.GetTotalCost(r.Context(), filter)
+	totals, err := h.service.GetTeamTotalCost(r.Context(), userIDs, filter)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.filterError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, map[string]int{"total": total})
+	resp := model.TeamTotalCost{Totals: totals}
+	for _, total := range totals {
+		resp.Total += total
+	}
+	h.render(w, r, http.StatusOK, resp)
 }
 
-// ***
-// Helper funcs
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// GetMonthlyCost возвращает помесячные расходы на подписки
+// @Summary Расходы по месяцам
+// @Description Возвращает по одной записи на каждый календарный месяц периода, включая месяцы без расходов. Сумма месяца складывается из месячного эквивалента цены всех подписок, активных в этом месяце хотя бы один день. Без to_date период заканчивается текущим месяцем; длина периода ограничена так же, как для /subscriptions/total
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string false "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string true "Первый месяц ряда (RFC3339, YYYY-MM-DD или MM-YYYY)" example(01-2025)
+// @Param to_date query string false "Последний месяц ряда (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Success 200 {array} model.MonthlyCost
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "month": "2025-01-01T00:00:00Z",
+//	        "total": 1500
+//	    },
+//	    {
+//	        "month": "2025-02-01T00:00:00Z",
+//	        "total": 0
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса, нет from_date, from_date позже to_date или слишком большой период"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/total/monthly [get]
+func (h *SubscriptionHandler) GetMonthlyCost(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	filter := filterFromQuery(q)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	months, err := h.service.GetMonthlyCost(r.Context(), filter)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, months)
+}
+
+// ComparePeriods сравнивает расходы на подписки за два периода
+// @Summary Сравнение расходов за два периода
+// @Description Считает расходы за периоды A и B так же, как /subscriptions/total с mode=prorated, и возвращает обе суммы, разницу B минус A, ее процент от суммы A (null, если за период A расходов не было) и до 5 сервисов, расходы на которые изменились сильнее всего в любую сторону. Границы периодов входят в них. Так как месяц учитывается целиком, периоды не должны пересекаться и не должны задевать один и тот же календарный месяц; длина каждого периода ограничена так же, как для /subscriptions/total
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param period_a_from query string true "Начало периода A (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param period_a_to query string true "Конец периода A (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-03-31)
+// @Param period_b_from query string true "Начало периода B (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-04-01)
+// @Param period_b_to query string true "Конец периода B (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-06-30)
+// @Param user_id query string false "ID пользователя; без него считаются расходы всего тенанта" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {object} model.PeriodComparison
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "period_a": {"from": "2025-01-01T00:00:00Z", "to": "2025-03-31T00:00:00Z", "total": 14400},
+//	    "period_b": {"from": "2025-04-01T00:00:00Z", "to": "2025-06-30T00:00:00Z", "total": 16200},
+//	    "delta": 1800,
+//	    "percent_change": 12.5,
+//	    "top_movers": [
+//	        {"service_name": "netflix", "total_a": 2997, "total_b": 3597, "delta": 600}
+//	    ],
+//	    "currency": "RUB"
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Нет или неверная граница периода, период задан наоборот, слишком длинный период или периоды пересекаются"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/total/compare [get]
+func (h *SubscriptionHandler) ComparePeriods(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	bound := func(name string) time.Time {
+		q.Require(name)
+		if d := q.Date(name); d != nil {
+			return *d
+		}
+		return time.Time{}
+	}
+	req := service.ComparePeriodsRequest{
+		UserID:      q.UUID("user_id"),
+		PeriodAFrom: bound("period_a_from"),
+		PeriodATo:   bound("period_a_to"),
+		PeriodBFrom: bound("period_b_from"),
+		PeriodBTo:   bound("period_b_to"),
+	}
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	cmp, err := h.service.ComparePeriods(r.Context(), req)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, cmp)
+}
+
+// ListExpiringSoonByService возвращает количество подписок, истекающих в ближайшие дни
+// @Summary Скоро истекающие подписки по сервисам
+// @Description Для каждого сервиса возвращает количество подписок, у которых end_date наступит в ближайшие days дней, и самую раннюю дату окончания
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param days query int false "Горизонт в днях (1-365)" default(7)
+// @Param user_id query string false "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {array} model.ExpiringServiceSummary
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "service_name": "Netflix",
+//	        "count": 2,
+//	        "earliest_expiry": "2025-09-12T00:00:00Z"
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверное значение days или user_id"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/expiring-soon/by-service [get]
+func (h *SubscriptionHandler) ListExpiringSoonByService(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := q.UUID("user_id")
+	days := q.Int("days", defaultExpiringDays)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	summaries, err := h.service.ListExpiringSoonByService(r.Context(), userID, days)
+	if err != nil {
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			h.respondWithJSON(w, http.StatusBadRequest, model.ValidationErrorResponse{
+				Error:  model.ErrValidation.Error(),
+				Fields: verr.Fields,
+			})
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, summaries)
+}
+
+// ListUpcomingRenewals возвращает подписки, которые продлеваются в ближайшие дни
+// @Summary Ближайшие продления
+// @Description Подписки, следующее продление которых наступит в ближайшие days дней (сегодня включительно), по возрастанию даты продления. Продление наступает через каждый период оплаты от start_date в тот же день месяца; если в месяце нет такого дня, то в последний день месяца (подписка от 31 января продлевается 28 февраля и 31 марта). Подписка, которая заканчивается раньше продления или в его день, не продлевается
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param days query int false "Горизонт в днях (1-365)" default(14)
+// @Param user_id query string false "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {array} model.Subscription
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "id": "550e8400-e29b-41d4-a716-446655440000",
+//	        "service_name": "Yandex Plus",
+//	        "price": 599,
+//	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	        "start_date": "2025-01-31T00:00:00Z",
+//	        "billing_cycle": "monthly",
+//	        "next_renewal_date": "2025-02-28T00:00:00Z"
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверное значение days или user_id"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/upcoming [get]
+func (h *SubscriptionHandler) ListUpcomingRenewals(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := q.UUID("user_id")
+	days := q.Int("days", defaultUpcomingDays)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	upcoming, err := h.service.ListUpcomingRenewals(r.Context(), userID, days)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, upcoming)
 }
 
-func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(payload)
+// RankSubscriptions ранжирует подписки по стоимости дня
+// @Summary Рейтинг подписок по стоимости дня
+// @Description Стоимость дня подписки с end_date - все ее списания до end_date, деленные на число дней между start_date и end_date; бессрочной - цена, деленная на среднюю длину периода оплаты (365 дней, деленные на число периодов в году). При равной стоимости подписки идут по названию сервиса. Подписки, стоимость дня которых не посчитать (короче дня), идут последними без cost_per_day и rank
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string false "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param sort query string false "По чему ранжировать" Enums(cost_per_day) default(cost_per_day)
+// @Param order query string false "asc - сначала самые выгодные, desc - самые дорогие" Enums(asc, desc) default(asc)
+// @Success 200 {array} model.RankedSubscription
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "id": "550e8400-e29b-41d4-a716-446655440000",
+//	        "service_name": "yandex plus",
+//	        "price": 599,
+//	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	        "start_date": "2025-01-01T00:00:00Z",
+//	        "billing_cycle": "monthly",
+//	        "version": 1,
+//	        "cost_per_day": 19.69,
+//	        "rank": 1
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный user_id, sort или order"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/rank [get]
+func (h *SubscriptionHandler) RankSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	req := service.RankRequest{
+		UserID: q.UUID("user_id"),
+		Sort:   q.get("sort"),
+		Order:  q.get("order"),
+	}
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	ranked, err := h.service.RankSubscriptions(r.Context(), req)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, ranked)
 }
 
-func getUUIDQueryParam(r *http.Request, param string) *uuid.UUID {
-	val := r.URL.Query().Get(param)
-	if val == "" {
-		return nil
+// ListExpiredSubscriptions возвращает подписки с истекшим сроком действия
+// @Summary Истекшие подписки
+// @Description Возвращает подписки, у которых end_date уже прошла, с количеством дней с момента окончания
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string false "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Success 200 {array} model.ExpiredSubscription
+// @Header 200 {integer} X-Total-Count "Количество истекших подписок"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "id": "550e8400-e29b-41d4-a716-446655440000",
+//	        "service_name": "Yandex Plus",
+//	        "price": 599,
+//	        "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	        "start_date": "2025-01-01T00:00:00Z",
+//	        "end_date": "2025-02-01T00:00:00Z",
+//	        "expired_for_days": 14
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса или from_date позже to_date"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/expired [get]
+func (h *SubscriptionHandler) ListExpiredSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	filter := filterFromQuery(q)
+	if !h.checkQuery(w, r, q) {
+		return
 	}
-	id, err := uuid.Parse(val)
+
+	subs, err := h.service.ListExpiredSubscriptions(r.Context(), filter)
 	if err != nil {
-		return nil
+		h.filterError(w, r, err)
+		return
 	}
-	return &id
+
+	setTotalCount(w, len(subs))
+	h.render(w, r, http.StatusOK, subs)
 }
 
-func getStringQueryParam(r *http.Request, param string) *string {
-	val := r.URL.Query().Get(param)
-	if val == "" {
-		return nil
+// CleanupExpiredSubscriptions удаляет истекшие подписки пользователя
+// @Summary Очистить истекшие подписки
+// @Description Помечает удаленными все истекшие подписки пользователя и возвращает их количество
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {object} model.CleanupResponse
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "deleted": 3
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Не указан или неверный ID пользователя"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/expired/cleanup [post]
+func (h *SubscriptionHandler) CleanupExpiredSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	q.Require("user_id")
+	userID := q.UUID("user_id")
+	if !h.checkQuery(w, r, q) {
+		return
 	}
-	return &val
+
+	deleted, err := h.service.CleanupExpiredSubscriptions(r.Context(), *userID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, model.CleanupResponse{Deleted: deleted})
 }
 
-func getTimeQueryParam(r *http.Request, param string) *time.Time {
-	val := r.URL.Query().Get(param)
-	if val == "" {
-		return nil
+// StreamSubscriptionChanges транслирует изменения подписок
+// @Summary Поток изменений подписок
+// @Description Server-Sent Events: каждое создание, изменение или удаление подписки приходит строкой "data: {...}". Изменение цены дополнительно приходит событием price_changed с полями old_price и new_price. Превышение лимита расходов приходит событием spending_limit_breached без id, пользователь указан в user_id
+// @Tags Subscriptions
+// @Produce text/event-stream
+// @Security Tenant
+// @Success 200 {object} model.SubscriptionEvent "Событие об изменении подписки"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	data: {"event":"created","id":"550e8400-e29b-41d4-a716-446655440000"}
+//
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 503 {object} model.ErrorResponse "Поток изменений не настроен"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/stream [get]
+func (h *SubscriptionHandler) StreamSubscriptionChanges(w http.ResponseWriter, r *http.Request) {
+	changes, err := h.service.SubscribeToChanges(r.Context())
+	if err != nil {
+		if errors.Is(err, service.ErrChangesUnavailable) {
+			h.respondWithError(w, http.StatusServiceUnavailable, "change stream unavailable")
+			return
+		}
+		h.internalError(w, r, err)
+		return
 	}
-	t, err := time.Parse(time.RFC3339, val)
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's WriteTimeout by design.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.log.Error("streaming is not supported", slog.String("error", err.Error()))
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-changes:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.log.Error("failed to encode subscription event", slog.String("error", err.Error()))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// GetCostByCycle возвращает расходы в разбивке по периодам оплаты
+// @Summary Расходы по периодам оплаты
+// @Description Суммирует стоимость подписок отдельно для каждого периода оплаты (weekly, monthly, quarterly, annual) и пересчитывает каждую сумму в месячный эквивалент
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string false "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Success 200 {array} model.BillingCycleSummary
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "billing_cycle": "annual",
+//	        "total": 2400,
+//	        "count": 1,
+//	        "monthly_equivalent": 200
+//	    },
+//	    {
+//	        "billing_cycle": "monthly",
+//	        "total": 1200,
+//	        "count": 3,
+//	        "monthly_equivalent": 1200
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса или from_date позже to_date"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/summary/by-cycle [get]
+func (h *SubscriptionHandler) GetCostByCycle(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	filter := filterFromQuery(q)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	summaries, err := h.service.GetCostByCycle(r.Context(), filter)
 	if err != nil {
-		return nil
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, summaries)
+}
+
+// GetPriceStats возвращает статистику цен подписок
+// @Summary Статистика цен
+// @Description Возвращает количество подписок, минимальную, максимальную, среднюю и медианную цену среди подписок, подходящих под фильтр. Если подписок нет, все значения равны 0
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string false "ID пользователя; повторите параметр или перечислите через запятую, чтобы выбрать нескольких" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param service_name query string false "Название сервиса (без учета регистра и пробелов по краям); повторите параметр или перечислите через запятую, чтобы выбрать несколько" example(Yandex Plus)
+// @Param from_date query string false "Начало периода: подписки, закончившиеся раньше, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода: подписки, начавшиеся позже, не учитываются (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Success 200 {object} model.PriceStats
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "count": 12,
+//	    "min_price": 199,
+//	    "max_price": 1299,
+//	    "avg_price": 574.5,
+//	    "median_price": 499
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса или from_date позже to_date"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/stats [get]
+func (h *SubscriptionHandler) GetPriceStats(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	filter := filterFromQuery(q)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	stats, err := h.service.GetPriceStats(r.Context(), filter)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, stats)
+}
+
+// ListServices возвращает список сервисов с количеством подписок
+// @Summary Список сервисов
+// @Description Возвращает названия сервисов и количество подписок на каждый из них
+// @Tags Services
+// @Produce json
+// @Security Tenant
+// @Param user_id query string false "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {array} model.ServiceSummary
+// @Header 200 {integer} X-Total-Count "Количество сервисов"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {
+//	        "service_name": "Netflix",
+//	        "subscription_count": 3
+//	    }
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Некорректные параметры запроса"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /services [get]
+func (h *SubscriptionHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := q.UUID("user_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	services, err := h.service.ListServices(r.Context(), userID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, len(services))
+	h.render(w, r, http.StatusOK, services)
+}
+
+// ShareSubscription открывает доступ к подписке другому пользователю
+// @Summary Поделиться подпиской
+// @Description Открывает пользователю доступ к подписке (например, для семейного тарифа)
+// @Tags Shares
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param input body service.ShareSubscriptionRequest true "Пользователь и уровень доступа (read или write)"
+// @Success 201 {object} model.ShareEntry "Доступ открыт"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 201 Created
+//	{
+//	    "subscription_id": "550e8400-e29b-41d4-a716-446655440000",
+//	    "user_id": "7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b",
+//	    "permission": "read",
+//	    "created_at": "2025-08-12T00:00:00Z"
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/shares [post]
+func (h *SubscriptionHandler) ShareSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	var req service.ShareSubscriptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.SubscriptionID = id
+
+	if req.UserID == uuid.Nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	if req.Permission == "" {
+		req.Permission = model.PermissionRead
+	}
+	if !req.Permission.Valid() {
+		h.respondWithError(w, http.StatusBadRequest, "permission must be read or write")
+		return
+	}
+
+	share, err := h.service.ShareSubscription(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
+			return
+		}
+		h.storeError(w, r, err)
+		return
+	}
+
+	// No Location: a single share cannot be read back, only listed with
+	// the others under /subscriptions/{id}/shares.
+	h.render(w, r, http.StatusCreated, share)
+}
+
+// GetSharedUsers возвращает пользователей, которым открыт доступ к подписке
+// @Summary Список доступов к подписке
+// @Description Возвращает пользователей, с которыми поделились подпиской
+// @Tags Shares
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Success 200 {array} model.ShareEntry
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/shares [get]
+func (h *SubscriptionHandler) GetSharedUsers(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	shares, err := h.service.GetSharedUsers(r.Context(), id)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, len(shares))
+	h.render(w, r, http.StatusOK, shares)
+}
+
+// UnshareSubscription закрывает доступ пользователя к подписке
+// @Summary Закрыть доступ к подписке
+// @Description Удаляет доступ пользователя к подписке
+// @Tags Shares
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param user_id path string true "ID пользователя" example(7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b)
+// @Success 204 "Доступ закрыт"
+// @Failure 400 {object} model.ErrorInput "Неверный ID"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Доступ не найден"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/shares/{user_id} [delete]
+func (h *SubscriptionHandler) UnshareSubscription(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+	userID, err := uuid.Parse(vars["user_id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if err := h.service.UnshareSubscription(r.Context(), id, userID); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "share not found")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ***
+// Helper funcs
+
+// setTotalCount exposes the number of matching records to clients that
+// paginate without parsing a response envelope.
+func setTotalCount(w http.ResponseWriter, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
+}
+
+// setETag exposes the subscription version, so a client can send it back
+// in If-Match on the next PUT.
+func setETag(w http.ResponseWriter, sub *model.Subscription) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(sub.Version)))
+	w.Header().Add("Access-Control-Expose-Headers", "ETag")
+}
+
+// ifMatchVersion reads the version from an If-Match header holding an ETag
+// set by setETag. It returns 0 when the header is absent and false when it
+// holds anything but a single positive version.
+func ifMatchVersion(r *http.Request) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version < 1 {
+		return 0, false
 	}
-	return &t
+	return version, true
 }
 
 //***
@@ -6,18 +6,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"SubscriptionAggregator/pkg/circuitbreaker"
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/middleware"
 	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/render"
+	"SubscriptionAggregator/pkg/repository"
 	"SubscriptionAggregator/pkg/service"
 )
 
@@ -30,31 +41,245 @@ func (m *MockSubscriptionService) CreateSubscription(ctx context.Context, req se
 	return args.Get(0).(*model.Subscription), args.Error(1)
 }
 
+func (m *MockSubscriptionService) FindOrCreateSubscription(ctx context.Context, req service.CreateSubscriptionRequest) (*model.Subscription, bool, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.Subscription), args.Bool(1), args.Error(2)
+}
+
+func (m *MockSubscriptionService) BulkCreateSubscriptions(ctx context.Context, reqs []service.CreateSubscriptionRequest) (*service.BulkCreateResult, error) {
+	args := m.Called(ctx, reqs)
+	return args.Get(0).(*service.BulkCreateResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetUserSummary(ctx context.Context, userID uuid.UUID) (*model.UserSummary, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*model.UserSummary), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetCreationRate(ctx context.Context, from, to time.Time) (*model.CreationRate, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(*model.CreationRate), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	return args.Get(0).(*model.UserCostResult), args.Error(1)
+}
+
 func (m *MockSubscriptionService) GetSubscription(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*model.Subscription), args.Error(1)
 }
 
+func (m *MockSubscriptionService) BatchGetSubscriptions(ctx context.Context, ids []uuid.UUID) (*model.BatchGetResult, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BatchGetResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) SimulateCost(ctx context.Context, req service.SimulateCostRequest) (*model.CostSimulation, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CostSimulation), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ComparePeriods(ctx context.Context, req service.ComparePeriodsRequest) (*model.PeriodComparison, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PeriodComparison), args.Error(1)
+}
+
+func (m *MockSubscriptionService) DetectPriceAnomaly(ctx context.Context, id uuid.UUID) (*model.PriceAnomalyReport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PriceAnomalyReport), args.Error(1)
+}
+
 func (m *MockSubscriptionService) UpdateSubscription(ctx context.Context, req service.UpdateSubscriptionRequest) (*model.Subscription, error) {
 	args := m.Called(ctx, req)
 	return args.Get(0).(*model.Subscription), args.Error(1)
 }
 
+func (m *MockSubscriptionService) UpsertSubscription(ctx context.Context, req service.UpdateSubscriptionRequest) (*model.Subscription, bool, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.Subscription), args.Bool(1), args.Error(2)
+}
+
+func (m *MockSubscriptionService) UpdatePrice(ctx context.Context, id uuid.UUID, newPrice int) (*model.Subscription, error) {
+	args := m.Called(ctx, id, newPrice)
+	return args.Get(0).(*model.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BulkUpdatePrice(ctx context.Context, serviceName string, newPrice int) (int, error) {
+	args := m.Called(ctx, serviceName, newPrice)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSubscriptionService) UpdateServicePrice(ctx context.Context, req service.UpdateServicePriceRequest) (*model.ServicePriceUpdateResult, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.ServicePriceUpdateResult), args.Error(1)
+}
+
 func (m *MockSubscriptionService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+func (m *MockSubscriptionService) ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(*model.ListResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error) {
 	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.BillingCycleSummary), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CreateSubscriptionWithShares(ctx context.Context, req service.CreateAndShareRequest) (*model.SharedSubscription, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SharedSubscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetTopServices(ctx context.Context, userID uuid.UUID, filter model.SubscriptionFilter, limit int) (*model.TopServices, error) {
+	args := m.Called(ctx, userID, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TopServices), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetForecast(ctx context.Context, userID uuid.UUID, months int) (*model.Forecast, error) {
+	args := m.Called(ctx, userID, months)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Forecast), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ProjectCosts(ctx context.Context, userID uuid.UUID, months int) ([]model.ProjectedCost, error) {
+	args := m.Called(ctx, userID, months)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ProjectedCost), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetTeamTotalCost(ctx context.Context, userIDs []uuid.UUID, filter model.SubscriptionFilter) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx, userIDs, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
+func (m *MockSubscriptionService) RankSubscriptions(ctx context.Context, req service.RankRequest) ([]model.RankedSubscription, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RankedSubscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListUpcomingRenewals(ctx context.Context, userID *uuid.UUID, days int) ([]*model.Subscription, error) {
+	args := m.Called(ctx, userID, days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]*model.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionService) GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+func (m *MockSubscriptionService) GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error) {
 	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PriceStats), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListExpiringSoonByService(ctx context.Context, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error) {
+	args := m.Called(ctx, userID, days)
+	return args.Get(0).([]model.ExpiringServiceSummary), args.Error(1)
+}
+
+func (m *MockSubscriptionService) RecordPayment(ctx context.Context, req service.RecordPaymentRequest) (*model.Payment, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.Payment), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListPayments(ctx context.Context, subscriptionID uuid.UUID) ([]model.Payment, error) {
+	args := m.Called(ctx, subscriptionID)
+	return args.Get(0).([]model.Payment), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetTotalCost(ctx context.Context, req service.TotalCostRequest) (*model.TotalCostResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TotalCostResponse), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.MonthlyCost), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ListServices(ctx context.Context, userID *uuid.UUID) ([]*model.ServiceSummary, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.ServiceSummary), args.Error(1)
+}
+
+func (m *MockSubscriptionService) ShareSubscription(ctx context.Context, req service.ShareSubscriptionRequest) (*model.ShareEntry, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.ShareEntry), args.Error(1)
+}
+
+func (m *MockSubscriptionService) UnshareSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) error {
+	args := m.Called(ctx, subscriptionID, userID)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) GetSharedUsers(ctx context.Context, subscriptionID uuid.UUID) ([]model.ShareEntry, error) {
+	args := m.Called(ctx, subscriptionID)
+	return args.Get(0).([]model.ShareEntry), args.Error(1)
+}
+
+func (m *MockSubscriptionService) PinSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) (*model.Pin, error) {
+	args := m.Called(ctx, subscriptionID, userID)
+	return args.Get(0).(*model.Pin), args.Error(1)
+}
+
+func (m *MockSubscriptionService) UnpinSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) error {
+	args := m.Called(ctx, subscriptionID, userID)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ListExpiredSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]model.ExpiredSubscription, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.ExpiredSubscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CleanupExpiredSubscriptions(ctx context.Context, userID uuid.UUID) (int, error) {
+	args := m.Called(ctx, userID)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockSubscriptionService) SubscribeToChanges(ctx context.Context) (<-chan model.SubscriptionEvent, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(<-chan model.SubscriptionEvent), args.Error(1)
+}
+
 func newTestRequest(method, path string, body interface{}) *http.Request {
 	var buf bytes.Buffer
 	if body != nil {
@@ -63,9 +288,12 @@ func newTestRequest(method, path string, body interface{}) *http.Request {
 	return httptest.NewRequest(method, path, &buf)
 }
 
+// testMaxPageSize is the page size cap of handlers built by tests.
+const testMaxPageSize = 500
+
 func newTestHandler() (*SubscriptionHandler, *MockSubscriptionService) {
 	mockSvc := &MockSubscriptionService{}
-	return NewSubscriptionHandler(mockSvc), mockSvc
+	return NewSubscriptionHandler(mockSvc, testMaxPageSize, slog.New(slog.NewTextHandler(io.Discard, nil))), mockSvc
 }
 
 func parseResponse(t *testing.T, w *httptest.ResponseRecorder, dest interface{}) {
@@ -83,7 +311,7 @@ func TestCreateSubscription_Success(t *testing.T) {
 		ServiceName: "Yandex Plus",
 		Price:       599,
 		UserID:      uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
-		StartDate:   time.Now().Round(0),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	expectedSub := &model.Subscription{
@@ -103,6 +331,7 @@ func TestCreateSubscription_Success(t *testing.T) {
 	var response model.Subscription
 	parseResponse(t, w, &response)
 	assert.Equal(t, *expectedSub, response)
+	assert.Equal(t, "/subscriptions/"+response.ID.String(), w.Header().Get("Location"))
 	mockSvc.AssertExpectations(t)
 }
 
@@ -127,7 +356,7 @@ func TestCreateSubscription_ServiceError(t *testing.T) {
 		ServiceName: "Yandex Plus",
 		Price:       599,
 		UserID:      uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
-		StartDate:   time.Now().Round(0),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	mockSvc.On("CreateSubscription", mock.Anything, reqBody).Return(&model.Subscription{}, errors.New("db error"))
@@ -138,7 +367,53 @@ func TestCreateSubscription_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	var response map[string]string
 	parseResponse(t, w, &response)
-	assert.Equal(t, "db error", response["error"])
+	assert.Equal(t, "internal server error", response["error"])
+	assert.NotContains(t, w.Body.String(), "db error")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestInternalError_HidesDetailsAndLogsThem(t *testing.T) {
+	var logs bytes.Buffer
+	mockSvc := &MockSubscriptionService{}
+	h := NewSubscriptionHandler(mockSvc, testMaxPageSize, slog.New(slog.NewTextHandler(&logs, nil)))
+	w := httptest.NewRecorder()
+
+	repoErr := errors.New(`failed to list subscriptions: repository.postgresql.List: pq: relation "subscriptions" does not exist`)
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: defaultPageSize}).Return((*model.ListResult)(nil), repoErr)
+
+	router := mux.NewRouter()
+	router.Use(middleware.RequestIDMiddleware())
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	r.Header.Set(middleware.RequestIDHeader, "req-42")
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var response model.ServerError
+	parseResponse(t, w, &response)
+	assert.Equal(t, model.ServerError{Error: "internal server error", RequestID: "req-42"}, response)
+	assert.NotContains(t, w.Body.String(), "pq:")
+	assert.NotContains(t, w.Body.String(), "repository.postgresql")
+	assert.Contains(t, logs.String(), "request_id=req-42")
+	assert.Contains(t, logs.String(), "does not exist")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestInternalError_OpenCircuitIs503(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	err := fmt.Errorf("failed to get subscription: %w", circuitbreaker.ErrCircuitOpen)
+	mockSvc.On("GetSubscription", mock.Anything, mock.Anything).Return((*model.Subscription)(nil), err)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/"+uuid.NewString(), nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error":"database unavailable, try again later"}`, w.Body.String())
 	mockSvc.AssertExpectations(t)
 }
 
@@ -152,7 +427,8 @@ func TestGetSubscription_Success(t *testing.T) {
 		ServiceName: "Yandex Plus",
 		Price:       599,
 		UserID:      uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
-		StartDate:   time.Now().Round(0),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Version:     2,
 	}
 
 	mockSvc.On("GetSubscription", mock.Anything, subID).Return(expectedSub, nil)
@@ -164,6 +440,7 @@ func TestGetSubscription_Success(t *testing.T) {
 	router.ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
 	var response model.Subscription
 	parseResponse(t, w, &response)
 	assert.Equal(t, *expectedSub, response)
@@ -197,7 +474,7 @@ func TestGetSubscription_NotFound(t *testing.T) {
 	h.RegisterRoutes(router)
 
 	r := httptest.NewRequest(http.MethodGet, "/subscriptions/"+subID.String(), nil)
-	router.ServeHTTP(w, r)
+	router.ServeHTTP(w, withTenant(r))
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	var response map[string]string
@@ -215,7 +492,7 @@ func TestUpdateSubscription_Success(t *testing.T) {
 		ServiceName: "Yandex Plus Premium",
 		Price:       799,
 		UserID:      uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
-		StartDate:   time.Now().Round(0),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 
 	expectedSub := &model.Subscription{
@@ -226,9 +503,9 @@ func TestUpdateSubscription_Success(t *testing.T) {
 		StartDate:   reqBody.StartDate,
 	}
 
-	mockSvc.On("UpdateSubscription", mock.Anything, mock.MatchedBy(func(req service.UpdateSubscriptionRequest) bool {
+	mockSvc.On("UpsertSubscription", mock.Anything, mock.MatchedBy(func(req service.UpdateSubscriptionRequest) bool {
 		return req.ID == subID
-	})).Return(expectedSub, nil)
+	})).Return(expectedSub, false, nil)
 
 	router := mux.NewRouter()
 	h.RegisterRoutes(router)
@@ -243,6 +520,70 @@ func TestUpdateSubscription_Success(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestUpdatePrice(t *testing.T) {
+	invalid := &model.ValidationError{}
+	invalid.Add("price", "must be positive")
+	subID := uuid.New()
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		price    int
+		sub      *model.Subscription
+		svcErr   error
+		wantCode int
+	}{
+		{"updated", "/subscriptions/" + subID.String() + "/price", `{"price":799}`, 799, &model.Subscription{ID: subID, Price: 799}, nil, http.StatusOK},
+		{"negative price", "/subscriptions/" + subID.String() + "/price", `{"price":-1}`, -1, nil, invalid, http.StatusUnprocessableEntity},
+		{"not found", "/subscriptions/" + subID.String() + "/price", `{"price":799}`, 799, nil, fmt.Errorf("failed to update price: %w", model.ErrNotFound), http.StatusNotFound},
+		{"malformed body", "/subscriptions/" + subID.String() + "/price", `{"price":"cheap"}`, 0, nil, nil, http.StatusBadRequest},
+		{"invalid id", "/subscriptions/nope/price", `{"price":799}`, 0, nil, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			if tt.sub != nil || tt.svcErr != nil {
+				mockSvc.On("UpdatePrice", mock.Anything, subID, tt.price).Return(tt.sub, tt.svcErr)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPatch, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateSubscription_CreatesMissing(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	subID := uuid.New()
+
+	mockSvc.On("UpsertSubscription", mock.Anything, mock.MatchedBy(func(req service.UpdateSubscriptionRequest) bool {
+		return req.ID == subID && req.AllowDuplicate
+	})).Return(&model.Subscription{ID: subID, ServiceName: "netflix"}, true, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	r := newTestRequest(http.MethodPut, "/subscriptions/"+subID.String()+"?allow_duplicate=true", service.UpdateSubscriptionRequest{ServiceName: "Netflix", Price: 999})
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response model.Subscription
+	parseResponse(t, w, &response)
+	assert.Equal(t, subID, response.ID)
+	assert.Equal(t, "/subscriptions/"+response.ID.String(), w.Header().Get("Location"))
+	mockSvc.AssertExpectations(t)
+}
+
 func TestUpdateSubscription_IDMismatch(t *testing.T) {
 	h, _ := newTestHandler()
 	w := httptest.NewRecorder()
@@ -264,6 +605,72 @@ func TestUpdateSubscription_IDMismatch(t *testing.T) {
 	assert.Equal(t, "invalid subscription ID", response["error"])
 }
 
+func TestUpdateSubscription_IfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		bodyVersion int
+		wantVersion int
+		wantCode    int
+	}{
+		{"header only", `"3"`, 0, 3, http.StatusOK},
+		{"weak tag", `W/"3"`, 0, 3, http.StatusOK},
+		{"body only", "", 3, 3, http.StatusOK},
+		{"header and body agree", `"3"`, 3, 3, http.StatusOK},
+		{"header and body differ", `"3"`, 2, 0, http.StatusBadRequest},
+		{"not a version", `"abc"`, 0, 0, http.StatusBadRequest},
+		{"wildcard", "*", 0, 0, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			subID := uuid.New()
+
+			if tt.wantCode == http.StatusOK {
+				mockSvc.On("UpsertSubscription", mock.Anything, mock.MatchedBy(func(req service.UpdateSubscriptionRequest) bool {
+					return req.Version == tt.wantVersion
+				})).Return(&model.Subscription{ID: subID, Version: tt.wantVersion + 1}, false, nil)
+			}
+
+			w := httptest.NewRecorder()
+			r := newTestRequest(http.MethodPut, "/subscriptions/"+subID.String(),
+				service.UpdateSubscriptionRequest{ServiceName: "Netflix", Price: 999, Version: tt.bodyVersion})
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateSubscription_VersionConflict(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	subID := uuid.New()
+
+	mockSvc.On("UpsertSubscription", mock.Anything, mock.Anything).
+		Return((*model.Subscription)(nil), false, fmt.Errorf("failed to update subscription: %w", &model.VersionConflictError{Current: 5}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newTestRequest(http.MethodPut, "/subscriptions/"+subID.String(),
+		service.UpdateSubscriptionRequest{ServiceName: "Netflix", Price: 999, Version: 4}))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response model.VersionConflictResponse
+	parseResponse(t, w, &response)
+	assert.Equal(t, 5, response.CurrentVersion)
+}
+
 func TestDeleteSubscription_Success(t *testing.T) {
 	h, mockSvc := newTestHandler()
 	w := httptest.NewRecorder()
@@ -278,7 +685,8 @@ func TestDeleteSubscription_Success(t *testing.T) {
 	router.ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusNoContent, w.Code)
-	assert.Equal(t, 5, w.Body.Len())
+	assert.Zero(t, w.Body.Len())
+	assert.Empty(t, w.Header().Get("Content-Type"))
 	mockSvc.AssertExpectations(t)
 }
 
@@ -293,7 +701,7 @@ func TestDeleteSubscription_NotFound(t *testing.T) {
 	h.RegisterRoutes(router)
 
 	r := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+subID.String(), nil)
-	router.ServeHTTP(w, r)
+	router.ServeHTTP(w, withTenant(r))
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	var response map[string]string
@@ -324,7 +732,7 @@ func TestListSubscriptions_Success(t *testing.T) {
 		return filter.UserID != nil && *filter.UserID == userID &&
 			filter.FromDate != nil && filter.FromDate.Equal(fromDate) &&
 			filter.ToDate != nil && filter.ToDate.Equal(toDate)
-	})).Return(expectedSubs, nil)
+	})).Return(&model.ListResult{Items: expectedSubs, TotalCount: len(expectedSubs)}, nil)
 
 	router := mux.NewRouter()
 	h.RegisterRoutes(router)
@@ -338,12 +746,150 @@ func TestListSubscriptions_Success(t *testing.T) {
 	router.ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, "X-Total-Count", w.Header().Get("Access-Control-Expose-Headers"))
 	var response []*model.Subscription
 	parseResponse(t, w, &response)
 	assert.Equal(t, expectedSubs, response)
 	mockSvc.AssertExpectations(t)
 }
 
+func TestListSubscriptions_TotalCountHeader(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	subs := []*model.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), ServiceName: "Spotify", Price: 299, UserID: uuid.New(), StartDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: uuid.New(), ServiceName: "Yandex Plus", Price: 599, UserID: uuid.New(), StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: defaultPageSize}).
+		Return(&model.ListResult{Items: subs, TotalCount: len(subs)}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []*model.Subscription
+	parseResponse(t, w, &response)
+	assert.Equal(t, strconv.Itoa(len(response)), w.Header().Get("X-Total-Count"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_Envelope(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+	netflix := "netflix"
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: netflix, Price: 999, UserID: uuid.New(), StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	// The count covers every page of the filtered matches.
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{ServiceName: &netflix, Limit: 1, Offset: 5}).
+		Return(&model.ListResult{Items: []*model.Subscription{sub}, TotalCount: 12}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions?service_name=Netflix&limit=1&offset=5&envelope=true", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "12", w.Header().Get("X-Total-Count"))
+	var response model.SubscriptionListResponse
+	parseResponse(t, w, &response)
+	assert.Equal(t, model.SubscriptionListResponse{Subscriptions: []*model.Subscription{sub}, Count: 12, Limit: 1, Offset: 5}, response)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_EmptyEnvelope(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: defaultPageSize}).
+		Return(&model.ListResult{}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions?envelope=true", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"subscriptions":[],"count":0,"limit":50,"offset":0}`, w.Body.String())
+}
+
+func TestListSubscriptions_EmptyIsArray(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: defaultPageSize}).
+		Return(&model.ListResult{}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]\n", w.Body.String())
+}
+
+func TestListSubscriptions_CSV(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	sub := &model.Subscription{
+		ID:           uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		ServiceName:  "Netflix",
+		Price:        999,
+		UserID:       uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		BillingCycle: model.CycleMonthly,
+		Version:      1,
+	}
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: defaultPageSize}).
+		Return(&model.ListResult{Items: []*model.Subscription{sub}, TotalCount: 1}, nil)
+
+	router := mux.NewRouter()
+	router.Use(middleware.NegotiateMiddleware())
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	r.Header.Set("Accept", "text/csv")
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, render.CSVContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "id,service_name,price,user_id,start_date,end_date,billing_cycle,"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "550e8400-e29b-41d4-a716-446655440000,Netflix,999,60601fee-2bf1-4721-ae6f-7636e79a0cba,2025-01-01T00:00:00Z,,monthly,"), lines[1])
+}
+
+func TestGetSubscription_CSVErrorIsJSON(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	subID := uuid.New()
+	mockSvc.On("GetSubscription", mock.Anything, subID).Return(&model.Subscription{}, model.ErrNotFound)
+
+	router := mux.NewRouter()
+	router.Use(middleware.NegotiateMiddleware())
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/"+subID.String(), nil)
+	r.Header.Set("Accept", "text/csv")
+	router.ServeHTTP(w, withTenant(r))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"subscription not found"}`, w.Body.String())
+}
+
 func TestGetTotalCost_Success(t *testing.T) {
 	h, mockSvc := newTestHandler()
 	w := httptest.NewRecorder()
@@ -351,9 +897,9 @@ func TestGetTotalCost_Success(t *testing.T) {
 	serviceName := "Yandex Plus"
 	expectedTotal := 1500
 
-	mockSvc.On("GetTotalCost", mock.Anything, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
-		return f.ServiceName != nil && *f.ServiceName == serviceName
-	})).Return(expectedTotal, nil)
+	mockSvc.On("GetTotalCost", mock.Anything, mock.MatchedBy(func(req service.TotalCostRequest) bool {
+		return req.Filter.ServiceName != nil && *req.Filter.ServiceName == "yandex plus" && req.Currency == ""
+	})).Return(&model.TotalCostResponse{Total: expectedTotal, Currency: "RUB"}, nil)
 
 	router := mux.NewRouter()
 	h.RegisterRoutes(router)
@@ -363,8 +909,1260 @@ func TestGetTotalCost_Success(t *testing.T) {
 	router.ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	var response map[string]int
+	var response model.TotalCostResponse
 	parseResponse(t, w, &response)
-	assert.Equal(t, expectedTotal, response["total"])
+	assert.Equal(t, expectedTotal, response.Total)
+	assert.Equal(t, "RUB", response.Currency)
+	assert.Nil(t, response.ConvertedTotal)
 	mockSvc.AssertExpectations(t)
 }
+
+func TestGetMonthlyCost_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("GetMonthlyCost", mock.Anything, model.SubscriptionFilter{FromDate: &from, ToDate: &to}).
+		Return([]model.MonthlyCost{{Month: from, Total: 1500}, {Month: to, Total: 0}}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/total/monthly?from_date=01-2025&to_date=02-2025", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"month":"2025-01-01T00:00:00Z","total":1500},{"month":"2025-02-01T00:00:00Z","total":0}]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetMonthlyCost_ValidationIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	verr := &model.ValidationError{}
+	verr.Add("from_date", "must not be empty")
+	mockSvc.On("GetMonthlyCost", mock.Anything, model.SubscriptionFilter{}).Return([]model.MonthlyCost(nil), verr)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/total/monthly", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "from_date")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestComparePeriods_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	req := service.ComparePeriodsRequest{
+		UserID:      &userID,
+		PeriodAFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodATo:   time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		PeriodBFrom: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		PeriodBTo:   time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+	pct := 12.5
+	mockSvc.On("ComparePeriods", mock.Anything, req).Return(&model.PeriodComparison{
+		PeriodA:       model.PeriodTotal{From: req.PeriodAFrom, To: req.PeriodATo, Total: 14400},
+		PeriodB:       model.PeriodTotal{From: req.PeriodBFrom, To: req.PeriodBTo, Total: 16200},
+		Delta:         1800,
+		PercentChange: &pct,
+		TopMovers:     []model.ServiceCostChange{{ServiceName: "netflix", TotalA: 2997, TotalB: 3597, Delta: 600}},
+		Currency:      "RUB",
+	}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/total/compare?period_a_from=2025-01-01&period_a_to=2025-03-31"+
+		"&period_b_from=2025-04-01&period_b_to=2025-06-30&user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"period_a": {"from": "2025-01-01T00:00:00Z", "to": "2025-03-31T00:00:00Z", "total": 14400},
+		"period_b": {"from": "2025-04-01T00:00:00Z", "to": "2025-06-30T00:00:00Z", "total": 16200},
+		"delta": 1800,
+		"percent_change": 12.5,
+		"top_movers": [{"service_name": "netflix", "total_a": 2997, "total_b": 3597, "delta": 600}],
+		"currency": "RUB"
+	}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestComparePeriods_MissingBounds(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/total/compare?period_a_from=2025-01-01&period_b_to=bad", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	parseResponse(t, w, &resp)
+	assert.Equal(t, "is required", resp.Fields["period_a_to"])
+	assert.Equal(t, "is required", resp.Fields["period_b_from"])
+	assert.Contains(t, resp.Fields, "period_b_to")
+	assert.NotContains(t, resp.Fields, "period_a_from")
+	mockSvc.AssertNotCalled(t, "ComparePeriods", mock.Anything, mock.Anything)
+}
+
+func TestComparePeriods_ValidationIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	verr := &model.ValidationError{}
+	verr.Add("period_b_from", "periods must not overlap or share a calendar month")
+	mockSvc.On("ComparePeriods", mock.Anything, mock.Anything).Return((*model.PeriodComparison)(nil), verr)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/total/compare?period_a_from=2025-01-01&period_a_to=2025-04-10"+
+		"&period_b_from=2025-04-11&period_b_to=2025-06-30", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "share a calendar month")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTotalCost_Converted(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	converted := 18.50
+	mockSvc.On("GetTotalCost", mock.Anything, mock.MatchedBy(func(req service.TotalCostRequest) bool {
+		return req.Currency == "USD"
+	})).Return(&model.TotalCostResponse{Total: 1500, Mode: model.TotalProrated, Currency: "RUB", ConvertedTotal: &converted, TargetCurrency: "USD"}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/total?currency=USD", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"total":1500,"mode":"prorated","currency":"RUB","converted_total":18.5,"target_currency":"USD"}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTotalCost_PassesMode(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("GetTotalCost", mock.Anything, mock.MatchedBy(func(req service.TotalCostRequest) bool {
+		return req.Mode == model.TotalFlat
+	})).Return(&model.TotalCostResponse{Total: 599, Mode: model.TotalFlat, Currency: "RUB"}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/total?mode=flat", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"total":599,"mode":"flat","currency":"RUB"}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTotalCost_UnsupportedCurrency(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("GetTotalCost", mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("failed to convert total cost: %w", currency.ErrUnsupportedCurrency))
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/total?currency=XYZ", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response model.ErrorResponse
+	parseResponse(t, w, &response)
+	assert.Equal(t, "unsupported currency", response.Error)
+}
+
+func TestListServices_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	expected := []*model.ServiceSummary{
+		{ServiceName: "Netflix", SubscriptionCount: 3},
+		{ServiceName: "Yandex Plus", SubscriptionCount: 1},
+	}
+
+	mockSvc.On("ListServices", mock.Anything, &userID).Return(expected, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/services?user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	var response []map[string]interface{}
+	parseResponse(t, w, &response)
+	assert.Equal(t, []map[string]interface{}{
+		{"service_name": "Netflix", "subscription_count": float64(3)},
+		{"service_name": "Yandex Plus", "subscription_count": float64(1)},
+	}, response)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestShareSubscription_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	subID := uuid.New()
+	sharedWith := uuid.New()
+	expected := &model.ShareEntry{
+		SubscriptionID: subID,
+		UserID:         sharedWith,
+		Permission:     model.PermissionRead,
+		CreatedAt:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	mockSvc.On("ShareSubscription", mock.Anything, service.ShareSubscriptionRequest{
+		SubscriptionID: subID,
+		UserID:         sharedWith,
+		Permission:     model.PermissionRead,
+	}).Return(expected, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := newTestRequest(http.MethodPost, "/subscriptions/"+subID.String()+"/shares", map[string]string{
+		"user_id": sharedWith.String(),
+	})
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response model.ShareEntry
+	parseResponse(t, w, &response)
+	assert.Equal(t, *expected, response)
+	assert.Empty(t, w.Header().Get("Location"), "a share has no URL of its own")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestShareSubscription_InvalidPermission(t *testing.T) {
+	h, _ := newTestHandler()
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := newTestRequest(http.MethodPost, "/subscriptions/"+uuid.NewString()+"/shares", map[string]string{
+		"user_id":    uuid.NewString(),
+		"permission": "admin",
+	})
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response map[string]string
+	parseResponse(t, w, &response)
+	assert.Equal(t, "permission must be read or write", response["error"])
+}
+
+func TestUnshareSubscription_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	subID := uuid.New()
+	userID := uuid.New()
+	mockSvc.On("UnshareSubscription", mock.Anything, subID, userID).Return(nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+subID.String()+"/shares/"+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUnshareSubscription_NotFound(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	subID := uuid.New()
+	userID := uuid.New()
+	mockSvc.On("UnshareSubscription", mock.Anything, subID, userID).Return(fmt.Errorf("failed to unshare subscription: %w", model.ErrNotFound))
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+subID.String()+"/shares/"+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetSharedUsers_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	subID := uuid.New()
+	expected := []model.ShareEntry{
+		{SubscriptionID: subID, UserID: uuid.New(), Permission: model.PermissionWrite, CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	mockSvc.On("GetSharedUsers", mock.Anything, subID).Return(expected, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/"+subID.String()+"/shares", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []model.ShareEntry
+	parseResponse(t, w, &response)
+	assert.Equal(t, expected, response)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_SharedWithMe(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.New()
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{UserID: &userID, SharedWithMe: true, Limit: defaultPageSize}).
+		Return(&model.ListResult{}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions?shared_with_me=true&user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_SeveralUsers(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	alice, bob := uuid.New(), uuid.New()
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{UserIDs: []uuid.UUID{alice, bob}, Limit: defaultPageSize}).
+		Return(&model.ListResult{}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions?user_id="+alice.String()+"&user_id="+bob.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_OneBadUserIDRejectsAll(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions?user_id="+uuid.NewString()+"&user_id=bob", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":"must be UUIDs, invalid: bob"`)
+	mockSvc.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything)
+}
+
+func TestGetTeamTotalCost_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	alice, bob := uuid.New(), uuid.New()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("GetTeamTotalCost", mock.Anything, []uuid.UUID{alice, bob}, model.SubscriptionFilter{FromDate: &from}).
+		Return(map[uuid.UUID]int{alice: 1800, bob: 0}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/team-total?user_id="+alice.String()+"&user_id="+bob.String()+"&from_date=2025-01-01", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"totals":{"`+alice.String()+`":1800,"`+bob.String()+`":0},"total":1800}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTeamTotalCost_RequiresUserID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/team-total", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":"is required"`)
+	mockSvc.AssertNotCalled(t, "GetTeamTotalCost", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListExpiredSubscriptions_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.New()
+	endDate := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := []model.ExpiredSubscription{
+		{Subscription: model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: userID, StartDate: endDate.AddDate(0, -1, 0), EndDate: &endDate}, ExpiredForDays: 14},
+		{Subscription: model.Subscription{ID: uuid.New(), ServiceName: "Yandex Plus", Price: 599, UserID: userID, StartDate: endDate.AddDate(0, -1, 0), EndDate: &endDate}},
+	}
+	mockSvc.On("ListExpiredSubscriptions", mock.Anything, model.SubscriptionFilter{UserID: &userID}).Return(expected, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/expired?user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	var response []map[string]any
+	parseResponse(t, w, &response)
+	assert.Len(t, response, 2)
+	assert.EqualValues(t, 14, response[0]["expired_for_days"])
+	assert.Contains(t, response[1], "expired_for_days", "ended today is 0 days, not unknown")
+	assert.EqualValues(t, 0, response[1]["expired_for_days"])
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCleanupExpiredSubscriptions_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.New()
+	mockSvc.On("CleanupExpiredSubscriptions", mock.Anything, userID).Return(2, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/expired/cleanup?user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response model.CleanupResponse
+	parseResponse(t, w, &response)
+	assert.Equal(t, 2, response.Deleted)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCleanupExpiredSubscriptions_MissingUserID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/expired/cleanup", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":"is required"`)
+	mockSvc.AssertNotCalled(t, "CleanupExpiredSubscriptions", mock.Anything, mock.Anything)
+}
+
+func TestRespondWithJSON_EncodingErrorBecomes500(t *testing.T) {
+	var logs bytes.Buffer
+	h := NewSubscriptionHandler(&MockSubscriptionService{}, testMaxPageSize, slog.New(slog.NewTextHandler(&logs, nil)))
+	w := httptest.NewRecorder()
+
+	h.respondWithJSON(w, http.StatusOK, map[string]any{"bad": make(chan int)})
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+	assert.Contains(t, logs.String(), "failed to encode response")
+}
+
+func TestRespondWithJSON_NoBodyFor304(t *testing.T) {
+	h, _ := newTestHandler()
+	w := httptest.NewRecorder()
+
+	h.respondWithJSON(w, http.StatusNotModified, map[string]string{"ignored": "yes"})
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestStreamSubscriptionChanges_WritesEvents(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	changes := make(chan model.SubscriptionEvent, 2)
+	changes <- model.SubscriptionEvent{Event: model.EventCreated, ID: id}
+	changes <- model.SubscriptionEvent{Event: model.EventDeleted, ID: id}
+	close(changes)
+	mockSvc.On("SubscribeToChanges", mock.Anything).Return((<-chan model.SubscriptionEvent)(changes), nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/stream", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t,
+		`data: {"event":"created","id":"550e8400-e29b-41d4-a716-446655440000"}`+"\n\n"+
+			`data: {"event":"deleted","id":"550e8400-e29b-41d4-a716-446655440000"}`+"\n\n",
+		w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestStreamSubscriptionChanges_Unavailable(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("SubscribeToChanges", mock.Anything).
+		Return((<-chan model.SubscriptionEvent)(nil), service.ErrChangesUnavailable)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/stream", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+// newRepoBackedHandler wires the real service and repository over sqlmock so
+// tests exercise the full error chain from SQL result to HTTP status.
+func newRepoBackedHandler(t *testing.T) (*mux.Router, sqlmock.Sqlmock) {
+	t.Helper()
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.NewSubscriptionService(repository.NewSubscriptionRepository(db), log)
+
+	router := mux.NewRouter()
+	router.Use(middleware.TenantMiddleware(middleware.TenantSource{}))
+	NewSubscriptionHandler(svc, testMaxPageSize, log).RegisterRoutes(router)
+	return router, dbMock
+}
+
+const testTenant = "0b7e1c52-8d4f-4a3e-9c61-2f5a7d8e9b10"
+
+func withTenant(r *http.Request) *http.Request {
+	r.Header.Set(middleware.DefaultTenantHeader, testTenant)
+	return r
+}
+
+func TestRepoBackedHandler_WithoutTenantIs401(t *testing.T) {
+	router, dbMock := newRepoBackedHandler(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/"+uuid.NewString(), nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, dbMock.ExpectationsWereMet(), "no query may run without a tenant")
+}
+
+func TestUpdateSubscription_MissingIDCreates(t *testing.T) {
+	router, dbMock := newRepoBackedHandler(t)
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("FOR UPDATE").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	dbMock.ExpectQuery("end_date > ").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	dbMock.ExpectExec("INSERT INTO subscriptions").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	id := uuid.New()
+	w := httptest.NewRecorder()
+	r := newTestRequest(http.MethodPut, "/subscriptions/"+id.String(), service.UpdateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       999,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	router.ServeHTTP(w, withTenant(r))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response model.Subscription
+	parseResponse(t, w, &response)
+	assert.Equal(t, id, response.ID)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestUpdateSubscription_NilIDIsRejected(t *testing.T) {
+	router, dbMock := newRepoBackedHandler(t)
+
+	w := httptest.NewRecorder()
+	r := newTestRequest(http.MethodPut, "/subscriptions/"+uuid.Nil.String(), service.UpdateSubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       999,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	router.ServeHTTP(w, withTenant(r))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"error":"validation failed","fields":{"id":"must not be the nil UUID"}}`, w.Body.String())
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestDeleteSubscription_MissingIDReturns404(t *testing.T) {
+	router, dbMock := newRepoBackedHandler(t)
+	dbMock.ExpectQuery("FROM subscriptions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date"}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+uuid.NewString(), nil)
+	router.ServeHTTP(w, withTenant(r))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestGetSubscription_MissingIDReturns404(t *testing.T) {
+	router, dbMock := newRepoBackedHandler(t)
+	dbMock.ExpectQuery("FROM subscriptions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date"}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/"+uuid.NewString(), nil)
+	router.ServeHTTP(w, withTenant(r))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NotContains(t, w.Body.String(), "no rows")
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestCreateSubscription_ConstraintViolations(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    int
+		message string
+	}{
+		{
+			name:    "unique",
+			err:     fmt.Errorf("failed to create subscription: %w: %w", model.ErrConflict, errors.New(`pq: duplicate key value violates unique constraint "subscriptions_pkey"`)),
+			code:    http.StatusConflict,
+			message: "subscription conflicts with an existing record",
+		},
+		{
+			name:    "foreign key",
+			err:     fmt.Errorf("failed to create subscription: %w: %w", model.ErrInvalidReference, errors.New(`pq: insert or update on table "subscriptions" violates foreign key constraint`)),
+			code:    http.StatusUnprocessableEntity,
+			message: "subscription references a record that does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			w := httptest.NewRecorder()
+
+			mockSvc.On("CreateSubscription", mock.Anything, mock.Anything).Return((*model.Subscription)(nil), tt.err)
+
+			r := newTestRequest(http.MethodPost, "/subscriptions", service.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 999})
+			h.CreateSubscription(w, r)
+
+			assert.Equal(t, tt.code, w.Code)
+			var response map[string]string
+			parseResponse(t, w, &response)
+			assert.Equal(t, tt.message, response["error"])
+			assert.NotContains(t, w.Body.String(), "pq:")
+		})
+	}
+}
+
+func TestCreateSubscription_UserLimitReached(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("CreateSubscription", mock.Anything, mock.Anything).
+		Return((*model.Subscription)(nil), fmt.Errorf("failed to create subscription: %w", model.ErrLimitExceeded))
+
+	h.CreateSubscription(w, newTestRequest(http.MethodPost, "/subscriptions", service.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 999}))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var response map[string]string
+	parseResponse(t, w, &response)
+	assert.Equal(t, "user has reached their subscription limit", response["error"])
+}
+
+func TestCreateSubscription_DuplicateReturnsExistingID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+	existing := uuid.New()
+
+	mockSvc.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(req service.CreateSubscriptionRequest) bool {
+		return !req.AllowDuplicate
+	})).Return((*model.Subscription)(nil), fmt.Errorf("failed to create subscription: %w", &model.DuplicateError{ExistingID: existing}))
+
+	r := newTestRequest(http.MethodPost, "/subscriptions", service.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 999})
+	h.CreateSubscription(w, r)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response model.DuplicateErrorResponse
+	parseResponse(t, w, &response)
+	assert.Equal(t, existing, response.ExistingID)
+	assert.Equal(t, http.StatusConflict, response.Code)
+	assert.Contains(t, response.Error, "allow_duplicate=true")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateSubscription_AllowDuplicate(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(req service.CreateSubscriptionRequest) bool {
+		return req.AllowDuplicate
+	})).Return(&model.Subscription{ID: uuid.New()}, nil)
+
+	r := newTestRequest(http.MethodPost, "/subscriptions?allow_duplicate=true", service.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 999})
+	h.CreateSubscription(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateSubscription_ValidationError(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("CreateSubscription", mock.Anything, mock.Anything).Return((*model.Subscription)(nil), &model.ValidationError{
+		Fields: map[string]string{"price": "must be positive"},
+	})
+
+	body := `{"service_name":"Yandex Plus","price":-500,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"2025-01-01T00:00:00Z"}`
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBufferString(body))
+	h.CreateSubscription(w, r)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"error":"validation failed","fields":{"price":"must be positive"}}`, w.Body.String())
+}
+
+func TestUpdateSubscription_ValidationThroughService(t *testing.T) {
+	router, dbMock := newRepoBackedHandler(t)
+	w := httptest.NewRecorder()
+
+	body := `{"service_name":"Yandex Plus","price":599,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"2025-06-01T00:00:00Z","end_date":"2024-06-01T00:00:00Z"}`
+	r := httptest.NewRequest(http.MethodPut, "/subscriptions/550e8400-e29b-41d4-a716-446655440000", bytes.NewBufferString(body))
+	router.ServeHTTP(w, withTenant(r))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"error":"validation failed","fields":{"end_date":"must be after start_date"}}`, w.Body.String())
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestGetCostByCycle_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	mockSvc.On("GetCostByCycle", mock.Anything, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+		return f.UserID != nil && *f.UserID == userID
+	})).Return([]model.BillingCycleSummary{
+		{BillingCycle: model.CycleAnnual, Total: 2400, Count: 1, MonthlyEquivalent: 200},
+	}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/summary/by-cycle?user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"billing_cycle":"annual","total":2400,"count":1,"monthly_equivalent":200}]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBatchGetSubscriptions_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	found, missing := uuid.New(), uuid.New()
+	mockSvc.On("BatchGetSubscriptions", mock.Anything, []uuid.UUID{found, missing}).Return(&model.BatchGetResult{
+		Subscriptions: []*model.Subscription{{ID: found, ServiceName: "netflix", Price: 999}},
+		Missing:       []uuid.UUID{missing},
+	}, nil)
+
+	body := fmt.Sprintf(`{"ids":[%q,%q]}`, found, missing)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/batch-get", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp model.BatchGetResult
+	parseResponse(t, w, &resp)
+	if assert.Len(t, resp.Subscriptions, 1) {
+		assert.Equal(t, found, resp.Subscriptions[0].ID)
+	}
+	assert.Equal(t, []uuid.UUID{missing}, resp.Missing)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestBatchGetSubscriptions_Errors(t *testing.T) {
+	tooMany := &model.ValidationError{}
+	tooMany.Add("ids", "must list at most 200 IDs")
+
+	tests := []struct {
+		name     string
+		body     string
+		svcErr   error
+		wantCode int
+		wantBody string
+	}{
+		{"invalid id", `{"ids":["not-a-uuid"]}`, nil, http.StatusBadRequest, "invalid request payload"},
+		{"unknown field", `{"id":[]}`, nil, http.StatusBadRequest, `unknown field \"id\"`},
+		{"validation", `{"ids":[]}`, tooMany, http.StatusUnprocessableEntity, `"ids":"must list at most 200 IDs"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			if tt.svcErr != nil {
+				mockSvc.On("BatchGetSubscriptions", mock.Anything, mock.Anything).Return(nil, tt.svcErr)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/batch-get", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.svcErr == nil {
+				mockSvc.AssertNotCalled(t, "BatchGetSubscriptions", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCreateAndShareSubscription_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	owner, reader := uuid.New(), uuid.New()
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: owner}
+	mockSvc.On("CreateSubscriptionWithShares", mock.Anything, mock.MatchedBy(func(req service.CreateAndShareRequest) bool {
+		return req.Subscription.UserID == owner && len(req.ShareWith) == 1 &&
+			req.ShareWith[0].UserID == reader && req.ShareWith[0].Permission == model.PermissionWrite
+	})).Return(&model.SharedSubscription{
+		Subscription: sub,
+		Shares:       []model.ShareEntry{{SubscriptionID: sub.ID, UserID: reader, Permission: model.PermissionWrite}},
+	}, nil)
+
+	body := fmt.Sprintf(`{"subscription":{"service_name":"Netflix","price":999,"user_id":%q,"start_date":"2025-07-01"},`+
+		`"share_with":[{"user_id":%q,"permission":"write"}]}`, owner, reader)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/create-and-share", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp model.SharedSubscription
+	parseResponse(t, w, &resp)
+	assert.Equal(t, sub.ID, resp.Subscription.ID)
+	assert.Equal(t, "/subscriptions/"+resp.Subscription.ID.String(), w.Header().Get("Location"))
+	assert.Equal(t, []model.ShareEntry{{SubscriptionID: sub.ID, UserID: reader, Permission: model.PermissionWrite}}, resp.Shares)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateAndShareSubscription_ValidationError(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	verr := &model.ValidationError{}
+	verr.Add("share_with[0].user_id", "must not be the owner")
+	mockSvc.On("CreateSubscriptionWithShares", mock.Anything, mock.Anything).Return(nil, verr)
+
+	body := `{"subscription":{"service_name":"Netflix","price":999,"start_date":"2025-07-01"},"share_with":[{}]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/create-and-share", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"share_with[0].user_id":"must not be the owner"`)
+}
+
+func TestGetPriceStats_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	serviceName := "netflix"
+	mockSvc.On("GetPriceStats", mock.Anything, model.SubscriptionFilter{ServiceName: &serviceName}).
+		Return(&model.PriceStats{Count: 3, MinPrice: 499, MaxPrice: 999, AvgPrice: 665.67, MedianPrice: 499}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/stats?service_name=Netflix", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"count":3,"min_price":499,"max_price":999,"avg_price":665.67,"median_price":499}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetPriceStats_InvalidQuery(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/stats?user_id=not-a-uuid", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "GetPriceStats", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_Idempotent(t *testing.T) {
+	tests := []struct {
+		name    string
+		created bool
+		status  int
+	}{
+		{"existing", false, http.StatusOK},
+		{"created", true, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			w := httptest.NewRecorder()
+
+			reqBody := service.CreateSubscriptionRequest{
+				ServiceName: "Yandex Plus",
+				Price:       599,
+				UserID:      uuid.New(),
+				StartDate:   time.Now().UTC().Truncate(time.Second),
+			}
+			sub := &model.Subscription{ID: uuid.New(), ServiceName: reqBody.ServiceName, UserID: reqBody.UserID}
+			mockSvc.On("FindOrCreateSubscription", mock.Anything, reqBody).Return(sub, tt.created, nil)
+
+			r := newTestRequest(http.MethodPost, "/subscriptions?idempotent=true", reqBody)
+			h.CreateSubscription(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			var response model.Subscription
+			parseResponse(t, w, &response)
+			assert.Equal(t, sub.ID, response.ID)
+			if tt.created {
+				assert.Equal(t, "/subscriptions/"+response.ID.String(), w.Header().Get("Location"))
+			} else {
+				assert.Empty(t, w.Header().Get("Location"), "nothing was created")
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateSubscription_IdempotentAmbiguous(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("FindOrCreateSubscription", mock.Anything, mock.Anything).
+		Return((*model.Subscription)(nil), false, fmt.Errorf("failed to find subscription: %w", model.ErrConflict))
+
+	r := newTestRequest(http.MethodPost, "/subscriptions?idempotent=true", service.CreateSubscriptionRequest{ServiceName: "Netflix"})
+	h.CreateSubscription(w, r)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockSvc.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+}
+
+func TestListExpiringSoonByService_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	mockSvc.On("ListExpiringSoonByService", mock.Anything, &userID, 14).Return([]model.ExpiringServiceSummary{
+		{ServiceName: "Netflix", Count: 2, EarliestExpiry: time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/expiring-soon/by-service?days=14&user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"service_name":"Netflix","count":2,"earliest_expiry":"2025-09-12T00:00:00Z"}]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListExpiringSoonByService_EmptyIsArray(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("ListExpiringSoonByService", mock.Anything, (*uuid.UUID)(nil), 7).Return([]model.ExpiringServiceSummary{}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/expiring-soon/by-service", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestListExpiringSoonByService_InvalidDays(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("ListExpiringSoonByService", mock.Anything, mock.Anything, 400).
+		Return([]model.ExpiringServiceSummary(nil), &model.ValidationError{Fields: map[string]string{"days": "must be between 1 and 365"}})
+
+	for _, query := range []string{"days=week", "days=400"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/subscriptions/expiring-soon/by-service?"+query, nil)
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestListUpcomingRenewals_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	subID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	renewal := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("ListUpcomingRenewals", mock.Anything, &userID, 30).Return([]*model.Subscription{{
+		ID:              subID,
+		ServiceName:     "Yandex Plus",
+		Price:           599,
+		UserID:          userID,
+		StartDate:       time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		BillingCycle:    model.CycleMonthly,
+		NextRenewalDate: &renewal,
+		Version:         1,
+	}}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/upcoming?days=30&user_id="+userID.String(), nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id":"550e8400-e29b-41d4-a716-446655440000","service_name":"Yandex Plus","price":599,
+		"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"2025-01-31T00:00:00Z","billing_cycle":"monthly",
+		"next_renewal_date":"2025-02-28T00:00:00Z","version":1}]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListUpcomingRenewals_DefaultDays(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("ListUpcomingRenewals", mock.Anything, (*uuid.UUID)(nil), defaultUpcomingDays).Return([]*model.Subscription{}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/upcoming", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRankSubscriptions_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	w := httptest.NewRecorder()
+
+	userID := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("RankSubscriptions", mock.Anything, service.RankRequest{UserID: &userID, Sort: "cost_per_day", Order: "desc"}).
+		Return([]model.RankedSubscription{
+			{
+				Subscription: model.Subscription{ServiceName: "netflix", Price: 599, UserID: userID, StartDate: start, BillingCycle: model.CycleMonthly},
+				CostPerDay:   19.69,
+				Rank:         1,
+			},
+			{Subscription: model.Subscription{ServiceName: "trial", UserID: userID, StartDate: start, EndDate: &start, BillingCycle: model.CycleMonthly}},
+		}, nil)
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/rank?sort=cost_per_day&order=desc&user_id="+userID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+		{"id":"00000000-0000-0000-0000-000000000000","service_name":"netflix","price":599,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		 "start_date":"2025-01-01T00:00:00Z","billing_cycle":"monthly","version":0,"cost_per_day":19.69,"rank":1},
+		{"id":"00000000-0000-0000-0000-000000000000","service_name":"trial","price":0,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		 "start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:00:00Z","billing_cycle":"monthly","version":0}
+	]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRankSubscriptions_InvalidQuery(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	verr := &model.ValidationError{}
+	verr.Add("order", "must be asc or desc")
+	mockSvc.On("RankSubscriptions", mock.Anything, service.RankRequest{Order: "up"}).Return(nil, verr)
+
+	for _, query := range []string{"user_id=abc", "order=up"} {
+		t.Run(query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/rank?"+query, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+	mockSvc.AssertNumberOfCalls(t, "RankSubscriptions", 1)
+}
+
+func TestCreateSubscription_MonthYearDates(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	july := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(req service.CreateSubscriptionRequest) bool {
+		return req.StartDate.Equal(july) && req.EndDate != nil &&
+			req.EndDate.Equal(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC))
+	})).Return(&model.Subscription{ID: uuid.New(), ServiceName: "Netflix", UserID: userID, StartDate: july}, nil)
+
+	body := fmt.Sprintf(`{"service_name":"Netflix","price":999,"user_id":%q,"start_date":"07-2025","end_date":"2025-12-31"}`, userID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"start_date":"2025-07-01T00:00:00Z"`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateSubscription_PassesMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+	}{
+		{"nested object", `{"card":{"last4":"4242","exp":{"month":12,"year":2027}}}`},
+		{"array", `["family",{"members":4},[1,2]]`},
+		{"null", `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+
+			mockSvc.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(req service.CreateSubscriptionRequest) bool {
+				return string(req.Metadata) == tt.metadata
+			})).Return(&model.Subscription{ID: uuid.New(), ServiceName: "Netflix"}, nil)
+
+			body := fmt.Sprintf(`{"service_name":"Netflix","price":999,"user_id":%q,"start_date":"2025-07-01","metadata":%s}`, uuid.New(), tt.metadata)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body)))
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetSubscription_ReturnsMetadata(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	subID := uuid.New()
+	mockSvc.On("GetSubscription", mock.Anything, subID).Return(&model.Subscription{
+		ID:          subID,
+		ServiceName: "Netflix",
+		Metadata:    json.RawMessage(`{"invoice":"INV-42","tags":["work","shared"]}`),
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/"+subID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"metadata":{"invoice":"INV-42","tags":["work","shared"]}`)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateSubscription_InvalidDateNamesField(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	body := `{"service_name":"Netflix","price":999,"start_date":"July 2025"}`
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ErrorInput
+	parseResponse(t, w, &resp)
+	assert.Equal(t, `invalid request payload: start_date: invalid date "July 2025", use RFC3339, YYYY-MM-DD or MM-YYYY`, resp.Error)
+	mockSvc.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+}
+
+func TestListSubscriptions_DateOnlyFilter(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("ListSubscriptions", mock.Anything, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+		return f.FromDate != nil && f.FromDate.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+			f.ToDate != nil && f.ToDate.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	})).Return(&model.ListResult{}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?from_date=2025-01-01&to_date=06-2025", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestFilterEndpoints_InvalidDateIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	for _, path := range []string{
+		"/subscriptions?to_date=yesterday",
+		"/subscriptions/total?to_date=yesterday",
+		"/subscriptions/expired?to_date=yesterday",
+		"/subscriptions/summary/by-cycle?to_date=yesterday",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		var resp model.ValidationErrorResponse
+		parseResponse(t, w, &resp)
+		assert.Contains(t, resp.Fields, "to_date", path)
+	}
+	assert.Empty(t, mockSvc.Calls)
+}
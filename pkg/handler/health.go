@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+const readinessTimeout = time.Second
+
+// Pinger reports whether a dependency is reachable; *sql.DB satisfies it.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	db  Pinger
+	log *slog.Logger
+}
+
+func NewHealthHandler(db Pinger, log *slog.Logger) *HealthHandler {
+	return &HealthHandler{db: db, log: log}
+}
+
+func (h *HealthHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/live", h.Live).Methods("GET")
+	router.HandleFunc("/ready", h.Ready).Methods("GET")
+	// Kept for existing probes; same semantics as /ready.
+	router.HandleFunc("/health", h.Ready).Methods("GET")
+}
+
+// Live сообщает, что процесс жив
+// @Summary Liveness-проба
+// @Description Всегда возвращает 200, если процесс способен ответить. База данных не проверяется
+// @Tags Health
+// @Produce json
+// @Success 200 {object} model.HealthResponse
+// @Router /live [get]
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, "ok")
+}
+
+// Ready сообщает, готов ли сервис принимать трафик
+// @Summary Readiness-проба
+// @Description Проверяет соединение с базой данных (таймаут 1 секунда). Также доступна по /health
+// @Tags Health
+// @Produce json
+// @Success 200 {object} model.HealthResponse
+// @Failure 503 {object} model.HealthResponse "База данных недоступна"
+// @Router /ready [get]
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		h.log.Warn("readiness check failed", slog.String("error", err.Error()))
+		writeHealth(w, http.StatusServiceUnavailable, "unavailable")
+		return
+	}
+
+	writeHealth(w, http.StatusOK, "ok")
+}
+
+func writeHealth(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(model.HealthResponse{Status: status})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type fakePinger struct {
+	err   error
+	delay time.Duration
+}
+
+func (p fakePinger) PingContext(ctx context.Context) error {
+	select {
+	case <-time.After(p.delay):
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func serveHealth(t *testing.T, db Pinger, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := mux.NewRouter()
+	NewHealthHandler(db, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestLive_NeverChecksDB(t *testing.T) {
+	w := serveHealth(t, fakePinger{err: errors.New("connection refused")}, "/live")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response model.HealthResponse
+	parseResponse(t, w, &response)
+	assert.Equal(t, "ok", response.Status)
+}
+
+func TestReady_DBReachable(t *testing.T) {
+	for _, path := range []string{"/ready", "/health"} {
+		w := serveHealth(t, fakePinger{}, path)
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+func TestReady_DBUnreachable(t *testing.T) {
+	for _, path := range []string{"/ready", "/health"} {
+		w := serveHealth(t, fakePinger{err: errors.New("connection refused")}, path)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+		var response model.HealthResponse
+		parseResponse(t, w, &response)
+		assert.Equal(t, "unavailable", response.Status)
+	}
+}
+
+func TestReady_DBHangs(t *testing.T) {
+	start := time.Now()
+	w := serveHealth(t, fakePinger{delay: time.Minute}, "/ready")
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Less(t, time.Since(start), 2*readinessTimeout)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"SubscriptionAggregator/pkg/importer"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// maxImportFileBytes caps the uploaded CSV itself; the request body limit
+// for ImportRoute leaves room for the multipart framing around it.
+const maxImportFileBytes = 5 << 20
+
+// ImportSubscriptions импортирует подписки из CSV-файла
+// @Summary Импорт подписок из CSV
+// @Description Принимает CSV-файл с заголовком service_name,price,user_id,start_date,end_date (не больше 5 МБ). Корректные строки сохраняются; строки с ошибками, пересекающиеся с активной подпиской на тот же сервис или сверх лимита подписок пользователя возвращаются с номером строки (заголовок — строка 1)
+// @Tags Subscriptions
+// @Accept multipart/form-data
+// @Produce json
+// @Security Tenant
+// @Param file formData file true "CSV-файл с подписками"
+// @Param allow_duplicate query bool false "Сохранять строки, пересекающиеся с активной подпиской пользователя на тот же сервис"
+// @Success 200 {object} importer.Result
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "imported": 45,
+//	    "failed": 3,
+//	    "errors": [
+//	        {"row": 7, "error": "start_date: invalid date \"soon\", use RFC3339, YYYY-MM-DD or MM-YYYY"}
+//	    ]
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Нет файла или некорректный CSV"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 409 {object} model.ErrorResponse "Конфликт с существующей записью"
+// @Failure 413 {object} model.ErrorResponse "Файл больше 5 МБ"
+// @Failure 415 {object} model.ErrorResponse "Content-Type должен быть multipart/form-data"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, http.ErrNotMultipart):
+			h.respondWithError(w, http.StatusUnsupportedMediaType, "Content-Type must be multipart/form-data")
+		case errors.As(err, &maxErr):
+			h.respondWithError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge.Error())
+		case errors.Is(err, http.ErrMissingFile):
+			h.respondWithError(w, http.StatusBadRequest, `form field "file" is required`)
+		default:
+			h.respondWithError(w, http.StatusBadRequest, "invalid multipart form")
+		}
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportFileBytes {
+		h.respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file must not exceed %d MB", maxImportFileBytes>>20))
+		return
+	}
+
+	rows, rowErrs, err := importer.ParseCSVRows(file)
+	if err != nil {
+		if errors.Is(err, importer.ErrMalformedCSV) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	allowDuplicate := r.URL.Query().Get("allow_duplicate") == "true"
+	reqs := make([]service.CreateSubscriptionRequest, len(rows))
+	for i, row := range rows {
+		reqs[i] = row.Request
+		reqs[i].AllowDuplicate = allowDuplicate
+	}
+
+	created, err := h.service.BulkCreateSubscriptions(r.Context(), reqs)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	for _, f := range created.Failed {
+		rowErrs = append(rowErrs, importer.ImportError{Row: rows[f.Index].Row, Error: f.Err.Error()})
+	}
+	sort.Slice(rowErrs, func(i, j int) bool { return rowErrs[i].Row < rowErrs[j].Row })
+	if rowErrs == nil {
+		rowErrs = []importer.ImportError{}
+	}
+
+	h.render(w, r, http.StatusOK, importer.Result{
+		Imported: len(created.Created),
+		Failed:   len(rowErrs),
+		Errors:   rowErrs,
+	})
+}
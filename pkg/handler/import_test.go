@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/importer"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+const importUserID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+func newImportRequest(t *testing.T, csv string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "subscriptions.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csv))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	r := httptest.NewRequest(http.MethodPost, ImportRoute, &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestImportSubscriptions_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("BulkCreateSubscriptions", mock.Anything, mock.MatchedBy(func(reqs []service.CreateSubscriptionRequest) bool {
+		return len(reqs) == 2 && reqs[0].ServiceName == "Yandex Plus" && reqs[1].ServiceName == "Netflix"
+	})).Return(&service.BulkCreateResult{Created: make([]*model.Subscription, 2)}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest(t, "service_name,price,user_id,start_date,end_date\n"+
+		"Yandex Plus,599,"+importUserID+",07-2025,\n"+
+		"Netflix,999,"+importUserID+",2025-01-01,2025-12-31\n"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"imported":2,"failed":0,"errors":[]}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestImportSubscriptions_PartiallyInvalid(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	// Row 3 is rejected by the parser, row 4 by the service's validation.
+	mockSvc.On("BulkCreateSubscriptions", mock.Anything, mock.Anything).Return(&service.BulkCreateResult{
+		Created: make([]*model.Subscription, 1),
+		Failed: []service.BulkFailure{{Index: 1, Err: &model.ValidationError{Fields: map[string]string{
+			"price": "must be positive",
+		}}}},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest(t, "service_name,price,user_id,start_date,end_date\n"+
+		"Yandex Plus,599,"+importUserID+",07-2025,\n"+
+		"Netflix,999,"+importUserID+",soon,\n"+
+		"Spotify,0,"+importUserID+",07-2025,\n"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp importer.Result
+	parseResponse(t, w, &resp)
+	assert.Equal(t, 1, resp.Imported)
+	assert.Equal(t, 2, resp.Failed)
+	require.Len(t, resp.Errors, 2)
+	assert.Equal(t, 3, resp.Errors[0].Row)
+	assert.Contains(t, resp.Errors[0].Error, "start_date")
+	assert.Equal(t, importer.ImportError{Row: 4, Error: "validation failed: price: must be positive"}, resp.Errors[1])
+}
+
+func TestImportSubscriptions_MalformedCSV(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest(t, "name;cost\nNetflix;999\n"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "header must be service_name,price,user_id,start_date,end_date")
+	mockSvc.AssertNotCalled(t, "BulkCreateSubscriptions", mock.Anything, mock.Anything)
+}
+
+func TestImportSubscriptions_StoreError(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("BulkCreateSubscriptions", mock.Anything, mock.Anything).
+		Return((*service.BulkCreateResult)(nil), errors.New("connection reset"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest(t, "service_name,price,user_id,start_date,end_date\n"+
+		"Netflix,999,"+importUserID+",07-2025,\n"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestImportSubscriptions_RequestErrors(t *testing.T) {
+	h, _ := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	t.Run("not multipart", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, ImportRoute, strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.WriteField("note", "no file here"))
+		require.NoError(t, mw.Close())
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, ImportRoute, &body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `form field \"file\" is required`)
+	})
+
+	t.Run("file too large", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, strings.Repeat("x", maxImportFileBytes+1)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("body over limit", func(t *testing.T) {
+		limited := mux.NewRouter()
+		limited.Use(middleware.BodyLimitMiddleware(1024, nil))
+		h.RegisterRoutes(limited)
+
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, newImportRequest(t, strings.Repeat("x", 4096)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
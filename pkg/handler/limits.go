@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type SpendingLimitHandler struct {
+	responder
+	service service.SpendingLimitService
+}
+
+func NewSpendingLimitHandler(service service.SpendingLimitService, log *slog.Logger) *SpendingLimitHandler {
+	return &SpendingLimitHandler{responder: responder{log: log}, service: service}
+}
+
+func (h *SpendingLimitHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/users/{user_id}/spending-limit", h.SetSpendingLimit).Methods("PUT")
+	router.HandleFunc("/users/{user_id}/spending-limit", h.GetSpendingLimit).Methods("GET")
+	router.HandleFunc("/users/{user_id}/spending-limit", h.DeleteSpendingLimit).Methods("DELETE")
+	router.HandleFunc("/users/{user_id}/spending-limit/status", h.GetSpendingLimitStatus).Methods("GET")
+}
+
+// SetSpendingLimit задает лимит расходов пользователя
+// @Summary Задать лимит расходов
+// @Description Создает или заменяет лимит расходов пользователя на подписки. Валюта по умолчанию - валюта цен, порог предупреждения по умолчанию - 80%
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param input body service.SetSpendingLimitRequest true "Лимит, валюта и порог предупреждения в процентах (1-100)"
+// @Success 200 {object} model.SpendingLimit "Лимит изменен"
+// @Success 201 {object} model.SpendingLimit "Лимита не было, он создан"
+// @Header 201 {string} Location "URL лимита, /users/{user_id}/spending-limit"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 201 Created
+//	{
+//	    "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	    "limit_amount": 2000,
+//	    "currency": "RUB",
+//	    "alert_threshold_pct": 80,
+//	    "created_at": "2025-08-12T00:00:00Z",
+//	    "updated_at": "2025-08-12T00:00:00Z"
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный ID пользователя или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/spending-limit [put]
+func (h *SpendingLimitHandler) SetSpendingLimit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.SetSpendingLimitRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.UserID = userID
+
+	limit, created, err := h.service.SetSpendingLimit(r.Context(), req)
+	if err != nil {
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			h.respondWithJSON(w, http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+				Error:  model.ErrValidation.Error(),
+				Fields: verr.Fields,
+			})
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	if created {
+		h.respondCreated(w, r, "/users/"+limit.UserID.String()+"/spending-limit", limit)
+		return
+	}
+	h.render(w, r, http.StatusOK, limit)
+}
+
+// GetSpendingLimit возвращает лимит расходов пользователя
+// @Summary Лимит расходов
+// @Tags Users
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {object} model.SpendingLimit
+// @Failure 400 {object} model.ErrorInput "Неверный ID пользователя"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Лимит не задан"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/spending-limit [get]
+func (h *SpendingLimitHandler) GetSpendingLimit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := h.service.GetSpendingLimit(r.Context(), userID)
+	if err != nil {
+		h.limitError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, limit)
+}
+
+// DeleteSpendingLimit удаляет лимит расходов пользователя
+// @Summary Удалить лимит расходов
+// @Tags Users
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 204 "Лимит удален"
+// @Failure 400 {object} model.ErrorInput "Неверный ID пользователя"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Лимит не задан"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/spending-limit [delete]
+func (h *SpendingLimitHandler) DeleteSpendingLimit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSpendingLimit(r.Context(), userID); err != nil {
+		h.limitError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusNoContent, nil)
+}
+
+// GetSpendingLimitStatus сравнивает текущие расходы пользователя с лимитом
+// @Summary Состояние лимита расходов
+// @Description Суммирует цены подписок пользователя, активных сегодня, в валюте лимита. alert становится true, когда used_pct достигает порога предупреждения. Только читает: превышение лимита проверяется при каждой записи подписок пользователя и при установке лимита, и при первом превышении в поток изменений публикуется событие spending_limit_breached
+// @Tags Users
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {object} model.SpendingLimitStatus
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "limit": 2000,
+//	    "currency": "RUB",
+//	    "current_total": 1750,
+//	    "used_pct": 87.5,
+//	    "over_limit": false,
+//	    "alert": true
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный ID пользователя или валюта лимита больше не поддерживается"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Лимит не задан"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/spending-limit/status [get]
+func (h *SpendingLimitHandler) GetSpendingLimitStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetSpendingLimitStatus(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, currency.ErrUnsupportedCurrency) {
+			h.respondWithError(w, http.StatusBadRequest, "unsupported currency")
+			return
+		}
+		h.limitError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, status)
+}
+
+func (h *SpendingLimitHandler) userID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (h *SpendingLimitHandler) limitError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, model.ErrNotFound) {
+		h.respondWithError(w, http.StatusNotFound, "spending limit not found")
+		return
+	}
+	h.internalError(w, r, err)
+}
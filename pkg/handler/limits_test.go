@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type MockSpendingLimitService struct {
+	mock.Mock
+}
+
+func (m *MockSpendingLimitService) SetSpendingLimit(ctx context.Context, req service.SetSpendingLimitRequest) (*model.SpendingLimit, bool, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.SpendingLimit), args.Bool(1), args.Error(2)
+}
+
+func (m *MockSpendingLimitService) GetSpendingLimit(ctx context.Context, userID uuid.UUID) (*model.SpendingLimit, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*model.SpendingLimit), args.Error(1)
+}
+
+func (m *MockSpendingLimitService) DeleteSpendingLimit(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockSpendingLimitService) GetSpendingLimitStatus(ctx context.Context, userID uuid.UUID) (*model.SpendingLimitStatus, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*model.SpendingLimitStatus), args.Error(1)
+}
+
+func (m *MockSpendingLimitService) CheckBreach(ctx context.Context, tenantID, userID uuid.UUID) {
+	m.Called(ctx, tenantID, userID)
+}
+
+func newTestSpendingLimitRouter() (*mux.Router, *MockSpendingLimitService) {
+	mockSvc := &MockSpendingLimitService{}
+	router := mux.NewRouter()
+	NewSpendingLimitHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestSetSpendingLimit_CreatedAndReplaced(t *testing.T) {
+	tests := []struct {
+		created bool
+		want    int
+		// location is the Location header expected, if any.
+		location bool
+	}{
+		{true, http.StatusCreated, true},
+		{false, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		router, mockSvc := newTestSpendingLimitRouter()
+		w := httptest.NewRecorder()
+		userID := uuid.New()
+
+		limit := &model.SpendingLimit{UserID: userID, LimitAmount: 2000, Currency: "RUB", AlertThresholdPct: 90}
+		mockSvc.On("SetSpendingLimit", mock.Anything, service.SetSpendingLimitRequest{UserID: userID, LimitAmount: 2000, AlertThresholdPct: 90}).
+			Return(limit, tt.created, nil)
+
+		r := httptest.NewRequest(http.MethodPut, "/users/"+userID.String()+"/spending-limit",
+			bytes.NewBufferString(`{"limit_amount":2000,"alert_threshold_pct":90}`))
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, tt.want, w.Code)
+		var response model.SpendingLimit
+		parseResponse(t, w, &response)
+		assert.Equal(t, *limit, response)
+		if tt.location {
+			assert.Equal(t, "/users/"+response.UserID.String()+"/spending-limit", w.Header().Get("Location"))
+		} else {
+			assert.Empty(t, w.Header().Get("Location"))
+		}
+		mockSvc.AssertExpectations(t)
+	}
+}
+
+func TestSetSpendingLimit_Validation(t *testing.T) {
+	router, mockSvc := newTestSpendingLimitRouter()
+	w := httptest.NewRecorder()
+
+	verr := &model.ValidationError{}
+	verr.Add("limit_amount", "must be greater than 0")
+	mockSvc.On("SetSpendingLimit", mock.Anything, mock.Anything).Return((*model.SpendingLimit)(nil), false, verr)
+
+	r := httptest.NewRequest(http.MethodPut, "/users/"+uuid.NewString()+"/spending-limit", bytes.NewBufferString(`{"limit_amount":0}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit_amount":"must be greater than 0"`)
+}
+
+func TestSpendingLimit_InvalidUserID(t *testing.T) {
+	router, mockSvc := newTestSpendingLimitRouter()
+
+	for _, path := range []string{"/users/bob/spending-limit", "/users/bob/spending-limit/status"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+	mockSvc.AssertNotCalled(t, "GetSpendingLimit", mock.Anything, mock.Anything)
+	mockSvc.AssertNotCalled(t, "GetSpendingLimitStatus", mock.Anything, mock.Anything)
+}
+
+func TestDeleteSpendingLimit_NotFound(t *testing.T) {
+	router, mockSvc := newTestSpendingLimitRouter()
+	w := httptest.NewRecorder()
+	userID := uuid.New()
+
+	mockSvc.On("DeleteSpendingLimit", mock.Anything, userID).
+		Return(fmt.Errorf("failed to delete spending limit: %w", model.ErrNotFound))
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/spending-limit", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetSpendingLimitStatus_Success(t *testing.T) {
+	router, mockSvc := newTestSpendingLimitRouter()
+	w := httptest.NewRecorder()
+	userID := uuid.New()
+
+	mockSvc.On("GetSpendingLimitStatus", mock.Anything, userID).Return(&model.SpendingLimitStatus{
+		Limit:        2000,
+		Currency:     "RUB",
+		CurrentTotal: 1750,
+		UsedPct:      87.5,
+		Alert:        true,
+	}, nil)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/spending-limit/status", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"limit":2000,"currency":"RUB","current_total":1750,"used_pct":87.5,"over_limit":false,"alert":true}`, w.Body.String())
+}
+
+func TestGetSpendingLimitStatus_NoLimit(t *testing.T) {
+	router, mockSvc := newTestSpendingLimitRouter()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("GetSpendingLimitStatus", mock.Anything, mock.Anything).
+		Return((*model.SpendingLimitStatus)(nil), fmt.Errorf("failed to get spending limit: %w", model.ErrNotFound))
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString()+"/spending-limit/status", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "spending limit not found")
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// MergeUsersRoute moves a duplicate account's subscriptions to the user in
+// the path. It hands one user's data to another, so it sits under /admin
+// and takes the admin token instead of a tenant.
+const MergeUsersRoute = "/admin/users/{target_id}/merge"
+
+// UserMergeHandler serves merging duplicate users.
+type UserMergeHandler struct {
+	responder
+	service    service.UserMergeService
+	adminToken string
+}
+
+// NewUserMergeHandler builds the handler; with an empty adminToken every
+// merge is rejected.
+func NewUserMergeHandler(service service.UserMergeService, adminToken string, log *slog.Logger) *UserMergeHandler {
+	return &UserMergeHandler{responder: responder{log: log}, service: service, adminToken: adminToken}
+}
+
+// RegisterRoutes adds the route with its own token check, since it is not
+// part of AdminHandler's subrouter.
+func (h *UserMergeHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle(MergeUsersRoute, h.requireAdminToken(h.adminToken)(http.HandlerFunc(h.MergeUsers))).Methods("POST")
+}
+
+// MergeUsers переносит подписки одного пользователя на другого
+// @Summary Объединить пользователей
+// @Description Для пользователя, заведенного дважды: в одной транзакции переносит все подписки source_user_id, включая удаленные, на target_id. При deduplicate=true действующие подписки источника с тем же сервисом и датой начала, что у действующей подписки цели, удаляются и считаются пропущенными. Закрепления источника и доступы, выданные источнику, переходят к цели, если у нее таких еще нет; лимиты остаются как были. У источника ничего не остается, поэтому повторный вызов ничего не меняет и возвращает нули. В журнал обоих пользователей пишется запись об объединении как действие администратора. Требует заголовок Authorization: Bearer <admin-token>
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param target_id path string true "ID пользователя, которому переходят подписки" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param tenant_id query string true "ID тенанта пользователей" example(0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d)
+// @Param input body model.MergeUsersRequest true "Дубликат и режим объединения"
+// @Success 200 {object} model.MergeUsersResponse
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "moved": 3,
+//	    "skipped": 1
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный target_id или формат данных либо нет tenant_id"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 422 {object} model.ValidationErrorResponse "Не указан source_user_id или он совпадает с target_id"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/users/{target_id}/merge [post]
+func (h *UserMergeHandler) MergeUsers(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	targetID, err := uuid.Parse(mux.Vars(r)["target_id"])
+	if err != nil || targetID == uuid.Nil {
+		q.errs.Add("target_id", "must be a UUID")
+	}
+	q.Require("tenant_id")
+	tenantID := q.UUID("tenant_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	var body model.MergeUsersRequest
+	if err := decodeJSON(r, &body); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+
+	r = withAdminTenant(r, *tenantID)
+	resp, err := h.service.MergeUsers(r.Context(), service.MergeUsersRequest{
+		TargetUserID: targetID,
+		SourceUserID: body.SourceUserID,
+		Deduplicate:  body.Deduplicate,
+		Actor:        model.AuditActorAdmin,
+		RequestID:    middleware.RequestIDFromContext(r.Context()),
+	})
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, resp)
+}
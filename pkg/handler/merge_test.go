@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type MockUserMergeService struct {
+	mock.Mock
+}
+
+func (m *MockUserMergeService) MergeUsers(ctx context.Context, req service.MergeUsersRequest) (*model.MergeUsersResponse, error) {
+	args := m.Called(ctx, req)
+	resp, _ := args.Get(0).(*model.MergeUsersResponse)
+	return resp, args.Error(1)
+}
+
+func newTestUserMergeRouter() (*mux.Router, *MockUserMergeService) {
+	mockSvc := &MockUserMergeService{}
+	router := mux.NewRouter()
+	router.Use(middleware.TenantMiddleware(middleware.TenantSource{}, "/admin/"))
+	NewUserMergeHandler(mockSvc, testAdminToken, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func mergeRequest(targetID, tenant, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/admin/users/"+targetID+"/merge?tenant_id="+tenant, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return r
+}
+
+func TestMergeUsers(t *testing.T) {
+	router, mockSvc := newTestUserMergeRouter()
+	tenantID, sourceID, targetID := uuid.New(), uuid.New(), uuid.New()
+
+	mockSvc.On("MergeUsers", tenantIs(tenantID), service.MergeUsersRequest{
+		TargetUserID: targetID,
+		SourceUserID: sourceID,
+		Deduplicate:  true,
+		Actor:        model.AuditActorAdmin,
+	}).Return(&model.MergeUsersResponse{Moved: 3, Skipped: 1}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, mergeRequest(targetID.String(), tenantID.String(), `{"source_user_id":"`+sourceID.String()+`","deduplicate":true}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"moved":3,"skipped":1}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestMergeUsers_Errors(t *testing.T) {
+	tenantID, targetID := uuid.New(), uuid.New()
+	body := `{"source_user_id":"` + uuid.NewString() + `"}`
+	tests := []struct {
+		name   string
+		target string
+		tenant string
+		body   string
+		err    error
+		want   int
+	}{
+		{"no tenant", targetID.String(), "", body, nil, http.StatusBadRequest},
+		{"invalid target", "nope", tenantID.String(), body, nil, http.StatusBadRequest},
+		{"invalid body", targetID.String(), tenantID.String(), `{"source_user_id":"nope"}`, nil, http.StatusBadRequest},
+		{"unknown field", targetID.String(), tenantID.String(), `{"source":"x"}`, nil, http.StatusBadRequest},
+		{"validation", targetID.String(), tenantID.String(), body, &model.ValidationError{Fields: map[string]string{"source_user_id": "is required"}}, http.StatusUnprocessableEntity},
+		{"conflict", targetID.String(), tenantID.String(), body, fmt.Errorf("merge: %w", model.ErrConflict), http.StatusConflict},
+		{"store error", targetID.String(), tenantID.String(), body, errors.New("db error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestUserMergeRouter()
+			mockSvc.On("MergeUsers", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, mergeRequest(tt.target, tt.tenant, tt.body))
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Contains(t, w.Body.String(), `"error"`)
+			if tt.err == nil {
+				mockSvc.AssertNotCalled(t, "MergeUsers", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// A tenant's user must not be able to pull another user's subscriptions
+// over to themselves, whatever tenant and caller they present.
+func TestMergeUsers_RejectsTenantCaller(t *testing.T) {
+	router, mockSvc := newTestUserMergeRouter()
+	tenantID, callerID, victimID := uuid.New(), uuid.New(), uuid.New()
+	body := `{"source_user_id":"` + victimID.String() + `"}`
+
+	for path, want := range map[string]int{
+		"/admin/users/" + callerID.String() + "/merge?tenant_id=" + tenantID.String(): http.StatusUnauthorized,
+		"/users/" + callerID.String() + "/merge":                                      http.StatusNotFound,
+	} {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(middleware.DefaultTenantHeader, tenantID.String())
+		r.Header.Set(middleware.DefaultUserHeader, callerID.String())
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, want, w.Code, path)
+	}
+	mockSvc.AssertNotCalled(t, "MergeUsers", mock.Anything, mock.Anything)
+}
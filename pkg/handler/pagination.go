@@ -0,0 +1,49 @@
+package handler
+
+import "net/http"
+
+// defaultPageSize is the page size when limit is not given, unless the
+// configured maximum is smaller.
+const defaultPageSize = 50
+
+// maxPageSizeHeader tells the client its limit was lowered to the maximum.
+const maxPageSizeHeader = "X-Max-Page-Size-Applied"
+
+// Pagination is one page of a list endpoint. Clamped is set when the
+// requested limit exceeded the maximum and Limit was lowered to it.
+type Pagination struct {
+	Limit   int
+	Offset  int
+	Clamped bool
+}
+
+// parsePagination reads limit and offset. A missing limit is
+// min(defaultPageSize, maxSize) and one above maxSize is clamped to it
+// rather than rejected; values below 1 and negative offsets are errors in
+// q.
+func parsePagination(q *queryParams, maxSize int) Pagination {
+	p := Pagination{
+		Limit:  q.Int("limit", min(defaultPageSize, maxSize)),
+		Offset: q.Int("offset", 0),
+	}
+	if p.Limit < 1 {
+		q.errs.Add("limit", "must be at least 1")
+	}
+	if p.Offset < 0 {
+		q.errs.Add("offset", "must not be negative")
+	}
+	if p.Limit > maxSize {
+		p.Limit, p.Clamped = maxSize, true
+	}
+	return p
+}
+
+// setPageHeaders flags a clamped limit and, like setTotalCount, exposes the
+// header to browser clients; it must be called after setTotalCount.
+func setPageHeaders(w http.ResponseWriter, p Pagination) {
+	if !p.Clamped {
+		return
+	}
+	w.Header().Set(maxPageSizeHeader, "true")
+	w.Header().Add("Access-Control-Expose-Headers", maxPageSizeHeader)
+}
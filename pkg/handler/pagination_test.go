@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		maxSize int
+		want    Pagination
+	}{
+		{"default", "", 500, Pagination{Limit: 50}},
+		{"default above max", "", 20, Pagination{Limit: 20}},
+		{"within max", "?limit=100&offset=200", 500, Pagination{Limit: 100, Offset: 200}},
+		{"at max", "?limit=500", 500, Pagination{Limit: 500}},
+		{"above max", "?limit=501", 500, Pagination{Limit: 500, Clamped: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newQueryParams(httptest.NewRequest(http.MethodGet, "/subscriptions"+tt.query, nil))
+
+			got := parsePagination(q, tt.maxSize)
+
+			assert.Equal(t, tt.want, got)
+			assert.Nil(t, q.Err())
+		})
+	}
+}
+
+func TestParsePagination_Invalid(t *testing.T) {
+	q := newQueryParams(httptest.NewRequest(http.MethodGet, "/subscriptions?limit=0&offset=-1", nil))
+
+	parsePagination(q, 500)
+
+	assert.Equal(t, map[string]string{
+		"limit":  "must be at least 1",
+		"offset": "must not be negative",
+	}, q.Err().Fields)
+}
+
+func TestListSubscriptions_ClampsLimit(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	h.maxPageSize = 100
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: 100, Offset: 300}).
+		Return(&model.ListResult{TotalCount: 1000}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?limit=1000&offset=300", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get(maxPageSizeHeader))
+	assert.Equal(t, []string{"X-Total-Count", maxPageSizeHeader}, w.Header().Values("Access-Control-Expose-Headers"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_LimitWithinMaxHasNoWarning(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{Limit: 10}).
+		Return(&model.ListResult{}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?limit=10", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(maxPageSizeHeader))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_InvalidLimitIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?limit=ten", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":"must be an integer"`)
+	mockSvc.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// RecordPayment записывает платеж по подписке
+// @Summary Записать платеж
+// @Description Добавляет платеж в историю оплат подписки с текущей датой. Валюта по умолчанию - валюта цен, другие валюты не принимаются
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param input body service.RecordPaymentRequest true "Сумма и валюта платежа"
+// @Success 201 {object} model.Payment "Платеж записан"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 201 Created
+//	{
+//	    "id": "9b2d7c4e-1f3a-4b5c-8d6e-7f8091a2b3c4",
+//	    "subscription_id": "550e8400-e29b-41d4-a716-446655440000",
+//	    "amount": 599,
+//	    "currency": "RUB",
+//	    "billed_at": "2025-08-12T00:00:00Z",
+//	    "status": "paid"
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/payments [post]
+func (h *SubscriptionHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	var req service.RecordPaymentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.SubscriptionID = id
+
+	payment, err := h.service.RecordPayment(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
+			return
+		}
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusCreated, payment)
+}
+
+// ListPayments возвращает историю оплат подписки
+// @Summary История оплат подписки
+// @Description Возвращает платежи подписки, начиная с самого раннего
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Success 200 {array} model.Payment
+// @Header 200 {integer} X-Total-Count "Количество платежей"
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/payments [get]
+func (h *SubscriptionHandler) ListPayments(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	payments, err := h.service.ListPayments(r.Context(), id)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, len(payments))
+	h.render(w, r, http.StatusOK, payments)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+func TestRecordPayment_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	w := httptest.NewRecorder()
+	subID := uuid.New()
+
+	payment := &model.Payment{ID: uuid.New(), SubscriptionID: subID, Amount: 599, Currency: "RUB", Status: model.PaymentPaid}
+	mockSvc.On("RecordPayment", mock.Anything, service.RecordPaymentRequest{SubscriptionID: subID, Amount: 599}).
+		Return(payment, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/"+subID.String()+"/payments", bytes.NewBufferString(`{"amount":599}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response model.Payment
+	parseResponse(t, w, &response)
+	assert.Equal(t, payment.ID, response.ID)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestRecordPayment_Errors(t *testing.T) {
+	invalid := &model.ValidationError{}
+	invalid.Add("amount", "must be positive")
+
+	tests := []struct {
+		name     string
+		svcErr   error
+		wantCode int
+	}{
+		{"unknown subscription", fmt.Errorf("failed to record payment: %w", model.ErrNotFound), http.StatusNotFound},
+		{"invalid amount", invalid, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			w := httptest.NewRecorder()
+
+			mockSvc.On("RecordPayment", mock.Anything, mock.Anything).Return((*model.Payment)(nil), tt.svcErr)
+
+			r := httptest.NewRequest(http.MethodPost, "/subscriptions/"+uuid.NewString()+"/payments", bytes.NewBufferString(`{"amount":0}`))
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestListPayments(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	w := httptest.NewRecorder()
+	subID := uuid.New()
+
+	payments := []model.Payment{
+		{ID: uuid.New(), SubscriptionID: subID, Amount: 599, Currency: "RUB", Status: model.PaymentPaid},
+		{ID: uuid.New(), SubscriptionID: subID, Amount: 699, Currency: "RUB", Status: model.PaymentPaid},
+	}
+	mockSvc.On("ListPayments", mock.Anything, subID).Return(payments, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/"+subID.String()+"/payments", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	var response []model.Payment
+	parseResponse(t, w, &response)
+	assert.Equal(t, payments[1].ID, response[1].ID)
+}
+
+func TestListPayments_EmptyIsArray(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	w := httptest.NewRecorder()
+	subID := uuid.New()
+
+	mockSvc.On("ListPayments", mock.Anything, subID).Return([]model.Payment(nil), nil)
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/"+subID.String()+"/payments", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]\n", w.Body.String())
+}
+
+func TestGetTotalCost_PassesSource(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	w := httptest.NewRecorder()
+
+	mockSvc.On("GetTotalCost", mock.Anything, mock.MatchedBy(func(req service.TotalCostRequest) bool {
+		return req.Source == model.TotalFromBillingHistory && req.Mode == ""
+	})).Return(&model.TotalCostResponse{Total: 1198, Source: model.TotalFromBillingHistory, Currency: "RUB"}, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/total?source=billing_history", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"total":1198,"source":"billing_history","currency":"RUB"}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
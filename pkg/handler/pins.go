@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// PinSubscription закрепляет подписку в избранном пользователя
+// @Summary Закрепить подписку
+// @Description Добавляет подписку в избранное пользователя user_id. Повторное закрепление не ошибка и сохраняет исходное время
+// @Tags Pins
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param user_id query string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {object} model.Pin "Подписка закреплена"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "subscription_id": "550e8400-e29b-41d4-a716-446655440000",
+//	    "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	    "pinned_at": "2025-08-12T00:00:00Z"
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный ID подписки, не указан или неверный ID пользователя"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/pin [post]
+func (h *SubscriptionHandler) PinSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+	q := newQueryParams(r)
+	q.Require("user_id")
+	userID := q.UUID("user_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	pin, err := h.service.PinSubscription(r.Context(), id, *userID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, pin)
+}
+
+// UnpinSubscription убирает подписку из избранного пользователя
+// @Summary Открепить подписку
+// @Tags Pins
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param user_id query string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 204 "Подписка откреплена"
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный ID подписки, не указан или неверный ID пользователя"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не закреплена"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/pin [delete]
+func (h *SubscriptionHandler) UnpinSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+	q := newQueryParams(r)
+	q.Require("user_id")
+	userID := q.UUID("user_id")
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	if err := h.service.UnpinSubscription(r.Context(), id, *userID); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "pin not found")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
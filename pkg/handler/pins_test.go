@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestPinSubscription_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	subID, userID := uuid.New(), uuid.New()
+	mockSvc.On("PinSubscription", mock.Anything, subID, userID).Return(&model.Pin{
+		SubscriptionID: subID,
+		UserID:         userID,
+		PinnedAt:       time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC),
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/"+subID.String()+"/pin?user_id="+userID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"subscription_id":"`+subID.String()+`","user_id":"`+userID.String()+`",
+		"pinned_at":"2025-08-12T00:00:00Z"}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestPinSubscription_RequiresUserID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/"+uuid.NewString()+"/pin", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "user_id")
+	mockSvc.AssertNotCalled(t, "PinSubscription", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPinSubscription_NotFound(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	subID, userID := uuid.New(), uuid.New()
+	mockSvc.On("PinSubscription", mock.Anything, subID, userID).Return((*model.Pin)(nil), model.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/"+subID.String()+"/pin?user_id="+userID.String(), nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUnpinSubscription(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"unpinned", nil, http.StatusNoContent},
+		{"not pinned", model.ErrNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+
+			subID, userID := uuid.New(), uuid.New()
+			mockSvc.On("UnpinSubscription", mock.Anything, subID, userID).Return(tt.err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/subscriptions/"+subID.String()+"/pin?user_id="+userID.String(), nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListSubscriptions_PinnedOnly(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{UserID: &userID, PinnedOnly: true, Limit: defaultPageSize}).
+		Return(&model.ListResult{Items: []*model.Subscription{
+			{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: userID, Pinned: true},
+		}, TotalCount: 1}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?pinned_only=true&user_id="+userID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []map[string]any
+	parseResponse(t, w, &response)
+	assert.Len(t, response, 1)
+	assert.Equal(t, true, response[0]["pinned"])
+	mockSvc.AssertExpectations(t)
+}
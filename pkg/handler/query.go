@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// errInvalidQuery is the error text of a 400 caused by query parameters;
+// the offending parameters are listed in the response's fields.
+const errInvalidQuery = "invalid query parameters"
+
+// queryParams reads typed query parameters. Malformed values are collected
+// instead of being dropped, so a bad user_id can never widen a filter to
+// every user, and one response can name all of them.
+type queryParams struct {
+	values url.Values
+	read   map[string]bool
+	errs   model.ValidationError
+}
+
+func newQueryParams(r *http.Request) *queryParams {
+	return &queryParams{values: r.URL.Query(), read: make(map[string]bool)}
+}
+
+func (q *queryParams) get(name string) string {
+	q.read[name] = true
+	return q.values.Get(name)
+}
+
+func (q *queryParams) String(name string) *string {
+	val := q.get(name)
+	if val == "" {
+		return nil
+	}
+	return &val
+}
+
+// ServiceName is String normalised the way service names are stored, see
+// service.NormaliseServiceName; a blank value is no filter at all.
+func (q *queryParams) ServiceName(name string) *string {
+	val := service.NormaliseServiceName(q.get(name))
+	if val == "" {
+		return nil
+	}
+	return &val
+}
+
+func (q *queryParams) UUID(name string) *uuid.UUID {
+	val := q.get(name)
+	if val == "" {
+		return nil
+	}
+	id, err := uuid.Parse(val)
+	if err != nil {
+		q.errs.Add(name, "must be a UUID")
+		return nil
+	}
+	return &id
+}
+
+// list reads every value of a parameter that may be repeated, comma
+// separated, or both, trimmed and without empty entries.
+func (q *queryParams) list(name string) []string {
+	q.read[name] = true
+	var vals []string
+	for _, val := range q.values[name] {
+		for _, part := range strings.Split(val, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				vals = append(vals, part)
+			}
+		}
+	}
+	return vals
+}
+
+// UUIDs reads every value of a list parameter, see list. If several values
+// are given the error names the malformed ones, so a client can tell which
+// of its IDs to fix.
+func (q *queryParams) UUIDs(name string) []uuid.UUID {
+	vals := q.list(name)
+	ids := make([]uuid.UUID, 0, len(vals))
+	var bad []string
+	for _, val := range vals {
+		id, err := uuid.Parse(val)
+		if err != nil {
+			bad = append(bad, val)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	switch {
+	case len(bad) == 0:
+		if len(ids) == 0 {
+			return nil
+		}
+		return ids
+	case len(vals) == 1:
+		q.errs.Add(name, "must be a UUID")
+	default:
+		q.errs.Add(name, "must be UUIDs, invalid: "+strings.Join(bad, ", "))
+	}
+	return nil
+}
+
+// ServiceNames reads every value of a list parameter, see list, each
+// normalised like ServiceName.
+func (q *queryParams) ServiceNames(name string) []string {
+	var names []string
+	for _, val := range q.list(name) {
+		if val = service.NormaliseServiceName(val); val != "" {
+			names = append(names, val)
+		}
+	}
+	return names
+}
+
+func (q *queryParams) Date(name string) *time.Time {
+	val := q.get(name)
+	if val == "" {
+		return nil
+	}
+	t, err := model.ParseDate(val)
+	if err != nil {
+		q.errs.Add(name, "must be a date in RFC3339, YYYY-MM-DD or MM-YYYY format")
+		return nil
+	}
+	return &t
+}
+
+// Int returns def when name is absent.
+func (q *queryParams) Int(name string, def int) int {
+	val := q.get(name)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		q.errs.Add(name, "must be an integer")
+		return def
+	}
+	return n
+}
+
+func (q *queryParams) Bool(name string) bool {
+	val := q.get(name)
+	if val == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		q.errs.Add(name, "must be true or false")
+		return false
+	}
+	return b
+}
+
+// Require records name as missing if it was not given.
+func (q *queryParams) Require(name string) {
+	if q.values.Get(name) == "" {
+		q.errs.Add(name, "is required")
+	}
+}
+
+// Err reports the malformed parameters read so far.
+func (q *queryParams) Err() *model.ValidationError {
+	if len(q.errs.Fields) == 0 {
+		return nil
+	}
+	return &q.errs
+}
+
+// unknown lists parameters the endpoint never read, sorted.
+func (q *queryParams) unknown() []string {
+	var names []string
+	for name := range q.values {
+		if !q.read[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkQuery answers 400 and returns false if q collected any error. It is
+// called once every parameter has been read; parameters the endpoint does
+// not know are logged, usually a client typo, but not rejected.
+func (h *responder) checkQuery(w http.ResponseWriter, r *http.Request, q *queryParams) bool {
+	if unknown := q.unknown(); len(unknown) > 0 {
+		h.log.Warn("unknown query parameters",
+			slog.String("path", r.URL.Path),
+			slog.String("params", strings.Join(unknown, ",")))
+	}
+
+	if verr := q.Err(); verr != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, model.ValidationErrorResponse{
+			Error:  errInvalidQuery,
+			Fields: verr.Fields,
+		})
+		return false
+	}
+	return true
+}
+
+// filterError answers a failed filter query: a *model.ValidationError from
+// the service names rejected parameters just as checkQuery does, anything
+// else is a 500.
+func (h *responder) filterError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *model.ValidationError
+	if errors.As(err, &verr) {
+		h.respondWithJSON(w, http.StatusBadRequest, model.ValidationErrorResponse{
+			Error:  errInvalidQuery,
+			Fields: verr.Fields,
+		})
+		return
+	}
+	h.internalError(w, r, err)
+}
+
+// checkFilter records the rules filter breaks, see
+// model.SubscriptionFilter.Validate, as query errors, so that checkQuery
+// reports them together with the malformed parameters and the service is
+// never asked.
+func checkFilter(filter model.SubscriptionFilter, q *queryParams) {
+	var verr *model.ValidationError
+	if errors.As(filter.Validate(), &verr) {
+		for field, msg := range verr.Fields {
+			q.errs.Add(field, msg)
+		}
+	}
+}
+
+// filterFromQuery reads the filter shared by the list and summary
+// endpoints from user_id, service_name, from_date and to_date. user_id and
+// service_name may be repeated or comma separated to match several users
+// or services.
+func filterFromQuery(q *queryParams) model.SubscriptionFilter {
+	filter := model.SubscriptionFilter{
+		FromDate: q.Date("from_date"),
+		ToDate:   q.Date("to_date"),
+	}
+	setServiceNames(&filter, q)
+	switch ids := q.UUIDs("user_id"); len(ids) {
+	case 0:
+	case 1:
+		filter.UserID = &ids[0]
+	default:
+		filter.UserIDs = ids
+	}
+	return filter
+}
+
+// setActiveOn reads active_on into filter as a window that starts and ends
+// on that day, which matches the subscriptions active on it. A
+// point in time and a range contradict each other, so active_on cannot be
+// combined with from_date or to_date.
+func setActiveOn(filter *model.SubscriptionFilter, q *queryParams) {
+	day := q.Date("active_on")
+	if day == nil {
+		return
+	}
+	if q.values.Get("from_date") != "" || q.values.Get("to_date") != "" {
+		q.errs.Add("active_on", "cannot be combined with from_date or to_date")
+		return
+	}
+	filter.FromDate, filter.ToDate = day, day
+}
+
+// setOnlyActive reads only_active and its inverse include_ended into
+// filter. Either alone decides; left out, ended subscriptions are kept as
+// they always were. Giving both with opposite meanings is an error.
+func setOnlyActive(filter *model.SubscriptionFilter, q *queryParams) {
+	filter.OnlyActive = q.Bool("only_active")
+	if q.values.Get("include_ended") == "" {
+		return
+	}
+	excludeEnded := !q.Bool("include_ended")
+	if q.values.Get("only_active") != "" && excludeEnded != filter.OnlyActive {
+		q.errs.Add("include_ended", "contradicts only_active")
+		return
+	}
+	filter.OnlyActive = excludeEnded
+}
+
+// setServiceNames reads service_name into filter: one name goes in
+// ServiceName exactly as before, several in ServiceNames.
+func setServiceNames(filter *model.SubscriptionFilter, q *queryParams) {
+	switch names := q.ServiceNames("service_name"); len(names) {
+	case 0:
+	case 1:
+		filter.ServiceName = &names[0]
+	default:
+		filter.ServiceNames = names
+	}
+}
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+func TestListSubscriptions_MalformedQueryIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/subscriptions?user_id=oops&from_date=yesterday&shared_with_me=maybe", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	parseResponse(t, w, &resp)
+	assert.Equal(t, "invalid query parameters", resp.Error)
+	assert.Equal(t, map[string]string{
+		"user_id":        "must be a UUID",
+		"from_date":      "must be a date in RFC3339, YYYY-MM-DD or MM-YYYY format",
+		"shared_with_me": "must be true or false",
+	}, resp.Fields)
+	mockSvc.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything)
+}
+
+func TestListSubscriptions_NormalisesServiceName(t *testing.T) {
+	tests := []struct {
+		query string
+		// want is empty when no service_name filter must be applied.
+		want string
+	}{
+		{"?service_name=%20Yandex%20Plus", "yandex plus"},
+		{"?service_name=yandex%20plus", "yandex plus"},
+		{"?service_name=%20%20", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			mockSvc.On("ListSubscriptions", mock.Anything, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+				if tt.want == "" {
+					return f.ServiceName == nil
+				}
+				return f.ServiceName != nil && *f.ServiceName == tt.want
+			})).Return(&model.ListResult{}, nil)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions"+tt.query, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListSubscriptions_SearchKeepsExactFilter(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	mockSvc.On("ListSubscriptions", mock.Anything, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+		return f.Search != nil && *f.Search == "netflix" &&
+			f.ServiceName != nil && *f.ServiceName == "netflix premium"
+	})).Return(&model.ListResult{}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?q=%20Netflix&service_name=Netflix%20Premium", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListSubscriptions_ListParams(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	netflix := "netflix"
+
+	tests := []struct {
+		name  string
+		query string
+		want  model.SubscriptionFilter
+	}{
+		{
+			name:  "comma separated",
+			query: fmt.Sprintf("?user_id=%s,%s&service_name=Netflix,%%20Spotify", alice, bob),
+			want:  model.SubscriptionFilter{UserIDs: []uuid.UUID{alice, bob}, ServiceNames: []string{"netflix", "spotify"}},
+		},
+		{
+			name:  "repeated and comma separated",
+			query: fmt.Sprintf("?user_id=%s&user_id=%s,%s&service_name=netflix&service_name=spotify", alice, bob, carol),
+			want:  model.SubscriptionFilter{UserIDs: []uuid.UUID{alice, bob, carol}, ServiceNames: []string{"netflix", "spotify"}},
+		},
+		{
+			name:  "single values",
+			query: fmt.Sprintf("?user_id=%s&service_name=Netflix", alice),
+			want:  model.SubscriptionFilter{UserID: &alice, ServiceName: &netflix},
+		},
+		{
+			name:  "empty entries",
+			query: fmt.Sprintf("?user_id=%s,&service_name=,netflix,", alice),
+			want:  model.SubscriptionFilter{UserID: &alice, ServiceName: &netflix},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			tt.want.Limit = defaultPageSize
+			mockSvc.On("ListSubscriptions", mock.Anything, tt.want).Return(&model.ListResult{}, nil)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions"+tt.query, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListSubscriptions_NamesEveryBadUserID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?user_id=bob,"+uuid.NewString()+"&user_id=eve", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	parseResponse(t, w, &resp)
+	assert.Equal(t, map[string]string{"user_id": "must be UUIDs, invalid: bob, eve"}, resp.Fields)
+	mockSvc.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything)
+}
+
+func TestActiveOn_IsOneDayWindow(t *testing.T) {
+	march1 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{FromDate: &march1, ToDate: &march1, Limit: defaultPageSize}).
+		Return(&model.ListResult{}, nil)
+	mockSvc.On("GetTotalCost", mock.Anything, service.TotalCostRequest{Filter: model.SubscriptionFilter{FromDate: &march1, ToDate: &march1}}).
+		Return(&model.TotalCostResponse{}, nil)
+
+	for _, path := range []string{"/subscriptions?active_on=2025-03-01", "/subscriptions/total?active_on=2025-03-01T00:00:00Z"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+func TestActiveOn_ExcludesDateRange(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	for _, path := range []string{
+		"/subscriptions?active_on=2025-03-01&from_date=2025-01-01",
+		"/subscriptions/total?active_on=2025-03-01&to_date=12-2025",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		var resp model.ValidationErrorResponse
+		parseResponse(t, w, &resp)
+		assert.Equal(t, map[string]string{"active_on": "cannot be combined with from_date or to_date"}, resp.Fields, path)
+	}
+	mockSvc.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything)
+	mockSvc.AssertNotCalled(t, "GetTotalCost", mock.Anything, mock.Anything)
+}
+
+func TestOnlyActive(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", false},
+		{"only_active=true", true},
+		{"only_active=false", false},
+		{"include_ended=false", true},
+		{"include_ended=true", false},
+		{"only_active=true&include_ended=false", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			mockSvc.On("ListSubscriptions", mock.Anything, model.SubscriptionFilter{OnlyActive: tt.want, Limit: defaultPageSize}).
+				Return(&model.ListResult{}, nil)
+			mockSvc.On("GetTotalCost", mock.Anything, service.TotalCostRequest{Filter: model.SubscriptionFilter{OnlyActive: tt.want}}).
+				Return(&model.TotalCostResponse{}, nil)
+
+			for _, path := range []string{"/subscriptions?" + tt.query, "/subscriptions/total?" + tt.query} {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+				assert.Equal(t, http.StatusOK, w.Code, path)
+			}
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOnlyActive_ContradictsIncludeEnded(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions?only_active=true&include_ended=true", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	parseResponse(t, w, &resp)
+	assert.Equal(t, map[string]string{"include_ended": "contradicts only_active"}, resp.Fields)
+	mockSvc.AssertNotCalled(t, "ListSubscriptions", mock.Anything, mock.Anything)
+}
+
+func TestGetTotalCost_MalformedUserIDIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/total?user_id=123", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp model.ValidationErrorResponse
+	parseResponse(t, w, &resp)
+	assert.Equal(t, map[string]string{"user_id": "must be a UUID"}, resp.Fields)
+	mockSvc.AssertNotCalled(t, "GetTotalCost", mock.Anything, mock.Anything)
+}
+
+func TestUserScopedEndpoints_MalformedUserIDIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/services?user_id=oops", nil),
+		httptest.NewRequest(http.MethodGet, "/subscriptions/expired?user_id=oops", nil),
+		httptest.NewRequest(http.MethodGet, "/subscriptions/summary/by-cycle?user_id=oops", nil),
+		httptest.NewRequest(http.MethodGet, "/subscriptions/expiring-soon/by-service?user_id=oops", nil),
+		httptest.NewRequest(http.MethodPost, "/subscriptions/expired/cleanup?user_id=oops", nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, req.URL.String())
+		assert.Contains(t, w.Body.String(), `"user_id":"must be a UUID"`, req.URL.String())
+	}
+	assert.Empty(t, mockSvc.Calls)
+}
+
+func TestListSubscriptions_UnknownQueryKeyIsLogged(t *testing.T) {
+	var logs bytes.Buffer
+	mockSvc := &MockSubscriptionService{}
+	h := NewSubscriptionHandler(mockSvc, testMaxPageSize, slog.New(slog.NewTextHandler(&logs, nil)))
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	mockSvc.On("ListSubscriptions", mock.Anything, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+		return f.UserID != nil && *f.UserID == userID
+	})).Return(&model.ListResult{}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/subscriptions?user_id="+userID.String()+"&usr_id=x&servce_name=Netflix", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, logs.String(), "unknown query parameters")
+	assert.Contains(t, logs.String(), "params=servce_name,usr_id")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTotalCost_ServiceRangeErrorIs400(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	mockSvc.On("GetTotalCost", mock.Anything, mock.Anything).Return((*model.TotalCostResponse)(nil),
+		&model.ValidationError{Fields: map[string]string{"to_date": "must be at most 5 years after from_date"}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/total?from_date=2000-01-01&to_date=2025-01-01", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"invalid query parameters","fields":{"to_date":"must be at most 5 years after from_date"}}`, w.Body.String())
+}
+
+func TestFilterQuery_InvalidFilterIs400(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		method string
+		fields map[string]string
+	}{
+		{
+			"list, reversed range",
+			"/subscriptions?from_date=2025-06-01&to_date=2025-01-01",
+			"ListSubscriptions",
+			map[string]string{"from_date": "must not be after to_date"},
+		},
+		{
+			"total, reversed range",
+			"/subscriptions/total?from_date=2025-06-01&to_date=2025-01-01",
+			"GetTotalCost",
+			map[string]string{"from_date": "must not be after to_date"},
+		},
+		{
+			"list, nil user and malformed date",
+			"/subscriptions?user_id=" + uuid.Nil.String() + "&to_date=soon",
+			"ListSubscriptions",
+			map[string]string{
+				"user_id": "must not be the nil UUID",
+				"to_date": "must be a date in RFC3339, YYYY-MM-DD or MM-YYYY format",
+			},
+		},
+		{
+			"total, nil user",
+			"/subscriptions/total?user_id=" + uuid.Nil.String(),
+			"GetTotalCost",
+			map[string]string{"user_id": "must not be the nil UUID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp model.ValidationErrorResponse
+			parseResponse(t, w, &resp)
+			assert.Equal(t, tt.fields, resp.Fields)
+			mockSvc.AssertNotCalled(t, tt.method, mock.Anything, mock.Anything)
+		})
+	}
+}
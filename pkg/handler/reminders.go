@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type ReminderHandler struct {
+	responder
+	service service.ReminderService
+}
+
+func NewReminderHandler(service service.ReminderService, log *slog.Logger) *ReminderHandler {
+	return &ReminderHandler{responder: responder{log: log}, service: service}
+}
+
+func (h *ReminderHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/subscriptions/{id}/reminders", h.CreateReminder).Methods("POST")
+	router.HandleFunc("/subscriptions/{id}/reminders", h.ListReminders).Methods("GET")
+	router.HandleFunc("/subscriptions/{id}/reminders/{reminder_id}", h.UpdateReminder).Methods("PUT")
+	router.HandleFunc("/subscriptions/{id}/reminders/{reminder_id}", h.DeleteReminder).Methods("DELETE")
+}
+
+// CreateReminder добавляет напоминание об окончании подписки
+// @Summary Создать напоминание
+// @Description Добавляет напоминание за remind_days_before дней до end_date подписки
+// @Tags Reminders
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param input body service.CreateReminderRequest true "За сколько дней напомнить (0-365)"
+// @Success 201 {object} model.Reminder "Напоминание создано"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 201 Created
+//	{
+//	    "id": "3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8",
+//	    "subscription_id": "550e8400-e29b-41d4-a716-446655440000",
+//	    "remind_days_before": 3,
+//	    "created_at": "2025-08-12T00:00:00Z"
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} model.ErrorResponse "Такое напоминание уже существует"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/reminders [post]
+func (h *ReminderHandler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	var req service.CreateReminderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.SubscriptionID = subscriptionID
+
+	reminder, err := h.service.CreateReminder(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "subscription not found")
+			return
+		}
+		h.reminderError(w, r, err)
+		return
+	}
+
+	// No Location: a single reminder cannot be read back, only listed with
+	// the others under /subscriptions/{id}/reminders.
+	h.render(w, r, http.StatusCreated, reminder)
+}
+
+// ListReminders возвращает напоминания подписки
+// @Summary Напоминания подписки
+// @Description Возвращает все напоминания подписки, начиная с самого раннего
+// @Tags Reminders
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Success 200 {array} model.Reminder
+// @Failure 400 {object} model.ErrorInput "Неверный ID подписки"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/reminders [get]
+func (h *ReminderHandler) ListReminders(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return
+	}
+
+	reminders, err := h.service.ListReminders(r.Context(), subscriptionID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	setTotalCount(w, len(reminders))
+	h.render(w, r, http.StatusOK, reminders)
+}
+
+// UpdateReminder изменяет срок напоминания
+// @Summary Изменить напоминание
+// @Description Меняет, за сколько дней до end_date придет напоминание. Отметка о последней отправке сбрасывается
+// @Tags Reminders
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param reminder_id path string true "ID напоминания" example(3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8)
+// @Param input body service.UpdateReminderRequest true "За сколько дней напомнить (0-365)"
+// @Success 200 {object} model.Reminder
+// @Failure 400 {object} model.ErrorInput "Неверный ID или формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Напоминание не найдено"
+// @Failure 409 {object} model.ErrorResponse "Такое напоминание уже существует"
+// @Failure 422 {object} model.ValidationErrorResponse "Ошибка валидации полей"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/reminders/{reminder_id} [put]
+func (h *ReminderHandler) UpdateReminder(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, reminderID, ok := h.reminderIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateReminderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+	req.ID = reminderID
+	req.SubscriptionID = subscriptionID
+
+	reminder, err := h.service.UpdateReminder(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "reminder not found")
+			return
+		}
+		h.reminderError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, reminder)
+}
+
+// DeleteReminder удаляет напоминание
+// @Summary Удалить напоминание
+// @Tags Reminders
+// @Security Tenant
+// @Param id path string true "ID подписки" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param reminder_id path string true "ID напоминания" example(3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8)
+// @Success 204 "Напоминание удалено"
+// @Failure 400 {object} model.ErrorInput "Неверный ID"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 404 {object} model.ErrorResponse "Напоминание не найдено"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/{id}/reminders/{reminder_id} [delete]
+func (h *ReminderHandler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	subscriptionID, reminderID, ok := h.reminderIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteReminder(r.Context(), subscriptionID, reminderID); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "reminder not found")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusNoContent, nil)
+}
+
+func (h *ReminderHandler) reminderIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	vars := mux.Vars(r)
+	subscriptionID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid subscription ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	reminderID, err := uuid.Parse(vars["reminder_id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid reminder ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return subscriptionID, reminderID, true
+}
+
+func (h *ReminderHandler) reminderError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *model.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		h.respondWithJSON(w, http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+			Error:  model.ErrValidation.Error(),
+			Fields: verr.Fields,
+		})
+	case errors.Is(err, model.ErrConflict):
+		h.respondWithError(w, http.StatusConflict, "reminder for this many days already exists")
+	default:
+		h.internalError(w, r, err)
+	}
+}
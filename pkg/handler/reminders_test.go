@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type MockReminderService struct {
+	mock.Mock
+}
+
+func (m *MockReminderService) CreateReminder(ctx context.Context, req service.CreateReminderRequest) (*model.Reminder, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.Reminder), args.Error(1)
+}
+
+func (m *MockReminderService) ListReminders(ctx context.Context, subscriptionID uuid.UUID) ([]model.Reminder, error) {
+	args := m.Called(ctx, subscriptionID)
+	return args.Get(0).([]model.Reminder), args.Error(1)
+}
+
+func (m *MockReminderService) UpdateReminder(ctx context.Context, req service.UpdateReminderRequest) (*model.Reminder, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*model.Reminder), args.Error(1)
+}
+
+func (m *MockReminderService) DeleteReminder(ctx context.Context, subscriptionID, id uuid.UUID) error {
+	args := m.Called(ctx, subscriptionID, id)
+	return args.Error(0)
+}
+
+func newTestReminderRouter() (*mux.Router, *MockReminderService) {
+	mockSvc := &MockReminderService{}
+	router := mux.NewRouter()
+	NewReminderHandler(mockSvc, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestCreateReminder_Success(t *testing.T) {
+	router, mockSvc := newTestReminderRouter()
+	w := httptest.NewRecorder()
+	subID := uuid.New()
+
+	reminder := &model.Reminder{ID: uuid.New(), SubscriptionID: subID, RemindDaysBefore: 3}
+	mockSvc.On("CreateReminder", mock.Anything, service.CreateReminderRequest{SubscriptionID: subID, RemindDaysBefore: 3}).
+		Return(reminder, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/"+subID.String()+"/reminders", bytes.NewBufferString(`{"remind_days_before":3}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response model.Reminder
+	parseResponse(t, w, &response)
+	assert.Equal(t, reminder.ID, response.ID)
+	assert.Empty(t, w.Header().Get("Location"), "a reminder has no URL of its own")
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateReminder_SubscriptionNotFound(t *testing.T) {
+	router, mockSvc := newTestReminderRouter()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("CreateReminder", mock.Anything, mock.Anything).
+		Return((*model.Reminder)(nil), fmt.Errorf("failed to create reminder: %w", model.ErrNotFound))
+
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/"+uuid.NewString()+"/reminders", bytes.NewBufferString(`{"remind_days_before":3}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateReminder_Conflict(t *testing.T) {
+	router, mockSvc := newTestReminderRouter()
+	w := httptest.NewRecorder()
+
+	mockSvc.On("UpdateReminder", mock.Anything, mock.Anything).
+		Return((*model.Reminder)(nil), fmt.Errorf("failed to update reminder: %w", model.ErrConflict))
+
+	r := httptest.NewRequest(http.MethodPut, "/subscriptions/"+uuid.NewString()+"/reminders/"+uuid.NewString(), bytes.NewBufferString(`{"remind_days_before":7}`))
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestDeleteReminder_InvalidID(t *testing.T) {
+	router, mockSvc := newTestReminderRouter()
+	w := httptest.NewRecorder()
+
+	r := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+uuid.NewString()+"/reminders/not-a-uuid", nil)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "DeleteReminder", mock.Anything, mock.Anything, mock.Anything)
+}
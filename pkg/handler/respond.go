@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"SubscriptionAggregator/pkg/circuitbreaker"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/render"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// responder writes responses and errors; every resource handler embeds
+// it so they all share one error envelope.
+type responder struct {
+	log *slog.Logger
+}
+
+// internalError logs err with the request context and answers with a
+// generic message: repository errors carry SQL and connection details that
+// must not reach clients. The request id lets support find the log line.
+// A missing tenant is the one error every service call may return, so it
+// is answered here with the same 401 the tenant middleware sends. An open
+// database circuit can reach every handler too; it gets a 503 so clients
+// retry later instead of reporting a bug.
+func (h *responder) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrNoTenant) {
+		h.respondWithError(w, http.StatusUnauthorized, "missing or invalid tenant")
+		return
+	}
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		h.respondWithError(w, http.StatusServiceUnavailable, "database unavailable, try again later")
+		return
+	}
+
+	requestID := middleware.RequestIDFromContext(r.Context())
+
+	h.log.Error("request failed",
+		slog.String("request_id", requestID),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("error", err.Error()),
+	)
+
+	h.respondWithJSON(w, http.StatusInternalServerError, model.ServerError{
+		Error:     "internal server error",
+		RequestID: requestID,
+	})
+}
+
+func (h *responder) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+// storeError answers the validation errors of the services and the
+// constraint violations reported by the repository with 409 or 422 and
+// anything else with a generic 500.
+func (h *responder) storeError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *model.ValidationError
+	var derr *model.DuplicateError
+	var cerr *model.VersionConflictError
+	switch {
+	case errors.As(err, &verr):
+		h.respondWithJSON(w, http.StatusUnprocessableEntity, model.ValidationErrorResponse{
+			Error:  model.ErrValidation.Error(),
+			Fields: verr.Fields,
+		})
+	case errors.As(err, &cerr):
+		h.respondWithJSON(w, http.StatusConflict, model.VersionConflictResponse{
+			Error:          "subscription was changed meanwhile, fetch it again and retry",
+			CurrentVersion: cerr.Current,
+		})
+	case errors.As(err, &derr):
+		h.respondWithJSON(w, http.StatusConflict, model.DuplicateErrorResponse{
+			Error:      "subscription overlaps an existing one, pass allow_duplicate=true to store it anyway",
+			Code:       http.StatusConflict,
+			ExistingID: derr.ExistingID,
+		})
+	case errors.Is(err, model.ErrConflict):
+		h.respondWithError(w, http.StatusConflict, "subscription conflicts with an existing record")
+	case errors.Is(err, model.ErrInvalidReference):
+		h.respondWithError(w, http.StatusUnprocessableEntity, "subscription references a record that does not exist")
+	case errors.Is(err, model.ErrLimitExceeded):
+		h.respondWithError(w, http.StatusUnprocessableEntity, "user has reached their subscription limit")
+	default:
+		h.internalError(w, r, err)
+	}
+}
+
+// respondCreated answers 201 with payload and a Location header pointing
+// at the created resource, so a client can fetch it without knowing how
+// its URL is built.
+func (h *responder) respondCreated(w http.ResponseWriter, r *http.Request, location string, payload interface{}) {
+	w.Header().Set("Location", location)
+	h.render(w, r, http.StatusCreated, payload)
+}
+
+// render answers with payload in the media type negotiated for r, see
+// middleware.NegotiateMiddleware. Errors go through respondWithJSON
+// instead: a client must be able to read them whatever it asked for.
+func (h *responder) render(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	h.renderWith(w, render.FromContext(r.Context()), code, payload)
+}
+
+func (h *responder) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	h.renderWith(w, render.JSON{}, code, payload)
+}
+
+// renderWith reports a payload the renderer cannot encode as a 500; the
+// renderer has written nothing yet at that point.
+func (h *responder) renderWith(w http.ResponseWriter, renderer render.Renderer, code int, payload interface{}) {
+	if err := renderer.Render(w, code, payload); err != nil {
+		h.log.Error("failed to encode response", slog.Int("status", code), slog.String("error", err.Error()))
+		w.Header().Set("Content-Type", render.JSONContentType)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"internal server error"}` + "\n"))
+	}
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// defaultTopServicesLimit is how many services are ranked when limit is not
+// given.
+const defaultTopServicesLimit = 5
+
+// defaultForecastMonths is how far ahead the forecast and the projection
+// look when months is not given.
+const defaultForecastMonths = 12
+
+// GetUserSummary возвращает краткую сводку по подпискам пользователя
+// @Summary Сводка по подпискам пользователя
+// @Description Количество активных и истекших подписок, месячная стоимость активных подписок, самый дорогой сервис и ближайшая дата окончания. Для пользователя без подписок возвращаются нули
+// @Tags Users
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success 200 {object} model.UserSummary
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "active_count": 5,
+//	    "total_monthly_cost": 1500,
+//	    "most_expensive_service": "Netflix",
+//	    "next_expiry": "2025-09-01T00:00:00Z",
+//	    "expired_count": 2
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный ID пользователя"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/summary [get]
+func (h *SubscriptionHandler) GetUserSummary(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	summary, err := h.service.GetUserSummary(r.Context(), userID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, summary)
+}
+
+// GetTopServices возвращает самые дорогие сервисы пользователя
+// @Summary Самые дорогие сервисы пользователя
+// @Description Сервисы пользователя по убыванию расходов за период (с учетом периода оплаты и числа оплаченных месяцев) и доля каждого в процентах. Все остальные сервисы суммируются в other, так что проценты в сумме дают 100
+// @Tags Users
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param limit query int false "Сколько сервисов вернуть (1-50)" default(5)
+// @Param from_date query string false "Начало периода (RFC3339, YYYY-MM-DD или MM-YYYY)" example(2025-01-01)
+// @Param to_date query string false "Конец периода, по умолчанию текущий месяц (RFC3339, YYYY-MM-DD или MM-YYYY)" example(12-2025)
+// @Success 200 {object} model.TopServices
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "services": [
+//	        {"service_name": "Netflix", "total": 5994, "percent": 41.63},
+//	        {"service_name": "Yandex Plus", "total": 3594, "percent": 24.96}
+//	    ],
+//	    "other": {"service_count": 3, "total": 4812, "percent": 33.41},
+//	    "total": 14400
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный ID пользователя, limit или период"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/subscriptions/top [get]
+func (h *SubscriptionHandler) GetTopServices(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	q := newQueryParams(r)
+	filter := model.SubscriptionFilter{
+		FromDate: q.Date("from_date"),
+		ToDate:   q.Date("to_date"),
+	}
+	limit := q.Int("limit", defaultTopServicesLimit)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	top, err := h.service.GetTopServices(r.Context(), userID, filter, limit)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, top)
+}
+
+// GetForecast возвращает прогноз расходов пользователя
+// @Summary Прогноз расходов пользователя
+// @Description Расходы пользователя по месяцам, начиная с текущего, если ничего не изменится. Месяц считается так же, как в /subscriptions/total/monthly: месячный эквивалент цены каждой подписки, активной в этом месяце. Подписка с end_date перестает учитываться после месяца окончания
+// @Tags Users
+// @Produce json
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param months query int false "На сколько месяцев вперед (1-36)" default(12)
+// @Success 200 {object} model.Forecast
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "months": [
+//	        {"month": "2025-08-01T00:00:00Z", "total": 1500},
+//	        {"month": "2025-09-01T00:00:00Z", "total": 901}
+//	    ],
+//	    "total": 2401
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный ID пользователя или months"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/subscriptions/forecast [get]
+func (h *SubscriptionHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	q := newQueryParams(r)
+	months := q.Int("months", defaultForecastMonths)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	forecast, err := h.service.GetForecast(r.Context(), userID, months)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, forecast)
+}
+
+// ProjectCosts возвращает прогноз расходов на подписки по месяцам
+// @Summary Прогноз расходов по месяцам
+// @Description Ожидаемые расходы пользователя на каждый из следующих месяцев, начиная со следующего за текущим. Подписка учитывается в месяце, только если действует весь месяц: началась не позже первого дня и заканчивается не раньше последнего. Сумма месяца складывается из месячного эквивалента цены этих подписок
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Param user_id query string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param months query int false "На сколько месяцев вперед (1-36)" default(12)
+// @Success 200 {array} model.ProjectedCost
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	[
+//	    {"month": "2025-08", "projected_cost": 1500},
+//	    {"month": "2025-09", "projected_cost": 901}
+//	]
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Нет или неверный user_id, неверный months"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/project [get]
+func (h *SubscriptionHandler) ProjectCosts(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	q.Require("user_id")
+	userID := q.UUID("user_id")
+	months := q.Int("months", defaultForecastMonths)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	projection, err := h.service.ProjectCosts(r.Context(), *userID, months)
+	if err != nil {
+		h.filterError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, projection)
+}
+
+// SimulateCost считает, как изменятся расходы пользователя при отмене и добавлении подписок
+// @Summary Расчет расходов "что если"
+// @Description Сравнивает стоимость подписок пользователя в текущем календарном месяце, посчитанную так же, как /subscriptions/total с mode=prorated за этот месяц, со стоимостью после воображаемых изменений: подписок из add, как будто они созданы, и без подписок из exclude_ids, как будто они отменены. Ничего не сохраняется. Подписки из add проверяются так же, как при создании, user_id в них можно не указывать. В exclude_ids можно передать только подписки этого пользователя. За один запрос можно передать не больше 50 подписок в add и 50 ID в exclude_ids
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Security Tenant
+// @Param input body service.SimulateCostRequest true "Пользователь и воображаемые изменения"
+// @Success 200 {object} model.CostSimulation
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	{
+//	    "month": "2025-03-01T00:00:00Z",
+//	    "current_total": 1798,
+//	    "simulated_total": 1398,
+//	    "difference": -400,
+//	    "currency": "RUB"
+//	}
+//
+// @Failure 400 {object} model.ErrorInput "Неверный формат данных"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 413 {object} model.ErrorResponse "Слишком большое тело запроса"
+// @Failure 415 {object} model.ErrorResponse "Content-Type должен быть application/json"
+// @Failure 422 {object} model.ValidationErrorResponse "Нет user_id, неверная подписка в add или чужая подписка в exclude_ids"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /subscriptions/simulate [post]
+func (h *SubscriptionHandler) SimulateCost(w http.ResponseWriter, r *http.Request) {
+	var req service.SimulateCostRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.payloadError(w, err)
+		return
+	}
+
+	sim, err := h.service.SimulateCost(r.Context(), req)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, sim)
+}
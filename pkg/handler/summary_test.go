@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+func TestGetUserSummary_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	next := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("GetUserSummary", mock.Anything, userID).Return(&model.UserSummary{
+		ActiveCount:          5,
+		TotalMonthlyCost:     1500,
+		MostExpensiveService: "Netflix",
+		NextExpiry:           &next,
+		ExpiredCount:         2,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/summary", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active_count":5,"total_monthly_cost":1500,"most_expensive_service":"Netflix",
+		"next_expiry":"2025-09-01T00:00:00Z","expired_count":2}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetUserSummary_InvalidUserID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/oops/summary", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "GetUserSummary", mock.Anything, mock.Anything)
+}
+
+func TestGetTopServices_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("GetTopServices", mock.Anything, userID, model.SubscriptionFilter{FromDate: &from}, 2).Return(&model.TopServices{
+		Services: []model.ServiceCost{
+			{ServiceName: "Netflix", Total: 600, Percent: 60},
+			{ServiceName: "Spotify", Total: 300, Percent: 30},
+		},
+		Other: model.OtherServicesCost{ServiceCount: 1, Total: 100, Percent: 10},
+		Total: 1000,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/subscriptions/top?limit=2&from_date=2025-01-01", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"services":[{"service_name":"Netflix","total":600,"percent":60},{"service_name":"Spotify","total":300,"percent":30}],
+		"other":{"service_count":1,"total":100,"percent":10},"total":1000}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTopServices_DefaultLimit(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	mockSvc.On("GetTopServices", mock.Anything, userID, model.SubscriptionFilter{}, defaultTopServicesLimit).
+		Return(&model.TopServices{Services: []model.ServiceCost{}}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/subscriptions/top", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetTopServices_InvalidLimit(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	verr := &model.ValidationError{}
+	verr.Add("limit", "must be between 1 and 50")
+	mockSvc.On("GetTopServices", mock.Anything, mock.Anything, mock.Anything, 51).Return(nil, verr)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString()+"/subscriptions/top?limit=51", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"limit":"must be between 1 and 50"`)
+}
+
+func TestGetForecast_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	aug := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("GetForecast", mock.Anything, userID, 2).Return(&model.Forecast{
+		Months: []model.MonthlyCost{{Month: aug, Total: 1500}, {Month: aug.AddDate(0, 1, 0), Total: 901}},
+		Total:  2401,
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/subscriptions/forecast?months=2", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"months":[{"month":"2025-08-01T00:00:00Z","total":1500},{"month":"2025-09-01T00:00:00Z","total":901}],"total":2401}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetForecast_DefaultMonths(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	mockSvc.On("GetForecast", mock.Anything, userID, defaultForecastMonths).
+		Return(&model.Forecast{Months: []model.MonthlyCost{}}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/subscriptions/forecast", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetForecast_InvalidMonths(t *testing.T) {
+	h, _ := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString()+"/subscriptions/forecast?months=a+year", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"months"`)
+}
+
+func TestProjectCosts_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID := uuid.New()
+	mockSvc.On("ProjectCosts", mock.Anything, userID, 2).Return([]model.ProjectedCost{
+		{Month: "2025-08", ProjectedCost: 1500},
+		{Month: "2025-09", ProjectedCost: 901},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/project?user_id="+userID.String()+"&months=2", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"month":"2025-08","projected_cost":1500},{"month":"2025-09","projected_cost":901}]`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestProjectCosts_RequiresUserID(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/project?months=6", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id"`)
+	mockSvc.AssertNotCalled(t, "ProjectCosts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSimulateCost_Success(t *testing.T) {
+	h, mockSvc := newTestHandler()
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+
+	userID, cancelled := uuid.New(), uuid.New()
+	want := service.SimulateCostRequest{
+		UserID: userID,
+		Add: []service.CreateSubscriptionRequest{
+			{ServiceName: "Kinopoisk", Price: 1200, StartDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		ExcludeIDs: []uuid.UUID{cancelled},
+	}
+	mockSvc.On("SimulateCost", mock.Anything, want).Return(&model.CostSimulation{
+		Month:          time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		CurrentTotal:   1798,
+		SimulatedTotal: 1398,
+		Difference:     -400,
+		Currency:       "RUB",
+	}, nil)
+
+	body := fmt.Sprintf(`{"user_id":%q,"add":[{"service_name":"Kinopoisk","price":1200,"start_date":"03-2025"}],"exclude_ids":[%q]}`,
+		userID, cancelled)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/simulate", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp model.CostSimulation
+	parseResponse(t, w, &resp)
+	assert.Equal(t, 1398, resp.SimulatedTotal)
+	assert.Equal(t, -400, resp.Difference)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSimulateCost_Errors(t *testing.T) {
+	foreign := &model.ValidationError{}
+	foreign.Add("exclude_ids[0]", "is not a subscription of user_id")
+
+	tests := []struct {
+		name     string
+		body     string
+		svcErr   error
+		wantCode int
+		wantBody string
+	}{
+		{"invalid date", `{"add":[{"start_date":"yesterday"}]}`, nil, http.StatusBadRequest, "start_date"},
+		{"unknown field", `{"remove":[]}`, nil, http.StatusBadRequest, `unknown field \"remove\"`},
+		{"validation", `{"exclude_ids":[]}`, foreign, http.StatusUnprocessableEntity, `"exclude_ids[0]":"is not a subscription of user_id"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandler()
+			router := mux.NewRouter()
+			h.RegisterRoutes(router)
+			if tt.svcErr != nil {
+				mockSvc.On("SimulateCost", mock.Anything, mock.Anything).Return(nil, tt.svcErr)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/simulate", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.svcErr == nil {
+				mockSvc.AssertNotCalled(t, "SimulateCost", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/exporter"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+const (
+	// UserExportRoute lets a user download their own data.
+	UserExportRoute = "/users/{user_id}/export"
+	// AdminUserExportRoute lets an administrator download any user's data.
+	// It sits under /admin, so it takes the admin token instead of a tenant.
+	AdminUserExportRoute = "/admin/users/{user_id}/export"
+)
+
+// User export formats; JSON is the default.
+const (
+	userExportFormatJSON = "json"
+	userExportFormatZip  = "zip"
+)
+
+// UserExportHandler serves the data export of a single user.
+type UserExportHandler struct {
+	responder
+	service    service.UserExportService
+	adminToken string
+}
+
+// NewUserExportHandler builds the handler; with an empty adminToken only
+// users can export, each their own data.
+func NewUserExportHandler(service service.UserExportService, adminToken string, log *slog.Logger) *UserExportHandler {
+	return &UserExportHandler{responder: responder{log: log}, service: service, adminToken: adminToken}
+}
+
+// RegisterRoutes adds both routes. AdminUserExportRoute carries its own
+// token check since it is not part of AdminHandler's subrouter.
+func (h *UserExportHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(UserExportRoute, h.ExportOwnData).Methods("GET")
+	router.Handle(AdminUserExportRoute, h.requireAdminToken(h.adminToken)(http.HandlerFunc(h.ExportUserData))).Methods("GET")
+}
+
+// ExportOwnData выгружает все данные пользователя
+// @Summary Выгрузка данных пользователя
+// @Description Возвращает все подписки пользователя, историю их цен и журнал доступа к его данным одним JSON-документом или, при format=zip, архивом с файлами subscriptions.csv, price_history.csv и audit_log.csv. Доступно только самому пользователю: ID из заголовка X-User-ID (или claim sub JWT) должен совпадать с user_id. Данные передаются по мере чтения из базы, поэтому прерванная выгрузка обрывается на середине файла. Каждая выгрузка записывается в журнал после завершения со статусом completed или failed
+// @Tags Users
+// @Produce json,application/zip
+// @Security Tenant
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param X-User-ID header string false "ID вызывающего пользователя, если тенант передается заголовком" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param format query string false "Формат выгрузки" Enums(json, zip) default(json)
+// @Success 200 {object} model.UserDataExport
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 200 OK
+//	Content-Disposition: attachment; filename="user-60601fee-2bf1-4721-ae6f-7636e79a0cba.json"
+//	{
+//	    "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	    "exported_at": "2025-08-12T10:30:00Z",
+//	    "subscriptions": [
+//	        {
+//	            "id": "550e8400-e29b-41d4-a716-446655440000",
+//	            "service_name": "yandex plus",
+//	            "price": 599,
+//	            "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	            "start_date": "2025-07-12T00:00:00Z",
+//	            "billing_cycle": "monthly",
+//	            "version": 1
+//	        }
+//	    ],
+//	    "price_history": [
+//	        {
+//	            "subscription_id": "550e8400-e29b-41d4-a716-446655440000",
+//	            "price": 599,
+//	            "recorded_at": "2025-07-12T00:00:00Z"
+//	        }
+//	    ],
+//	    "audit_log": [
+//	        {
+//	            "id": 17,
+//	            "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+//	            "actor": "user",
+//	            "action": "user_data_export",
+//	            "request_id": "c0ffee00-1234-4abc-9def-0123456789ab",
+//	            "status": "completed",
+//	            "created_at": "2025-07-12T10:30:00Z"
+//	        }
+//	    ]
+//	}
+//
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный user_id или неизвестный format"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант либо не указан вызывающий пользователь"
+// @Failure 403 {object} model.ErrorResponse "Выгрузка чужих данных"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /users/{user_id}/export [get]
+func (h *UserExportHandler) ExportOwnData(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := pathUserID(r, q)
+	format := exportFormat(q)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	if !h.checkCaller(w, r, userID) {
+		return
+	}
+
+	h.export(w, r, userID, model.AuditActorUser, format)
+}
+
+// ExportUserData выгружает все данные любого пользователя тенанта
+// @Summary Выгрузка данных пользователя администратором
+// @Description То же, что GET /users/{user_id}/export, для любого пользователя тенанта tenant_id. Требует заголовок Authorization: Bearer <admin-token>. Выгрузка записывается в журнал пользователя как действие администратора
+// @Tags Admin
+// @Produce json,application/zip
+// @Security AdminToken
+// @Param user_id path string true "ID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Param tenant_id query string true "ID тенанта пользователя" example(0b6d1c2a-3e4f-4a5b-8c9d-0e1f2a3b4c5d)
+// @Param format query string false "Формат выгрузки" Enums(json, zip) default(json)
+// @Success 200 {object} model.UserDataExport
+// @Failure 400 {object} model.ValidationErrorResponse "Неверный user_id, нет tenant_id или неизвестный format"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный admin-токен"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /admin/users/{user_id}/export [get]
+func (h *UserExportHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	userID := pathUserID(r, q)
+	q.Require("tenant_id")
+	tenantID := q.UUID("tenant_id")
+	format := exportFormat(q)
+	if !h.checkQuery(w, r, q) {
+		return
+	}
+
+	h.export(w, withAdminTenant(r, *tenantID), userID, model.AuditActorAdmin, format)
+}
+
+func (h *UserExportHandler) export(w http.ResponseWriter, r *http.Request, userID uuid.UUID, actor, format string) {
+	requestID := middleware.RequestIDFromContext(r.Context())
+	out := &exportResponse{ResponseWriter: w, filename: "user-" + userID.String() + "." + format}
+	var writer service.UserDataWriter
+	if format == userExportFormatZip {
+		out.contentType = exporter.ZipContentType
+		writer = exporter.NewUserDataZipWriter(out, time.Now())
+	} else {
+		out.contentType = "application/json"
+		writer = exporter.NewUserDataJSONWriter(out, userID, time.Now())
+	}
+
+	err := h.service.ExportUserData(r.Context(), service.UserExportRequest{
+		UserID:    userID,
+		Actor:     actor,
+		RequestID: requestID,
+	}, writer)
+	if err == nil {
+		return
+	}
+	// The export is written straight to the client; once the first byte is
+	// out a failure can only be logged.
+	if !out.started {
+		h.internalError(w, r, err)
+		return
+	}
+	h.log.Warn("user data export interrupted",
+		slog.String("request_id", requestID),
+		slog.String("error", err.Error()),
+	)
+}
+
+// exportResponse sends the headers of a download with its first byte, so
+// that an export failing before then is still answered with an error.
+type exportResponse struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.Header().Set("Content-Type", e.contentType)
+		e.Header().Set("Content-Disposition", `attachment; filename="`+e.filename+`"`)
+		e.WriteHeader(http.StatusOK)
+	}
+	return e.ResponseWriter.Write(p)
+}
+
+// pathUserID parses the user_id path variable, reporting a bad one through
+// q so that it is answered with the query errors.
+func pathUserID(r *http.Request, q *queryParams) uuid.UUID {
+	id, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil || id == uuid.Nil {
+		q.errs.Add("user_id", "must be a UUID")
+	}
+	return id
+}
+
+// checkCaller answers 401 when the request names no calling user and 403
+// when it names someone other than userID, and then returns false.
+func (h *responder) checkCaller(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	caller, ok := ctxkey.Get[uuid.UUID](r.Context(), ctxkey.KeyUserID)
+	if !ok {
+		h.respondWithError(w, http.StatusUnauthorized, "missing or invalid user")
+		return false
+	}
+	if caller != userID {
+		h.respondWithError(w, http.StatusForbidden, "users can only access their own data")
+		return false
+	}
+	return true
+}
+
+// withAdminTenant sets the tenant of an /admin request, which the tenant
+// middleware skips, from its tenant_id parameter.
+func withAdminTenant(r *http.Request, tenantID uuid.UUID) *http.Request {
+	return r.WithContext(ctxkey.With(r.Context(), ctxkey.KeyTenantID, tenantID))
+}
+
+func exportFormat(q *queryParams) string {
+	format := q.String("format")
+	if format == nil {
+		return userExportFormatJSON
+	}
+	if *format != userExportFormatJSON && *format != userExportFormatZip {
+		q.errs.Add("format", "must be "+userExportFormatJSON+" or "+userExportFormatZip)
+	}
+	return *format
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+type MockUserExportService struct {
+	mock.Mock
+}
+
+func (m *MockUserExportService) ExportUserData(ctx context.Context, req service.UserExportRequest, w service.UserDataWriter) error {
+	args := m.Called(ctx, req, w)
+	if rf, ok := args.Get(0).(func(service.UserDataWriter) error); ok {
+		return rf(w)
+	}
+	return args.Error(0)
+}
+
+// writesExport is a mock answer to ExportUserData that writes export and,
+// if it is not nil, fails with err before closing the writer.
+func writesExport(export *model.UserDataExport, err error) func(service.UserDataWriter) error {
+	return func(w service.UserDataWriter) error {
+		for _, sub := range export.Subscriptions {
+			if err := w.WriteSubscription(sub); err != nil {
+				return err
+			}
+		}
+		for _, c := range export.PriceHistory {
+			if err := w.WritePriceChange(c); err != nil {
+				return err
+			}
+		}
+		for _, e := range export.AuditLog {
+			if err := w.WriteAuditEntry(e); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return w.Close()
+	}
+}
+
+// newTestUserExportRouter registers the export routes ahead of the admin
+// API behind the tenant middleware, as main does.
+func newTestUserExportRouter() (*mux.Router, *MockUserExportService) {
+	mockSvc := &MockUserExportService{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := mux.NewRouter()
+	router.Use(middleware.TenantMiddleware(middleware.TenantSource{}, "/admin/"))
+	NewUserExportHandler(mockSvc, testAdminToken, log).RegisterRoutes(router)
+	NewAdminHandler(&MockSubscriptionService{}, testAdminToken, log).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func testUserDataExport(userID uuid.UUID) *model.UserDataExport {
+	at := time.Date(2025, 8, 12, 10, 30, 0, 0, time.UTC)
+	return &model.UserDataExport{
+		UserID:        userID,
+		ExportedAt:    at,
+		Subscriptions: []*model.Subscription{{ID: uuid.New(), ServiceName: "netflix", Price: 799, UserID: userID, BillingCycle: model.CycleMonthly}},
+		PriceHistory:  []model.PriceChange{},
+		AuditLog:      []model.AuditEntry{{ID: 1, UserID: userID, Actor: model.AuditActorUser, Action: model.AuditActionExport, Status: model.AuditStatusCompleted, CreatedAt: at}},
+	}
+}
+
+// userRequest is a request to path from user of tenant; empty values are
+// left out.
+func userRequest(method, path, tenant, user string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if tenant != "" {
+		r.Header.Set(middleware.DefaultTenantHeader, tenant)
+	}
+	if user != "" {
+		r.Header.Set(middleware.DefaultUserHeader, user)
+	}
+	return r
+}
+
+func TestExportOwnData_JSON(t *testing.T) {
+	router, mockSvc := newTestUserExportRouter()
+	tenantID, userID := uuid.New(), uuid.New()
+	export := testUserDataExport(userID)
+
+	mockSvc.On("ExportUserData", mock.MatchedBy(func(ctx context.Context) bool {
+		got, _ := ctxkey.Get[uuid.UUID](ctx, ctxkey.KeyTenantID)
+		return got == tenantID
+	}), service.UserExportRequest{UserID: userID, Actor: model.AuditActorUser}, mock.Anything).Return(writesExport(export, nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest(http.MethodGet, "/users/"+userID.String()+"/export", tenantID.String(), userID.String()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="user-`+userID.String()+`.json"`, w.Header().Get("Content-Disposition"))
+	var got model.UserDataExport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.WithinDuration(t, time.Now(), got.ExportedAt, time.Minute)
+	got.ExportedAt = export.ExportedAt
+	assert.Equal(t, *export, got)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestExportOwnData_Zip(t *testing.T) {
+	router, mockSvc := newTestUserExportRouter()
+	userID := uuid.New()
+	mockSvc.On("ExportUserData", mock.Anything, mock.Anything, mock.Anything).Return(writesExport(testUserDataExport(userID), nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest(http.MethodGet, "/users/"+userID.String()+"/export?format=zip", uuid.NewString(), userID.String()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="user-`+userID.String()+`.zip"`, w.Header().Get("Content-Disposition"))
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"subscriptions.csv", "price_history.csv", "audit_log.csv"}, names)
+}
+
+func TestExportOwnData_Refused(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name   string
+		path   string
+		tenant string
+		user   string
+		want   int
+	}{
+		{"no tenant", "/users/" + userID.String() + "/export", "", userID.String(), http.StatusUnauthorized},
+		{"no user", "/users/" + userID.String() + "/export", uuid.NewString(), "", http.StatusUnauthorized},
+		{"someone else", "/users/" + userID.String() + "/export", uuid.NewString(), uuid.NewString(), http.StatusForbidden},
+		{"bad user_id", "/users/nope/export", uuid.NewString(), userID.String(), http.StatusBadRequest},
+		{"unknown format", "/users/" + userID.String() + "/export?format=xml", uuid.NewString(), userID.String(), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestUserExportRouter()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, userRequest(http.MethodGet, tt.path, tt.tenant, tt.user))
+
+			assert.Equal(t, tt.want, w.Code)
+			mockSvc.AssertNotCalled(t, "ExportUserData", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestExportOwnData_ServiceError(t *testing.T) {
+	router, mockSvc := newTestUserExportRouter()
+	userID := uuid.New()
+	mockSvc.On("ExportUserData", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest(http.MethodGet, "/users/"+userID.String()+"/export", uuid.NewString(), userID.String()))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestExportOwnData_InterruptedAfterFirstRow(t *testing.T) {
+	router, mockSvc := newTestUserExportRouter()
+	userID := uuid.New()
+	mockSvc.On("ExportUserData", mock.Anything, mock.Anything, mock.Anything).
+		Return(writesExport(testUserDataExport(userID), errors.New("connection reset")))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, userRequest(http.MethodGet, "/users/"+userID.String()+"/export", uuid.NewString(), userID.String()))
+
+	assert.Equal(t, http.StatusOK, w.Code, "the status was sent with the first row")
+	assert.Equal(t, `attachment; filename="user-`+userID.String()+`.json"`, w.Header().Get("Content-Disposition"))
+	assert.False(t, json.Valid(w.Body.Bytes()), "the document is cut short")
+}
+
+func TestAdminExportUserData(t *testing.T) {
+	router, mockSvc := newTestUserExportRouter()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mockSvc.On("ExportUserData", mock.MatchedBy(func(ctx context.Context) bool {
+		got, _ := ctxkey.Get[uuid.UUID](ctx, ctxkey.KeyTenantID)
+		return got == tenantID
+	}), service.UserExportRequest{UserID: userID, Actor: model.AuditActorAdmin}, mock.Anything).Return(writesExport(testUserDataExport(userID), nil))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin/users/"+userID.String()+"/export?tenant_id="+tenantID.String(), nil)
+	r.Header.Set("Authorization", "Bearer "+testAdminToken)
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="user-`+userID.String()+`.json"`, w.Header().Get("Content-Disposition"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestAdminExportUserData_Refused(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name  string
+		query string
+		auth  string
+		want  int
+	}{
+		{"no token", "?tenant_id=" + uuid.NewString(), "", http.StatusUnauthorized},
+		{"wrong token", "?tenant_id=" + uuid.NewString(), "Bearer nope", http.StatusUnauthorized},
+		{"no tenant_id", "", "Bearer " + testAdminToken, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockSvc := newTestUserExportRouter()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/admin/users/"+userID.String()+"/export"+tt.query, nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			mockSvc.AssertNotCalled(t, "ExportUserData", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// WebSocketRoute pushes the events of StreamRoute over a WebSocket, for
+// clients behind proxies that do not pass Server-Sent Events through.
+const WebSocketRoute = "/ws"
+
+const (
+	// wsWriteWait bounds every write, so a client that stopped reading
+	// cannot hold its connection forever.
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long a connection may stay silent; pings go out
+	// often enough for a live client to answer within it.
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessage bounds a client message; a filter is far smaller.
+	wsMaxMessage = 1024
+)
+
+const invalidFilterMessage = `invalid filter, send {"user_id":"<uuid>"} or {"user_id":null}`
+
+// wsFilter is the message a client sends to receive only the events of one
+// user. A null or missing user_id receives every event of the tenant again.
+type wsFilter struct {
+	UserID *uuid.UUID `json:"user_id"`
+}
+
+// WebSocketHandler serves WebSocketRoute. It keeps track of its
+// connections because http.Server.Shutdown neither closes nor waits for
+// hijacked ones, see Shutdown.
+type WebSocketHandler struct {
+	responder
+	service  service.SubscriptionService
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	stopped bool
+	closing chan struct{}
+	conns   sync.WaitGroup
+}
+
+func NewWebSocketHandler(service service.SubscriptionService, log *slog.Logger) *WebSocketHandler {
+	h := &WebSocketHandler{responder: responder{log: log}, service: service, closing: make(chan struct{})}
+	h.upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		h.respondWithError(w, status, reason.Error())
+	}
+	return h
+}
+
+func (h *WebSocketHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(WebSocketRoute, h.StreamSubscriptionChanges).Methods("GET")
+}
+
+// StreamSubscriptionChanges транслирует изменения подписок через WebSocket
+// @Summary Изменения подписок через WebSocket
+// @Description Те же события, что и /subscriptions/stream, но через WebSocket: каждое событие приходит текстовым фреймом с JSON. Чтобы получать события одного пользователя, отправьте {"user_id":"<uuid>"}, чтобы снова получать все - {"user_id":null}; на другое сообщение придет {"error":"..."}. Сервер отправляет ping каждые 54 секунды и закрывает соединение, если за 60 секунд не пришло ни одного фрейма; при остановке сервера соединение закрывается с кодом 1001
+// @Tags Subscriptions
+// @Produce json
+// @Security Tenant
+// @Success 101 {object} model.SubscriptionEvent "Соединение переключено на WebSocket"
+// @SuccessExample {json} Success-Response:
+//
+//	HTTP/1.1 101 Switching Protocols
+//	{"event":"created","id":"550e8400-e29b-41d4-a716-446655440000","user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba"}
+//
+// @Failure 400 {object} model.ErrorResponse "Запрос не является WebSocket handshake"
+// @Failure 401 {object} model.ErrorResponse "Нет или неверный тенант"
+// @Failure 403 {object} model.ErrorResponse "Origin не совпадает с Host"
+// @Failure 503 {object} model.ErrorResponse "Поток изменений не настроен или сервер останавливается"
+// @Failure 500 {object} model.ServerError "Ошибка сервера"
+// @Router /ws [get]
+func (h *WebSocketHandler) StreamSubscriptionChanges(w http.ResponseWriter, r *http.Request) {
+	if !h.track() {
+		h.respondWithError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+	defer h.conns.Done()
+
+	// A hijacked connection no longer cancels the request context, so the
+	// reader cancels this one when the client goes away.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	changes, err := h.service.SubscribeToChanges(ctx)
+	if err != nil {
+		if errors.Is(err, service.ErrChangesUnavailable) {
+			h.respondWithError(w, http.StatusServiceUnavailable, "change stream unavailable")
+			return
+		}
+		h.internalError(w, r, err)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered through its Error func.
+		return
+	}
+	defer conn.Close()
+
+	filters := make(chan *wsFilter)
+	go readFilters(ctx, cancel, conn, filters)
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	var userID *uuid.UUID
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.closing:
+			closeWebSocket(ctx, conn, "server is shutting down")
+			return
+		case f := <-filters:
+			if f == nil {
+				if err := writeWebSocketJSON(conn, model.ErrorResponse{Error: invalidFilterMessage}); err != nil {
+					return
+				}
+				continue
+			}
+			userID = f.UserID
+		case event, ok := <-changes:
+			if !ok {
+				closeWebSocket(ctx, conn, "change stream ended")
+				return
+			}
+			if userID != nil && (event.UserID == nil || *event.UserID != *userID) {
+				continue
+			}
+			if err := writeWebSocketJSON(conn, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Shutdown closes every open connection with 1001 Going Away and waits
+// until their handlers return or ctx is done. Connections opened after it
+// is called are refused with 503. Call it once http.Server.Shutdown has
+// returned.
+func (h *WebSocketHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.stopped {
+		h.stopped = true
+		close(h.closing)
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track counts a new connection unless Shutdown has been called.
+func (h *WebSocketHandler) track() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return false
+	}
+	h.conns.Add(1)
+	return true
+}
+
+// readFilters reads client messages until the connection fails or the
+// client closes it, then cancels the stream. Pongs extend the read
+// deadline. A message that is not a filter is passed on as nil, so the
+// writer can answer it; gorilla/websocket allows one writer only.
+func readFilters(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, filters chan<- *wsFilter) {
+	defer cancel()
+
+	conn.SetReadLimit(wsMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		f := &wsFilter{}
+		if err := json.Unmarshal(data, f); err != nil {
+			f = nil
+		}
+		select {
+		case filters <- f:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeWebSocketJSON(conn *websocket.Conn, v any) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteJSON(v)
+}
+
+// closeWebSocket starts the closing handshake and waits, at most
+// wsWriteWait, for the client to answer it, which ends the reader and so
+// ctx.
+func closeWebSocket(ctx context.Context, conn *websocket.Conn, reason string) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, reason)
+	if err := conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait)); err != nil {
+		return
+	}
+
+	timer := time.NewTimer(wsWriteWait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/middleware"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// newTestWebSocketServer serves WebSocketRoute behind the request ID,
+// logging and tenant middleware, as main does.
+func newTestWebSocketServer(t *testing.T) (*httptest.Server, *WebSocketHandler, *MockSubscriptionService) {
+	t.Helper()
+	mockSvc := &MockSubscriptionService{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ws := NewWebSocketHandler(mockSvc, log)
+
+	router := mux.NewRouter()
+	router.Use(
+		middleware.RequestIDMiddleware(),
+		middleware.LoggingMiddleware(log),
+		middleware.TenantMiddleware(middleware.TenantSource{}),
+	)
+	ws.RegisterRoutes(router)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, ws, mockSvc
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, tenant string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	header := http.Header{}
+	if tenant != "" {
+		header.Set(middleware.DefaultTenantHeader, tenant)
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+WebSocketRoute, header)
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) model.SubscriptionEvent {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	var event model.SubscriptionEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	return event
+}
+
+func TestWebSocket_PushesFilteredEvents(t *testing.T) {
+	srv, _, mockSvc := newTestWebSocketServer(t)
+	changes := make(chan model.SubscriptionEvent)
+	mockSvc.On("SubscribeToChanges", mock.Anything).Return((<-chan model.SubscriptionEvent)(changes), nil)
+
+	conn, _, err := dialWebSocket(t, srv, uuid.NewString())
+	require.NoError(t, err)
+
+	alice, bob := uuid.New(), uuid.New()
+	first := model.SubscriptionEvent{Event: model.EventCreated, ID: uuid.New(), UserID: &bob}
+	changes <- first
+	assert.Equal(t, first, readEvent(t, conn), "every user's events before a filter")
+
+	require.NoError(t, conn.WriteJSON(wsFilter{UserID: &alice}))
+	// Messages are handled in order, so the answer to this one shows the
+	// filter is in place.
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not a filter")))
+	var answer model.ErrorResponse
+	require.NoError(t, conn.ReadJSON(&answer))
+	assert.Equal(t, invalidFilterMessage, answer.Error)
+
+	changes <- model.SubscriptionEvent{Event: model.EventDeleted, ID: uuid.New(), UserID: &bob}
+	changes <- model.SubscriptionEvent{Event: model.EventUpdated, ID: uuid.New()}
+	own := model.SubscriptionEvent{Event: model.EventUpdated, ID: uuid.New(), UserID: &alice}
+	changes <- own
+	assert.Equal(t, own, readEvent(t, conn))
+}
+
+func TestWebSocket_AnswersPings(t *testing.T) {
+	srv, _, mockSvc := newTestWebSocketServer(t)
+	mockSvc.On("SubscribeToChanges", mock.Anything).Return((<-chan model.SubscriptionEvent)(make(chan model.SubscriptionEvent)), nil)
+
+	conn, _, err := dialWebSocket(t, srv, uuid.NewString())
+	require.NoError(t, err)
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(data string) error {
+		pong <- data
+		return nil
+	})
+	require.NoError(t, conn.WriteControl(websocket.PingMessage, []byte("keepalive"), time.Now().Add(time.Second)))
+	go conn.ReadMessage()
+
+	select {
+	case data := <-pong:
+		assert.Equal(t, "keepalive", data)
+	case <-time.After(time.Second):
+		t.Fatal("no pong")
+	}
+}
+
+func TestWebSocket_ShutdownClosesConnections(t *testing.T) {
+	srv, ws, mockSvc := newTestWebSocketServer(t)
+	mockSvc.On("SubscribeToChanges", mock.Anything).Return((<-chan model.SubscriptionEvent)(make(chan model.SubscriptionEvent)), nil)
+
+	conn, _, err := dialWebSocket(t, srv, uuid.NewString())
+	require.NoError(t, err)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		shutdown <- ws.Shutdown(ctx)
+	}()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+	require.NoError(t, <-shutdown)
+
+	_, resp, err := dialWebSocket(t, srv, uuid.NewString())
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestWebSocket_RefusedBeforeUpgrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		tenant string
+		err    error
+		want   int
+	}{
+		{"no tenant", "", nil, http.StatusUnauthorized},
+		{"stream unavailable", uuid.NewString(), service.ErrChangesUnavailable, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, mockSvc := newTestWebSocketServer(t)
+			mockSvc.On("SubscribeToChanges", mock.Anything).Return((<-chan model.SubscriptionEvent)(nil), tt.err)
+
+			_, resp, err := dialWebSocket(t, srv, tt.tenant)
+
+			require.ErrorIs(t, err, websocket.ErrBadHandshake)
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestWebSocket_NotAHandshake(t *testing.T) {
+	srv, _, mockSvc := newTestWebSocketServer(t)
+	mockSvc.On("SubscribeToChanges", mock.Anything).Return((<-chan model.SubscriptionEvent)(make(chan model.SubscriptionEvent)), nil)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+WebSocketRoute, nil)
+	require.NoError(t, err)
+	req.Header.Set(middleware.DefaultTenantHeader, uuid.NewString())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+}
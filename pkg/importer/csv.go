@@ -0,0 +1,155 @@
+// Package importer turns uploaded files into subscription requests.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/service"
+)
+
+// Header is the column order every CSV import must start with.
+var Header = []string{"service_name", "price", "user_id", "start_date", "end_date"}
+
+// ErrMalformedCSV is returned when the file as a whole cannot be imported:
+// the header is wrong or the CSV syntax is broken.
+var ErrMalformedCSV = errors.New("malformed CSV")
+
+// ImportError reports a rejected row. Row counts lines as a spreadsheet
+// does, so the header is row 1 and the first subscription row 2.
+type ImportError struct {
+	Row   int    `json:"row" example:"7"`
+	Error string `json:"error" example:"start_date: invalid date \"soon\", use RFC3339, YYYY-MM-DD or MM-YYYY"`
+}
+
+// Row is a parsed CSV line together with its row number.
+type Row struct {
+	Row     int
+	Request service.CreateSubscriptionRequest
+}
+
+// ParseCSV reads subscriptions in the Header format. A row that cannot be
+// parsed is reported in the ImportError slice and does not stop the rest.
+func ParseCSV(r io.Reader) ([]service.CreateSubscriptionRequest, []ImportError, error) {
+	rows, rowErrs, err := ParseCSVRows(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reqs := make([]service.CreateSubscriptionRequest, len(rows))
+	for i, row := range rows {
+		reqs[i] = row.Request
+	}
+	return reqs, rowErrs, nil
+}
+
+// ParseCSVRows is ParseCSV keeping the row number of every request, so
+// errors found later can still be attributed to their row.
+func ParseCSVRows(r io.Reader) ([]Row, []ImportError, error) {
+	cr := csv.NewReader(r)
+	// Column counts are checked per row so one short row is reported
+	// instead of failing the whole file.
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("%w: file is empty", ErrMalformedCSV)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrMalformedCSV, err)
+	}
+	if err := checkHeader(header); err != nil {
+		return nil, nil, err
+	}
+
+	var rows []Row
+	var rowErrs []ImportError
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Quoting errors leave the reader out of step with the rows,
+			// so nothing after them can be trusted.
+			return nil, nil, fmt.Errorf("%w: %w", ErrMalformedCSV, err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(Header) {
+			rowErrs = append(rowErrs, ImportError{Row: line, Error: fmt.Sprintf("expected %d columns, got %d", len(Header), len(record))})
+			continue
+		}
+
+		req, err := parseRecord(record)
+		if err != nil {
+			rowErrs = append(rowErrs, ImportError{Row: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, Row{Row: line, Request: req})
+	}
+
+	return rows, rowErrs, nil
+}
+
+func checkHeader(header []string) error {
+	if len(header) != len(Header) {
+		return fmt.Errorf("%w: header must be %s", ErrMalformedCSV, strings.Join(Header, ","))
+	}
+	for i, name := range Header {
+		if got := strings.TrimPrefix(strings.TrimSpace(header[i]), "\ufeff"); !strings.EqualFold(got, name) {
+			return fmt.Errorf("%w: header must be %s", ErrMalformedCSV, strings.Join(Header, ","))
+		}
+	}
+	return nil
+}
+
+func parseRecord(record []string) (service.CreateSubscriptionRequest, error) {
+	var req service.CreateSubscriptionRequest
+
+	req.ServiceName = strings.TrimSpace(record[0])
+	if req.ServiceName == "" {
+		return req, errors.New("service_name: must not be empty")
+	}
+
+	price, err := strconv.Atoi(strings.TrimSpace(record[1]))
+	if err != nil {
+		return req, fmt.Errorf("price: %q is not an integer", record[1])
+	}
+	req.Price = price
+
+	req.UserID, err = uuid.Parse(strings.TrimSpace(record[2]))
+	if err != nil {
+		return req, fmt.Errorf("user_id: %q is not a UUID", record[2])
+	}
+
+	req.StartDate, err = model.ParseDateField("start_date", strings.TrimSpace(record[3]))
+	if err != nil {
+		return req, err
+	}
+
+	if end := strings.TrimSpace(record[4]); end != "" {
+		t, err := model.ParseDateField("end_date", end)
+		if err != nil {
+			return req, err
+		}
+		req.EndDate = &t
+	}
+
+	return req, nil
+}
+
+// Result is the outcome of an import request.
+type Result struct {
+	Imported int           `json:"imported" example:"45"`
+	Failed   int           `json:"failed" example:"3"`
+	Errors   []ImportError `json:"errors"`
+}
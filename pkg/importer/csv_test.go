@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+func TestParseCSV_Valid(t *testing.T) {
+	input := "service_name,price,user_id,start_date,end_date\n" +
+		"Yandex Plus,599," + userID + ",07-2025,\n" +
+		"\"Netflix, Premium\",999," + userID + ",2025-01-15,2025-12-31T00:00:00Z\n"
+
+	reqs, rowErrs, err := ParseCSV(strings.NewReader(input))
+
+	require.NoError(t, err)
+	assert.Empty(t, rowErrs)
+	require.Len(t, reqs, 2)
+
+	assert.Equal(t, "Yandex Plus", reqs[0].ServiceName)
+	assert.Equal(t, 599, reqs[0].Price)
+	assert.Equal(t, uuid.MustParse(userID), reqs[0].UserID)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), reqs[0].StartDate)
+	assert.Nil(t, reqs[0].EndDate)
+
+	assert.Equal(t, "Netflix, Premium", reqs[1].ServiceName)
+	require.NotNil(t, reqs[1].EndDate)
+	assert.Equal(t, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), *reqs[1].EndDate)
+}
+
+func TestParseCSV_PartiallyInvalid(t *testing.T) {
+	input := "service_name,price,user_id,start_date,end_date\n" +
+		"Yandex Plus,599," + userID + ",07-2025,\n" +
+		"Netflix,cheap," + userID + ",07-2025,\n" +
+		"Spotify,299,nobody,07-2025,\n" +
+		"Kinopoisk,399," + userID + ",soon,\n" +
+		",100," + userID + ",07-2025,\n" +
+		"Okko,499\n" +
+		"Ivi,399," + userID + ",2025-03-01,2025-04-01\n"
+
+	rows, rowErrs, err := ParseCSVRows(strings.NewReader(input))
+
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 2, rows[0].Row)
+	assert.Equal(t, 8, rows[1].Row)
+
+	assert.Equal(t, []ImportError{
+		{Row: 3, Error: `price: "cheap" is not an integer`},
+		{Row: 4, Error: `user_id: "nobody" is not a UUID`},
+		{Row: 5, Error: `start_date: invalid date "soon", use RFC3339, YYYY-MM-DD or MM-YYYY`},
+		{Row: 6, Error: "service_name: must not be empty"},
+		{Row: 7, Error: "expected 5 columns, got 2"},
+	}, rowErrs)
+}
+
+func TestParseCSV_Malformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"wrong header", "name,cost,user,start,end\nNetflix,999," + userID + ",07-2025,\n"},
+		{"missing column", "service_name,price,user_id,start_date\n"},
+		{"broken quoting", "service_name,price,user_id,start_date,end_date\n\"Netflix,999," + userID + ",07-2025,\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, rowErrs, err := ParseCSV(strings.NewReader(tt.input))
+
+			assert.ErrorIs(t, err, ErrMalformedCSV)
+			assert.Nil(t, reqs)
+			assert.Nil(t, rowErrs)
+		})
+	}
+}
+
+func TestParseCSV_HeaderWithBOM(t *testing.T) {
+	input := "\ufeffService_Name,Price,User_ID,Start_Date,End_Date\r\nNetflix,999," + userID + ",07-2025,\r\n"
+
+	reqs, rowErrs, err := ParseCSV(strings.NewReader(input))
+
+	require.NoError(t, err)
+	assert.Empty(t, rowErrs)
+	assert.Len(t, reqs, 1)
+}
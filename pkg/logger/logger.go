@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+)
+
+// LevelHandler filters records by a level that can be changed while the
+// service is running, e.g. to enable debug output without a restart.
+type LevelHandler struct {
+	level *atomic.Value
+	next  slog.Handler
+}
+
+// NewLevelHandler wraps next with a dynamic minimum level. next should be
+// created with this handler as its HandlerOptions.Level (see New).
+func NewLevelHandler(level slog.Level, next slog.Handler) *LevelHandler {
+	v := &atomic.Value{}
+	v.Store(level)
+	return &LevelHandler{level: v, next: next}
+}
+
+// New builds a LevelHandler around a handler produced by newHandler, sharing
+// the dynamic level with it so both filter consistently.
+func New(level slog.Level, newHandler func(opts *slog.HandlerOptions) slog.Handler) *LevelHandler {
+	h := NewLevelHandler(level, nil)
+	h.next = newHandler(&slog.HandlerOptions{Level: h})
+	return h
+}
+
+// Level implements slog.Leveler.
+func (h *LevelHandler) Level() slog.Level {
+	return h.level.Load().(slog.Level)
+}
+
+// SetLevel changes the minimum level for this handler and every handler
+// derived from it via WithAttrs/WithGroup.
+func (h *LevelHandler) SetLevel(level slog.Level) {
+	h.level.Store(level)
+}
+
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.Level()
+}
+
+func (h *LevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LevelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	return &LevelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+// ParseLevel maps the config values debug, info, warn and error to slog levels.
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", level)
+}
+
+// WithContext returns a copy of ctx carrying log, for FromContext.
+func WithContext(ctx context.Context, log *slog.Logger) context.Context {
+	return ctxkey.With(ctx, ctxkey.KeyLogger, log)
+}
+
+// FromContext returns the logger stored by WithContext, which for a request
+// is the one LoggingMiddleware binds its request ID to, or slog.Default
+// when there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if log, ok := ctxkey.Get[*slog.Logger](ctx, ctxkey.KeyLogger); ok && log != nil {
+		return log
+	}
+	return slog.Default()
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"WARN":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	for in, want := range tests {
+		got, err := ParseLevel(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestLevelHandler_SetLevelAtRuntime(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(slog.LevelInfo, func(opts *slog.HandlerOptions) slog.Handler {
+		return slog.NewTextHandler(&buf, opts)
+	})
+	log := slog.New(h).With(slog.String("component", "test"))
+
+	log.Debug("hidden")
+	assert.NotContains(t, buf.String(), "hidden")
+
+	h.SetLevel(slog.LevelDebug)
+	log.Debug("visible")
+	assert.Contains(t, buf.String(), "visible")
+	assert.Contains(t, buf.String(), "component=test")
+
+	h.SetLevel(slog.LevelError)
+	log.Warn("suppressed")
+	assert.NotContains(t, buf.String(), "suppressed")
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil)).With(slog.String("request_id", "req-7"))
+	ctx := WithContext(context.Background(), log)
+
+	FromContext(ctx).Info("handled")
+	assert.Contains(t, buf.String(), "request_id=req-7")
+
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+}
@@ -0,0 +1,22 @@
+// Package metrics counts what the service layer does, for dashboards and
+// alerts rather than for the access log.
+package metrics
+
+import "time"
+
+// Metrics records subscription operations once they have succeeded.
+type Metrics interface {
+	IncrSubscriptionsCreated()
+	IncrSubscriptionsDeleted()
+	IncrSubscriptionsUpdated()
+	ObserveListDuration(d time.Duration)
+}
+
+// Noop discards everything; it is what a service records to when it is
+// given no Metrics.
+type Noop struct{}
+
+func (Noop) IncrSubscriptionsCreated()         {}
+func (Noop) IncrSubscriptionsDeleted()         {}
+func (Noop) IncrSubscriptionsUpdated()         {}
+func (Noop) ObserveListDuration(time.Duration) {}
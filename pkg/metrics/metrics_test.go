@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoop(t *testing.T) {
+	var m Metrics = Noop{}
+
+	assert.NotPanics(t, func() {
+		m.IncrSubscriptionsCreated()
+		m.IncrSubscriptionsDeleted()
+		m.IncrSubscriptionsUpdated()
+		m.ObserveListDuration(time.Second)
+	})
+}
+
+func TestPrometheus_Counts(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewPrometheus(reg)
+	require.NoError(t, err)
+
+	m.IncrSubscriptionsCreated()
+	m.IncrSubscriptionsCreated()
+	m.IncrSubscriptionsDeleted()
+	m.ObserveListDuration(20 * time.Millisecond)
+	m.ObserveListDuration(3 * time.Second)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.created))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.deleted))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.updated))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var histogram *float64
+	for _, f := range families {
+		if f.GetName() == "subscription_aggregator_subscriptions_list_duration_seconds" {
+			h := f.GetMetric()[0].GetHistogram()
+			assert.Equal(t, uint64(2), h.GetSampleCount())
+			sum := h.GetSampleSum()
+			histogram = &sum
+		}
+	}
+	require.NotNil(t, histogram, "list duration histogram not gathered")
+	assert.InDelta(t, 3.02, *histogram, 1e-9)
+}
+
+func TestNewPrometheus_AlreadyRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	_, err := NewPrometheus(reg)
+	require.NoError(t, err)
+
+	_, err = NewPrometheus(reg)
+
+	var already prometheus.AlreadyRegisteredError
+	assert.ErrorAs(t, err, &already)
+}
+
+func TestHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewPrometheus(reg)
+	require.NoError(t, err)
+	m.IncrSubscriptionsUpdated()
+
+	w := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "subscription_aggregator_subscriptions_updated_total 1")
+}
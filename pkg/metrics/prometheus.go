@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name.
+const namespace = "subscription_aggregator"
+
+// Prometheus keeps the counters and the list latency histogram in a
+// Prometheus registry.
+type Prometheus struct {
+	created      prometheus.Counter
+	deleted      prometheus.Counter
+	updated      prometheus.Counter
+	listDuration prometheus.Histogram
+}
+
+// NewPrometheus registers the metrics with reg. It fails if reg already
+// has metrics of the same names.
+func NewPrometheus(reg prometheus.Registerer) (*Prometheus, error) {
+	m := &Prometheus{
+		created: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "subscriptions_created_total",
+			Help:      "Subscriptions created.",
+		}),
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "subscriptions_deleted_total",
+			Help:      "Subscriptions deleted, expired ones cleaned up included.",
+		}),
+		updated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "subscriptions_updated_total",
+			Help:      "Subscriptions updated, one per subscription a bulk price change touched.",
+		}),
+		listDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "subscriptions_list_duration_seconds",
+			Help:      "How long listing subscriptions took.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.created, m.deleted, m.updated, m.listDuration} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Prometheus) IncrSubscriptionsCreated() { m.created.Inc() }
+
+func (m *Prometheus) IncrSubscriptionsDeleted() { m.deleted.Inc() }
+
+func (m *Prometheus) IncrSubscriptionsUpdated() { m.updated.Inc() }
+
+func (m *Prometheus) ObserveListDuration(d time.Duration) {
+	m.listDuration.Observe(d.Seconds())
+}
+
+// Handler serves what g gathers in the Prometheus text format, for
+// GET /metrics.
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const bodyTooLargeBody = `{"error":"request body too large"}` + "\n"
+
+// BodyLimitMiddleware caps request bodies at limit bytes. overrides maps a
+// route path template, e.g. "/subscriptions/import", to its own limit so
+// upload endpoints can accept more than regular JSON requests. Requests that
+// declare a larger Content-Length are refused with 413 up front; otherwise
+// reading past the limit fails with *http.MaxBytesError, which handlers
+// report as 413 too.
+func BodyLimitMiddleware(limit int64, overrides map[string]int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			max := limit
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					if override, ok := overrides[tpl]; ok {
+						max = override
+					}
+				}
+			}
+
+			if r.ContentLength > max {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Connection", "close")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				w.Write([]byte(bodyTooLargeBody))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// readAll reports whether the handler could read the whole body.
+func readAll(w http.ResponseWriter, r *http.Request) {
+	if _, err := io.ReadAll(r.Body); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func newBodyLimitRouter(limit int64, overrides map[string]int64) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(BodyLimitMiddleware(limit, overrides))
+	router.HandleFunc("/subscriptions", readAll).Methods(http.MethodPost)
+	router.HandleFunc("/subscriptions/import", readAll).Methods(http.MethodPost)
+	return router
+}
+
+func TestBodyLimitMiddleware_DeclaredLengthTooLarge(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(strings.Repeat("a", 11)))
+
+	newBodyLimitRouter(10, nil).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"request body too large"}`, w.Body.String())
+}
+
+func TestBodyLimitMiddleware_UndeclaredLengthStopsReading(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", io.NopCloser(strings.NewReader(strings.Repeat("a", 11))))
+	r.ContentLength = -1
+
+	newBodyLimitRouter(10, nil).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestBodyLimitMiddleware_WithinLimit(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(strings.Repeat("a", 10)))
+
+	newBodyLimitRouter(10, nil).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBodyLimitMiddleware_RouteOverride(t *testing.T) {
+	body := strings.Repeat("a", 50)
+	router := newBodyLimitRouter(10, map[string]int64{"/subscriptions/import": 100})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions/import", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
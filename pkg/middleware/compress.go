@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// CompressionMiddleware gzips responses for clients that send
+// Accept-Encoding: gzip. Responses without a body, such as 204 and 304,
+// and HEAD requests are left as they are. Server-Sent Events requests and
+// the routes whose path template is listed in exempt pass through
+// untouched, since a client reads them as they are written.
+func CompressionMiddleware(exempt ...string) mux.MiddlewareFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, tpl := range exempt {
+		skip[tpl] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && skip[tpl] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Values("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding headers list gzip, or
+// the * wildcard, without q=0.
+func acceptsGzip(values []string) bool {
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || params["q"] == "0" {
+				continue
+			}
+			if coding == "gzip" || coding == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter compresses the body once the status shows there is
+// one to compress.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.decided && code >= http.StatusOK {
+		w.decided = true
+		h := w.Header()
+		if code != http.StatusNoContent && code != http.StatusNotModified && h.Get("Content-Encoding") == "" {
+			h.Set("Content-Encoding", "gzip")
+			h.Del("Content-Length")
+			w.gz = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		// net/http would sniff the type from the compressed bytes.
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.gz.Write(b)
+}
+
+// Flush sends what has been compressed so far. It is the writer's own
+// rather than reached through Unwrap, which would skip the gzip buffer.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const compressedJSON = `{"service_name":"netflix","price":999}`
+
+func newCompressionRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(CompressionMiddleware("/subscriptions/stream"))
+	writeJSON := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(compressedJSON))
+	}
+	router.HandleFunc("/subscriptions", writeJSON)
+	router.HandleFunc("/subscriptions/stream", writeJSON)
+	router.HandleFunc("/subscriptions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return router
+}
+
+func TestCompressionMiddleware_GzipsWhenAccepted(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	r.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+
+	newCompressionRouter().ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, compressedJSON, string(body))
+}
+
+func TestCompressionMiddleware_PassesThrough(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		acceptEncoding string
+	}{
+		{"not accepted", http.MethodGet, "/subscriptions", ""},
+		{"refused with q=0", http.MethodGet, "/subscriptions", "gzip;q=0"},
+		{"exempt route", http.MethodGet, "/subscriptions/stream", "gzip"},
+		{"no body", http.MethodDelete, "/subscriptions/1", "gzip"},
+		{"head", http.MethodHead, "/subscriptions", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			newCompressionRouter().ServeHTTP(w, r)
+
+			assert.Empty(t, w.Header().Get("Content-Encoding"))
+			if w.Code == http.StatusOK && tt.method != http.MethodHead {
+				assert.Equal(t, compressedJSON, w.Body.String())
+			}
+		})
+	}
+}
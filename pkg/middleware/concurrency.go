@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultConcurrencyWait is how long a request waits for a free slot when
+// http_server.concurrency_wait is not set.
+const DefaultConcurrencyWait = 100 * time.Millisecond
+
+const busyBody = `{"error":"server is busy, retry later"}`
+
+// ConcurrencyMiddleware lets at most maxConcurrent requests run at once, so
+// that a traffic spike queues up in front of the handlers instead of behind
+// the database pool. A request that finds no free slot within wait is
+// answered 503 with Retry-After. Server-Sent Events requests, which hold
+// their connection open by design, and routes whose path template starts
+// with one of exempt, such as the health probes, take no slot. A
+// maxConcurrent below 1 disables the limit.
+func ConcurrencyMiddleware(maxConcurrent int, wait time.Duration, exempt ...string) mux.MiddlewareFunc {
+	if maxConcurrent < 1 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	// mux applies middleware on every request, so the slots are shared
+	// here rather than per wrapped handler.
+	slots := make(chan struct{}, maxConcurrent)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && hasAnyPrefix(tpl, exempt) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if !acquire(r, slots, wait) {
+				if r.Context().Err() != nil {
+					return
+				}
+				w.Header().Set("Retry-After", "1")
+				writeJSONError(w, http.StatusServiceUnavailable, busyBody)
+				return
+			}
+			defer func() { <-slots }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire takes a slot, waiting up to wait for one to free up. It gives up
+// early if the client goes away.
+func acquire(r *http.Request, slots chan struct{}, wait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBlockingRouter serves /subscriptions with a handler that signals
+// entered and then holds its slot until release is closed; /live answers
+// at once.
+func newBlockingRouter(maxConcurrent int, wait time.Duration) (router *mux.Router, entered chan struct{}, release chan struct{}) {
+	entered, release = make(chan struct{}), make(chan struct{})
+
+	router = mux.NewRouter()
+	router.Use(ConcurrencyMiddleware(maxConcurrent, wait, "/live"))
+	router.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	router.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return router, entered, release
+}
+
+func TestConcurrencyMiddleware_RejectsOverLimit(t *testing.T) {
+	const n = 3
+	router, entered, release := newBlockingRouter(n, 10*time.Millisecond)
+
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+			codes[i] = w.Code
+		}(i)
+	}
+	for i := 0; i < n; i++ {
+		<-entered
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, busyBody, w.Body.String())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK}, codes)
+}
+
+func TestConcurrencyMiddleware_WaitsForFreedSlot(t *testing.T) {
+	router, entered, release := newBlockingRouter(1, time.Second)
+
+	first := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+		first <- w.Code
+	}()
+	<-entered
+
+	second := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+		second <- w.Code
+	}()
+
+	release <- struct{}{}
+	require.Equal(t, http.StatusOK, <-first)
+	<-entered
+	close(release)
+
+	assert.Equal(t, http.StatusOK, <-second, "the waiting request got the freed slot")
+}
+
+func TestConcurrencyMiddleware_ExemptAndStreamsTakeNoSlot(t *testing.T) {
+	router, entered, release := newBlockingRouter(1, 0)
+	defer close(release)
+	go router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+	<-entered
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "probes are exempt")
+
+	// With no wait, a request that needed a slot would be rejected before
+	// reaching the handler.
+	stream := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	stream.Header.Set("Accept", "text/event-stream")
+	go router.ServeHTTP(httptest.NewRecorder(), stream)
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("the event stream was not let through")
+	}
+}
+
+func TestConcurrencyMiddleware_DisabledBelowOne(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	ConcurrencyMiddleware(0, 0)(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
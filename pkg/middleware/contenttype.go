@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const unsupportedMediaTypeBody = `{"error":"unsupported media type, use application/json"}` + "\n"
+
+// ContentTypeMiddleware requires JSON request bodies: requests to POST, PUT
+// and PATCH that carry a body with another Content-Type get 415. Routes
+// whose path template is listed in exempt, such as multipart uploads,
+// streams or the Swagger UI, are not checked. The Accept header is
+// NegotiateMiddleware's.
+func ContentTypeMiddleware(exempt ...string) mux.MiddlewareFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, tpl := range exempt {
+		skip[tpl] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && skip[tpl] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if hasBody(r) && !isJSON(r.Header.Get("Content-Type")) {
+				writeJSONError(w, http.StatusUnsupportedMediaType, unsupportedMediaTypeBody)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasBody(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+	}
+	return false
+}
+
+// isJSON accepts application/json with any parameters, e.g. a charset.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+func writeJSONError(w http.ResponseWriter, code int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write([]byte(body))
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContentTypeRouter() *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	router := mux.NewRouter()
+	router.Use(ContentTypeMiddleware("/subscriptions/import"))
+	router.HandleFunc("/subscriptions", ok).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc("/subscriptions/import", ok).Methods(http.MethodPost)
+	router.HandleFunc("/subscriptions/expired/cleanup", ok).Methods(http.MethodPost)
+	return router
+}
+
+func TestContentTypeMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		contentType string
+		want        int
+	}{
+		{"json body", http.MethodPost, "/subscriptions", "{}", "application/json", http.StatusOK},
+		{"json with charset", http.MethodPost, "/subscriptions", "{}", "application/json; charset=utf-8", http.StatusOK},
+		{"form body", http.MethodPost, "/subscriptions", "a=b", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"missing content type", http.MethodPost, "/subscriptions", "{}", "", http.StatusUnsupportedMediaType},
+		{"post without body", http.MethodPost, "/subscriptions/expired/cleanup", "", "", http.StatusOK},
+		{"multipart on exempt route", http.MethodPost, "/subscriptions/import", "--x--", "multipart/form-data; boundary=x", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			newContentTypeRouter().ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want != http.StatusOK {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Body.String(), `"error"`)
+			}
+		})
+	}
+}
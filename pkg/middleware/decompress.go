@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	invalidGzipBody         = `{"error":"request body is not valid gzip"}` + "\n"
+	unsupportedEncodingBody = `{"error":"unsupported content encoding, use gzip"}` + "\n"
+)
+
+// DecompressionMiddleware inflates request bodies sent with
+// Content-Encoding: gzip, so handlers read plain JSON either way. A body
+// that does not start with a gzip header is refused with 400 before the
+// handler runs, any other encoding with 415. Put it before
+// BodyLimitMiddleware, so the limit applies to the inflated body rather
+// than the compressed one.
+func DecompressionMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			switch encoding {
+			case "", "identity":
+				next.ServeHTTP(w, r)
+				return
+			case "gzip", "x-gzip":
+			default:
+				writeJSONError(w, http.StatusUnsupportedMediaType, unsupportedEncodingBody)
+				return
+			}
+
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, invalidGzipBody)
+				return
+			}
+			defer zr.Close()
+
+			r.Body = gzipBody{Reader: zr, body: r.Body}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// gzipBody reads the inflated stream and closes the compressed one too.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
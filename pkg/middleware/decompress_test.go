@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// echoBody answers with the request body it read.
+func echoBody(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func newDecompressionRouter(handler http.HandlerFunc) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(DecompressionMiddleware())
+	router.HandleFunc("/subscriptions", handler).Methods(http.MethodPost)
+	return router
+}
+
+func TestDecompressionMiddleware_InflatesGzipBody(t *testing.T) {
+	body := `{"service_name":"netflix","price":999}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(gzipped(t, body)))
+	r.Header.Set("Content-Encoding", "gzip")
+
+	var encoding string
+	newDecompressionRouter(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		echoBody(w, r)
+	}).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.Empty(t, encoding, "handlers see a plain body")
+}
+
+func TestDecompressionMiddleware_PlainBodyUntouched(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{"price":999}`))
+
+	newDecompressionRouter(echoBody).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"price":999}`, w.Body.String())
+}
+
+func TestDecompressionMiddleware_Refuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		want     int
+	}{
+		{"not gzip", "gzip", http.StatusBadRequest},
+		{"unknown encoding", "br", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{"price":999}`))
+			r.Header.Set("Content-Encoding", tt.encoding)
+
+			newDecompressionRouter(func(w http.ResponseWriter, r *http.Request) { called = true }).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.False(t, called)
+		})
+	}
+}
+
+func TestDecompressionMiddleware_BodyLimitCountsInflatedBytes(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(DecompressionMiddleware(), BodyLimitMiddleware(100, nil))
+	router.HandleFunc("/subscriptions", readAll).Methods(http.MethodPost)
+
+	w := httptest.NewRecorder()
+	compressed := gzipped(t, strings.Repeat("a", 1000))
+	require.Less(t, len(compressed), 100)
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(compressed))
+	r.Header.Set("Content-Encoding", "gzip")
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
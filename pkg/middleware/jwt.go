@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// verifyHS256 checks a compact JWT signed with HMAC-SHA256 and returns its
+// claims. The exp and nbf claims are honoured when present; no other
+// algorithm is accepted, so a token cannot downgrade itself to "none".
+func verifyHS256(token string, secret []byte, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: want 3 parts, got %d", errInvalidToken, len(parts))
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", errInvalidToken, err)
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %q", errInvalidToken, header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %w", errInvalidToken, err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: bad signature", errInvalidToken)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %w", errInvalidToken, err)
+	}
+	if exp, ok := claims["exp"].(float64); ok && !now.Before(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("%w: expired", errInvalidToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("%w: not valid yet", errInvalidToken)
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/logger"
+)
+
+// quietPaths are polled by infrastructure and would drown the access log.
+var quietPaths = map[string]struct{}{
+	"/health":  {},
+	"/live":    {},
+	"/ready":   {},
+	"/metrics": {},
+}
+
+// responseWriterWrapper records the status code written downstream.
+type responseWriterWrapper struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *responseWriterWrapper) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriterWrapper) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach Flush and deadlines on the
+// underlying writer, which the SSE stream relies on.
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack hands the connection to a WebSocket upgrader, which asserts
+// http.Hijacker rather than using http.ResponseController. The access log
+// then records 101 Switching Protocols.
+func (w *responseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// LoggingMiddleware writes one access log record per request: Info for
+// 2xx/3xx, Warn for 4xx and Error for 5xx. It also stores a logger bound to
+// the request ID, and to the user_id query parameter when that is a UUID,
+// in the request context for logger.FromContext, so every line logged for
+// the request can be told apart from the others.
+func LoggingMiddleware(log *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(logger.WithContext(r.Context(), requestLogger(log, r)))
+			if _, ok := quietPaths[r.URL.Path]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := &responseWriterWrapper{ResponseWriter: w}
+			next.ServeHTTP(ww, r)
+
+			status := ww.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+
+			log.LogAttrs(r.Context(), level, "request completed",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+			)
+		})
+	}
+}
+
+// requestLogger is log with the attributes that identify r.
+func requestLogger(log *slog.Logger, r *http.Request) *slog.Logger {
+	attrs := []any{slog.String("request_id", RequestIDFromContext(r.Context()))}
+	if uid, err := uuid.Parse(r.URL.Query().Get("user_id")); err == nil {
+		attrs = append(attrs, slog.String("user_id", uid.String()))
+	}
+	return log.With(attrs...)
+}
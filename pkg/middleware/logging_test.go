@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/logger"
+)
+
+// captureHandler keeps every record so tests can inspect typed attributes.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func serveLogged(t *testing.T, path string, status int) *captureHandler {
+	t.Helper()
+	capture := &captureHandler{}
+
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware(), LoggingMiddleware(slog.New(capture)))
+	router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.Header.Set(RequestIDHeader, "req-7")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	return capture
+}
+
+func TestLoggingMiddleware_RecordsAttributes(t *testing.T) {
+	capture := serveLogged(t, "/subscriptions", http.StatusCreated)
+
+	require.Len(t, capture.records, 1)
+	rec := capture.records[0]
+	attrs := recordAttrs(rec)
+
+	assert.Equal(t, slog.LevelInfo, rec.Level)
+	assert.Equal(t, "GET", attrs["method"].String())
+	assert.Equal(t, "/subscriptions", attrs["path"].String())
+	assert.EqualValues(t, http.StatusCreated, attrs["status"].Int64())
+	assert.Equal(t, "req-7", attrs["request_id"].String())
+	assert.Equal(t, slog.KindInt64, attrs["duration_ms"].Kind())
+}
+
+func TestLoggingMiddleware_LevelByStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		level  slog.Level
+	}{
+		{http.StatusOK, slog.LevelInfo},
+		{http.StatusPermanentRedirect, slog.LevelInfo},
+		{http.StatusNotFound, slog.LevelWarn},
+		{http.StatusInternalServerError, slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			capture := serveLogged(t, "/subscriptions", tt.status)
+
+			require.Len(t, capture.records, 1)
+			assert.Equal(t, tt.level, capture.records[0].Level)
+		})
+	}
+}
+
+func TestLoggingMiddleware_SkipsQuietPaths(t *testing.T) {
+	for _, path := range []string{"/health", "/metrics"} {
+		capture := serveLogged(t, path, http.StatusOK)
+
+		assert.Empty(t, capture.records, path)
+	}
+}
+
+func TestLoggingMiddleware_KeepsFlusher(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(LoggingMiddleware(slog.New(&captureHandler{})))
+	router.HandleFunc("/subscriptions/stream", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/stream", nil))
+
+	assert.True(t, w.Flushed)
+}
+
+func TestLoggingMiddleware_StoresRequestLogger(t *testing.T) {
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	tests := []struct {
+		name     string
+		target   string
+		wantUser string
+	}{
+		{"with user", "/subscriptions?user_id=" + userID, userID},
+		{"without user", "/subscriptions", ""},
+		{"invalid user", "/subscriptions?user_id=abc", ""},
+		{"quiet path", "/health", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var line map[string]any
+
+			router := mux.NewRouter()
+			router.Use(RequestIDMiddleware(), LoggingMiddleware(slog.New(slog.NewJSONHandler(&buf, nil))))
+			router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				logger.FromContext(r.Context()).Info("handled")
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+			})
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.Header.Set(RequestIDHeader, "req-7")
+			router.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, "handled", line["msg"])
+			assert.Equal(t, "req-7", line["request_id"])
+			if tt.wantUser == "" {
+				assert.NotContains(t, line, "user_id")
+			} else {
+				assert.Equal(t, tt.wantUser, line["user_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/render"
+)
+
+const notAcceptableBody = `{"error":"not acceptable, responses are application/json or text/csv"}` + "\n"
+
+// offers are the media types handlers can render, in order of preference
+// when a client accepts several equally.
+var offers = []struct {
+	mediaType string
+	renderer  render.Renderer
+}{
+	{"application/json", render.JSON{}},
+	{"text/csv", render.CSV{}},
+}
+
+// NegotiateMiddleware picks the media type of the response from the Accept
+// header and stores its renderer in the request context, where handlers
+// find it with render.FromContext. A missing header, */* or
+// application/json get JSON and text/csv gets CSV; when both are accepted
+// the higher q-value wins and JSON breaks ties. An Accept header that rules
+// out both gets 406. Errors are JSON whatever was negotiated. Routes whose
+// path template is listed in exempt, such as the iCalendar export, streams
+// or the Swagger UI, choose their own media type and are not checked.
+func NegotiateMiddleware(exempt ...string) mux.MiddlewareFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, tpl := range exempt {
+		skip[tpl] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && skip[tpl] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			renderer, ok := negotiate(r.Header.Values("Accept"))
+			if !ok {
+				writeJSONError(w, http.StatusNotAcceptable, notAcceptableBody)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctxkey.With(r.Context(), ctxkey.KeyRenderer, renderer)))
+		})
+	}
+}
+
+// negotiate returns the renderer of the offer with the highest q-value in
+// the Accept headers. Each offer takes the q-value of the most specific
+// range that covers it, so "*/*, text/csv;q=0" rules out CSV only.
+// Malformed ranges are ignored.
+func negotiate(values []string) (render.Renderer, bool) {
+	if len(values) == 0 {
+		return render.JSON{}, true
+	}
+
+	var (
+		best  render.Renderer
+		bestQ float64
+	)
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, value := range values {
+			for _, part := range strings.Split(value, ",") {
+				mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+				if err != nil {
+					continue
+				}
+				s := matches(mediaType, offer.mediaType)
+				if s <= specificity {
+					continue
+				}
+				specificity, q = s, 1
+				if v, ok := params["q"]; ok {
+					if q, err = strconv.ParseFloat(v, 64); err != nil {
+						q = 0
+					}
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer.renderer, q
+		}
+	}
+	return best, best != nil
+}
+
+// matches reports how specifically the media range covers mediaType: 2 for
+// the type itself, 1 for type/*, 0 for */* and -1 when it does not.
+func matches(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
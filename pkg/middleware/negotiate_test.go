@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/render"
+)
+
+func newNegotiateRouter() *mux.Router {
+	// Each route answers with the media type it was asked to render in.
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		render.FromContext(r.Context()).Render(w, http.StatusOK, []string{"ok"})
+	}
+
+	router := mux.NewRouter()
+	router.Use(NegotiateMiddleware("/subscriptions/export"))
+	router.HandleFunc("/subscriptions", ok).Methods(http.MethodGet)
+	router.HandleFunc("/subscriptions/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+	return router
+}
+
+func TestNegotiateMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept []string
+		want   int
+		ctype  string
+	}{
+		{"no accept header", nil, http.StatusOK, render.JSONContentType},
+		{"json", []string{"application/json"}, http.StatusOK, render.JSONContentType},
+		{"json with charset", []string{"application/json; charset=utf-8"}, http.StatusOK, render.JSONContentType},
+		{"wildcard", []string{"*/*"}, http.StatusOK, render.JSONContentType},
+		{"browser default", []string{"text/html, */*;q=0.8"}, http.StatusOK, render.JSONContentType},
+		{"application wildcard", []string{"application/*"}, http.StatusOK, render.JSONContentType},
+		{"csv", []string{"text/csv"}, http.StatusOK, render.CSVContentType},
+		{"text wildcard", []string{"text/*"}, http.StatusOK, render.CSVContentType},
+		{"csv preferred", []string{"application/json;q=0.5, text/csv"}, http.StatusOK, render.CSVContentType},
+		{"json preferred", []string{"text/csv;q=0.5, application/json"}, http.StatusOK, render.JSONContentType},
+		{"tie goes to json", []string{"text/csv, application/json"}, http.StatusOK, render.JSONContentType},
+		{"json refused", []string{"application/json;q=0, text/csv"}, http.StatusOK, render.CSVContentType},
+		{"specific range wins", []string{"*/*, application/json;q=0"}, http.StatusOK, render.CSVContentType},
+		{"several headers", []string{"application/xml", "text/csv"}, http.StatusOK, render.CSVContentType},
+		{"xml only", []string{"application/xml"}, http.StatusNotAcceptable, "application/json"},
+		{"html only", []string{"text/html"}, http.StatusNotAcceptable, "application/json"},
+		{"everything refused", []string{"*/*;q=0"}, http.StatusNotAcceptable, "application/json"},
+		{"both refused", []string{"application/json;q=0, text/csv;q=0"}, http.StatusNotAcceptable, "application/json"},
+		{"malformed", []string{"json"}, http.StatusNotAcceptable, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			for _, v := range tt.accept {
+				r.Header.Add("Accept", v)
+			}
+
+			newNegotiateRouter().ServeHTTP(w, r)
+
+			require.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.ctype, w.Header().Get("Content-Type"))
+			if tt.want == http.StatusNotAcceptable {
+				assert.JSONEq(t, notAcceptableBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiateMiddleware_ExemptRoute(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/export", nil)
+	r.Header.Set("Accept", "text/calendar")
+
+	newNegotiateRouter().ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar", w.Header().Get("Content-Type"))
+}
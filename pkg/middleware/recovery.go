@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gorilla/mux"
+)
+
+// RecoveryMiddleware turns a panicking handler into a 500 JSON response and
+// logs the stack trace instead of letting the connection drop silently.
+// http.ErrAbortHandler is re-panicked so net/http can abort the response
+// as intended.
+func RecoveryMiddleware(log *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				requestID := RequestIDFromContext(r.Context())
+				log.Error("panic recovered",
+					slog.Any("panic", rec),
+					slog.String("request_id", requestID),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(struct {
+					Error     string `json:"error"`
+					RequestID string `json:"request_id,omitempty"`
+				}{Error: "internal server error", RequestID: requestID})
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
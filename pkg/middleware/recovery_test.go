@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryMiddleware_Panic(t *testing.T) {
+	var logs bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&logs, nil))
+
+	router := mux.NewRouter()
+	router.Use(RecoveryMiddleware(log))
+	router.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		var sub *struct{ Price int }
+		_ = sub.Price
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+	assert.Contains(t, logs.String(), "panic recovered")
+	assert.Contains(t, logs.String(), "stack=")
+}
+
+func TestRecoveryMiddleware_ErrAbortHandler(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	h := RecoveryMiddleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecoveryMiddleware_IncludesRequestID(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware(), RecoveryMiddleware(log))
+	router.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"internal server error","request_id":"req-1"}`, w.Body.String())
+}
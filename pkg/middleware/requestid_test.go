@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveWithRequestID(r *http.Request) (*httptest.ResponseRecorder, string) {
+	var seen string
+
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware())
+	router.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w, seen
+}
+
+func TestRequestIDMiddleware_Generates(t *testing.T) {
+	w, seen := serveWithRequestID(httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+
+	_, err := uuid.Parse(seen)
+	assert.NoError(t, err)
+	assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+}
+
+func TestRequestIDMiddleware_ReusesIncoming(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	r.Header.Set(RequestIDHeader, "abc-123")
+
+	w, seen := serveWithRequestID(r)
+
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", w.Header().Get(RequestIDHeader))
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+)
+
+const missingTenantBody = `{"error":"missing or invalid tenant"}` + "\n"
+
+// DefaultTenantHeader carries the tenant ID when no JWT secret is set.
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// DefaultUserHeader carries the ID of the calling user when no JWT secret
+// is set.
+const DefaultUserHeader = "X-User-ID"
+
+// DefaultJWTUserClaim names the calling user in a JWT.
+const DefaultJWTUserClaim = "sub"
+
+// TenantSource says where TenantMiddleware finds the tenant ID.
+type TenantSource struct {
+	// Header is read when JWTSecret is empty. It must be set by a trusted
+	// gateway; the service cannot tell a forged value from a real one.
+	Header string
+	// UserHeader names the calling user the same way; it is optional.
+	UserHeader string
+	// JWTSecret, when set, makes the tenant come only from the JWTClaim
+	// claim of an HS256 token in "Authorization: Bearer", and the user from
+	// its JWTUserClaim claim; the headers are then ignored.
+	JWTSecret    []byte
+	JWTClaim     string
+	JWTUserClaim string
+}
+
+// TenantMiddleware stores the request's tenant ID in the context under
+// ctxkey.KeyTenantID and answers 401 when there is none or it is not a
+// UUID. The calling user's ID, when given as a UUID, is stored under
+// ctxkey.KeyUserID; requests without one are let through. Routes whose
+// path template starts with one of exempt, such as the health probes or
+// the Swagger UI, pass through without a tenant.
+func TenantMiddleware(src TenantSource, exempt ...string) mux.MiddlewareFunc {
+	if src.Header == "" {
+		src.Header = DefaultTenantHeader
+	}
+	if src.UserHeader == "" {
+		src.UserHeader = DefaultUserHeader
+	}
+	if src.JWTUserClaim == "" {
+		src.JWTUserClaim = DefaultJWTUserClaim
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && hasAnyPrefix(tpl, exempt) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			tenantRaw, userRaw, ok := src.identity(r)
+			tenantID, valid := parseID(tenantRaw)
+			if !ok || !valid {
+				if len(src.JWTSecret) > 0 {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="tenant"`)
+				}
+				writeJSONError(w, http.StatusUnauthorized, missingTenantBody)
+				return
+			}
+
+			ctx := ctxkey.With(r.Context(), ctxkey.KeyTenantID, tenantID)
+			if userID, ok := parseID(userRaw); ok {
+				ctx = ctxkey.With(ctx, ctxkey.KeyUserID, userID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// identity returns the raw tenant and user IDs of r; ok is false when the
+// bearer token is missing or invalid.
+func (src TenantSource) identity(r *http.Request) (tenant, user string, ok bool) {
+	if len(src.JWTSecret) == 0 {
+		return r.Header.Get(src.Header), r.Header.Get(src.UserHeader), true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", "", false
+	}
+	claims, err := verifyHS256(token, src.JWTSecret, time.Now())
+	if err != nil {
+		return "", "", false
+	}
+	tenant, _ = claims[src.JWTClaim].(string)
+	user, _ = claims[src.JWTUserClaim].(string)
+	return tenant, user, true
+}
+
+// parseID accepts a UUID other than uuid.Nil.
+func parseID(raw string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(raw)
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
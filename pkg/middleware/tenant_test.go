@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+)
+
+const testTenant = "0b7e1c52-8d4f-4a3e-9c61-2f5a7d8e9b10"
+
+// signHS256 builds a compact JWT for claims, signed with secret.
+func signHS256(t *testing.T, alg string, claims map[string]any, secret string) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newTenantRouter(src TenantSource) *mux.Router {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		tenantID, ok := ctxkey.Get[uuid.UUID](r.Context(), ctxkey.KeyTenantID)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(tenantID.String()))
+	}
+
+	router := mux.NewRouter()
+	router.Use(TenantMiddleware(src, "/live", "/swagger/"))
+	router.HandleFunc("/subscriptions", echo)
+	router.HandleFunc("/live", echo)
+	router.PathPrefix("/swagger/").HandlerFunc(echo)
+	return router
+}
+
+func TestTenantMiddleware_Header(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		tenant string
+		want   int
+	}{
+		{"valid tenant", "/subscriptions", testTenant, http.StatusOK},
+		{"missing tenant", "/subscriptions", "", http.StatusUnauthorized},
+		{"not a uuid", "/subscriptions", "acme", http.StatusUnauthorized},
+		{"nil tenant", "/subscriptions", uuid.Nil.String(), http.StatusUnauthorized},
+		{"exempt route", "/live", "", http.StatusNoContent},
+		{"exempt prefix", "/swagger/index.html", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.tenant != "" {
+				r.Header.Set(DefaultTenantHeader, tt.tenant)
+			}
+
+			newTenantRouter(TenantSource{}).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			switch tt.want {
+			case http.StatusOK:
+				assert.Equal(t, testTenant, w.Body.String())
+			case http.StatusUnauthorized:
+				assert.JSONEq(t, `{"error":"missing or invalid tenant"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTenantMiddleware_JWT(t *testing.T) {
+	const secret = "s3cret"
+	src := TenantSource{JWTSecret: []byte(secret), JWTClaim: "tenant_id"}
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		auth   string
+		header string
+		want   int
+	}{
+		{"valid token", "Bearer " + signHS256(t, "HS256", map[string]any{"tenant_id": testTenant, "exp": future}, secret), "", http.StatusOK},
+		{"wrong secret", "Bearer " + signHS256(t, "HS256", map[string]any{"tenant_id": testTenant}, "other"), "", http.StatusUnauthorized},
+		{"expired", "Bearer " + signHS256(t, "HS256", map[string]any{"tenant_id": testTenant, "exp": past}, secret), "", http.StatusUnauthorized},
+		{"not yet valid", "Bearer " + signHS256(t, "HS256", map[string]any{"tenant_id": testTenant, "nbf": future}, secret), "", http.StatusUnauthorized},
+		{"other algorithm", "Bearer " + signHS256(t, "none", map[string]any{"tenant_id": testTenant}, secret), "", http.StatusUnauthorized},
+		{"claim missing", "Bearer " + signHS256(t, "HS256", map[string]any{"sub": testTenant}, secret), "", http.StatusUnauthorized},
+		{"malformed", "Bearer abc.def", "", http.StatusUnauthorized},
+		{"header ignored", "", testTenant, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			if tt.header != "" {
+				r.Header.Set(DefaultTenantHeader, tt.header)
+			}
+
+			newTenantRouter(src).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="tenant"`, w.Header().Get("WWW-Authenticate"))
+			} else {
+				assert.Equal(t, testTenant, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTenantMiddleware_User(t *testing.T) {
+	const secret = "s3cret"
+	const testUser = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	tests := []struct {
+		name   string
+		src    TenantSource
+		header string
+		auth   string
+		want   string
+	}{
+		{"header", TenantSource{}, testUser, "", testUser},
+		{"no user", TenantSource{}, "", "", ""},
+		{"not a uuid", TenantSource{}, "alice", "", ""},
+		{"jwt sub", TenantSource{JWTSecret: []byte(secret), JWTClaim: "tenant_id"}, "",
+			signHS256(t, "HS256", map[string]any{"tenant_id": testTenant, "sub": testUser}, secret), testUser},
+		{"jwt ignores header", TenantSource{JWTSecret: []byte(secret), JWTClaim: "tenant_id"}, testUser,
+			signHS256(t, "HS256", map[string]any{"tenant_id": testTenant}, secret), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(TenantMiddleware(tt.src))
+			router.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+				if userID, ok := ctxkey.Get[uuid.UUID](r.Context(), ctxkey.KeyUserID); ok {
+					w.Write([]byte(userID.String()))
+				}
+			})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			r.Header.Set(DefaultTenantHeader, testTenant)
+			if tt.header != "" {
+				r.Header.Set(DefaultUserHeader, tt.header)
+			}
+			if tt.auth != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.auth)
+			}
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const timeoutBody = `{"error":"request timed out"}`
+
+// TimeoutMiddleware aborts handlers running longer than timeout with a 503,
+// so a stuck handler cannot silently hold the connection until the server's
+// WriteTimeout drops it. http.TimeoutHandler buffers the whole response, so
+// Server-Sent Events requests, long-lived by design, and the routes whose
+// path template is listed in streamed pass through untouched; the latter
+// still have the repository's query timeout and the server's WriteTimeout.
+func TimeoutMiddleware(timeout time.Duration, streamed ...string) mux.MiddlewareFunc {
+	skip := make(map[string]bool, len(streamed))
+	for _, tpl := range streamed {
+		skip[tpl] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		th := http.TimeoutHandler(next, timeout, timeoutBody)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && skip[tpl] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			// http.TimeoutHandler keeps headers set on the outer writer, so the
+			// timeout body is served as JSON while handlers can still override it.
+			w.Header().Set("Content-Type", "application/json")
+			th.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutMiddleware_SlowHandler(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	TimeoutMiddleware(10*time.Millisecond)(slow).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"request timed out"}`, w.Body.String())
+}
+
+func TestTimeoutMiddleware_FastHandler(t *testing.T) {
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions", nil)
+	TimeoutMiddleware(time.Second)(fast).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestTimeoutMiddleware_EventStreamPassesThrough(t *testing.T) {
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/subscriptions/stream", nil)
+	r.Header.Set("Accept", "text/event-stream")
+	TimeoutMiddleware(10*time.Millisecond)(stream).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+}
+
+func TestTimeoutMiddleware_StreamedRoutePassesThrough(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(TimeoutMiddleware(10*time.Millisecond, "/subscriptions/export"))
+	router.HandleFunc("/subscriptions/export", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/export", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed, "the response is not buffered")
+}
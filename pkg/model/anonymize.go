@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditActionAnonymize records that a user was detached from their data.
+const AuditActionAnonymize = "user_anonymize"
+
+// Anonymization links an anonymized user to the random ID that took their
+// place on the subscriptions they held.
+type Anonymization struct {
+	UserID       uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	AnonymousID  uuid.UUID `json:"anonymous_id" example:"d4c3b2a1-9f8e-4d7c-b6a5-0f1e2d3c4b5a"`
+	AnonymizedAt time.Time `json:"anonymized_at" example:"2025-08-12T10:30:00Z"`
+}
+
+// AnonymizeResponse is the answer to an anonymization. The anonymous ID is
+// deliberately left out: only administrators may link it to the user.
+type AnonymizeResponse struct {
+	// Subscriptions is how many live subscriptions were detached.
+	Subscriptions int64 `json:"subscriptions" example:"4"`
+	// Irreversible says no mapping to the anonymous ID was kept.
+	Irreversible bool `json:"irreversible" example:"false"`
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// BillingCycle is how often a subscription's price is charged. Price is
+// always stored per cycle.
+type BillingCycle string
+
+const (
+	CycleWeekly    BillingCycle = "weekly"
+	CycleMonthly   BillingCycle = "monthly"
+	CycleQuarterly BillingCycle = "quarterly"
+	CycleAnnual    BillingCycle = "annual"
+)
+
+// DefaultBillingCycle applies when a request leaves billing_cycle empty.
+const DefaultBillingCycle = CycleMonthly
+
+func (c BillingCycle) Valid() bool {
+	switch c {
+	case CycleWeekly, CycleMonthly, CycleQuarterly, CycleAnnual:
+		return true
+	}
+	return false
+}
+
+// cyclesPerYear is how many times a cycle is charged in a year.
+var cyclesPerYear = map[BillingCycle]float64{
+	CycleWeekly:    52,
+	CycleMonthly:   12,
+	CycleQuarterly: 4,
+	CycleAnnual:    1,
+}
+
+// monthsPerCycle is how many calendar months a cycle other than weekly
+// lasts.
+var monthsPerCycle = map[BillingCycle]int{
+	CycleMonthly:   1,
+	CycleQuarterly: 3,
+	CycleAnnual:    12,
+}
+
+// Renewal is the n-th renewal of a subscription that started at start, n
+// cycles later. Monthly, quarterly and annual renewals keep start's day of
+// the month, moved back to the month's last day when the month is
+// shorter: a subscription started on 31 January renews on 28 or 29
+// February and on 31 March again. An unknown cycle is treated as monthly.
+func (c BillingCycle) Renewal(start time.Time, n int) time.Time {
+	if c == CycleWeekly {
+		return start.AddDate(0, 0, 7*n)
+	}
+	months, ok := monthsPerCycle[c]
+	if !ok {
+		months = monthsPerCycle[CycleMonthly]
+	}
+
+	year, month, day := start.Date()
+	first := time.Date(year, month+time.Month(months*n), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, lastDay)-1)
+}
+
+// renewalsBefore is a lower bound on how many renewals of a subscription
+// started at start fall before t.
+func (c BillingCycle) renewalsBefore(start, t time.Time) int {
+	if c == CycleWeekly {
+		return int(t.Sub(start).Hours() / 24 / 7)
+	}
+	months, ok := monthsPerCycle[c]
+	if !ok {
+		months = monthsPerCycle[CycleMonthly]
+	}
+	return (monthIndex(t) - monthIndex(start)) / months
+}
+
+// MonthlyEquivalent normalises price, charged once per c, to what it costs
+// per month, rounded to two decimals. An unknown cycle is treated as monthly.
+func (c BillingCycle) MonthlyEquivalent(price int) float64 {
+	perYear, ok := cyclesPerYear[c]
+	if !ok {
+		perYear = cyclesPerYear[CycleMonthly]
+	}
+	return math.Round(float64(price)*perYear/12*100) / 100
+}
+
+// Days is the average length of c in days, or 0 for an unknown cycle.
+func (c BillingCycle) Days() float64 {
+	perYear, ok := cyclesPerYear[c]
+	if !ok {
+		return 0
+	}
+	return 365 / perYear
+}
+
+// BillingCycleSummary is the spend on subscriptions sharing one cycle.
+type BillingCycleSummary struct {
+	BillingCycle      BillingCycle `json:"billing_cycle" example:"monthly"`
+	Total             int          `json:"total" example:"1200"`
+	Count             int          `json:"count" example:"3"`
+	MonthlyEquivalent float64      `json:"monthly_equivalent" example:"1200"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CatalogEntry is a service listed in a tenant's catalog. A subscription
+// created from it takes its Name and DefaultPrice unless the request gives
+// its own.
+type CatalogEntry struct {
+	ID           uuid.UUID `json:"id" example:"2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"`
+	Name         string    `json:"name" example:"yandex plus"`
+	Description  string    `json:"description,omitempty" example:"Музыка, фильмы и кешбэк баллами"`
+	Category     string    `json:"category,omitempty" example:"streaming"`
+	LogoURL      string    `json:"logo_url,omitempty" example:"https://example.com/logos/yandex-plus.png"`
+	DefaultPrice int       `json:"default_price" example:"599"`
+	CreatedAt    time.Time `json:"created_at" example:"2025-08-12T00:00:00Z"`
+	UpdatedAt    time.Time `json:"updated_at" example:"2025-08-12T00:00:00Z"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// PeriodTotal is the prorated spend between From and To, both included.
+type PeriodTotal struct {
+	From  time.Time `json:"from" example:"2025-01-01T00:00:00Z"`
+	To    time.Time `json:"to" example:"2025-03-31T00:00:00Z"`
+	Total int       `json:"total" example:"14400"`
+}
+
+// ServiceCostChange is how one service's spend moved from period A to
+// period B.
+type ServiceCostChange struct {
+	ServiceName string `json:"service_name" example:"netflix"`
+	TotalA      int    `json:"total_a" example:"2997"`
+	TotalB      int    `json:"total_b" example:"3597"`
+	Delta       int    `json:"delta" example:"600"`
+}
+
+// PeriodComparison compares the prorated spend of two periods. Delta is
+// period B less period A and PercentChange is Delta as a percentage of
+// period A, null when period A cost nothing. TopMovers lists the services
+// whose spend changed the most either way, largest change first.
+type PeriodComparison struct {
+	PeriodA       PeriodTotal         `json:"period_a"`
+	PeriodB       PeriodTotal         `json:"period_b"`
+	Delta         int                 `json:"delta" example:"1800"`
+	PercentChange *float64            `json:"percent_change" example:"12.5"`
+	TopMovers     []ServiceCostChange `json:"top_movers"`
+	Currency      string              `json:"currency" example:"RUB"`
+}
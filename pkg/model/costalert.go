@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CostAlertConfig asks for WebhookURL to be called when the user's current
+// spend goes over ThresholdAmount, in the base currency. LastTriggeredAt is
+// when it was last called, nil if never.
+type CostAlertConfig struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	ThresholdAmount int
+	WebhookURL      string
+	LastTriggeredAt *time.Time
+}
+
+// CostAlert is what a cost alert webhook receives. TotalCost is the summed
+// price of the user's subscriptions active at TriggeredAt.
+type CostAlert struct {
+	UserID          uuid.UUID `json:"user_id"`
+	ThresholdAmount int       `json:"threshold_amount"`
+	TotalCost       int       `json:"total_cost"`
+	TriggeredAt     time.Time `json:"triggered_at"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayouts are tried in order by ParseDate.
+var dateLayouts = []string{
+	time.RFC3339,
+	time.DateOnly,
+	"01-2006",
+}
+
+// ParseDate accepts RFC3339 timestamps, YYYY-MM-DD dates and MM-YYYY
+// months. Date-only values are midnight UTC and a month means its first
+// day, so "07-2025" and "2025-07-01" are the same instant.
+func ParseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use RFC3339, YYYY-MM-DD or MM-YYYY", s)
+}
+
+// DateFieldError reports a request field or query parameter that is not a
+// date ParseDate understands.
+type DateFieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *DateFieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *DateFieldError) Unwrap() error {
+	return e.Err
+}
+
+// ParseDateField is ParseDate with the error attributed to field.
+func ParseDateField(field, s string) (time.Time, error) {
+	t, err := ParseDate(s)
+	if err != nil {
+		return time.Time{}, &DateFieldError{Field: field, Err: err}
+	}
+	return t, nil
+}
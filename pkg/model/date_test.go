@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	july := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2025-07-01T00:00:00Z", july},
+		{"2025-07-01", july},
+		{"07-2025", july},
+		{"2025-07-15T10:30:00+03:00", time.Date(2025, 7, 15, 7, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDate(tt.in)
+
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestParseDateField_Invalid(t *testing.T) {
+	for _, in := range []string{"", "2025/07/01", "13-2025", "July 2025", "2025-07"} {
+		_, err := ParseDateField("start_date", in)
+
+		var ferr *DateFieldError
+		require.True(t, errors.As(err, &ferr), in)
+		assert.Equal(t, "start_date", ferr.Field)
+		assert.Contains(t, err.Error(), "use RFC3339, YYYY-MM-DD or MM-YYYY")
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Who an AuditEntry says acted: the user the data belongs to, an
+// administrator, or the tenant's own systems acting on its users.
+const (
+	AuditActorUser   = "user"
+	AuditActorAdmin  = "admin"
+	AuditActorTenant = "tenant"
+)
+
+// AuditActionExport records a download of all of a user's data.
+const AuditActionExport = "user_data_export"
+
+// Outcomes of an audited action. An action recorded in the transaction
+// that performs it only ever completes; an export fails when its data
+// could not be read or sent in full.
+const (
+	AuditStatusCompleted = "completed"
+	AuditStatusFailed    = "failed"
+)
+
+// AuditEntry records that Actor performed Action on the data of UserID.
+type AuditEntry struct {
+	ID        int64     `json:"id" example:"17"`
+	UserID    uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Actor     string    `json:"actor" example:"user"`
+	Action    string    `json:"action" example:"user_data_export"`
+	RequestID string    `json:"request_id,omitempty" example:"c0ffee00-1234-4abc-9def-0123456789ab"`
+	Status    string    `json:"status" example:"completed"`
+	CreatedAt time.Time `json:"created_at" example:"2025-08-12T10:30:00Z"`
+}
+
+// PriceChange is one row of a subscription's price history.
+type PriceChange struct {
+	SubscriptionID uuid.UUID `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Price          int       `json:"price" example:"599"`
+	RecordedAt     time.Time `json:"recorded_at" example:"2025-08-12T00:00:00Z"`
+}
+
+// UserDataExport is everything stored about one user of a tenant: their
+// subscriptions, the price history of those and the audit trail of their
+// data. It documents the JSON export, which is streamed section by section
+// rather than built as a whole.
+type UserDataExport struct {
+	UserID        uuid.UUID       `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ExportedAt    time.Time       `json:"exported_at" example:"2025-08-12T10:30:00Z"`
+	Subscriptions []*Subscription `json:"subscriptions"`
+	PriceHistory  []PriceChange   `json:"price_history"`
+	AuditLog      []AuditEntry    `json:"audit_log"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpendingLimit is the most a user means to spend on subscriptions at a
+// time, in Currency. An alert is raised once the spend reaches
+// AlertThresholdPct percent of LimitAmount.
+type SpendingLimit struct {
+	UserID            uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	LimitAmount       int       `json:"limit_amount" example:"2000"`
+	Currency          string    `json:"currency" example:"RUB"`
+	AlertThresholdPct int       `json:"alert_threshold_pct" example:"80"`
+	CreatedAt         time.Time `json:"created_at" example:"2025-08-12T00:00:00Z"`
+	UpdatedAt         time.Time `json:"updated_at" example:"2025-08-12T00:00:00Z"`
+}
+
+// SpendingLimitStatus compares a user's current spend with their limit.
+// CurrentTotal is the summed price of the subscriptions active today,
+// converted to the limit's currency.
+type SpendingLimitStatus struct {
+	Limit        int     `json:"limit" example:"2000"`
+	Currency     string  `json:"currency" example:"RUB"`
+	CurrentTotal float64 `json:"current_total" example:"1750"`
+	UsedPct      float64 `json:"used_pct" example:"87.5"`
+	OverLimit    bool    `json:"over_limit" example:"false"`
+	Alert        bool    `json:"alert" example:"true"`
+}
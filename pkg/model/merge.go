@@ -0,0 +1,31 @@
+package model
+
+import "github.com/google/uuid"
+
+// Audit actions of a merge; one entry is filed under each of the two users.
+const (
+	// AuditActionMergeInto records that a user's subscriptions were moved
+	// to another user.
+	AuditActionMergeInto = "user_merge_into"
+	// AuditActionMergeFrom records that a user received the subscriptions
+	// of another user.
+	AuditActionMergeFrom = "user_merge_from"
+)
+
+// MergeUsersRequest is the body of POST /admin/users/{target_id}/merge.
+type MergeUsersRequest struct {
+	// SourceUserID is the duplicate account whose subscriptions move.
+	SourceUserID uuid.UUID `json:"source_user_id" example:"7a1d4c2e-5b3f-4e8a-9c6d-1f2e3a4b5c6d"`
+	// Deduplicate drops the source's live subscriptions that the target
+	// already has, same service and start date, instead of keeping both.
+	Deduplicate bool `json:"deduplicate" example:"true"`
+}
+
+// MergeUsersResponse counts the source's live subscriptions by what
+// happened to them. Running the same merge again gives zeros.
+type MergeUsersResponse struct {
+	// Moved is how many now belong to the target.
+	Moved int64 `json:"moved" example:"3"`
+	// Skipped is how many duplicated one of the target's and were deleted.
+	Skipped int64 `json:"skipped" example:"1"`
+}
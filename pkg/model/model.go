@@ -1,7 +1,10 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,25 +12,476 @@ import (
 
 type Subscription struct {
 	ID          uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ServiceName string     `json:"service_name" example:"Yandex Plus"`
+	ServiceName string     `json:"service_name" example:"yandex plus"`
 	Price       int        `json:"price" example:"599"`
 	UserID      uuid.UUID  `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
 	StartDate   time.Time  `json:"start_date" example:"2025-08-12T00:00:00Z"`
 	EndDate     *time.Time `json:"end_date,omitempty" example:"2025-09-12T00:00:00Z"`
+	// BillingCycle is how often Price is charged.
+	BillingCycle BillingCycle `json:"billing_cycle" example:"monthly"`
+	// Metadata is arbitrary client data stored as given, e.g. an invoice
+	// number or the card used.
+	Metadata json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
+	// CatalogServiceID is the catalog entry the subscription was created
+	// from, if any.
+	CatalogServiceID *uuid.UUID `json:"catalog_service_id,omitempty" example:"2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"`
+	// Version starts at 1 and goes up with every update. An update must
+	// name the version it was made from, see ErrVersionConflict.
+	Version int `json:"version" example:"3"`
+	// NextRenewalDate is only filled in by the upcoming renewals listing.
+	NextRenewalDate *time.Time `json:"next_renewal_date,omitempty" example:"2025-09-12T00:00:00Z"`
+	// Pinned is only filled in by the listing filtered by user_id and says
+	// whether that user pinned the subscription.
+	Pinned bool `json:"pinned,omitempty" example:"true"`
+	// TenantID is the organisation the subscription belongs to. It comes
+	// from the authenticated request, never from the body.
+	TenantID uuid.UUID `json:"-"`
+}
+
+// BilledMonths is how many calendar months of s fall within [from, to].
+// A month counts in full as soon as the subscription is active on any day
+// of it, so a subscription running from 15 March to 2 April is billed for
+// two months. A nil from starts the window at StartDate; to is required
+// because an open-ended subscription has no last month of its own.
+func (s *Subscription) BilledMonths(from *time.Time, to time.Time) int {
+	start := s.StartDate
+	if from != nil && from.After(start) {
+		start = *from
+	}
+	end := to
+	if s.EndDate != nil && s.EndDate.Before(end) {
+		end = *s.EndDate
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return monthIndex(end) - monthIndex(start) + 1
+}
+
+// ProratedCost is what s costs within [from, to] counted like
+// TotalProrated: its monthly equivalent for each of BilledMonths. It is
+// not rounded, so that a sum of them can be rounded once as the prorated
+// total is.
+func (s *Subscription) ProratedCost(from *time.Time, to time.Time) float64 {
+	perYear, ok := cyclesPerYear[s.BillingCycle]
+	if !ok {
+		perYear = cyclesPerYear[CycleMonthly]
+	}
+	return float64(s.Price) * perYear * float64(s.BilledMonths(from, to)) / 12
+}
+
+// NextRenewal is the first renewal of s, see BillingCycle.Renewal, on or
+// after on. The start itself is not a renewal. It reports false if s ends
+// first: a renewal falling on EndDate would pay for time after it and does
+// not happen.
+func (s *Subscription) NextRenewal(on time.Time) (time.Time, bool) {
+	n := max(1, s.BillingCycle.renewalsBefore(s.StartDate, on))
+	next := s.BillingCycle.Renewal(s.StartDate, n)
+	for next.Before(on) {
+		n++
+		next = s.BillingCycle.Renewal(s.StartDate, n)
+	}
+
+	if s.EndDate != nil && !next.Before(*s.EndDate) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// CostPerDay is what s costs per day of its life: every charge from
+// StartDate until EndDate, see NextRenewal, spread over the days between
+// them. An open-ended subscription has no such span, so its price is
+// spread over one cycle, see BillingCycle.Days. It reports false for a
+// subscription shorter than a day or with an unknown cycle.
+func (s *Subscription) CostPerDay() (float64, bool) {
+	if !s.BillingCycle.Valid() {
+		return 0, false
+	}
+	if s.EndDate == nil {
+		return float64(s.Price) / s.BillingCycle.Days(), true
+	}
+
+	days := s.EndDate.Sub(s.StartDate).Hours() / 24
+	if days < 1 {
+		return 0, false
+	}
+	charges := max(1, s.BillingCycle.renewalsBefore(s.StartDate, *s.EndDate))
+	for s.BillingCycle.Renewal(s.StartDate, charges).Before(*s.EndDate) {
+		charges++
+	}
+	return float64(s.Price*charges) / days, true
+}
+
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month())
 }
 
 type SubscriptionFilter struct {
+	// TenantID limits the match to one tenant. The repository requires it:
+	// a nil TenantID matches nothing.
+	TenantID    *uuid.UUID `json:"-"`
 	UserID      *uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
-	ServiceName *string    `json:"service_name" example:"Yandex Plus"`
-	FromDate    *time.Time `json:"from_date" example:"2025-08-12T00:00:00Z"`
-	ToDate      *time.Time `json:"to_date" example:"2025-09-12T00:00:00Z"`
+	ServiceName *string    `json:"service_name" example:"yandex plus"`
+	// ServiceNames matches any of these services as well as ServiceName,
+	// see AllServiceNames.
+	ServiceNames []string `json:"service_names,omitempty"`
+	// UserIDs matches the subscriptions of any of these users as well as
+	// UserID's, see AllUserIDs.
+	UserIDs []uuid.UUID `json:"user_ids,omitempty"`
+	// FromDate and ToDate match subscriptions active at any point between
+	// them, both days included; a nil bound leaves that side open and a
+	// missing end date means the subscription is still running.
+	FromDate *time.Time `json:"from_date" example:"2025-08-12T00:00:00Z"`
+	ToDate   *time.Time `json:"to_date" example:"2025-09-12T00:00:00Z"`
+	// SharedWithMe also matches subscriptions shared with UserID.
+	SharedWithMe bool `json:"shared_with_me" example:"false"`
+	// PinnedOnly keeps the subscriptions UserID pinned; it requires UserID.
+	PinnedOnly bool `json:"pinned_only" example:"false"`
+	// OnlyActive drops subscriptions whose end date has passed; open-ended
+	// ones and those yet to start are kept.
+	OnlyActive bool `json:"only_active" example:"false"`
+	// Search matches subscriptions whose service name contains it, ignoring
+	// case; unlike ServiceName it is honoured by List only.
+	Search *string `json:"-"`
+	// Limit and Offset select one page of List, ordered by start date; a
+	// zero Limit returns every match. Other queries ignore them.
+	Limit  int `json:"-"`
+	Offset int `json:"-"`
+}
+
+// AllUserIDs merges UserID and UserIDs, in that order and without
+// duplicates. A single user, however given, filters exactly like UserID
+// alone; SharedWithMe and PinnedOnly only make sense for a single user.
+func (f SubscriptionFilter) AllUserIDs() []uuid.UUID {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	add := func(id uuid.UUID) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if f.UserID != nil {
+		add(*f.UserID)
+	}
+	for _, id := range f.UserIDs {
+		add(id)
+	}
+	return ids
+}
+
+// AllServiceNames merges ServiceName and ServiceNames, in that order and
+// without duplicates.
+func (f SubscriptionFilter) AllServiceNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if f.ServiceName != nil {
+		add(*f.ServiceName)
+	}
+	for _, name := range f.ServiceNames {
+		add(name)
+	}
+	return names
+}
+
+// Validate checks the rules a filter has to meet whoever built it and
+// returns a *ValidationError keyed by query parameter. A date range that
+// ends before it starts can only ever match nothing, a nil user ID or a
+// blank service name names nobody, and pins and sharing are per user, so
+// neither goes with several users and pinned_only needs one.
+func (f SubscriptionFilter) Validate() error {
+	verr := &ValidationError{}
+	if f.FromDate != nil && f.ToDate != nil && f.FromDate.After(*f.ToDate) {
+		verr.Add("from_date", "must not be after to_date")
+	}
+
+	users := f.AllUserIDs()
+	for _, id := range users {
+		if id == uuid.Nil {
+			verr.Add("user_id", "must not be the nil UUID")
+		}
+	}
+	for _, name := range f.AllServiceNames() {
+		if strings.TrimSpace(name) == "" {
+			verr.Add("service_name", "must not be empty")
+		}
+	}
+
+	if f.PinnedOnly && len(users) == 0 {
+		verr.Add("pinned_only", "requires user_id")
+	}
+	if f.PinnedOnly && len(users) > 1 {
+		verr.Add("pinned_only", "requires a single user_id")
+	}
+	if f.SharedWithMe && len(users) > 1 {
+		verr.Add("shared_with_me", "requires a single user_id")
+	}
+	return verr.OrNil()
+}
+
+type SharePermission string
+
+const (
+	PermissionRead  SharePermission = "read"
+	PermissionWrite SharePermission = "write"
+)
+
+func (p SharePermission) Valid() bool {
+	return p == PermissionRead || p == PermissionWrite
+}
+
+// ShareEntry grants a user other than the owner access to a subscription.
+type ShareEntry struct {
+	SubscriptionID uuid.UUID       `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID         uuid.UUID       `json:"user_id" example:"7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b"`
+	Permission     SharePermission `json:"permission" example:"read"`
+	CreatedAt      time.Time       `json:"created_at" example:"2025-08-12T00:00:00Z"`
+}
+
+// SharedSubscription is a subscription created together with its shares.
+type SharedSubscription struct {
+	Subscription *Subscription `json:"subscription"`
+	Shares       []ShareEntry  `json:"shares"`
+}
+
+// Pin marks a subscription as a favourite of one user.
+type Pin struct {
+	SubscriptionID uuid.UUID `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID         uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	PinnedAt       time.Time `json:"pinned_at" example:"2025-08-12T00:00:00Z"`
+}
+
+type SubscriptionEventType string
+
+const (
+	EventCreated SubscriptionEventType = "created"
+	EventUpdated SubscriptionEventType = "updated"
+	EventDeleted SubscriptionEventType = "deleted"
+	// EventPriceChanged follows the updated event of a subscription whose
+	// price changed and carries the old and the new price.
+	EventPriceChanged SubscriptionEventType = "price_changed"
+	// EventSpendingLimitBreached is published when a user's spend first
+	// goes over their spending limit; it carries UserID and no ID.
+	EventSpendingLimitBreached SubscriptionEventType = "spending_limit_breached"
+	// EventRenewed is published when the renewal processor extends a
+	// subscription with auto-renewal enabled.
+	EventRenewed SubscriptionEventType = "renewed"
+)
+
+// SubscriptionEvent is published on every change to a subscription row and
+// whenever a spending limit is breached.
+// TenantID routes the event to streams of the same tenant only and is
+// never sent to clients.
+type SubscriptionEvent struct {
+	Event SubscriptionEventType `json:"event" example:"created"`
+	// ID is the subscription's; events about a user rather than one of
+	// their subscriptions leave it out.
+	ID       uuid.UUID `json:"id,omitzero" example:"550e8400-e29b-41d4-a716-446655440000"`
+	TenantID uuid.UUID `json:"-"`
+	// UserID is the user the subscription or spending limit belongs to.
+	// Events published before it was added lack it.
+	UserID *uuid.UUID `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	// OldPrice and NewPrice are set on EventPriceChanged only.
+	OldPrice *int `json:"old_price,omitempty" example:"599"`
+	NewPrice *int `json:"new_price,omitempty" example:"799"`
+}
+
+// ListResult is a page of subscriptions together with the number of
+// records matching the filter.
+type ListResult struct {
+	Items      []*Subscription
+	TotalCount int
+}
+
+// BatchGetResult holds the subscriptions found for a list of IDs, in the
+// order they were asked for, and the IDs that were not found.
+type BatchGetResult struct {
+	Subscriptions []*Subscription `json:"subscriptions"`
+	Missing       []uuid.UUID     `json:"missing"`
+}
+
+// PriceAnomalyReport compares a subscription's current price with the
+// prices it had before. ZScore is how many standard deviations the current
+// price is from their mean; it is null when the earlier prices never
+// varied, in which case any other price is an anomaly.
+type PriceAnomalyReport struct {
+	CurrentPrice int      `json:"current_price" example:"1200"`
+	Mean         float64  `json:"mean" example:"700"`
+	StdDev       float64  `json:"std_dev" example:"50"`
+	ZScore       *float64 `json:"z_score" example:"10"`
+	IsAnomaly    bool     `json:"is_anomaly" example:"true"`
+	// HistorySize is the number of earlier prices compared against.
+	HistorySize int `json:"history_size" example:"4"`
+}
+
+// RankedSubscription is a subscription with its cost per day, see
+// Subscription.CostPerDay, rounded to two decimals, and its place when
+// ranked by it. Both are left out for a subscription whose cost per day is
+// unknown; such subscriptions are ranked last.
+type RankedSubscription struct {
+	Subscription
+	CostPerDay float64 `json:"cost_per_day,omitempty" example:"19.32"`
+	Rank       int     `json:"rank,omitempty" example:"1"`
+}
+
+// ExpiredSubscription is a subscription whose end date has passed, with
+// the whole days since then. A subscription that ended today has been
+// expired for 0 days.
+type ExpiredSubscription struct {
+	Subscription
+	ExpiredForDays int `json:"expired_for_days" example:"14"`
+}
+
+type ServiceSummary struct {
+	ServiceName       string `json:"service_name" example:"netflix"`
+	SubscriptionCount int    `json:"subscription_count" example:"3"`
+}
+
+// ExpiringServiceSummary counts a service's subscriptions that end soon.
+type ExpiringServiceSummary struct {
+	ServiceName    string    `json:"service_name" example:"netflix"`
+	Count          int       `json:"count" example:"2"`
+	EarliestExpiry time.Time `json:"earliest_expiry" example:"2025-09-12T00:00:00Z"`
+}
+
+// UserSummary is a compact overview of one user's subscriptions. A user
+// without subscriptions gets zero values; MostExpensiveService is then empty
+// and NextExpiry null.
+type UserSummary struct {
+	ActiveCount int `json:"active_count" example:"5"`
+	// TotalMonthlyCost is the monthly equivalent of all active
+	// subscriptions, whatever their billing cycle.
+	TotalMonthlyCost     float64    `json:"total_monthly_cost" example:"1500"`
+	MostExpensiveService string     `json:"most_expensive_service" example:"netflix"`
+	NextExpiry           *time.Time `json:"next_expiry" example:"2025-09-01T00:00:00Z"`
+	ExpiredCount         int        `json:"expired_count" example:"2"`
+}
+
+// CreationRate is how many subscriptions were created in [From, To).
+type CreationRate struct {
+	From   time.Time `json:"from" example:"2025-01-01T00:00:00Z"`
+	To     time.Time `json:"to" example:"2025-02-01T00:00:00Z"`
+	Count  int       `json:"count" example:"310"`
+	PerDay float64   `json:"per_day" example:"10"`
+}
+
+// UserCost is one user's line of the admin spend leaderboard; Total is
+// prorated like model.TotalProrated.
+type UserCost struct {
+	UserID            uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	SubscriptionCount int       `json:"subscription_count" example:"4"`
+	Total             int       `json:"total" example:"14376"`
+}
+
+// ServiceCost is one service's prorated spend in a period and its share of
+// the total, in percent.
+type ServiceCost struct {
+	ServiceName string  `json:"service_name" example:"netflix"`
+	Total       int     `json:"total" example:"5994"`
+	Percent     float64 `json:"percent" example:"41.63"`
+}
+
+// OtherServicesCost sums up every service outside the top.
+type OtherServicesCost struct {
+	ServiceCount int     `json:"service_count" example:"3"`
+	Total        int     `json:"total" example:"1797"`
+	Percent      float64 `json:"percent" example:"12.48"`
+}
+
+// TopServices ranks a user's services by spend. The percentages of
+// Services and Other add up to 100 unless Total is 0.
+type TopServices struct {
+	Services []ServiceCost     `json:"services"`
+	Other    OtherServicesCost `json:"other"`
+	Total    int               `json:"total" example:"14400"`
+}
+
+// UserCostResult is a page of the leaderboard together with the number of
+// users matching the filter.
+type UserCostResult struct {
+	Items      []UserCost
+	TotalCount int
+}
+
+// TotalMode selects how a subscription's price counts towards a total.
+type TotalMode string
+
+const (
+	// TotalProrated charges the price for every billing month the
+	// subscription overlaps the period, see Subscription.BilledMonths.
+	TotalProrated TotalMode = "prorated"
+	// TotalFlat counts each matching subscription's price once, however
+	// long the period.
+	TotalFlat TotalMode = "flat"
+)
+
+func (m TotalMode) Valid() bool {
+	return m == TotalProrated || m == TotalFlat
+}
+
+// TotalSource selects what a total adds up.
+type TotalSource string
+
+const (
+	// TotalFromPrices adds up subscription prices as TotalMode says.
+	TotalFromPrices TotalSource = "prices"
+	// TotalFromBillingHistory adds up the payments recorded in the period.
+	TotalFromBillingHistory TotalSource = "billing_history"
+)
+
+func (s TotalSource) Valid() bool {
+	return s == TotalFromPrices || s == TotalFromBillingHistory
 }
 
 // Custom errors for handlers
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrConflict means the write collides with an existing record.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidReference means the write points at a record that does not exist.
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrVersionConflict is returned by an update made from a version of
+	// the record that is no longer current.
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrLimitExceeded means the user already has as many subscriptions as
+	// their limit allows.
+	ErrLimitExceeded = errors.New("subscription limit exceeded")
 )
 
+// DuplicateError reports that a new subscription overlaps a live one of
+// the same user and service. It matches ErrConflict via errors.Is.
+type DuplicateError struct {
+	ExistingID uuid.UUID
+}
+
+func (e *DuplicateError) Error() string {
+	return ErrConflict.Error() + ": overlaps subscription " + e.ExistingID.String()
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// VersionConflictError reports that an update named another version than
+// the Current one. It matches ErrVersionConflict via errors.Is.
+type VersionConflictError struct {
+	Current int
+}
+
+func (e *VersionConflictError) Error() string {
+	return ErrVersionConflict.Error() + ": current version is " + strconv.Itoa(e.Current)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
 // ***
 // Custom responses for swagger
 type ErrorResponse struct {
@@ -35,23 +489,103 @@ type ErrorResponse struct {
 	Code  int    `json:"code" example:"404"`
 }
 
+// DuplicateErrorResponse is returned with 409 when a new subscription
+// overlaps an existing one; ExistingID points the client at it.
+type DuplicateErrorResponse struct {
+	Error      string    `json:"error" example:"subscription overlaps an existing one"`
+	Code       int       `json:"code" example:"409"`
+	ExistingID uuid.UUID `json:"existing_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// VersionConflictResponse is returned with 409 when an update was made
+// from a stale copy; the client should re-fetch CurrentVersion and retry.
+type VersionConflictResponse struct {
+	Error          string `json:"error" example:"subscription was changed meanwhile, fetch it again and retry"`
+	CurrentVersion int    `json:"current_version" example:"4"`
+}
+
 type ErrorInput struct {
 	Error string `json:"error" example:"invalid input"`
 	Code  int    `json:"code" example:"400"`
 }
 
+// TotalCostResponse reports Total in the base Currency; ConvertedTotal and
+// TargetCurrency are present only when another currency was requested. A
+// total of payments has Source billing_history and no Mode.
 type TotalCostResponse struct {
-	Total int `json:"total" example:"1500"`
+	Total          int         `json:"total" example:"1500"`
+	Mode           TotalMode   `json:"mode,omitempty" example:"prorated"`
+	Source         TotalSource `json:"source,omitempty" example:"prices"`
+	Currency       string      `json:"currency" example:"RUB"`
+	ConvertedTotal *float64    `json:"converted_total,omitempty" example:"18.5"`
+	TargetCurrency string      `json:"target_currency,omitempty" example:"USD"`
+}
+
+// TeamTotalCost is the prorated spend of each user of a team, keyed by
+// user ID, and their sum.
+type TeamTotalCost struct {
+	Totals map[uuid.UUID]int `json:"totals"`
+	Total  int               `json:"total" example:"3000"`
+}
+
+// MonthlyCost is one point of the spending timeseries: the prorated cost of
+// the subscriptions active in the calendar month that starts at Month.
+type MonthlyCost struct {
+	Month time.Time `json:"month" example:"2025-01-01T00:00:00Z"`
+	Total int       `json:"total" example:"1500"`
+}
+
+// Forecast projects spending forward from the current month on the
+// assumption that nothing changes: subscriptions keep running until their
+// EndDate and each month is priced like MonthlyCost.
+type Forecast struct {
+	Months []MonthlyCost `json:"months"`
+	Total  int           `json:"total" example:"18000"`
+}
+
+// ProjectedCost is the expected spend in one future calendar month,
+// formatted YYYY-MM: the monthly equivalent of every subscription that
+// runs for the whole month.
+type ProjectedCost struct {
+	Month         string  `json:"month" example:"2025-08"`
+	ProjectedCost float64 `json:"projected_cost" example:"1500"`
+}
+
+// PriceStats describes the prices of the subscriptions matching a filter.
+// With no matches every field is zero.
+type PriceStats struct {
+	Count       int     `json:"count" example:"12"`
+	MinPrice    int     `json:"min_price" example:"199"`
+	MaxPrice    int     `json:"max_price" example:"1299"`
+	AvgPrice    float64 `json:"avg_price" example:"574.5"`
+	MedianPrice float64 `json:"median_price" example:"499"`
 }
 
+// SubscriptionListResponse is a page of the list in an envelope, for
+// clients that cannot read the X-Total-Count header. Count is the number
+// of matches on every page, not the length of Subscriptions.
 type SubscriptionListResponse struct {
 	Subscriptions []*Subscription `json:"subscriptions"`
-	Count         int             `json:"count" example:"5"`
+	Count         int             `json:"count" example:"120"`
+	Limit         int             `json:"limit" example:"50"`
+	Offset        int             `json:"offset" example:"0"`
+}
+
+type CleanupResponse struct {
+	Deleted int `json:"deleted" example:"3"`
+}
+
+type BulkUpdatePriceResponse struct {
+	Updated int `json:"updated" example:"42"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
 }
 
 type ServerError struct {
-	Error string `json:"error" example:"server does not respond"`
-	Code  int    `json:"code" example:"500"`
+	Error     string `json:"error" example:"internal server error"`
+	RequestID string `json:"request_id,omitempty" example:"3f2b8c1e-5d4a-4b6f-9e7d-2a1c0b9f8e7d"`
 }
 
 //***
@@ -0,0 +1,254 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestSubscriptionFilter_AllUserIDs(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	assert.Nil(t, SubscriptionFilter{}.AllUserIDs())
+	assert.Equal(t, []uuid.UUID{a}, SubscriptionFilter{UserIDs: []uuid.UUID{a}}.AllUserIDs())
+	assert.Equal(t, []uuid.UUID{a, b}, SubscriptionFilter{UserID: &a, UserIDs: []uuid.UUID{b, a, b}}.AllUserIDs())
+}
+
+func TestSubscriptionFilter_AllServiceNames(t *testing.T) {
+	netflix := "netflix"
+
+	assert.Nil(t, SubscriptionFilter{}.AllServiceNames())
+	assert.Equal(t, []string{"spotify"}, SubscriptionFilter{ServiceNames: []string{"spotify"}}.AllServiceNames())
+	assert.Equal(t, []string{"netflix", "spotify"}, SubscriptionFilter{ServiceName: &netflix, ServiceNames: []string{"spotify", "netflix"}}.AllServiceNames())
+}
+
+func TestSubscriptionFilter_Validate(t *testing.T) {
+	userID := uuid.New()
+	nilID := uuid.Nil
+	netflix, blank := "netflix", "  "
+
+	tests := []struct {
+		name   string
+		filter SubscriptionFilter
+		// want is nil when the filter is valid.
+		want map[string]string
+	}{
+		{"empty", SubscriptionFilter{}, nil},
+		{"full", SubscriptionFilter{
+			UserID: &userID, ServiceName: &netflix,
+			FromDate: ptr(day(1, 1)), ToDate: ptr(day(3, 1)),
+			SharedWithMe: true, PinnedOnly: true,
+		}, nil},
+		{"range of one day", SubscriptionFilter{FromDate: ptr(day(3, 1)), ToDate: ptr(day(3, 1))}, nil},
+		{"open range", SubscriptionFilter{FromDate: ptr(day(3, 1))}, nil},
+		{"reversed range", SubscriptionFilter{FromDate: ptr(day(3, 2)), ToDate: ptr(day(3, 1))},
+			map[string]string{"from_date": "must not be after to_date"}},
+		{"nil user", SubscriptionFilter{UserID: &nilID},
+			map[string]string{"user_id": "must not be the nil UUID"}},
+		{"nil user among several", SubscriptionFilter{UserIDs: []uuid.UUID{userID, uuid.Nil}},
+			map[string]string{"user_id": "must not be the nil UUID"}},
+		{"blank service name", SubscriptionFilter{ServiceName: &blank},
+			map[string]string{"service_name": "must not be empty"}},
+		{"blank among several service names", SubscriptionFilter{ServiceNames: []string{"netflix", ""}},
+			map[string]string{"service_name": "must not be empty"}},
+		{"pinned without user", SubscriptionFilter{PinnedOnly: true},
+			map[string]string{"pinned_only": "requires user_id"}},
+		{"pinned and shared for several users", SubscriptionFilter{
+			UserIDs: []uuid.UUID{userID, uuid.New()}, PinnedOnly: true, SharedWithMe: true,
+		}, map[string]string{
+			"pinned_only":    "requires a single user_id",
+			"shared_with_me": "requires a single user_id",
+		}},
+		{"everything at once", SubscriptionFilter{
+			UserID: &nilID, ServiceName: &blank,
+			FromDate: ptr(day(6, 1)), ToDate: ptr(day(1, 1)),
+		}, map[string]string{
+			"from_date":    "must not be after to_date",
+			"user_id":      "must not be the nil UUID",
+			"service_name": "must not be empty",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.ErrorIs(t, err, ErrValidation)
+			assert.Equal(t, tt.want, verr.Fields)
+		})
+	}
+}
+
+func TestSubscription_BilledMonths(t *testing.T) {
+	lastYear := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      *time.Time
+		from, to time.Time
+		want     int
+	}{
+		{"open-ended, whole year", lastYear, nil, day(1, 1), day(12, 31), 12},
+		{"ended subscription, whole year", lastYear, ptr(day(4, 10)), day(1, 1), day(12, 31), 4},
+		{"starts mid-month", day(3, 15), nil, day(1, 1), day(12, 31), 10},
+		{"starts and ends in one month", day(3, 5), ptr(day(3, 20)), day(1, 1), day(12, 31), 1},
+		{"crosses a month boundary by a day", day(3, 31), ptr(day(4, 1)), day(1, 1), day(12, 31), 2},
+		{"ends on first day of window", lastYear, ptr(day(1, 1)), day(1, 1), day(12, 31), 1},
+		{"window starts and ends mid-month", lastYear, nil, day(3, 15), day(5, 10), 3},
+		{"window within one month", lastYear, nil, day(3, 10), day(3, 20), 1},
+		{"window across new year", lastYear, nil, time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC), day(2, 1), 4},
+		{"ends before window", lastYear, ptr(day(2, 28)), day(3, 1), day(12, 31), 0},
+		{"starts after window", day(6, 1), nil, day(1, 1), day(3, 31), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Subscription{StartDate: tt.start, EndDate: tt.end}
+
+			assert.Equal(t, tt.want, s.BilledMonths(&tt.from, tt.to))
+		})
+	}
+}
+
+func TestSubscription_BilledMonthsFromStart(t *testing.T) {
+	s := &Subscription{StartDate: day(11, 20)}
+
+	assert.Equal(t, 2, s.BilledMonths(nil, day(12, 1)))
+	assert.Equal(t, 0, s.BilledMonths(nil, day(10, 31)))
+}
+
+func TestSubscription_ProratedCost(t *testing.T) {
+	from, to := day(1, 1), day(3, 31)
+
+	tests := []struct {
+		name  string
+		cycle BillingCycle
+		start time.Time
+		want  float64
+	}{
+		{"monthly, three months", CycleMonthly, day(1, 1), 3600},
+		{"monthly, starts in the last month", CycleMonthly, day(3, 31), 1200},
+		{"annual is spread over the months", CycleAnnual, day(1, 1), 300},
+		{"weekly", CycleWeekly, day(2, 10), 10400},
+		{"unknown cycle is monthly", "", day(1, 1), 3600},
+		{"outside the window", CycleMonthly, day(4, 1), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Subscription{Price: 1200, BillingCycle: tt.cycle, StartDate: tt.start}
+
+			assert.InDelta(t, tt.want, s.ProratedCost(&from, to), 1e-9)
+		})
+	}
+}
+
+func TestBillingCycle_RenewalAtMonthEnd(t *testing.T) {
+	jan31 := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	leapJan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	leapDay := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		cycle BillingCycle
+		start time.Time
+		n     int
+		want  time.Time
+	}{
+		{"February is shorter", CycleMonthly, jan31, 1, day(2, 28)},
+		{"leap February", CycleMonthly, leapJan31, 1, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"back to the 31st after February", CycleMonthly, jan31, 2, day(3, 31)},
+		{"April has 30 days", CycleMonthly, jan31, 3, day(4, 30)},
+		{"into the next year", CycleMonthly, day(11, 30), 3, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"quarterly from the 31st", CycleQuarterly, jan31, 1, day(4, 30)},
+		{"annual from a leap day", CycleAnnual, leapDay, 1, day(2, 28)},
+		{"annual back on a leap day", CycleAnnual, leapDay, 4, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"weekly across a month end", CycleWeekly, jan31, 1, day(2, 7)},
+		{"unknown cycle is monthly", "", jan31, 1, day(2, 28)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cycle.Renewal(tt.start, tt.n))
+		})
+	}
+}
+
+func TestSubscription_NextRenewal(t *testing.T) {
+	jan31 := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		sub    Subscription
+		on     time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{"renews on the day itself", Subscription{StartDate: day(1, 15), BillingCycle: CycleMonthly}, day(3, 15), day(3, 15), true},
+		{"next month's anniversary", Subscription{StartDate: day(1, 15), BillingCycle: CycleMonthly}, day(3, 16), day(4, 15), true},
+		{"clamped in February", Subscription{StartDate: jan31, BillingCycle: CycleMonthly}, day(2, 1), day(2, 28), true},
+		{"31st again in March", Subscription{StartDate: jan31, BillingCycle: CycleMonthly}, day(3, 1), day(3, 31), true},
+		{"start is not a renewal", Subscription{StartDate: day(6, 1), BillingCycle: CycleMonthly}, day(5, 1), day(7, 1), true},
+		{"years of weekly renewals", Subscription{StartDate: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), BillingCycle: CycleWeekly}, day(3, 4), day(3, 10), true},
+		{"quarterly", Subscription{StartDate: time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC), BillingCycle: CycleQuarterly}, day(1, 1), day(2, 28), true},
+		{"annual", Subscription{StartDate: time.Date(2023, 9, 12, 0, 0, 0, 0, time.UTC), BillingCycle: CycleAnnual}, day(1, 1), day(9, 12), true},
+		{"ends before renewing", Subscription{StartDate: day(1, 15), EndDate: ptr(day(3, 10)), BillingCycle: CycleMonthly}, day(3, 1), time.Time{}, false},
+		{"ends on the renewal day", Subscription{StartDate: day(1, 15), EndDate: ptr(day(3, 15)), BillingCycle: CycleMonthly}, day(3, 1), time.Time{}, false},
+		{"renews before it ends", Subscription{StartDate: day(1, 15), EndDate: ptr(day(3, 16)), BillingCycle: CycleMonthly}, day(3, 1), day(3, 15), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.sub.NextRenewal(tt.on)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSubscription_CostPerDay(t *testing.T) {
+	tests := []struct {
+		name   string
+		sub    Subscription
+		want   float64
+		wantOK bool
+	}{
+		{"two months", Subscription{Price: 590, StartDate: day(1, 1), EndDate: ptr(day(3, 1)), BillingCycle: CycleMonthly}, 20, true},
+		{"renewal a day before the end", Subscription{Price: 590, StartDate: day(1, 1), EndDate: ptr(day(3, 2)), BillingCycle: CycleMonthly}, 1770.0 / 60, true},
+		{"two weeks", Subscription{Price: 70, StartDate: day(1, 1), EndDate: ptr(day(1, 15)), BillingCycle: CycleWeekly}, 10, true},
+		{"annual cancelled after ten days", Subscription{Price: 3650, StartDate: day(1, 1), EndDate: ptr(day(1, 11)), BillingCycle: CycleAnnual}, 365, true},
+		{"a full year of quarters", Subscription{Price: 910, StartDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: ptr(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), BillingCycle: CycleQuarterly}, 3640.0 / 365, true},
+		{"open-ended annual", Subscription{Price: 3650, StartDate: day(1, 1), BillingCycle: CycleAnnual}, 10, true},
+		{"open-ended weekly", Subscription{Price: 70, StartDate: day(1, 1), BillingCycle: CycleWeekly}, 70.0 * 52 / 365, true},
+		{"ends the day it starts", Subscription{Price: 590, StartDate: day(1, 1), EndDate: ptr(day(1, 1)), BillingCycle: CycleMonthly}, 0, false},
+		{"unknown cycle", Subscription{Price: 590, StartDate: day(1, 1), BillingCycle: "daily"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.sub.CostPerDay()
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
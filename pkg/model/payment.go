@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentStatus string
+
+// PaymentPaid is the status of every payment recorded through the API;
+// only paid payments count towards totals.
+const PaymentPaid PaymentStatus = "paid"
+
+// Payment is one charge of a subscription, in Currency.
+type Payment struct {
+	ID             uuid.UUID     `json:"id" example:"9b2d7c4e-1f3a-4b5c-8d6e-7f8091a2b3c4"`
+	SubscriptionID uuid.UUID     `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Amount         int           `json:"amount" example:"599"`
+	Currency       string        `json:"currency" example:"RUB"`
+	BilledAt       time.Time     `json:"billed_at" example:"2025-08-12T00:00:00Z"`
+	Status         PaymentStatus `json:"status" example:"paid"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder asks for a notification RemindDaysBefore days ahead of the
+// subscription's end_date.
+type Reminder struct {
+	ID               uuid.UUID  `json:"id" example:"3f1c2b4a-5d6e-4f70-8192-a3b4c5d6e7f8"`
+	SubscriptionID   uuid.UUID  `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RemindDaysBefore int        `json:"remind_days_before" example:"3"`
+	LastRemindedAt   *time.Time `json:"last_reminded_at,omitempty" example:"2025-09-09T09:00:00Z"`
+	CreatedAt        time.Time  `json:"created_at" example:"2025-08-12T00:00:00Z"`
+}
+
+// DueAt is the day the reminder fires for a subscription ending on end.
+func (r Reminder) DueAt(end time.Time) time.Time {
+	end = end.UTC()
+	return time.Date(end.Year(), end.Month(), end.Day()-r.RemindDaysBefore, 0, 0, 0, 0, time.UTC)
+}
+
+// PendingReminder pairs a reminder with the subscription it is about.
+type PendingReminder struct {
+	Reminder     Reminder
+	Subscription Subscription
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AutoRenewalConfig is a row of auto_renewal_config. While Enabled, the
+// subscription's end date is moved RenewalDurationDays on whenever
+// NextRenewalDate comes.
+type AutoRenewalConfig struct {
+	SubscriptionID      uuid.UUID
+	Enabled             bool
+	RenewalDurationDays int
+	NextRenewalDate     time.Time
+}
+
+// DueOn reports whether the renewal falls due on or before day.
+func (c AutoRenewalConfig) DueOn(day time.Time) bool {
+	return c.Enabled && !c.NextRenewalDate.After(day)
+}
+
+// Renewed is c after one renewal: NextRenewalDate moves on by
+// RenewalDurationDays.
+func (c AutoRenewalConfig) Renewed() AutoRenewalConfig {
+	c.NextRenewalDate = c.NextRenewalDate.AddDate(0, 0, c.RenewalDurationDays)
+	return c
+}
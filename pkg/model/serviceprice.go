@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServicePriceUpdate changes the price of every live subscription to
+// ServiceName, of TenantID or of every tenant when it is nil. The new price
+// is NewPrice or, when that is nil, the current one changed by
+// PercentChange percent, rounded and kept between 1 and MaxPrice-1. With
+// ActiveOnly, subscriptions not active today are left alone. EffectiveDate,
+// when set, is when the new prices are recorded to apply from in the price
+// history instead of now.
+type ServicePriceUpdate struct {
+	ServiceName   string
+	TenantID      *uuid.UUID
+	NewPrice      *int
+	PercentChange *float64
+	MaxPrice      int
+	ActiveOnly    bool
+	EffectiveDate *time.Time
+}
+
+// ServicePriceUpdateResult counts the subscriptions a ServicePriceUpdate
+// changed and what they cost together before and after it. Owners lists
+// the users whose subscriptions changed, once each; it is not part of the
+// response.
+type ServicePriceUpdateResult struct {
+	Updated     int64               `json:"updated" example:"42"`
+	TotalBefore int64               `json:"total_before" example:"41958"`
+	TotalAfter  int64               `json:"total_after" example:"50358"`
+	Owners      []SubscriptionOwner `json:"-"`
+}
+
+// SubscriptionOwner is a user of a tenant.
+type SubscriptionOwner struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+}
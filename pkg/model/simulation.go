@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// CostSimulation compares what a user's subscriptions cost in Month,
+// prorated like TotalProrated, with what they would cost after
+// hypothetical changes. Difference is SimulatedTotal less CurrentTotal;
+// all three are in the base Currency.
+type CostSimulation struct {
+	Month          time.Time `json:"month" example:"2025-03-01T00:00:00Z"`
+	CurrentTotal   int       `json:"current_total" example:"1798"`
+	SimulatedTotal int       `json:"simulated_total" example:"1398"`
+	Difference     int       `json:"difference" example:"-400"`
+	Currency       string    `json:"currency" example:"RUB"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrValidation matches any *ValidationError via errors.Is.
+var ErrValidation = errors.New("validation failed")
+
+// ValidationError lists the rejected fields of a request, keyed by their JSON
+// name.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+// Add records msg for field, keeping the first message if one already exists.
+func (e *ValidationError) Add(field, msg string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = msg
+	}
+}
+
+// OrNil returns e if any field was rejected and nil otherwise, so callers can
+// return it directly.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Fields[name]
+	}
+	return ErrValidation.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ValidationErrorResponse is returned with 422 when a request is well-formed
+// JSON but breaks one or more field rules.
+type ValidationErrorResponse struct {
+	Error  string            `json:"error" example:"validation failed"`
+	Fields map[string]string `json:"fields"`
+}
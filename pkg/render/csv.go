@@ -0,0 +1,250 @@
+package render
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CSV renders data as a CSV table with a header row. A slice of structs
+// gets one row per element and one column per JSON field, named as in the
+// JSON; a single struct is a table of one row. Maps become key and value
+// columns, anything else a single value column. Values nested in a cell,
+// such as a list inside a struct, are written as JSON.
+type CSV struct{}
+
+func (CSV) Render(w http.ResponseWriter, statusCode int, data interface{}) error {
+	if !hasBody(statusCode) {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	rows, err := table(reflect.ValueOf(data))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	write(w, statusCode, CSVContentType, buf.Bytes())
+	return nil
+}
+
+var (
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// table lays v out as a header row followed by the data rows.
+func table(v reflect.Value) ([][]string, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return [][]string{{"value"}}, nil
+	}
+
+	switch {
+	case isRecord(v.Type()):
+		fields := columns(v.Type(), nil)
+		row, err := record(v, fields)
+		if err != nil {
+			return nil, err
+		}
+		return [][]string{header(fields), row}, nil
+
+	case (v.Kind() == reflect.Slice && v.Type() != rawMessageType) || v.Kind() == reflect.Array:
+		elem := v.Type().Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if !isRecord(elem) {
+			rows := [][]string{{"value"}}
+			for i := range v.Len() {
+				c, err := cell(v.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				rows = append(rows, []string{c})
+			}
+			return rows, nil
+		}
+
+		fields := columns(elem, nil)
+		rows := [][]string{header(fields)}
+		for i := range v.Len() {
+			row, err := record(indirect(v.Index(i)), fields)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+
+	case v.Kind() == reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		rows := [][]string{{"key", "value"}}
+		for _, k := range keys {
+			key, err := cell(k)
+			if err != nil {
+				return nil, err
+			}
+			value, err := cell(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, []string{key, value})
+		}
+		return rows, nil
+	}
+
+	c, err := cell(v)
+	if err != nil {
+		return nil, err
+	}
+	return [][]string{{"value"}, {c}}, nil
+}
+
+// isRecord reports whether values of t are rows of their own rather than
+// cells: structs, except the ones that encode to a single text value such
+// as times.
+func isRecord(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !t.Implements(textMarshalerType) && !reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// field is one column: its JSON name and where it sits in the struct.
+type field struct {
+	name  string
+	index []int
+}
+
+// columns lists the fields encoding/json would write for t, in order, with
+// the fields of untagged embedded structs in place of the struct.
+func columns(t reflect.Type, parent []int) []field {
+	var fields []field
+	for i := range t.NumField() {
+		f := t.Field(i)
+		index := append(append([]int(nil), parent...), i)
+		tag, tagged := f.Tag.Lookup("json")
+		if f.Anonymous && !tagged {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, columns(ft, index)...)
+				continue
+			}
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, field{name: name, index: index})
+	}
+	return fields
+}
+
+func header(fields []field) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// record is the row of struct v; a missing v, such as a nil element of a
+// list, is a row of empty cells.
+func record(v reflect.Value, fields []field) ([]string, error) {
+	row := make([]string, len(fields))
+	if !v.IsValid() {
+		return row, nil
+	}
+	for i, f := range fields {
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// A nil embedded pointer: its fields are empty.
+			continue
+		}
+		if row[i], err = cell(fv); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+	return row, nil
+}
+
+// cell formats one value. Strings that a spreadsheet would take for a
+// formula are prefixed with a quote so that opening the file cannot run
+// anything.
+func cell(v reflect.Value) (string, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "", nil
+	}
+
+	if v.Type() == rawMessageType {
+		return string(v.Bytes()), nil
+	}
+	if m, ok := textMarshaler(v); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+			s = "'" + s
+		}
+		return s, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+
+	data, err := json.Marshal(v.Interface())
+	return string(data), err
+}
+
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return m, true
+	}
+	if v.CanAddr() {
+		m, ok := v.Addr().Interface().(encoding.TextMarshaler)
+		return m, ok
+	}
+	return nil, false
+}
+
+// indirect follows pointers and interfaces; a nil one gives the zero Value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
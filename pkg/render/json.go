@@ -0,0 +1,30 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+)
+
+// JSON renders data as JSON. A nil slice, which is what an empty result
+// usually comes back as, is sent as [] rather than null: an endpoint that
+// returns a list always returns an array.
+type JSON struct{}
+
+func (JSON) Render(w http.ResponseWriter, statusCode int, data interface{}) error {
+	if !hasBody(statusCode) {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Slice && v.IsNil() {
+		data = []struct{}{}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		return err
+	}
+	write(w, statusCode, JSONContentType, buf.Bytes())
+	return nil
+}
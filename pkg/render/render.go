@@ -0,0 +1,45 @@
+// Package render writes response payloads in the media type a client
+// negotiated with its Accept header.
+package render
+
+import (
+	"context"
+	"net/http"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+)
+
+// Media types a response can be rendered in.
+const (
+	JSONContentType = "application/json"
+	CSVContentType  = "text/csv; charset=utf-8"
+)
+
+// Renderer writes data as the body of a statusCode response. Data is fully
+// encoded before anything is written, so an error means nothing was sent
+// and the caller can still answer with an error of its own. 204 and 304
+// never carry a body. Failures writing to w are not reported: the client
+// has gone away and there is no one left to answer.
+type Renderer interface {
+	Render(w http.ResponseWriter, statusCode int, data interface{}) error
+}
+
+// FromContext returns the Renderer negotiated for the request, JSON when
+// none was.
+func FromContext(ctx context.Context) Renderer {
+	if r, ok := ctxkey.Get[Renderer](ctx, ctxkey.KeyRenderer); ok {
+		return r
+	}
+	return JSON{}
+}
+
+// write sends an encoded body.
+func write(w http.ResponseWriter, statusCode int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+func hasBody(statusCode int) bool {
+	return statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
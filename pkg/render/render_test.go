@@ -0,0 +1,145 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+)
+
+type base struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type row struct {
+	base
+	Name     string          `json:"name"`
+	Price    int             `json:"price"`
+	Start    time.Time       `json:"start_date"`
+	End      *time.Time      `json:"end_date,omitempty"`
+	Tags     []string        `json:"tags"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Secret   string          `json:"-"`
+	internal int
+}
+
+var (
+	rowID = uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	start = time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC)
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		data interface{}
+		want string
+	}{
+		{"object", http.StatusOK, map[string]int{"a": 1}, `{"a":1}` + "\n"},
+		{"nil slice", http.StatusOK, []row(nil), "[]\n"},
+		{"created", http.StatusCreated, []int{1}, "[1]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			require.NoError(t, JSON{}.Render(w, tt.code, tt.data))
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, JSONContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestCSV(t *testing.T) {
+	end := start.AddDate(0, 1, 0)
+	full := row{
+		base:     base{ID: rowID},
+		Name:     "yandex plus",
+		Price:    599,
+		Start:    start,
+		End:      &end,
+		Tags:     []string{"music", "video"},
+		Metadata: json.RawMessage(`{"card":"x"}`),
+		Secret:   "hidden",
+	}
+	const header = "id,name,price,start_date,end_date,tags,metadata\n"
+	const fullRow = `550e8400-e29b-41d4-a716-446655440000,yandex plus,599,2025-08-12T00:00:00Z,2025-09-12T00:00:00Z,"[""music"",""video""]","{""card"":""x""}"` + "\n"
+
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"list", []row{full, {Name: "netflix"}}, header + fullRow +
+			"00000000-0000-0000-0000-000000000000,netflix,0,0001-01-01T00:00:00Z,,,\n"},
+		{"list of pointers", []*row{&full, nil}, header + fullRow + ",,,,,,\n"},
+		{"empty list", []row(nil), header},
+		{"single struct", &full, header + fullRow},
+		{"map", map[string]int{"b": 2, "a": 1}, "key,value\na,1\nb,2\n"},
+		{"scalars", []float64{1.5, 2}, "value\n1.5\n2\n"},
+		{"scalar", "ok", "value\nok\n"},
+		{"nil", nil, "value\n"},
+		{"formula", []string{"=SUM(A1)", "-1", "@x", "+1"}, "value\n'=SUM(A1)\n'-1\n'@x\n'+1\n"},
+		{"comma and newline", []string{"a,b", "c\nd"}, "value\n\"a,b\"\n\"c\nd\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			require.NoError(t, CSV{}.Render(w, http.StatusOK, tt.data))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, CSVContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestRender_NoBody(t *testing.T) {
+	for _, renderer := range []Renderer{JSON{}, CSV{}} {
+		for _, code := range []int{http.StatusNoContent, http.StatusNotModified} {
+			w := httptest.NewRecorder()
+
+			require.NoError(t, renderer.Render(w, code, []int{1}))
+
+			assert.Equal(t, code, w.Code)
+			assert.Empty(t, w.Body.String())
+			assert.Empty(t, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestRender_EncodingErrorWritesNothing(t *testing.T) {
+	unencodable := []struct {
+		Value interface{} `json:"value"`
+	}{{Value: make(chan int)}}
+
+	for _, renderer := range []Renderer{JSON{}, CSV{}} {
+		w := httptest.NewRecorder()
+
+		require.Error(t, renderer.Render(w, http.StatusOK, unencodable))
+
+		assert.False(t, w.Flushed)
+		assert.Empty(t, w.Body.String())
+		assert.Empty(t, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, JSON{}, FromContext(context.Background()))
+
+	ctx := ctxkey.With(context.Background(), ctxkey.KeyRenderer, Renderer(CSV{}))
+	assert.Equal(t, CSV{}, FromContext(ctx))
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// AnonymizationRepository detaches users of tenantID from their data.
+type AnonymizationRepository interface {
+	AnonymizeUser(ctx context.Context, tenantID uuid.UUID, a *model.Anonymization, keepMapping bool, entry *model.AuditEntry) (int64, error)
+	ListAnonymizations(ctx context.Context, tenantID, userID uuid.UUID) ([]model.Anonymization, error)
+}
+
+type postgresAnonymizationRepo struct {
+	db *sql.DB
+}
+
+func NewAnonymizationRepository(db *sql.DB) AnonymizationRepository {
+	return &postgresAnonymizationRepo{db: db}
+}
+
+// AnonymizeUser replaces a.UserID by a.AnonymousID on every subscription
+// of the tenant, deleted ones included, clears their metadata and removes
+// what only concerns the person. Within the same transaction it records entry, whose CreatedAt
+// becomes a.AnonymizedAt, and, with keepMapping, a in user_anonymizations.
+// It returns the number of live subscriptions detached, each of which
+// publishes an "updated" change.
+func (r *postgresAnonymizationRepo) AnonymizeUser(ctx context.Context, tenantID uuid.UUID, a *model.Anonymization, keepMapping bool, entry *model.AuditEntry) (int64, error) {
+	const op = "repository.postgresql.anonymize.AnonymizeUser"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		WITH changed AS (
+			UPDATE subscriptions 
+			SET 
+				user_id = $3, 
+				metadata = NULL, 
+				version = version + 1 
+			WHERE 
+				tenant_id = $1 AND user_id = $2 
+			RETURNING id, tenant_id, user_id, deleted_at
+		)` + notifyChanged(model.EventUpdated) + ` 
+		WHERE 
+			deleted_at IS NULL`
+
+	result, err := tx.ExecContext(ctx, query, tenantID, a.UserID, a.AnonymousID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: subscriptions: %w", op, err)
+	}
+	detached, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	// The reminders of the detached subscriptions are dropped, and what
+	// only concerns the person, their pins, shares, limits and cost alerts,
+	// whose webhook would go on reporting their spend, is deleted.
+	// Their audit trail follows the subscriptions.
+	cleanup := []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{"reminders", `
+			DELETE FROM reminders 
+			WHERE 
+				subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $1 AND user_id = $2)`,
+			[]any{tenantID, a.AnonymousID}},
+		{"pins", `
+			DELETE FROM pinned_subscriptions p 
+			USING subscriptions s 
+			WHERE 
+				p.subscription_id = s.id AND s.tenant_id = $1 AND p.user_id = $2`,
+			[]any{tenantID, a.UserID}},
+		{"shares", `
+			DELETE FROM subscription_shares sh 
+			USING subscriptions s 
+			WHERE 
+				sh.subscription_id = s.id AND s.tenant_id = $1 AND sh.shared_with_user_id = $2`,
+			[]any{tenantID, a.UserID}},
+		{"spending limit", `DELETE FROM spending_limits WHERE tenant_id = $1 AND user_id = $2`, []any{tenantID, a.UserID}},
+		{"user limit", `DELETE FROM user_limits WHERE tenant_id = $1 AND user_id = $2`, []any{tenantID, a.UserID}},
+		{"cost alerts", `DELETE FROM cost_alert_configs WHERE tenant_id = $1 AND user_id = $2`, []any{tenantID, a.UserID}},
+		{"audit trail", `UPDATE audit_log SET user_id = $3 WHERE tenant_id = $1 AND user_id = $2`, []any{tenantID, a.UserID, a.AnonymousID}},
+	}
+	for _, stmt := range cleanup {
+		if _, err := tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return 0, fmt.Errorf("%s: %s: %w", op, stmt.name, err)
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, insertAuditQuery, tenantID, entry.UserID, entry.Actor, entry.Action, entry.RequestID).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: audit: %w", op, err)
+	}
+	a.AnonymizedAt = entry.CreatedAt
+
+	if keepMapping {
+		query = `
+			INSERT INTO user_anonymizations 
+				(tenant_id, anonymous_id, user_id, anonymized_at) 
+			VALUES 
+				($1, $2, $3, $4)`
+
+		if _, err := tx.ExecContext(ctx, query, tenantID, a.AnonymousID, a.UserID, a.AnonymizedAt); err != nil {
+			return 0, fmt.Errorf("%s: mapping: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return detached, nil
+}
+
+// ListAnonymizations returns the recorded anonymizations of userID, oldest
+// first; irreversible ones are not among them.
+func (r *postgresAnonymizationRepo) ListAnonymizations(ctx context.Context, tenantID, userID uuid.UUID) ([]model.Anonymization, error) {
+	const op = "repository.postgresql.anonymize.ListAnonymizations"
+
+	query := `
+		SELECT 
+			user_id, anonymous_id, anonymized_at 
+		FROM 
+			user_anonymizations 
+		WHERE 
+			tenant_id = $1 AND user_id = $2 
+		ORDER BY 
+			anonymized_at`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var list []model.Anonymization
+	for rows.Next() {
+		var a model.Anonymization
+		if err := rows.Scan(&a.UserID, &a.AnonymousID, &a.AnonymizedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan anonymization: %w", op, err)
+		}
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestAnonymizationRepo(t *testing.T) (AnonymizationRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewAnonymizationRepository(db), mock
+}
+
+// expectAnonymizeUpTo expects AnonymizeUser's statements up to and
+// including the audit entry.
+func expectAnonymizeUpTo(mock sqlmock.Sqlmock, a *model.Anonymization) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions SET user_id = $3, metadata = NULL, version = version + 1 WHERE tenant_id = $1 AND user_id = $2 RETURNING id, tenant_id, user_id, deleted_at )`)).
+		WithArgs(testTenantID, a.UserID, a.AnonymousID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM reminders`)).
+		WithArgs(testTenantID, a.AnonymousID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM pinned_subscriptions`)).
+		WithArgs(testTenantID, a.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM subscription_shares`)).
+		WithArgs(testTenantID, a.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM spending_limits WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(testTenantID, a.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_limits WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(testTenantID, a.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM cost_alert_configs WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(testTenantID, a.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE audit_log SET user_id = $3 WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(testTenantID, a.UserID, a.AnonymousID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(testTenantID, a.AnonymousID, "admin", "user_anonymize", "req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(18, fixedTime()))
+}
+
+func newTestAnonymization() (*model.Anonymization, *model.AuditEntry) {
+	a := &model.Anonymization{UserID: uuid.New(), AnonymousID: uuid.New()}
+	entry := &model.AuditEntry{UserID: a.AnonymousID, Actor: model.AuditActorAdmin, Action: model.AuditActionAnonymize, RequestID: "req-1"}
+	return a, entry
+}
+
+func TestAnonymizeUser_KeepsMapping(t *testing.T) {
+	repo, mock := newTestAnonymizationRepo(t)
+	a, entry := newTestAnonymization()
+
+	expectAnonymizeUpTo(mock, a)
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO user_anonymizations (tenant_id, anonymous_id, user_id, anonymized_at) VALUES ($1, $2, $3, $4)`)).
+		WithArgs(testTenantID, a.AnonymousID, a.UserID, fixedTime()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	detached, err := repo.AnonymizeUser(context.Background(), testTenantID, a, true, entry)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), detached)
+	assert.Equal(t, fixedTime(), a.AnonymizedAt)
+	assert.Equal(t, int64(18), entry.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnonymizeUser_Irreversible(t *testing.T) {
+	repo, mock := newTestAnonymizationRepo(t)
+	a, entry := newTestAnonymization()
+
+	expectAnonymizeUpTo(mock, a)
+	mock.ExpectCommit()
+
+	_, err := repo.AnonymizeUser(context.Background(), testTenantID, a, false, entry)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "no mapping is written")
+}
+
+func TestAnonymizeUser_RollsBackOnError(t *testing.T) {
+	repo, mock := newTestAnonymizationRepo(t)
+	a, entry := newTestAnonymization()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions`)).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM reminders`)).WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	_, err := repo.AnonymizeUser(context.Background(), testTenantID, a, true, entry)
+
+	assert.ErrorContains(t, err, "reminders")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAnonymizations(t *testing.T) {
+	repo, mock := newTestAnonymizationRepo(t)
+	userID, anonID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM user_anonymizations WHERE tenant_id = $1 AND user_id = $2 ORDER BY anonymized_at`)).
+		WithArgs(testTenantID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "anonymous_id", "anonymized_at"}).AddRow(userID, anonID, fixedTime()))
+
+	list, err := repo.ListAnonymizations(context.Background(), testTenantID, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.Anonymization{{UserID: userID, AnonymousID: anonID, AnonymizedAt: fixedTime()}}, list)
+}
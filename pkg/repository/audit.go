@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// AuditRepository keeps the audit trail of the users of tenantID.
+type AuditRepository interface {
+	Record(ctx context.Context, tenantID uuid.UUID, entry *model.AuditEntry) error
+	StreamByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.AuditEntry) error) error
+}
+
+// insertAuditQuery stores one completed audit entry for the transactions
+// that record their own entry.
+const insertAuditQuery = `
+		INSERT INTO audit_log 
+			(tenant_id, user_id, actor, action, request_id) 
+		VALUES 
+			($1, $2, $3, $4, $5) 
+		RETURNING id, created_at`
+
+type postgresAuditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &postgresAuditRepo{db: db}
+}
+
+// Record stores entry, as completed unless its Status says otherwise, and
+// fills in its ID and CreatedAt.
+func (r *postgresAuditRepo) Record(ctx context.Context, tenantID uuid.UUID, entry *model.AuditEntry) error {
+	const op = "repository.postgresql.audit.Record"
+
+	if entry.Status == "" {
+		entry.Status = model.AuditStatusCompleted
+	}
+
+	query := `
+		INSERT INTO audit_log 
+			(tenant_id, user_id, actor, action, request_id, status) 
+		VALUES 
+			($1, $2, $3, $4, $5, $6) 
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, tenantID, entry.UserID, entry.Actor, entry.Action, entry.RequestID, entry.Status).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// StreamByUser passes the user's audit trail to fn, oldest first, as it is
+// read. An error from fn stops the stream and is returned as is.
+func (r *postgresAuditRepo) StreamByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.AuditEntry) error) error {
+	const op = "repository.postgresql.audit.StreamByUser"
+
+	query := `
+		SELECT 
+			id, user_id, actor, action, request_id, status, created_at 
+		FROM 
+			audit_log 
+		WHERE 
+			tenant_id = $1 AND user_id = $2 
+		ORDER BY 
+			created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Actor, &e.Action, &e.RequestID, &e.Status, &e.CreatedAt); err != nil {
+			return fmt.Errorf("%s: failed to scan audit entry: %w", op, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return nil
+}
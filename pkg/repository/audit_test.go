@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestAuditRepo(t *testing.T) (AuditRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewAuditRepository(db), mock
+}
+
+func TestAuditRecord(t *testing.T) {
+	repo, mock := newTestAuditRepo(t)
+	entry := &model.AuditEntry{UserID: uuid.New(), Actor: model.AuditActorUser, Action: model.AuditActionExport, RequestID: "req-1"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO audit_log (tenant_id, user_id, actor, action, request_id, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`)).
+		WithArgs(testTenantID, entry.UserID, "user", "user_data_export", "req-1", "completed").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(17, fixedTime()))
+
+	require.NoError(t, repo.Record(context.Background(), testTenantID, entry))
+	assert.Equal(t, int64(17), entry.ID)
+	assert.Equal(t, fixedTime(), entry.CreatedAt)
+	assert.Equal(t, model.AuditStatusCompleted, entry.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditRecord_Failed(t *testing.T) {
+	repo, mock := newTestAuditRepo(t)
+	entry := &model.AuditEntry{UserID: uuid.New(), Actor: model.AuditActorAdmin, Action: model.AuditActionExport, Status: model.AuditStatusFailed}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(testTenantID, entry.UserID, "admin", "user_data_export", "", "failed").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(18, fixedTime()))
+
+	require.NoError(t, repo.Record(context.Background(), testTenantID, entry))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditStreamByUser(t *testing.T) {
+	repo, mock := newTestAuditRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE tenant_id = $1 AND user_id = $2 ORDER BY created_at, id`)).
+		WithArgs(testTenantID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "actor", "action", "request_id", "status", "created_at"}).
+			AddRow(17, userID, "admin", "user_data_export", "", "failed", fixedTime()))
+
+	var entries []model.AuditEntry
+	err := repo.StreamByUser(context.Background(), testTenantID, userID, func(e model.AuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.AuditEntry{{
+		ID: 17, UserID: userID, Actor: "admin", Action: "user_data_export", Status: "failed", CreatedAt: fixedTime(),
+	}}, entries)
+}
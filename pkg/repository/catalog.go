@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// CatalogRepository stores the service catalog of each tenant. Entry
+// names are unique within a tenant; a duplicate is model.ErrConflict.
+type CatalogRepository interface {
+	Create(ctx context.Context, tenantID uuid.UUID, entry *model.CatalogEntry) error
+	Get(ctx context.Context, tenantID, id uuid.UUID) (*model.CatalogEntry, error)
+	// List returns the entries ordered by name, only those in category
+	// when it is not empty.
+	List(ctx context.Context, tenantID uuid.UUID, category string) ([]model.CatalogEntry, error)
+	Update(ctx context.Context, tenantID uuid.UUID, entry *model.CatalogEntry) error
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+}
+
+type postgresCatalogRepo struct {
+	db *sql.DB
+}
+
+func NewCatalogRepository(db *sql.DB) CatalogRepository {
+	return &postgresCatalogRepo{db: db}
+}
+
+func (r *postgresCatalogRepo) Create(ctx context.Context, tenantID uuid.UUID, entry *model.CatalogEntry) error {
+	const op = "repository.postgresql.catalog.Create"
+
+	query := `
+		INSERT INTO service_catalog 
+			(id, tenant_id, name, description, category, logo_url, default_price) 
+		VALUES 
+			($1, $2, $3, $4, $5, $6, $7) 
+		RETURNING 
+			created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		entry.ID,
+		tenantID,
+		entry.Name,
+		entry.Description,
+		entry.Category,
+		entry.LogoURL,
+		entry.DefaultPrice,
+	).Scan(&entry.CreatedAt, &entry.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, classifyError(err))
+	}
+
+	return nil
+}
+
+func (r *postgresCatalogRepo) Get(ctx context.Context, tenantID, id uuid.UUID) (*model.CatalogEntry, error) {
+	const op = "repository.postgresql.catalog.Get"
+
+	query := `
+		SELECT 
+			id, name, description, category, logo_url, default_price, created_at, updated_at 
+		FROM 
+			service_catalog 
+		WHERE 
+			id = $1 AND tenant_id = $2`
+
+	entry, err := scanCatalogEntry(r.db.QueryRowContext(ctx, query, id, tenantID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+func (r *postgresCatalogRepo) List(ctx context.Context, tenantID uuid.UUID, category string) ([]model.CatalogEntry, error) {
+	const op = "repository.postgresql.catalog.List"
+
+	query := `
+		SELECT 
+			id, name, description, category, logo_url, default_price, created_at, updated_at 
+		FROM 
+			service_catalog 
+		WHERE 
+			tenant_id = $1 AND ($2 = '' OR category = $2) 
+		ORDER BY 
+			name`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, category)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var entries []model.CatalogEntry
+	for rows.Next() {
+		entry, err := scanCatalogEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan catalog entry: %w", op, err)
+		}
+		entries = append(entries, *entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+// Update replaces every field of the entry but its ID and creation time.
+// Subscriptions already created from it keep their name and price.
+func (r *postgresCatalogRepo) Update(ctx context.Context, tenantID uuid.UUID, entry *model.CatalogEntry) error {
+	const op = "repository.postgresql.catalog.Update"
+
+	query := `
+		UPDATE service_catalog 
+		SET 
+			name = $3, 
+			description = $4, 
+			category = $5, 
+			logo_url = $6, 
+			default_price = $7, 
+			updated_at = NOW() 
+		WHERE 
+			id = $1 AND tenant_id = $2 
+		RETURNING 
+			created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		entry.ID,
+		tenantID,
+		entry.Name,
+		entry.Description,
+		entry.Category,
+		entry.LogoURL,
+		entry.DefaultPrice,
+	).Scan(&entry.CreatedAt, &entry.UpdatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, classifyError(err))
+	}
+
+	return nil
+}
+
+// Delete removes the entry; subscriptions created from it lose the
+// reference but are otherwise unchanged.
+func (r *postgresCatalogRepo) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	const op = "repository.postgresql.catalog.Delete"
+
+	query := `DELETE FROM service_catalog WHERE id = $1 AND tenant_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCatalogEntry(row rowScanner) (*model.CatalogEntry, error) {
+	var entry model.CatalogEntry
+	err := row.Scan(
+		&entry.ID,
+		&entry.Name,
+		&entry.Description,
+		&entry.Category,
+		&entry.LogoURL,
+		&entry.DefaultPrice,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
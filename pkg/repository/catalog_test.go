@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+var catalogColumns = []string{"id", "name", "description", "category", "logo_url", "default_price", "created_at", "updated_at"}
+
+func newTestCatalogRepo(t *testing.T) (CatalogRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewCatalogRepository(db), mock
+}
+
+func TestCatalogCreate_DuplicateNameIsConflict(t *testing.T) {
+	repo, mock := newTestCatalogRepo(t)
+	entry := &model.CatalogEntry{ID: uuid.New(), Name: "netflix", Category: "streaming", DefaultPrice: 999}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO service_catalog`)).
+		WithArgs(entry.ID, testTenantID, "netflix", "", "streaming", "", 999).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+
+	err := repo.Create(context.Background(), testTenantID, entry)
+
+	assert.ErrorIs(t, err, model.ErrConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogGet_NotFound(t *testing.T) {
+	repo, mock := newTestCatalogRepo(t)
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM service_catalog WHERE id = $1 AND tenant_id = $2`)).
+		WithArgs(id, testTenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.Get(context.Background(), testTenantID, id)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogList_FiltersByCategory(t *testing.T) {
+	repo, mock := newTestCatalogRepo(t)
+	now := time.Now()
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE tenant_id = $1 AND ($2 = '' OR category = $2) ORDER BY name`)).
+		WithArgs(testTenantID, "streaming").
+		WillReturnRows(sqlmock.NewRows(catalogColumns).
+			AddRow(id, "netflix", "", "streaming", "", 999, now, now))
+
+	entries, err := repo.List(context.Background(), testTenantID, "streaming")
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.CatalogEntry{{
+		ID:           id,
+		Name:         "netflix",
+		Category:     "streaming",
+		DefaultPrice: 999,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}}, entries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogUpdate_NotFound(t *testing.T) {
+	repo, mock := newTestCatalogRepo(t)
+	entry := &model.CatalogEntry{ID: uuid.New(), Name: "netflix", DefaultPrice: 999}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE service_catalog`)).
+		WithArgs(entry.ID, testTenantID, "netflix", "", "", "", 999).
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.Update(context.Background(), testTenantID, entry)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogDelete_NotFound(t *testing.T) {
+	repo, mock := newTestCatalogRepo(t)
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM service_catalog WHERE id = $1 AND tenant_id = $2`)).
+		WithArgs(id, testTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete(context.Background(), testTenantID, id)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_StoresCatalogServiceID(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	catalogID := uuid.New()
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID, CatalogServiceID: &catalogID}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).
+		WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.BillingCycle, testTenantID, nil, catalogID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Create(context.Background(), sub))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"SubscriptionAggregator/pkg/circuitbreaker"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// CircuitBreakerRepository guards another SubscriptionRepository with a
+// circuit breaker: once the database has failed enough calls in a row,
+// calls return circuitbreaker.ErrCircuitOpen at once instead of waiting
+// for a pool connection that will not come.
+type CircuitBreakerRepository struct {
+	next    SubscriptionRepository
+	breaker *circuitbreaker.Breaker
+}
+
+func NewCircuitBreakerRepository(next SubscriptionRepository, breaker *circuitbreaker.Breaker) *CircuitBreakerRepository {
+	return &CircuitBreakerRepository{next: next, breaker: breaker}
+}
+
+// SQLSTATE classes that mean the database, not the query, is in trouble.
+const (
+	connectionException   = "08"
+	insufficientResources = "53"
+	operatorIntervention  = "57"
+	systemError           = "58"
+)
+
+// isOutage tells a database that is unavailable from one that answered
+// with an error. Not found, constraint violations and any other error the
+// server reports about the query itself prove it is up. A call whose
+// caller went away says nothing either way and is not counted.
+func isOutage(ctx context.Context, err error) (outage, counted bool) {
+	if err == nil || errors.Is(err, model.ErrNotFound) ||
+		errors.Is(err, model.ErrConflict) || errors.Is(err, model.ErrVersionConflict) ||
+		errors.Is(err, model.ErrInvalidReference) {
+		return false, true
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false, false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case connectionException, insufficientResources, operatorIntervention, systemError:
+			return true, true
+		}
+		return false, true
+	}
+	return true, true
+}
+
+// guard runs call if the breaker allows it and reports the outcome.
+func guard[T any](ctx context.Context, b *circuitbreaker.Breaker, call func() (T, error)) (T, error) {
+	if err := b.Allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	v, err := call()
+	switch outage, counted := isOutage(ctx, err); {
+	case !counted:
+	case outage:
+		b.Failure()
+	default:
+		b.Success()
+	}
+	return v, err
+}
+
+func (r *CircuitBreakerRepository) do(ctx context.Context, call func() error) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+// stream guards a call that hands rows to fn. An error from fn is the
+// caller's, such as a client that went away, and proves nothing about the
+// database, which answered until then.
+func stream[T any](ctx context.Context, r *CircuitBreakerRepository, fn func(T) error, call func(fn func(T) error) error) error {
+	var fnErr, callErr error
+	err := r.do(ctx, func() error {
+		callErr = call(func(v T) error {
+			fnErr = fn(v)
+			return fnErr
+		})
+		if fnErr != nil {
+			return nil
+		}
+		return callErr
+	})
+	if err != nil {
+		return err
+	}
+	return callErr
+}
+
+func (r *CircuitBreakerRepository) Create(ctx context.Context, sub *model.Subscription) error {
+	return r.do(ctx, func() error { return r.next.Create(ctx, sub) })
+}
+
+func (r *CircuitBreakerRepository) BulkCreate(ctx context.Context, subs []*model.Subscription) error {
+	return r.do(ctx, func() error { return r.next.BulkCreate(ctx, subs) })
+}
+
+func (r *CircuitBreakerRepository) ExistsActiveOverlap(ctx context.Context, tenantID, userID uuid.UUID, serviceName string, start time.Time, end *time.Time) (*uuid.UUID, error) {
+	return guard(ctx, r.breaker, func() (*uuid.UUID, error) {
+		return r.next.ExistsActiveOverlap(ctx, tenantID, userID, serviceName, start, end)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+	return guard(ctx, r.breaker, func() (*model.Subscription, error) { return r.next.GetByID(ctx, tenantID, id) })
+}
+
+func (r *CircuitBreakerRepository) GetByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*model.Subscription, error) {
+	return guard(ctx, r.breaker, func() ([]*model.Subscription, error) { return r.next.GetByIDs(ctx, tenantID, ids) })
+}
+
+func (r *CircuitBreakerRepository) GetPriceHistory(ctx context.Context, tenantID, id uuid.UUID) ([]int, error) {
+	return guard(ctx, r.breaker, func() ([]int, error) { return r.next.GetPriceHistory(ctx, tenantID, id) })
+}
+
+func (r *CircuitBreakerRepository) StreamPriceHistoryByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.PriceChange) error) error {
+	return stream(ctx, r, fn, func(fn func(model.PriceChange) error) error {
+		return r.next.StreamPriceHistoryByUser(ctx, tenantID, userID, fn)
+	})
+}
+
+func (r *CircuitBreakerRepository) RecordPayment(ctx context.Context, tenantID uuid.UUID, payment *model.Payment) error {
+	return r.do(ctx, func() error { return r.next.RecordPayment(ctx, tenantID, payment) })
+}
+
+func (r *CircuitBreakerRepository) ListPayments(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Payment, error) {
+	return guard(ctx, r.breaker, func() ([]model.Payment, error) { return r.next.ListPayments(ctx, tenantID, subscriptionID) })
+}
+
+func (r *CircuitBreakerRepository) LockSubscription(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+	return guard(ctx, r.breaker, func() (*model.Subscription, error) { return r.next.LockSubscription(ctx, tenantID, id) })
+}
+
+func (r *CircuitBreakerRepository) Update(ctx context.Context, sub *model.Subscription) error {
+	return r.do(ctx, func() error { return r.next.Update(ctx, sub) })
+}
+
+func (r *CircuitBreakerRepository) NotifyPriceChanged(ctx context.Context, tenantID, id uuid.UUID, oldPrice, newPrice int) error {
+	return r.do(ctx, func() error { return r.next.NotifyPriceChanged(ctx, tenantID, id, oldPrice, newPrice) })
+}
+
+func (r *CircuitBreakerRepository) UpdateServicePrice(ctx context.Context, update model.ServicePriceUpdate) (*model.ServicePriceUpdateResult, error) {
+	return guard(ctx, r.breaker, func() (*model.ServicePriceUpdateResult, error) {
+		return r.next.UpdateServicePrice(ctx, update)
+	})
+}
+
+func (r *CircuitBreakerRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.do(ctx, func() error { return r.next.Delete(ctx, tenantID, id) })
+}
+
+func (r *CircuitBreakerRepository) List(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error) {
+	return guard(ctx, r.breaker, func() (*model.ListResult, error) { return r.next.List(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) StreamUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID, fn func(*model.Subscription) error) error {
+	return stream(ctx, r, fn, func(fn func(*model.Subscription) error) error {
+		return r.next.StreamUserSubscriptions(ctx, tenantID, userID, fn)
+	})
+}
+
+func (r *CircuitBreakerRepository) GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.GetTotalCost(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) Count(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.Count(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) GetUserLimit(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.GetUserLimit(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) LockUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return r.do(ctx, func() error { return r.next.LockUserSubscriptions(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) GetPaidTotal(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.GetPaidTotal(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) GetProratedTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.GetProratedTotalCost(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) GetProratedCostPerUser(ctx context.Context, filter model.SubscriptionFilter) (map[uuid.UUID]int, error) {
+	return guard(ctx, r.breaker, func() (map[uuid.UUID]int, error) { return r.next.GetProratedCostPerUser(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error) {
+	return guard(ctx, r.breaker, func() ([]model.MonthlyCost, error) { return r.next.GetMonthlyCost(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) ListServices(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.ServiceSummary, error) {
+	return guard(ctx, r.breaker, func() ([]*model.ServiceSummary, error) { return r.next.ListServices(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) ShareSubscription(ctx context.Context, tenantID uuid.UUID, share *model.ShareEntry) error {
+	return r.do(ctx, func() error { return r.next.ShareSubscription(ctx, tenantID, share) })
+}
+
+func (r *CircuitBreakerRepository) UnshareSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error {
+	return r.do(ctx, func() error { return r.next.UnshareSubscription(ctx, tenantID, subscriptionID, userID) })
+}
+
+func (r *CircuitBreakerRepository) CreateShares(ctx context.Context, tenantID uuid.UUID, shares []model.ShareEntry) error {
+	return r.do(ctx, func() error { return r.next.CreateShares(ctx, tenantID, shares) })
+}
+
+func (r *CircuitBreakerRepository) GetSharedUsers(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.ShareEntry, error) {
+	return guard(ctx, r.breaker, func() ([]model.ShareEntry, error) { return r.next.GetSharedUsers(ctx, tenantID, subscriptionID) })
+}
+
+func (r *CircuitBreakerRepository) PinSubscription(ctx context.Context, tenantID uuid.UUID, pin *model.Pin) error {
+	return r.do(ctx, func() error { return r.next.PinSubscription(ctx, tenantID, pin) })
+}
+
+func (r *CircuitBreakerRepository) UnpinSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error {
+	return r.do(ctx, func() error { return r.next.UnpinSubscription(ctx, tenantID, subscriptionID, userID) })
+}
+
+func (r *CircuitBreakerRepository) ListExpired(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+	return guard(ctx, r.breaker, func() ([]*model.Subscription, error) { return r.next.ListExpired(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) SoftDeleteExpired(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.SoftDeleteExpired(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error) {
+	return guard(ctx, r.breaker, func() ([]model.BillingCycleSummary, error) { return r.next.GetCostByCycle(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error) {
+	return guard(ctx, r.breaker, func() (*model.PriceStats, error) { return r.next.GetPriceStats(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) GetCostByService(ctx context.Context, filter model.SubscriptionFilter) ([]model.ServiceCost, error) {
+	return guard(ctx, r.breaker, func() ([]model.ServiceCost, error) { return r.next.GetCostByService(ctx, filter) })
+}
+
+func (r *CircuitBreakerRepository) ListExpiringSoonByService(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error) {
+	return guard(ctx, r.breaker, func() ([]model.ExpiringServiceSummary, error) {
+		return r.next.ListExpiringSoonByService(ctx, tenantID, userID, days)
+	})
+}
+
+func (r *CircuitBreakerRepository) CountByStatus(ctx context.Context, tenantID, userID uuid.UUID) (active, expired int, err error) {
+	err = r.do(ctx, func() error {
+		var err error
+		active, expired, err = r.next.CountByStatus(ctx, tenantID, userID)
+		return err
+	})
+	return active, expired, err
+}
+
+func (r *CircuitBreakerRepository) GetActiveCostByCycle(ctx context.Context, tenantID, userID uuid.UUID) ([]model.BillingCycleSummary, error) {
+	return guard(ctx, r.breaker, func() ([]model.BillingCycleSummary, error) { return r.next.GetActiveCostByCycle(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) GetMostExpensiveActive(ctx context.Context, tenantID, userID uuid.UUID) (string, error) {
+	return guard(ctx, r.breaker, func() (string, error) { return r.next.GetMostExpensiveActive(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) GetNextExpiry(ctx context.Context, tenantID, userID uuid.UUID) (*time.Time, error) {
+	return guard(ctx, r.breaker, func() (*time.Time, error) { return r.next.GetNextExpiry(ctx, tenantID, userID) })
+}
+
+func (r *CircuitBreakerRepository) CountCreatedBetween(ctx context.Context, from, to time.Time) (int, error) {
+	return guard(ctx, r.breaker, func() (int, error) { return r.next.CountCreatedBetween(ctx, from, to) })
+}
+
+func (r *CircuitBreakerRepository) GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error) {
+	return guard(ctx, r.breaker, func() (*model.UserCostResult, error) {
+		return r.next.GetTotalCostByUser(ctx, filter, limit, offset)
+	})
+}
+
+func (r *CircuitBreakerRepository) Transactional(ctx context.Context) (txCtx context.Context, commit CommitFunc, rollback RollbackFunc, err error) {
+	err = r.do(ctx, func() error {
+		var err error
+		txCtx, commit, rollback, err = r.next.Transactional(ctx)
+		return err
+	})
+	return txCtx, commit, rollback, err
+}
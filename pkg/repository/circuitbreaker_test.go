@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/circuitbreaker"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// stubRepo answers GetByID and StreamPriceHistoryByUser with err and
+// counts the calls; every other method panics through the nil embedded
+// interface.
+type stubRepo struct {
+	SubscriptionRepository
+	err   error
+	calls int
+}
+
+func (s *stubRepo) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Subscription{ID: id, TenantID: tenantID}, nil
+}
+
+// StreamPriceHistoryByUser hands fn one row before failing with err.
+func (s *stubRepo) StreamPriceHistoryByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.PriceChange) error) error {
+	s.calls++
+	if err := fn(model.PriceChange{Price: 599}); err != nil {
+		return err
+	}
+	return s.err
+}
+
+func TestCircuitBreakerRepository_OpensOnOutage(t *testing.T) {
+	stub := &stubRepo{err: errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")}
+	repo := NewCircuitBreakerRepository(stub, circuitbreaker.New(2, time.Minute))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetByID(ctx, testTenantID, uuid.New())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+	}
+
+	_, err := repo.GetByID(ctx, testTenantID, uuid.New())
+
+	assert.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+	assert.Equal(t, 2, stub.calls, "an open circuit does not call through")
+}
+
+func TestCircuitBreakerRepository_ProbeClosesCircuit(t *testing.T) {
+	stub := &stubRepo{err: errors.New("connection refused")}
+	repo := NewCircuitBreakerRepository(stub, circuitbreaker.New(1, 10*time.Millisecond))
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, testTenantID, uuid.New())
+	require.Error(t, err)
+	_, err = repo.GetByID(ctx, testTenantID, uuid.New())
+	require.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+
+	time.Sleep(20 * time.Millisecond)
+	stub.err = nil
+	id := uuid.New()
+	sub, err := repo.GetByID(ctx, testTenantID, id)
+
+	require.NoError(t, err)
+	assert.Equal(t, id, sub.ID)
+	assert.Equal(t, circuitbreaker.StateClosed, repo.breaker.State())
+}
+
+func TestCircuitBreakerRepository_AnswersDoNotTrip(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{"not found", context.Background(), fmt.Errorf("op: %w", model.ErrNotFound)},
+		{"conflict", context.Background(), fmt.Errorf("op: %w", model.ErrConflict)},
+		{"stale version", context.Background(), fmt.Errorf("op: %w", &model.VersionConflictError{Current: 2})},
+		{"query error", context.Background(), &pq.Error{Code: "22P02"}},
+		{"caller went away", canceled, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubRepo{err: tt.err}
+			repo := NewCircuitBreakerRepository(stub, circuitbreaker.New(1, time.Minute))
+
+			for i := 0; i < 3; i++ {
+				_, err := repo.GetByID(tt.ctx, testTenantID, uuid.New())
+				assert.ErrorIs(t, err, tt.err)
+			}
+
+			assert.Equal(t, circuitbreaker.StateClosed, repo.breaker.State())
+			assert.Equal(t, 3, stub.calls)
+		})
+	}
+}
+
+func TestCircuitBreakerRepository_ServerShutdownTrips(t *testing.T) {
+	stub := &stubRepo{err: &pq.Error{Code: "57P01", Message: "terminating connection due to administrator command"}}
+	repo := NewCircuitBreakerRepository(stub, circuitbreaker.New(1, time.Minute))
+
+	_, err := repo.GetByID(context.Background(), testTenantID, uuid.New())
+	require.Error(t, err)
+
+	assert.Equal(t, circuitbreaker.StateOpen, repo.breaker.State())
+}
+
+func TestCircuitBreakerRepository_StreamCallbackErrorsDoNotTrip(t *testing.T) {
+	stub := &stubRepo{}
+	repo := NewCircuitBreakerRepository(stub, circuitbreaker.New(1, time.Minute))
+	broken := errors.New("write: broken pipe")
+
+	for i := 0; i < 3; i++ {
+		err := repo.StreamPriceHistoryByUser(context.Background(), testTenantID, uuid.New(), func(model.PriceChange) error {
+			return broken
+		})
+		assert.Same(t, broken, err)
+	}
+
+	assert.Equal(t, 3, stub.calls)
+	assert.Equal(t, circuitbreaker.StateClosed, repo.breaker.State())
+}
+
+func TestCircuitBreakerRepository_StreamOutageTrips(t *testing.T) {
+	stub := &stubRepo{err: errors.New("connection refused")}
+	repo := NewCircuitBreakerRepository(stub, circuitbreaker.New(1, time.Minute))
+	keep := func(model.PriceChange) error { return nil }
+
+	err := repo.StreamPriceHistoryByUser(context.Background(), testTenantID, uuid.New(), keep)
+	require.ErrorContains(t, err, "connection refused")
+
+	err = repo.StreamPriceHistoryByUser(context.Background(), testTenantID, uuid.New(), keep)
+	assert.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+	assert.Equal(t, 1, stub.calls)
+}
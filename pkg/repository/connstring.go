@@ -0,0 +1,27 @@
+package repository
+
+import "regexp"
+
+var (
+	// kvPassword matches password=value in a key/value connection string,
+	// where value is either single-quoted with backslash escapes or runs
+	// up to the next space. As in libpq, spaces around = are skipped.
+	kvPassword = regexp.MustCompile(`(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s']\S*)`)
+	// urlPassword matches the password in the userinfo of a URL. Like
+	// net/url, the userinfo runs up to the last @ of the authority.
+	urlPassword = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*://[^:/?#@]*:)[^/?#]*@`)
+	// urlQueryPassword matches a password passed as a URL query parameter.
+	urlQueryPassword = regexp.MustCompile(`([?&]password=)[^&#]*`)
+)
+
+// MaskedConnString returns connString with its password replaced by ***,
+// so the result can go into logs and error messages. Both key/value
+// strings (host=db password=secret) and URLs
+// (postgres://user:secret@db/name) are understood.
+func MaskedConnString(connString string) string {
+	if urlPassword.MatchString(connString) || urlQueryPassword.MatchString(connString) {
+		masked := urlPassword.ReplaceAllString(connString, "${1}***@")
+		return urlQueryPassword.ReplaceAllString(masked, "${1}***")
+	}
+	return kvPassword.ReplaceAllString(connString, "${1}***")
+}
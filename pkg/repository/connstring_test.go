@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskedConnString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"key/value",
+			"host=db port=5432 user=app password=secret dbname=subs sslmode=disable",
+			"host=db port=5432 user=app password=*** dbname=subs sslmode=disable",
+		},
+		{
+			"key/value with special characters",
+			"host=db password=p@ss:w/rd#1=2&x dbname=subs",
+			"host=db password=*** dbname=subs",
+		},
+		{
+			"quoted with spaces and an escaped quote",
+			`host=db password='it\'s a s3cret' dbname=subs`,
+			"host=db password=*** dbname=subs",
+		},
+		{
+			"spaces around the equals sign",
+			"user=app password = secret dbname=subs",
+			"user=app password = *** dbname=subs",
+		},
+		{
+			"empty password",
+			"user=app password='' dbname=subs",
+			"user=app password=*** dbname=subs",
+		},
+		{
+			"password last",
+			"host=db password=secret",
+			"host=db password=***",
+		},
+		{
+			"URL",
+			"postgres://app:secret@db:5432/subs?sslmode=disable",
+			"postgres://app:***@db:5432/subs?sslmode=disable",
+		},
+		{
+			"URL with an escaped password",
+			"postgresql://app:p%40ss%2Fw@db/subs",
+			"postgresql://app:***@db/subs",
+		},
+		{
+			"URL with @ in the password",
+			"postgres://app:p@ss@db/subs",
+			"postgres://app:***@db/subs",
+		},
+		{
+			"URL with the password in the query",
+			"postgres://app@db/subs?sslmode=disable&password=secret",
+			"postgres://app@db/subs?sslmode=disable&password=***",
+		},
+		{
+			"no password",
+			"host=db user=app dbname=subs",
+			"host=db user=app dbname=subs",
+		},
+		{
+			"URL without a password",
+			"postgres://app@db/subs",
+			"postgres://app@db/subs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MaskedConnString(tt.in))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// CostAlertRepository reads the cost alert configs of a user and throttles
+// their webhooks.
+type CostAlertRepository interface {
+	ListByUser(ctx context.Context, tenantID, userID uuid.UUID) ([]model.CostAlertConfig, error)
+	ClaimTrigger(ctx context.Context, tenantID, id uuid.UUID, at, before time.Time) (bool, error)
+	ResetTrigger(ctx context.Context, tenantID, id uuid.UUID, at time.Time, previous *time.Time) error
+}
+
+type postgresCostAlertRepo struct {
+	db *sql.DB
+}
+
+func NewCostAlertRepository(db *sql.DB) CostAlertRepository {
+	return &postgresCostAlertRepo{db: db}
+}
+
+func (r *postgresCostAlertRepo) ListByUser(ctx context.Context, tenantID, userID uuid.UUID) ([]model.CostAlertConfig, error) {
+	const op = "repository.postgresql.costalert.ListByUser"
+
+	query := `
+		SELECT 
+			id, user_id, threshold_amount, webhook_url, last_triggered_at 
+		FROM 
+			cost_alert_configs 
+		WHERE 
+			tenant_id = $1 AND user_id = $2 
+		ORDER BY 
+			threshold_amount, id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var configs []model.CostAlertConfig
+	for rows.Next() {
+		var c model.CostAlertConfig
+		if err := rows.Scan(&c.ID, &c.UserID, &c.ThresholdAmount, &c.WebhookURL, &c.LastTriggeredAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		configs = append(configs, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return configs, nil
+}
+
+// ClaimTrigger sets the config's last_triggered_at to at, unless it was
+// last triggered at or after before, and reports whether it did. Of two
+// instances seeing the same breach, only one gets to call the webhook.
+func (r *postgresCostAlertRepo) ClaimTrigger(ctx context.Context, tenantID, id uuid.UUID, at, before time.Time) (bool, error) {
+	const op = "repository.postgresql.costalert.ClaimTrigger"
+
+	query := `
+		UPDATE cost_alert_configs 
+		SET 
+			last_triggered_at = $3 
+		WHERE 
+			tenant_id = $1 AND id = $2 
+			AND (last_triggered_at IS NULL OR last_triggered_at < $4)`
+
+	result, err := r.db.ExecContext(ctx, query, tenantID, id, at, before)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ResetTrigger gives back a claim made at at, restoring previous, so that a
+// webhook that could not be called is tried again on the next check. A
+// claim made since by someone else is kept.
+func (r *postgresCostAlertRepo) ResetTrigger(ctx context.Context, tenantID, id uuid.UUID, at time.Time, previous *time.Time) error {
+	const op = "repository.postgresql.costalert.ResetTrigger"
+
+	query := `
+		UPDATE cost_alert_configs 
+		SET 
+			last_triggered_at = $4 
+		WHERE 
+			tenant_id = $1 AND id = $2 AND last_triggered_at = $3`
+
+	if _, err := r.db.ExecContext(ctx, query, tenantID, id, at, previous); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
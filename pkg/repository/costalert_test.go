@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestCostAlertRepo(t *testing.T) (CostAlertRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewCostAlertRepository(db), mock
+}
+
+func TestCostAlertListByUser(t *testing.T) {
+	repo, mock := newTestCostAlertRepo(t)
+	tenantID, userID := uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+	triggered := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM cost_alert_configs WHERE tenant_id = $1 AND user_id = $2 ORDER BY threshold_amount, id`)).
+		WithArgs(tenantID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "threshold_amount", "webhook_url", "last_triggered_at"}).
+			AddRow(first, userID, 1000, "https://hooks.example.com/a", nil).
+			AddRow(second, userID, 5000, "https://hooks.example.com/b", triggered))
+
+	configs, err := repo.ListByUser(context.Background(), tenantID, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.CostAlertConfig{
+		{ID: first, UserID: userID, ThresholdAmount: 1000, WebhookURL: "https://hooks.example.com/a"},
+		{ID: second, UserID: userID, ThresholdAmount: 5000, WebhookURL: "https://hooks.example.com/b", LastTriggeredAt: &triggered},
+	}, configs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCostAlertClaimTrigger(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{"claimed", 1, true},
+		{"within cooldown", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newTestCostAlertRepo(t)
+			tenantID, id := uuid.New(), uuid.New()
+			at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+			mock.ExpectExec(regexp.QuoteMeta(`SET last_triggered_at = $3 WHERE tenant_id = $1 AND id = $2 AND (last_triggered_at IS NULL OR last_triggered_at < $4)`)).
+				WithArgs(tenantID, id, at, at.Add(-time.Hour)).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			claimed, err := repo.ClaimTrigger(context.Background(), tenantID, id, at, at.Add(-time.Hour))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, claimed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCostAlertResetTrigger(t *testing.T) {
+	repo, mock := newTestCostAlertRepo(t)
+	tenantID, id := uuid.New(), uuid.New()
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta(`SET last_triggered_at = $4 WHERE tenant_id = $1 AND id = $2 AND last_triggered_at = $3`)).
+		WithArgs(tenantID, id, at, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.ResetTrigger(context.Background(), tenantID, id, at, nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
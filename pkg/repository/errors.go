@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// SQLSTATE codes for the constraint violations we translate.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// classifyError maps constraint violations to model.ErrConflict and
+// model.ErrInvalidReference so callers can branch on them without knowing
+// the driver. The driver error stays in the chain for logging; any other
+// error is returned unchanged.
+func classifyError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case uniqueViolation:
+		return fmt.Errorf("%w: %w", model.ErrConflict, err)
+	case foreignKeyViolation:
+		return fmt.Errorf("%w: %w", model.ErrInvalidReference, err)
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// A one-day window, as active_on sets, includes the exact start and end
+// dates and nothing outside them.
+func TestIntegration_ActiveOnBoundaries(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	date := func(month time.Month, day int) time.Time { return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC) }
+
+	sub := newIntegrationSubscription(tenantID)
+	sub.StartDate = date(3, 1)
+	end := date(3, 31)
+	sub.EndDate = &end
+	require.NoError(t, repo.Create(ctx, sub))
+
+	for _, tt := range []struct {
+		on   time.Time
+		want bool
+	}{
+		{date(2, 28), false},
+		{date(3, 1), true},
+		{date(3, 31), true},
+		{date(4, 1), false},
+	} {
+		filter := model.SubscriptionFilter{TenantID: &tenantID, FromDate: &tt.on, ToDate: &tt.on}
+		name := tt.on.Format(time.DateOnly)
+
+		list, err := repo.List(ctx, filter)
+		require.NoError(t, err, name)
+		total, err := repo.GetTotalCost(ctx, filter)
+		require.NoError(t, err, name)
+
+		if tt.want {
+			assert.Equal(t, 1, list.TotalCount, name)
+			assert.Equal(t, sub.Price, total, name)
+		} else {
+			assert.Zero(t, list.TotalCount, name)
+			assert.Zero(t, total, name)
+		}
+	}
+}
+
+// FromDate and ToDate select the subscriptions that overlap the window,
+// both ends included; a missing bound leaves that side open. Each case
+// stores one subscription under a tenant of its own.
+func TestIntegration_DateFilterSelectsOverlap(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	date := func(month time.Month, day int) time.Time { return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC) }
+	ptr := func(t time.Time) *time.Time { return &t }
+	from, to := date(3, 1), date(3, 31)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      *time.Time
+		from, to *time.Time
+		want     bool
+	}{
+		{"starts before window, ends inside", date(1, 1), ptr(date(3, 15)), &from, &to, true},
+		{"starts before window, ends after", date(1, 1), ptr(date(6, 1)), &from, &to, true},
+		{"starts before window, open-ended", date(1, 1), nil, &from, &to, true},
+		{"starts inside window, open-ended", date(3, 10), nil, &from, &to, true},
+		{"open-ended, starts after window", date(4, 1), nil, &from, &to, false},
+		{"ends on from_date", date(1, 1), ptr(from), &from, &to, true},
+		{"starts on to_date", to, ptr(date(6, 1)), &from, &to, true},
+		{"fully before window", date(1, 1), ptr(date(2, 28)), &from, &to, false},
+		{"fully after window", date(4, 1), ptr(date(6, 1)), &from, &to, false},
+		{"from_date only, ends on it", date(1, 1), ptr(from), &from, nil, true},
+		{"from_date only, ended before", date(1, 1), ptr(date(2, 28)), &from, nil, false},
+		{"from_date only, starts long after", date(12, 1), nil, &from, nil, true},
+		{"to_date only, starts on it", to, nil, nil, &to, true},
+		{"to_date only, starts after", date(4, 1), nil, nil, &to, false},
+		{"to_date only, ended long before", date(1, 1), ptr(date(1, 31)), nil, &to, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantID := uuid.New()
+			sub := newIntegrationSubscription(tenantID)
+			sub.StartDate = tt.start
+			sub.EndDate = tt.end
+			require.NoError(t, repo.Create(ctx, sub))
+			filter := model.SubscriptionFilter{TenantID: &tenantID, FromDate: tt.from, ToDate: tt.to}
+
+			list, err := repo.List(ctx, filter)
+			require.NoError(t, err)
+			total, err := repo.GetTotalCost(ctx, filter)
+			require.NoError(t, err)
+
+			if tt.want {
+				assert.Equal(t, 1, list.TotalCount)
+				assert.Equal(t, sub.Price, total)
+			} else {
+				assert.Zero(t, list.TotalCount)
+				assert.Zero(t, total)
+			}
+		})
+	}
+}
+
+// OnlyActive drops a subscription that has ended and keeps open-ended ones
+// as well as those that have not started yet.
+func TestIntegration_OnlyActive(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	now := time.Now().UTC()
+
+	ended := newIntegrationSubscription(tenantID)
+	ended.StartDate = now.AddDate(-1, 0, 0)
+	endedAt := now.AddDate(0, -1, 0)
+	ended.EndDate = &endedAt
+	openEnded := newIntegrationSubscription(tenantID)
+	openEnded.StartDate = now.AddDate(-1, 0, 0)
+	upcoming := newIntegrationSubscription(tenantID)
+	upcoming.StartDate = now.AddDate(0, 1, 0)
+	upcomingEnd := now.AddDate(1, 0, 0)
+	upcoming.EndDate = &upcomingEnd
+	for _, sub := range []*model.Subscription{ended, openEnded, upcoming} {
+		require.NoError(t, repo.Create(ctx, sub))
+	}
+
+	all, err := repo.List(ctx, model.SubscriptionFilter{TenantID: &tenantID})
+	require.NoError(t, err)
+	active, err := repo.List(ctx, model.SubscriptionFilter{TenantID: &tenantID, OnlyActive: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, all.TotalCount, "ended subscriptions are kept by default")
+	var ids []uuid.UUID
+	for _, sub := range active.Items {
+		ids = append(ids, sub.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{openEnded.ID, upcoming.ID}, ids)
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// GetPriceHistory returns every price the subscription has had, oldest
+// first; the last one is its current price. The history is written by the
+// triggers of migration 013. A subscription that does not exist, is
+// deleted or belongs to another tenant has no history.
+func (r *postgresSubscriptionRepo) GetPriceHistory(ctx context.Context, tenantID, id uuid.UUID) ([]int, error) {
+	const op = "repository.postgresql.GetPriceHistory"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			h.price 
+		FROM 
+			subscription_price_history h 
+			JOIN subscriptions s ON s.id = h.subscription_id 
+		WHERE 
+			h.subscription_id = $1 AND s.tenant_id = $2 AND s.deleted_at IS NULL 
+		ORDER BY 
+			h.recorded_at, h.id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, id, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var prices []int
+	for rows.Next() {
+		var price int
+		if err := rows.Scan(&price); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan price: %w", op, err)
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return prices, nil
+}
+
+// StreamPriceHistoryByUser passes the price history of every live
+// subscription of the user to fn, by subscription and then oldest first,
+// as it is read. An error from fn stops the stream and is returned as is.
+// There is no query timeout: fn may be writing to a slow client, and ctx
+// ends with the request.
+func (r *postgresSubscriptionRepo) StreamPriceHistoryByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.PriceChange) error) error {
+	const op = "repository.postgresql.StreamPriceHistoryByUser"
+
+	query := `
+		SELECT 
+			h.subscription_id, h.price, h.recorded_at 
+		FROM 
+			subscription_price_history h 
+			JOIN subscriptions s ON s.id = h.subscription_id 
+		WHERE 
+			s.user_id = $1 AND s.tenant_id = $2 AND s.deleted_at IS NULL 
+		ORDER BY 
+			h.subscription_id, h.recorded_at, h.id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change model.PriceChange
+		if err := rows.Scan(&change.SubscriptionID, &change.Price, &change.RecordedAt); err != nil {
+			return fmt.Errorf("%s: failed to scan price: %w", op, err)
+		}
+		if err := fn(change); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return nil
+}
+
+// NotifyPriceChanged publishes model.EventPriceChanged for the subscription.
+// Called within a transaction, the event is delivered only if it commits,
+// like the events of the writes themselves.
+func (r *postgresSubscriptionRepo) NotifyPriceChanged(ctx context.Context, tenantID, id uuid.UUID, oldPrice, newPrice int) error {
+	const op = "repository.postgresql.NotifyPriceChanged"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			pg_notify('` + ChangesChannel + `', json_build_object(
+				'event', '` + string(model.EventPriceChanged) + `', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id, 
+				'old_price', $3::integer, 'new_price', $4::integer)::text) 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $1 AND tenant_id = $2`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, id, tenantID, oldPrice, newPrice); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateServicePrice applies update and returns what it changed, with the
+// owners of the changed subscriptions; subscriptions whose price it would
+// not change are left alone and not counted. Each changed subscription publishes model.EventUpdated followed
+// by model.EventPriceChanged with its own old price. With
+// update.EffectiveDate, the history rows the triggers of migration 013
+// write for the new prices are dated then instead of now, though never
+// before the price they follow. The two statements are atomic only
+// within Transactional.
+func (r *postgresSubscriptionRepo) UpdateServicePrice(ctx context.Context, update model.ServicePriceUpdate) (*model.ServicePriceUpdateResult, error) {
+	const op = "repository.postgresql.UpdateServicePrice"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH old AS (
+			SELECT 
+				id, price, 
+				COALESCE($3::integer, LEAST($5::integer - 1, GREATEST(1, ROUND(price * (100 + $4::numeric) / 100)::integer))) AS new_price 
+			FROM 
+				subscriptions 
+			WHERE 
+				service_name = $1 AND deleted_at IS NULL AND 
+				($2::uuid IS NULL OR tenant_id = $2) AND 
+				(NOT $6::boolean OR (start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE))) 
+			FOR UPDATE
+		), changed AS (
+			UPDATE subscriptions s 
+			SET 
+				price = o.new_price, 
+				version = s.version + 1 
+			FROM 
+				old o 
+			WHERE 
+				s.id = o.id AND o.new_price <> o.price 
+			RETURNING s.id, s.tenant_id, s.user_id, o.price AS old_price, o.new_price
+		)
+		SELECT 
+			tenant_id, user_id, old_price, new_price, 
+			pg_notify('` + ChangesChannel + `', json_build_object(
+				'event', '` + string(model.EventUpdated) + `', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id)::text), 
+			pg_notify('` + ChangesChannel + `', json_build_object(
+				'event', '` + string(model.EventPriceChanged) + `', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id, 
+				'old_price', old_price, 'new_price', new_price)::text) 
+		FROM 
+			changed`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query,
+		update.ServiceName,
+		update.TenantID,
+		update.NewPrice,
+		update.PercentChange,
+		update.MaxPrice,
+		update.ActiveOnly,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := &model.ServicePriceUpdateResult{}
+	seen := make(map[model.SubscriptionOwner]bool)
+	for rows.Next() {
+		var owner model.SubscriptionOwner
+		var oldPrice, newPrice int64
+		var notified, priceNotified sql.RawBytes
+		if err := rows.Scan(&owner.TenantID, &owner.UserID, &oldPrice, &newPrice, &notified, &priceNotified); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan prices: %w", op, err)
+		}
+		if !seen[owner] {
+			seen[owner] = true
+			result.Owners = append(result.Owners, owner)
+		}
+		result.Updated++
+		result.TotalBefore += oldPrice
+		result.TotalAfter += newPrice
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	if update.EffectiveDate == nil || result.Updated == 0 {
+		return result, nil
+	}
+
+	// NOW() is the start of the transaction, so the rows dated NOW() are
+	// the ones the UPDATE above just wrote.
+	query = `
+		UPDATE subscription_price_history h 
+		SET 
+			recorded_at = GREATEST($1::timestamptz, COALESCE(
+				(SELECT MAX(p.recorded_at) FROM subscription_price_history p WHERE p.subscription_id = h.subscription_id AND p.id < h.id), 
+				$1)) 
+		FROM 
+			subscriptions s 
+		WHERE 
+			s.id = h.subscription_id AND h.recorded_at = NOW() AND 
+			s.service_name = $2 AND ($3::uuid IS NULL OR s.tenant_id = $3)`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, *update.EffectiveDate, update.ServiceName, update.TenantID); err != nil {
+		return nil, fmt.Errorf("%s: history: %w", op, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// A percentage change rounds each price on its own, and an effective date
+// before a subscription's last price change keeps the history in order.
+func TestIntegration_UpdateServicePrice(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	var ids []uuid.UUID
+	for _, price := range []int{999, 599} {
+		sub := newIntegrationSubscription(tenantID)
+		sub.ServiceName, sub.Price, sub.UserID = "netflix", price, userID
+		require.NoError(t, repo.Create(ctx, sub))
+		ids = append(ids, sub.ID)
+	}
+	other := newIntegrationSubscription(tenantID)
+	other.ServiceName = "spotify"
+	require.NoError(t, repo.Create(ctx, other))
+
+	txCtx, commit, rollback, err := repo.Transactional(ctx)
+	require.NoError(t, err)
+	defer rollback()
+	pct, effective := 10.0, fixedTime()
+	result, err := repo.UpdateServicePrice(txCtx, model.ServicePriceUpdate{
+		ServiceName:   "netflix",
+		TenantID:      &tenantID,
+		PercentChange: &pct,
+		MaxPrice:      1000000,
+		EffectiveDate: &effective,
+	})
+	require.NoError(t, err)
+	require.NoError(t, commit())
+
+	assert.Equal(t, &model.ServicePriceUpdateResult{
+		Updated: 2, TotalBefore: 1598, TotalAfter: 1758,
+		Owners: []model.SubscriptionOwner{{TenantID: tenantID, UserID: userID}},
+	}, result)
+	history, err := repo.GetPriceHistory(ctx, tenantID, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, []int{999, 1099}, history)
+
+	unchanged, err := repo.GetByID(ctx, tenantID, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 999, unchanged.Price)
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestGetPriceHistory_OldestFirst(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE h.subscription_id = $1 AND s.tenant_id = $2 AND s.deleted_at IS NULL ORDER BY h.recorded_at, h.id")).
+		WithArgs(id, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"price"}).AddRow(650).AddRow(750).AddRow(1200))
+
+	prices, err := repo.GetPriceHistory(context.Background(), testTenantID, id)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{650, 750, 1200}, prices)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPriceHistory_UsesCallersTransaction(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	// The transaction holds the only connection, so a query on the pool
+	// would wait for it until the context ends.
+	repo.db.SetMaxOpenConns(1)
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM subscription_price_history h")).
+		WithArgs(id, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"price"}).AddRow(650))
+	mock.ExpectCommit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, commit, rollback, err := repo.Transactional(ctx)
+	require.NoError(t, err)
+	defer rollback()
+
+	_, err = repo.GetPriceHistory(ctx, testTenantID, id)
+	require.NoError(t, err)
+	require.NoError(t, commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotifyPriceChanged(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`pg_notify('subscriptions_changed', json_build_object( 'event', 'price_changed', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id, 'old_price', $3::integer, 'new_price', $4::integer)::text) FROM subscriptions WHERE id = $1 AND tenant_id = $2`)).
+		WithArgs(id, testTenantID, 599, 799).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.NotifyPriceChanged(context.Background(), testTenantID, id, 599, 799)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateServicePrice_SumsChangedPrices(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	pct := 10.0
+	userID, otherTenantID := uuid.New(), uuid.New()
+	columns := []string{"tenant_id", "user_id", "old_price", "new_price", "pg_notify", "pg_notify"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`COALESCE($3::integer, LEAST($5::integer - 1, GREATEST(1, ROUND(price * (100 + $4::numeric) / 100)::integer))) AS new_price`)).
+		WithArgs("netflix", nil, nil, &pct, 100000, true).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(testTenantID, userID, 599, 659, "", "").
+			AddRow(testTenantID, userID, 999, 1099, "", "").
+			AddRow(otherTenantID, userID, 299, 329, "", ""))
+
+	result, err := repo.UpdateServicePrice(context.Background(), model.ServicePriceUpdate{
+		ServiceName:   "netflix",
+		PercentChange: &pct,
+		MaxPrice:      100000,
+		ActiveOnly:    true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.ServicePriceUpdateResult{
+		Updated: 3, TotalBefore: 1897, TotalAfter: 2087,
+		Owners: []model.SubscriptionOwner{{TenantID: testTenantID, UserID: userID}, {TenantID: otherTenantID, UserID: userID}},
+	}, result, "each owner once, told apart by tenant")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateServicePrice_DatesHistory(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	newPrice := 1199
+	effective := fixedTime()
+	userID := uuid.New()
+	columns := []string{"tenant_id", "user_id", "old_price", "new_price", "pg_notify", "pg_notify"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`'old_price', old_price, 'new_price', new_price)::text) FROM changed`)).
+		WithArgs("netflix", &testTenantID, &newPrice, nil, 100000, false).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(testTenantID, userID, 999, 1199, "", ""))
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE s.id = h.subscription_id AND h.recorded_at = NOW() AND s.service_name = $2 AND ($3::uuid IS NULL OR s.tenant_id = $3)`)).
+		WithArgs(effective, "netflix", &testTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := repo.UpdateServicePrice(context.Background(), model.ServicePriceUpdate{
+		ServiceName:   "netflix",
+		TenantID:      &testTenantID,
+		NewPrice:      &newPrice,
+		MaxPrice:      100000,
+		EffectiveDate: &effective,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.ServicePriceUpdateResult{
+		Updated: 1, TotalBefore: 999, TotalAfter: 1199,
+		Owners: []model.SubscriptionOwner{{TenantID: testTenantID, UserID: userID}},
+	}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateServicePrice_NothingChangedSkipsHistory(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	newPrice := 1199
+	effective := fixedTime()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM changed`)).
+		WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "user_id", "old_price", "new_price", "pg_notify", "pg_notify"}))
+
+	result, err := repo.UpdateServicePrice(context.Background(), model.ServicePriceUpdate{
+		ServiceName:   "netflix",
+		NewPrice:      &newPrice,
+		EffectiveDate: &effective,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.ServicePriceUpdateResult{}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStreamPriceHistoryByUser(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID, subID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE s.user_id = $1 AND s.tenant_id = $2 AND s.deleted_at IS NULL ORDER BY h.subscription_id, h.recorded_at, h.id")).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "price", "recorded_at"}).
+			AddRow(subID, 599, fixedTime()).
+			AddRow(subID, 799, fixedTime().AddDate(0, 1, 0)))
+
+	var history []model.PriceChange
+	err := repo.StreamPriceHistoryByUser(context.Background(), testTenantID, userID, func(c model.PriceChange) error {
+		history = append(history, c)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.PriceChange{
+		{SubscriptionID: subID, Price: 599, RecordedAt: fixedTime()},
+		{SubscriptionID: subID, Price: 799, RecordedAt: fixedTime().AddDate(0, 1, 0)},
+	}, history)
+}
+
+func TestStreamPriceHistoryByUser_StopsAtCallbackError(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subID := uuid.New()
+	broken := errors.New("broken pipe")
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM subscription_price_history h")).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "price", "recorded_at"}).
+			AddRow(subID, 599, fixedTime()).
+			AddRow(subID, 799, fixedTime()))
+
+	calls := 0
+	err := repo.StreamPriceHistoryByUser(context.Background(), testTenantID, uuid.New(), func(model.PriceChange) error {
+		calls++
+		return broken
+	})
+
+	assert.Same(t, broken, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,61 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+const integrationImage = "postgres:15-alpine"
+
+// integrationPostgres is the throwaway database the integration tests
+// share. The first test that needs it starts it and TestMain removes it.
+var integrationPostgres struct {
+	once      sync.Once
+	container *postgres.PostgresContainer
+	dsn       string
+	err       error
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if err := testcontainers.TerminateContainer(integrationPostgres.container); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to remove the postgres container: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// integrationDSN returns the address of an empty database in a postgres
+// container, started with testcontainers-go. The test is skipped when
+// there is no Docker to start it in. Run with
+// go test -tags integration ./pkg/repository/.
+func integrationDSN(t *testing.T) string {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	integrationPostgres.once.Do(func() {
+		ctx := context.Background()
+		c, err := postgres.Run(ctx, integrationImage,
+			postgres.WithDatabase("subscriptions"),
+			postgres.WithUsername("postgres"),
+			postgres.WithPassword("postgres"),
+			postgres.BasicWaitStrategies(),
+		)
+		integrationPostgres.container = c
+		if err != nil {
+			integrationPostgres.err = err
+			return
+		}
+		integrationPostgres.dsn, integrationPostgres.err = c.ConnectionString(ctx, "sslmode=disable")
+	})
+	require.NoError(t, integrationPostgres.err, "failed to start postgres")
+	return integrationPostgres.dsn
+}
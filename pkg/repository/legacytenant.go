@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Migration 006 gave every subscription created before tenants existed
+// the nil tenant, which no request may use. Until those rows are moved to
+// a real tenant they cannot be read, changed or deleted through the API.
+
+// AssignLegacyTenant moves the subscriptions still under the nil tenant to
+// tenantID and returns how many it moved. Once they are moved there is
+// nothing left to match, so it is safe to run on every start.
+func AssignLegacyTenant(ctx context.Context, db *sql.DB, tenantID uuid.UUID) (int64, error) {
+	const op = "repository.postgresql.AssignLegacyTenant"
+
+	if tenantID == uuid.Nil {
+		return 0, fmt.Errorf("%s: legacy tenant must not be the nil UUID", op)
+	}
+
+	res, err := db.ExecContext(ctx, `UPDATE subscriptions SET tenant_id = $1 WHERE tenant_id = $2`, tenantID, uuid.Nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return moved, nil
+}
+
+// CountUnassignedTenant returns how many subscriptions are still under the
+// nil tenant.
+func CountUnassignedTenant(ctx context.Context, db *sql.DB) (int64, error) {
+	const op = "repository.postgresql.CountUnassignedTenant"
+
+	var count int64
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM subscriptions WHERE tenant_id = $1`, uuid.Nil).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignLegacyTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	tenantID := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions SET tenant_id = $1 WHERE tenant_id = $2`)).
+		WithArgs(tenantID, uuid.Nil).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	moved, err := AssignLegacyTenant(context.Background(), db, tenantID)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), moved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignLegacyTenant_RejectsNilTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = AssignLegacyTenant(context.Background(), db, uuid.Nil)
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountUnassignedTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM subscriptions WHERE tenant_id = $1`)).
+		WithArgs(uuid.Nil).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := CountUnassignedTenant(context.Background(), db)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	mock.ExpectQuery(`SELECT COUNT`).WillReturnError(errors.New("db down"))
+	_, err = CountUnassignedTenant(context.Background(), db)
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// SpendingLimitRepository stores one spending limit per user of a tenant.
+type SpendingLimitRepository interface {
+	Upsert(ctx context.Context, tenantID uuid.UUID, limit *model.SpendingLimit) (created bool, err error)
+	Get(ctx context.Context, tenantID, userID uuid.UUID) (*model.SpendingLimit, error)
+	Delete(ctx context.Context, tenantID, userID uuid.UUID) error
+	SetBreached(ctx context.Context, tenantID, userID uuid.UUID, breached bool) error
+}
+
+type postgresSpendingLimitRepo struct {
+	db *sql.DB
+}
+
+func NewSpendingLimitRepository(db *sql.DB) SpendingLimitRepository {
+	return &postgresSpendingLimitRepo{db: db}
+}
+
+// Upsert creates the user's limit or replaces it. A replaced limit forgets
+// an earlier breach, so going over the new one is announced again.
+func (r *postgresSpendingLimitRepo) Upsert(ctx context.Context, tenantID uuid.UUID, limit *model.SpendingLimit) (bool, error) {
+	const op = "repository.postgresql.limits.Upsert"
+
+	query := `
+		INSERT INTO spending_limits 
+			(tenant_id, user_id, limit_amount, currency, alert_threshold_pct) 
+		VALUES 
+			($1, $2, $3, $4, $5) 
+		ON CONFLICT (tenant_id, user_id) DO UPDATE 
+		SET 
+			limit_amount = EXCLUDED.limit_amount, 
+			currency = EXCLUDED.currency, 
+			alert_threshold_pct = EXCLUDED.alert_threshold_pct, 
+			breached_at = NULL, 
+			updated_at = NOW() 
+		RETURNING 
+			created_at, updated_at, (xmax = 0)`
+
+	var created bool
+	err := r.db.QueryRowContext(ctx, query,
+		tenantID,
+		limit.UserID,
+		limit.LimitAmount,
+		limit.Currency,
+		limit.AlertThresholdPct,
+	).Scan(&limit.CreatedAt, &limit.UpdatedAt, &created)
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (r *postgresSpendingLimitRepo) Get(ctx context.Context, tenantID, userID uuid.UUID) (*model.SpendingLimit, error) {
+	const op = "repository.postgresql.limits.Get"
+
+	query := `
+		SELECT 
+			user_id, limit_amount, currency, alert_threshold_pct, created_at, updated_at 
+		FROM 
+			spending_limits 
+		WHERE 
+			tenant_id = $1 AND user_id = $2`
+
+	var limit model.SpendingLimit
+	err := r.db.QueryRowContext(ctx, query, tenantID, userID).Scan(
+		&limit.UserID,
+		&limit.LimitAmount,
+		&limit.Currency,
+		&limit.AlertThresholdPct,
+		&limit.CreatedAt,
+		&limit.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &limit, nil
+}
+
+func (r *postgresSpendingLimitRepo) Delete(ctx context.Context, tenantID, userID uuid.UUID) error {
+	const op = "repository.postgresql.limits.Delete"
+
+	query := `DELETE FROM spending_limits WHERE tenant_id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// SetBreached records whether the user is over their limit. Only the
+// change from within the limit to over it publishes
+// model.EventSpendingLimitBreached, so a user who stays over the limit is
+// announced once. The event names the user in user_id and has no id, since
+// no single subscription caused it.
+func (r *postgresSpendingLimitRepo) SetBreached(ctx context.Context, tenantID, userID uuid.UUID, breached bool) error {
+	const op = "repository.postgresql.limits.SetBreached"
+
+	query := `
+		UPDATE spending_limits 
+		SET 
+			breached_at = NULL 
+		WHERE 
+			tenant_id = $1 AND user_id = $2 AND breached_at IS NOT NULL`
+	if breached {
+		query = `
+		WITH changed AS (
+			UPDATE spending_limits 
+			SET 
+				breached_at = NOW() 
+			WHERE 
+				tenant_id = $1 AND user_id = $2 AND breached_at IS NULL 
+			RETURNING 
+				NULL::uuid AS id, tenant_id, user_id
+		)` + notifyChanged(model.EventSpendingLimitBreached)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, tenantID, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
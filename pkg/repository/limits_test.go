@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestSpendingLimitRepo(t *testing.T) (SpendingLimitRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewSpendingLimitRepository(db), mock
+}
+
+func TestSpendingLimitUpsert_ReportsCreated(t *testing.T) {
+	repo, mock := newTestSpendingLimitRepo(t)
+	tenantID := uuid.New()
+	limit := &model.SpendingLimit{UserID: uuid.New(), LimitAmount: 2000, Currency: "RUB", AlertThresholdPct: 80}
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT (tenant_id, user_id) DO UPDATE`)).
+		WithArgs(tenantID, limit.UserID, 2000, "RUB", 80).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "created"}).AddRow(now, now, true))
+
+	created, err := repo.Upsert(context.Background(), tenantID, limit)
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, now, limit.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSpendingLimitGet_NotFound(t *testing.T) {
+	repo, mock := newTestSpendingLimitRepo(t)
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM spending_limits WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, userID).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.Get(context.Background(), tenantID, userID)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSpendingLimitDelete_NotFound(t *testing.T) {
+	repo, mock := newTestSpendingLimitRepo(t)
+	tenantID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM spending_limits WHERE tenant_id = $1 AND user_id = $2`)).
+		WithArgs(tenantID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete(context.Background(), tenantID, userID)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSpendingLimitSetBreached(t *testing.T) {
+	tests := []struct {
+		name     string
+		breached bool
+		query    string
+	}{
+		// Only a limit not yet breached is marked, and only that publishes.
+		{"breached", true, `SET breached_at = NOW() WHERE tenant_id = $1 AND user_id = $2 AND breached_at IS NULL RETURNING NULL::uuid AS id, tenant_id, user_id ) SELECT pg_notify('subscriptions_changed', json_build_object('event', 'spending_limit_breached'`},
+		{"back under", false, `SET breached_at = NULL WHERE tenant_id = $1 AND user_id = $2 AND breached_at IS NOT NULL`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newTestSpendingLimitRepo(t)
+			tenantID, userID := uuid.New(), uuid.New()
+
+			mock.ExpectExec(regexp.QuoteMeta(tt.query)).
+				WithArgs(tenantID, userID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err := repo.SetBreached(context.Background(), tenantID, userID, tt.breached)
+
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// LoggingRepository times every call to another SubscriptionRepository and
+// logs it at Debug, or at Warn with slow_query=true once it takes longer
+// than SlowQueryThreshold. A zero threshold never warns. Calls are logged
+// through logger.FromContext, so they carry the request ID.
+type LoggingRepository struct {
+	next               SubscriptionRepository
+	SlowQueryThreshold time.Duration
+}
+
+func NewLoggingRepository(next SubscriptionRepository, slowQueryThreshold time.Duration) *LoggingRepository {
+	return &LoggingRepository{next: next, SlowQueryThreshold: slowQueryThreshold}
+}
+
+// timed runs call and logs how long the op it makes took.
+func timed[T any](ctx context.Context, r *LoggingRepository, op string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	v, err := call()
+	r.record(ctx, op, time.Since(start), err)
+	return v, err
+}
+
+func (r *LoggingRepository) do(ctx context.Context, op string, call func() error) error {
+	_, err := timed(ctx, r, op, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+// streamed runs a call that hands rows to fn and logs how long the op took
+// without the time fn spent on them, which is the caller's.
+func streamed[T any](ctx context.Context, r *LoggingRepository, op string, fn func(T) error, call func(fn func(T) error) error) error {
+	var inFn time.Duration
+	start := time.Now()
+	err := call(func(v T) error {
+		fnStart := time.Now()
+		defer func() { inFn += time.Since(fnStart) }()
+		return fn(v)
+	})
+	r.record(ctx, op, time.Since(start)-inFn, err)
+	return err
+}
+
+func (r *LoggingRepository) record(ctx context.Context, op string, d time.Duration, err error) {
+	level := slog.LevelDebug
+	attrs := []slog.Attr{slog.String("op", op), slog.Duration("duration", d)}
+	if r.SlowQueryThreshold > 0 && d > r.SlowQueryThreshold {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Bool("slow_query", true))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.FromContext(ctx).LogAttrs(ctx, level, "repository call", attrs...)
+}
+
+func (r *LoggingRepository) Create(ctx context.Context, sub *model.Subscription) error {
+	return r.do(ctx, "Create", func() error { return r.next.Create(ctx, sub) })
+}
+
+func (r *LoggingRepository) BulkCreate(ctx context.Context, subs []*model.Subscription) error {
+	return r.do(ctx, "BulkCreate", func() error { return r.next.BulkCreate(ctx, subs) })
+}
+
+func (r *LoggingRepository) ExistsActiveOverlap(ctx context.Context, tenantID, userID uuid.UUID, serviceName string, start time.Time, end *time.Time) (*uuid.UUID, error) {
+	return timed(ctx, r, "ExistsActiveOverlap", func() (*uuid.UUID, error) {
+		return r.next.ExistsActiveOverlap(ctx, tenantID, userID, serviceName, start, end)
+	})
+}
+
+func (r *LoggingRepository) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+	return timed(ctx, r, "GetByID", func() (*model.Subscription, error) { return r.next.GetByID(ctx, tenantID, id) })
+}
+
+func (r *LoggingRepository) GetByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*model.Subscription, error) {
+	return timed(ctx, r, "GetByIDs", func() ([]*model.Subscription, error) { return r.next.GetByIDs(ctx, tenantID, ids) })
+}
+
+func (r *LoggingRepository) GetPriceHistory(ctx context.Context, tenantID, id uuid.UUID) ([]int, error) {
+	return timed(ctx, r, "GetPriceHistory", func() ([]int, error) { return r.next.GetPriceHistory(ctx, tenantID, id) })
+}
+
+func (r *LoggingRepository) StreamPriceHistoryByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.PriceChange) error) error {
+	return streamed(ctx, r, "StreamPriceHistoryByUser", fn, func(fn func(model.PriceChange) error) error {
+		return r.next.StreamPriceHistoryByUser(ctx, tenantID, userID, fn)
+	})
+}
+
+func (r *LoggingRepository) RecordPayment(ctx context.Context, tenantID uuid.UUID, payment *model.Payment) error {
+	return r.do(ctx, "RecordPayment", func() error { return r.next.RecordPayment(ctx, tenantID, payment) })
+}
+
+func (r *LoggingRepository) ListPayments(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Payment, error) {
+	return timed(ctx, r, "ListPayments", func() ([]model.Payment, error) { return r.next.ListPayments(ctx, tenantID, subscriptionID) })
+}
+
+func (r *LoggingRepository) LockSubscription(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+	return timed(ctx, r, "LockSubscription", func() (*model.Subscription, error) { return r.next.LockSubscription(ctx, tenantID, id) })
+}
+
+func (r *LoggingRepository) Update(ctx context.Context, sub *model.Subscription) error {
+	return r.do(ctx, "Update", func() error { return r.next.Update(ctx, sub) })
+}
+
+func (r *LoggingRepository) NotifyPriceChanged(ctx context.Context, tenantID, id uuid.UUID, oldPrice, newPrice int) error {
+	return r.do(ctx, "NotifyPriceChanged", func() error { return r.next.NotifyPriceChanged(ctx, tenantID, id, oldPrice, newPrice) })
+}
+
+func (r *LoggingRepository) UpdateServicePrice(ctx context.Context, update model.ServicePriceUpdate) (*model.ServicePriceUpdateResult, error) {
+	return timed(ctx, r, "UpdateServicePrice", func() (*model.ServicePriceUpdateResult, error) {
+		return r.next.UpdateServicePrice(ctx, update)
+	})
+}
+
+func (r *LoggingRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	return r.do(ctx, "Delete", func() error { return r.next.Delete(ctx, tenantID, id) })
+}
+
+func (r *LoggingRepository) List(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error) {
+	return timed(ctx, r, "List", func() (*model.ListResult, error) { return r.next.List(ctx, filter) })
+}
+
+func (r *LoggingRepository) StreamUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID, fn func(*model.Subscription) error) error {
+	return streamed(ctx, r, "StreamUserSubscriptions", fn, func(fn func(*model.Subscription) error) error {
+		return r.next.StreamUserSubscriptions(ctx, tenantID, userID, fn)
+	})
+}
+
+func (r *LoggingRepository) GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return timed(ctx, r, "GetTotalCost", func() (int, error) { return r.next.GetTotalCost(ctx, filter) })
+}
+
+func (r *LoggingRepository) Count(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return timed(ctx, r, "Count", func() (int, error) { return r.next.Count(ctx, filter) })
+}
+
+func (r *LoggingRepository) GetUserLimit(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	return timed(ctx, r, "GetUserLimit", func() (int, error) { return r.next.GetUserLimit(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) LockUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID) error {
+	return r.do(ctx, "LockUserSubscriptions", func() error { return r.next.LockUserSubscriptions(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) GetPaidTotal(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return timed(ctx, r, "GetPaidTotal", func() (int, error) { return r.next.GetPaidTotal(ctx, filter) })
+}
+
+func (r *LoggingRepository) GetProratedTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	return timed(ctx, r, "GetProratedTotalCost", func() (int, error) { return r.next.GetProratedTotalCost(ctx, filter) })
+}
+
+func (r *LoggingRepository) GetProratedCostPerUser(ctx context.Context, filter model.SubscriptionFilter) (map[uuid.UUID]int, error) {
+	return timed(ctx, r, "GetProratedCostPerUser", func() (map[uuid.UUID]int, error) { return r.next.GetProratedCostPerUser(ctx, filter) })
+}
+
+func (r *LoggingRepository) GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error) {
+	return timed(ctx, r, "GetMonthlyCost", func() ([]model.MonthlyCost, error) { return r.next.GetMonthlyCost(ctx, filter) })
+}
+
+func (r *LoggingRepository) ListServices(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.ServiceSummary, error) {
+	return timed(ctx, r, "ListServices", func() ([]*model.ServiceSummary, error) { return r.next.ListServices(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) ShareSubscription(ctx context.Context, tenantID uuid.UUID, share *model.ShareEntry) error {
+	return r.do(ctx, "ShareSubscription", func() error { return r.next.ShareSubscription(ctx, tenantID, share) })
+}
+
+func (r *LoggingRepository) UnshareSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error {
+	return r.do(ctx, "UnshareSubscription", func() error { return r.next.UnshareSubscription(ctx, tenantID, subscriptionID, userID) })
+}
+
+func (r *LoggingRepository) CreateShares(ctx context.Context, tenantID uuid.UUID, shares []model.ShareEntry) error {
+	return r.do(ctx, "CreateShares", func() error { return r.next.CreateShares(ctx, tenantID, shares) })
+}
+
+func (r *LoggingRepository) GetSharedUsers(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.ShareEntry, error) {
+	return timed(ctx, r, "GetSharedUsers", func() ([]model.ShareEntry, error) { return r.next.GetSharedUsers(ctx, tenantID, subscriptionID) })
+}
+
+func (r *LoggingRepository) PinSubscription(ctx context.Context, tenantID uuid.UUID, pin *model.Pin) error {
+	return r.do(ctx, "PinSubscription", func() error { return r.next.PinSubscription(ctx, tenantID, pin) })
+}
+
+func (r *LoggingRepository) UnpinSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error {
+	return r.do(ctx, "UnpinSubscription", func() error { return r.next.UnpinSubscription(ctx, tenantID, subscriptionID, userID) })
+}
+
+func (r *LoggingRepository) ListExpired(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+	return timed(ctx, r, "ListExpired", func() ([]*model.Subscription, error) { return r.next.ListExpired(ctx, filter) })
+}
+
+func (r *LoggingRepository) SoftDeleteExpired(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	return timed(ctx, r, "SoftDeleteExpired", func() (int, error) { return r.next.SoftDeleteExpired(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error) {
+	return timed(ctx, r, "GetCostByCycle", func() ([]model.BillingCycleSummary, error) { return r.next.GetCostByCycle(ctx, filter) })
+}
+
+func (r *LoggingRepository) GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error) {
+	return timed(ctx, r, "GetPriceStats", func() (*model.PriceStats, error) { return r.next.GetPriceStats(ctx, filter) })
+}
+
+func (r *LoggingRepository) GetCostByService(ctx context.Context, filter model.SubscriptionFilter) ([]model.ServiceCost, error) {
+	return timed(ctx, r, "GetCostByService", func() ([]model.ServiceCost, error) { return r.next.GetCostByService(ctx, filter) })
+}
+
+func (r *LoggingRepository) ListExpiringSoonByService(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error) {
+	return timed(ctx, r, "ListExpiringSoonByService", func() ([]model.ExpiringServiceSummary, error) {
+		return r.next.ListExpiringSoonByService(ctx, tenantID, userID, days)
+	})
+}
+
+func (r *LoggingRepository) CountByStatus(ctx context.Context, tenantID, userID uuid.UUID) (active, expired int, err error) {
+	err = r.do(ctx, "CountByStatus", func() error {
+		var err error
+		active, expired, err = r.next.CountByStatus(ctx, tenantID, userID)
+		return err
+	})
+	return active, expired, err
+}
+
+func (r *LoggingRepository) GetActiveCostByCycle(ctx context.Context, tenantID, userID uuid.UUID) ([]model.BillingCycleSummary, error) {
+	return timed(ctx, r, "GetActiveCostByCycle", func() ([]model.BillingCycleSummary, error) { return r.next.GetActiveCostByCycle(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) GetMostExpensiveActive(ctx context.Context, tenantID, userID uuid.UUID) (string, error) {
+	return timed(ctx, r, "GetMostExpensiveActive", func() (string, error) { return r.next.GetMostExpensiveActive(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) GetNextExpiry(ctx context.Context, tenantID, userID uuid.UUID) (*time.Time, error) {
+	return timed(ctx, r, "GetNextExpiry", func() (*time.Time, error) { return r.next.GetNextExpiry(ctx, tenantID, userID) })
+}
+
+func (r *LoggingRepository) CountCreatedBetween(ctx context.Context, from, to time.Time) (int, error) {
+	return timed(ctx, r, "CountCreatedBetween", func() (int, error) { return r.next.CountCreatedBetween(ctx, from, to) })
+}
+
+func (r *LoggingRepository) GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error) {
+	return timed(ctx, r, "GetTotalCostByUser", func() (*model.UserCostResult, error) {
+		return r.next.GetTotalCostByUser(ctx, filter, limit, offset)
+	})
+}
+
+func (r *LoggingRepository) Transactional(ctx context.Context) (txCtx context.Context, commit CommitFunc, rollback RollbackFunc, err error) {
+	err = r.do(ctx, "Transactional", func() error {
+		var err error
+		txCtx, commit, rollback, err = r.next.Transactional(ctx)
+		return err
+	})
+	return txCtx, commit, rollback, err
+}
@@ -0,0 +1,131 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+var testTenantID = uuid.MustParse("0b7e1c52-8d4f-4a3e-9c61-2f5a7d8e9b10")
+
+// answerGetByID makes inner answer GetByID with err after delay, or with
+// the subscription asked for.
+func answerGetByID(inner *mocks.SubscriptionRepository, delay time.Duration, err error) {
+	call := inner.On("GetByID", mock.Anything, testTenantID, mock.Anything).After(delay)
+	if err != nil {
+		call.Return(nil, err)
+		return
+	}
+	call.Return(func(_ context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+		return &model.Subscription{ID: id, TenantID: tenantID}, nil
+	})
+}
+
+// newTestLoggingRepo wraps a mock and returns it, a context carrying a
+// logger and the JSON log lines written to it.
+func newTestLoggingRepo(t *testing.T, threshold time.Duration) (*repository.LoggingRepository, *mocks.SubscriptionRepository, context.Context, func(t *testing.T) []map[string]any) {
+	inner := mocks.NewSubscriptionRepository(t)
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	lines := func(t *testing.T) []map[string]any {
+		t.Helper()
+		var entries []map[string]any
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var entry map[string]any
+			require.NoError(t, dec.Decode(&entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	return repository.NewLoggingRepository(inner, threshold), inner, logger.WithContext(context.Background(), log), lines
+}
+
+func TestLoggingRepository_WarnsAboutSlowQueries(t *testing.T) {
+	repo, inner, ctx, lines := newTestLoggingRepo(t, 5*time.Millisecond)
+	answerGetByID(inner, 20*time.Millisecond, nil)
+	id := uuid.New()
+
+	sub, err := repo.GetByID(ctx, testTenantID, id)
+
+	require.NoError(t, err)
+	assert.Equal(t, id, sub.ID)
+	entries := lines(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "WARN", entries[0]["level"])
+	assert.Equal(t, "GetByID", entries[0]["op"])
+	assert.Equal(t, true, entries[0]["slow_query"])
+	assert.GreaterOrEqual(t, entries[0]["duration"], float64(20*time.Millisecond))
+}
+
+func TestLoggingRepository_FastQueriesAtDebug(t *testing.T) {
+	repo, inner, ctx, lines := newTestLoggingRepo(t, time.Second)
+	answerGetByID(inner, 0, nil)
+
+	_, err := repo.GetByID(ctx, testTenantID, uuid.New())
+
+	require.NoError(t, err)
+	entries := lines(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DEBUG", entries[0]["level"])
+	assert.Equal(t, "GetByID", entries[0]["op"])
+	assert.NotContains(t, entries[0], "slow_query")
+	assert.NotContains(t, entries[0], "error")
+}
+
+func TestLoggingRepository_ZeroThresholdNeverWarns(t *testing.T) {
+	repo, inner, ctx, lines := newTestLoggingRepo(t, 0)
+	answerGetByID(inner, 5*time.Millisecond, nil)
+
+	_, err := repo.GetByID(ctx, testTenantID, uuid.New())
+
+	require.NoError(t, err)
+	entries := lines(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DEBUG", entries[0]["level"])
+}
+
+func TestLoggingRepository_PassesErrorsThrough(t *testing.T) {
+	repo, inner, ctx, lines := newTestLoggingRepo(t, time.Second)
+	answerGetByID(inner, 0, model.ErrNotFound)
+
+	_, err := repo.GetByID(ctx, testTenantID, uuid.New())
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	entries := lines(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, model.ErrNotFound.Error(), entries[0]["error"])
+}
+
+func TestLoggingRepository_StreamsLeaveOutTheCallersTime(t *testing.T) {
+	repo, inner, ctx, lines := newTestLoggingRepo(t, 10*time.Millisecond)
+	userID := uuid.New()
+	inner.On("StreamPriceHistoryByUser", mock.Anything, testTenantID, userID, mock.Anything).
+		Return(func(_ context.Context, _, _ uuid.UUID, fn func(model.PriceChange) error) error {
+			return fn(model.PriceChange{Price: 599})
+		})
+
+	err := repo.StreamPriceHistoryByUser(ctx, testTenantID, userID, func(model.PriceChange) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	require.NoError(t, err)
+	entries := lines(t)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DEBUG", entries[0]["level"], "a slow client is not a slow query")
+	assert.Equal(t, "StreamPriceHistoryByUser", entries[0]["op"])
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// UserMergeRepository moves everything of one user of tenantID to another.
+type UserMergeRepository interface {
+	MergeUsers(ctx context.Context, tenantID, sourceID, targetID uuid.UUID, deduplicate bool, entries ...*model.AuditEntry) (*model.MergeUsersResponse, error)
+}
+
+type postgresUserMergeRepo struct {
+	db *sql.DB
+}
+
+func NewUserMergeRepository(db *sql.DB) UserMergeRepository {
+	return &postgresUserMergeRepo{db: db}
+}
+
+// MergeUsers gives targetID every subscription of sourceID, deleted ones
+// included, in one transaction that also records entries. With
+// deduplicate, a live subscription of the source with the service and
+// start date of a live one of the target is soft-deleted as it moves and
+// counted as skipped. The source's pins and the shares made with the
+// source pass to the target unless the target has them already; a share
+// with the target of a subscription it now owns is dropped. Spending and
+// subscription limits stay as they are: the target's apply from now on.
+//
+// Nothing is left under sourceID afterwards, so running the merge again
+// moves and skips nothing. Moved subscriptions publish an "updated" change
+// and skipped ones a "deleted" change.
+func (r *postgresUserMergeRepo) MergeUsers(ctx context.Context, tenantID, sourceID, targetID uuid.UUID, deduplicate bool, entries ...*model.AuditEntry) (*model.MergeUsersResponse, error) {
+	const op = "repository.postgresql.merge.MergeUsers"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	resp := &model.MergeUsersResponse{}
+
+	if deduplicate {
+		query := `
+			WITH changed AS (
+				UPDATE subscriptions s 
+				SET 
+					user_id = $3, 
+					deleted_at = NOW(), 
+					version = s.version + 1 
+				WHERE 
+					s.tenant_id = $1 AND s.user_id = $2 AND s.deleted_at IS NULL AND 
+					EXISTS (
+						SELECT 1 FROM subscriptions t 
+						WHERE 
+							t.tenant_id = $1 AND t.user_id = $3 AND t.deleted_at IS NULL AND 
+							t.service_name = s.service_name AND t.start_date = s.start_date
+					) 
+				RETURNING s.id, s.tenant_id, s.user_id
+			)` + notifyChanged(model.EventDeleted)
+
+		if resp.Skipped, err = execCount(ctx, tx, query, tenantID, sourceID, targetID); err != nil {
+			return nil, fmt.Errorf("%s: duplicates: %w", op, err)
+		}
+	}
+
+	query := `
+		WITH changed AS (
+			UPDATE subscriptions 
+			SET 
+				user_id = $3, 
+				version = version + 1 
+			WHERE 
+				tenant_id = $1 AND user_id = $2 
+			RETURNING id, tenant_id, user_id, deleted_at
+		)` + notifyChanged(model.EventUpdated) + ` 
+		WHERE 
+			deleted_at IS NULL`
+
+	if resp.Moved, err = execCount(ctx, tx, query, tenantID, sourceID, targetID); err != nil {
+		return nil, fmt.Errorf("%s: subscriptions: %w", op, err)
+	}
+
+	// Copies go first and skip what the target has; the source's rows are
+	// then deleted whether they were copied or not.
+	statements := []struct {
+		name  string
+		query string
+	}{
+		{"pins", `
+			INSERT INTO pinned_subscriptions 
+				(user_id, subscription_id, pinned_at) 
+			SELECT 
+				$3, p.subscription_id, p.pinned_at 
+			FROM 
+				pinned_subscriptions p 
+				JOIN subscriptions s ON s.id = p.subscription_id 
+			WHERE 
+				s.tenant_id = $1 AND p.user_id = $2 
+			ON CONFLICT DO NOTHING`},
+		{"source pins", `
+			DELETE FROM pinned_subscriptions p 
+			USING subscriptions s 
+			WHERE 
+				p.subscription_id = s.id AND s.tenant_id = $1 AND p.user_id = $2`},
+		{"shares", `
+			INSERT INTO subscription_shares 
+				(subscription_id, shared_with_user_id, permission, created_at) 
+			SELECT 
+				sh.subscription_id, $3, sh.permission, sh.created_at 
+			FROM 
+				subscription_shares sh 
+				JOIN subscriptions s ON s.id = sh.subscription_id 
+			WHERE 
+				s.tenant_id = $1 AND sh.shared_with_user_id = $2 AND s.user_id <> $3 
+			ON CONFLICT DO NOTHING`},
+		{"source shares", `
+			DELETE FROM subscription_shares sh 
+			USING subscriptions s 
+			WHERE 
+				sh.subscription_id = s.id AND s.tenant_id = $1 AND 
+				(sh.shared_with_user_id = $2 OR (s.user_id = $3 AND sh.shared_with_user_id = $3))`},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt.query, tenantID, sourceID, targetID); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, stmt.name, err)
+		}
+	}
+
+	for _, entry := range entries {
+		err = tx.QueryRowContext(ctx, insertAuditQuery, tenantID, entry.UserID, entry.Actor, entry.Action, entry.RequestID).
+			Scan(&entry.ID, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: audit: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resp, nil
+}
+
+// execCount runs query in tx and returns the number of rows it affected.
+func execCount(ctx context.Context, tx *sql.Tx, query string, args ...any) (int64, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return n, nil
+}
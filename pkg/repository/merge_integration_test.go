@@ -0,0 +1,47 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// A deduplicating merge leaves the target with one subscription per
+// service and start date and nothing under the source, so running it again
+// changes nothing.
+func TestIntegration_MergeUsersIsIdempotent(t *testing.T) {
+	db := newIntegrationDB(t)
+	repo, merges := NewSubscriptionRepository(db), NewUserMergeRepository(db)
+	ctx := context.Background()
+	tenantID, sourceID, targetID := uuid.New(), uuid.New(), uuid.New()
+
+	create := func(userID uuid.UUID, serviceName string) {
+		sub := newIntegrationSubscription(tenantID)
+		sub.UserID, sub.ServiceName = userID, serviceName
+		require.NoError(t, repo.Create(ctx, sub))
+	}
+	create(targetID, "netflix")
+	create(sourceID, "netflix")
+	create(sourceID, "spotify")
+
+	resp, err := merges.MergeUsers(ctx, tenantID, sourceID, targetID, true)
+	require.NoError(t, err)
+	assert.Equal(t, &model.MergeUsersResponse{Moved: 1, Skipped: 1}, resp)
+
+	resp, err = merges.MergeUsers(ctx, tenantID, sourceID, targetID, true)
+	require.NoError(t, err)
+	assert.Equal(t, &model.MergeUsersResponse{}, resp)
+
+	for userID, want := range map[uuid.UUID]int{sourceID: 0, targetID: 2} {
+		list, err := repo.List(ctx, model.SubscriptionFilter{TenantID: &tenantID, UserID: &userID})
+		require.NoError(t, err)
+		assert.Equal(t, want, list.TotalCount)
+	}
+}
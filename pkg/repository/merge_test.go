@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestUserMergeRepo(t *testing.T) (UserMergeRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewUserMergeRepository(db), mock
+}
+
+// expectMergeAfterMove expects MergeUsers' statements after the
+// subscriptions moved, up to the commit.
+func expectMergeAfterMove(mock sqlmock.Sqlmock, sourceID, targetID uuid.UUID, entries ...*model.AuditEntry) {
+	for _, stmt := range []string{
+		`INSERT INTO pinned_subscriptions (user_id, subscription_id, pinned_at) SELECT $3, p.subscription_id, p.pinned_at`,
+		`DELETE FROM pinned_subscriptions p`,
+		`INSERT INTO subscription_shares (subscription_id, shared_with_user_id, permission, created_at) SELECT sh.subscription_id, $3, sh.permission, sh.created_at`,
+		`DELETE FROM subscription_shares sh`,
+	} {
+		mock.ExpectExec(regexp.QuoteMeta(stmt)).
+			WithArgs(testTenantID, sourceID, targetID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	for i, entry := range entries {
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+			WithArgs(testTenantID, entry.UserID, entry.Actor, entry.Action, entry.RequestID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(20+i), fixedTime()))
+	}
+	mock.ExpectCommit()
+}
+
+func newTestMergeEntries(sourceID, targetID uuid.UUID) []*model.AuditEntry {
+	return []*model.AuditEntry{
+		{UserID: sourceID, Actor: model.AuditActorUser, Action: model.AuditActionMergeInto, RequestID: "req-1"},
+		{UserID: targetID, Actor: model.AuditActorUser, Action: model.AuditActionMergeFrom, RequestID: "req-1"},
+	}
+}
+
+func TestMergeUsers_Deduplicate(t *testing.T) {
+	repo, mock := newTestUserMergeRepo(t)
+	sourceID, targetID := uuid.New(), uuid.New()
+	entries := newTestMergeEntries(sourceID, targetID)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions s SET user_id = $3, deleted_at = NOW(), version = s.version + 1 WHERE s.tenant_id = $1 AND s.user_id = $2 AND s.deleted_at IS NULL AND EXISTS ( SELECT 1 FROM subscriptions t WHERE t.tenant_id = $1 AND t.user_id = $3 AND t.deleted_at IS NULL AND t.service_name = s.service_name AND t.start_date = s.start_date )`)).
+		WithArgs(testTenantID, sourceID, targetID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions SET user_id = $3, version = version + 1 WHERE tenant_id = $1 AND user_id = $2 RETURNING id, tenant_id, user_id, deleted_at )`)).
+		WithArgs(testTenantID, sourceID, targetID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	expectMergeAfterMove(mock, sourceID, targetID, entries...)
+
+	resp, err := repo.MergeUsers(context.Background(), testTenantID, sourceID, targetID, true, entries...)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.MergeUsersResponse{Moved: 3, Skipped: 1}, resp)
+	assert.Equal(t, int64(20), entries[0].ID)
+	assert.Equal(t, fixedTime(), entries[1].CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeUsers_KeepsDuplicates(t *testing.T) {
+	repo, mock := newTestUserMergeRepo(t)
+	sourceID, targetID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions SET user_id = $3, version = version + 1`)).
+		WithArgs(testTenantID, sourceID, targetID).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	expectMergeAfterMove(mock, sourceID, targetID)
+
+	resp, err := repo.MergeUsers(context.Background(), testTenantID, sourceID, targetID, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.MergeUsersResponse{Moved: 4}, resp)
+	assert.NoError(t, mock.ExpectationsWereMet(), "no duplicates are looked for")
+}
+
+func TestMergeUsers_RollsBackOnError(t *testing.T) {
+	repo, mock := newTestUserMergeRepo(t)
+	sourceID, targetID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions`)).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO pinned_subscriptions`)).WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	resp, err := repo.MergeUsers(context.Background(), testTenantID, sourceID, targetID, false, newTestMergeEntries(sourceID, targetID)...)
+
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "pins")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
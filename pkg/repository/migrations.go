@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationName is NNN_description.sql; the numeric prefix defines the order.
+var migrationName = regexp.MustCompile(`^\d{3}_[A-Za-z0-9_]+\.sql$`)
+
+// Concurrent RunMigrations calls, e.g. from several instances starting at
+// once, are serialised with a PostgreSQL advisory lock on migrationLockKey.
+// A caller that finds it taken retries every migrationLockPoll for up to
+// migrationLockWait, then gives up.
+const (
+	migrationLockKey  = 42
+	migrationLockPoll = 500 * time.Millisecond
+	migrationLockWait = 30 * time.Second
+)
+
+// RunMigrations applies the embedded migrations that are not yet recorded
+// in schema_migrations, each in its own transaction.
+//
+// Two instances migrating at once would both find a migration missing and
+// race to apply it, so only the holder of the migration lock runs them. The
+// lock is held by a database session, so it is taken and released on one
+// pinned connection; the migrations themselves run on the pool. Whoever
+// gets the lock next reads schema_migrations afresh and finds nothing left
+// to do.
+func RunMigrations(ctx context.Context, db *sql.DB, log *slog.Logger) error {
+	const op = "repository.postgresql.RunMigrations"
+
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if err := acquireMigrationLock(ctx, conn, migrationLockWait, migrationLockPoll); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		// The lock outlives a cancelled ctx, so it is released regardless.
+		// Should that fail, the connection is dropped rather than returned
+		// to the pool still holding the lock, which ends the session and
+		// with it the lock.
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			log.Warn("failed to release migration lock", slog.String("error", err.Error()))
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return runMigrations(ctx, db, sub, log)
+}
+
+// acquireMigrationLock takes the migration lock on conn, polling every poll
+// while another session holds it. It fails once wait has passed or ctx is
+// done.
+func acquireMigrationLock(ctx context.Context, conn *sql.Conn, wait, poll time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockKey).Scan(&locked); err != nil {
+			return fmt.Errorf("failed to take migration lock: %w", err)
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("migration lock still held by another instance after %s", wait)
+		}
+
+		timer := time.NewTimer(poll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("waiting for migration lock: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func runMigrations(ctx context.Context, db *sql.DB, migrations fs.FS, log *slog.Logger) error {
+	const op = "repository.postgresql.RunMigrations"
+
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+		return fmt.Errorf("%s: failed to create schema_migrations: %w", op, err)
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	entries, err := fs.ReadDir(migrations, ".")
+	if err != nil {
+		return fmt.Errorf("%s: failed to list migrations: %w", op, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if !migrationName.MatchString(entry.Name()) {
+			log.Warn("skipping migration with unexpected name", slog.String("file", entry.Name()))
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if applied[name] {
+			continue
+		}
+
+		migration, err := fs.ReadFile(migrations, name)
+		if err != nil {
+			return fmt.Errorf("%s: failed to read migration %s: %w", op, name, err)
+		}
+
+		if err := applyMigration(ctx, db, name, string(migration)); err != nil {
+			return fmt.Errorf("%s: failed to apply migration %s: %w", op, name, err)
+		}
+		log.Info("migration applied", slog.String("file", path.Base(name)))
+	}
+
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, name, migration string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,17 @@
+DO $$
+BEGIN
+    CREATE TYPE share_permission AS ENUM ('read', 'write');
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS subscription_shares (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    shared_with_user_id UUID NOT NULL,
+    permission share_permission NOT NULL DEFAULT 'read',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (subscription_id, shared_with_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_shares_user_id ON subscription_shares(shared_with_user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_expired ON subscriptions(user_id, end_date) WHERE deleted_at IS NULL AND end_date IS NOT NULL;
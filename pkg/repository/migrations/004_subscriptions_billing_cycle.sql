@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS billing_cycle TEXT NOT NULL DEFAULT 'monthly'
+    CHECK (billing_cycle IN ('weekly', 'monthly', 'quarterly', 'annual'));
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS reminders (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    remind_days_before INTEGER NOT NULL CHECK (remind_days_before >= 0),
+    last_reminded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (subscription_id, remind_days_before)
+);
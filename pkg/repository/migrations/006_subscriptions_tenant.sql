@@ -0,0 +1,7 @@
+-- Rows created before tenants existed belong to the nil tenant until they
+-- are moved to tenant.legacy_id on startup; new rows must name theirs.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE subscriptions ALTER COLUMN tenant_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_user ON subscriptions(tenant_id, user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS pinned_subscriptions (
+    user_id UUID NOT NULL,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    pinned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, subscription_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pinned_subscriptions_subscription_id ON pinned_subscriptions(subscription_id);
@@ -0,0 +1,6 @@
+-- The admin spend leaderboard groups every live subscription by user. With
+-- the priced columns included, it reads the groups in user_id order from
+-- this index alone instead of scanning and sorting the table.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_cost ON subscriptions(user_id)
+    INCLUDE (price, billing_cycle, service_name, start_date, end_date)
+    WHERE deleted_at IS NULL;
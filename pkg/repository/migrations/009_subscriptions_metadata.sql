@@ -0,0 +1,3 @@
+-- metadata holds whatever the client wants to keep next to a subscription.
+-- It is stored as given and never queried by the service.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS metadata JSONB;
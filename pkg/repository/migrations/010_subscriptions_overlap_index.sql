@@ -0,0 +1,7 @@
+-- Creating a subscription first looks for a live one of the same user and
+-- service whose period overlaps, see ExistsActiveOverlap. The check cannot
+-- be a unique or exclusion constraint: allow_duplicate=true lets a client
+-- store such a duplicate on purpose, e.g. two family members on separate
+-- plans. This index only keeps the lookup from scanning the user's rows.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_service ON subscriptions(tenant_id, user_id, service_name, start_date)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,12 @@
+-- Service names are stored trimmed and lower-cased from now on, see
+-- service.NormaliseServiceName, and filters are normalised the same way.
+-- Bring existing rows in line so that "Yandex Plus" and " yandex plus"
+-- become one service.
+--
+-- There is deliberately no unique index on (user_id, LOWER(service_name)):
+-- a user subscribes to the same service again after a lapse, and
+-- allow_duplicate=true stores overlapping ones on purpose. Migration 010's
+-- index already covers lookups by the stored name.
+UPDATE subscriptions
+SET service_name = LOWER(BTRIM(service_name, E' \t\r\n'))
+WHERE service_name <> LOWER(BTRIM(service_name, E' \t\r\n'));
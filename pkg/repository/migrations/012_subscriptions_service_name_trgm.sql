@@ -0,0 +1,6 @@
+-- GET /subscriptions?q= matches service names with ILIKE '%q%', which a
+-- btree index cannot serve; a trigram index can.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name_trgm ON subscriptions USING gin (service_name gin_trgm_ops)
+    WHERE deleted_at IS NULL;
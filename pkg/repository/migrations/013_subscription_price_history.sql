@@ -0,0 +1,30 @@
+-- Every price a subscription has had, oldest first by recorded_at. Triggers
+-- keep it in step with subscriptions whichever statement writes the price,
+-- so the application never inserts into it. Existing subscriptions start
+-- with their current price.
+CREATE TABLE IF NOT EXISTS subscription_price_history (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    price INTEGER NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_price_history_subscription ON subscription_price_history(subscription_id, recorded_at);
+
+INSERT INTO subscription_price_history (subscription_id, price, recorded_at)
+SELECT id, price, COALESCE(created_at, NOW()) FROM subscriptions;
+
+CREATE OR REPLACE FUNCTION record_subscription_price() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO subscription_price_history (subscription_id, price) VALUES (NEW.id, NEW.price);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscriptions_price_inserted ON subscriptions;
+CREATE TRIGGER subscriptions_price_inserted AFTER INSERT ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION record_subscription_price();
+
+DROP TRIGGER IF EXISTS subscriptions_price_changed ON subscriptions;
+CREATE TRIGGER subscriptions_price_changed AFTER UPDATE OF price ON subscriptions
+    FOR EACH ROW WHEN (OLD.price IS DISTINCT FROM NEW.price) EXECUTE FUNCTION record_subscription_price();
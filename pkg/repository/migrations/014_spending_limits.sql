@@ -0,0 +1,13 @@
+-- breached_at is set when the limit is first found exceeded and cleared
+-- once spend falls back under it, so every breach is announced once.
+CREATE TABLE IF NOT EXISTS spending_limits (
+    tenant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    limit_amount INTEGER NOT NULL CHECK (limit_amount > 0),
+    currency TEXT NOT NULL,
+    alert_threshold_pct INTEGER NOT NULL CHECK (alert_threshold_pct BETWEEN 1 AND 100),
+    breached_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, user_id)
+);
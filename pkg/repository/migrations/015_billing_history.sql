@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS billing_history (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL,
+    billed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    status TEXT NOT NULL DEFAULT 'paid'
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_history_subscription_billed ON billing_history(subscription_id, billed_at);
@@ -0,0 +1,20 @@
+-- service_catalog lists the services a tenant's users can subscribe to.
+-- A subscription created from an entry keeps a reference to it; deleting
+-- the entry leaves the subscription in place.
+CREATE TABLE IF NOT EXISTS service_catalog (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    logo_url TEXT NOT NULL DEFAULT '',
+    default_price INTEGER NOT NULL CHECK (default_price > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_catalog_tenant_category ON service_catalog(tenant_id, category);
+
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS catalog_service_id UUID REFERENCES service_catalog(id) ON DELETE SET NULL;
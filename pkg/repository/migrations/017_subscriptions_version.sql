@@ -0,0 +1,3 @@
+-- version counts the updates of a subscription, so that an update made
+-- from a stale copy can be detected and refused.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- max_subscriptions caps how many subscriptions a user may have and
+-- overrides limits.default_max_subscriptions_per_user; 0 means unlimited.
+CREATE TABLE IF NOT EXISTS user_limits (
+    tenant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    max_subscriptions INTEGER NOT NULL CHECK (max_subscriptions >= 0),
+    PRIMARY KEY (tenant_id, user_id)
+);
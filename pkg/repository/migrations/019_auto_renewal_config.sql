@@ -0,0 +1,11 @@
+-- While enabled, the renewal processor extends the subscription's end_date
+-- by renewal_duration_days whenever next_renewal_date comes and moves
+-- next_renewal_date on by the same number of days.
+CREATE TABLE IF NOT EXISTS auto_renewal_config (
+    subscription_id UUID PRIMARY KEY REFERENCES subscriptions(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    renewal_duration_days INTEGER NOT NULL CHECK (renewal_duration_days > 0),
+    next_renewal_date DATE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_auto_renewal_config_due ON auto_renewal_config(next_renewal_date) WHERE enabled;
@@ -0,0 +1,7 @@
+-- The report job records the end of the last period it delivered a report
+-- for, so a restart does not send it again.
+CREATE TABLE IF NOT EXISTS report_runs (
+    report TEXT PRIMARY KEY,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
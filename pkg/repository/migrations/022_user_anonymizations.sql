@@ -0,0 +1,13 @@
+-- Which anonymous ID replaced an anonymized user, kept unless the
+-- anonymization was irreversible; a user anonymized twice has two rows.
+-- Only administrators can read it. Anonymizing also moves the user's
+-- audit_log rows to the anonymous ID, the one update audit_log ever sees.
+CREATE TABLE IF NOT EXISTS user_anonymizations (
+    tenant_id UUID NOT NULL,
+    anonymous_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    anonymized_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, anonymous_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_anonymizations_user ON user_anonymizations(tenant_id, user_id);
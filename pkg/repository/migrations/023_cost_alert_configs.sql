@@ -0,0 +1,13 @@
+-- Webhooks to call when a user's current spend goes over a threshold.
+-- last_triggered_at throttles the calls of each config.
+CREATE TABLE IF NOT EXISTS cost_alert_configs (
+    id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    threshold_amount INTEGER NOT NULL CHECK (threshold_amount > 0),
+    webhook_url TEXT NOT NULL,
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_alert_configs_user ON cost_alert_configs(tenant_id, user_id);
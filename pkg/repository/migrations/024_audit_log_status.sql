@@ -0,0 +1,4 @@
+-- Whether the audited action went through. Actions recorded in their own
+-- transaction only ever complete; an export is recorded once it has been
+-- sent or has failed.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'completed';
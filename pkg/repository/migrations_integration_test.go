@@ -0,0 +1,86 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Two instances starting at once migrate an empty schema of their own. The
+// migration lock lets one apply everything while the other waits, then
+// finds nothing left to do; without it both would apply 001 and one would
+// fail on the duplicate schema_migrations row.
+func TestIntegration_ConcurrentRunMigrations(t *testing.T) {
+	dsn := integrationDSN(t)
+	ctx := context.Background()
+
+	admin, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+	schema := "migrations_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	_, err = admin.ExecContext(ctx, `CREATE SCHEMA `+pq.QuoteIdentifier(schema))
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + pq.QuoteIdentifier(schema) + ` CASCADE`) })
+
+	// public stays on the path for the pg_trgm operator classes.
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("search_path", schema+",public")
+	u.RawQuery = q.Encode()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		db, err := sql.Open("postgres", u.String())
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = RunMigrations(ctx, db, discardLog)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		assert.NoError(t, err, "instance %d", i)
+	}
+
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	require.NoError(t, err)
+	var want []string
+	for _, entry := range entries {
+		want = append(want, entry.Name())
+	}
+
+	rows, err := admin.QueryContext(ctx, `SELECT version FROM `+pq.QuoteIdentifier(schema)+`.schema_migrations ORDER BY version`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var applied []string
+	for rows.Next() {
+		var v string
+		require.NoError(t, rows.Scan(&v))
+		applied = append(applied, v)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, want, applied, "every migration recorded exactly once")
+
+	var tables int
+	require.NoError(t, admin.QueryRowContext(ctx,
+		`SELECT count(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name = 'subscriptions'`, schema,
+	).Scan(&tables))
+	assert.Equal(t, 1, tables)
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Each pooled connection would get its own in-memory database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func appliedVersions(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query(`SELECT version FROM schema_migrations ORDER BY version`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var v string
+		require.NoError(t, rows.Scan(&v))
+		versions = append(versions, v)
+	}
+	require.NoError(t, rows.Err())
+	return versions
+}
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRunMigrations_AppliesMatchingFilesInOrder(t *testing.T) {
+	db := newSQLite(t)
+	migrations := fstest.MapFS{
+		"002_add_price.sql": {Data: []byte(`ALTER TABLE items ADD COLUMN price INTEGER`)},
+		"001_init.sql":      {Data: []byte(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)},
+		"README.md":         {Data: []byte(`not a migration`)},
+		"3_bad_name.sql":    {Data: []byte(`DROP TABLE items`)},
+	}
+
+	require.NoError(t, runMigrations(context.Background(), db, migrations, discardLog))
+
+	assert.Equal(t, []string{"001_init.sql", "002_add_price.sql"}, appliedVersions(t, db))
+	_, err := db.Exec(`INSERT INTO items (id, price) VALUES (1, 100)`)
+	assert.NoError(t, err)
+}
+
+func TestRunMigrations_SkipsAlreadyApplied(t *testing.T) {
+	db := newSQLite(t)
+	migrations := fstest.MapFS{
+		"001_init.sql": {Data: []byte(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)},
+	}
+
+	require.NoError(t, runMigrations(context.Background(), db, migrations, discardLog))
+	// A second CREATE TABLE would fail if 001 ran again.
+	migrations["002_more.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE more (id INTEGER)`)}
+	require.NoError(t, runMigrations(context.Background(), db, migrations, discardLog))
+
+	assert.Equal(t, []string{"001_init.sql", "002_more.sql"}, appliedVersions(t, db))
+}
+
+func TestRunMigrations_FailedMigrationIsRolledBack(t *testing.T) {
+	db := newSQLite(t)
+	migrations := fstest.MapFS{
+		"001_init.sql":   {Data: []byte(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)},
+		"002_broken.sql": {Data: []byte(`ALTER TABLE missing ADD COLUMN x INTEGER`)},
+	}
+
+	err := runMigrations(context.Background(), db, migrations, discardLog)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "002_broken.sql")
+	assert.Equal(t, []string{"001_init.sql"}, appliedVersions(t, db))
+}
+
+func TestEmbeddedMigrations_AreNamedCorrectly(t *testing.T) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	for _, entry := range entries {
+		assert.Regexp(t, migrationName, entry.Name())
+	}
+}
+
+func newLockConn(t *testing.T) (*sql.Conn, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, mock
+}
+
+func expectTryLock(mock sqlmock.Sqlmock, locked bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_lock($1)`)).
+		WithArgs(migrationLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(locked))
+}
+
+func TestAcquireMigrationLock_WaitsForOtherInstance(t *testing.T) {
+	conn, mock := newLockConn(t)
+	expectTryLock(mock, false)
+	expectTryLock(mock, false)
+	expectTryLock(mock, true)
+
+	err := acquireMigrationLock(context.Background(), conn, time.Second, time.Millisecond)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcquireMigrationLock_GivesUp(t *testing.T) {
+	conn, mock := newLockConn(t)
+	mock.MatchExpectationsInOrder(false)
+	for range 100 {
+		expectTryLock(mock, false)
+	}
+
+	err := acquireMigrationLock(context.Background(), conn, 5*time.Millisecond, time.Millisecond)
+
+	assert.ErrorContains(t, err, "still held by another instance")
+}
+
+func TestAcquireMigrationLock_StopsWithContext(t *testing.T) {
+	conn, mock := newLockConn(t)
+	expectTryLock(mock, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := acquireMigrationLock(ctx, conn, time.Minute, time.Minute)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
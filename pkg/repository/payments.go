@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// RecordPayment stores payment against a live subscription of tenantID and
+// fills in BilledAt. Any other subscription is model.ErrNotFound.
+func (r *postgresSubscriptionRepo) RecordPayment(ctx context.Context, tenantID uuid.UUID, payment *model.Payment) error {
+	const op = "repository.postgresql.RecordPayment"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO billing_history 
+			(id, subscription_id, amount, currency, status) 
+		SELECT 
+			$1, id, $3, $4, $5 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $2 AND tenant_id = $6 AND deleted_at IS NULL 
+		RETURNING 
+			billed_at`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		payment.ID,
+		payment.SubscriptionID,
+		payment.Amount,
+		payment.Currency,
+		payment.Status,
+		tenantID,
+	).Scan(&payment.BilledAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: subscription %s: %w", op, payment.SubscriptionID, model.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListPayments returns the payments of a subscription of tenantID, oldest
+// first.
+func (r *postgresSubscriptionRepo) ListPayments(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Payment, error) {
+	const op = "repository.postgresql.ListPayments"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			b.id, b.subscription_id, b.amount, b.currency, b.billed_at, b.status 
+		FROM 
+			billing_history b 
+			JOIN subscriptions s ON s.id = b.subscription_id 
+		WHERE 
+			b.subscription_id = $1 AND s.tenant_id = $2 AND s.deleted_at IS NULL 
+		ORDER BY 
+			b.billed_at, b.id`
+
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var payments []model.Payment
+	for rows.Next() {
+		var p model.Payment
+		err := rows.Scan(&p.ID, &p.SubscriptionID, &p.Amount, &p.Currency, &p.BilledAt, &p.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan payment: %w", op, err)
+		}
+		payments = append(payments, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return payments, nil
+}
+
+// GetPaidTotal adds up the paid payments of the subscriptions matching
+// filter. Unlike the price totals, the date bounds apply to the day each
+// payment was billed, both days included.
+func (r *postgresSubscriptionRepo) GetPaidTotal(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	const op = "repository.postgresql.GetPaidTotal"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COALESCE(SUM(amount), 0) 
+		FROM 
+			billing_history 
+		WHERE 
+			status = '` + string(model.PaymentPaid) + `' AND 
+			($10::timestamp IS NULL OR billed_at::date >= $10::date) AND 
+			($11::timestamp IS NULL OR billed_at::date <= $11::date) AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE ` + subscriptionFilterClause + `)`
+
+	from, to := filter.FromDate, filter.ToDate
+	filter.FromDate, filter.ToDate = nil, nil
+	args := append(filterArgs(filter), from, to)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return total, nil
+}
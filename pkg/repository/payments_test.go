@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestRecordPayment(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	payment := &model.Payment{ID: uuid.New(), SubscriptionID: uuid.New(), Amount: 599, Currency: "RUB", Status: model.PaymentPaid}
+	billedAt := fixedTime()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $2 AND tenant_id = $6 AND deleted_at IS NULL RETURNING billed_at`)).
+		WithArgs(payment.ID, payment.SubscriptionID, 599, "RUB", model.PaymentPaid, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"billed_at"}).AddRow(billedAt))
+
+	err := repo.RecordPayment(context.Background(), testTenantID, payment)
+
+	require.NoError(t, err)
+	assert.Equal(t, billedAt, payment.BilledAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordPayment_UnknownSubscription(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	payment := &model.Payment{ID: uuid.New(), SubscriptionID: uuid.New(), Amount: 599, Currency: "RUB", Status: model.PaymentPaid}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO billing_history`)).
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.RecordPayment(context.Background(), testTenantID, payment)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListPayments(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	billedAt := fixedTime()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE b.subscription_id = $1 AND s.tenant_id = $2 AND s.deleted_at IS NULL ORDER BY b.billed_at, b.id`)).
+		WithArgs(subID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "amount", "currency", "billed_at", "status"}).
+			AddRow(first, subID, 599, "RUB", billedAt, "paid").
+			AddRow(second, subID, 699, "RUB", billedAt.AddDate(0, 1, 0), "paid"))
+
+	payments, err := repo.ListPayments(context.Background(), testTenantID, subID)
+
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.Equal(t, first, payments[0].ID)
+	assert.Equal(t, 699, payments[1].Amount)
+	assert.Equal(t, model.PaymentPaid, payments[1].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPaidTotal_DatesBoundBilledAt(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	from := fixedTime()
+	to := from.AddDate(0, 1, 0)
+
+	// The period bounds the payments, not the subscriptions they belong to.
+	mock.ExpectQuery(regexp.QuoteMeta(`($10::timestamp IS NULL OR billed_at::date >= $10::date)`)+`(.|\n)*`+
+		regexp.QuoteMeta(`subscription_id IN (SELECT id FROM subscriptions WHERE`)).
+		WithArgs(nil, nil, nil, nil, false, &testTenantID, false, nil, false, &from, &to).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1198))
+
+	total, err := repo.GetPaidTotal(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, FromDate: &from, ToDate: &to})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1198, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
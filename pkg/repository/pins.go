@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// PinSubscription pins a subscription for pin.UserID and fills in
+// pin.PinnedAt. Pinning again keeps the original time.
+func (r *postgresSubscriptionRepo) PinSubscription(ctx context.Context, tenantID uuid.UUID, pin *model.Pin) error {
+	const op = "repository.postgresql.PinSubscription"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO pinned_subscriptions 
+			(subscription_id, user_id) 
+		SELECT 
+			id, $2 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $1 AND tenant_id = $3 AND deleted_at IS NULL 
+		ON CONFLICT (user_id, subscription_id) 
+			DO UPDATE SET pinned_at = pinned_subscriptions.pinned_at 
+		RETURNING 
+			pinned_at`
+
+	err := r.db.QueryRowContext(ctx, query, pin.SubscriptionID, pin.UserID, tenantID).Scan(&pin.PinnedAt)
+
+	err = classifyError(err)
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, model.ErrInvalidReference) {
+		return fmt.Errorf("%s: subscription %s: %w", op, pin.SubscriptionID, model.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *postgresSubscriptionRepo) UnpinSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error {
+	const op = "repository.postgresql.UnpinSubscription"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM pinned_subscriptions 
+		WHERE 
+			subscription_id = $1 AND user_id = $2 AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $3)`
+
+	result, err := r.db.ExecContext(ctx, query, subscriptionID, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestPinSubscription_Upsert(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	pin := &model.Pin{SubscriptionID: uuid.New(), UserID: uuid.New()}
+
+	mock.ExpectQuery(regexp.QuoteMeta("ON CONFLICT (user_id, subscription_id)")).
+		WithArgs(pin.SubscriptionID, pin.UserID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"pinned_at"}).AddRow(fixedTime()))
+
+	err := repo.PinSubscription(context.Background(), testTenantID, pin)
+
+	require.NoError(t, err)
+	assert.Equal(t, fixedTime(), pin.PinnedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinSubscription_MissingSubscription(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	pin := &model.Pin{SubscriptionID: uuid.New(), UserID: uuid.New()}
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO pinned_subscriptions")).
+		WithArgs(pin.SubscriptionID, pin.UserID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"pinned_at"}))
+
+	err := repo.PinSubscription(context.Background(), testTenantID, pin)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnpinSubscription_NotPinned(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM pinned_subscriptions")).
+		WithArgs(subID, userID, testTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UnpinSubscription(context.Background(), testTenantID, subID, userID)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_PinnedOnly(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	userID := uuid.New()
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, UserID: &userID, PinnedOnly: true}
+	args := []driver.Value{&userID, nil, nil, nil, false, &testTenantID, true, nil, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT subscription_id FROM pinned_subscriptions WHERE user_id = $1")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(uuid.New(), "Yandex Plus", 599, userID, fixedTime(), nil, "monthly", nil, nil, 1, true))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	result, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.True(t, result.Items[0].Pinned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// ReminderRepository confines the methods used by the API to the
+// subscriptions of tenantID. ListPending and MarkReminded serve the
+// background worker, which handles every tenant.
+type ReminderRepository interface {
+	Create(ctx context.Context, tenantID uuid.UUID, reminder *model.Reminder) error
+	ListBySubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Reminder, error)
+	Update(ctx context.Context, tenantID uuid.UUID, reminder *model.Reminder) error
+	Delete(ctx context.Context, tenantID, subscriptionID, id uuid.UUID) error
+	ListPending(ctx context.Context, day time.Time) ([]model.PendingReminder, error)
+	MarkReminded(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type postgresReminderRepo struct {
+	db *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) ReminderRepository {
+	return &postgresReminderRepo{db: db}
+}
+
+func (r *postgresReminderRepo) Create(ctx context.Context, tenantID uuid.UUID, reminder *model.Reminder) error {
+	const op = "repository.postgresql.reminders.Create"
+
+	query := `
+		INSERT INTO reminders 
+			(id, subscription_id, remind_days_before) 
+		SELECT 
+			$1, id, $3 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $2 AND tenant_id = $4 AND deleted_at IS NULL 
+		RETURNING 
+			created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		reminder.ID,
+		reminder.SubscriptionID,
+		reminder.RemindDaysBefore,
+		tenantID,
+	).Scan(&reminder.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: subscription %s: %w", op, reminder.SubscriptionID, model.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, classifyError(err))
+	}
+
+	return nil
+}
+
+func (r *postgresReminderRepo) ListBySubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Reminder, error) {
+	const op = "repository.postgresql.reminders.ListBySubscription"
+
+	query := `
+		SELECT 
+			id, subscription_id, remind_days_before, last_reminded_at, created_at 
+		FROM 
+			reminders 
+		WHERE 
+			subscription_id = $1 AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $2) 
+		ORDER BY 
+			remind_days_before DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var reminders []model.Reminder
+	for rows.Next() {
+		var reminder model.Reminder
+		err := rows.Scan(
+			&reminder.ID,
+			&reminder.SubscriptionID,
+			&reminder.RemindDaysBefore,
+			&reminder.LastRemindedAt,
+			&reminder.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan reminder: %w", op, err)
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return reminders, nil
+}
+
+// Update changes how many days ahead the reminder fires and clears
+// last_reminded_at so the new schedule is honoured.
+func (r *postgresReminderRepo) Update(ctx context.Context, tenantID uuid.UUID, reminder *model.Reminder) error {
+	const op = "repository.postgresql.reminders.Update"
+
+	query := `
+		UPDATE reminders 
+		SET 
+			remind_days_before = $3, 
+			last_reminded_at = NULL 
+		WHERE 
+			id = $1 AND subscription_id = $2 AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $4) 
+		RETURNING 
+			created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		reminder.ID,
+		reminder.SubscriptionID,
+		reminder.RemindDaysBefore,
+		tenantID,
+	).Scan(&reminder.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, classifyError(err))
+	}
+	reminder.LastRemindedAt = nil
+
+	return nil
+}
+
+func (r *postgresReminderRepo) Delete(ctx context.Context, tenantID, subscriptionID, id uuid.UUID) error {
+	const op = "repository.postgresql.reminders.Delete"
+
+	query := `
+		DELETE FROM reminders 
+		WHERE 
+			id = $1 AND subscription_id = $2 AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $3)`
+
+	result, err := r.db.ExecContext(ctx, query, id, subscriptionID, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListPending returns reminders of live subscriptions that end on or after
+// day and have not fired on day yet. Whether one is due exactly on day is
+// left to the caller.
+func (r *postgresReminderRepo) ListPending(ctx context.Context, day time.Time) ([]model.PendingReminder, error) {
+	const op = "repository.postgresql.reminders.ListPending"
+
+	query := `
+		SELECT 
+			r.id, r.subscription_id, r.remind_days_before, r.last_reminded_at, r.created_at, 
+			s.service_name, s.price, s.user_id, s.start_date, s.end_date, s.billing_cycle 
+		FROM 
+			reminders r 
+			JOIN subscriptions s ON s.id = r.subscription_id 
+		WHERE 
+			s.deleted_at IS NULL AND 
+			s.end_date IS NOT NULL AND s.end_date::date >= $1::date AND 
+			s.end_date::date - r.remind_days_before <= $1::date AND 
+			(r.last_reminded_at IS NULL OR r.last_reminded_at::date < $1::date)`
+
+	rows, err := r.db.QueryContext(ctx, query, day)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var pending []model.PendingReminder
+	for rows.Next() {
+		var p model.PendingReminder
+		err := rows.Scan(
+			&p.Reminder.ID,
+			&p.Reminder.SubscriptionID,
+			&p.Reminder.RemindDaysBefore,
+			&p.Reminder.LastRemindedAt,
+			&p.Reminder.CreatedAt,
+			&p.Subscription.ServiceName,
+			&p.Subscription.Price,
+			&p.Subscription.UserID,
+			&p.Subscription.StartDate,
+			&p.Subscription.EndDate,
+			&p.Subscription.BillingCycle,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan reminder: %w", op, err)
+		}
+		p.Subscription.ID = p.Reminder.SubscriptionID
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return pending, nil
+}
+
+func (r *postgresReminderRepo) MarkReminded(ctx context.Context, id uuid.UUID, at time.Time) error {
+	const op = "repository.postgresql.reminders.MarkReminded"
+
+	query := `UPDATE reminders SET last_reminded_at = $2 WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, at); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
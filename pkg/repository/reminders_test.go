@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestReminderRepo(t *testing.T) (ReminderRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewReminderRepository(db), mock
+}
+
+func TestReminderCreate_MissingSubscription(t *testing.T) {
+	repo, mock := newTestReminderRepo(t)
+	tenantID := uuid.New()
+	reminder := &model.Reminder{ID: uuid.New(), SubscriptionID: uuid.New(), RemindDaysBefore: 3}
+
+	// A subscription of another tenant is as missing as a deleted one.
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO reminders`)).
+		WithArgs(reminder.ID, reminder.SubscriptionID, reminder.RemindDaysBefore, tenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.Create(context.Background(), tenantID, reminder)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReminderCreate_Duplicate(t *testing.T) {
+	repo, mock := newTestReminderRepo(t)
+	reminder := &model.Reminder{ID: uuid.New(), SubscriptionID: uuid.New(), RemindDaysBefore: 3}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO reminders`)).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+
+	err := repo.Create(context.Background(), uuid.New(), reminder)
+
+	assert.ErrorIs(t, err, model.ErrConflict)
+}
+
+func TestReminderDelete_NotFound(t *testing.T) {
+	repo, mock := newTestReminderRepo(t)
+	tenantID, subID, id := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM reminders WHERE id = $1 AND subscription_id = $2 AND subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $3)`)).
+		WithArgs(id, subID, tenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete(context.Background(), tenantID, subID, id)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReminderListPending_JoinsSubscription(t *testing.T) {
+	repo, mock := newTestReminderRepo(t)
+	id, subID, userID := uuid.New(), uuid.New(), uuid.New()
+	end := fixedTime().AddDate(0, 0, 3)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`s.end_date::date - r.remind_days_before <= $1::date`)).
+		WithArgs(fixedTime()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "subscription_id", "remind_days_before", "last_reminded_at", "created_at",
+			"service_name", "price", "user_id", "start_date", "end_date", "billing_cycle",
+		}).AddRow(id, subID, 3, nil, fixedTime(), "Netflix", 999, userID, fixedTime(), end, "monthly"))
+
+	pending, err := repo.ListPending(context.Background(), fixedTime())
+
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, id, pending[0].Reminder.ID)
+	assert.Equal(t, subID, pending[0].Subscription.ID)
+	assert.Equal(t, "Netflix", pending[0].Subscription.ServiceName)
+	assert.Equal(t, end, *pending[0].Subscription.EndDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
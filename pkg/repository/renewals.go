@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// RenewalRepository serves the auto-renewal processor, which handles every
+// tenant.
+type RenewalRepository interface {
+	ListDue(ctx context.Context, day time.Time) ([]model.AutoRenewalConfig, error)
+	RenewSubscription(ctx context.Context, cfg model.AutoRenewalConfig) error
+}
+
+type postgresRenewalRepo struct {
+	db *sql.DB
+}
+
+func NewRenewalRepository(db *sql.DB) RenewalRepository {
+	return &postgresRenewalRepo{db: db}
+}
+
+// ListDue returns the enabled configs whose next renewal is on or before
+// day, of live subscriptions that have an end date to extend.
+func (r *postgresRenewalRepo) ListDue(ctx context.Context, day time.Time) ([]model.AutoRenewalConfig, error) {
+	const op = "repository.postgresql.renewals.ListDue"
+
+	query := `
+		SELECT 
+			c.subscription_id, c.enabled, c.renewal_duration_days, c.next_renewal_date 
+		FROM 
+			auto_renewal_config c 
+			JOIN subscriptions s ON s.id = c.subscription_id 
+		WHERE 
+			c.enabled AND c.next_renewal_date <= $1::date AND 
+			s.deleted_at IS NULL AND s.end_date IS NOT NULL 
+		ORDER BY 
+			c.next_renewal_date, c.subscription_id`
+
+	rows, err := r.db.QueryContext(ctx, query, day)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var due []model.AutoRenewalConfig
+	for rows.Next() {
+		var cfg model.AutoRenewalConfig
+		if err := rows.Scan(&cfg.SubscriptionID, &cfg.Enabled, &cfg.RenewalDurationDays, &cfg.NextRenewalDate); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan renewal: %w", op, err)
+		}
+		due = append(due, cfg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return due, nil
+}
+
+// RenewSubscription extends the subscription's end_date by
+// cfg.RenewalDurationDays, moves next_renewal_date on by as many days and
+// publishes model.EventRenewed, all in one statement. It matches only while
+// next_renewal_date is still cfg.NextRenewalDate, so a renewal is never
+// applied twice; otherwise, or once the config is disabled or the
+// subscription deleted, it returns model.ErrNotFound.
+func (r *postgresRenewalRepo) RenewSubscription(ctx context.Context, cfg model.AutoRenewalConfig) error {
+	const op = "repository.postgresql.renewals.RenewSubscription"
+
+	query := `
+		WITH renewal AS (
+			UPDATE auto_renewal_config 
+			SET 
+				next_renewal_date = next_renewal_date + renewal_duration_days 
+			WHERE 
+				subscription_id = $1 AND enabled AND next_renewal_date = $2::date AND 
+				subscription_id IN (SELECT id FROM subscriptions WHERE deleted_at IS NULL AND end_date IS NOT NULL) 
+			RETURNING subscription_id, renewal_duration_days
+		), changed AS (
+			UPDATE subscriptions s 
+			SET 
+				end_date = s.end_date + r.renewal_duration_days * INTERVAL '1 day', 
+				version = s.version + 1 
+			FROM 
+				renewal r 
+			WHERE 
+				s.id = r.subscription_id 
+			RETURNING s.id, s.tenant_id, s.user_id
+		)` + notifyChanged(model.EventRenewed)
+
+	result, err := r.db.ExecContext(ctx, query, cfg.SubscriptionID, cfg.NextRenewalDate)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: subscription %s: %w", op, cfg.SubscriptionID, model.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestRenewalRepo(t *testing.T) (RenewalRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewRenewalRepository(db), mock
+}
+
+func TestRenewalListDue(t *testing.T) {
+	repo, mock := newTestRenewalRepo(t)
+	subID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`c.enabled AND c.next_renewal_date <= $1::date AND s.deleted_at IS NULL AND s.end_date IS NOT NULL`)).
+		WithArgs(fixedTime()).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "enabled", "renewal_duration_days", "next_renewal_date"}).
+			AddRow(subID, true, 30, fixedTime()))
+
+	due, err := repo.ListDue(context.Background(), fixedTime())
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.AutoRenewalConfig{{
+		SubscriptionID: subID, Enabled: true, RenewalDurationDays: 30, NextRenewalDate: fixedTime(),
+	}}, due)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenewalRenewSubscription_Notifies(t *testing.T) {
+	repo, mock := newTestRenewalRepo(t)
+	cfg := model.AutoRenewalConfig{SubscriptionID: uuid.New(), Enabled: true, RenewalDurationDays: 30, NextRenewalDate: fixedTime()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`RETURNING s.id, s.tenant_id, s.user_id ) SELECT pg_notify('subscriptions_changed', json_build_object('event', 'renewed', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id)::text) FROM changed`)).
+		WithArgs(cfg.SubscriptionID, cfg.NextRenewalDate).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.RenewSubscription(context.Background(), cfg))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenewalRenewSubscription_AlreadyRenewed(t *testing.T) {
+	repo, mock := newTestRenewalRepo(t)
+	cfg := model.AutoRenewalConfig{SubscriptionID: uuid.New(), Enabled: true, RenewalDurationDays: 30, NextRenewalDate: fixedTime()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`subscription_id = $1 AND enabled AND next_renewal_date = $2::date`)).
+		WithArgs(cfg.SubscriptionID, cfg.NextRenewalDate).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.RenewSubscription(context.Background(), cfg)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// ReportRepository serves the summary report job. Its aggregates span
+// every tenant.
+type ReportRepository interface {
+	CountCreated(ctx context.Context, from, to time.Time) (int, error)
+	CountCancelled(ctx context.Context, from, to time.Time) (int, error)
+	ActiveCostByCycle(ctx context.Context, at time.Time) ([]model.BillingCycleSummary, error)
+	LastRun(ctx context.Context, report string) (time.Time, error)
+	RecordRun(ctx context.Context, report string, periodEnd time.Time) error
+}
+
+type postgresReportRepo struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &postgresReportRepo{db: db}
+}
+
+// CountCreated counts the subscriptions created in [from, to), including
+// those deleted since.
+func (r *postgresReportRepo) CountCreated(ctx context.Context, from, to time.Time) (int, error) {
+	const op = "repository.postgresql.reports.CountCreated"
+
+	query := `
+		SELECT 
+			COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE 
+			created_at >= $1 AND created_at < $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// CountCancelled counts the subscriptions deleted in [from, to), whether by
+// their user, the expiry cleanup or a merge. Deletion only sets deleted_at,
+// so none of them is lost.
+func (r *postgresReportRepo) CountCancelled(ctx context.Context, from, to time.Time) (int, error) {
+	const op = "repository.postgresql.reports.CountCancelled"
+
+	query := `
+		SELECT 
+			COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE 
+			deleted_at >= $1 AND deleted_at < $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// ActiveCostByCycle sums the subscriptions active at at per billing cycle.
+func (r *postgresReportRepo) ActiveCostByCycle(ctx context.Context, at time.Time) ([]model.BillingCycleSummary, error) {
+	const op = "repository.postgresql.reports.ActiveCostByCycle"
+
+	query := `
+		SELECT 
+			billing_cycle, COALESCE(SUM(price), 0), COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE 
+			deleted_at IS NULL AND 
+			start_date <= $1 AND 
+			(end_date IS NULL OR end_date >= $1) 
+		GROUP BY billing_cycle 
+		ORDER BY billing_cycle`
+
+	rows, err := r.db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var summaries []model.BillingCycleSummary
+	for rows.Next() {
+		var s model.BillingCycleSummary
+		if err := rows.Scan(&s.BillingCycle, &s.Total, &s.Count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		summaries = append(summaries, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return summaries, nil
+}
+
+// LastRun returns the end of the last period report was sent for, or the
+// zero time if it never was.
+func (r *postgresReportRepo) LastRun(ctx context.Context, report string) (time.Time, error) {
+	const op = "repository.postgresql.reports.LastRun"
+
+	query := `SELECT period_end FROM report_runs WHERE report = $1`
+
+	var periodEnd time.Time
+	err := r.db.QueryRowContext(ctx, query, report).Scan(&periodEnd)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return periodEnd, nil
+}
+
+// RecordRun records that report was sent for the period ending at
+// periodEnd. It never moves the recorded period back.
+func (r *postgresReportRepo) RecordRun(ctx context.Context, report string, periodEnd time.Time) error {
+	const op = "repository.postgresql.reports.RecordRun"
+
+	query := `
+		INSERT INTO report_runs 
+			(report, period_end) 
+		VALUES 
+			($1, $2) 
+		ON CONFLICT (report) DO UPDATE 
+		SET 
+			period_end = GREATEST(report_runs.period_end, EXCLUDED.period_end), 
+			sent_at = NOW()`
+
+	if _, err := r.db.ExecContext(ctx, query, report, periodEnd); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestReportRepo(t *testing.T) (ReportRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewReportRepository(db), mock
+}
+
+func TestReportCounts(t *testing.T) {
+	repo, mock := newTestReportRepo(t)
+	from, to := fixedTime(), fixedTime().Add(24*time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM subscriptions WHERE created_at >= $1 AND created_at < $2`)).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM subscriptions WHERE deleted_at >= $1 AND deleted_at < $2`)).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	created, err := repo.CountCreated(context.Background(), from, to)
+	require.NoError(t, err)
+	cancelled, err := repo.CountCancelled(context.Background(), from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, created)
+	assert.Equal(t, 1, cancelled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReportActiveCostByCycle(t *testing.T) {
+	repo, mock := newTestReportRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`deleted_at IS NULL AND start_date <= $1 AND (end_date IS NULL OR end_date >= $1) GROUP BY billing_cycle`)).
+		WithArgs(fixedTime()).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_cycle", "sum", "count"}).
+			AddRow("annual", 1200, 1).
+			AddRow("monthly", 1500, 2))
+
+	cycles, err := repo.ActiveCostByCycle(context.Background(), fixedTime())
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.BillingCycleSummary{
+		{BillingCycle: model.CycleAnnual, Total: 1200, Count: 1},
+		{BillingCycle: model.CycleMonthly, Total: 1500, Count: 2},
+	}, cycles)
+}
+
+func TestReportLastRun_NeverRun(t *testing.T) {
+	repo, mock := newTestReportRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT period_end FROM report_runs WHERE report = $1`)).
+		WithArgs("summary").
+		WillReturnRows(sqlmock.NewRows([]string{"period_end"}))
+
+	last, err := repo.LastRun(context.Background(), "summary")
+
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+}
+
+func TestReportRecordRun(t *testing.T) {
+	repo, mock := newTestReportRepo(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`ON CONFLICT (report) DO UPDATE SET period_end = GREATEST(report_runs.period_end, EXCLUDED.period_end)`)).
+		WithArgs("summary", fixedTime()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.RecordRun(context.Background(), "summary", fixedTime()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,13 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"SubscriptionAggregator/pkg/model"
 )
@@ -18,7 +20,7 @@ type Postgres struct {
 	DB *sql.DB
 }
 
-func New(ctx context.Context, connString string) (*Postgres, error) {
+func New(ctx context.Context, connString string, log *slog.Logger) (*Postgres, error) {
 	const op = "repository.postgresql.New"
 
 	db, err := sql.Open("postgres", connString)
@@ -35,7 +37,7 @@ func New(ctx context.Context, connString string) (*Postgres, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(time.Hour)
 
-	if err := RunMigrations(ctx, db); err != nil {
+	if err := RunMigrations(ctx, db, log); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("%s: migrations failed: %w", op, err)
 	}
@@ -47,68 +49,473 @@ func (p *Postgres) Close() error {
 	return p.DB.Close()
 }
 
+// The service tests use a mock of SubscriptionRepository generated into
+// pkg/mocks; run make mocks after changing the interface.
+//go:generate go run github.com/vektra/mockery/v2@v2.53.3 --name=SubscriptionRepository --outpkg=mocks --output=../mocks --filename=subscription_repository.go
+
+// SubscriptionRepository stores subscriptions of many tenants side by side.
+// Every method is confined to one tenant, given by tenantID, by
+// Subscription.TenantID or by SubscriptionFilter.TenantID, except
+// CountCreatedBetween and GetTotalCostByUser, which report on the whole
+// deployment.
 type SubscriptionRepository interface {
 	Create(ctx context.Context, sub *model.Subscription) error
-	GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	BulkCreate(ctx context.Context, subs []*model.Subscription) error
+	ExistsActiveOverlap(ctx context.Context, tenantID, userID uuid.UUID, serviceName string, start time.Time, end *time.Time) (*uuid.UUID, error)
+	GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error)
+	GetByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*model.Subscription, error)
+	GetPriceHistory(ctx context.Context, tenantID, id uuid.UUID) ([]int, error)
+	StreamPriceHistoryByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.PriceChange) error) error
+	RecordPayment(ctx context.Context, tenantID uuid.UUID, payment *model.Payment) error
+	ListPayments(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Payment, error)
+	LockSubscription(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error)
 	Update(ctx context.Context, sub *model.Subscription) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error)
+	NotifyPriceChanged(ctx context.Context, tenantID, id uuid.UUID, oldPrice, newPrice int) error
+	UpdateServicePrice(ctx context.Context, update model.ServicePriceUpdate) (*model.ServicePriceUpdateResult, error)
+	Delete(ctx context.Context, tenantID, id uuid.UUID) error
+	List(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error)
+	StreamUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID, fn func(*model.Subscription) error) error
 	GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error)
+	Count(ctx context.Context, filter model.SubscriptionFilter) (int, error)
+	GetUserLimit(ctx context.Context, tenantID, userID uuid.UUID) (int, error)
+	LockUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID) error
+	GetPaidTotal(ctx context.Context, filter model.SubscriptionFilter) (int, error)
+	GetProratedTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error)
+	GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error)
+	GetProratedCostPerUser(ctx context.Context, filter model.SubscriptionFilter) (map[uuid.UUID]int, error)
+	ListServices(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.ServiceSummary, error)
+	ShareSubscription(ctx context.Context, tenantID uuid.UUID, share *model.ShareEntry) error
+	CreateShares(ctx context.Context, tenantID uuid.UUID, shares []model.ShareEntry) error
+	UnshareSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error
+	GetSharedUsers(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.ShareEntry, error)
+	PinSubscription(ctx context.Context, tenantID uuid.UUID, pin *model.Pin) error
+	UnpinSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error
+	ListExpired(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error)
+	SoftDeleteExpired(ctx context.Context, tenantID, userID uuid.UUID) (int, error)
+	GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error)
+	GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error)
+	GetCostByService(ctx context.Context, filter model.SubscriptionFilter) ([]model.ServiceCost, error)
+	ListExpiringSoonByService(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error)
+	CountByStatus(ctx context.Context, tenantID, userID uuid.UUID) (active, expired int, err error)
+	GetActiveCostByCycle(ctx context.Context, tenantID, userID uuid.UUID) ([]model.BillingCycleSummary, error)
+	GetMostExpensiveActive(ctx context.Context, tenantID, userID uuid.UUID) (string, error)
+	GetNextExpiry(ctx context.Context, tenantID, userID uuid.UUID) (*time.Time, error)
+	CountCreatedBetween(ctx context.Context, from, to time.Time) (int, error)
+	GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error)
+	Transactional(ctx context.Context) (context.Context, CommitFunc, RollbackFunc, error)
+}
+
+// ChangesChannel is the NOTIFY channel that carries model.SubscriptionEvent
+// payloads for every created, updated or deleted subscription and every
+// breached spending limit.
+const ChangesChannel = "subscriptions_changed"
+
+// notifyChanged turns a data-modifying CTE named "changed" that returns id,
+// tenant_id and user_id into a statement that also publishes one event per
+// affected row. The notification is delivered only if the statement
+// commits, and RowsAffected still reports the number of changed rows.
+func notifyChanged(event model.SubscriptionEventType) string {
+	return `
+		SELECT 
+			pg_notify('` + ChangesChannel + `', json_build_object('event', '` + string(event) + `', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id)::text) 
+		FROM 
+			changed`
+}
+
+// subscriptionFilterClause is shared by every query that honours
+// model.SubscriptionFilter; its placeholders match filterArgs. Soft-deleted
+// rows and rows of other tenants never match. The date bounds select
+// subscriptions that overlap the window, both ends included, and an open
+// end_date overlaps every later day. Several users go in $8 instead of $1;
+// service names always go in the $2 array, see serviceNamesArg.
+const subscriptionFilterClause = `
+			deleted_at IS NULL AND
+			tenant_id = $6 AND
+			($1::uuid IS NULL OR user_id = $1 OR
+				($5::boolean AND id IN (
+					SELECT subscription_id FROM subscription_shares WHERE shared_with_user_id = $1))) AND
+			($8::uuid[] IS NULL OR user_id = ANY($8)) AND
+			($2::text[] IS NULL OR service_name = ANY($2)) AND
+			($3::timestamp IS NULL OR end_date IS NULL OR end_date >= $3) AND
+			($4::timestamp IS NULL OR start_date <= $4) AND
+			(NOT $7::boolean OR id IN (
+				SELECT subscription_id FROM pinned_subscriptions WHERE user_id = $1)) AND
+			(NOT $9::boolean OR end_date IS NULL OR end_date >= NOW())`
+
+// allTenantsFilterClause is the part of subscriptionFilterClause that makes
+// sense across tenants: service names as $1 and the date window as $2 and $3,
+// see allTenantsFilterArgs.
+const allTenantsFilterClause = `
+			deleted_at IS NULL AND
+			($1::text[] IS NULL OR service_name = ANY($1)) AND
+			($2::timestamp IS NULL OR end_date IS NULL OR end_date >= $2) AND
+			($3::timestamp IS NULL OR start_date <= $3)`
+
+func allTenantsFilterArgs(filter model.SubscriptionFilter) []any {
+	return []any{serviceNamesArg(filter), filter.FromDate, filter.ToDate}
+}
+
+// billedMonths counts the calendar months between period_start and
+// period_end, both included, as model.Subscription.BilledMonths does.
+const billedMonths = `GREATEST(0, 
+					EXTRACT(YEAR FROM period_end) * 12 + EXTRACT(MONTH FROM period_end) - 
+					EXTRACT(YEAR FROM period_start) * 12 - EXTRACT(MONTH FROM period_start) + 1)`
+
+// chargesPerYear is how many times a row's price is charged in a year; it
+// mirrors model.BillingCycle.MonthlyEquivalent.
+const chargesPerYear = `CASE billing_cycle 
+				WHEN 'weekly' THEN 52 
+				WHEN 'quarterly' THEN 4 
+				WHEN 'annual' THEN 1 
+				ELSE 12 
+			END`
+
+// serviceNamesArg passes ServiceName and ServiceNames as one array, or
+// NULL when there are none.
+func serviceNamesArg(filter model.SubscriptionFilter) any {
+	names := filter.AllServiceNames()
+	if len(names) == 0 {
+		return nil
+	}
+	return pq.StringArray(names)
+}
+
+// filterArgs passes a single user, whether given as UserID or in UserIDs,
+// as $1 so that sharing and pins apply to it, and several as the $8 array.
+func filterArgs(filter model.SubscriptionFilter) []any {
+	var userID *uuid.UUID
+	var userIDs any
+	switch ids := filter.AllUserIDs(); len(ids) {
+	case 0:
+	case 1:
+		userID = &ids[0]
+	default:
+		strs := make([]string, len(ids))
+		for i, id := range ids {
+			strs[i] = id.String()
+		}
+		userIDs = pq.StringArray(strs)
+	}
+
+	return []any{
+		userID,
+		serviceNamesArg(filter),
+		filter.FromDate,
+		filter.ToDate,
+		filter.SharedWithMe,
+		filter.TenantID,
+		filter.PinnedOnly,
+		userIDs,
+		filter.OnlyActive,
+	}
 }
 
+// DefaultQueryTimeout bounds each repository call when no other timeout is
+// configured.
+const DefaultQueryTimeout = 5 * time.Second
+
 type postgresSubscriptionRepo struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func NewSubscriptionRepository(db *sql.DB) SubscriptionRepository {
-	return &postgresSubscriptionRepo{db: db}
+type RepositoryOption func(*postgresSubscriptionRepo)
+
+// WithQueryTimeout bounds every query so a slow one releases its pool
+// connection instead of holding it until the client gives up. Zero keeps
+// DefaultQueryTimeout.
+func WithQueryTimeout(d time.Duration) RepositoryOption {
+	return func(r *postgresSubscriptionRepo) {
+		if d > 0 {
+			r.queryTimeout = d
+		}
+	}
 }
 
-func (r *postgresSubscriptionRepo) Create(ctx context.Context, sub *model.Subscription) error {
-	const op = "repository.postgresql.Create"
+func NewSubscriptionRepository(db *sql.DB, opts ...RepositoryOption) SubscriptionRepository {
+	r := &postgresSubscriptionRepo{db: db, queryTimeout: DefaultQueryTimeout}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
 
-	query := `
-		INSERT INTO subscriptions 
-			(id, service_name, price, user_id, start_date, end_date) 
-		VALUES 
-			($1, $2, $3, $4, $5, $6)`
+// withTimeout derives the context a single repository call runs its
+// queries under.
+func (r *postgresSubscriptionRepo) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// firstVersion is the version of a new subscription, the default of the
+// version column.
+const firstVersion = 1
 
-	_, err := r.db.ExecContext(ctx, query,
+// insertSubscriptionQuery is shared by Create and BulkCreate; its
+// placeholders match insertArgs.
+var insertSubscriptionQuery = `
+		WITH changed AS (
+			INSERT INTO subscriptions 
+				(id, service_name, price, user_id, start_date, end_date, billing_cycle, tenant_id, metadata, catalog_service_id) 
+			VALUES 
+				($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
+			RETURNING id, tenant_id, user_id
+		)` + notifyChanged(model.EventCreated)
+
+func insertArgs(sub *model.Subscription) []any {
+	return []any{
 		sub.ID,
 		sub.ServiceName,
 		sub.Price,
 		sub.UserID,
 		sub.StartDate,
-		sub.EndDate)
+		sub.EndDate,
+		sub.BillingCycle,
+		sub.TenantID,
+		metadataArg(sub.Metadata),
+		sub.CatalogServiceID,
+	}
+}
+
+// metadataArg passes metadata as text: pq sends a []byte as bytea, which
+// Postgres will not store in a jsonb column.
+func metadataArg(metadata json.RawMessage) any {
+	if metadata == nil {
+		return nil
+	}
+	return string(metadata)
+}
+
+// metadataDest is the scan destination of the metadata column. database/sql
+// only turns NULL into nil for a plain *[]byte, not for json.RawMessage.
+func metadataDest(sub *model.Subscription) *[]byte {
+	return (*[]byte)(&sub.Metadata)
+}
+
+func (r *postgresSubscriptionRepo) Create(ctx context.Context, sub *model.Subscription) error {
+	const op = "repository.postgresql.Create"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.conn(ctx).ExecContext(ctx, insertSubscriptionQuery, insertArgs(sub)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, classifyError(err))
+	}
+	sub.Version = firstVersion
+
+	return nil
+}
+
+// BulkCreate inserts subs in a single transaction: either all of them are
+// stored or, on the first failure, none are. Inside Transactional it uses
+// that transaction and leaves the commit to the caller.
+func (r *postgresSubscriptionRepo) BulkCreate(ctx context.Context, subs []*model.Subscription) error {
+	const op = "repository.postgresql.BulkCreate"
+
+	tx, inTx := ctx.Value(txKey{}).(*sql.Tx)
+	if !inTx {
+		var err error
+		if tx, err = r.db.BeginTx(ctx, nil); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		defer tx.Rollback()
+	}
 
+	stmt, err := tx.PrepareContext(ctx, insertSubscriptionQuery)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer stmt.Close()
+
+	for _, sub := range subs {
+		// Each row gets the full query timeout; a large import would not
+		// fit into a single one.
+		execCtx, cancel := r.withTimeout(ctx)
+		_, err := stmt.ExecContext(execCtx, insertArgs(sub)...)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, classifyError(err))
+		}
+	}
+
+	if !inTx {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	for _, sub := range subs {
+		sub.Version = firstVersion
+	}
 
 	return nil
 }
 
-func (r *postgresSubscriptionRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+// ExistsActiveOverlap returns the ID of a live subscription of userID to
+// serviceName whose period overlaps [start, end], or nil if there is none;
+// a nil end means the new subscription never ends. A subscription that
+// ends on the day the other starts is a renewal, not an overlap.
+func (r *postgresSubscriptionRepo) ExistsActiveOverlap(ctx context.Context, tenantID, userID uuid.UUID, serviceName string, start time.Time, end *time.Time) (*uuid.UUID, error) {
+	const op = "repository.postgresql.ExistsActiveOverlap"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			id 
+		FROM 
+			subscriptions 
+		WHERE 
+			deleted_at IS NULL AND 
+			tenant_id = $1 AND user_id = $2 AND service_name = $3 AND 
+			(end_date IS NULL OR end_date > $4) AND 
+			($5::timestamp IS NULL OR start_date < $5) 
+		ORDER BY 
+			start_date, id 
+		LIMIT 1`
+
+	var id uuid.UUID
+	err := r.conn(ctx).QueryRowContext(ctx, query, tenantID, userID, serviceName, start, end).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &id, nil
+}
+
+func (r *postgresSubscriptionRepo) GetByID(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
 	const op = "repository.postgresql.GetByID"
 
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+
+	sub := model.Subscription{TenantID: tenantID}
+	err := r.db.QueryRowContext(ctx, query, id, tenantID).Scan(
+		&sub.ID,
+		&sub.ServiceName,
+		&sub.Price,
+		&sub.UserID,
+		&sub.StartDate,
+		&sub.EndDate,
+		&sub.BillingCycle,
+		metadataDest(&sub),
+		&sub.CatalogServiceID,
+		&sub.Version,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &sub, nil
+}
+
+// GetByIDs returns the subscriptions among ids in no particular order;
+// ids that do not exist, are deleted or belong to another tenant are left
+// out.
+func (r *postgresSubscriptionRepo) GetByIDs(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]*model.Subscription, error) {
+	const op = "repository.postgresql.GetByIDs"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = ANY($1::uuid[]) AND tenant_id = $2 AND deleted_at IS NULL`
+
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, pq.StringArray(strs), tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*model.Subscription
+	for rows.Next() {
+		sub := model.Subscription{TenantID: tenantID}
+		err := rows.Scan(
+			&sub.ID,
+			&sub.ServiceName,
+			&sub.Price,
+			&sub.UserID,
+			&sub.StartDate,
+			&sub.EndDate,
+			&sub.BillingCycle,
+			metadataDest(&sub),
+			&sub.CatalogServiceID,
+			&sub.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan subscription: %w", op, err)
+		}
+		subscriptions = append(subscriptions, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return subscriptions, nil
+}
+
+// LockSubscription is GetByID that also locks the row until the
+// transaction ends, so another LockSubscription of it waits and then reads
+// what this one committed. It must be called with a context from
+// Transactional; without one the lock is released as soon as it is taken.
+func (r *postgresSubscriptionRepo) LockSubscription(ctx context.Context, tenantID, id uuid.UUID) (*model.Subscription, error) {
+	const op = "repository.postgresql.LockSubscription"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT 
-			id, service_name, price, user_id, start_date, end_date 
+			id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version 
 		FROM 
 			subscriptions 
 		WHERE 
-			id = $1`
+			id = $1 AND tenant_id = $2 AND deleted_at IS NULL 
+		FOR UPDATE`
 
-	var sub model.Subscription
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	sub := model.Subscription{TenantID: tenantID}
+	err := r.conn(ctx).QueryRowContext(ctx, query, id, tenantID).Scan(
 		&sub.ID,
 		&sub.ServiceName,
 		&sub.Price,
 		&sub.UserID,
 		&sub.StartDate,
 		&sub.EndDate,
+		&sub.BillingCycle,
+		metadataDest(&sub),
+		&sub.CatalogServiceID,
+		&sub.Version,
 	)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -116,31 +523,47 @@ func (r *postgresSubscriptionRepo) GetByID(ctx context.Context, id uuid.UUID) (*
 	return &sub, nil
 }
 
+// Update stores sub if sub.Version is still the current version and then
+// advances sub.Version. An update made from an older version fails with a
+// *model.VersionConflictError carrying the current one.
 func (r *postgresSubscriptionRepo) Update(ctx context.Context, sub *model.Subscription) error {
 	const op = "repository.postgresql.Update"
 
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
 	query := `
-		UPDATE subscriptions 
-		SET 
-			service_name = $2, 
-			price = $3, 
-			user_id = $4, 
-			start_date = $5, 
-			end_date = $6 
-		WHERE 
-			id = $1`
+		WITH changed AS (
+			UPDATE subscriptions 
+			SET 
+				service_name = $2, 
+				price = $3, 
+				user_id = $4, 
+				start_date = $5, 
+				end_date = $6, 
+				billing_cycle = $7, 
+				metadata = $8, 
+				version = version + 1 
+			WHERE 
+				id = $1 AND tenant_id = $9 AND deleted_at IS NULL AND version = $10 
+			RETURNING id, tenant_id, user_id
+		)` + notifyChanged(model.EventUpdated)
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.conn(ctx).ExecContext(ctx, query,
 		sub.ID,
 		sub.ServiceName,
 		sub.Price,
 		sub.UserID,
 		sub.StartDate,
 		sub.EndDate,
+		sub.BillingCycle,
+		metadataArg(sub.Metadata),
+		sub.TenantID,
+		sub.Version,
 	)
 
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, classifyError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -149,20 +572,52 @@ func (r *postgresSubscriptionRepo) Update(ctx context.Context, sub *model.Subscr
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("not found")
+		return fmt.Errorf("%s: %w", op, r.missedUpdate(ctx, sub))
 	}
+	sub.Version++
 
 	return nil
 }
 
-func (r *postgresSubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+// missedUpdate explains why Update matched no row: the subscription is
+// gone, or it exists under another version.
+func (r *postgresSubscriptionRepo) missedUpdate(ctx context.Context, sub *model.Subscription) error {
+	query := `
+		SELECT 
+			version 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+
+	var current int
+	err := r.conn(ctx).QueryRowContext(ctx, query, sub.ID, sub.TenantID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return &model.VersionConflictError{Current: current}
+}
+
+// Delete soft-deletes the subscription, like SoftDeleteExpired: the row
+// stays for the reports, which count deletions and creations, and drops
+// out of everything else. Deleting it again is ErrNotFound.
+func (r *postgresSubscriptionRepo) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
 	const op = "repository.postgresql.Delete"
 
-	query := `DELETE FROM subscriptions WHERE id = $1`
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH changed AS (
+			UPDATE subscriptions SET deleted_at = NOW() WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL RETURNING id, tenant_id, user_id
+		)` + notifyChanged(model.EventDeleted)
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, id, tenantID)
 	if err != nil {
-		return fmt.Errorf("%s: failed to delete subscription: %w", op, err)
+		return fmt.Errorf("%s: failed to delete subscription: %w", op, classifyError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -171,33 +626,82 @@ func (r *postgresSubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) err
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s, subscription not found", op)
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
 	}
 
 	return nil
 }
 
-func (r *postgresSubscriptionRepo) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+// listSearchClause narrows List to service names containing
+// SubscriptionFilter.Search; $10 is its containsPattern. The trigram index
+// of migration 012 serves the unanchored match.
+const listSearchClause = ` AND 
+			($10::text IS NULL OR service_name ILIKE $10)`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern turns search into an ILIKE pattern matching it anywhere,
+// with the wildcards a user may type matched literally. A nil search is
+// no filter.
+func containsPattern(search *string) *string {
+	if search == nil {
+		return nil
+	}
+	pattern := "%" + likeEscaper.Replace(*search) + "%"
+	return &pattern
+}
+
+func (r *postgresSubscriptionRepo) List(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error) {
 	const op = "repository.postgresql.List"
 
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// pinned is false for every row when no user_id is given.
 	query := `
 		SELECT 
-			id, service_name, price, user_id, start_date, end_date 
+			id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version, 
+			EXISTS (
+				SELECT 1 FROM pinned_subscriptions p 
+				WHERE p.subscription_id = subscriptions.id AND p.user_id = $1
+			) AS pinned 
 		FROM 
 			subscriptions 
-		WHERE 
-			($1::uuid IS NULL OR user_id = $1) AND
-			($2::text IS NULL OR service_name = $2) AND
-			($3::timestamp IS NULL OR start_date >= $3) AND
-			($4::timestamp IS NULL OR (end_date IS NULL OR end_date <= $4))`
-
-	rows, err := r.db.QueryContext(ctx, query,
-		filter.UserID,
-		filter.ServiceName,
-		filter.FromDate,
-		filter.ToDate,
-	)
+		WHERE ` + subscriptionFilterClause + listSearchClause + ` 
+		ORDER BY 
+			start_date, id`
+
+	countQuery := `
+		SELECT 
+			COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE ` + subscriptionFilterClause + listSearchClause
+
+	args := append(filterArgs(filter), containsPattern(filter.Search))
+	// The count runs concurrently with args, so the page bounds go into a
+	// copy.
+	listArgs := args
+	if filter.Limit > 0 {
+		query += ` 
+		LIMIT $11 OFFSET $12`
+		listArgs = append(args[:len(args):len(args)], filter.Limit, filter.Offset)
+	}
+
+	type countResult struct {
+		total int
+		err   error
+	}
+	countCh := make(chan countResult, 1)
+	go func() {
+		var total int
+		err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+		countCh <- countResult{total: total, err: err}
+	}()
+
+	rows, err := r.db.QueryContext(ctx, query, listArgs...)
 	if err != nil {
+		<-countCh
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
@@ -212,41 +716,104 @@ func (r *postgresSubscriptionRepo) List(ctx context.Context, filter model.Subscr
 			&sub.UserID,
 			&sub.StartDate,
 			&sub.EndDate,
+			&sub.BillingCycle,
+			metadataDest(&sub),
+			&sub.CatalogServiceID,
+			&sub.Version,
+			&sub.Pinned,
 		)
 		if err != nil {
+			<-countCh
 			return nil, fmt.Errorf("%s: failed to scan subscription: %w", op, err)
 		}
 		subscriptions = append(subscriptions, &sub)
 	}
 
 	if err := rows.Err(); err != nil {
+		<-countCh
 		return nil, fmt.Errorf("%s: rows error: %w", op, err)
 	}
 
-	return subscriptions, nil
+	count := <-countCh
+	if count.err != nil {
+		return nil, fmt.Errorf("%s: failed to count subscriptions: %w", op, count.err)
+	}
+
+	return &model.ListResult{Items: subscriptions, TotalCount: count.total}, nil
+}
+
+// StreamUserSubscriptions passes every live subscription of the user to
+// fn, in List's order, as it is read. An error from fn stops the stream
+// and is returned as is. There is no query timeout: fn may be writing to a
+// slow client, and ctx ends with the request.
+func (r *postgresSubscriptionRepo) StreamUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID, fn func(*model.Subscription) error) error {
+	const op = "repository.postgresql.StreamUserSubscriptions"
+
+	query := `
+		SELECT 
+			id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version, 
+			EXISTS (
+				SELECT 1 FROM pinned_subscriptions p 
+				WHERE p.subscription_id = subscriptions.id AND p.user_id = $1
+			) AS pinned 
+		FROM 
+			subscriptions 
+		WHERE 
+			user_id = $1 AND tenant_id = $2 AND deleted_at IS NULL 
+		ORDER BY 
+			start_date, id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub := model.Subscription{TenantID: tenantID}
+		err := rows.Scan(
+			&sub.ID,
+			&sub.ServiceName,
+			&sub.Price,
+			&sub.UserID,
+			&sub.StartDate,
+			&sub.EndDate,
+			&sub.BillingCycle,
+			metadataDest(&sub),
+			&sub.CatalogServiceID,
+			&sub.Version,
+			&sub.Pinned,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: failed to scan subscription: %w", op, err)
+		}
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return nil
 }
 
 func (r *postgresSubscriptionRepo) GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
 	const op = "repository.postgresql.GetTotalCost"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 	//COALESCE to prevent null error
 	query := `
 		SELECT 
 			COALESCE(SUM(price), 0) 
 		FROM 
 			subscriptions 
-		WHERE 
-			($1::uuid IS NULL OR user_id = $1) AND
-			($2::text IS NULL OR service_name = $2) AND
-			($3::timestamp IS NULL OR start_date >= $3) AND
-			($4::timestamp IS NULL OR (end_date IS NULL OR end_date <= $4))`
+		WHERE ` + subscriptionFilterClause
 
 	var total int
-	err := r.db.QueryRowContext(ctx, query,
-		filter.UserID,
-		filter.ServiceName,
-		filter.FromDate,
-		filter.ToDate,
-	).Scan(&total)
+	err := r.db.QueryRowContext(ctx, query, filterArgs(filter)...).Scan(&total)
 
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
@@ -255,24 +822,756 @@ func (r *postgresSubscriptionRepo) GetTotalCost(ctx context.Context, filter mode
 	return total, nil
 }
 
-func RunMigrations(ctx context.Context, db *sql.DB) error {
-	const op = "repository.postgresql.RunMigrations"
+// Count returns the number of subscriptions matching filter, ended ones
+// included unless filter.OnlyActive is set.
+func (r *postgresSubscriptionRepo) Count(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	const op = "repository.postgresql.Count"
 
-	wd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("%s: failed to get working directory: %w", op, err)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE ` + subscriptionFilterClause
+
+	var count int
+	if err := r.conn(ctx).QueryRowContext(ctx, query, filterArgs(filter)...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	migrationPath := filepath.Join(wd, "migrations", "001_init.sql")
+	return count, nil
+}
+
+// GetUserLimit returns the most subscriptions the user may have, zero
+// meaning unlimited, or model.ErrNotFound if the user has no limit of
+// their own.
+func (r *postgresSubscriptionRepo) GetUserLimit(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	const op = "repository.postgresql.GetUserLimit"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			max_subscriptions 
+		FROM 
+			user_limits 
+		WHERE 
+			tenant_id = $1 AND user_id = $2`
 
-	migration, err := os.ReadFile(migrationPath)
+	var limit int
+	err := r.conn(ctx).QueryRowContext(ctx, query, tenantID, userID).Scan(&limit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("%s, failed to read migration file at %s: %w", op, migrationPath, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if _, err := db.ExecContext(ctx, string(migration)); err != nil {
-		return fmt.Errorf("%s: failed to execute migration: %w", op, err)
-	}
+	return limit, nil
+}
+
+// LockUserSubscriptions locks the user's subscriptions as a whole until
+// the transaction ends, so that a second caller waits and then counts
+// what the first one committed. It is an advisory lock rather than a row
+// lock: a user on the default limit has no user_limits row to lock. It
+// must be called with a context from Transactional; without one the lock
+// is released as soon as it is taken.
+func (r *postgresSubscriptionRepo) LockUserSubscriptions(ctx context.Context, tenantID, userID uuid.UUID) error {
+	const op = "repository.postgresql.LockUserSubscriptions"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
+	query := `SELECT pg_advisory_xact_lock(hashtextextended('user_subscriptions:' || $1::text || ':' || $2::text, 0))`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, tenantID, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
+
+// GetProratedTotalCost charges every matching subscription for each month
+// it is billed within the filter's window, see
+// model.Subscription.BilledMonths; non-monthly prices are spread evenly over
+// the months. A window without ToDate ends now. LEAST and GREATEST skip a
+// NULL bound, which leaves the subscription's own dates in place.
+func (r *postgresSubscriptionRepo) GetProratedTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
+	const op = "repository.postgresql.GetProratedTotalCost"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COALESCE(ROUND(SUM(price * charges * months / 12.0)), 0)::bigint 
+		FROM (
+			SELECT 
+				price, 
+				` + chargesPerYear + ` AS charges, 
+				` + billedMonths + ` AS months 
+			FROM (
+				SELECT 
+					price, billing_cycle, 
+					GREATEST(start_date, $3::timestamp) AS period_start, 
+					LEAST(end_date, COALESCE($4::timestamp, NOW())) AS period_end 
+				FROM 
+					subscriptions 
+				WHERE ` + subscriptionFilterClause + `
+			) AS windowed
+		) AS billed`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, filterArgs(filter)...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return total, nil
+}
+
+// GetProratedCostPerUser is GetProratedTotalCost broken down by the user
+// owning the subscriptions. Users without a matching subscription are
+// left out.
+func (r *postgresSubscriptionRepo) GetProratedCostPerUser(ctx context.Context, filter model.SubscriptionFilter) (map[uuid.UUID]int, error) {
+	const op = "repository.postgresql.GetProratedCostPerUser"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			user_id, 
+			COALESCE(ROUND(SUM(price * charges * months / 12.0)), 0)::bigint 
+		FROM (
+			SELECT 
+				user_id, price, 
+				` + chargesPerYear + ` AS charges, 
+				` + billedMonths + ` AS months 
+			FROM (
+				SELECT 
+					user_id, price, billing_cycle, 
+					GREATEST(start_date, $3::timestamp) AS period_start, 
+					LEAST(end_date, COALESCE($4::timestamp, NOW())) AS period_end 
+				FROM 
+					subscriptions 
+				WHERE ` + subscriptionFilterClause + `
+			) AS windowed
+		) AS billed 
+		GROUP BY 
+			user_id`
+
+	rows, err := r.db.QueryContext(ctx, query, filterArgs(filter)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	totals := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var userID uuid.UUID
+		var total int
+		if err := rows.Scan(&userID, &total); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan user cost: %w", op, err)
+		}
+		totals[userID] = total
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return totals, nil
+}
+
+// GetMonthlyCost returns one entry per calendar month from filter.FromDate
+// to filter.ToDate, both required, with the monthly equivalent of every
+// matching subscription active in that month; months without any are 0.
+// It agrees with GetProratedTotalCost up to rounding.
+func (r *postgresSubscriptionRepo) GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error) {
+	const op = "repository.postgresql.GetMonthlyCost"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			months.month, 
+			COALESCE(ROUND(SUM(active.price * active.charges / 12.0)), 0)::bigint 
+		FROM 
+			generate_series(
+				date_trunc('month', $3::timestamp), 
+				date_trunc('month', $4::timestamp), 
+				interval '1 month'
+			) AS months(month) 
+		LEFT JOIN (
+			SELECT 
+				price, 
+				` + chargesPerYear + ` AS charges, 
+				date_trunc('month', start_date) AS first_month, 
+				date_trunc('month', end_date) AS last_month 
+			FROM 
+				subscriptions 
+			WHERE ` + subscriptionFilterClause + `
+		) AS active ON 
+			active.first_month <= months.month AND 
+			(active.last_month IS NULL OR active.last_month >= months.month) 
+		GROUP BY 
+			months.month 
+		ORDER BY 
+			months.month`
+
+	rows, err := r.db.QueryContext(ctx, query, filterArgs(filter)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var months []model.MonthlyCost
+	for rows.Next() {
+		var month model.MonthlyCost
+		if err := rows.Scan(&month.Month, &month.Total); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan monthly cost: %w", op, err)
+		}
+		month.Month = month.Month.UTC()
+		months = append(months, month)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return months, nil
+}
+
+func (r *postgresSubscriptionRepo) ListServices(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID) ([]*model.ServiceSummary, error) {
+	const op = "repository.postgresql.ListServices"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			service_name, COUNT(*) AS subscription_count 
+		FROM 
+			subscriptions 
+		WHERE 
+			deleted_at IS NULL AND 
+			tenant_id = $2 AND 
+			($1::uuid IS NULL OR user_id = $1) 
+		GROUP BY 
+			service_name 
+		ORDER BY 
+			service_name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var services []*model.ServiceSummary
+	for rows.Next() {
+		var svc model.ServiceSummary
+		if err := rows.Scan(&svc.ServiceName, &svc.SubscriptionCount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan service summary: %w", op, err)
+		}
+		services = append(services, &svc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return services, nil
+}
+
+// ShareSubscription grants share.UserID access to the subscription, updating
+// the permission if the user already has one.
+func (r *postgresSubscriptionRepo) ShareSubscription(ctx context.Context, tenantID uuid.UUID, share *model.ShareEntry) error {
+	const op = "repository.postgresql.ShareSubscription"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if err := insertShare(ctx, r.db, tenantID, share); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CreateShares stores shares one after another and fills in their
+// CreatedAt. Run it inside Transactional to get all of them or none.
+func (r *postgresSubscriptionRepo) CreateShares(ctx context.Context, tenantID uuid.UUID, shares []model.ShareEntry) error {
+	const op = "repository.postgresql.CreateShares"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	conn := r.conn(ctx)
+	for i := range shares {
+		if err := insertShare(ctx, conn, tenantID, &shares[i]); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// insertShare grants share, or changes the permission of an existing one.
+func insertShare(ctx context.Context, conn querier, tenantID uuid.UUID, share *model.ShareEntry) error {
+	query := `
+		INSERT INTO subscription_shares 
+			(subscription_id, shared_with_user_id, permission) 
+		SELECT 
+			id, $2, $3 
+		FROM 
+			subscriptions 
+		WHERE 
+			id = $1 AND tenant_id = $4 AND deleted_at IS NULL 
+		ON CONFLICT (subscription_id, shared_with_user_id) 
+			DO UPDATE SET permission = EXCLUDED.permission 
+		RETURNING 
+			created_at`
+
+	err := conn.QueryRowContext(ctx, query,
+		share.SubscriptionID,
+		share.UserID,
+		share.Permission,
+		tenantID,
+	).Scan(&share.CreatedAt)
+
+	err = classifyError(err)
+	// The only reference is the subscription in the URL, so a dangling one
+	// means the subscription itself does not exist, or not for this tenant.
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, model.ErrInvalidReference) {
+		return fmt.Errorf("subscription %s: %w", share.SubscriptionID, model.ErrNotFound)
+	}
+	return err
+}
+
+func (r *postgresSubscriptionRepo) UnshareSubscription(ctx context.Context, tenantID, subscriptionID, userID uuid.UUID) error {
+	const op = "repository.postgresql.UnshareSubscription"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		DELETE FROM subscription_shares 
+		WHERE 
+			subscription_id = $1 AND shared_with_user_id = $2 AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $3)`
+
+	result, err := r.db.ExecContext(ctx, query, subscriptionID, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *postgresSubscriptionRepo) GetSharedUsers(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.ShareEntry, error) {
+	const op = "repository.postgresql.GetSharedUsers"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			subscription_id, shared_with_user_id, permission, created_at 
+		FROM 
+			subscription_shares 
+		WHERE 
+			subscription_id = $1 AND 
+			subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $2 AND deleted_at IS NULL) 
+		ORDER BY 
+			created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var shares []model.ShareEntry
+	for rows.Next() {
+		var share model.ShareEntry
+		if err := rows.Scan(&share.SubscriptionID, &share.UserID, &share.Permission, &share.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan share: %w", op, err)
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return shares, nil
+}
+
+// ListExpired returns subscriptions matching filter whose end_date has
+// already passed.
+func (r *postgresSubscriptionRepo) ListExpired(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+	const op = "repository.postgresql.ListExpired"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version 
+		FROM 
+			subscriptions 
+		WHERE ` + subscriptionFilterClause + ` AND 
+			end_date IS NOT NULL AND end_date < NOW() 
+		ORDER BY 
+			end_date`
+
+	rows, err := r.db.QueryContext(ctx, query, filterArgs(filter)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*model.Subscription
+	for rows.Next() {
+		var sub model.Subscription
+		err := rows.Scan(
+			&sub.ID,
+			&sub.ServiceName,
+			&sub.Price,
+			&sub.UserID,
+			&sub.StartDate,
+			&sub.EndDate,
+			&sub.BillingCycle,
+			metadataDest(&sub),
+			&sub.CatalogServiceID,
+			&sub.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan subscription: %w", op, err)
+		}
+		subscriptions = append(subscriptions, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return subscriptions, nil
+}
+
+// SoftDeleteExpired marks every expired subscription of userID as deleted
+// and returns how many rows were affected.
+func (r *postgresSubscriptionRepo) SoftDeleteExpired(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	const op = "repository.postgresql.SoftDeleteExpired"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		WITH changed AS (
+			UPDATE subscriptions 
+			SET 
+				deleted_at = NOW() 
+			WHERE 
+				user_id = $1 AND 
+				tenant_id = $2 AND 
+				deleted_at IS NULL AND 
+				end_date IS NOT NULL AND end_date < NOW() 
+			RETURNING id, tenant_id, user_id
+		)` + notifyChanged(model.EventDeleted)
+
+	result, err := r.db.ExecContext(ctx, query, userID, tenantID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to check rows affected: %w", op, err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetCostByCycle sums prices of subscriptions matching filter per billing
+// cycle. Totals are in each cycle's own period, not normalised.
+func (r *postgresSubscriptionRepo) GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error) {
+	const op = "repository.postgresql.GetCostByCycle"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			billing_cycle, COALESCE(SUM(price), 0), COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE ` + subscriptionFilterClause + ` 
+		GROUP BY 
+			billing_cycle 
+		ORDER BY 
+			billing_cycle`
+
+	rows, err := r.db.QueryContext(ctx, query, filterArgs(filter)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var summaries []model.BillingCycleSummary
+	for rows.Next() {
+		var summary model.BillingCycleSummary
+		if err := rows.Scan(&summary.BillingCycle, &summary.Total, &summary.Count); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan billing cycle summary: %w", op, err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return summaries, nil
+}
+
+// GetCostByService sums the prorated cost of the subscriptions matching
+// filter per service, most expensive first, the way GetProratedTotalCost
+// does for all of them. Percent is left to the caller.
+func (r *postgresSubscriptionRepo) GetCostByService(ctx context.Context, filter model.SubscriptionFilter) ([]model.ServiceCost, error) {
+	const op = "repository.postgresql.GetCostByService"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			service_name, 
+			COALESCE(ROUND(SUM(price * charges * months / 12.0)), 0)::bigint AS total 
+		FROM (
+			SELECT 
+				service_name, price, 
+				` + chargesPerYear + ` AS charges, 
+				` + billedMonths + ` AS months 
+			FROM (
+				SELECT 
+					service_name, price, billing_cycle, 
+					GREATEST(start_date, $3::timestamp) AS period_start, 
+					LEAST(end_date, COALESCE($4::timestamp, NOW())) AS period_end 
+				FROM 
+					subscriptions 
+				WHERE ` + subscriptionFilterClause + `
+			) AS windowed
+		) AS billed 
+		GROUP BY 
+			service_name 
+		ORDER BY 
+			total DESC, service_name`
+
+	rows, err := r.db.QueryContext(ctx, query, filterArgs(filter)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var costs []model.ServiceCost
+	for rows.Next() {
+		var cost model.ServiceCost
+		if err := rows.Scan(&cost.ServiceName, &cost.Total); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan service cost: %w", op, err)
+		}
+		costs = append(costs, cost)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return costs, nil
+}
+
+// GetPriceStats computes the count, extremes, mean and median of the
+// prices matching filter in one pass. Aggregates over no rows are NULL in
+// SQL and come back as zeros.
+func (r *postgresSubscriptionRepo) GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error) {
+	const op = "repository.postgresql.GetPriceStats"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COUNT(*), 
+			COALESCE(MIN(price), 0), 
+			COALESCE(MAX(price), 0), 
+			COALESCE(ROUND(AVG(price), 2), 0)::float8, 
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY price), 0) 
+		FROM 
+			subscriptions 
+		WHERE ` + subscriptionFilterClause
+
+	var stats model.PriceStats
+	err := r.db.QueryRowContext(ctx, query, filterArgs(filter)...).Scan(
+		&stats.Count,
+		&stats.MinPrice,
+		&stats.MaxPrice,
+		&stats.AvgPrice,
+		&stats.MedianPrice,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &stats, nil
+}
+
+// ListExpiringSoonByService counts, per service, live subscriptions whose
+// end_date falls within the next days days.
+func (r *postgresSubscriptionRepo) ListExpiringSoonByService(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error) {
+	const op = "repository.postgresql.ListExpiringSoonByService"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			service_name, COUNT(*), MIN(end_date) 
+		FROM 
+			subscriptions 
+		WHERE 
+			deleted_at IS NULL AND 
+			tenant_id = $3 AND 
+			($1::uuid IS NULL OR user_id = $1) AND 
+			end_date BETWEEN NOW() AND NOW() + $2 * INTERVAL '1 day' 
+		GROUP BY 
+			service_name 
+		ORDER BY 
+			MIN(end_date), service_name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, days, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var summaries []model.ExpiringServiceSummary
+	for rows.Next() {
+		var summary model.ExpiringServiceSummary
+		if err := rows.Scan(&summary.ServiceName, &summary.Count, &summary.EarliestExpiry); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan expiring service: %w", op, err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return summaries, nil
+}
+
+// CountCreatedBetween counts subscriptions stored in [from, to), soft-deleted
+// ones included, so that adjacent windows never count a row twice.
+func (r *postgresSubscriptionRepo) CountCreatedBetween(ctx context.Context, from, to time.Time) (int, error) {
+	const op = "repository.postgresql.CountCreatedBetween"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE 
+			created_at >= $1 AND created_at < $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// GetTotalCostByUser ranks the users of every tenant by what their
+// subscriptions matching filter cost within its window, highest first, and
+// returns limit of them starting at offset. Only ServiceName, FromDate and
+// ToDate of filter apply. Totals are prorated like GetProratedTotalCost;
+// ties are broken by user_id so that pages do not overlap.
+func (r *postgresSubscriptionRepo) GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error) {
+	const op = "repository.postgresql.GetTotalCostByUser"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			user_id, 
+			COUNT(*), 
+			COALESCE(ROUND(SUM(price * charges * months / 12.0)), 0)::bigint AS total 
+		FROM (
+			SELECT 
+				user_id, 
+				price, 
+				` + chargesPerYear + ` AS charges, 
+				` + billedMonths + ` AS months 
+			FROM (
+				SELECT 
+					user_id, price, billing_cycle, 
+					GREATEST(start_date, $2::timestamp) AS period_start, 
+					LEAST(end_date, COALESCE($3::timestamp, NOW())) AS period_end 
+				FROM 
+					subscriptions 
+				WHERE ` + allTenantsFilterClause + `
+			) AS windowed
+		) AS billed 
+		GROUP BY 
+			user_id 
+		ORDER BY 
+			total DESC, user_id 
+		LIMIT $4 OFFSET $5`
+
+	args := append(allTenantsFilterArgs(filter), limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := &model.UserCostResult{}
+	for rows.Next() {
+		var cost model.UserCost
+		if err := rows.Scan(&cost.UserID, &cost.SubscriptionCount, &cost.Total); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan user cost: %w", op, err)
+		}
+		result.Items = append(result.Items, cost)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	countQuery := `
+		SELECT 
+			COUNT(DISTINCT user_id) 
+		FROM 
+			subscriptions 
+		WHERE ` + allTenantsFilterClause
+
+	if err := r.db.QueryRowContext(ctx, countQuery, allTenantsFilterArgs(filter)...).Scan(&result.TotalCount); err != nil {
+		return nil, fmt.Errorf("%s: failed to count users: %w", op, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,940 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestRepo(t *testing.T) (*postgresSubscriptionRepo, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewSubscriptionRepository(db).(*postgresSubscriptionRepo), mock
+}
+
+func fixedTime() time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// testTenantID is the tenant every test query is scoped to.
+var testTenantID = uuid.MustParse("0b7e1c52-8d4f-4a3e-9c61-2f5a7d8e9b10")
+
+func TestShareSubscription_Upsert(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	share := &model.ShareEntry{
+		SubscriptionID: uuid.New(),
+		UserID:         uuid.New(),
+		Permission:     model.PermissionWrite,
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO subscription_shares")).
+		WithArgs(share.SubscriptionID, share.UserID, share.Permission, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(fixedTime()))
+
+	err := repo.ShareSubscription(context.Background(), testTenantID, share)
+
+	require.NoError(t, err)
+	assert.Equal(t, fixedTime(), share.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnshareSubscription_NotFound(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subID, userID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM subscription_shares")).
+		WithArgs(subID, userID, testTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UnshareSubscription(context.Background(), testTenantID, subID, userID)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSharedUsers(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subID := uuid.New()
+	reader, writer := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM subscription_shares WHERE subscription_id = $1 AND subscription_id IN (SELECT id FROM subscriptions WHERE tenant_id = $2 AND deleted_at IS NULL)")).
+		WithArgs(subID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "shared_with_user_id", "permission", "created_at"}).
+			AddRow(subID, reader, "read", fixedTime()).
+			AddRow(subID, writer, "write", fixedTime()))
+
+	shares, err := repo.GetSharedUsers(context.Background(), testTenantID, subID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ShareEntry{
+		{SubscriptionID: subID, UserID: reader, Permission: model.PermissionRead, CreatedAt: fixedTime()},
+		{SubscriptionID: subID, UserID: writer, Permission: model.PermissionWrite, CreatedAt: fixedTime()},
+	}, shares)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_SharedWithMePassesFlag(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	userID := uuid.New()
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, UserID: &userID, SharedWithMe: true}
+	args := []driver.Value{&userID, nil, nil, nil, true, &testTenantID, false, nil, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT subscription_id FROM subscription_shares WHERE shared_with_user_id = $1")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(uuid.New(), "Yandex Plus", 599, uuid.New(), fixedTime(), nil, "monthly", nil, nil, 1, false))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	result, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, 1, result.TotalCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_PagesWithLimitAndOffset(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, Limit: 50, Offset: 100}
+	args := []driver.Value{nil, nil, nil, nil, false, &testTenantID, false, nil, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY start_date, id LIMIT $11 OFFSET $12")).
+		WithArgs(append(args, 50, 100)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(uuid.New(), "yandex plus", 599, uuid.New(), fixedTime(), nil, "monthly", nil, nil, 1, false))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(101))
+
+	result, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, 101, result.TotalCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_OnlyActiveDropsEnded(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, OnlyActive: true}
+	args := []driver.Value{nil, nil, nil, nil, false, &testTenantID, false, nil, true, nil}
+
+	// Open-ended subscriptions have no end date to have passed.
+	mock.ExpectQuery(regexp.QuoteMeta("(NOT $9::boolean OR end_date IS NULL OR end_date >= NOW())")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(uuid.New(), "netflix", 599, uuid.New(), fixedTime(), nil, "monthly", nil, nil, 1, false))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	result, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_SearchMatchesWildcardsLiterally(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	search := `100%_off\`
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, Search: &search}
+	pattern := `%100\%\_off\\%`
+	args := []driver.Value{nil, nil, nil, nil, false, &testTenantID, false, nil, false, &pattern}
+
+	mock.ExpectQuery(regexp.QuoteMeta("($10::text IS NULL OR service_name ILIKE $10) ORDER BY")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_SeveralServicesGoInArray(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	netflix := "netflix"
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, ServiceName: &netflix, ServiceNames: []string{"spotify", netflix}}
+	args := []driver.Value{nil, pq.StringArray{"netflix", "spotify"}, nil, nil, false, &testTenantID, false, nil, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("service_name = ANY($2)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(uuid.New(), "spotify", 299, uuid.New(), fixedTime(), nil, "monthly", nil, nil, 1, false))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	result, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_SeveralUsersGoInArray(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	lead, member := uuid.New(), uuid.New()
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, UserID: &lead, UserIDs: []uuid.UUID{member, lead}}
+	args := []driver.Value{nil, nil, nil, nil, false, &testTenantID, false, pq.StringArray{lead.String(), member.String()}, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("user_id = ANY($8)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(uuid.New(), "Yandex Plus", 599, member, fixedTime(), nil, "monthly", nil, nil, 1, false))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	result, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList_SingleUserInUserIDsActsLikeUserID(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	userID := uuid.New()
+	filter := model.SubscriptionFilter{TenantID: &testTenantID, UserIDs: []uuid.UUID{userID}}
+	args := []driver.Value{&userID, nil, nil, nil, false, &testTenantID, false, nil, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT subscription_id FROM pinned_subscriptions")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListExpired_OnlyPastEndDates(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+	endDate := fixedTime()
+
+	mock.ExpectQuery(regexp.QuoteMeta("end_date IS NOT NULL AND end_date < NOW()")).
+		WithArgs(&userID, nil, nil, nil, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version"}).
+			AddRow(uuid.New(), "Netflix", 999, userID, fixedTime().AddDate(0, -1, 0), endDate, "monthly", nil, nil, 1))
+
+	subs, err := repo.ListExpired(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, UserID: &userID})
+
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, endDate, *subs[0].EndDate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteExpired_ReturnsCount(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta("SET deleted_at = NOW()")).
+		WithArgs(userID, testTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	deleted, err := repo.SoftDeleteExpired(context.Background(), testTenantID, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_NotifiesChange(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID}
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`RETURNING id, tenant_id, user_id ) SELECT pg_notify('subscriptions_changed', json_build_object('event', 'created', 'id', id, 'tenant_id', tenant_id, 'user_id', user_id)::text) FROM changed`)).
+		WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.BillingCycle, testTenantID, nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Create(context.Background(), sub))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdate_NotifiesChange(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID, Version: 3}
+
+	mock.ExpectExec(regexp.QuoteMeta(`version = version + 1 WHERE id = $1 AND tenant_id = $9 AND deleted_at IS NULL AND version = $10`)).
+		WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.BillingCycle, nil, testTenantID, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Update(context.Background(), sub))
+	assert.Equal(t, 4, sub.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDelete_NotifiesChange(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	id := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE subscriptions SET deleted_at = NOW() WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL RETURNING id, tenant_id, user_id ) SELECT pg_notify(`)).
+		WithArgs(id, testTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Delete(context.Background(), testTenantID, id))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdate_NotFound(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime()}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM subscriptions")).
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.Update(context.Background(), sub)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.Contains(t, err.Error(), "repository.postgresql.Update")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdate_StaleVersionIsConflict(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID, Version: 2}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version FROM subscriptions WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL")).
+		WithArgs(sub.ID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+
+	err := repo.Update(context.Background(), sub)
+
+	var conflict *model.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 5, conflict.Current)
+	assert.ErrorIs(t, err, model.ErrVersionConflict)
+	assert.Equal(t, 2, sub.Version, "a failed update leaves the version alone")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDelete_NotFound(t *testing.T) {
+	repo, mock := newTestRepo(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions SET deleted_at = NOW()")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete(context.Background(), testTenantID, uuid.New())
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.Contains(t, err.Error(), "repository.postgresql.Delete")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetByID_NotFound(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM subscriptions WHERE id = $1 AND tenant_id = $2")).
+		WithArgs(id, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version"}))
+
+	sub, err := repo.GetByID(context.Background(), testTenantID, id)
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NotContains(t, err.Error(), "no rows")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetByID_ScansMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored any
+		want   json.RawMessage
+	}{
+		{name: "nested object", stored: []byte(`{"card": {"last4": "4242", "exp": "12/27"}}`), want: json.RawMessage(`{"card": {"last4": "4242", "exp": "12/27"}}`)},
+		{name: "array", stored: []byte(`["family", "shared"]`), want: json.RawMessage(`["family", "shared"]`)},
+		{name: "null", stored: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newTestRepo(t)
+			id := uuid.New()
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT id, service_name, price, user_id, start_date, end_date, billing_cycle, metadata, catalog_service_id, version FROM subscriptions")).
+				WithArgs(id, testTenantID).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version"}).
+					AddRow(id, "Netflix", 999, uuid.New(), fixedTime(), nil, "monthly", tt.stored, nil, 1))
+
+			sub, err := repo.GetByID(context.Background(), testTenantID, id)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sub.Metadata)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetByIDs_PassesIDsAsArray(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	a, b := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE id = ANY($1::uuid[]) AND tenant_id = $2 AND deleted_at IS NULL")).
+		WithArgs(pq.StringArray{a.String(), b.String()}, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version"}).
+			AddRow(b, "netflix", 999, uuid.New(), fixedTime(), nil, "monthly", nil, nil, 1))
+
+	subs, err := repo.GetByIDs(context.Background(), testTenantID, []uuid.UUID{a, b})
+
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, b, subs[0].ID)
+	assert.Equal(t, testTenantID, subs[0].TenantID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_SendsMetadataAsText(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{
+		ID:        uuid.New(),
+		Price:     999,
+		StartDate: fixedTime(),
+		TenantID:  testTenantID,
+		Metadata:  json.RawMessage(`{"invoice": "INV-42", "tags": ["work"]}`),
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).
+		WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.BillingCycle, testTenantID,
+			`{"invoice": "INV-42", "tags": ["work"]}`, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Create(context.Background(), sub))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShareSubscription_MissingSubscription(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	share := &model.ShareEntry{SubscriptionID: uuid.New(), UserID: uuid.New(), Permission: model.PermissionRead}
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO subscription_shares")).
+		WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+	err := repo.ShareSubscription(context.Background(), testTenantID, share)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "unique", err: &pq.Error{Code: "23505", Constraint: "subscriptions_pkey"}, want: model.ErrConflict},
+		{name: "foreign key", err: &pq.Error{Code: "23503"}, want: model.ErrInvalidReference},
+		{name: "wrapped", err: fmt.Errorf("exec: %w", &pq.Error{Code: "23505"}), want: model.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(tt.err)
+
+			assert.ErrorIs(t, err, tt.want)
+			var pqErr *pq.Error
+			assert.ErrorAs(t, err, &pqErr, "driver error must stay in the chain")
+		})
+	}
+}
+
+func TestClassifyError_PassesThroughOtherErrors(t *testing.T) {
+	checkViolation := &pq.Error{Code: "23514"}
+	other := errors.New("connection reset")
+
+	assert.Same(t, checkViolation, classifyError(checkViolation))
+	assert.Same(t, other, classifyError(other))
+}
+
+func TestCreate_DuplicateIsConflict(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime()}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "subscriptions_pkey"})
+
+	err := repo.Create(context.Background(), sub)
+
+	assert.ErrorIs(t, err, model.ErrConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExistsActiveOverlap(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID, existing := uuid.New(), uuid.New()
+	end := fixedTime().AddDate(0, 1, 0)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`(end_date IS NULL OR end_date > $4)`)).
+		WithArgs(testTenantID, userID, "Netflix", fixedTime(), end).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(existing))
+	mock.ExpectQuery(regexp.QuoteMeta(`(end_date IS NULL OR end_date > $4)`)).
+		WithArgs(testTenantID, userID, "Netflix", fixedTime(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	id, err := repo.ExistsActiveOverlap(context.Background(), testTenantID, userID, "Netflix", fixedTime(), &end)
+	require.NoError(t, err)
+	require.NotNil(t, id)
+	assert.Equal(t, existing, *id)
+
+	id, err = repo.ExistsActiveOverlap(context.Background(), testTenantID, userID, "Netflix", fixedTime(), nil)
+	require.NoError(t, err)
+	assert.Nil(t, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCostByCycle_GroupsByCycle(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY billing_cycle ORDER BY billing_cycle`)).
+		WithArgs(&userID, nil, nil, nil, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_cycle", "sum", "count"}).
+			AddRow("annual", 2400, 1).
+			AddRow("monthly", 1200, 3))
+
+	summaries, err := repo.GetCostByCycle(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, UserID: &userID})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.BillingCycleSummary{
+		{BillingCycle: model.CycleAnnual, Total: 2400, Count: 1},
+		{BillingCycle: model.CycleMonthly, Total: 1200, Count: 3},
+	}, summaries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProratedCostPerUser_GroupsByUser(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	alice, bob := uuid.New(), uuid.New()
+	from := fixedTime()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY user_id`)).
+		WithArgs(nil, nil, &from, nil, false, &testTenantID, false, pq.StringArray{alice.String(), bob.String()}, false).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "total"}).
+			AddRow(alice, 5994).
+			AddRow(bob, 2388))
+
+	totals, err := repo.GetProratedCostPerUser(context.Background(),
+		model.SubscriptionFilter{TenantID: &testTenantID, UserIDs: []uuid.UUID{alice, bob}, FromDate: &from})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{alice: 5994, bob: 2388}, totals)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCostByService_OrdersBySpend(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+	from := fixedTime()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY service_name ORDER BY total DESC, service_name`)).
+		WithArgs(&userID, nil, &from, nil, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"service_name", "total"}).
+			AddRow("Netflix", 5994).
+			AddRow("Spotify", 2388))
+
+	costs, err := repo.GetCostByService(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, UserID: &userID, FromDate: &from})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ServiceCost{
+		{ServiceName: "Netflix", Total: 5994},
+		{ServiceName: "Spotify", Total: 2388},
+	}, costs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPriceStats(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []driver.Value
+		want model.PriceStats
+	}{
+		{
+			name: "matches",
+			row:  []driver.Value{3, 299, 999, 632.33, 599},
+			want: model.PriceStats{Count: 3, MinPrice: 299, MaxPrice: 999, AvgPrice: 632.33, MedianPrice: 599},
+		},
+		{
+			name: "no matches",
+			row:  []driver.Value{0, 0, 0, 0, 0},
+			want: model.PriceStats{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newTestRepo(t)
+			serviceName := "Netflix"
+
+			mock.ExpectQuery(regexp.QuoteMeta("COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY price), 0) FROM subscriptions WHERE")).
+				WithArgs(nil, pq.StringArray{serviceName}, nil, nil, false, &testTenantID, false, nil, false).
+				WillReturnRows(sqlmock.NewRows([]string{"count", "min", "max", "avg", "median"}).AddRow(tt.row...))
+
+			stats, err := repo.GetPriceStats(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, ServiceName: &serviceName})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *stats)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListExpiringSoonByService_UsesDayInterval(t *testing.T) {
+	repo, mock := newTestRepo(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`end_date BETWEEN NOW() AND NOW() + $2 * INTERVAL '1 day' GROUP BY service_name`)).
+		WithArgs(nil, 7, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"service_name", "count", "min"}).
+			AddRow("Netflix", 2, fixedTime()))
+
+	summaries, err := repo.ListExpiringSoonByService(context.Background(), testTenantID, nil, 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ExpiringServiceSummary{{ServiceName: "Netflix", Count: 2, EarliestExpiry: fixedTime()}}, summaries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkCreate_CommitsAllRows(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subs := []*model.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), BillingCycle: model.CycleMonthly, TenantID: testTenantID},
+		{ID: uuid.New(), ServiceName: "Spotify", Price: 299, UserID: uuid.New(), StartDate: fixedTime(), BillingCycle: model.CycleAnnual, TenantID: testTenantID},
+	}
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO subscriptions"))
+	for _, sub := range subs {
+		prep.ExpectExec().
+			WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.BillingCycle, testTenantID, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.BulkCreate(context.Background(), subs))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkCreate_RollsBackOnFailure(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	subs := []*model.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime()},
+		{ID: uuid.New(), ServiceName: "Spotify", Price: 299, UserID: uuid.New(), StartDate: fixedTime()},
+	}
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO subscriptions"))
+	prep.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	err := repo.BulkCreate(context.Background(), subs)
+
+	assert.ErrorIs(t, err, model.ErrConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkCreate_UsesCallersTransaction(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO subscriptions")).
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx, commit, rollback, err := repo.Transactional(context.Background())
+	require.NoError(t, err)
+	defer rollback()
+
+	require.NoError(t, repo.BulkCreate(ctx, []*model.Subscription{sub}))
+	require.NoError(t, commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStreamUserSubscriptions(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND tenant_id = $2 AND deleted_at IS NULL ORDER BY start_date, id")).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version", "pinned"}).
+			AddRow(first, "netflix", 999, userID, fixedTime(), nil, "monthly", nil, nil, 1, true).
+			AddRow(second, "spotify", 299, userID, fixedTime(), nil, "monthly", nil, nil, 3, false))
+
+	var ids []uuid.UUID
+	err := repo.StreamUserSubscriptions(context.Background(), testTenantID, userID, func(sub *model.Subscription) error {
+		ids = append(ids, sub.ID)
+		assert.Equal(t, testTenantID, sub.TenantID)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first, second}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryTimeout_CancelsSlowQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	repo := NewSubscriptionRepository(db, WithQueryTimeout(time.Millisecond))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT")).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+
+	start := time.Now()
+	_, err = repo.GetTotalCost(context.Background(), model.SubscriptionFilter{})
+
+	// sqlmock reports the cancellation with its own error rather than
+	// ctx.Err(); what matters is that the call gives up early.
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestWithQueryTimeout_ZeroKeepsDefault(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	repo := NewSubscriptionRepository(db, WithQueryTimeout(0)).(*postgresSubscriptionRepo)
+
+	assert.Equal(t, DefaultQueryTimeout, repo.queryTimeout)
+}
+
+func TestGetTotalCostByUser(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	from := fixedTime()
+	service := "Netflix"
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY user_id ORDER BY total DESC, user_id LIMIT $4 OFFSET $5`)).
+		WithArgs(pq.StringArray{service}, &from, nil, 2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "count", "total"}).
+			AddRow(first, 4, 14376).
+			AddRow(second, 1, 599))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(DISTINCT user_id) FROM subscriptions`)).
+		WithArgs(pq.StringArray{service}, &from, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+	result, err := repo.GetTotalCostByUser(context.Background(), model.SubscriptionFilter{ServiceName: &service, FromDate: &from}, 2, 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.UserCostResult{
+		Items: []model.UserCost{
+			{UserID: first, SubscriptionCount: 4, Total: 14376},
+			{UserID: second, SubscriptionCount: 1, Total: 599},
+		},
+		TotalCount: 12,
+	}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountCreatedBetween(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	from := fixedTime()
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM subscriptions WHERE created_at >= $1 AND created_at < $2`)).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(310))
+
+	count, err := repo.CountCreatedBetween(context.Background(), from, to)
+
+	require.NoError(t, err)
+	assert.Equal(t, 310, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// A subscription matches when it overlaps the window: it ended on or after
+// from_date, or has no end, and started on or before to_date.
+// TestIntegration_DateFilterSelectsOverlap runs each boundary case.
+func TestGetTotalCost_DateFilterSelectsOverlap(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	from := fixedTime()
+	to := from.AddDate(0, 1, 0)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`($3::timestamp IS NULL OR end_date IS NULL OR end_date >= $3) AND ($4::timestamp IS NULL OR start_date <= $4)`)).
+		WithArgs(nil, nil, &from, &to, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1500))
+
+	total, err := repo.GetTotalCost(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, FromDate: &from, ToDate: &to})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1500, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCount_FiltersByUser(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM subscriptions WHERE deleted_at IS NULL`)).
+		WithArgs(&userID, nil, nil, nil, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	count, err := repo.Count(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, UserID: &userID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserLimit(t *testing.T) {
+	userID := uuid.New()
+	query := regexp.QuoteMeta(`SELECT max_subscriptions FROM user_limits WHERE tenant_id = $1 AND user_id = $2`)
+
+	t.Run("set", func(t *testing.T) {
+		repo, mock := newTestRepo(t)
+		mock.ExpectQuery(query).WithArgs(testTenantID, userID).
+			WillReturnRows(sqlmock.NewRows([]string{"max_subscriptions"}).AddRow(10))
+
+		limit, err := repo.GetUserLimit(context.Background(), testTenantID, userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, 10, limit)
+	})
+
+	t.Run("not set", func(t *testing.T) {
+		repo, mock := newTestRepo(t)
+		mock.ExpectQuery(query).WithArgs(testTenantID, userID).
+			WillReturnRows(sqlmock.NewRows([]string{"max_subscriptions"}))
+
+		_, err := repo.GetUserLimit(context.Background(), testTenantID, userID)
+
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func TestLockUserSubscriptions(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock(")).
+		WithArgs(testTenantID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
+
+	ctx, _, rollback, err := repo.Transactional(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, repo.LockUserSubscriptions(ctx, testTenantID, userID))
+	// Counted in the same transaction, under the lock.
+	count, err := repo.Count(ctx, model.SubscriptionFilter{UserID: &userID, TenantID: &testTenantID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.NoError(t, rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// The month arithmetic is model.Subscription.BilledMonths, whose tests cover
+// the partial-month boundaries; here the query must clip each subscription
+// to the window and scale non-monthly prices.
+func TestGetProratedTotalCost(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	from := fixedTime()
+	to := from.AddDate(1, 0, -1)
+
+	mock.ExpectQuery(`ROUND\(SUM\(price \* charges \* months / 12\.0\)\)(.|\n)*`+
+		regexp.QuoteMeta(`GREATEST(start_date, $3::timestamp) AS period_start`)+`(.|\n)*`+
+		regexp.QuoteMeta(`LEAST(end_date, COALESCE($4::timestamp, NOW())) AS period_end`)).
+		WithArgs(nil, nil, &from, &to, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(7188))
+
+	total, err := repo.GetProratedTotalCost(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, FromDate: &from, ToDate: &to})
+
+	require.NoError(t, err)
+	assert.Equal(t, 7188, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMonthlyCost_OneRowPerMonth(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	from := fixedTime()
+	to := from.AddDate(0, 2, 0)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`generate_series(`)+`(.|\n)*`+
+		regexp.QuoteMeta(`date_trunc('month', $3::timestamp)`)+`(.|\n)*`+
+		regexp.QuoteMeta(`LEFT JOIN (`)).
+		WithArgs(nil, nil, &from, &to, false, &testTenantID, false, nil, false).
+		WillReturnRows(sqlmock.NewRows([]string{"month", "total"}).
+			AddRow(from, 599).
+			AddRow(from.AddDate(0, 1, 0), 0).
+			AddRow(to, 599))
+
+	months, err := repo.GetMonthlyCost(context.Background(), model.SubscriptionFilter{TenantID: &testTenantID, FromDate: &from, ToDate: &to})
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.MonthlyCost{
+		{Month: from, Total: 599},
+		{Month: from.AddDate(0, 1, 0), Total: 0},
+		{Month: to, Total: 599},
+	}, months)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// tenant_id = NULL is never true, so a filter that lost its tenant returns
+// nothing rather than every tenant's rows.
+func TestList_WithoutTenantMatchesNothing(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	mock.MatchExpectationsInOrder(false)
+	args := []driver.Value{nil, nil, nil, nil, false, nil, false, nil, false, nil}
+
+	mock.ExpectQuery(regexp.QuoteMeta("tenant_id = $6")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "pinned"}))
+	mock.ExpectQuery(regexp.QuoteMeta("COUNT(*)")).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	result, err := repo.List(context.Background(), model.SubscriptionFilter{})
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
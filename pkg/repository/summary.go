@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// activeSubscriptionClause matches the subscriptions of user $1 in tenant
+// $2 that have started and not yet ended.
+const activeSubscriptionClause = `
+			deleted_at IS NULL AND
+			user_id = $1 AND
+			tenant_id = $2 AND
+			start_date <= NOW() AND
+			(end_date IS NULL OR end_date >= NOW())`
+
+// CountByStatus counts the user's active and expired subscriptions.
+func (r *postgresSubscriptionRepo) CountByStatus(ctx context.Context, tenantID, userID uuid.UUID) (int, int, error) {
+	const op = "repository.postgresql.CountByStatus"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			COUNT(*) FILTER (WHERE start_date <= NOW() AND (end_date IS NULL OR end_date >= NOW())),
+			COUNT(*) FILTER (WHERE end_date IS NOT NULL AND end_date < NOW())
+		FROM 
+			subscriptions 
+		WHERE 
+			deleted_at IS NULL AND user_id = $1 AND tenant_id = $2`
+
+	var active, expired int
+	if err := r.db.QueryRowContext(ctx, query, userID, tenantID).Scan(&active, &expired); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return active, expired, nil
+}
+
+// GetActiveCostByCycle sums the user's active subscriptions per billing
+// cycle.
+func (r *postgresSubscriptionRepo) GetActiveCostByCycle(ctx context.Context, tenantID, userID uuid.UUID) ([]model.BillingCycleSummary, error) {
+	const op = "repository.postgresql.GetActiveCostByCycle"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			billing_cycle, COALESCE(SUM(price), 0), COUNT(*) 
+		FROM 
+			subscriptions 
+		WHERE` + activeSubscriptionClause + `
+		GROUP BY billing_cycle 
+		ORDER BY billing_cycle`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var summaries []model.BillingCycleSummary
+	for rows.Next() {
+		var s model.BillingCycleSummary
+		if err := rows.Scan(&s.BillingCycle, &s.Total, &s.Count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		summaries = append(summaries, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return summaries, nil
+}
+
+// GetMostExpensiveActive returns the service name of the user's active
+// subscription that costs the most per year, or "" if there is none.
+func (r *postgresSubscriptionRepo) GetMostExpensiveActive(ctx context.Context, tenantID, userID uuid.UUID) (string, error) {
+	const op = "repository.postgresql.GetMostExpensiveActive"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			service_name 
+		FROM 
+			subscriptions 
+		WHERE` + activeSubscriptionClause + `
+		ORDER BY 
+			price * ` + chargesPerYear + ` DESC, service_name 
+		LIMIT 1`
+
+	var name string
+	err := r.db.QueryRowContext(ctx, query, userID, tenantID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return name, nil
+}
+
+// GetNextExpiry returns the earliest end_date among the user's active
+// subscriptions, or nil if none of them ends.
+func (r *postgresSubscriptionRepo) GetNextExpiry(ctx context.Context, tenantID, userID uuid.UUID) (*time.Time, error) {
+	const op = "repository.postgresql.GetNextExpiry"
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT 
+			MIN(end_date) 
+		FROM 
+			subscriptions 
+		WHERE` + activeSubscriptionClause
+
+	var next sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID, tenantID).Scan(&next); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !next.Valid {
+		return nil, nil
+	}
+
+	return &next.Time, nil
+}
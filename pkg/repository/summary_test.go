@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestCountByStatus(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`COUNT(*) FILTER (WHERE end_date IS NOT NULL AND end_date < NOW())`)).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"active", "expired"}).AddRow(5, 2))
+
+	active, expired, err := repo.CountByStatus(context.Background(), testTenantID, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, active)
+	assert.Equal(t, 2, expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActiveCostByCycle(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`(end_date IS NULL OR end_date >= NOW()) GROUP BY billing_cycle`)).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_cycle", "total", "count"}).
+			AddRow("annual", 2400, 1).
+			AddRow("monthly", 1300, 4))
+
+	cycles, err := repo.GetActiveCostByCycle(context.Background(), testTenantID, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.BillingCycleSummary{
+		{BillingCycle: model.CycleAnnual, Total: 2400, Count: 1},
+		{BillingCycle: model.CycleMonthly, Total: 1300, Count: 4},
+	}, cycles)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMostExpensiveActive_NoneIsEmpty(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHEN 'annual' THEN 1 ELSE 12 END DESC, service_name LIMIT 1`)).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"service_name"}))
+
+	name, err := repo.GetMostExpensiveActive(context.Background(), testTenantID, userID)
+
+	require.NoError(t, err)
+	assert.Empty(t, name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetNextExpiry(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT MIN(end_date)`)).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(fixedTime()))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT MIN(end_date)`)).
+		WithArgs(userID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+
+	next, err := repo.GetNextExpiry(context.Background(), testTenantID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, fixedTime(), *next)
+
+	next, err = repo.GetNextExpiry(context.Background(), testTenantID, userID)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CommitFunc commits the transaction started by Transactional.
+type CommitFunc func() error
+
+// RollbackFunc aborts the transaction started by Transactional. Once the
+// transaction is committed it does nothing, so it is safe to defer.
+type RollbackFunc func() error
+
+type txKey struct{}
+
+// querier is what *sql.DB and *sql.Tx have in common.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Transactional begins a transaction and returns a context carrying it.
+// Create, CreateShares, LockSubscription and Update called with that
+// context run inside it; every other method keeps using its own
+// connection. The transaction is also
+// rolled back when ctx is done before commit.
+func (r *postgresSubscriptionRepo) Transactional(ctx context.Context) (context.Context, CommitFunc, RollbackFunc, error) {
+	const op = "repository.postgresql.Transactional"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	commit := func() error {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: commit: %w", op, err)
+		}
+		return nil
+	}
+	rollback := func() error {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			return fmt.Errorf("%s: rollback: %w", op, err)
+		}
+		return nil
+	}
+
+	return context.WithValue(ctx, txKey{}, tx), commit, rollback, nil
+}
+
+// conn returns the transaction ctx carries, or the pool when there is none.
+func (r *postgresSubscriptionRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.db
+}
@@ -0,0 +1,232 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// newIntegrationDB connects to the integration database, see
+// integrationDSN, and applies the migrations.
+func newIntegrationDB(t *testing.T) *sql.DB {
+	t.Helper()
+	pg, err := New(context.Background(), integrationDSN(t), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	t.Cleanup(func() { pg.Close() })
+	return pg.DB
+}
+
+func newIntegrationRepo(t *testing.T) SubscriptionRepository {
+	t.Helper()
+	return NewSubscriptionRepository(newIntegrationDB(t))
+}
+
+func newIntegrationSubscription(tenantID uuid.UUID) *model.Subscription {
+	return &model.Subscription{
+		ID:           uuid.New(),
+		TenantID:     tenantID,
+		ServiceName:  "Netflix",
+		Price:        999,
+		UserID:       uuid.New(),
+		StartDate:    fixedTime(),
+		BillingCycle: model.CycleMonthly,
+	}
+}
+
+func TestIntegration_TransactionalCommit(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	sub := newIntegrationSubscription(tenantID)
+	shares := []model.ShareEntry{
+		{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: model.PermissionRead},
+		{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: model.PermissionWrite},
+	}
+
+	txCtx, commit, rollback, err := repo.Transactional(ctx)
+	require.NoError(t, err)
+	defer rollback()
+	require.NoError(t, repo.Create(txCtx, sub))
+	require.NoError(t, repo.CreateShares(txCtx, tenantID, shares))
+
+	_, err = repo.GetByID(ctx, tenantID, sub.ID)
+	assert.ErrorIs(t, err, model.ErrNotFound, "uncommitted rows are not visible outside the transaction")
+
+	require.NoError(t, commit())
+
+	_, err = repo.GetByID(ctx, tenantID, sub.ID)
+	require.NoError(t, err)
+	stored, err := repo.GetSharedUsers(ctx, tenantID, sub.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+}
+
+func TestIntegration_TransactionalRollback(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	sub := newIntegrationSubscription(tenantID)
+
+	txCtx, _, rollback, err := repo.Transactional(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(txCtx, sub))
+	err = repo.CreateShares(txCtx, tenantID, []model.ShareEntry{
+		{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: model.PermissionRead},
+		{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: "owner"},
+	})
+	require.Error(t, err)
+	require.NoError(t, rollback())
+
+	_, err = repo.GetByID(ctx, tenantID, sub.ID)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	stored, err := repo.GetSharedUsers(ctx, tenantID, sub.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+// Both goroutines lock, read, pause and write, the way
+// service.UpdateSubscription does. Whichever locks first sees the original
+// row; the other waits for it to commit and sees its price, never the
+// original, so no update is lost.
+func TestIntegration_LockSubscriptionSerialisesUpdates(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	sub := newIntegrationSubscription(tenantID)
+	require.NoError(t, repo.Create(ctx, sub))
+
+	update := func(price int) (seen int, err error) {
+		txCtx, commit, rollback, err := repo.Transactional(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer rollback()
+
+		current, err := repo.LockSubscription(txCtx, tenantID, sub.ID)
+		if err != nil {
+			return 0, err
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		next := *current
+		next.Price = price
+		if err := repo.Update(txCtx, &next); err != nil {
+			return 0, err
+		}
+		return current.Price, commit()
+	}
+
+	prices := []int{1299, 1499}
+	seen := make([]int, len(prices))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, price := range prices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			var err error
+			seen[i], err = update(price)
+			assert.NoError(t, err)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	winner := slices.Index(seen, sub.Price)
+	require.NotEqual(t, -1, winner, "one update saw the original row: %v", seen)
+	loser := 1 - winner
+	assert.Equal(t, prices[winner], seen[loser], "the other saw the winner's update")
+
+	stored, err := repo.GetByID(ctx, tenantID, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, prices[loser], stored.Price)
+}
+
+// Both goroutines lock the user, count, pause and insert, the way
+// service.CreateSubscription does under a user limit. Whichever locks
+// second waits for the first to commit and counts its subscription.
+func TestIntegration_LockUserSubscriptionsSerialisesCreates(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	tenantID := uuid.New()
+	userID := uuid.New()
+
+	create := func() (seen int, err error) {
+		txCtx, commit, rollback, err := repo.Transactional(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer rollback()
+
+		if err := repo.LockUserSubscriptions(txCtx, tenantID, userID); err != nil {
+			return 0, err
+		}
+		count, err := repo.Count(txCtx, model.SubscriptionFilter{UserID: &userID, TenantID: &tenantID})
+		if err != nil {
+			return 0, err
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		sub := newIntegrationSubscription(tenantID)
+		sub.UserID = userID
+		if err := repo.Create(txCtx, sub); err != nil {
+			return 0, err
+		}
+		return count, commit()
+	}
+
+	seen := make([]int, 2)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range seen {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			var err error
+			seen[i], err = create()
+			assert.NoError(t, err)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	slices.Sort(seen)
+	assert.Equal(t, []int{0, 1}, seen, "the second create counted the first")
+}
+
+func TestIntegration_UpdateRefusesStaleVersion(t *testing.T) {
+	repo := newIntegrationRepo(t)
+	ctx := context.Background()
+	sub := newIntegrationSubscription(uuid.New())
+	require.NoError(t, repo.Create(ctx, sub))
+	stale := *sub
+
+	sub.Price = 1299
+	require.NoError(t, repo.Update(ctx, sub))
+	assert.Equal(t, 2, sub.Version)
+
+	stale.Price = 1499
+	var conflict *model.VersionConflictError
+	require.ErrorAs(t, repo.Update(ctx, &stale), &conflict)
+	assert.Equal(t, 2, conflict.Current)
+
+	stored, err := repo.GetByID(ctx, sub.TenantID, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1299, stored.Price)
+	assert.Equal(t, 2, stored.Version)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestTransactional_CommitsCreateAndShares(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID}
+	shares := []model.ShareEntry{
+		{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: model.PermissionRead},
+		{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: model.PermissionWrite},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, share := range shares {
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO subscription_shares")).
+			WithArgs(sub.ID, share.UserID, share.Permission, testTenantID).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(fixedTime()))
+	}
+	mock.ExpectCommit()
+
+	ctx, commit, rollback, err := repo.Transactional(context.Background())
+	require.NoError(t, err)
+	defer rollback()
+
+	require.NoError(t, repo.Create(ctx, sub))
+	require.NoError(t, repo.CreateShares(ctx, testTenantID, shares))
+	require.NoError(t, commit())
+
+	assert.NoError(t, rollback(), "rollback after commit is a no-op")
+	assert.Equal(t, fixedTime(), shares[1].CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactional_RollsBackOnShareFailure(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO subscription_shares")).
+		WillReturnError(&pq.Error{Code: "22P02", Message: "invalid input value for enum share_permission"})
+	mock.ExpectRollback()
+
+	ctx, _, rollback, err := repo.Transactional(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(ctx, sub))
+	err = repo.CreateShares(ctx, testTenantID, []model.ShareEntry{{SubscriptionID: sub.ID, UserID: uuid.New(), Permission: "owner"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository.postgresql.CreateShares")
+
+	assert.NoError(t, rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactional_LockThenUpdate(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 1299, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).
+		WithArgs(sub.ID, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_cycle", "metadata", "catalog_service_id", "version"}).
+			AddRow(sub.ID, "Netflix", 999, sub.UserID, fixedTime(), nil, "monthly", nil, nil, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE subscriptions")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx, commit, rollback, err := repo.Transactional(context.Background())
+	require.NoError(t, err)
+	defer rollback()
+
+	current, err := repo.LockSubscription(ctx, testTenantID, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 999, current.Price)
+	require.NoError(t, repo.Update(ctx, sub))
+	require.NoError(t, commit())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockSubscription_NotFound(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).
+		WithArgs(id, testTenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	sub, err := repo.LockSubscription(context.Background(), testTenantID, id)
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_OutsideTransactionUsesPool(t *testing.T) {
+	repo, mock := newTestRepo(t)
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: uuid.New(), StartDate: fixedTime(), TenantID: testTenantID}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Create(context.Background(), sub))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// DefaultAnomalyThreshold is used when the service is built without
+// WithAnomalyThreshold.
+const DefaultAnomalyThreshold = 2.0
+
+// minAnomalyHistory is how many earlier prices a subscription needs before
+// its current price can be flagged: the spread of one or two prices says
+// too little about what is unusual.
+const minAnomalyHistory = 3
+
+// WithAnomalyThreshold sets the |z-score| above which DetectPriceAnomaly
+// flags the current price.
+func WithAnomalyThreshold(threshold float64) ServiceOption {
+	return func(s *subscriptionService) {
+		s.anomalyThreshold = threshold
+	}
+}
+
+// DetectPriceAnomaly compares the subscription's current price with every
+// price it had before, see model.PriceAnomalyReport.
+func (s *subscriptionService) DetectPriceAnomaly(ctx context.Context, id uuid.UUID) (*model.PriceAnomalyReport, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	history, err := s.repo.GetPriceHistory(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	// The last entry records the current price itself.
+	if len(history) > 0 {
+		history = history[:len(history)-1]
+	}
+	return priceAnomaly(sub.Price, history, s.anomalyThreshold), nil
+}
+
+// priceAnomaly scores current against the population mean and standard
+// deviation of past. Without past prices there is nothing to deviate from
+// and current is its own mean. With fewer than minAnomalyHistory of them
+// the scores are still reported but current is never an anomaly.
+func priceAnomaly(current int, past []int, threshold float64) *model.PriceAnomalyReport {
+	mean := float64(current)
+	var stdDev float64
+	if n := float64(len(past)); n > 0 {
+		var sum float64
+		for _, p := range past {
+			sum += float64(p)
+		}
+		mean = sum / n
+
+		var squares float64
+		for _, p := range past {
+			squares += (float64(p) - mean) * (float64(p) - mean)
+		}
+		stdDev = math.Sqrt(squares / n)
+	}
+
+	report := &model.PriceAnomalyReport{
+		CurrentPrice: current,
+		Mean:         math.Round(mean*100) / 100,
+		StdDev:       math.Round(stdDev*100) / 100,
+		HistorySize:  len(past),
+	}
+	deviation := float64(current) - mean
+	if stdDev == 0 {
+		if deviation == 0 {
+			report.ZScore = new(float64)
+		}
+		report.IsAnomaly = deviation != 0
+	} else {
+		z := deviation / stdDev
+		rounded := math.Round(z*100) / 100
+		report.ZScore = &rounded
+		report.IsAnomaly = math.Abs(z) > threshold
+	}
+	if len(past) < minAnomalyHistory {
+		report.IsAnomaly = false
+	}
+	return report
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPriceAnomaly(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   int
+		past      []int
+		threshold float64
+		want      model.PriceAnomalyReport
+	}{
+		{
+			// mean 700, variance 4·50²/4 = 2500, σ = 50,
+			// z = (1200-700)/50 = 10.
+			name: "far above", current: 1200, past: []int{650, 750, 650, 750}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 1200, Mean: 700, StdDev: 50, ZScore: ptr(10.0), IsAnomaly: true, HistorySize: 4},
+		},
+		{
+			// mean 200, variance (100²+0+100²)/3 = 6666.67, σ = 81.65,
+			// z = 50/81.65 = 0.61.
+			name: "within spread", current: 250, past: []int{100, 200, 300}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 250, Mean: 200, StdDev: 81.65, ZScore: ptr(0.61), IsAnomaly: false, HistorySize: 3},
+		},
+		{
+			// mean 500, σ = 100, z = -3.
+			name: "far below", current: 200, past: []int{400, 600, 400, 600}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 200, Mean: 500, StdDev: 100, ZScore: ptr(-3.0), IsAnomaly: true, HistorySize: 4},
+		},
+		{
+			// z = (700-500)/100 = 2, which is not above the threshold.
+			name: "at threshold", current: 700, past: []int{400, 600, 400, 600}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 700, Mean: 500, StdDev: 100, ZScore: ptr(2.0), IsAnomaly: false, HistorySize: 4},
+		},
+		{
+			name: "lower threshold", current: 700, past: []int{400, 600, 400, 600}, threshold: 1.5,
+			want: model.PriceAnomalyReport{CurrentPrice: 700, Mean: 500, StdDev: 100, ZScore: ptr(2.0), IsAnomaly: true, HistorySize: 4},
+		},
+		{
+			name: "constant history changed", current: 800, past: []int{500, 500, 500}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 800, Mean: 500, StdDev: 0, ZScore: nil, IsAnomaly: true, HistorySize: 3},
+		},
+		{
+			// z = 10 but two earlier prices are too few to flag it.
+			name: "too little history", current: 1200, past: []int{650, 750}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 1200, Mean: 700, StdDev: 50, ZScore: ptr(10.0), IsAnomaly: false, HistorySize: 2},
+		},
+		{
+			name: "single changed price", current: 800, past: []int{500}, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 800, Mean: 500, StdDev: 0, ZScore: nil, IsAnomaly: false, HistorySize: 1},
+		},
+		{
+			name: "no history", current: 599, past: nil, threshold: 2,
+			want: model.PriceAnomalyReport{CurrentPrice: 599, Mean: 599, StdDev: 0, ZScore: ptr(0.0), IsAnomaly: false, HistorySize: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, &tt.want, priceAnomaly(tt.current, tt.past, tt.threshold))
+		})
+	}
+}
+
+func TestDetectPriceAnomaly_ExcludesCurrentPrice(t *testing.T) {
+	svc, mockRepo := newTestService()
+	id := fixedUUID()
+	mockRepo.On("GetByID", mock.Anything, testTenantID, id).Return(&model.Subscription{ID: id, Price: 1200}, nil)
+	mockRepo.On("GetPriceHistory", mock.Anything, testTenantID, id).Return([]int{650, 750, 650, 750, 1200}, nil)
+
+	report, err := svc.DetectPriceAnomaly(testCtx(), id)
+
+	require.NoError(t, err)
+	assert.Equal(t, 700.0, report.Mean)
+	assert.Equal(t, 4, report.HistorySize)
+	assert.True(t, report.IsAnomaly)
+}
+
+func TestDetectPriceAnomaly_UsesConfiguredThreshold(t *testing.T) {
+	svc, mockRepo := newTestService()
+	WithAnomalyThreshold(5)(svc)
+	id := fixedUUID()
+	mockRepo.On("GetByID", mock.Anything, testTenantID, id).Return(&model.Subscription{ID: id, Price: 200}, nil)
+	mockRepo.On("GetPriceHistory", mock.Anything, testTenantID, id).Return([]int{400, 600, 400, 600, 200}, nil)
+
+	report, err := svc.DetectPriceAnomaly(testCtx(), id)
+
+	require.NoError(t, err)
+	assert.Equal(t, -3.0, *report.ZScore)
+	assert.False(t, report.IsAnomaly)
+}
+
+func TestDetectPriceAnomaly_NotFound(t *testing.T) {
+	svc, mockRepo := newTestService()
+	mockRepo.On("GetByID", mock.Anything, testTenantID, mock.Anything).Return((*model.Subscription)(nil), model.ErrNotFound)
+
+	report, err := svc.DetectPriceAnomaly(testCtx(), uuid.New())
+
+	assert.Nil(t, report)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	mockRepo.AssertNotCalled(t, "GetPriceHistory", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDetectPriceAnomaly_HistoryError(t *testing.T) {
+	svc, mockRepo := newTestService()
+	mockRepo.On("GetByID", mock.Anything, testTenantID, mock.Anything).Return(&model.Subscription{Price: 599}, nil)
+	mockRepo.On("GetPriceHistory", mock.Anything, testTenantID, mock.Anything).Return([]int(nil), errors.New("connection reset"))
+
+	report, err := svc.DetectPriceAnomaly(testCtx(), uuid.New())
+
+	assert.Nil(t, report)
+	assert.EqualError(t, err, "failed to get price history: connection reset")
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+// AnonymizeService detaches users from the subscriptions they held, so
+// that the spending stays in every total while the person is forgotten.
+type AnonymizeService interface {
+	AnonymizeUser(ctx context.Context, req AnonymizeRequest) (*model.AnonymizeResponse, error)
+	ListAnonymizations(ctx context.Context, userID uuid.UUID) ([]model.Anonymization, error)
+}
+
+// AnonymizeRequest names the user to anonymize and, for the audit trail,
+// who asked. Irreversible keeps no record of the anonymous ID.
+type AnonymizeRequest struct {
+	UserID       uuid.UUID
+	Irreversible bool
+	Actor        string
+	RequestID    string
+}
+
+type anonymizeService struct {
+	repo repository.AnonymizationRepository
+}
+
+func NewAnonymizeService(repo repository.AnonymizationRepository) AnonymizeService {
+	return &anonymizeService{repo: repo}
+}
+
+// AnonymizeUser moves the user's subscriptions to a new random ID. The
+// audit entry is filed under that ID. An irreversible anonymization leaves
+// the request ID out of it: the access log ties the request ID to the URL,
+// and so to the user.
+func (s *anonymizeService) AnonymizeUser(ctx context.Context, req AnonymizeRequest) (*model.AnonymizeResponse, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &model.Anonymization{UserID: req.UserID, AnonymousID: uuid.New()}
+	entry := &model.AuditEntry{
+		UserID: a.AnonymousID,
+		Actor:  req.Actor,
+		Action: model.AuditActionAnonymize,
+	}
+	if !req.Irreversible {
+		entry.RequestID = req.RequestID
+	}
+
+	detached, err := s.repo.AnonymizeUser(ctx, tenantID, a, !req.Irreversible, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	// Neither ID is logged, or the log would link them.
+	logger.FromContext(ctx).Info("user anonymized",
+		slog.String("actor", req.Actor),
+		slog.Bool("irreversible", req.Irreversible),
+		slog.Int64("subscriptions", detached),
+	)
+	return &model.AnonymizeResponse{Subscriptions: detached, Irreversible: req.Irreversible}, nil
+}
+
+// ListAnonymizations returns the anonymous IDs recorded for userID, oldest
+// first; it is empty for a user never anonymized or only irreversibly.
+func (s *anonymizeService) ListAnonymizations(ctx context.Context, userID uuid.UUID) ([]model.Anonymization, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.repo.ListAnonymizations(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list anonymizations: %w", err)
+	}
+	if list == nil {
+		list = []model.Anonymization{}
+	}
+	return list, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockAnonymizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnonymizationRepository) AnonymizeUser(ctx context.Context, tenantID uuid.UUID, a *model.Anonymization, keepMapping bool, entry *model.AuditEntry) (int64, error) {
+	args := m.Called(ctx, tenantID, a, keepMapping, entry)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAnonymizationRepository) ListAnonymizations(ctx context.Context, tenantID, userID uuid.UUID) ([]model.Anonymization, error) {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Get(0).([]model.Anonymization), args.Error(1)
+}
+
+func newTestAnonymizeService() (AnonymizeService, *MockAnonymizationRepository) {
+	repo := &MockAnonymizationRepository{}
+	return NewAnonymizeService(repo), repo
+}
+
+func TestAnonymizeUser_KeepsMapping(t *testing.T) {
+	s, repo := newTestAnonymizeService()
+	ctx := testCtx()
+	userID := fixedUUID()
+
+	var anonID uuid.UUID
+	repo.On("AnonymizeUser", ctx, testTenantID, mock.MatchedBy(func(a *model.Anonymization) bool {
+		anonID = a.AnonymousID
+		return a.UserID == userID && a.AnonymousID != uuid.Nil && a.AnonymousID != userID
+	}), true, mock.MatchedBy(func(e *model.AuditEntry) bool {
+		return e.UserID == anonID && e.Actor == model.AuditActorUser && e.Action == model.AuditActionAnonymize && e.RequestID == "req-1"
+	})).Return(int64(4), nil)
+
+	resp, err := s.AnonymizeUser(ctx, AnonymizeRequest{UserID: userID, Actor: model.AuditActorUser, RequestID: "req-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.AnonymizeResponse{Subscriptions: 4}, resp)
+	repo.AssertExpectations(t)
+}
+
+func TestAnonymizeUser_IrreversibleLeavesNoLink(t *testing.T) {
+	s, repo := newTestAnonymizeService()
+	ctx := testCtx()
+
+	repo.On("AnonymizeUser", ctx, testTenantID, mock.Anything, false, mock.MatchedBy(func(e *model.AuditEntry) bool {
+		return e.RequestID == ""
+	})).Return(int64(0), nil)
+
+	resp, err := s.AnonymizeUser(ctx, AnonymizeRequest{UserID: fixedUUID(), Irreversible: true, Actor: model.AuditActorAdmin, RequestID: "req-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.AnonymizeResponse{Irreversible: true}, resp)
+	repo.AssertExpectations(t)
+}
+
+func TestAnonymizeUser_RepoError(t *testing.T) {
+	s, repo := newTestAnonymizeService()
+	repo.On("AnonymizeUser", mock.Anything, testTenantID, mock.Anything, true, mock.Anything).Return(int64(0), errors.New("db error"))
+
+	resp, err := s.AnonymizeUser(testCtx(), AnonymizeRequest{UserID: fixedUUID()})
+
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "failed to anonymize user")
+}
+
+func TestAnonymizeUser_NoTenant(t *testing.T) {
+	s, repo := newTestAnonymizeService()
+
+	_, err := s.AnonymizeUser(context.Background(), AnonymizeRequest{UserID: fixedUUID()})
+
+	assert.ErrorIs(t, err, ErrNoTenant)
+	repo.AssertNotCalled(t, "AnonymizeUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListAnonymizations_Empty(t *testing.T) {
+	s, repo := newTestAnonymizeService()
+	ctx := testCtx()
+	repo.On("ListAnonymizations", ctx, testTenantID, fixedUUID()).Return([]model.Anonymization(nil), nil)
+
+	list, err := s.ListAnonymizations(ctx, fixedUUID())
+
+	require.NoError(t, err)
+	assert.NotNil(t, list)
+	assert.Empty(t, list)
+}
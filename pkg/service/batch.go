@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// maxBatchGetSize bounds how many IDs BatchGetSubscriptions looks up at
+// once.
+const maxBatchGetSize = 200
+
+// BatchGetRequest lists the subscriptions to look up.
+type BatchGetRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// BatchGetSubscriptions looks up several subscriptions in one query. Found
+// subscriptions come back in the order of ids and the rest are listed in
+// Missing, also in that order; an ID given twice is reported once.
+func (s *subscriptionService) BatchGetSubscriptions(ctx context.Context, ids []uuid.UUID) (*model.BatchGetResult, error) {
+	verr := &model.ValidationError{}
+	switch {
+	case len(ids) == 0:
+		verr.Add("ids", "is required")
+	case len(ids) > maxBatchGetSize:
+		verr.Add("ids", "must list at most 200 IDs")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	subs, err := s.repo.GetByIDs(ctx, tenantID, unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	found := make(map[uuid.UUID]*model.Subscription, len(subs))
+	for _, sub := range subs {
+		found[sub.ID] = sub
+	}
+
+	result := &model.BatchGetResult{
+		Subscriptions: make([]*model.Subscription, 0, len(subs)),
+		Missing:       []uuid.UUID{},
+	}
+	for _, id := range unique {
+		if sub, ok := found[id]; ok {
+			result.Subscriptions = append(result.Subscriptions, sub)
+		} else {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	return result, nil
+}
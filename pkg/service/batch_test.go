@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestBatchGetSubscriptions_KeepsRequestOrder(t *testing.T) {
+	svc, mockRepo := newTestService()
+	a, b, c, gone := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	mockRepo.On("GetByIDs", mock.Anything, testTenantID, []uuid.UUID{c, gone, a, b}).Return([]*model.Subscription{
+		{ID: a}, {ID: b}, {ID: c},
+	}, nil)
+
+	result, err := svc.BatchGetSubscriptions(testCtx(), []uuid.UUID{c, gone, a, c, b})
+
+	require.NoError(t, err)
+	require.Len(t, result.Subscriptions, 3)
+	assert.Equal(t, c, result.Subscriptions[0].ID)
+	assert.Equal(t, a, result.Subscriptions[1].ID)
+	assert.Equal(t, b, result.Subscriptions[2].ID)
+	assert.Equal(t, []uuid.UUID{gone}, result.Missing)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchGetSubscriptions_NothingFound(t *testing.T) {
+	svc, mockRepo := newTestService()
+	id := uuid.New()
+	mockRepo.On("GetByIDs", mock.Anything, testTenantID, []uuid.UUID{id}).Return([]*model.Subscription(nil), nil)
+
+	result, err := svc.BatchGetSubscriptions(testCtx(), []uuid.UUID{id})
+
+	require.NoError(t, err)
+	assert.NotNil(t, result.Subscriptions)
+	assert.Empty(t, result.Subscriptions)
+	assert.Equal(t, []uuid.UUID{id}, result.Missing)
+}
+
+func TestBatchGetSubscriptions_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []uuid.UUID
+		want string
+	}{
+		{"empty", nil, "is required"},
+		{"too many", make([]uuid.UUID, maxBatchGetSize+1), "must list at most 200 IDs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mockRepo := newTestService()
+
+			result, err := svc.BatchGetSubscriptions(testCtx(), tt.ids)
+
+			assert.Nil(t, result)
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.want, verr.Fields["ids"])
+			mockRepo.AssertNotCalled(t, "GetByIDs", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestBatchGetSubscriptions_StoreError(t *testing.T) {
+	svc, mockRepo := newTestService()
+	mockRepo.On("GetByIDs", mock.Anything, mock.Anything, mock.Anything).Return([]*model.Subscription(nil), errors.New("connection reset"))
+
+	result, err := svc.BatchGetSubscriptions(testCtx(), []uuid.UUID{uuid.New()})
+
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "failed to get subscriptions: connection reset")
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// BulkFailure explains why the request at Index was not stored.
+type BulkFailure struct {
+	Index int
+	Err   error
+}
+
+// BulkCreateResult lists what BulkCreateSubscriptions stored and what it
+// rejected.
+type BulkCreateResult struct {
+	Created []*model.Subscription
+	Failed  []BulkFailure
+}
+
+// BulkCreateSubscriptions checks every request like CreateSubscription
+// and stores the valid ones together. Invalid requests, including ones
+// naming a missing catalog entry, duplicates of stored subscriptions and
+// those that would take their user past their subscription limit are
+// reported in Failed and do not stop the rest; a storage error fails the
+// whole batch.
+func (s *subscriptionService) BulkCreateSubscriptions(ctx context.Context, reqs []CreateSubscriptionRequest) (*BulkCreateResult, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkCreateResult{Created: make([]*model.Subscription, 0, len(reqs))}
+	// subs holds the prepared subscription of every valid request, nil
+	// for the others, until their owners' limits are known.
+	subs := make([]*model.Subscription, len(reqs))
+	var users []uuid.UUID
+
+	for i, req := range reqs {
+		sub, err := s.newSubscription(ctx, req)
+		if err != nil {
+			var verr *model.ValidationError
+			var dup *model.DuplicateError
+			if !errors.As(err, &verr) && !errors.As(err, &dup) {
+				return nil, err
+			}
+			result.Failed = append(result.Failed, BulkFailure{Index: i, Err: err})
+			continue
+		}
+		if !slices.Contains(users, sub.UserID) {
+			users = append(users, sub.UserID)
+		}
+		subs[i] = sub
+	}
+
+	txCtx, commit, rollback, err := s.userLimitTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscriptions: %w", err)
+	}
+	defer rollback()
+
+	// subscriptionsLeft locks each user until the batch commits. Taking
+	// the locks in one order keeps two batches for the same users from
+	// deadlocking.
+	slices.SortFunc(users, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	left := make(map[uuid.UUID]int, len(users))
+	for _, userID := range users {
+		if left[userID], err = s.subscriptionsLeft(txCtx, tenantID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, sub := range subs {
+		if sub == nil {
+			continue
+		}
+		switch n := left[sub.UserID]; n {
+		case 0:
+			result.Failed = append(result.Failed, BulkFailure{Index: i, Err: model.ErrLimitExceeded})
+			continue
+		case unlimited:
+		default:
+			left[sub.UserID] = n - 1
+		}
+		result.Created = append(result.Created, sub)
+	}
+	slices.SortFunc(result.Failed, func(a, b BulkFailure) int { return a.Index - b.Index })
+
+	if len(result.Created) > 0 {
+		if err := s.repo.BulkCreate(txCtx, result.Created); err != nil {
+			return nil, fmt.Errorf("failed to create subscriptions: %w", err)
+		}
+	}
+	if err := commit(); err != nil {
+		return nil, fmt.Errorf("failed to create subscriptions: %w", err)
+	}
+	s.logger(ctx).Info("subscriptions imported",
+		slog.Int("created", len(result.Created)),
+		slog.Int("failed", len(result.Failed)))
+	s.subscriptionsCreated(ctx, result.Created...)
+
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestBulkCreateSubscriptions_SkipsInvalid(t *testing.T) {
+	svc, mockRepo := newTestService()
+	ctx := testCtx()
+
+	invalid := validCreateRequest()
+	invalid.Price = 0
+	reqs := []CreateSubscriptionRequest{validCreateRequest(), invalid, validCreateRequest()}
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("BulkCreate", mock.Anything, mock.MatchedBy(func(subs []*model.Subscription) bool {
+		return len(subs) == 2 && subs[0].BillingCycle == model.CycleMonthly
+	})).Return(nil)
+
+	result, err := svc.BulkCreateSubscriptions(ctx, reqs)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, 1, result.Failed[0].Index)
+	assert.ErrorIs(t, result.Failed[0].Err, model.ErrValidation)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkCreateSubscriptions_NothingValid(t *testing.T) {
+	svc, mockRepo := newTestService()
+
+	result, err := svc.BulkCreateSubscriptions(testCtx(), []CreateSubscriptionRequest{{}})
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Failed, 1)
+	mockRepo.AssertNotCalled(t, "BulkCreate", mock.Anything, mock.Anything)
+}
+
+func TestBulkCreateSubscriptions_StoreError(t *testing.T) {
+	svc, mockRepo := newTestService()
+	ctx := testCtx()
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("BulkCreate", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+
+	result, err := svc.BulkCreateSubscriptions(ctx, []CreateSubscriptionRequest{validCreateRequest()})
+
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "failed to create subscriptions: connection reset")
+}
+
+func TestBulkCreateSubscriptions_StopsAtUserLimit(t *testing.T) {
+	svc, mockRepo := newTestService()
+	WithUserLimits(0)(svc)
+	ctx := testCtx()
+	req := validCreateRequest()
+
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("LockUserSubscriptions", txCtx, testTenantID, req.UserID).Return(nil).Once()
+	mockRepo.On("GetUserLimit", txCtx, testTenantID, req.UserID).Return(3, nil).Once()
+	mockRepo.On("Count", txCtx, mock.Anything).Return(1, nil).Once()
+	mockRepo.On("BulkCreate", txCtx, mock.MatchedBy(func(subs []*model.Subscription) bool {
+		return len(subs) == 2
+	})).Return(nil)
+
+	result, err := svc.BulkCreateSubscriptions(ctx, []CreateSubscriptionRequest{req, req, req})
+
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, 2, result.Failed[0].Index)
+	assert.ErrorIs(t, result.Failed[0].Err, model.ErrLimitExceeded)
+	assert.True(t, tx.committed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkCreateSubscriptions_LocksUsersInOrder(t *testing.T) {
+	svc, mockRepo := newTestService()
+	WithUserLimits(0)(svc)
+	ctx := testCtx()
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	second := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	reqFirst, reqSecond := validCreateRequest(), validCreateRequest()
+	reqFirst.UserID, reqSecond.UserID = first, second
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	var locked []uuid.UUID
+	mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil)
+	mockRepo.On("LockUserSubscriptions", txCtx, testTenantID, mock.Anything).
+		Run(func(args mock.Arguments) { locked = append(locked, args.Get(2).(uuid.UUID)) }).
+		Return(nil)
+	mockRepo.On("GetUserLimit", txCtx, testTenantID, mock.Anything).Return(0, nil)
+	mockRepo.On("BulkCreate", txCtx, mock.Anything).Return(nil)
+
+	_, err := svc.BulkCreateSubscriptions(ctx, []CreateSubscriptionRequest{reqSecond, reqFirst, reqSecond})
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first, second}, locked)
+}
+
+func TestBulkCreateSubscriptions_DefaultDuration(t *testing.T) {
+	svc, mockRepo := newTestService()
+	WithDefaultDuration(365)(svc)
+	ctx := testCtx()
+	req := validCreateRequest()
+	want := req.StartDate.AddDate(1, 0, 0)
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("BulkCreate", mock.Anything, mock.MatchedBy(func(subs []*model.Subscription) bool {
+		return len(subs) == 1 && subs[0].EndDate != nil && subs[0].EndDate.Equal(want)
+	})).Return(nil)
+
+	_, err := svc.BulkCreateSubscriptions(ctx, []CreateSubscriptionRequest{req})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkCreateSubscriptions_SkipsDuplicates(t *testing.T) {
+	svc, mockRepo := newTestService()
+	ctx := testCtx()
+	existing := uuid.New()
+	dup := validCreateRequest()
+	dup.ServiceName = "Netflix"
+
+	mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, dup.UserID, "netflix", mock.Anything, mock.Anything).
+		Return(&existing, nil)
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("BulkCreate", ctx, mock.MatchedBy(func(subs []*model.Subscription) bool {
+		return len(subs) == 1
+	})).Return(nil)
+
+	result, err := svc.BulkCreateSubscriptions(ctx, []CreateSubscriptionRequest{validCreateRequest(), dup})
+
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 1)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, 1, result.Failed[0].Index)
+	var dupErr *model.DuplicateError
+	require.ErrorAs(t, result.Failed[0].Err, &dupErr)
+	assert.Equal(t, existing, dupErr.ExistingID)
+}
+
+func TestBulkCreateSubscriptions_AllowDuplicate(t *testing.T) {
+	svc, mockRepo := newTestService()
+	req := validCreateRequest()
+	req.AllowDuplicate = true
+	mockRepo.On("BulkCreate", mock.Anything, mock.Anything).Return(nil)
+
+	result, err := svc.BulkCreateSubscriptions(testCtx(), []CreateSubscriptionRequest{req})
+
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 1)
+	mockRepo.AssertNotCalled(t, "ExistsActiveOverlap", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+type CatalogService interface {
+	CreateCatalogEntry(ctx context.Context, req CatalogEntryRequest) (*model.CatalogEntry, error)
+	GetCatalogEntry(ctx context.Context, id uuid.UUID) (*model.CatalogEntry, error)
+	// ListCatalogEntries returns the tenant's catalog, only the entries in
+	// category when it is not empty.
+	ListCatalogEntries(ctx context.Context, category string) ([]model.CatalogEntry, error)
+	UpdateCatalogEntry(ctx context.Context, req CatalogEntryRequest) (*model.CatalogEntry, error)
+	DeleteCatalogEntry(ctx context.Context, id uuid.UUID) error
+}
+
+type catalogService struct {
+	repo repository.CatalogRepository
+}
+
+func NewCatalogService(repo repository.CatalogRepository) CatalogService {
+	return &catalogService{repo: repo}
+}
+
+// CatalogEntryRequest creates a catalog entry or replaces one. Name is
+// stored normalised like a subscription's service name, since it becomes
+// one; category is lower-cased.
+type CatalogEntryRequest struct {
+	ID           uuid.UUID `json:"-"`
+	Name         string    `json:"name" example:"yandex plus"`
+	Description  string    `json:"description,omitempty" example:"Музыка, фильмы и кешбэк баллами"`
+	Category     string    `json:"category,omitempty" example:"streaming"`
+	LogoURL      string    `json:"logo_url,omitempty" example:"https://example.com/logos/yandex-plus.png"`
+	DefaultPrice int       `json:"default_price" example:"599"`
+}
+
+func (s *catalogService) CreateCatalogEntry(ctx context.Context, req CatalogEntryRequest) (*model.CatalogEntry, error) {
+	entry, err := newCatalogEntry(uuid.New(), req)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, tenantID, entry); err != nil {
+		return nil, fmt.Errorf("failed to create catalog entry: %w", err)
+	}
+	logger.FromContext(ctx).Info("catalog entry created", slog.String("id", entry.ID.String()), slog.String("name", entry.Name))
+
+	return entry, nil
+}
+
+func (s *catalogService) GetCatalogEntry(ctx context.Context, id uuid.UUID) (*model.CatalogEntry, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.repo.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (s *catalogService) ListCatalogEntries(ctx context.Context, category string) ([]model.CatalogEntry, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.List(ctx, tenantID, normaliseCategory(category))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *catalogService) UpdateCatalogEntry(ctx context.Context, req CatalogEntryRequest) (*model.CatalogEntry, error) {
+	entry, err := newCatalogEntry(req.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, tenantID, entry); err != nil {
+		return nil, fmt.Errorf("failed to update catalog entry: %w", err)
+	}
+	logger.FromContext(ctx).Info("catalog entry updated", slog.String("id", entry.ID.String()), slog.String("name", entry.Name))
+
+	return entry, nil
+}
+
+func (s *catalogService) DeleteCatalogEntry(ctx context.Context, id uuid.UUID) error {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		return fmt.Errorf("failed to delete catalog entry: %w", err)
+	}
+	logger.FromContext(ctx).Info("catalog entry deleted", slog.String("id", id.String()))
+	return nil
+}
+
+// newCatalogEntry normalises req into the entry with the given ID and
+// validates it.
+func newCatalogEntry(id uuid.UUID, req CatalogEntryRequest) (*model.CatalogEntry, error) {
+	entry := &model.CatalogEntry{
+		ID:           id,
+		Name:         NormaliseServiceName(req.Name),
+		Description:  strings.TrimSpace(req.Description),
+		Category:     normaliseCategory(req.Category),
+		LogoURL:      strings.TrimSpace(req.LogoURL),
+		DefaultPrice: req.DefaultPrice,
+	}
+
+	verr := &model.ValidationError{}
+	switch {
+	case entry.Name == "":
+		verr.Add("name", "must not be empty")
+	case utf8.RuneCountInString(entry.Name) > maxServiceNameLength:
+		verr.Add("name", "must be at most 255 characters")
+	}
+	if entry.DefaultPrice <= 0 {
+		verr.Add("default_price", "must be greater than 0")
+	}
+	if entry.LogoURL != "" {
+		u, err := url.Parse(entry.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.Add("logo_url", "must be an absolute http or https URL")
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func normaliseCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// WithCatalog lets subscriptions be created from a catalog entry, see
+// CreateSubscriptionRequest.CatalogServiceID. Without it a request naming
+// an entry is rejected.
+func WithCatalog(repo repository.CatalogRepository) ServiceOption {
+	return func(s *subscriptionService) {
+		s.catalog = repo
+	}
+}
+
+// fillFromCatalog copies the service name and price of the catalog entry
+// req refers to into the fields req leaves empty. A request without
+// CatalogServiceID is left alone; one naming an entry the tenant does not
+// have is a validation error.
+func (s *subscriptionService) fillFromCatalog(ctx context.Context, req *CreateSubscriptionRequest) error {
+	if req.CatalogServiceID == nil {
+		return nil
+	}
+	notFound := &model.ValidationError{}
+	notFound.Add("catalog_service_id", "does not exist")
+	if s.catalog == nil {
+		return notFound
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.catalog.Get(ctx, tenantID, *req.CatalogServiceID)
+	if errors.Is(err, model.ErrNotFound) {
+		return notFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get catalog entry: %w", err)
+	}
+
+	if strings.TrimSpace(req.ServiceName) == "" {
+		req.ServiceName = entry.Name
+	}
+	if req.Price == 0 {
+		req.Price = entry.DefaultPrice
+	}
+	return nil
+}
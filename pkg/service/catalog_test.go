@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockCatalogRepository struct {
+	mock.Mock
+}
+
+func (m *MockCatalogRepository) Create(ctx context.Context, tenantID uuid.UUID, entry *model.CatalogEntry) error {
+	args := m.Called(ctx, tenantID, entry)
+	return args.Error(0)
+}
+
+func (m *MockCatalogRepository) Get(ctx context.Context, tenantID, id uuid.UUID) (*model.CatalogEntry, error) {
+	args := m.Called(ctx, tenantID, id)
+	return args.Get(0).(*model.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogRepository) List(ctx context.Context, tenantID uuid.UUID, category string) ([]model.CatalogEntry, error) {
+	args := m.Called(ctx, tenantID, category)
+	return args.Get(0).([]model.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogRepository) Update(ctx context.Context, tenantID uuid.UUID, entry *model.CatalogEntry) error {
+	args := m.Called(ctx, tenantID, entry)
+	return args.Error(0)
+}
+
+func (m *MockCatalogRepository) Delete(ctx context.Context, tenantID, id uuid.UUID) error {
+	args := m.Called(ctx, tenantID, id)
+	return args.Error(0)
+}
+
+func newTestCatalogService() (CatalogService, *MockCatalogRepository) {
+	repo := &MockCatalogRepository{}
+	return NewCatalogService(repo), repo
+}
+
+func TestCreateCatalogEntry_Normalises(t *testing.T) {
+	s, repo := newTestCatalogService()
+	ctx := testCtx()
+
+	repo.On("Create", ctx, testTenantID, mock.MatchedBy(func(entry *model.CatalogEntry) bool {
+		return entry.ID != uuid.Nil &&
+			entry.Name == "yandex plus" &&
+			entry.Category == "streaming" &&
+			entry.DefaultPrice == 599
+	})).Return(nil)
+
+	entry, err := s.CreateCatalogEntry(ctx, CatalogEntryRequest{Name: " Yandex Plus", Category: "Streaming ", DefaultPrice: 599})
+
+	require.NoError(t, err)
+	assert.Equal(t, "yandex plus", entry.Name)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateCatalogEntry_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   CatalogEntryRequest
+		field string
+	}{
+		{"no name", CatalogEntryRequest{Name: "  ", DefaultPrice: 599}, "name"},
+		{"no price", CatalogEntryRequest{Name: "netflix"}, "default_price"},
+		{"relative logo", CatalogEntryRequest{Name: "netflix", DefaultPrice: 999, LogoURL: "/logos/netflix.png"}, "logo_url"},
+		{"ftp logo", CatalogEntryRequest{Name: "netflix", DefaultPrice: 999, LogoURL: "ftp://example.com/netflix.png"}, "logo_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestCatalogService()
+
+			_, err := s.CreateCatalogEntry(testCtx(), tt.req)
+
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Contains(t, verr.Fields, tt.field)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestListCatalogEntries_NormalisesCategory(t *testing.T) {
+	s, repo := newTestCatalogService()
+	ctx := testCtx()
+	want := []model.CatalogEntry{{ID: uuid.New(), Name: "netflix", Category: "streaming", DefaultPrice: 999}}
+
+	repo.On("List", ctx, testTenantID, "streaming").Return(want, nil)
+
+	entries, err := s.ListCatalogEntries(ctx, " Streaming")
+
+	require.NoError(t, err)
+	assert.Equal(t, want, entries)
+}
+
+func newTestServiceWithCatalog() (*subscriptionService, *mocks.SubscriptionRepository, *MockCatalogRepository) {
+	s, mockRepo := newTestService()
+	catalog := &MockCatalogRepository{}
+	WithCatalog(catalog)(s)
+	return s, mockRepo, catalog
+}
+
+func TestCreateSubscription_FillsFromCatalog(t *testing.T) {
+	catalogID := uuid.New()
+	entry := &model.CatalogEntry{ID: catalogID, Name: "netflix", DefaultPrice: 999}
+
+	tests := []struct {
+		name      string
+		req       CreateSubscriptionRequest
+		wantName  string
+		wantPrice int
+	}{
+		{"both missing", CreateSubscriptionRequest{}, "netflix", 999},
+		{"own price kept", CreateSubscriptionRequest{Price: 799}, "netflix", 799},
+		{"own name kept", CreateSubscriptionRequest{ServiceName: "Netflix Family"}, "netflix family", 999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo, catalog := newTestServiceWithCatalog()
+			ctx := testCtx()
+			req := tt.req
+			req.CatalogServiceID = &catalogID
+			req.UserID = fixedUUID()
+			req.StartDate = fixedTime()
+
+			catalog.On("Get", ctx, testTenantID, catalogID).Return(entry, nil)
+			expectNoOverlap(mockRepo, ctx)
+			mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *model.Subscription) bool {
+				return sub.ServiceName == tt.wantName &&
+					sub.Price == tt.wantPrice &&
+					sub.CatalogServiceID != nil && *sub.CatalogServiceID == catalogID
+			})).Return(nil)
+
+			_, err := s.CreateSubscription(ctx, req)
+
+			require.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateSubscription_MissingCatalogEntry(t *testing.T) {
+	s, mockRepo, catalog := newTestServiceWithCatalog()
+	ctx := testCtx()
+	catalogID := uuid.New()
+
+	catalog.On("Get", ctx, testTenantID, catalogID).
+		Return((*model.CatalogEntry)(nil), model.ErrNotFound)
+
+	_, err := s.CreateSubscription(ctx, CreateSubscriptionRequest{
+		CatalogServiceID: &catalogID,
+		UserID:           fixedUUID(),
+		StartDate:        fixedTime(),
+	})
+
+	var verr *model.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, map[string]string{"catalog_service_id": "does not exist"}, verr.Fields)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_CatalogLookupError(t *testing.T) {
+	s, _, catalog := newTestServiceWithCatalog()
+	ctx := testCtx()
+	catalogID := uuid.New()
+	dbErr := errors.New("connection refused")
+
+	catalog.On("Get", ctx, testTenantID, catalogID).Return((*model.CatalogEntry)(nil), dbErr)
+
+	_, err := s.CreateSubscription(ctx, CreateSubscriptionRequest{CatalogServiceID: &catalogID})
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.NotErrorAs(t, err, new(*model.ValidationError))
+}
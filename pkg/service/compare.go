@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// comparisonMovers is how many services ComparePeriods lists in TopMovers.
+const comparisonMovers = 5
+
+// ComparePeriodsRequest asks for the spend of UserID, or of the whole
+// tenant when it is nil, in two periods. Every bound is required and
+// included in its period.
+type ComparePeriodsRequest struct {
+	UserID      *uuid.UUID
+	PeriodAFrom time.Time
+	PeriodATo   time.Time
+	PeriodBFrom time.Time
+	PeriodBTo   time.Time
+}
+
+// ComparePeriods totals both periods the way GetTotalCost does with
+// model.TotalProrated, one query per period broken down by service, and
+// ranks the services by how much their spend changed. Since a month is
+// billed in full as soon as a period touches it, periods sharing a
+// calendar month are rejected along with inverted ones.
+func (s *subscriptionService) ComparePeriods(ctx context.Context, req ComparePeriodsRequest) (*model.PeriodComparison, error) {
+	if err := s.validateComparison(req); err != nil {
+		return nil, err
+	}
+
+	var costsA, costsB []model.ServiceCost
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		costsA, err = s.periodCostByService(gctx, req.UserID, req.PeriodAFrom, req.PeriodATo)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		costsB, err = s.periodCostByService(gctx, req.UserID, req.PeriodBFrom, req.PeriodBTo)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	cmp := &model.PeriodComparison{
+		PeriodA:  model.PeriodTotal{From: req.PeriodAFrom, To: req.PeriodATo},
+		PeriodB:  model.PeriodTotal{From: req.PeriodBFrom, To: req.PeriodBTo},
+		Currency: s.baseCurrency(),
+	}
+	changes := make(map[string]*model.ServiceCostChange)
+	change := func(name string) *model.ServiceCostChange {
+		if c, ok := changes[name]; ok {
+			return c
+		}
+		c := &model.ServiceCostChange{ServiceName: name}
+		changes[name] = c
+		return c
+	}
+	for _, cost := range costsA {
+		cmp.PeriodA.Total += cost.Total
+		change(cost.ServiceName).TotalA = cost.Total
+	}
+	for _, cost := range costsB {
+		cmp.PeriodB.Total += cost.Total
+		change(cost.ServiceName).TotalB = cost.Total
+	}
+
+	cmp.Delta = cmp.PeriodB.Total - cmp.PeriodA.Total
+	if cmp.PeriodA.Total != 0 {
+		pct := math.Round(float64(cmp.Delta)/float64(cmp.PeriodA.Total)*100*100) / 100
+		cmp.PercentChange = &pct
+	}
+
+	cmp.TopMovers = make([]model.ServiceCostChange, 0, len(changes))
+	for _, c := range changes {
+		if c.Delta = c.TotalB - c.TotalA; c.Delta != 0 {
+			cmp.TopMovers = append(cmp.TopMovers, *c)
+		}
+	}
+	sort.Slice(cmp.TopMovers, func(i, j int) bool {
+		a, b := cmp.TopMovers[i], cmp.TopMovers[j]
+		if abs(a.Delta) != abs(b.Delta) {
+			return abs(a.Delta) > abs(b.Delta)
+		}
+		return a.ServiceName < b.ServiceName
+	})
+	cmp.TopMovers = cmp.TopMovers[:min(comparisonMovers, len(cmp.TopMovers))]
+
+	return cmp, nil
+}
+
+// validateComparison checks that both periods are given, run forwards, are
+// within the range cap and share no calendar month.
+func (s *subscriptionService) validateComparison(req ComparePeriodsRequest) error {
+	verr := &model.ValidationError{}
+	if req.UserID != nil && *req.UserID == uuid.Nil {
+		verr.Add("user_id", "must not be the nil UUID")
+	}
+
+	periods := []struct {
+		name     string
+		from, to time.Time
+	}{
+		{"period_a", req.PeriodAFrom, req.PeriodATo},
+		{"period_b", req.PeriodBFrom, req.PeriodBTo},
+	}
+	valid := true
+	for _, p := range periods {
+		switch {
+		case p.from.IsZero():
+			verr.Add(p.name+"_from", "is required")
+		case p.to.IsZero():
+			verr.Add(p.name+"_to", "is required")
+		case p.from.After(p.to):
+			verr.Add(p.name+"_from", "must not be after "+p.name+"_to")
+		case p.to.After(p.from.AddDate(s.maxTotalRangeYears, 0, 0)):
+			verr.Add(p.name+"_to", "must be at most "+strconv.Itoa(s.maxTotalRangeYears)+
+				" years after "+p.name+"_from")
+		default:
+			continue
+		}
+		valid = false
+	}
+
+	if valid && monthStart(req.PeriodAFrom).Compare(monthStart(req.PeriodBTo)) <= 0 &&
+		monthStart(req.PeriodBFrom).Compare(monthStart(req.PeriodATo)) <= 0 {
+		verr.Add("period_b_from", "periods must not overlap or share a calendar month")
+	}
+	return verr.OrNil()
+}
+
+// periodCostByService is the prorated spend of userID, or of the tenant,
+// between from and to per service.
+func (s *subscriptionService) periodCostByService(ctx context.Context, userID *uuid.UUID, from, to time.Time) ([]model.ServiceCost, error) {
+	filter, err := scopeFilter(ctx, model.SubscriptionFilter{UserID: userID, FromDate: &from, ToDate: &to})
+	if err != nil {
+		return nil, err
+	}
+	costs, err := s.repo.GetCostByService(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost by service: %w", err)
+	}
+	return costs, nil
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
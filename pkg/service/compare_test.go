@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// quarters compares the second quarter of 2025 with the first.
+func quarters(userID *uuid.UUID) ComparePeriodsRequest {
+	return ComparePeriodsRequest{
+		UserID:      userID,
+		PeriodAFrom: utcDate(2025, 1, 1),
+		PeriodATo:   utcDate(2025, 3, 31),
+		PeriodBFrom: utcDate(2025, 4, 1),
+		PeriodBTo:   utcDate(2025, 6, 30),
+	}
+}
+
+func expectPeriodCosts(mockRepo *mocks.SubscriptionRepository, userID *uuid.UUID, from, to time.Time, costs ...model.ServiceCost) {
+	mockRepo.On("GetCostByService", mock.Anything, scoped(model.SubscriptionFilter{UserID: userID, FromDate: &from, ToDate: &to})).
+		Return(costs, nil).Once()
+}
+
+func TestComparePeriods(t *testing.T) {
+	s, mockRepo := newTestService()
+	userID := fixedUUID()
+	req := quarters(&userID)
+
+	expectPeriodCosts(mockRepo, &userID, req.PeriodAFrom, req.PeriodATo,
+		model.ServiceCost{ServiceName: "office", Total: 6000},
+		model.ServiceCost{ServiceName: "netflix", Total: 2997},
+		model.ServiceCost{ServiceName: "spotify", Total: 897},
+		model.ServiceCost{ServiceName: "gym", Total: 600},
+	)
+	expectPeriodCosts(mockRepo, &userID, req.PeriodBFrom, req.PeriodBTo,
+		model.ServiceCost{ServiceName: "office", Total: 6000},
+		model.ServiceCost{ServiceName: "netflix", Total: 3597},
+		model.ServiceCost{ServiceName: "kinopoisk", Total: 1197},
+		model.ServiceCost{ServiceName: "spotify", Total: 897},
+	)
+
+	cmp, err := s.ComparePeriods(testCtx(), req)
+
+	require.NoError(t, err)
+	pct := 11.41
+	assert.Equal(t, &model.PeriodComparison{
+		PeriodA:       model.PeriodTotal{From: req.PeriodAFrom, To: req.PeriodATo, Total: 10494},
+		PeriodB:       model.PeriodTotal{From: req.PeriodBFrom, To: req.PeriodBTo, Total: 11691},
+		Delta:         1197,
+		PercentChange: &pct,
+		TopMovers: []model.ServiceCostChange{
+			{ServiceName: "kinopoisk", TotalA: 0, TotalB: 1197, Delta: 1197},
+			{ServiceName: "gym", TotalA: 600, TotalB: 0, Delta: -600},
+			{ServiceName: "netflix", TotalA: 2997, TotalB: 3597, Delta: 600},
+		},
+		Currency: defaultCurrency,
+	}, cmp)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestComparePeriods_KeepsTheLargestMovers(t *testing.T) {
+	s, mockRepo := newTestService()
+	req := quarters(nil)
+
+	var costsB []model.ServiceCost
+	for i := range comparisonMovers + 2 {
+		costsB = append(costsB, model.ServiceCost{ServiceName: string(rune('a' + i)), Total: 100 * (i + 1)})
+	}
+	expectPeriodCosts(mockRepo, nil, req.PeriodAFrom, req.PeriodATo)
+	expectPeriodCosts(mockRepo, nil, req.PeriodBFrom, req.PeriodBTo, costsB...)
+
+	cmp, err := s.ComparePeriods(testCtx(), req)
+
+	require.NoError(t, err)
+	assert.Nil(t, cmp.PercentChange, "no spend in period A")
+	require.Len(t, cmp.TopMovers, comparisonMovers)
+	assert.Equal(t, "g", cmp.TopMovers[0].ServiceName)
+	assert.Equal(t, "c", cmp.TopMovers[comparisonMovers-1].ServiceName)
+}
+
+func TestComparePeriods_NoSpend(t *testing.T) {
+	s, mockRepo := newTestService()
+	req := quarters(nil)
+	expectPeriodCosts(mockRepo, nil, req.PeriodAFrom, req.PeriodATo)
+	expectPeriodCosts(mockRepo, nil, req.PeriodBFrom, req.PeriodBTo)
+
+	cmp, err := s.ComparePeriods(testCtx(), req)
+
+	require.NoError(t, err)
+	assert.Zero(t, cmp.Delta)
+	assert.Nil(t, cmp.PercentChange)
+	assert.NotNil(t, cmp.TopMovers)
+	assert.Empty(t, cmp.TopMovers)
+}
+
+func TestComparePeriods_Validation(t *testing.T) {
+	nilUser := uuid.Nil
+
+	tests := []struct {
+		name  string
+		edit  func(*ComparePeriodsRequest)
+		field string
+		want  string
+	}{
+		{"missing bound", func(r *ComparePeriodsRequest) { r.PeriodBTo = time.Time{} }, "period_b_to", "is required"},
+		{"inverted period", func(r *ComparePeriodsRequest) { r.PeriodAFrom, r.PeriodATo = r.PeriodATo, r.PeriodAFrom },
+			"period_a_from", "must not be after period_a_to"},
+		{"too long", func(r *ComparePeriodsRequest) { r.PeriodBTo = utcDate(2031, 1, 1) },
+			"period_b_to", "must be at most 5 years after period_b_from"},
+		{"overlapping", func(r *ComparePeriodsRequest) { r.PeriodBFrom = utcDate(2025, 3, 15) },
+			"period_b_from", "periods must not overlap or share a calendar month"},
+		{"sharing a month", func(r *ComparePeriodsRequest) {
+			r.PeriodATo = utcDate(2025, 4, 10)
+			r.PeriodBFrom = utcDate(2025, 4, 11)
+		},
+			"period_b_from", "periods must not overlap or share a calendar month"},
+		{"b before a, overlapping", func(r *ComparePeriodsRequest) {
+			r.PeriodBFrom, r.PeriodBTo = utcDate(2024, 12, 1), utcDate(2025, 1, 31)
+		},
+			"period_b_from", "periods must not overlap or share a calendar month"},
+		{"nil user", func(r *ComparePeriodsRequest) { r.UserID = &nilUser }, "user_id", "must not be the nil UUID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			req := quarters(nil)
+			tt.edit(&req)
+
+			_, err := s.ComparePeriods(testCtx(), req)
+
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr), "got %v", err)
+			assert.Equal(t, tt.want, verr.Fields[tt.field], "fields: %v", verr.Fields)
+			assert.Empty(t, mockRepo.Calls)
+		})
+	}
+}
+
+func TestComparePeriods_BPeriodFirstIsFine(t *testing.T) {
+	s, mockRepo := newTestService()
+	req := quarters(nil)
+	req.PeriodAFrom, req.PeriodBFrom = req.PeriodBFrom, req.PeriodAFrom
+	req.PeriodATo, req.PeriodBTo = req.PeriodBTo, req.PeriodATo
+	mockRepo.On("GetCostByService", mock.Anything, mock.Anything).Return([]model.ServiceCost(nil), nil)
+
+	_, err := s.ComparePeriods(testCtx(), req)
+
+	require.NoError(t, err)
+}
+
+func TestComparePeriods_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	mockRepo.On("GetCostByService", mock.Anything, mock.Anything).Return([]model.ServiceCost(nil), errors.New("db error"))
+
+	cmp, err := s.ComparePeriods(testCtx(), quarters(nil))
+
+	assert.Nil(t, cmp)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get cost by service")
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+// CostAlertCooldown is how long a cost alert webhook stays quiet after it
+// was called, however often the threshold is crossed meanwhile.
+const CostAlertCooldown = time.Hour
+
+// costAlertTimeout bounds one webhook call. Calls run after the write that
+// triggered them has returned, so this only bounds how long Wait may take.
+const costAlertTimeout = 5 * time.Second
+
+// CostAlertChecker is told whenever a user's subscriptions have been
+// written, and alerts whoever wants to know that their spend is too high.
+// It never fails the write, so it reports nothing back.
+type CostAlertChecker interface {
+	Check(ctx context.Context, tenantID, userID uuid.UUID)
+}
+
+type noopCostAlertChecker struct{}
+
+func (noopCostAlertChecker) Check(context.Context, uuid.UUID, uuid.UUID) {}
+
+// WithCostAlertChecker has c checked after every subscription created or
+// updated. Without it no cost alerts are sent.
+func WithCostAlertChecker(c CostAlertChecker) ServiceOption {
+	return func(s *subscriptionService) {
+		s.costAlerts = c
+	}
+}
+
+// WebhookCostAlertChecker adds up the monthly equivalent of the prices of
+// the user's subscriptions active today and POSTs a model.CostAlert to the
+// webhook of each of their cost_alert_configs whose threshold the sum
+// exceeds, at most once per CostAlertCooldown. The webhooks are called in
+// the background, so a slow one does not hold up the write.
+type WebhookCostAlertChecker struct {
+	subs   repository.SubscriptionRepository
+	alerts repository.CostAlertRepository
+	client *http.Client
+	log    *slog.Logger
+	now    func() time.Time
+	// sends tracks the webhook calls in flight for Wait.
+	sends sync.WaitGroup
+}
+
+func NewCostAlertChecker(subs repository.SubscriptionRepository, alerts repository.CostAlertRepository, log *slog.Logger) *WebhookCostAlertChecker {
+	return &WebhookCostAlertChecker{
+		subs:   subs,
+		alerts: alerts,
+		client: &http.Client{Timeout: costAlertTimeout},
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+func (c *WebhookCostAlertChecker) Check(ctx context.Context, tenantID, userID uuid.UUID) {
+	if err := c.check(ctx, tenantID, userID); err != nil {
+		c.log.Warn("failed to check cost alerts",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (c *WebhookCostAlertChecker) check(ctx context.Context, tenantID, userID uuid.UUID) error {
+	configs, err := c.alerts.ListByUser(ctx, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list cost alerts: %w", err)
+	}
+	if len(configs) == 0 {
+		return nil
+	}
+
+	// A window of one day charges every subscription active today its
+	// monthly equivalent, so an annual plan counts a twelfth of its price.
+	now := c.now()
+	today := truncateToDay(now)
+	total, err := c.subs.GetProratedTotalCost(ctx, model.SubscriptionFilter{
+		UserID:   &userID,
+		TenantID: &tenantID,
+		FromDate: &today,
+		ToDate:   &today,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to calculate current spend: %w", err)
+	}
+
+	for _, config := range configs {
+		if total <= config.ThresholdAmount {
+			continue
+		}
+		claimed, err := c.alerts.ClaimTrigger(ctx, tenantID, config.ID, now, now.Add(-CostAlertCooldown))
+		if err != nil {
+			return fmt.Errorf("failed to claim cost alert: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		alert := model.CostAlert{UserID: userID, ThresholdAmount: config.ThresholdAmount, TotalCost: total, TriggeredAt: now}
+		c.sends.Add(1)
+		go func() {
+			defer c.sends.Done()
+			c.dispatch(context.WithoutCancel(ctx), tenantID, config, alert)
+		}()
+	}
+
+	return nil
+}
+
+// dispatch sends alert to the webhook of config, which was claimed for it,
+// and releases the claim again if the call fails so the next check retries.
+func (c *WebhookCostAlertChecker) dispatch(ctx context.Context, tenantID uuid.UUID, config model.CostAlertConfig, alert model.CostAlert) {
+	if err := c.send(ctx, config.WebhookURL, alert); err != nil {
+		c.log.Warn("failed to send cost alert",
+			slog.String("config_id", config.ID.String()),
+			slog.String("error", err.Error()),
+		)
+		if err := c.alerts.ResetTrigger(ctx, tenantID, config.ID, alert.TriggeredAt, config.LastTriggeredAt); err != nil {
+			c.log.Warn("failed to reset cost alert",
+				slog.String("config_id", config.ID.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+		return
+	}
+	c.log.Info("cost alert sent",
+		slog.String("config_id", config.ID.String()),
+		slog.String("user_id", alert.UserID.String()),
+		slog.Int("total_cost", alert.TotalCost),
+	)
+}
+
+// Wait blocks until the webhook calls in flight are done, so that they can
+// release their claims before the database is closed.
+func (c *WebhookCostAlertChecker) Wait() {
+	c.sends.Wait()
+}
+
+// send POSTs alert as JSON to url and expects a 2xx answer.
+func (c *WebhookCostAlertChecker) send(ctx context.Context, url string, alert model.CostAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
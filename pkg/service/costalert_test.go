@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// memCostAlertStore keeps cost alert configs in memory and claims them the
+// way the repository's UPDATE does.
+type memCostAlertStore struct {
+	configs []model.CostAlertConfig
+}
+
+func (s *memCostAlertStore) ListByUser(_ context.Context, _, userID uuid.UUID) ([]model.CostAlertConfig, error) {
+	var configs []model.CostAlertConfig
+	for _, c := range s.configs {
+		if c.UserID == userID {
+			configs = append(configs, c)
+		}
+	}
+	return configs, nil
+}
+
+func (s *memCostAlertStore) ClaimTrigger(_ context.Context, _, id uuid.UUID, at, before time.Time) (bool, error) {
+	for i := range s.configs {
+		c := &s.configs[i]
+		if c.ID != id || (c.LastTriggeredAt != nil && !c.LastTriggeredAt.Before(before)) {
+			continue
+		}
+		c.LastTriggeredAt = &at
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *memCostAlertStore) ResetTrigger(_ context.Context, _, id uuid.UUID, at time.Time, previous *time.Time) error {
+	for i := range s.configs {
+		c := &s.configs[i]
+		if c.ID == id && c.LastTriggeredAt != nil && c.LastTriggeredAt.Equal(at) {
+			c.LastTriggeredAt = previous
+		}
+	}
+	return nil
+}
+
+// alertWebhook records the cost alerts POSTed to it and answers status.
+type alertWebhook struct {
+	mu     sync.Mutex
+	alerts []model.CostAlert
+	status int
+}
+
+func newAlertWebhook(t *testing.T) (*alertWebhook, *httptest.Server) {
+	t.Helper()
+	hook := &alertWebhook{status: http.StatusNoContent}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var alert model.CostAlert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+
+		hook.mu.Lock()
+		defer hook.mu.Unlock()
+		hook.alerts = append(hook.alerts, alert)
+		w.WriteHeader(hook.status)
+	}))
+	t.Cleanup(srv.Close)
+	return hook, srv
+}
+
+func (h *alertWebhook) calls() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.alerts)
+}
+
+func newTestCostAlertChecker(store *memCostAlertStore, now *time.Time) (*WebhookCostAlertChecker, *mocks.SubscriptionRepository) {
+	subs := &mocks.SubscriptionRepository{}
+	c := NewCostAlertChecker(subs, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.now = func() time.Time { return *now }
+	return c, subs
+}
+
+func TestCostAlertChecker_FiresOncePerCooldown(t *testing.T) {
+	hook, srv := newAlertWebhook(t)
+	userID := uuid.New()
+	store := &memCostAlertStore{configs: []model.CostAlertConfig{
+		{ID: uuid.New(), UserID: userID, ThresholdAmount: 1000, WebhookURL: srv.URL},
+		{ID: uuid.New(), UserID: userID, ThresholdAmount: 5000, WebhookURL: srv.URL},
+	}}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	c, subs := newTestCostAlertChecker(store, &now)
+	ctx := testCtx()
+
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	subs.On("GetProratedTotalCost", ctx, scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &today, ToDate: &today})).
+		Return(1500, nil)
+
+	c.Check(ctx, testTenantID, userID)
+	c.Wait()
+	require.Equal(t, 1, hook.calls(), "only the breached threshold")
+	assert.Equal(t, model.CostAlert{UserID: userID, ThresholdAmount: 1000, TotalCost: 1500, TriggeredAt: now}, hook.alerts[0])
+
+	now = now.Add(CostAlertCooldown)
+	c.Check(ctx, testTenantID, userID)
+	c.Wait()
+	assert.Equal(t, 1, hook.calls(), "within the cooldown")
+
+	now = now.Add(time.Second)
+	c.Check(ctx, testTenantID, userID)
+	c.Wait()
+	assert.Equal(t, 2, hook.calls(), "once the cooldown is over")
+}
+
+func TestCostAlertChecker_UnderThreshold(t *testing.T) {
+	hook, srv := newAlertWebhook(t)
+	userID := uuid.New()
+	store := &memCostAlertStore{configs: []model.CostAlertConfig{
+		{ID: uuid.New(), UserID: userID, ThresholdAmount: 1000, WebhookURL: srv.URL},
+	}}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	c, subs := newTestCostAlertChecker(store, &now)
+	subs.On("GetProratedTotalCost", mock.Anything, mock.Anything).Return(1000, nil)
+
+	c.Check(testCtx(), testTenantID, userID)
+	c.Wait()
+
+	assert.Zero(t, hook.calls(), "reaching the threshold is not exceeding it")
+	assert.Nil(t, store.configs[0].LastTriggeredAt)
+}
+
+func TestCostAlertChecker_NoConfigsSkipsTotal(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	c, subs := newTestCostAlertChecker(&memCostAlertStore{}, &now)
+
+	c.Check(testCtx(), testTenantID, uuid.New())
+	c.Wait()
+
+	subs.AssertNotCalled(t, "GetProratedTotalCost", mock.Anything, mock.Anything)
+}
+
+func TestCostAlertChecker_FailedWebhookIsRetried(t *testing.T) {
+	hook, srv := newAlertWebhook(t)
+	hook.status = http.StatusBadGateway
+	userID := uuid.New()
+	store := &memCostAlertStore{configs: []model.CostAlertConfig{
+		{ID: uuid.New(), UserID: userID, ThresholdAmount: 1000, WebhookURL: srv.URL},
+	}}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	c, subs := newTestCostAlertChecker(store, &now)
+	subs.On("GetProratedTotalCost", mock.Anything, mock.Anything).Return(1500, nil)
+
+	c.Check(testCtx(), testTenantID, userID)
+	c.Wait()
+	require.Equal(t, 1, hook.calls())
+	assert.Nil(t, store.configs[0].LastTriggeredAt, "a failed call must not start the cooldown")
+
+	hook.status = http.StatusNoContent
+	now = now.Add(time.Minute)
+	c.Check(testCtx(), testTenantID, userID)
+	c.Wait()
+	assert.Equal(t, 2, hook.calls())
+	assert.Equal(t, now, *store.configs[0].LastTriggeredAt)
+}
+
+func TestCostAlertChecker_DoesNotWaitForWebhook(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	userID := uuid.New()
+	store := &memCostAlertStore{configs: []model.CostAlertConfig{
+		{ID: uuid.New(), UserID: userID, ThresholdAmount: 1000, WebhookURL: srv.URL},
+	}}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	c, subs := newTestCostAlertChecker(store, &now)
+	subs.On("GetProratedTotalCost", mock.Anything, mock.Anything).Return(1500, nil)
+
+	returned := make(chan struct{})
+	go func() {
+		c.Check(testCtx(), testTenantID, userID)
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Check waited for the webhook")
+	}
+	close(release)
+	c.Wait()
+	assert.Equal(t, now, *store.configs[0].LastTriggeredAt)
+}
+
+// recordingCostAlertChecker remembers the users it was asked to check.
+type recordingCostAlertChecker struct {
+	users []uuid.UUID
+}
+
+func (c *recordingCostAlertChecker) Check(_ context.Context, _, userID uuid.UUID) {
+	c.users = append(c.users, userID)
+}
+
+func TestCreateSubscription_ChecksCostAlerts(t *testing.T) {
+	s, mockRepo := newTestService()
+	checker := &recordingCostAlertChecker{}
+	WithCostAlertChecker(checker)(s)
+	ctx := testCtx()
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	_, err := s.CreateSubscription(ctx, CreateSubscriptionRequest{
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{fixedUUID()}, checker.users)
+}
+
+func TestUpdateSubscription_ChecksCostAlerts(t *testing.T) {
+	s, mockRepo := newTestService()
+	checker := &recordingCostAlertChecker{}
+	WithCostAlertChecker(checker)(s)
+	ctx := testCtx()
+	txCtx := (&fakeTx{}).expect(mockRepo, ctx)
+	userID := uuid.New()
+
+	current := &model.Subscription{ID: fixedUUID(), TenantID: testTenantID, Price: 599, UserID: userID}
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).Return(current, nil)
+	mockRepo.On("Update", txCtx, mock.Anything).Return(nil)
+
+	_, err := s.UpdateSubscription(ctx, UpdateSubscriptionRequest{
+		ID:          fixedUUID(),
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      userID,
+		StartDate:   fixedTime(),
+		Version:     1,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{userID}, checker.users)
+}
+
+func TestUpdateSubscription_FailedUpdateSkipsCostAlerts(t *testing.T) {
+	s, _ := newTestService()
+	checker := &recordingCostAlertChecker{}
+	WithCostAlertChecker(checker)(s)
+
+	_, err := s.UpdateSubscription(testCtx(), UpdateSubscriptionRequest{ID: fixedUUID(), Price: -1})
+
+	require.Error(t, err)
+	assert.Empty(t, checker.users)
+}
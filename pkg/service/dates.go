@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// parseRequestDates parses start_date and end_date with model.ParseDate
+// instead of encoding/json's RFC3339-only rule. Empty values stay zero so
+// validation can report them.
+func parseRequestDates(startDate string, endDate *string) (time.Time, *time.Time, error) {
+	var start time.Time
+	if startDate != "" {
+		t, err := model.ParseDateField("start_date", startDate)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		start = t
+	}
+
+	if endDate == nil || *endDate == "" {
+		return start, nil, nil
+	}
+	end, err := model.ParseDateField("end_date", *endDate)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return start, &end, nil
+}
+
+// decodeStrict keeps DisallowUnknownFields in effect inside UnmarshalJSON,
+// where the caller's decoder settings do not reach.
+func decodeStrict(data []byte, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
+
+// UnmarshalJSON accepts RFC3339, YYYY-MM-DD and MM-YYYY dates.
+func (r *CreateSubscriptionRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateSubscriptionRequest
+	aux := struct {
+		*plain
+		StartDate string  `json:"start_date"`
+		EndDate   *string `json:"end_date,omitempty"`
+	}{plain: (*plain)(r)}
+
+	if err := decodeStrict(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	r.StartDate, r.EndDate, err = parseRequestDates(aux.StartDate, aux.EndDate)
+	return err
+}
+
+// UnmarshalJSON accepts RFC3339, YYYY-MM-DD and MM-YYYY dates.
+func (r *UpdateSubscriptionRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateSubscriptionRequest
+	aux := struct {
+		*plain
+		StartDate string  `json:"start_date"`
+		EndDate   *string `json:"end_date,omitempty"`
+	}{plain: (*plain)(r)}
+
+	if err := decodeStrict(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	r.StartDate, r.EndDate, err = parseRequestDates(aux.StartDate, aux.EndDate)
+	return err
+}
+
+// UnmarshalJSON accepts RFC3339, YYYY-MM-DD and MM-YYYY dates.
+func (r *UpdateServicePriceRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateServicePriceRequest
+	aux := struct {
+		*plain
+		EffectiveDate *string `json:"effective_date,omitempty"`
+	}{plain: (*plain)(r)}
+
+	if err := decodeStrict(data, &aux); err != nil {
+		return err
+	}
+
+	r.EffectiveDate = nil
+	if aux.EffectiveDate == nil || *aux.EffectiveDate == "" {
+		return nil
+	}
+	t, err := model.ParseDateField("effective_date", *aux.EffectiveDate)
+	if err != nil {
+		return err
+	}
+	r.EffectiveDate = &t
+	return nil
+}
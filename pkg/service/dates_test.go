@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestCreateSubscriptionRequest_DateFormats(t *testing.T) {
+	july := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	august := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, body := range []string{
+		`{"service_name":"Netflix","start_date":"07-2025","end_date":"08-2025"}`,
+		`{"service_name":"Netflix","start_date":"2025-07-01","end_date":"2025-08-01"}`,
+		`{"service_name":"Netflix","start_date":"2025-07-01T00:00:00Z","end_date":"2025-08-01T00:00:00Z"}`,
+	} {
+		var req CreateSubscriptionRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req), body)
+
+		assert.Equal(t, "Netflix", req.ServiceName)
+		assert.True(t, july.Equal(req.StartDate), body)
+		require.NotNil(t, req.EndDate)
+		assert.True(t, august.Equal(*req.EndDate), body)
+	}
+}
+
+func TestUpdateSubscriptionRequest_NullEndDate(t *testing.T) {
+	var req UpdateSubscriptionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"price":599,"start_date":"07-2025","end_date":null}`), &req))
+
+	assert.Equal(t, 599, req.Price)
+	assert.Nil(t, req.EndDate)
+}
+
+func TestCreateSubscriptionRequest_InvalidDateNamesField(t *testing.T) {
+	var req CreateSubscriptionRequest
+	err := json.Unmarshal([]byte(`{"start_date":"2025-07-01","end_date":"next month"}`), &req)
+
+	var ferr *model.DateFieldError
+	require.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "end_date", ferr.Field)
+}
+
+func TestCreateSubscriptionRequest_StaysStrict(t *testing.T) {
+	var req CreateSubscriptionRequest
+	err := json.Unmarshal([]byte(`{"strat_date":"2025-07-01"}`), &req)
+
+	assert.EqualError(t, err, `json: unknown field "strat_date"`)
+}
+
+func TestUpdateServicePriceRequest_EffectiveDate(t *testing.T) {
+	var req UpdateServicePriceRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"percent_change":7.5,"effective_date":"09-2025"}`), &req))
+
+	require.NotNil(t, req.PercentChange)
+	assert.Equal(t, 7.5, *req.PercentChange)
+	require.NotNil(t, req.EffectiveDate)
+	assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), *req.EffectiveDate)
+
+	err := json.Unmarshal([]byte(`{"new_price":1199,"effective_date":"soon"}`), &req)
+	var ferr *model.DateFieldError
+	require.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "effective_date", ferr.Field)
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+// defaultAlertThresholdPct is used for a limit set without a threshold.
+const defaultAlertThresholdPct = 80
+
+type SpendingLimitService interface {
+	SetSpendingLimit(ctx context.Context, req SetSpendingLimitRequest) (limit *model.SpendingLimit, created bool, err error)
+	GetSpendingLimit(ctx context.Context, userID uuid.UUID) (*model.SpendingLimit, error)
+	DeleteSpendingLimit(ctx context.Context, userID uuid.UUID) error
+	GetSpendingLimitStatus(ctx context.Context, userID uuid.UUID) (*model.SpendingLimitStatus, error)
+	BreachChecker
+}
+
+// BreachChecker is told whenever a user's subscriptions have been written,
+// and records whether that took them over their spending limit. Like
+// CostAlertChecker it never fails the write.
+type BreachChecker interface {
+	CheckBreach(ctx context.Context, tenantID, userID uuid.UUID)
+}
+
+type noopBreachChecker struct{}
+
+func (noopBreachChecker) CheckBreach(context.Context, uuid.UUID, uuid.UUID) {}
+
+// WithBreachChecker has c checked after every subscription created,
+// updated or deleted. Without it spending limits are never found breached.
+func WithBreachChecker(c BreachChecker) ServiceOption {
+	return func(s *subscriptionService) {
+		s.breaches = c
+	}
+}
+
+type spendingLimitService struct {
+	limits    repository.SpendingLimitRepository
+	subs      repository.SubscriptionRepository
+	converter *currency.Converter
+	now       func() time.Time
+}
+
+// NewSpendingLimitService returns a service whose limits may be set in any
+// currency converter knows; without a converter only the default currency
+// is accepted.
+func NewSpendingLimitService(limits repository.SpendingLimitRepository, subs repository.SubscriptionRepository, converter *currency.Converter) SpendingLimitService {
+	return &spendingLimitService{limits: limits, subs: subs, converter: converter, now: time.Now}
+}
+
+type SetSpendingLimitRequest struct {
+	UserID      uuid.UUID `json:"-"`
+	LimitAmount int       `json:"limit_amount" example:"2000"`
+	// Currency defaults to the one prices are stored in.
+	Currency string `json:"currency,omitempty" example:"RUB"`
+	// AlertThresholdPct defaults to 80.
+	AlertThresholdPct int `json:"alert_threshold_pct,omitempty" example:"80"`
+}
+
+func (s *spendingLimitService) SetSpendingLimit(ctx context.Context, req SetSpendingLimitRequest) (*model.SpendingLimit, bool, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	limit := &model.SpendingLimit{
+		UserID:            req.UserID,
+		LimitAmount:       req.LimitAmount,
+		Currency:          currency.Normalize(req.Currency),
+		AlertThresholdPct: req.AlertThresholdPct,
+	}
+	if limit.Currency == "" {
+		limit.Currency = s.baseCurrency()
+	}
+	if limit.AlertThresholdPct == 0 {
+		limit.AlertThresholdPct = defaultAlertThresholdPct
+	}
+	if err := s.validateLimit(ctx, limit); err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.limits.Upsert(ctx, tenantID, limit)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to set spending limit: %w", err)
+	}
+	logger.FromContext(ctx).Info("spending limit set",
+		slog.String("user_id", limit.UserID.String()),
+		slog.Int("limit_amount", limit.LimitAmount),
+		slog.String("currency", limit.Currency),
+	)
+	// The user may be over the new limit already.
+	s.CheckBreach(ctx, tenantID, limit.UserID)
+
+	return limit, created, nil
+}
+
+func (s *spendingLimitService) GetSpendingLimit(ctx context.Context, userID uuid.UUID) (*model.SpendingLimit, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := s.limits.Get(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spending limit: %w", err)
+	}
+	return limit, nil
+}
+
+func (s *spendingLimitService) DeleteSpendingLimit(ctx context.Context, userID uuid.UUID) error {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.limits.Delete(ctx, tenantID, userID); err != nil {
+		return fmt.Errorf("failed to delete spending limit: %w", err)
+	}
+	logger.FromContext(ctx).Info("spending limit deleted", slog.String("user_id", userID.String()))
+	return nil
+}
+
+// GetSpendingLimitStatus adds up the prices of the user's subscriptions
+// active today and compares the sum with their limit. It only reads:
+// breaches are recorded by CheckBreach when the subscriptions are written.
+func (s *spendingLimitService) GetSpendingLimitStatus(ctx context.Context, userID uuid.UUID) (*model.SpendingLimitStatus, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := s.limits.Get(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spending limit: %w", err)
+	}
+	return s.status(ctx, tenantID, limit)
+}
+
+// CheckBreach records whether the user is over their limit now. The first
+// time they are, model.EventSpendingLimitBreached is published. A user
+// without a limit is left alone, and failures are only logged.
+func (s *spendingLimitService) CheckBreach(ctx context.Context, tenantID, userID uuid.UUID) {
+	err := s.checkBreach(ctx, tenantID, userID)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		logger.FromContext(ctx).Warn("failed to record spending limit breach",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (s *spendingLimitService) checkBreach(ctx context.Context, tenantID, userID uuid.UUID) error {
+	limit, err := s.limits.Get(ctx, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get spending limit: %w", err)
+	}
+	status, err := s.status(ctx, tenantID, limit)
+	if err != nil {
+		return err
+	}
+	return s.limits.SetBreached(ctx, tenantID, userID, status.OverLimit)
+}
+
+// status compares what the subscriptions of limit's user active today cost
+// with limit.
+func (s *spendingLimitService) status(ctx context.Context, tenantID uuid.UUID, limit *model.SpendingLimit) (*model.SpendingLimitStatus, error) {
+	today := truncateToDay(s.now())
+	total, err := s.subs.GetTotalCost(ctx, model.SubscriptionFilter{
+		UserID:   &limit.UserID,
+		TenantID: &tenantID,
+		FromDate: &today,
+		ToDate:   &today,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate current spend: %w", err)
+	}
+
+	current := float64(total)
+	if limit.Currency != s.baseCurrency() {
+		if s.converter == nil {
+			return nil, fmt.Errorf("failed to convert current spend: %w: %s", currency.ErrUnsupportedCurrency, limit.Currency)
+		}
+		if current, err = s.converter.Convert(ctx, total, limit.Currency); err != nil {
+			return nil, fmt.Errorf("failed to convert current spend: %w", err)
+		}
+	}
+
+	usedPct := math.Round(current/float64(limit.LimitAmount)*10000) / 100
+	return &model.SpendingLimitStatus{
+		Limit:        limit.LimitAmount,
+		Currency:     limit.Currency,
+		CurrentTotal: current,
+		UsedPct:      usedPct,
+		OverLimit:    current > float64(limit.LimitAmount),
+		Alert:        usedPct >= float64(limit.AlertThresholdPct),
+	}, nil
+}
+
+func (s *spendingLimitService) baseCurrency() string {
+	if s.converter != nil {
+		return s.converter.Base()
+	}
+	return defaultCurrency
+}
+
+// validateLimit checks a limit whose defaults are already filled in. A
+// currency other than the base one is accepted only if it can be
+// converted to now.
+func (s *spendingLimitService) validateLimit(ctx context.Context, limit *model.SpendingLimit) error {
+	verr := &model.ValidationError{}
+	if limit.LimitAmount <= 0 {
+		verr.Add("limit_amount", "must be greater than 0")
+	}
+	if limit.AlertThresholdPct < 1 || limit.AlertThresholdPct > 100 {
+		verr.Add("alert_threshold_pct", "must be between 1 and 100")
+	}
+
+	if limit.Currency != s.baseCurrency() {
+		supported := s.converter != nil
+		if supported {
+			_, err := s.converter.Convert(ctx, 0, limit.Currency)
+			if err != nil && !errors.Is(err, currency.ErrUnsupportedCurrency) {
+				return fmt.Errorf("failed to check currency: %w", err)
+			}
+			supported = err == nil
+		}
+		if !supported {
+			verr.Add("currency", "is not supported")
+		}
+	}
+
+	return verr.OrNil()
+}
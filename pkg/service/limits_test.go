@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockSpendingLimitRepository struct {
+	mock.Mock
+}
+
+func (m *MockSpendingLimitRepository) Upsert(ctx context.Context, tenantID uuid.UUID, limit *model.SpendingLimit) (bool, error) {
+	args := m.Called(ctx, tenantID, limit)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSpendingLimitRepository) Get(ctx context.Context, tenantID, userID uuid.UUID) (*model.SpendingLimit, error) {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Get(0).(*model.SpendingLimit), args.Error(1)
+}
+
+func (m *MockSpendingLimitRepository) Delete(ctx context.Context, tenantID, userID uuid.UUID) error {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Error(0)
+}
+
+func (m *MockSpendingLimitRepository) SetBreached(ctx context.Context, tenantID, userID uuid.UUID, breached bool) error {
+	args := m.Called(ctx, tenantID, userID, breached)
+	return args.Error(0)
+}
+
+func newTestSpendingLimitService() (*spendingLimitService, *MockSpendingLimitRepository, *mocks.SubscriptionRepository) {
+	limits, subs := &MockSpendingLimitRepository{}, &mocks.SubscriptionRepository{}
+	converter := currency.NewConverter("RUB", currency.NewStaticProvider("RUB", map[string]float64{"USD": 81.08}))
+	s := NewSpendingLimitService(limits, subs, converter).(*spendingLimitService)
+	s.now = func() time.Time { return time.Date(2025, 3, 15, 14, 30, 0, 0, time.UTC) }
+	return s, limits, subs
+}
+
+func TestSetSpendingLimit_FillsDefaults(t *testing.T) {
+	s, limits, _ := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	limits.On("Upsert", ctx, testTenantID, &model.SpendingLimit{
+		UserID:            userID,
+		LimitAmount:       2000,
+		Currency:          "RUB",
+		AlertThresholdPct: defaultAlertThresholdPct,
+	}).Return(true, nil)
+	limits.On("Get", ctx, testTenantID, userID).Return((*model.SpendingLimit)(nil), model.ErrNotFound)
+
+	limit, created, err := s.SetSpendingLimit(ctx, SetSpendingLimitRequest{UserID: userID, LimitAmount: 2000})
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "RUB", limit.Currency)
+	limits.AssertExpectations(t)
+}
+
+func TestSetSpendingLimit_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   SetSpendingLimitRequest
+		field string
+	}{
+		{"no amount", SetSpendingLimitRequest{}, "limit_amount"},
+		{"threshold over 100", SetSpendingLimitRequest{LimitAmount: 2000, AlertThresholdPct: 101}, "alert_threshold_pct"},
+		{"negative threshold", SetSpendingLimitRequest{LimitAmount: 2000, AlertThresholdPct: -5}, "alert_threshold_pct"},
+		{"unknown currency", SetSpendingLimitRequest{LimitAmount: 2000, Currency: "xyz"}, "currency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, limits, _ := newTestSpendingLimitService()
+
+			_, _, err := s.SetSpendingLimit(testCtx(), tt.req)
+
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Contains(t, verr.Fields, tt.field)
+			limits.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGetSpendingLimitStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		wantPct   float64
+		wantAlert bool
+		wantOver  bool
+	}{
+		{"under threshold", 1000, 50, false, false},
+		{"at threshold", 1600, 80, true, false},
+		{"at limit", 2000, 100, true, false},
+		{"over limit", 2500, 125, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, limits, subs := newTestSpendingLimitService()
+			ctx := testCtx()
+			userID := uuid.New()
+			today := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+
+			limits.On("Get", ctx, testTenantID, userID).
+				Return(&model.SpendingLimit{UserID: userID, LimitAmount: 2000, Currency: "RUB", AlertThresholdPct: 80}, nil)
+			subs.On("GetTotalCost", ctx, scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &today, ToDate: &today})).
+				Return(tt.total, nil)
+
+			status, err := s.GetSpendingLimitStatus(ctx, userID)
+
+			require.NoError(t, err)
+			assert.Equal(t, &model.SpendingLimitStatus{
+				Limit:        2000,
+				Currency:     "RUB",
+				CurrentTotal: float64(tt.total),
+				UsedPct:      tt.wantPct,
+				OverLimit:    tt.wantOver,
+				Alert:        tt.wantAlert,
+			}, status)
+			limits.AssertExpectations(t)
+			subs.AssertExpectations(t)
+			limits.AssertNotCalled(t, "SetBreached", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGetSpendingLimitStatus_ConvertsToLimitCurrency(t *testing.T) {
+	s, limits, subs := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	limits.On("Get", ctx, testTenantID, userID).
+		Return(&model.SpendingLimit{UserID: userID, LimitAmount: 200, Currency: "USD", AlertThresholdPct: 90}, nil)
+	subs.On("GetTotalCost", ctx, mock.Anything).Return(8108, nil)
+
+	status, err := s.GetSpendingLimitStatus(ctx, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, status.CurrentTotal)
+	assert.Equal(t, 50.0, status.UsedPct)
+	assert.Equal(t, "USD", status.Currency)
+}
+
+func TestCheckBreach_RecordsOverLimit(t *testing.T) {
+	s, limits, subs := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	limits.On("Get", ctx, testTenantID, userID).
+		Return(&model.SpendingLimit{UserID: userID, LimitAmount: 100, Currency: "RUB", AlertThresholdPct: 80}, nil)
+	subs.On("GetTotalCost", ctx, mock.Anything).Return(599, nil)
+	limits.On("SetBreached", ctx, testTenantID, userID, true).Return(nil)
+
+	s.CheckBreach(ctx, testTenantID, userID)
+
+	limits.AssertExpectations(t)
+}
+
+func TestCheckBreach_NoLimit(t *testing.T) {
+	s, limits, subs := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	limits.On("Get", ctx, testTenantID, userID).Return((*model.SpendingLimit)(nil), model.ErrNotFound)
+
+	s.CheckBreach(ctx, testTenantID, userID)
+
+	subs.AssertNotCalled(t, "GetTotalCost", mock.Anything, mock.Anything)
+	limits.AssertNotCalled(t, "SetBreached", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckBreach_FailureIsOnlyLogged(t *testing.T) {
+	s, limits, subs := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	limits.On("Get", ctx, testTenantID, userID).
+		Return(&model.SpendingLimit{UserID: userID, LimitAmount: 100, Currency: "RUB", AlertThresholdPct: 80}, nil)
+	subs.On("GetTotalCost", ctx, mock.Anything).Return(599, nil)
+	limits.On("SetBreached", ctx, testTenantID, userID, true).Return(errors.New("connection reset"))
+
+	assert.NotPanics(t, func() { s.CheckBreach(ctx, testTenantID, userID) })
+}
+
+func TestSetSpendingLimit_ChecksBreach(t *testing.T) {
+	s, limits, subs := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+	limit := &model.SpendingLimit{UserID: userID, LimitAmount: 500, Currency: "RUB", AlertThresholdPct: 80}
+
+	limits.On("Upsert", ctx, testTenantID, limit).Return(false, nil)
+	limits.On("Get", ctx, testTenantID, userID).Return(limit, nil)
+	subs.On("GetTotalCost", ctx, mock.Anything).Return(599, nil)
+	limits.On("SetBreached", ctx, testTenantID, userID, true).Return(nil)
+
+	_, _, err := s.SetSpendingLimit(ctx, SetSpendingLimitRequest{UserID: userID, LimitAmount: 500})
+
+	require.NoError(t, err)
+	limits.AssertExpectations(t)
+}
+
+// recordingBreachChecker remembers the users it was asked to check.
+type recordingBreachChecker struct {
+	users []uuid.UUID
+}
+
+func (c *recordingBreachChecker) CheckBreach(_ context.Context, _, userID uuid.UUID) {
+	c.users = append(c.users, userID)
+}
+
+func TestCreateSubscription_ChecksBreach(t *testing.T) {
+	s, mockRepo := newTestService()
+	checker := &recordingBreachChecker{}
+	WithBreachChecker(checker)(s)
+	ctx := testCtx()
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	_, err := s.CreateSubscription(ctx, validCreateRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{fixedUUID()}, checker.users)
+}
+
+func TestDeleteSubscription_ChecksOwnersBreach(t *testing.T) {
+	s, mockRepo := newTestService()
+	checker := &recordingBreachChecker{}
+	WithBreachChecker(checker)(s)
+	ctx := testCtx()
+	subID, owner := uuid.New(), uuid.New()
+
+	mockRepo.On("GetByID", ctx, testTenantID, subID).Return(&model.Subscription{ID: subID, UserID: owner}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, subID).Return(nil)
+
+	require.NoError(t, s.DeleteSubscription(ctx, subID))
+
+	assert.Equal(t, []uuid.UUID{owner}, checker.users)
+}
+
+func TestGetSpendingLimitStatus_NoLimit(t *testing.T) {
+	s, limits, subs := newTestSpendingLimitService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	limits.On("Get", ctx, testTenantID, userID).Return((*model.SpendingLimit)(nil), model.ErrNotFound)
+
+	_, err := s.GetSpendingLimitStatus(ctx, userID)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	subs.AssertNotCalled(t, "GetTotalCost", mock.Anything, mock.Anything)
+}
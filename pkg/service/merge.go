@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+// UserMergeService joins accounts of one person that were created twice
+// upstream.
+type UserMergeService interface {
+	MergeUsers(ctx context.Context, req MergeUsersRequest) (*model.MergeUsersResponse, error)
+}
+
+// MergeUsersRequest moves the subscriptions of SourceUserID to
+// TargetUserID; Actor and RequestID go into the audit trail.
+type MergeUsersRequest struct {
+	TargetUserID uuid.UUID
+	SourceUserID uuid.UUID
+	Deduplicate  bool
+	Actor        string
+	RequestID    string
+}
+
+type userMergeService struct {
+	repo repository.UserMergeRepository
+}
+
+func NewUserMergeService(repo repository.UserMergeRepository) UserMergeService {
+	return &userMergeService{repo: repo}
+}
+
+// MergeUsers moves everything of the source user to the target, see
+// repository.UserMergeRepository.MergeUsers, and files an audit entry under
+// each of them. A merge that finds nothing left to move is still recorded:
+// it was asked for.
+func (s *userMergeService) MergeUsers(ctx context.Context, req MergeUsersRequest) (*model.MergeUsersResponse, error) {
+	verr := &model.ValidationError{}
+	if req.SourceUserID == uuid.Nil {
+		verr.Add("source_user_id", "is required")
+	} else if req.SourceUserID == req.TargetUserID {
+		verr.Add("source_user_id", "must differ from the target user")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*model.AuditEntry{
+		{UserID: req.SourceUserID, Actor: req.Actor, Action: model.AuditActionMergeInto, RequestID: req.RequestID},
+		{UserID: req.TargetUserID, Actor: req.Actor, Action: model.AuditActionMergeFrom, RequestID: req.RequestID},
+	}
+	resp, err := s.repo.MergeUsers(ctx, tenantID, req.SourceUserID, req.TargetUserID, req.Deduplicate, entries...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge users: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("users merged",
+		slog.String("source_user_id", req.SourceUserID.String()),
+		slog.String("target_user_id", req.TargetUserID.String()),
+		slog.Bool("deduplicate", req.Deduplicate),
+		slog.Int64("moved", resp.Moved),
+		slog.Int64("skipped", resp.Skipped),
+	)
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockUserMergeRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserMergeRepository) MergeUsers(ctx context.Context, tenantID, sourceID, targetID uuid.UUID, deduplicate bool, entries ...*model.AuditEntry) (*model.MergeUsersResponse, error) {
+	args := m.Called(ctx, tenantID, sourceID, targetID, deduplicate, entries)
+	resp, _ := args.Get(0).(*model.MergeUsersResponse)
+	return resp, args.Error(1)
+}
+
+func newTestUserMergeService() (UserMergeService, *MockUserMergeRepository) {
+	repo := &MockUserMergeRepository{}
+	return NewUserMergeService(repo), repo
+}
+
+func TestMergeUsers_RecordsBothUsers(t *testing.T) {
+	s, repo := newTestUserMergeService()
+	ctx := testCtx()
+	sourceID, targetID := uuid.New(), fixedUUID()
+
+	repo.On("MergeUsers", ctx, testTenantID, sourceID, targetID, true, []*model.AuditEntry{
+		{UserID: sourceID, Actor: model.AuditActorUser, Action: model.AuditActionMergeInto, RequestID: "req-1"},
+		{UserID: targetID, Actor: model.AuditActorUser, Action: model.AuditActionMergeFrom, RequestID: "req-1"},
+	}).Return(&model.MergeUsersResponse{Moved: 3, Skipped: 1}, nil)
+
+	resp, err := s.MergeUsers(ctx, MergeUsersRequest{
+		TargetUserID: targetID,
+		SourceUserID: sourceID,
+		Deduplicate:  true,
+		Actor:        model.AuditActorUser,
+		RequestID:    "req-1",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.MergeUsersResponse{Moved: 3, Skipped: 1}, resp)
+	repo.AssertExpectations(t)
+}
+
+func TestMergeUsers_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		source uuid.UUID
+		want   string
+	}{
+		{"no source", uuid.Nil, "is required"},
+		{"same user", fixedUUID(), "must differ from the target user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestUserMergeService()
+
+			_, err := s.MergeUsers(testCtx(), MergeUsersRequest{TargetUserID: fixedUUID(), SourceUserID: tt.source})
+
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.want, verr.Fields["source_user_id"])
+			repo.AssertNotCalled(t, "MergeUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestMergeUsers_RepoError(t *testing.T) {
+	s, repo := newTestUserMergeService()
+	repo.On("MergeUsers", mock.Anything, testTenantID, mock.Anything, fixedUUID(), false, mock.Anything).Return(nil, errors.New("db error"))
+
+	resp, err := s.MergeUsers(testCtx(), MergeUsersRequest{TargetUserID: fixedUUID(), SourceUserID: uuid.New()})
+
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "failed to merge users")
+}
+
+func TestMergeUsers_NoTenant(t *testing.T) {
+	s, repo := newTestUserMergeService()
+
+	_, err := s.MergeUsers(context.Background(), MergeUsersRequest{TargetUserID: fixedUUID(), SourceUserID: uuid.New()})
+
+	assert.ErrorIs(t, err, ErrNoTenant)
+	repo.AssertNotCalled(t, "MergeUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
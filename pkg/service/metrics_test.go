@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/metrics"
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+func newTestServiceWithMetrics(t *testing.T) (*subscriptionService, *mocks.SubscriptionRepository, *prometheus.Registry) {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	m, err := metrics.NewPrometheus(reg)
+	require.NoError(t, err)
+	s, mockRepo := newTestService()
+	WithMetrics(m)(s)
+	return s, mockRepo, reg
+}
+
+// gathered is the value of every counter in reg and the number of samples
+// of every histogram, by metric name.
+func gathered(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	values := make(map[string]float64, len(families))
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		if h := m.GetHistogram(); h != nil {
+			values[f.GetName()] = float64(h.GetSampleCount())
+			continue
+		}
+		values[f.GetName()] = m.GetCounter().GetValue()
+	}
+	return values
+}
+
+func TestMetrics_CountSuccessfulOperations(t *testing.T) {
+	s, mockRepo, reg := newTestServiceWithMetrics(t)
+	ctx := testCtx()
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+	_, err := s.CreateSubscription(ctx, validCreateRequest())
+	require.NoError(t, err)
+
+	mockRepo.On("GetByID", ctx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID()}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, fixedUUID()).Return(nil)
+	require.NoError(t, s.DeleteSubscription(ctx, fixedUUID()))
+
+	mockRepo.On("SoftDeleteExpired", ctx, testTenantID, fixedUUID()).Return(2, nil)
+	_, err = s.CleanupExpiredSubscriptions(ctx, fixedUUID())
+	require.NoError(t, err)
+
+	txCtx := (&fakeTx{}).expect(mockRepo, ctx)
+	mockRepo.On("UpdateServicePrice", txCtx, mock.Anything).Return(&model.ServicePriceUpdateResult{Updated: 3}, nil)
+	_, err = s.BulkUpdatePrice(ctx, "netflix", 1199)
+	require.NoError(t, err)
+
+	mockRepo.On("List", ctx, mock.Anything).Return(&model.ListResult{}, nil)
+	_, err = s.ListSubscriptions(ctx, model.SubscriptionFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]float64{
+		"subscription_aggregator_subscriptions_created_total":         1,
+		"subscription_aggregator_subscriptions_deleted_total":         3,
+		"subscription_aggregator_subscriptions_updated_total":         3,
+		"subscription_aggregator_subscriptions_list_duration_seconds": 1,
+	}, gathered(t, reg))
+}
+
+func TestMetrics_FailuresAreNotCounted(t *testing.T) {
+	s, mockRepo, reg := newTestServiceWithMetrics(t)
+	ctx := testCtx()
+
+	mockRepo.On("GetByID", ctx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID()}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, fixedUUID()).Return(errors.New("db error"))
+	require.Error(t, s.DeleteSubscription(ctx, fixedUUID()))
+
+	mockRepo.On("List", ctx, mock.Anything).Return((*model.ListResult)(nil), errors.New("db error"))
+	_, err := s.ListSubscriptions(ctx, model.SubscriptionFilter{})
+	require.Error(t, err)
+
+	for name, value := range gathered(t, reg) {
+		assert.Zero(t, value, name)
+	}
+}
+
+func TestMetrics_NoopByDefault(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	mockRepo.On("GetByID", ctx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID()}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, fixedUUID()).Return(nil)
+
+	assert.Equal(t, metrics.Noop{}, s.metrics)
+	assert.NotPanics(t, func() { require.NoError(t, s.DeleteSubscription(ctx, fixedUUID())) })
+}
@@ -0,0 +1,10 @@
+package service
+
+import "strings"
+
+// NormaliseServiceName is the form service names are stored and filtered
+// in, so that "Yandex Plus", " Yandex Plus" and "yandex plus" are one
+// service in every listing and total.
+func NormaliseServiceName(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormaliseServiceName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Yandex Plus", "yandex plus"},
+		{" Yandex Plus", "yandex plus"},
+		{"yandex plus", "yandex plus"},
+		{"\tNETFLIX\n", "netflix"},
+		{"Кинопоиск HD", "кинопоиск hd"},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormaliseServiceName(tt.in))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/model"
+)
+
+type RecordPaymentRequest struct {
+	SubscriptionID uuid.UUID `json:"-"`
+	Amount         int       `json:"amount" example:"599"`
+	// Currency defaults to the one prices are stored in, which is also
+	// the only one accepted: totals add payments up without converting.
+	Currency string `json:"currency,omitempty" example:"RUB"`
+}
+
+func (s *subscriptionService) RecordPayment(ctx context.Context, req RecordPaymentRequest) (*model.Payment, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	base := s.baseCurrency()
+	payment := &model.Payment{
+		ID:             uuid.New(),
+		SubscriptionID: req.SubscriptionID,
+		Amount:         req.Amount,
+		Currency:       currency.Normalize(req.Currency),
+		Status:         model.PaymentPaid,
+	}
+	if payment.Currency == "" {
+		payment.Currency = base
+	}
+
+	verr := &model.ValidationError{}
+	if payment.Amount <= 0 {
+		verr.Add("amount", "must be positive")
+	}
+	if payment.Currency != base {
+		verr.Add("currency", "must be "+base)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RecordPayment(ctx, tenantID, payment); err != nil {
+		return nil, fmt.Errorf("failed to record payment: %w", err)
+	}
+	s.logger(ctx).Info("payment recorded",
+		slog.String("id", payment.ID.String()),
+		slog.String("subscription_id", payment.SubscriptionID.String()),
+		slog.Int("amount", payment.Amount),
+	)
+
+	return payment, nil
+}
+
+func (s *subscriptionService) ListPayments(ctx context.Context, subscriptionID uuid.UUID) ([]model.Payment, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.repo.ListPayments(ctx, tenantID, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	return payments, nil
+}
+
+// baseCurrency is the currency prices and payments are stored in.
+func (s *subscriptionService) baseCurrency() string {
+	if s.converter != nil {
+		return s.converter.Base()
+	}
+	return defaultCurrency
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestRecordPayment_DefaultsToBaseCurrency(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	subID := fixedUUID()
+
+	mockRepo.On("RecordPayment", ctx, testTenantID, mock.MatchedBy(func(p *model.Payment) bool {
+		return p.SubscriptionID == subID && p.Amount == 599 && p.Currency == "RUB" && p.Status == model.PaymentPaid
+	})).Return(nil)
+
+	payment, err := s.RecordPayment(ctx, RecordPaymentRequest{SubscriptionID: subID, Amount: 599})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, payment.ID)
+	assert.Equal(t, "RUB", payment.Currency)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRecordPayment_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   RecordPaymentRequest
+		field string
+	}{
+		{"zero amount", RecordPaymentRequest{Amount: 0}, "amount"},
+		{"negative amount", RecordPaymentRequest{Amount: -1}, "amount"},
+		{"foreign currency", RecordPaymentRequest{Amount: 599, Currency: "usd"}, "currency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			payment, err := s.RecordPayment(testCtx(), tt.req)
+
+			assert.Nil(t, payment)
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Contains(t, verr.Fields, tt.field)
+			mockRepo.AssertNotCalled(t, "RecordPayment", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRecordPayment_UnknownSubscription(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("RecordPayment", ctx, testTenantID, mock.Anything).Return(model.ErrNotFound)
+
+	_, err := s.RecordPayment(ctx, RecordPaymentRequest{SubscriptionID: fixedUUID(), Amount: 599, Currency: "rub"})
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestGetTotalCost_BillingHistory(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	filter := model.SubscriptionFilter{ServiceName: &[]string{"Netflix"}[0]}
+	mockRepo.On("GetPaidTotal", ctx, scoped(filter)).Return(1198, nil)
+
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter, Source: model.TotalFromBillingHistory})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.TotalCostResponse{Total: 1198, Source: model.TotalFromBillingHistory, Currency: "RUB"}, total)
+	mockRepo.AssertNotCalled(t, "GetProratedTotalCost", mock.Anything, mock.Anything)
+}
+
+func TestGetTotalCost_SourceValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   TotalCostRequest
+		field string
+	}{
+		{"unknown source", TotalCostRequest{Source: "invoices"}, "source"},
+		{"mode with billing history", TotalCostRequest{Source: model.TotalFromBillingHistory, Mode: model.TotalFlat}, "mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			total, err := s.GetTotalCost(testCtx(), tt.req)
+
+			assert.Nil(t, total)
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Contains(t, verr.Fields, tt.field)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// RankByCostPerDay is the only ranking RankSubscriptions knows so far.
+const RankByCostPerDay = "cost_per_day"
+
+// RankRequest asks for the subscriptions of UserID, or of the whole tenant
+// when it is nil, ranked by Sort in Order.
+type RankRequest struct {
+	UserID *uuid.UUID
+	// Sort defaults to RankByCostPerDay.
+	Sort string
+	// Order is asc, the default, for the best value first, or desc.
+	Order string
+}
+
+// RankSubscriptions ranks subscriptions by their cost per day, see
+// model.Subscription.CostPerDay. Ties go by service name. Subscriptions
+// whose cost per day is unknown come last in either order and get no rank.
+func (s *subscriptionService) RankSubscriptions(ctx context.Context, req RankRequest) ([]model.RankedSubscription, error) {
+	verr := &model.ValidationError{}
+	if req.Sort != "" && req.Sort != RankByCostPerDay {
+		verr.Add("sort", "must be "+RankByCostPerDay)
+	}
+	if req.Order != "" && req.Order != "asc" && req.Order != "desc" {
+		verr.Add("order", "must be asc or desc")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	result, err := s.ListSubscriptions(ctx, model.SubscriptionFilter{UserID: req.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	type costed struct {
+		sub  *model.Subscription
+		cost float64
+		ok   bool
+	}
+	items := make([]costed, len(result.Items))
+	for i, sub := range result.Items {
+		cost, ok := sub.CostPerDay()
+		items[i] = costed{sub: sub, cost: cost, ok: ok}
+	}
+	desc := req.Order == "desc"
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch {
+		case a.ok != b.ok:
+			return a.ok
+		case a.cost != b.cost:
+			return (a.cost < b.cost) != desc
+		}
+		return a.sub.ServiceName < b.sub.ServiceName
+	})
+
+	ranked := make([]model.RankedSubscription, len(items))
+	for i, item := range items {
+		ranked[i] = model.RankedSubscription{Subscription: *item.sub}
+		if item.ok {
+			ranked[i].CostPerDay = math.Round(item.cost*100) / 100
+			ranked[i].Rank = i + 1
+		}
+	}
+	return ranked, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// rankFixture has subscriptions of different lengths and cycles. Their
+// costs per day are music 10, cloud 10, video 20, gym 50; news is shorter
+// than a day, so its cost per day is unknown.
+func rankFixture() []*model.Subscription {
+	start := fixedTime()
+	at := func(days int) *time.Time {
+		t := start.AddDate(0, 0, days)
+		return &t
+	}
+	return []*model.Subscription{
+		{ServiceName: "news", Price: 100, StartDate: start, EndDate: &start, BillingCycle: model.CycleMonthly},
+		{ServiceName: "video", Price: 590, StartDate: start, EndDate: at(59), BillingCycle: model.CycleMonthly},
+		{ServiceName: "music", Price: 3650, StartDate: start, BillingCycle: model.CycleAnnual},
+		{ServiceName: "gym", Price: 500, StartDate: start, EndDate: at(10), BillingCycle: model.CycleAnnual},
+		{ServiceName: "cloud", Price: 70, StartDate: start, EndDate: at(14), BillingCycle: model.CycleWeekly},
+	}
+}
+
+func rankedNames(ranked []model.RankedSubscription) []string {
+	names := make([]string, len(ranked))
+	for i, r := range ranked {
+		names[i] = r.ServiceName
+	}
+	return names
+}
+
+func TestRankSubscriptions_Order(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"cloud", "music", "video", "gym", "news"}},
+		{"asc", []string{"cloud", "music", "video", "gym", "news"}},
+		{"desc", []string{"gym", "video", "cloud", "music", "news"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			userID := fixedUUID()
+			mockRepo.On("List", mock.Anything, scoped(model.SubscriptionFilter{UserID: &userID})).
+				Return(&model.ListResult{Items: rankFixture(), TotalCount: 5}, nil)
+
+			ranked, err := s.RankSubscriptions(testCtx(), RankRequest{UserID: &userID, Order: tt.order})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rankedNames(ranked))
+			for i, r := range ranked[:4] {
+				assert.Equal(t, i+1, r.Rank)
+			}
+			assert.Zero(t, ranked[4].Rank)
+			assert.Zero(t, ranked[4].CostPerDay)
+		})
+	}
+}
+
+func TestRankSubscriptions_RoundsCost(t *testing.T) {
+	s, mockRepo := newTestService()
+	end := fixedTime().AddDate(0, 0, 30)
+	mockRepo.On("List", mock.Anything, scoped(model.SubscriptionFilter{})).Return(&model.ListResult{Items: []*model.Subscription{
+		{ServiceName: "netflix", Price: 599, StartDate: fixedTime(), EndDate: &end, BillingCycle: model.CycleMonthly},
+	}}, nil)
+
+	ranked, err := s.RankSubscriptions(testCtx(), RankRequest{})
+
+	require.NoError(t, err)
+	require.Len(t, ranked, 1)
+	assert.Equal(t, 19.97, ranked[0].CostPerDay)
+	assert.Equal(t, 1, ranked[0].Rank)
+}
+
+func TestRankSubscriptions_InvalidRequest(t *testing.T) {
+	s, mockRepo := newTestService()
+
+	_, err := s.RankSubscriptions(testCtx(), RankRequest{Sort: "price", Order: "up"})
+
+	var verr *model.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Fields, 2)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestRankSubscriptions_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	mockRepo.On("List", mock.Anything, mock.Anything).Return((*model.ListResult)(nil), errors.New("db error"))
+
+	ranked, err := s.RankSubscriptions(testCtx(), RankRequest{})
+
+	assert.Error(t, err)
+	assert.Nil(t, ranked)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+// maxRemindDaysBefore keeps reminders within a year of the end date.
+const maxRemindDaysBefore = 365
+
+type ReminderService interface {
+	CreateReminder(ctx context.Context, req CreateReminderRequest) (*model.Reminder, error)
+	ListReminders(ctx context.Context, subscriptionID uuid.UUID) ([]model.Reminder, error)
+	UpdateReminder(ctx context.Context, req UpdateReminderRequest) (*model.Reminder, error)
+	DeleteReminder(ctx context.Context, subscriptionID, id uuid.UUID) error
+}
+
+type reminderService struct {
+	repo repository.ReminderRepository
+}
+
+func NewReminderService(repo repository.ReminderRepository) ReminderService {
+	return &reminderService{repo: repo}
+}
+
+type CreateReminderRequest struct {
+	SubscriptionID   uuid.UUID `json:"-"`
+	RemindDaysBefore int       `json:"remind_days_before" example:"3"`
+}
+
+func (s *reminderService) CreateReminder(ctx context.Context, req CreateReminderRequest) (*model.Reminder, error) {
+	if err := validateRemindDaysBefore(req.RemindDaysBefore); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder := &model.Reminder{
+		ID:               uuid.New(),
+		SubscriptionID:   req.SubscriptionID,
+		RemindDaysBefore: req.RemindDaysBefore,
+	}
+
+	if err := s.repo.Create(ctx, tenantID, reminder); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	logger.FromContext(ctx).Info("reminder created",
+		slog.String("id", reminder.ID.String()),
+		slog.String("subscription_id", reminder.SubscriptionID.String()),
+		slog.Int("remind_days_before", reminder.RemindDaysBefore),
+	)
+
+	return reminder, nil
+}
+
+func (s *reminderService) ListReminders(ctx context.Context, subscriptionID uuid.UUID) ([]model.Reminder, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reminders, err := s.repo.ListBySubscription(ctx, tenantID, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+type UpdateReminderRequest struct {
+	ID               uuid.UUID `json:"-"`
+	SubscriptionID   uuid.UUID `json:"-"`
+	RemindDaysBefore int       `json:"remind_days_before" example:"7"`
+}
+
+func (s *reminderService) UpdateReminder(ctx context.Context, req UpdateReminderRequest) (*model.Reminder, error) {
+	if err := validateRemindDaysBefore(req.RemindDaysBefore); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder := &model.Reminder{
+		ID:               req.ID,
+		SubscriptionID:   req.SubscriptionID,
+		RemindDaysBefore: req.RemindDaysBefore,
+	}
+
+	if err := s.repo.Update(ctx, tenantID, reminder); err != nil {
+		return nil, fmt.Errorf("failed to update reminder: %w", err)
+	}
+	logger.FromContext(ctx).Info("reminder updated", slog.String("id", reminder.ID.String()), slog.Int("remind_days_before", reminder.RemindDaysBefore))
+
+	return reminder, nil
+}
+
+func (s *reminderService) DeleteReminder(ctx context.Context, subscriptionID, id uuid.UUID) error {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, tenantID, subscriptionID, id); err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	logger.FromContext(ctx).Info("reminder deleted", slog.String("id", id.String()))
+	return nil
+}
+
+func validateRemindDaysBefore(days int) error {
+	verr := &model.ValidationError{}
+	if days < 0 || days > maxRemindDaysBefore {
+		verr.Add("remind_days_before", "must be between 0 and 365")
+	}
+	return verr.OrNil()
+}
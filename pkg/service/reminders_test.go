@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockReminderRepository struct {
+	mock.Mock
+}
+
+func (m *MockReminderRepository) Create(ctx context.Context, tenantID uuid.UUID, reminder *model.Reminder) error {
+	args := m.Called(ctx, tenantID, reminder)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ListBySubscription(ctx context.Context, tenantID, subscriptionID uuid.UUID) ([]model.Reminder, error) {
+	args := m.Called(ctx, tenantID, subscriptionID)
+	return args.Get(0).([]model.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) Update(ctx context.Context, tenantID uuid.UUID, reminder *model.Reminder) error {
+	args := m.Called(ctx, tenantID, reminder)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) Delete(ctx context.Context, tenantID, subscriptionID, id uuid.UUID) error {
+	args := m.Called(ctx, tenantID, subscriptionID, id)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ListPending(ctx context.Context, day time.Time) ([]model.PendingReminder, error) {
+	args := m.Called(ctx, day)
+	return args.Get(0).([]model.PendingReminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) MarkReminded(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func newTestReminderService() (ReminderService, *MockReminderRepository) {
+	mockRepo := &MockReminderRepository{}
+	return NewReminderService(mockRepo), mockRepo
+}
+
+func TestCreateReminder_Success(t *testing.T) {
+	s, mockRepo := newTestReminderService()
+	ctx := testCtx()
+
+	mockRepo.On("Create", ctx, testTenantID, mock.MatchedBy(func(r *model.Reminder) bool {
+		return r.SubscriptionID == fixedUUID() && r.RemindDaysBefore == 3 && r.ID != uuid.Nil
+	})).Return(nil)
+
+	reminder, err := s.CreateReminder(ctx, CreateReminderRequest{SubscriptionID: fixedUUID(), RemindDaysBefore: 3})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, reminder.RemindDaysBefore)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateReminder_Validation(t *testing.T) {
+	for _, days := range []int{-1, 366} {
+		s, mockRepo := newTestReminderService()
+
+		_, err := s.CreateReminder(testCtx(), CreateReminderRequest{SubscriptionID: fixedUUID(), RemindDaysBefore: days})
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	}
+}
+
+func TestUpdateReminder_NotFound(t *testing.T) {
+	s, mockRepo := newTestReminderService()
+	ctx := testCtx()
+
+	mockRepo.On("Update", ctx, testTenantID, mock.Anything).Return(model.ErrNotFound)
+
+	_, err := s.UpdateReminder(ctx, UpdateReminderRequest{ID: uuid.New(), SubscriptionID: fixedUUID(), RemindDaysBefore: 7})
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.Contains(t, err.Error(), "failed to update reminder")
+}
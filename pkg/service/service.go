@@ -2,57 +2,536 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/metrics"
 	"SubscriptionAggregator/pkg/model"
 	"SubscriptionAggregator/pkg/repository"
 )
 
 type SubscriptionService interface {
 	CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*model.Subscription, error)
+	FindOrCreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (sub *model.Subscription, created bool, err error)
+	CreateSubscriptionWithShares(ctx context.Context, req CreateAndShareRequest) (*model.SharedSubscription, error)
+	BulkCreateSubscriptions(ctx context.Context, reqs []CreateSubscriptionRequest) (*BulkCreateResult, error)
 	GetSubscription(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	BatchGetSubscriptions(ctx context.Context, ids []uuid.UUID) (*model.BatchGetResult, error)
+	SimulateCost(ctx context.Context, req SimulateCostRequest) (*model.CostSimulation, error)
+	DetectPriceAnomaly(ctx context.Context, id uuid.UUID) (*model.PriceAnomalyReport, error)
+	RecordPayment(ctx context.Context, req RecordPaymentRequest) (*model.Payment, error)
+	ListPayments(ctx context.Context, subscriptionID uuid.UUID) ([]model.Payment, error)
 	UpdateSubscription(ctx context.Context, req UpdateSubscriptionRequest) (*model.Subscription, error)
+	UpsertSubscription(ctx context.Context, req UpdateSubscriptionRequest) (sub *model.Subscription, created bool, err error)
+	UpdatePrice(ctx context.Context, id uuid.UUID, newPrice int) (*model.Subscription, error)
+	BulkUpdatePrice(ctx context.Context, serviceName string, newPrice int) (int, error)
+	UpdateServicePrice(ctx context.Context, req UpdateServicePriceRequest) (*model.ServicePriceUpdateResult, error)
 	DeleteSubscription(ctx context.Context, id uuid.UUID) error
-	ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error)
-	GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error)
+	ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error)
+	GetTotalCost(ctx context.Context, req TotalCostRequest) (*model.TotalCostResponse, error)
+	GetTeamTotalCost(ctx context.Context, userIDs []uuid.UUID, filter model.SubscriptionFilter) (map[uuid.UUID]int, error)
+	GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error)
+	ComparePeriods(ctx context.Context, req ComparePeriodsRequest) (*model.PeriodComparison, error)
+	ListServices(ctx context.Context, userID *uuid.UUID) ([]*model.ServiceSummary, error)
+	ShareSubscription(ctx context.Context, req ShareSubscriptionRequest) (*model.ShareEntry, error)
+	UnshareSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) error
+	GetSharedUsers(ctx context.Context, subscriptionID uuid.UUID) ([]model.ShareEntry, error)
+	PinSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) (*model.Pin, error)
+	UnpinSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) error
+	ListExpiredSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]model.ExpiredSubscription, error)
+	CleanupExpiredSubscriptions(ctx context.Context, userID uuid.UUID) (int, error)
+	SubscribeToChanges(ctx context.Context) (<-chan model.SubscriptionEvent, error)
+	GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error)
+	GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error)
+	ListExpiringSoonByService(ctx context.Context, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error)
+	ListUpcomingRenewals(ctx context.Context, userID *uuid.UUID, days int) ([]*model.Subscription, error)
+	RankSubscriptions(ctx context.Context, req RankRequest) ([]model.RankedSubscription, error)
+	GetUserSummary(ctx context.Context, userID uuid.UUID) (*model.UserSummary, error)
+	GetTopServices(ctx context.Context, userID uuid.UUID, filter model.SubscriptionFilter, limit int) (*model.TopServices, error)
+	GetForecast(ctx context.Context, userID uuid.UUID, months int) (*model.Forecast, error)
+	ProjectCosts(ctx context.Context, userID uuid.UUID, months int) ([]model.ProjectedCost, error)
+	GetCreationRate(ctx context.Context, from, to time.Time) (*model.CreationRate, error)
+	GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error)
+}
+
+// maxExpiringDays bounds the look-ahead of ListExpiringSoonByService and
+// ListUpcomingRenewals.
+const maxExpiringDays = 365
+
+// maxTeamSize bounds how many users GetTeamTotalCost adds up at once.
+const maxTeamSize = 100
+
+// maxUserCostLimit bounds a page of GetTotalCostByUser.
+const maxUserCostLimit = 500
+
+// maxTopServicesLimit bounds how many services GetTopServices ranks.
+const maxTopServicesLimit = 50
+
+// maxForecastMonths bounds how far ahead GetForecast and ProjectCosts
+// look.
+const maxForecastMonths = 36
+
+// defaultCurrency is what prices are stored in when no converter is set.
+const defaultCurrency = "RUB"
+
+// ErrChangesUnavailable is returned by SubscribeToChanges when the service
+// was built without a ChangeNotifier.
+var ErrChangesUnavailable = errors.New("subscription change stream is not configured")
+
+// ChangeNotifier delivers subscription events until ctx is done, then
+// closes the channel. It is implemented by events.PGNotifyListener.
+type ChangeNotifier interface {
+	Subscribe(ctx context.Context) <-chan model.SubscriptionEvent
 }
 
 type subscriptionService struct {
-	repo repository.SubscriptionRepository
+	repo      repository.SubscriptionRepository
+	log       *slog.Logger
+	now       func() time.Time
+	notifier  ChangeNotifier
+	converter *currency.Converter
+	maxPrice  int
+	// maxTotalRangeYears bounds the date range GetTotalCost accepts.
+	maxTotalRangeYears int
+	// anomalyThreshold is the |z-score| above which DetectPriceAnomaly
+	// reports an anomaly.
+	anomalyThreshold float64
+	// defaultDurationDays ends new subscriptions given without an end date
+	// that many days after they start; zero leaves them open-ended.
+	defaultDurationDays int
+	// catalog resolves CreateSubscriptionRequest.CatalogServiceID.
+	catalog repository.CatalogRepository
+	// userLimits makes creates respect the users' subscription limits;
+	// defaultMaxSubscriptions is the limit of a user without one of their
+	// own, zero meaning unlimited.
+	userLimits              bool
+	defaultMaxSubscriptions int
+	// loggerFactory, when set, gives the logger of a call instead of log.
+	loggerFactory LoggerFactory
+	// costAlerts is told about every user whose subscriptions were created
+	// or updated.
+	costAlerts CostAlertChecker
+	// breaches is told about every user whose subscriptions were written.
+	breaches BreachChecker
+	metrics  metrics.Metrics
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository) SubscriptionService {
-	return &subscriptionService{repo: repo}
+type ServiceOption func(*subscriptionService)
+
+// LoggerFactory returns the logger for work done under ctx, such as
+// logger.FromContext, which carries the request's ID.
+type LoggerFactory func(ctx context.Context) *slog.Logger
+
+// WithLoggerFactory makes the service log through f rather than the logger
+// it was created with.
+func WithLoggerFactory(f LoggerFactory) ServiceOption {
+	return func(s *subscriptionService) {
+		s.loggerFactory = f
+	}
+}
+
+// logger is the logger for a call made with ctx.
+func (s *subscriptionService) logger(ctx context.Context) *slog.Logger {
+	if s.loggerFactory != nil {
+		return s.loggerFactory(ctx)
+	}
+	return s.log
+}
+
+// WithDefaultDuration makes subscriptions created without an end date end
+// days after their start date. Zero, the default, keeps them open-ended.
+// Updates are not affected: a PUT without end_date still clears it unless
+// it creates the subscription.
+func WithDefaultDuration(days int) ServiceOption {
+	return func(s *subscriptionService) {
+		s.defaultDurationDays = days
+	}
+}
+
+// defaultEndDate is end, or the end date WithDefaultDuration gives a
+// subscription starting at start when end is nil.
+func (s *subscriptionService) defaultEndDate(start time.Time, end *time.Time) *time.Time {
+	if end != nil || s.defaultDurationDays == 0 {
+		return end
+	}
+	d := start.AddDate(0, 0, s.defaultDurationDays)
+	return &d
+}
+
+// WithUserLimits refuses to create a subscription for a user who already
+// has as many as their limit in user_limits allows, or defaultMax if they
+// have none. A zero limit, like a zero defaultMax, means unlimited.
+func WithUserLimits(defaultMax int) ServiceOption {
+	return func(s *subscriptionService) {
+		s.userLimits = true
+		s.defaultMaxSubscriptions = defaultMax
+	}
+}
+
+// WithChangeNotifier enables SubscribeToChanges.
+func WithChangeNotifier(n ChangeNotifier) ServiceOption {
+	return func(s *subscriptionService) {
+		s.notifier = n
+	}
+}
+
+// WithMetrics counts the subscriptions created, updated and deleted and
+// times their listing in m.
+func WithMetrics(m metrics.Metrics) ServiceOption {
+	return func(s *subscriptionService) {
+		s.metrics = m
+	}
+}
+
+// repeat calls f n times, to count each of n subscriptions written at once.
+func repeat(n int, f func()) {
+	for range n {
+		f()
+	}
+}
+
+// WithConverter enables totals in currencies other than the base one.
+func WithConverter(c *currency.Converter) ServiceOption {
+	return func(s *subscriptionService) {
+		s.converter = c
+	}
+}
+
+func NewSubscriptionService(repo repository.SubscriptionRepository, log *slog.Logger, opts ...ServiceOption) SubscriptionService {
+	s := &subscriptionService{
+		repo:               repo,
+		log:                log,
+		now:                time.Now,
+		maxPrice:           DefaultMaxPrice,
+		maxTotalRangeYears: DefaultMaxTotalRangeYears,
+		anomalyThreshold:   DefaultAnomalyThreshold,
+		costAlerts:         noopCostAlertChecker{},
+		breaches:           noopBreachChecker{},
+		metrics:            metrics.Noop{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 type CreateSubscriptionRequest struct {
+	// ID lets a client choose the subscription's ID; one is generated when
+	// it is missing. An ID that is already taken is a conflict.
+	ID          *uuid.UUID `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
 	ServiceName string     `json:"service_name"`
 	Price       int        `json:"price"`
 	UserID      uuid.UUID  `json:"user_id"`
 	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
+	// EndDate, when missing, leaves the subscription open-ended unless the
+	// server is configured with a default duration.
+	EndDate *time.Time `json:"end_date,omitempty"`
+	// BillingCycle defaults to monthly when empty.
+	BillingCycle model.BillingCycle `json:"billing_cycle,omitempty" example:"monthly"`
+	// Metadata is any JSON value; null or a missing field stores none.
+	Metadata json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
+	// CatalogServiceID creates the subscription from a catalog entry:
+	// service_name and price default to the entry's name and default
+	// price when they are missing.
+	CatalogServiceID *uuid.UUID `json:"catalog_service_id,omitempty" example:"2c7e4a1b-8d3f-4e6a-9b5c-0d1e2f3a4b5c"`
+	// AllowDuplicate skips the overlap check, e.g. for two family members
+	// on separate plans. It comes from the query string, never the body.
+	AllowDuplicate bool `json:"-"`
 }
 
+// CreateSubscription refuses a subscription that overlaps a live one of
+// the same user and service with a *model.DuplicateError, since both would
+// be counted in every total, unless req.AllowDuplicate is set.
 func (s *subscriptionService) CreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*model.Subscription, error) {
-	sub := &model.Subscription{
-		ID:          uuid.New(),
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
+	sub, err := s.newSubscription(ctx, req)
+	if err != nil {
+		return nil, err
 	}
+	if err := s.insertSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
 
-	if err := s.repo.Create(ctx, sub); err != nil {
-		return nil, fmt.Errorf("failed to create subscription: %w", err)
+// insertSubscription stores sub, which newSubscription returned, if its
+// owner may have another subscription.
+func (s *subscriptionService) insertSubscription(ctx context.Context, sub *model.Subscription) error {
+	txCtx, commit, rollback, err := s.userLimitTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
 	}
+	defer rollback()
 
+	if err := s.checkUserLimit(txCtx, sub); err != nil {
+		return err
+	}
+	if err := s.repo.Create(txCtx, sub); err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+	if err := commit(); err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription created", slog.String("id", sub.ID.String()), slog.String("user_id", sub.UserID.String()))
+	s.subscriptionsCreated(ctx, sub)
+	return nil
+}
+
+// newSubscription returns the subscription req asks for, prepared and
+// guarded by prepareSubscription and guardSubscription. Every path that
+// creates subscriptions starts here, so that they all store the same thing
+// for the same request.
+func (s *subscriptionService) newSubscription(ctx context.Context, req CreateSubscriptionRequest) (*model.Subscription, error) {
+	sub, err := s.prepareSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.guardSubscription(ctx, sub, req.AllowDuplicate); err != nil {
+		return nil, err
+	}
 	return sub, nil
 }
 
+// prepareSubscription fills req in from the catalog, applies the default
+// billing cycle and end date, validates it and builds the subscription for
+// the caller's tenant.
+func (s *subscriptionService) prepareSubscription(ctx context.Context, req CreateSubscriptionRequest) (*model.Subscription, error) {
+	if err := s.fillFromCatalog(ctx, &req); err != nil {
+		return nil, err
+	}
+	if req.BillingCycle == "" {
+		req.BillingCycle = model.DefaultBillingCycle
+	}
+	req.EndDate = s.defaultEndDate(req.StartDate, req.EndDate)
+	req.ServiceName = NormaliseServiceName(req.ServiceName)
+	if err := s.validateSubscription(req.ServiceName, req.Price, req.UserID, req.StartDate, req.EndDate, req.BillingCycle, req.Metadata); err != nil {
+		return nil, err
+	}
+	if err := validateClientID(req.ID); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Subscription{
+		ID:               newSubscriptionID(req.ID),
+		TenantID:         tenantID,
+		ServiceName:      req.ServiceName,
+		Price:            req.Price,
+		UserID:           req.UserID,
+		StartDate:        req.StartDate,
+		EndDate:          req.EndDate,
+		BillingCycle:     req.BillingCycle,
+		Metadata:         metadataOrNil(req.Metadata),
+		CatalogServiceID: req.CatalogServiceID,
+	}, nil
+}
+
+// guardSubscription refuses a new sub that overlaps a live subscription of
+// the same user and service, unless allowDuplicate is set.
+func (s *subscriptionService) guardSubscription(ctx context.Context, sub *model.Subscription, allowDuplicate bool) error {
+	if allowDuplicate {
+		return nil
+	}
+	return s.checkDuplicate(ctx, sub)
+}
+
+// subscriptionsCreated counts newly stored subscriptions and tells
+// spendChanged about each of their owners once, whichever path stored them.
+func (s *subscriptionService) subscriptionsCreated(ctx context.Context, subs ...*model.Subscription) {
+	repeat(len(subs), s.metrics.IncrSubscriptionsCreated)
+	checked := make(map[uuid.UUID]bool, len(subs))
+	for _, sub := range subs {
+		if !checked[sub.UserID] {
+			checked[sub.UserID] = true
+			s.spendChanged(ctx, sub.TenantID, sub.UserID)
+		}
+	}
+}
+
+// spendChanged tells the cost alerts and the spending limit of a user that
+// their subscriptions were written.
+func (s *subscriptionService) spendChanged(ctx context.Context, tenantID, userID uuid.UUID) {
+	s.costAlerts.Check(ctx, tenantID, userID)
+	s.breaches.CheckBreach(ctx, tenantID, userID)
+}
+
+// checkDuplicate returns a *model.DuplicateError if sub would overlap a live
+// subscription of the same user and service.
+func (s *subscriptionService) checkDuplicate(ctx context.Context, sub *model.Subscription) error {
+	existing, err := s.repo.ExistsActiveOverlap(ctx, sub.TenantID, sub.UserID, sub.ServiceName, sub.StartDate, sub.EndDate)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicates: %w", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("failed to create subscription: %w", &model.DuplicateError{ExistingID: *existing})
+	}
+	return nil
+}
+
+// checkUserLimit returns model.ErrLimitExceeded if the owner of sub may not
+// have another subscription. Call it in the transaction that creates sub,
+// see subscriptionsLeft.
+func (s *subscriptionService) checkUserLimit(ctx context.Context, sub *model.Subscription) error {
+	left, err := s.subscriptionsLeft(ctx, sub.TenantID, sub.UserID)
+	if err != nil {
+		return err
+	}
+	if left == 0 {
+		return fmt.Errorf("failed to create subscription: %w", model.ErrLimitExceeded)
+	}
+	return nil
+}
+
+// unlimited is what subscriptionsLeft returns for a user without a limit.
+const unlimited = -1
+
+// userLimitTx starts the transaction a create runs in so that
+// subscriptionsLeft's lock covers the insert. Without user limits nothing
+// is counted or locked, and ctx is returned with a commit and rollback
+// that do nothing.
+func (s *subscriptionService) userLimitTx(ctx context.Context) (context.Context, repository.CommitFunc, repository.RollbackFunc, error) {
+	if !s.userLimits {
+		noop := func() error { return nil }
+		return ctx, noop, noop, nil
+	}
+	return s.repo.Transactional(ctx)
+}
+
+// subscriptionsLeft returns how many more subscriptions the user may
+// have, or unlimited. Ended subscriptions count until they are deleted.
+// It locks the user's subscriptions first, so the answer holds until the
+// transaction in ctx ends: a concurrent create for the same user waits
+// for it instead of counting the same rows.
+func (s *subscriptionService) subscriptionsLeft(ctx context.Context, tenantID, userID uuid.UUID) (int, error) {
+	if !s.userLimits {
+		return unlimited, nil
+	}
+
+	if err := s.repo.LockUserSubscriptions(ctx, tenantID, userID); err != nil {
+		return 0, fmt.Errorf("failed to lock subscriptions: %w", err)
+	}
+	limit, err := s.repo.GetUserLimit(ctx, tenantID, userID)
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		limit = s.defaultMaxSubscriptions
+	case err != nil:
+		return 0, fmt.Errorf("failed to get subscription limit: %w", err)
+	}
+	if limit == 0 {
+		return unlimited, nil
+	}
+
+	count, err := s.repo.Count(ctx, model.SubscriptionFilter{UserID: &userID, TenantID: &tenantID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+	return max(limit-count, 0), nil
+}
+
+// FindOrCreateSubscription returns the user's existing subscription to
+// req.ServiceName, creating it only when there is none. More than one match
+// is reported as model.ErrConflict since the caller's intent is ambiguous.
+func (s *subscriptionService) FindOrCreateSubscription(ctx context.Context, req CreateSubscriptionRequest) (*model.Subscription, bool, error) {
+	sub, err := s.prepareSubscription(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	filter, err := scopeFilter(ctx, model.SubscriptionFilter{
+		UserID:      &sub.UserID,
+		ServiceName: &sub.ServiceName,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	result, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find subscription: %w", err)
+	}
+
+	switch len(result.Items) {
+	case 0:
+		if err := s.guardSubscription(ctx, sub, req.AllowDuplicate); err != nil {
+			return nil, false, err
+		}
+		if err := s.insertSubscription(ctx, sub); err != nil {
+			return nil, false, err
+		}
+		return sub, true, nil
+	case 1:
+		return result.Items[0], false, nil
+	default:
+		return nil, false, fmt.Errorf("failed to find subscription: %d subscriptions to %q: %w",
+			len(result.Items), sub.ServiceName, model.ErrConflict)
+	}
+}
+
+type CreateAndShareRequest struct {
+	Subscription CreateSubscriptionRequest `json:"subscription"`
+	// ShareWith lists the users to share with; permission defaults to read.
+	ShareWith []ShareSubscriptionRequest `json:"share_with"`
+}
+
+// CreateSubscriptionWithShares creates a subscription and shares it in one
+// transaction, so a failed share leaves no subscription behind.
+func (s *subscriptionService) CreateSubscriptionWithShares(ctx context.Context, req CreateAndShareRequest) (*model.SharedSubscription, error) {
+	if err := validateShareTargets(req.Subscription.UserID, req.ShareWith); err != nil {
+		return nil, err
+	}
+	sub, err := s.newSubscription(ctx, req.Subscription)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]model.ShareEntry, len(req.ShareWith))
+	for i, target := range req.ShareWith {
+		shares[i] = model.ShareEntry{
+			SubscriptionID: sub.ID,
+			UserID:         target.UserID,
+			Permission:     target.Permission,
+		}
+		if shares[i].Permission == "" {
+			shares[i].Permission = model.PermissionRead
+		}
+	}
+
+	txCtx, commit, rollback, err := s.repo.Transactional(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	defer rollback()
+
+	if err := s.checkUserLimit(txCtx, sub); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(txCtx, sub); err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	if err := s.repo.CreateShares(txCtx, sub.TenantID, shares); err != nil {
+		return nil, fmt.Errorf("failed to share subscription: %w", err)
+	}
+	if err := commit(); err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription created and shared",
+		slog.String("id", sub.ID.String()),
+		slog.String("user_id", sub.UserID.String()),
+		slog.Int("shares", len(shares)),
+	)
+	s.subscriptionsCreated(ctx, sub)
+
+	return &model.SharedSubscription{Subscription: sub, Shares: shares}, nil
+}
+
 type UpdateSubscriptionRequest struct {
 	ID          uuid.UUID  `json:"-"`
 	ServiceName string     `json:"service_name"`
@@ -60,52 +539,815 @@ type UpdateSubscriptionRequest struct {
 	UserID      uuid.UUID  `json:"user_id"`
 	StartDate   time.Time  `json:"start_date"`
 	EndDate     *time.Time `json:"end_date,omitempty"`
+	// BillingCycle defaults to monthly when empty.
+	BillingCycle model.BillingCycle `json:"billing_cycle,omitempty" example:"monthly"`
+	// Metadata is any JSON value; null or a missing field stores none.
+	Metadata json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
+	// Version is the version of the subscription the update was made
+	// from. It is required unless UpsertSubscription creates the
+	// subscription; an older one fails with a *model.VersionConflictError.
+	Version int `json:"version,omitempty" example:"3"`
+	// AllowDuplicate skips the overlap check when UpsertSubscription
+	// creates the subscription, see CreateSubscriptionRequest.
+	AllowDuplicate bool `json:"-"`
+}
+
+// UpdatePriceRequest is the body of PATCH /subscriptions/{id}/price.
+type UpdatePriceRequest struct {
+	Price int `json:"price" example:"799"`
+}
+
+// BulkUpdatePriceRequest is the body of PATCH /admin/subscriptions/bulk-price.
+type BulkUpdatePriceRequest struct {
+	NewPrice int `json:"new_price" example:"1199"`
+}
+
+// UpdateServicePriceRequest is the body of POST
+// /admin/services/{name}/price. Exactly one of NewPrice and PercentChange
+// must be given.
+type UpdateServicePriceRequest struct {
+	ServiceName string `json:"-"`
+	// TenantID restricts the change to one tenant; nil changes every
+	// tenant's subscriptions.
+	TenantID      *uuid.UUID `json:"-"`
+	NewPrice      *int       `json:"new_price,omitempty" example:"1199"`
+	PercentChange *float64   `json:"percent_change,omitempty" example:"10"`
+	// ActiveOnly leaves alone the subscriptions not active today.
+	ActiveOnly bool `json:"active_only,omitempty" example:"true"`
+	// EffectiveDate is when the new price applies from in the price
+	// history; it defaults to now and may not be in the future.
+	EffectiveDate *time.Time `json:"effective_date,omitempty" example:"2025-09-01T00:00:00Z"`
 }
 
 func (s *subscriptionService) UpdateSubscription(ctx context.Context, req UpdateSubscriptionRequest) (*model.Subscription, error) {
+	sub, _, err := s.saveSubscription(ctx, req, false)
+	return sub, err
+}
+
+// UpsertSubscription is UpdateSubscription that creates the subscription
+// under req.ID when the tenant has none with that ID, so that a sync client
+// can replay the same PUT. created reports which of the two happened. An ID
+// taken by another tenant or by a deleted subscription is a conflict.
+func (s *subscriptionService) UpsertSubscription(ctx context.Context, req UpdateSubscriptionRequest) (*model.Subscription, bool, error) {
+	return s.saveSubscription(ctx, req, true)
+}
+
+func (s *subscriptionService) saveSubscription(ctx context.Context, req UpdateSubscriptionRequest, upsert bool) (*model.Subscription, bool, error) {
+	if req.BillingCycle == "" {
+		req.BillingCycle = model.DefaultBillingCycle
+	}
+	req.ServiceName = NormaliseServiceName(req.ServiceName)
+	if err := s.validateSubscription(req.ServiceName, req.Price, req.UserID, req.StartDate, req.EndDate, req.BillingCycle, req.Metadata); err != nil {
+		return nil, false, err
+	}
+	if upsert {
+		if err := validateClientID(&req.ID); err != nil {
+			return nil, false, err
+		}
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
 	sub := &model.Subscription{
-		ID:          req.ID,
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
+		ID:           req.ID,
+		TenantID:     tenantID,
+		ServiceName:  req.ServiceName,
+		Price:        req.Price,
+		UserID:       req.UserID,
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		BillingCycle: req.BillingCycle,
+		Metadata:     metadataOrNil(req.Metadata),
 	}
 
-	if err := s.repo.Update(ctx, sub); err != nil {
-		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	// Locking the row first makes a concurrent update of the same
+	// subscription wait for this one to commit instead of overwriting it
+	// unseen. Two upserts that both create the row race on the primary key
+	// instead, and the loser gets model.ErrConflict.
+	txCtx, commit, rollback, err := s.repo.Transactional(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update subscription: %w", err)
 	}
+	defer rollback()
 
-	return sub, nil
+	created := false
+	current, err := s.repo.LockSubscription(txCtx, tenantID, sub.ID)
+	switch {
+	case upsert && errors.Is(err, model.ErrNotFound):
+		sub, err = s.newSubscription(txCtx, CreateSubscriptionRequest{
+			ID:             &req.ID,
+			ServiceName:    req.ServiceName,
+			Price:          req.Price,
+			UserID:         req.UserID,
+			StartDate:      req.StartDate,
+			EndDate:        req.EndDate,
+			BillingCycle:   req.BillingCycle,
+			Metadata:       req.Metadata,
+			AllowDuplicate: req.AllowDuplicate,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if err := s.checkUserLimit(txCtx, sub); err != nil {
+			return nil, false, err
+		}
+		if err := s.repo.Create(txCtx, sub); err != nil {
+			return nil, false, fmt.Errorf("failed to create subscription: %w", err)
+		}
+		created = true
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to update subscription: %w", err)
+	default:
+		if req.Version < 1 {
+			verr := &model.ValidationError{}
+			verr.Add("version", "must be the version the update was made from")
+			return nil, false, verr
+		}
+		sub.Version = req.Version
+		if err := s.update(txCtx, current, sub); err != nil {
+			return nil, false, fmt.Errorf("failed to update subscription: %w", err)
+		}
+	}
+	if err := commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	if created {
+		s.logger(ctx).Info("subscription created", slog.String("id", sub.ID.String()), slog.String("user_id", sub.UserID.String()))
+		s.subscriptionsCreated(ctx, sub)
+	} else {
+		s.logger(ctx).Info("subscription updated", slog.String("id", sub.ID.String()))
+		s.metrics.IncrSubscriptionsUpdated()
+		s.spendChanged(ctx, tenantID, sub.UserID)
+	}
+	return sub, created, nil
+}
+
+// UpdatePrice changes the price of a subscription and keeps every other
+// field as stored. Like UpdateSubscription it locks the row first, so an
+// edit made meanwhile is kept rather than overwritten with a stale copy.
+func (s *subscriptionService) UpdatePrice(ctx context.Context, id uuid.UUID, newPrice int) (*model.Subscription, error) {
+	verr := &model.ValidationError{}
+	s.checkPrice(verr, newPrice)
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txCtx, commit, rollback, err := s.repo.Transactional(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update price: %w", err)
+	}
+	defer rollback()
+
+	current, err := s.repo.LockSubscription(txCtx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update price: %w", err)
+	}
+	if current.Price == newPrice {
+		return current, nil
+	}
+
+	sub := *current
+	sub.Price = newPrice
+	if err := s.update(txCtx, current, &sub); err != nil {
+		return nil, fmt.Errorf("failed to update price: %w", err)
+	}
+	if err := commit(); err != nil {
+		return nil, fmt.Errorf("failed to update price: %w", err)
+	}
+
+	s.logger(ctx).Info("subscription price updated",
+		slog.String("id", id.String()),
+		slog.Int("old_price", current.Price),
+		slog.Int("new_price", newPrice),
+	)
+	s.metrics.IncrSubscriptionsUpdated()
+	s.spendChanged(ctx, tenantID, sub.UserID)
+	return &sub, nil
+}
+
+// BulkUpdatePrice sets the price of every subscription of the tenant to
+// serviceName, as when a provider raises its prices, and returns how many
+// subscriptions changed. It is UpdateServicePrice limited to the caller's
+// tenant.
+func (s *subscriptionService) BulkUpdatePrice(ctx context.Context, serviceName string, newPrice int) (int, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := s.UpdateServicePrice(ctx, UpdateServicePriceRequest{
+		ServiceName: serviceName,
+		TenantID:    &tenantID,
+		NewPrice:    &newPrice,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(result.Updated), nil
+}
+
+// UpdateServicePrice changes the price of every subscription to a service
+// in one transaction, as when a provider raises its prices, and returns
+// how many changed and their total before and after. Each of them
+// publishes a price_changed event, and the spend of each owner is checked
+// afterwards. Percentage changes are rounded per subscription and kept
+// below the maximum price.
+func (s *subscriptionService) UpdateServicePrice(ctx context.Context, req UpdateServicePriceRequest) (*model.ServicePriceUpdateResult, error) {
+	req.ServiceName = NormaliseServiceName(req.ServiceName)
+	if err := s.validateServicePrice(req); err != nil {
+		return nil, err
+	}
+
+	txCtx, commit, rollback, err := s.repo.Transactional(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service price: %w", err)
+	}
+	defer rollback()
+
+	result, err := s.repo.UpdateServicePrice(txCtx, model.ServicePriceUpdate{
+		ServiceName:   req.ServiceName,
+		TenantID:      req.TenantID,
+		NewPrice:      req.NewPrice,
+		PercentChange: req.PercentChange,
+		MaxPrice:      s.maxPrice,
+		ActiveOnly:    req.ActiveOnly,
+		EffectiveDate: req.EffectiveDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service price: %w", err)
+	}
+	if err := commit(); err != nil {
+		return nil, fmt.Errorf("failed to update service price: %w", err)
+	}
+	for _, owner := range result.Owners {
+		s.spendChanged(ctx, owner.TenantID, owner.UserID)
+	}
+
+	s.logger(ctx).Info("service price updated",
+		slog.String("service_name", req.ServiceName),
+		slog.Int64("updated", result.Updated),
+		slog.Int64("total_before", result.TotalBefore),
+		slog.Int64("total_after", result.TotalAfter),
+	)
+	repeat(int(result.Updated), s.metrics.IncrSubscriptionsUpdated)
+	return result, nil
+}
+
+func (s *subscriptionService) validateServicePrice(req UpdateServicePriceRequest) error {
+	verr := &model.ValidationError{}
+	if req.ServiceName == "" {
+		verr.Add("service_name", "must not be empty")
+	}
+	switch {
+	case req.NewPrice != nil && req.PercentChange != nil:
+		verr.Add("percent_change", "must not be given with new_price")
+	case req.NewPrice != nil:
+		s.checkPrice(verr, *req.NewPrice)
+	case req.PercentChange != nil:
+		if *req.PercentChange <= -100 {
+			verr.Add("percent_change", "must be greater than -100")
+		}
+	default:
+		verr.Add("new_price", "either new_price or percent_change is required")
+	}
+	if req.EffectiveDate != nil && req.EffectiveDate.After(s.now()) {
+		verr.Add("effective_date", "must not be in the future")
+	}
+	return verr.OrNil()
+}
+
+// update overwrites current, the locked row, with sub and announces a
+// change of price on top of the updated event every write publishes.
+func (s *subscriptionService) update(ctx context.Context, current, sub *model.Subscription) error {
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return err
+	}
+	if current.Price == sub.Price {
+		return nil
+	}
+	return s.repo.NotifyPriceChanged(ctx, sub.TenantID, sub.ID, current.Price, sub.Price)
 }
 
 func (s *subscriptionService) GetSubscription(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
-	sub, err := s.repo.GetByID(ctx, id)
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetByID(ctx, tenantID, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get subscription: %w", err)
 	}
 	return sub, nil
 }
 
+// DeleteSubscription looks the subscription up first to learn its owner,
+// whose spending limit may no longer be breached without it.
 func (s *subscriptionService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
 		return fmt.Errorf("failed to delete subscription: %w", err)
 	}
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription deleted", slog.String("id", id.String()))
+	s.metrics.IncrSubscriptionsDeleted()
+	s.breaches.CheckBreach(ctx, tenantID, sub.UserID)
 	return nil
 }
 
-func (s *subscriptionService) ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
-	subs, err := s.repo.List(ctx, filter)
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) (*model.ListResult, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := s.repo.List(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
 	}
-	return subs, nil
+	s.metrics.ObserveListDuration(time.Since(start))
+	return result, nil
 }
 
-func (s *subscriptionService) GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
-	total, err := s.repo.GetTotalCost(ctx, filter)
+// TotalCostRequest asks for the total of subscriptions matching Filter.
+// Mode defaults to model.TotalProrated. A Currency other than the base one
+// adds a converted total.
+type TotalCostRequest struct {
+	Filter model.SubscriptionFilter
+	Mode   model.TotalMode
+	// Source defaults to prices.
+	Source   model.TotalSource
+	Currency string
+}
+
+func (s *subscriptionService) GetTotalCost(ctx context.Context, req TotalCostRequest) (*model.TotalCostResponse, error) {
+	if req.Source == "" {
+		req.Source = model.TotalFromPrices
+	}
+	if req.Mode == "" && req.Source == model.TotalFromPrices {
+		req.Mode = model.TotalProrated
+	}
+	if err := s.validateTotalRequest(req); err != nil {
+		return nil, err
+	}
+	filter, err := scopeFilter(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	req.Filter = filter
+
+	var total int
+	switch {
+	case req.Source == model.TotalFromBillingHistory:
+		total, err = s.repo.GetPaidTotal(ctx, req.Filter)
+	case req.Mode == model.TotalFlat:
+		total, err = s.repo.GetTotalCost(ctx, req.Filter)
+	default:
+		total, err = s.repo.GetProratedTotalCost(ctx, req.Filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate total cost: %w", err)
+	}
+
+	base := s.baseCurrency()
+	resp := &model.TotalCostResponse{Total: total, Mode: req.Mode, Currency: base}
+	if req.Source != model.TotalFromPrices {
+		resp.Source = req.Source
+	}
+
+	target := currency.Normalize(req.Currency)
+	if target == "" || target == base {
+		return resp, nil
+	}
+	if s.converter == nil {
+		return nil, fmt.Errorf("failed to convert total cost: %w: %s", currency.ErrUnsupportedCurrency, target)
+	}
+
+	converted, err := s.converter.Convert(ctx, total, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert total cost: %w", err)
+	}
+	resp.ConvertedTotal = &converted
+	resp.TargetCurrency = target
+
+	return resp, nil
+}
+
+// GetTeamTotalCost returns the prorated total of each of userIDs, merged
+// with filter's own users, within filter's window. Every user is in the
+// result, those without spend at 0.
+func (s *subscriptionService) GetTeamTotalCost(ctx context.Context, userIDs []uuid.UUID, filter model.SubscriptionFilter) (map[uuid.UUID]int, error) {
+	filter.UserIDs = append(filter.UserIDs, userIDs...)
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	users := filter.AllUserIDs()
+	verr := &model.ValidationError{}
+	switch {
+	case len(users) == 0:
+		verr.Add("user_id", "is required")
+	case len(users) > maxTeamSize:
+		verr.Add("user_id", "must list at most 100 users")
+	}
+	if filter.FromDate != nil && filter.ToDate != nil {
+		s.checkRangeCap(verr, *filter.FromDate, *filter.ToDate)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repo.GetProratedCostPerUser(ctx, filter)
 	if err != nil {
-		return 0, fmt.Errorf("failed to calculate total cost: %w", err)
+		return nil, fmt.Errorf("failed to calculate team total cost: %w", err)
+	}
+
+	result := make(map[uuid.UUID]int, len(users))
+	for _, id := range users {
+		result[id] = totals[id]
+	}
+	return result, nil
+}
+
+// GetMonthlyCost returns the prorated cost of every calendar month from
+// filter.FromDate to filter.ToDate, zero months included. Without ToDate
+// the series ends with the current month.
+func (s *subscriptionService) GetMonthlyCost(ctx context.Context, filter model.SubscriptionFilter) ([]model.MonthlyCost, error) {
+	if filter.ToDate == nil {
+		now := s.now()
+		filter.ToDate = &now
+	}
+	if err := s.validateMonthlyFilter(filter); err != nil {
+		return nil, err
+	}
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := s.repo.GetMonthlyCost(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate monthly cost: %w", err)
+	}
+	return months, nil
+}
+
+func (s *subscriptionService) ListServices(ctx context.Context, userID *uuid.UUID) ([]*model.ServiceSummary, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := s.repo.ListServices(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return services, nil
+}
+
+type ShareSubscriptionRequest struct {
+	SubscriptionID uuid.UUID             `json:"-"`
+	UserID         uuid.UUID             `json:"user_id"`
+	Permission     model.SharePermission `json:"permission"`
+}
+
+func (s *subscriptionService) ShareSubscription(ctx context.Context, req ShareSubscriptionRequest) (*model.ShareEntry, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	share := &model.ShareEntry{
+		SubscriptionID: req.SubscriptionID,
+		UserID:         req.UserID,
+		Permission:     req.Permission,
+	}
+
+	if err := s.repo.ShareSubscription(ctx, tenantID, share); err != nil {
+		return nil, fmt.Errorf("failed to share subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription shared",
+		slog.String("id", share.SubscriptionID.String()),
+		slog.String("user_id", share.UserID.String()),
+		slog.String("permission", string(share.Permission)),
+	)
+
+	return share, nil
+}
+
+func (s *subscriptionService) UnshareSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) error {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UnshareSubscription(ctx, tenantID, subscriptionID, userID); err != nil {
+		return fmt.Errorf("failed to unshare subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription unshared", slog.String("id", subscriptionID.String()), slog.String("user_id", userID.String()))
+	return nil
+}
+
+func (s *subscriptionService) GetSharedUsers(ctx context.Context, subscriptionID uuid.UUID) ([]model.ShareEntry, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := s.repo.GetSharedUsers(ctx, tenantID, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared users: %w", err)
+	}
+	return shares, nil
+}
+
+// PinSubscription marks the subscription as a favourite of userID; pinning
+// it twice is not an error.
+func (s *subscriptionService) PinSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) (*model.Pin, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pin := &model.Pin{SubscriptionID: subscriptionID, UserID: userID}
+	if err := s.repo.PinSubscription(ctx, tenantID, pin); err != nil {
+		return nil, fmt.Errorf("failed to pin subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription pinned", slog.String("id", subscriptionID.String()), slog.String("user_id", userID.String()))
+
+	return pin, nil
+}
+
+func (s *subscriptionService) UnpinSubscription(ctx context.Context, subscriptionID, userID uuid.UUID) error {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UnpinSubscription(ctx, tenantID, subscriptionID, userID); err != nil {
+		return fmt.Errorf("failed to unpin subscription: %w", err)
+	}
+	s.logger(ctx).Info("subscription unpinned", slog.String("id", subscriptionID.String()), slog.String("user_id", userID.String()))
+	return nil
+}
+
+// ListExpiredSubscriptions returns subscriptions whose end_date has passed,
+// each with the whole days elapsed since end_date.
+func (s *subscriptionService) ListExpiredSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]model.ExpiredSubscription, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := s.repo.ListExpired(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired subscriptions: %w", err)
+	}
+
+	today := truncateToDay(s.now())
+	expired := make([]model.ExpiredSubscription, len(subs))
+	for i, sub := range subs {
+		expired[i] = model.ExpiredSubscription{Subscription: *sub}
+		if sub.EndDate != nil {
+			expired[i].ExpiredForDays = int(today.Sub(truncateToDay(*sub.EndDate)).Hours() / 24)
+		}
+	}
+
+	return expired, nil
+}
+
+func (s *subscriptionService) CleanupExpiredSubscriptions(ctx context.Context, userID uuid.UUID) (int, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.repo.SoftDeleteExpired(ctx, tenantID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean up expired subscriptions: %w", err)
+	}
+	s.logger(ctx).Info("expired subscriptions cleaned up", slog.String("user_id", userID.String()), slog.Int("deleted", deleted))
+	repeat(deleted, s.metrics.IncrSubscriptionsDeleted)
+	return deleted, nil
+}
+
+// SubscribeToChanges streams the changes of the caller's tenant; events of
+// other tenants are dropped before they reach the channel.
+func (s *subscriptionService) SubscribeToChanges(ctx context.Context) (<-chan model.SubscriptionEvent, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.notifier == nil {
+		return nil, ErrChangesUnavailable
+	}
+
+	all := s.notifier.Subscribe(ctx)
+	out := make(chan model.SubscriptionEvent)
+	go func() {
+		defer close(out)
+		for event := range all {
+			if event.TenantID != tenantID {
+				continue
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// GetCostByCycle breaks spending down by billing cycle and adds what each
+// group costs per month.
+func (s *subscriptionService) GetCostByCycle(ctx context.Context, filter model.SubscriptionFilter) ([]model.BillingCycleSummary, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.repo.GetCostByCycle(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost by billing cycle: %w", err)
+	}
+
+	for i := range summaries {
+		summaries[i].MonthlyEquivalent = summaries[i].BillingCycle.MonthlyEquivalent(summaries[i].Total)
+	}
+
+	return summaries, nil
+}
+
+// GetPriceStats summarises the prices of the subscriptions matching filter.
+func (s *subscriptionService) GetPriceStats(ctx context.Context, filter model.SubscriptionFilter) (*model.PriceStats, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.GetPriceStats(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price stats: %w", err)
+	}
+	return stats, nil
+}
+
+// ListExpiringSoonByService reports, per service, how many subscriptions end
+// within days (1-365) days. No matches is an empty list, not an error.
+func (s *subscriptionService) ListExpiringSoonByService(ctx context.Context, userID *uuid.UUID, days int) ([]model.ExpiringServiceSummary, error) {
+	if days < 1 || days > maxExpiringDays {
+		verr := &model.ValidationError{}
+		verr.Add("days", "must be between 1 and 365")
+		return nil, verr
+	}
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.repo.ListExpiringSoonByService(ctx, tenantID, userID, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring subscriptions: %w", err)
+	}
+	if summaries == nil {
+		summaries = []model.ExpiringServiceSummary{}
+	}
+
+	return summaries, nil
+}
+
+// ListUpcomingRenewals returns the subscriptions renewing within days days
+// from today, both ends included, with NextRenewalDate set and the soonest
+// renewal first. Renewal dates follow model.Subscription.NextRenewal.
+func (s *subscriptionService) ListUpcomingRenewals(ctx context.Context, userID *uuid.UUID, days int) ([]*model.Subscription, error) {
+	if days < 1 || days > maxExpiringDays {
+		verr := &model.ValidationError{}
+		verr.Add("days", "must be between 1 and 365")
+		return nil, verr
+	}
+
+	today := truncateToDay(s.now())
+	until := today.AddDate(0, 0, days)
+	filter, err := scopeFilter(ctx, model.SubscriptionFilter{UserID: userID, FromDate: &today, ToDate: &until})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	upcoming := []*model.Subscription{}
+	for _, sub := range result.Items {
+		next, ok := sub.NextRenewal(today)
+		if !ok || next.After(until) {
+			continue
+		}
+		sub.NextRenewalDate = &next
+		upcoming = append(upcoming, sub)
+	}
+	sort.Slice(upcoming, func(i, j int) bool {
+		a, b := upcoming[i], upcoming[j]
+		if !a.NextRenewalDate.Equal(*b.NextRenewalDate) {
+			return a.NextRenewalDate.Before(*b.NextRenewalDate)
+		}
+		return a.ServiceName < b.ServiceName
+	})
+
+	return upcoming, nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetCreationRate counts the subscriptions created in [from, to) and
+// averages them per day.
+func (s *subscriptionService) GetCreationRate(ctx context.Context, from, to time.Time) (*model.CreationRate, error) {
+	if !to.After(from) {
+		verr := &model.ValidationError{}
+		verr.Add("to", "must be after from")
+		return nil, verr
+	}
+
+	count, err := s.repo.CountCreatedBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count created subscriptions: %w", err)
+	}
+
+	days := to.Sub(from).Hours() / 24
+	return &model.CreationRate{
+		From:   from,
+		To:     to,
+		Count:  count,
+		PerDay: math.Round(float64(count)/days*100) / 100,
+	}, nil
+}
+
+// GetTotalCostByUser ranks the users of every tenant by the prorated cost
+// of their subscriptions, highest first, one page of limit (1-500) users at
+// a time. It serves admins and needs no tenant; only the service and date
+// filters apply. A page past the last user is empty, not an error.
+func (s *subscriptionService) GetTotalCostByUser(ctx context.Context, filter model.SubscriptionFilter, limit, offset int) (*model.UserCostResult, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	verr := &model.ValidationError{}
+	if limit < 1 || limit > maxUserCostLimit {
+		verr.Add("limit", "must be between 1 and 500")
+	}
+	if offset < 0 {
+		verr.Add("offset", "must not be negative")
+	}
+	if filter.FromDate != nil && filter.ToDate != nil {
+		s.checkRangeCap(verr, *filter.FromDate, *filter.ToDate)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.GetTotalCostByUser(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate total cost by user: %w", err)
+	}
+	if result.Items == nil {
+		result.Items = []model.UserCost{}
 	}
-	return total, nil
+	return result, nil
 }
@@ -1,68 +1,66 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/currency"
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/mocks"
 	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
 )
 
-type MockSubscriptionRepository struct {
-	mock.Mock
+func newTestService() (*subscriptionService, *mocks.SubscriptionRepository) {
+	mockRepo := &mocks.SubscriptionRepository{}
+	return NewSubscriptionService(mockRepo, slog.New(slog.NewTextHandler(io.Discard, nil))).(*subscriptionService), mockRepo
 }
 
-func (m *MockSubscriptionRepository) Create(ctx context.Context, sub *model.Subscription) error {
-	args := m.Called(ctx, sub)
-	return args.Error(0)
+// expectNoOverlap lets CreateSubscription past its duplicate check.
+func expectNoOverlap(mockRepo *mocks.SubscriptionRepository, ctx context.Context) {
+	mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return((*uuid.UUID)(nil), nil)
 }
 
-func (m *MockSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*model.Subscription), args.Error(1)
-}
-
-func (m *MockSubscriptionRepository) Update(ctx context.Context, sub *model.Subscription) error {
-	args := m.Called(ctx, sub)
-	return args.Error(0)
-}
-
-func (m *MockSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+func fixedTime() time.Time {
+	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 }
 
-func (m *MockSubscriptionRepository) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
-	args := m.Called(ctx, filter)
-	return args.Get(0).([]*model.Subscription), args.Error(1)
+func fixedUUID() uuid.UUID {
+	return uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 }
 
-func (m *MockSubscriptionRepository) GetTotalCost(ctx context.Context, filter model.SubscriptionFilter) (int, error) {
-	args := m.Called(ctx, filter)
-	return args.Int(0), args.Error(1)
-}
+// testTenantID is the tenant testCtx authenticates every call for.
+var testTenantID = uuid.MustParse("0b7e1c52-8d4f-4a3e-9c61-2f5a7d8e9b10")
 
-func newTestService() (*subscriptionService, *MockSubscriptionRepository) {
-	mockRepo := &MockSubscriptionRepository{}
-	return NewSubscriptionService(mockRepo).(*subscriptionService), mockRepo
-}
+var tenantCtx = ctxkey.With(context.Background(), ctxkey.KeyTenantID, testTenantID)
 
-func fixedTime() time.Time {
-	return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+func testCtx() context.Context {
+	return tenantCtx
 }
 
-func fixedUUID() uuid.UUID {
-	return uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+// scoped is filter as the repository receives it: confined to testTenantID.
+func scoped(filter model.SubscriptionFilter) model.SubscriptionFilter {
+	filter.TenantID = &testTenantID
+	return filter
 }
 
 func TestCreateSubscription_Success(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	req := CreateSubscriptionRequest{
 		ServiceName: "Yandex Plus",
@@ -71,8 +69,10 @@ func TestCreateSubscription_Success(t *testing.T) {
 		StartDate:   fixedTime(),
 	}
 
+	expectNoOverlap(mockRepo, ctx)
 	mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *model.Subscription) bool {
-		return sub.ServiceName == req.ServiceName &&
+		return sub.TenantID == testTenantID &&
+			sub.ServiceName == "yandex plus" &&
 			sub.Price == req.Price &&
 			sub.UserID == req.UserID &&
 			sub.StartDate.Equal(req.StartDate)
@@ -81,16 +81,188 @@ func TestCreateSubscription_Success(t *testing.T) {
 	sub, err := s.CreateSubscription(ctx, req)
 
 	assert.NoError(t, err)
-	assert.Equal(t, req.ServiceName, sub.ServiceName)
+	assert.Equal(t, "yandex plus", sub.ServiceName)
 	assert.Equal(t, req.Price, sub.Price)
 	assert.Equal(t, req.UserID, sub.UserID)
 	assert.Equal(t, req.StartDate, sub.StartDate)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateSubscription_UserLimit(t *testing.T) {
+	notSet := fmt.Errorf("repository.postgresql.GetUserLimit: %w", model.ErrNotFound)
+
+	tests := []struct {
+		name       string
+		defaultMax int
+		userLimit  int
+		limitErr   error
+		count      int
+		wantErr    error
+	}{
+		{"under own limit", 0, 3, nil, 2, nil},
+		{"at own limit", 0, 3, nil, 3, model.ErrLimitExceeded},
+		{"at default limit", 2, 0, notSet, 2, model.ErrLimitExceeded},
+		{"own unlimited overrides default", 1, 0, nil, -1, nil},
+		{"unlimited by default", 0, 0, notSet, -1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			WithUserLimits(tt.defaultMax)(s)
+			ctx := testCtx()
+			userID := fixedUUID()
+			tx := &fakeTx{}
+			txCtx := tx.expect(mockRepo, ctx)
+
+			expectNoOverlap(mockRepo, ctx)
+			// The count and the insert run under the user's lock.
+			mockRepo.On("LockUserSubscriptions", txCtx, testTenantID, userID).Return(nil)
+			mockRepo.On("GetUserLimit", txCtx, testTenantID, userID).Return(tt.userLimit, tt.limitErr)
+			if tt.count >= 0 {
+				mockRepo.On("Count", txCtx, model.SubscriptionFilter{UserID: &userID, TenantID: &testTenantID}).Return(tt.count, nil)
+			}
+			if tt.wantErr == nil {
+				mockRepo.On("Create", txCtx, mock.AnythingOfType("*model.Subscription")).Return(nil)
+			}
+
+			_, err := s.CreateSubscription(ctx, CreateSubscriptionRequest{
+				ServiceName: "Yandex Plus",
+				Price:       599,
+				UserID:      userID,
+				StartDate:   fixedTime(),
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				assert.True(t, tx.rolledBack)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, tx.committed)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateSubscription_UserLimitLockError(t *testing.T) {
+	s, mockRepo := newTestService()
+	WithUserLimits(5)(s)
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("LockUserSubscriptions", txCtx, testTenantID, fixedUUID()).Return(errors.New("lock timeout"))
+
+	_, err := s.CreateSubscription(ctx, CreateSubscriptionRequest{
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+	})
+
+	assert.EqualError(t, err, "failed to lock subscriptions: lock timeout")
+	assert.True(t, tx.rolledBack)
+	mockRepo.AssertNotCalled(t, "Count", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_DefaultDuration(t *testing.T) {
+	explicitEnd := fixedTime().AddDate(0, 6, 0)
+	defaultEnd := fixedTime().AddDate(0, 0, 30)
+
+	tests := []struct {
+		name string
+		days int
+		end  *time.Time
+		want *time.Time
+	}{
+		{"no default keeps nil end date", 0, nil, nil},
+		{"default sets end date", 30, nil, &defaultEnd},
+		{"explicit end date wins", 30, &explicitEnd, &explicitEnd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			WithDefaultDuration(tt.days)(s)
+			ctx := testCtx()
+
+			// The overlap check sees the end date that will be stored.
+			mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, fixedUUID(), "netflix", fixedTime(), tt.want).
+				Return((*uuid.UUID)(nil), nil)
+			mockRepo.On("Create", ctx, mock.AnythingOfType("*model.Subscription")).Return(nil)
+
+			sub, err := s.CreateSubscription(ctx, CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       999,
+				UserID:      fixedUUID(),
+				StartDate:   fixedTime(),
+				EndDate:     tt.end,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sub.EndDate)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateSubscription_Metadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata json.RawMessage
+		want     json.RawMessage
+	}{
+		{"nested object", json.RawMessage(`{"card": {"last4": "4242"}, "family": {"members": 4}}`), json.RawMessage(`{"card": {"last4": "4242"}, "family": {"members": 4}}`)},
+		{"array", json.RawMessage(`[{"tag": "work"}, ["nested", 1]]`), json.RawMessage(`[{"tag": "work"}, ["nested", 1]]`)},
+		{"null stores none", json.RawMessage(`null`), nil},
+		{"missing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			ctx := testCtx()
+			req := validCreateRequest()
+			req.Metadata = tt.metadata
+
+			expectNoOverlap(mockRepo, ctx)
+			mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *model.Subscription) bool {
+				return bytes.Equal(sub.Metadata, tt.want)
+			})).Return(nil)
+
+			sub, err := s.CreateSubscription(ctx, req)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sub.Metadata)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateSubscription_InvalidMetadata(t *testing.T) {
+	s, mockRepo := newTestService()
+
+	_, err := s.UpdateSubscription(testCtx(), UpdateSubscriptionRequest{
+		ID:          fixedUUID(),
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+		Metadata:    json.RawMessage(`{"a": 1,}`),
+	})
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+	assert.Contains(t, err.Error(), "metadata: must be valid JSON")
+	mockRepo.AssertNotCalled(t, "Update")
+}
+
 func TestCreateSubscription_RepositoryError(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	req := CreateSubscriptionRequest{
 		ServiceName: "Yandex Plus",
@@ -99,6 +271,7 @@ func TestCreateSubscription_RepositoryError(t *testing.T) {
 		StartDate:   fixedTime(),
 	}
 
+	expectNoOverlap(mockRepo, ctx)
 	mockRepo.On("Create", ctx, mock.Anything).Return(errors.New("db error"))
 
 	sub, err := s.CreateSubscription(ctx, req)
@@ -109,9 +282,57 @@ func TestCreateSubscription_RepositoryError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateSubscription_OverlapIsConflict(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	req := validCreateRequest()
+	existing := uuid.New()
+
+	mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, req.UserID, "yandex plus", req.StartDate, req.EndDate).
+		Return(&existing, nil)
+
+	sub, err := s.CreateSubscription(ctx, req)
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrConflict)
+	var derr *model.DuplicateError
+	require.ErrorAs(t, err, &derr)
+	assert.Equal(t, existing, derr.ExistingID)
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestCreateSubscription_AllowDuplicateSkipsCheck(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	req := validCreateRequest()
+	req.AllowDuplicate = true
+
+	mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	_, err := s.CreateSubscription(ctx, req)
+
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "ExistsActiveOverlap")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscription_OverlapCheckError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return((*uuid.UUID)(nil), errors.New("db error"))
+
+	_, err := s.CreateSubscription(ctx, validCreateRequest())
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, model.ErrConflict)
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
 func TestGetSubscription_Success(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 	subID := fixedUUID()
 
 	expectedSub := &model.Subscription{
@@ -120,9 +341,10 @@ func TestGetSubscription_Success(t *testing.T) {
 		Price:       599,
 		UserID:      fixedUUID(),
 		StartDate:   fixedTime(),
+		Version:     1,
 	}
 
-	mockRepo.On("GetByID", ctx, subID).Return(expectedSub, nil)
+	mockRepo.On("GetByID", ctx, testTenantID, subID).Return(expectedSub, nil)
 
 	sub, err := s.GetSubscription(ctx, subID)
 
@@ -133,10 +355,10 @@ func TestGetSubscription_Success(t *testing.T) {
 
 func TestGetSubscription_NotFound(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 	subID := fixedUUID()
 
-	mockRepo.On("GetByID", ctx, subID).Return((*model.Subscription)(nil), model.ErrNotFound)
+	mockRepo.On("GetByID", ctx, testTenantID, subID).Return((*model.Subscription)(nil), model.ErrNotFound)
 
 	sub, err := s.GetSubscription(ctx, subID)
 
@@ -148,36 +370,45 @@ func TestGetSubscription_NotFound(t *testing.T) {
 
 func TestUpdateSubscription_Success(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	req := UpdateSubscriptionRequest{
 		ID:          fixedUUID(),
-		ServiceName: "Yandex Plus",
+		ServiceName: " Yandex Plus ",
 		Price:       799,
 		UserID:      fixedUUID(),
 		StartDate:   fixedTime(),
+		Version:     3,
 	}
 
 	expectedSub := &model.Subscription{
-		ID:          req.ID,
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
+		ID:           req.ID,
+		ServiceName:  "yandex plus",
+		Price:        req.Price,
+		UserID:       req.UserID,
+		StartDate:    req.StartDate,
+		BillingCycle: model.CycleMonthly,
+		TenantID:     testTenantID,
+		Version:      3,
 	}
 
-	mockRepo.On("Update", ctx, expectedSub).Return(nil)
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	mockRepo.On("LockSubscription", txCtx, testTenantID, req.ID).Return(&model.Subscription{ID: req.ID, Price: 599}, nil)
+	mockRepo.On("Update", txCtx, expectedSub).Return(nil)
+	mockRepo.On("NotifyPriceChanged", txCtx, testTenantID, req.ID, 599, req.Price).Return(nil)
 
 	sub, err := s.UpdateSubscription(ctx, req)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedSub, sub)
+	assert.True(t, tx.committed)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestUpdateSubscription_RepositoryError(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	req := UpdateSubscriptionRequest{
 		ID:          fixedUUID(),
@@ -185,24 +416,494 @@ func TestUpdateSubscription_RepositoryError(t *testing.T) {
 		Price:       799,
 		UserID:      fixedUUID(),
 		StartDate:   fixedTime(),
+		Version:     1,
 	}
 
-	mockRepo.On("Update", ctx, mock.Anything).Return(errors.New("db error"))
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	mockRepo.On("LockSubscription", txCtx, testTenantID, req.ID).Return(&model.Subscription{ID: req.ID}, nil)
+	mockRepo.On("Update", txCtx, mock.Anything).Return(errors.New("db error"))
 
 	sub, err := s.UpdateSubscription(ctx, req)
 
 	assert.Nil(t, sub)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to update subscription")
+	assert.True(t, tx.rolledBack)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestDeleteSubscription_Success(t *testing.T) {
+func TestUpdateSubscription_LockNotFoundSkipsUpdate(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(nil, fmt.Errorf("repository.postgresql.LockSubscription: %w", model.ErrNotFound))
+
+	sub, err := s.UpdateSubscription(ctx, UpdateSubscriptionRequest{
+		ID:          fixedUUID(),
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+		Version:     1,
+	})
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.True(t, tx.rolledBack)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdatePrice_KeepsOtherFields(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	end := fixedTime().AddDate(1, 0, 0)
+	current := &model.Subscription{
+		ID:           fixedUUID(),
+		TenantID:     testTenantID,
+		ServiceName:  "yandex plus",
+		Price:        599,
+		UserID:       fixedUUID(),
+		StartDate:    fixedTime(),
+		EndDate:      &end,
+		BillingCycle: model.CycleAnnual,
+	}
+	want := *current
+	want.Price = 799
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).Return(current, nil)
+	mockRepo.On("Update", txCtx, &want).Return(nil)
+	mockRepo.On("NotifyPriceChanged", txCtx, testTenantID, fixedUUID(), 599, 799).Return(nil)
+
+	sub, err := s.UpdatePrice(ctx, fixedUUID(), 799)
+
+	require.NoError(t, err)
+	assert.Equal(t, &want, sub)
+	assert.True(t, tx.committed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdatePrice_SamePriceWritesNothing(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	txCtx := (&fakeTx{}).expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(&model.Subscription{ID: fixedUUID(), TenantID: testTenantID, Price: 599}, nil)
+
+	sub, err := s.UpdatePrice(ctx, fixedUUID(), 599)
+
+	require.NoError(t, err)
+	assert.Equal(t, 599, sub.Price)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "NotifyPriceChanged", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdatePrice_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		price int
+		want  string
+	}{
+		{"negative", -100, "must be positive"},
+		{"zero", 0, "must be positive"},
+		{"too high", DefaultMaxPrice, "must be less than 1000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			sub, err := s.UpdatePrice(testCtx(), fixedUUID(), tt.price)
+
+			assert.Nil(t, sub)
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, map[string]string{"price": tt.want}, verr.Fields)
+			mockRepo.AssertNotCalled(t, "Transactional", mock.Anything)
+		})
+	}
+}
+
+func TestUpdatePrice_NotFound(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(nil, fmt.Errorf("repository.postgresql.LockSubscription: %w", model.ErrNotFound))
+
+	sub, err := s.UpdatePrice(ctx, fixedUUID(), 799)
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.True(t, tx.rolledBack)
+}
+
+func TestUpdatePrice_NotifyErrorRollsBack(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(&model.Subscription{ID: fixedUUID(), TenantID: testTenantID, Price: 599}, nil)
+	mockRepo.On("Update", txCtx, mock.Anything).Return(nil)
+	mockRepo.On("NotifyPriceChanged", txCtx, testTenantID, fixedUUID(), 599, 799).Return(errors.New("db error"))
+
+	sub, err := s.UpdatePrice(ctx, fixedUUID(), 799)
+
+	assert.Nil(t, sub)
+	assert.ErrorContains(t, err, "failed to update price")
+	assert.True(t, tx.rolledBack)
+}
+
+func TestBulkUpdatePrice_ReturnsCount(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	price := 1199
+
+	// The caller's tenant only, every live subscription.
+	mockRepo.On("UpdateServicePrice", txCtx, model.ServicePriceUpdate{
+		ServiceName: "netflix",
+		TenantID:    &testTenantID,
+		NewPrice:    &price,
+		MaxPrice:    DefaultMaxPrice,
+	}).Return(&model.ServicePriceUpdateResult{Updated: 3}, nil)
+
+	updated, err := s.BulkUpdatePrice(ctx, " Netflix ", 1199)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated)
+	assert.True(t, tx.committed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBulkUpdatePrice_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceName string
+		price       int
+		want        map[string]string
+	}{
+		{"zero price", "Netflix", 0, map[string]string{"price": "must be positive"}},
+		{"too high", "Netflix", DefaultMaxPrice, map[string]string{"price": "must be less than 1000000"}},
+		{"blank service", "  ", 1199, map[string]string{"service_name": "must not be empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			updated, err := s.BulkUpdatePrice(testCtx(), tt.serviceName, tt.price)
+
+			assert.Zero(t, updated)
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.want, verr.Fields)
+			mockRepo.AssertNotCalled(t, "UpdateServicePrice", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestBulkUpdatePrice_RepoError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("UpdateServicePrice", txCtx, mock.Anything).Return((*model.ServicePriceUpdateResult)(nil), errors.New("db error"))
+
+	updated, err := s.BulkUpdatePrice(ctx, "netflix", 1199)
+
+	assert.Zero(t, updated)
+	assert.ErrorContains(t, err, "failed to update service price")
+	assert.True(t, tx.rolledBack)
+}
+
+func TestUpdateServicePrice_InTransaction(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.now = fixedTime
+	ctx := context.Background()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	pct, effective := 10.0, fixedTime().AddDate(0, 0, -7)
+	want := &model.ServicePriceUpdateResult{Updated: 2, TotalBefore: 1598, TotalAfter: 1758}
+
+	mockRepo.On("UpdateServicePrice", txCtx, model.ServicePriceUpdate{
+		ServiceName:   "netflix",
+		PercentChange: &pct,
+		MaxPrice:      DefaultMaxPrice,
+		ActiveOnly:    true,
+		EffectiveDate: &effective,
+	}).Return(want, nil)
+
+	result, err := s.UpdateServicePrice(ctx, UpdateServicePriceRequest{
+		ServiceName:   " Netflix ",
+		PercentChange: &pct,
+		ActiveOnly:    true,
+		EffectiveDate: &effective,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, want, result)
+	assert.True(t, tx.committed)
+	mockRepo.AssertExpectations(t)
+}
+
+// Repricing changes what every owner of a changed subscription spends, so
+// each of them is checked once, whatever their tenant.
+func TestUpdateServicePrice_ChecksOwnersSpend(t *testing.T) {
+	s, mockRepo := newTestService()
+	breaches, costAlerts := &recordingBreachChecker{}, &recordingCostAlertChecker{}
+	WithBreachChecker(breaches)(s)
+	WithCostAlertChecker(costAlerts)(s)
+	ctx := context.Background()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	price := 1199
+	alice, bob := uuid.New(), uuid.New()
+
+	mockRepo.On("UpdateServicePrice", txCtx, mock.Anything).Return(&model.ServicePriceUpdateResult{
+		Updated: 3,
+		Owners:  []model.SubscriptionOwner{{TenantID: testTenantID, UserID: alice}, {TenantID: uuid.New(), UserID: bob}},
+	}, nil)
+
+	_, err := s.UpdateServicePrice(ctx, UpdateServicePriceRequest{ServiceName: "netflix", NewPrice: &price})
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{alice, bob}, breaches.users)
+	assert.Equal(t, []uuid.UUID{alice, bob}, costAlerts.users)
+}
+
+func TestBulkUpdatePrice_ChecksOwnersSpend(t *testing.T) {
+	s, mockRepo := newTestService()
+	breaches := &recordingBreachChecker{}
+	WithBreachChecker(breaches)(s)
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	owner := uuid.New()
+
+	mockRepo.On("UpdateServicePrice", txCtx, mock.Anything).Return(&model.ServicePriceUpdateResult{
+		Updated: 2,
+		Owners:  []model.SubscriptionOwner{{TenantID: testTenantID, UserID: owner}},
+	}, nil)
+
+	_, err := s.BulkUpdatePrice(ctx, "netflix", 1199)
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{owner}, breaches.users)
+}
+
+func TestUpdateServicePrice_Validation(t *testing.T) {
+	price, zero, pct, fall := 1199, 0, 10.0, -100.0
+	tomorrow := fixedTime().AddDate(0, 0, 1)
+
+	tests := []struct {
+		name string
+		req  UpdateServicePriceRequest
+		want map[string]string
+	}{
+		{"neither", UpdateServicePriceRequest{ServiceName: "Netflix"},
+			map[string]string{"new_price": "either new_price or percent_change is required"}},
+		{"both", UpdateServicePriceRequest{ServiceName: "Netflix", NewPrice: &price, PercentChange: &pct},
+			map[string]string{"percent_change": "must not be given with new_price"}},
+		{"zero price", UpdateServicePriceRequest{ServiceName: "Netflix", NewPrice: &zero},
+			map[string]string{"price": "must be positive"}},
+		{"whole price off", UpdateServicePriceRequest{ServiceName: "Netflix", PercentChange: &fall},
+			map[string]string{"percent_change": "must be greater than -100"}},
+		{"future date", UpdateServicePriceRequest{ServiceName: "Netflix", NewPrice: &price, EffectiveDate: &tomorrow},
+			map[string]string{"effective_date": "must not be in the future"}},
+		{"blank service", UpdateServicePriceRequest{ServiceName: " ", NewPrice: &price},
+			map[string]string{"service_name": "must not be empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			s.now = fixedTime
+
+			result, err := s.UpdateServicePrice(context.Background(), tt.req)
+
+			assert.Nil(t, result)
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.want, verr.Fields)
+			mockRepo.AssertNotCalled(t, "Transactional", mock.Anything)
+		})
+	}
+}
+
+func TestUpdateServicePrice_RepoErrorRollsBack(t *testing.T) {
 	s, mockRepo := newTestService()
 	ctx := context.Background()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	price := 1199
+
+	mockRepo.On("UpdateServicePrice", txCtx, mock.Anything).Return((*model.ServicePriceUpdateResult)(nil), errors.New("db error"))
+
+	result, err := s.UpdateServicePrice(ctx, UpdateServicePriceRequest{ServiceName: "netflix", NewPrice: &price})
+
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "failed to update service price")
+	assert.True(t, tx.rolledBack)
+}
+
+func validUpsertRequest() UpdateSubscriptionRequest {
+	return UpdateSubscriptionRequest{
+		ID:          fixedUUID(),
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+		Version:     1,
+	}
+}
+
+func TestUpsertSubscription_UpdatesExisting(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID(), Price: 599}, nil)
+	mockRepo.On("Update", txCtx, mock.AnythingOfType("*model.Subscription")).Return(nil)
+
+	sub, created, err := s.UpsertSubscription(ctx, validUpsertRequest())
+
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, fixedUUID(), sub.ID)
+	assert.True(t, tx.committed)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpsertSubscription_CreatesMissing(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(nil, fmt.Errorf("repository.postgresql.LockSubscription: %w", model.ErrNotFound))
+	expectNoOverlap(mockRepo, txCtx)
+	mockRepo.On("Create", txCtx, mock.MatchedBy(func(sub *model.Subscription) bool {
+		return sub.ID == fixedUUID() && sub.TenantID == testTenantID && sub.ServiceName == "yandex plus"
+	})).Return(nil)
+
+	sub, created, err := s.UpsertSubscription(ctx, validUpsertRequest())
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, fixedUUID(), sub.ID)
+	assert.True(t, tx.committed)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpsertSubscription_CreateAppliesDefaultDuration(t *testing.T) {
+	s, mockRepo := newTestService()
+	WithDefaultDuration(30)(s)
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	req := validUpsertRequest()
+	want := req.StartDate.AddDate(0, 0, 30)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(nil, fmt.Errorf("repository.postgresql.LockSubscription: %w", model.ErrNotFound))
+	expectNoOverlap(mockRepo, txCtx)
+	mockRepo.On("Create", txCtx, mock.MatchedBy(func(sub *model.Subscription) bool {
+		return sub.EndDate != nil && sub.EndDate.Equal(want)
+	})).Return(nil)
+
+	sub, created, err := s.UpsertSubscription(ctx, req)
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	require.NotNil(t, sub.EndDate)
+	assert.Equal(t, want, *sub.EndDate)
+}
+
+func TestUpsertSubscription_IDTakenIsConflict(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).
+		Return(nil, fmt.Errorf("repository.postgresql.LockSubscription: %w", model.ErrNotFound))
+	expectNoOverlap(mockRepo, txCtx)
+	mockRepo.On("Create", txCtx, mock.Anything).Return(fmt.Errorf("repository.postgresql.Create: %w", model.ErrConflict))
+
+	sub, created, err := s.UpsertSubscription(ctx, validUpsertRequest())
+
+	assert.Nil(t, sub)
+	assert.False(t, created)
+	assert.ErrorIs(t, err, model.ErrConflict)
+	assert.True(t, tx.rolledBack)
+}
+
+func TestUpsertSubscription_NilIDIsRejected(t *testing.T) {
+	s, mockRepo := newTestService()
+	req := validUpsertRequest()
+	req.ID = uuid.Nil
+
+	_, _, err := s.UpsertSubscription(testCtx(), req)
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+	assert.ErrorContains(t, err, "id: must not be the nil UUID")
+	mockRepo.AssertNotCalled(t, "Transactional", mock.Anything)
+}
+
+func TestCreateSubscription_ClientID(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	id := uuid.New()
+	req := validCreateRequest()
+	req.ID = &id
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *model.Subscription) bool {
+		return sub.ID == id
+	})).Return(nil)
+
+	sub, err := s.CreateSubscription(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, id, sub.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscription_NilClientIDIsRejected(t *testing.T) {
+	s, mockRepo := newTestService()
+	nilID := uuid.Nil
+	req := validCreateRequest()
+	req.ID = &nilID
+
+	_, err := s.CreateSubscription(testCtx(), req)
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestDeleteSubscription_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
 	subID := fixedUUID()
 
-	mockRepo.On("Delete", ctx, subID).Return(nil)
+	mockRepo.On("GetByID", ctx, testTenantID, subID).Return(&model.Subscription{ID: subID, UserID: fixedUUID()}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, subID).Return(nil)
 
 	err := s.DeleteSubscription(ctx, subID)
 
@@ -210,12 +911,34 @@ func TestDeleteSubscription_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestWithLoggerFactory_LogsThroughContext(t *testing.T) {
+	var buf bytes.Buffer
+	reqLog := slog.New(slog.NewJSONHandler(&buf, nil)).With(slog.String("request_id", "req-7"))
+	mockRepo := &mocks.SubscriptionRepository{}
+	s := NewSubscriptionService(mockRepo, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithLoggerFactory(logger.FromContext))
+	ctx := logger.WithContext(testCtx(), reqLog)
+	subID := fixedUUID()
+
+	mockRepo.On("GetByID", ctx, testTenantID, subID).Return(&model.Subscription{ID: subID, UserID: fixedUUID()}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, subID).Return(nil)
+
+	require.NoError(t, s.DeleteSubscription(ctx, subID))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "subscription deleted", line["msg"])
+	assert.Equal(t, "req-7", line["request_id"])
+	assert.Equal(t, subID.String(), line["id"])
+}
+
 func TestDeleteSubscription_RepositoryError(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 	subID := fixedUUID()
 
-	mockRepo.On("Delete", ctx, subID).Return(errors.New("db error"))
+	mockRepo.On("GetByID", ctx, testTenantID, subID).Return(&model.Subscription{ID: subID, UserID: fixedUUID()}, nil)
+	mockRepo.On("Delete", ctx, testTenantID, subID).Return(errors.New("db error"))
 
 	err := s.DeleteSubscription(ctx, subID)
 
@@ -226,45 +949,48 @@ func TestDeleteSubscription_RepositoryError(t *testing.T) {
 
 func TestListSubscriptions_Success(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	filter := model.SubscriptionFilter{
 		UserID:      &[]uuid.UUID{fixedUUID()}[0],
 		ServiceName: &[]string{"Yandex Plus"}[0],
 	}
 
-	expectedSubs := []*model.Subscription{
-		{
-			ID:          fixedUUID(),
-			ServiceName: "Yandex Plus",
-			Price:       599,
-			UserID:      fixedUUID(),
-			StartDate:   fixedTime(),
+	expected := &model.ListResult{
+		Items: []*model.Subscription{
+			{
+				ID:          fixedUUID(),
+				ServiceName: "Yandex Plus",
+				Price:       599,
+				UserID:      fixedUUID(),
+				StartDate:   fixedTime(),
+			},
 		},
+		TotalCount: 1,
 	}
 
-	mockRepo.On("List", ctx, filter).Return(expectedSubs, nil)
+	mockRepo.On("List", ctx, scoped(filter)).Return(expected, nil)
 
-	subs, err := s.ListSubscriptions(ctx, filter)
+	result, err := s.ListSubscriptions(ctx, filter)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedSubs, subs)
+	assert.Equal(t, expected, result)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestListSubscriptions_RepositoryError(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	filter := model.SubscriptionFilter{
 		UserID: &[]uuid.UUID{fixedUUID()}[0],
 	}
 
-	mockRepo.On("List", ctx, filter).Return([]*model.Subscription(nil), errors.New("db error"))
+	mockRepo.On("List", ctx, scoped(filter)).Return((*model.ListResult)(nil), errors.New("db error"))
 
-	subs, err := s.ListSubscriptions(ctx, filter)
+	result, err := s.ListSubscriptions(ctx, filter)
 
-	assert.Nil(t, subs)
+	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to list subscriptions")
 	mockRepo.AssertExpectations(t)
@@ -272,36 +998,1076 @@ func TestListSubscriptions_RepositoryError(t *testing.T) {
 
 func TestGetTotalCost_Success(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
 	filter := model.SubscriptionFilter{
 		ServiceName: &[]string{"Yandex Plus"}[0],
 	}
 	expectedTotal := 1500
 
-	mockRepo.On("GetTotalCost", ctx, filter).Return(expectedTotal, nil)
+	mockRepo.On("GetProratedTotalCost", ctx, scoped(filter)).Return(expectedTotal, nil)
 
-	total, err := s.GetTotalCost(ctx, filter)
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter})
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedTotal, total)
+	assert.Equal(t, &model.TotalCostResponse{Total: expectedTotal, Mode: model.TotalProrated, Currency: "RUB"}, total)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetTotalCost_RepositoryError(t *testing.T) {
+func TestGetTotalCost_FlatMode(t *testing.T) {
 	s, mockRepo := newTestService()
-	ctx := context.Background()
+	ctx := testCtx()
 
-	filter := model.SubscriptionFilter{
-		ServiceName: &[]string{"Yandex Plus"}[0],
-	}
+	filter := model.SubscriptionFilter{}
+	mockRepo.On("GetTotalCost", ctx, scoped(filter)).Return(599, nil)
+
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter, Mode: model.TotalFlat})
 
-	mockRepo.On("GetTotalCost", ctx, filter).Return(0, errors.New("db error"))
+	require.NoError(t, err)
+	assert.Equal(t, 599, total.Total)
+	assert.Equal(t, model.TotalFlat, total.Mode)
+	mockRepo.AssertNotCalled(t, "GetProratedTotalCost", mock.Anything, mock.Anything)
+}
 
-	total, err := s.GetTotalCost(ctx, filter)
+func TestGetTotalCost_UnknownMode(t *testing.T) {
+	s, mockRepo := newTestService()
 
-	assert.Equal(t, 0, total)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to calculate total cost")
+	total, err := s.GetTotalCost(testCtx(), TotalCostRequest{Mode: "monthly"})
+
+	assert.Nil(t, total)
+	var verr *model.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "mode")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetTotalCost_Converted(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.converter = currency.NewConverter("RUB", currency.NewStaticProvider("RUB", map[string]float64{"USD": 81.08}))
+	ctx := testCtx()
+
+	filter := model.SubscriptionFilter{}
+	mockRepo.On("GetProratedTotalCost", ctx, scoped(filter)).Return(1500, nil)
+
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter, Currency: "usd"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1500, total.Total)
+	assert.Equal(t, "RUB", total.Currency)
+	require.NotNil(t, total.ConvertedTotal)
+	assert.Equal(t, 18.50, *total.ConvertedTotal)
+	assert.Equal(t, "USD", total.TargetCurrency)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTotalCost_UnsupportedCurrency(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.converter = currency.NewConverter("RUB", currency.NewStaticProvider("RUB", map[string]float64{"USD": 81.08}))
+	ctx := testCtx()
+
+	filter := model.SubscriptionFilter{}
+	mockRepo.On("GetProratedTotalCost", ctx, scoped(filter)).Return(1500, nil)
+
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter, Currency: "XYZ"})
+
+	assert.Nil(t, total)
+	assert.ErrorIs(t, err, currency.ErrUnsupportedCurrency)
+}
+
+func TestGetTotalCost_NoConverter(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	filter := model.SubscriptionFilter{}
+	mockRepo.On("GetProratedTotalCost", ctx, scoped(filter)).Return(1500, nil)
+
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter, Currency: "USD"})
+
+	assert.Nil(t, total)
+	assert.ErrorIs(t, err, currency.ErrUnsupportedCurrency)
+}
+
+func TestGetTotalCost_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	filter := model.SubscriptionFilter{
+		ServiceName: &[]string{"Yandex Plus"}[0],
+	}
+
+	mockRepo.On("GetProratedTotalCost", ctx, scoped(filter)).Return(0, errors.New("db error"))
+
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter})
+
+	assert.Nil(t, total)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to calculate total cost")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTeamTotalCost_MergesUsersAndFillsZeros(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	lead, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	from := fixedTime()
+
+	mockRepo.On("GetProratedCostPerUser", ctx, scoped(model.SubscriptionFilter{
+		UserID:   &lead,
+		UserIDs:  []uuid.UUID{alice, lead, bob},
+		FromDate: &from,
+	})).Return(map[uuid.UUID]int{lead: 1200, alice: 599}, nil)
+
+	totals, err := s.GetTeamTotalCost(ctx, []uuid.UUID{alice, lead, bob}, model.SubscriptionFilter{UserID: &lead, FromDate: &from})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{lead: 1200, alice: 599, bob: 0}, totals)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTeamTotalCost_Validation(t *testing.T) {
+	team := make([]uuid.UUID, maxTeamSize+1)
+	for i := range team {
+		team[i] = uuid.New()
+	}
+
+	tests := []struct {
+		name    string
+		userIDs []uuid.UUID
+		want    string
+	}{
+		{"no users", nil, "is required"},
+		{"too many users", team, "must list at most 100 users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			_, err := s.GetTeamTotalCost(testCtx(), tt.userIDs, model.SubscriptionFilter{})
+
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr), "got %v", err)
+			assert.Equal(t, tt.want, verr.Fields["user_id"])
+			assert.Empty(t, mockRepo.Calls)
+		})
+	}
+}
+
+func TestGetTeamTotalCost_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetProratedCostPerUser", ctx, mock.Anything).Return(map[uuid.UUID]int(nil), errors.New("db error"))
+
+	totals, err := s.GetTeamTotalCost(ctx, []uuid.UUID{uuid.New()}, model.SubscriptionFilter{})
+
+	assert.Nil(t, totals)
+	assert.Contains(t, err.Error(), "failed to calculate team total cost")
+}
+
+func TestGetMonthlyCost_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	filter := model.SubscriptionFilter{FromDate: &from, ToDate: &to}
+
+	expected := []model.MonthlyCost{
+		{Month: from, Total: 599},
+		{Month: from.AddDate(0, 1, 0), Total: 0},
+		{Month: to, Total: 599},
+	}
+	mockRepo.On("GetMonthlyCost", ctx, scoped(filter)).Return(expected, nil)
+
+	months, err := s.GetMonthlyCost(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, months)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetMonthlyCost_ToDateDefaultsToNow(t *testing.T) {
+	s, mockRepo := newTestService()
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := testCtx()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.On("GetMonthlyCost", ctx, scoped(model.SubscriptionFilter{FromDate: &from, ToDate: &now})).
+		Return([]model.MonthlyCost{}, nil)
+
+	_, err := s.GetMonthlyCost(ctx, model.SubscriptionFilter{FromDate: &from})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetMonthlyCost_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	filter := model.SubscriptionFilter{FromDate: &from, ToDate: &to}
+
+	mockRepo.On("GetMonthlyCost", ctx, scoped(filter)).Return([]model.MonthlyCost(nil), errors.New("db error"))
+
+	months, err := s.GetMonthlyCost(ctx, filter)
+
+	assert.Nil(t, months)
+	assert.Contains(t, err.Error(), "failed to calculate monthly cost")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListServices_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := fixedUUID()
+
+	expected := []*model.ServiceSummary{
+		{ServiceName: "Netflix", SubscriptionCount: 3},
+		{ServiceName: "Yandex Plus", SubscriptionCount: 1},
+	}
+
+	mockRepo.On("ListServices", ctx, testTenantID, &userID).Return(expected, nil)
+
+	services, err := s.ListServices(ctx, &userID)
+
+	assert.NoError(t, err)
+	assert.Len(t, services, 2)
+	assert.Equal(t, 3, services[0].SubscriptionCount)
+	assert.Equal(t, 1, services[1].SubscriptionCount)
+	for _, svc := range services {
+		assert.NotZero(t, svc.SubscriptionCount)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListServices_NoSubscriptions(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("ListServices", ctx, testTenantID, (*uuid.UUID)(nil)).Return([]*model.ServiceSummary(nil), nil)
+
+	services, err := s.ListServices(ctx, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListServices_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("ListServices", ctx, testTenantID, (*uuid.UUID)(nil)).Return([]*model.ServiceSummary(nil), errors.New("db error"))
+
+	services, err := s.ListServices(ctx, nil)
+
+	assert.Nil(t, services)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list services")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestShareSubscription_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	sharedWith := uuid.MustParse("7a1d9f2e-3c4b-4e5f-8a6b-1c2d3e4f5a6b")
+
+	req := ShareSubscriptionRequest{
+		SubscriptionID: fixedUUID(),
+		UserID:         sharedWith,
+		Permission:     model.PermissionWrite,
+	}
+
+	mockRepo.On("ShareSubscription", ctx, testTenantID, mock.MatchedBy(func(share *model.ShareEntry) bool {
+		return share.SubscriptionID == req.SubscriptionID &&
+			share.UserID == sharedWith &&
+			share.Permission == model.PermissionWrite
+	})).Run(func(args mock.Arguments) {
+		args.Get(2).(*model.ShareEntry).CreatedAt = fixedTime()
+	}).Return(nil)
+
+	share, err := s.ShareSubscription(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sharedWith, share.UserID)
+	assert.Equal(t, fixedTime(), share.CreatedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestShareSubscription_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("ShareSubscription", ctx, testTenantID, mock.Anything).Return(errors.New("db error"))
+
+	share, err := s.ShareSubscription(ctx, ShareSubscriptionRequest{SubscriptionID: fixedUUID(), UserID: uuid.New()})
+
+	assert.Nil(t, share)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to share subscription")
+	mockRepo.AssertExpectations(t)
+}
+
+// fakeTx stands in for a repository transaction and records how it ended.
+type fakeTx struct {
+	committed, rolledBack bool
+}
+
+func (tx *fakeTx) expect(mockRepo *mocks.SubscriptionRepository, ctx context.Context) context.Context {
+	txCtx := context.WithValue(ctx, tx, "tx")
+	commit := repository.CommitFunc(func() error { tx.committed = true; return nil })
+	rollback := repository.RollbackFunc(func() error {
+		tx.rolledBack = !tx.committed
+		return nil
+	})
+	mockRepo.On("Transactional", ctx).Return(txCtx, commit, rollback, nil)
+	return txCtx
+}
+
+func TestCreateSubscriptionWithShares_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	expectNoOverlap(mockRepo, ctx)
+	reader, writer := uuid.New(), uuid.New()
+
+	mockRepo.On("Create", txCtx, mock.AnythingOfType("*model.Subscription")).Return(nil)
+	mockRepo.On("CreateShares", txCtx, testTenantID, mock.MatchedBy(func(shares []model.ShareEntry) bool {
+		return len(shares) == 2 &&
+			shares[0].UserID == reader && shares[0].Permission == model.PermissionRead &&
+			shares[1].UserID == writer && shares[1].Permission == model.PermissionWrite
+	})).Return(nil)
+
+	result, err := s.CreateSubscriptionWithShares(ctx, CreateAndShareRequest{
+		Subscription: validCreateRequest(),
+		ShareWith: []ShareSubscriptionRequest{
+			{UserID: reader},
+			{UserID: writer, Permission: model.PermissionWrite},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, testTenantID, result.Subscription.TenantID)
+	require.Len(t, result.Shares, 2)
+	assert.Equal(t, result.Subscription.ID, result.Shares[0].SubscriptionID)
+	assert.True(t, tx.committed)
+	assert.False(t, tx.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscriptionWithShares_ShareFailureRollsBack(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	expectNoOverlap(mockRepo, ctx)
+
+	mockRepo.On("Create", txCtx, mock.Anything).Return(nil)
+	mockRepo.On("CreateShares", txCtx, testTenantID, mock.Anything).Return(errors.New("db error"))
+
+	result, err := s.CreateSubscriptionWithShares(ctx, CreateAndShareRequest{
+		Subscription: validCreateRequest(),
+		ShareWith:    []ShareSubscriptionRequest{{UserID: uuid.New()}},
+	})
+
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "failed to share subscription")
+	assert.False(t, tx.committed)
+	assert.True(t, tx.rolledBack)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSubscriptionWithShares_CreateFailureRollsBack(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+	expectNoOverlap(mockRepo, ctx)
+
+	mockRepo.On("Create", txCtx, mock.Anything).Return(fmt.Errorf("insert: %w", model.ErrConflict))
+
+	_, err := s.CreateSubscriptionWithShares(ctx, CreateAndShareRequest{
+		Subscription: validCreateRequest(),
+		ShareWith:    []ShareSubscriptionRequest{{UserID: uuid.New()}},
+	})
+
+	assert.ErrorIs(t, err, model.ErrConflict)
+	assert.True(t, tx.rolledBack)
+	mockRepo.AssertNotCalled(t, "CreateShares", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscriptionWithShares_Duplicate(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	existing := uuid.New()
+	mockRepo.On("ExistsActiveOverlap", ctx, testTenantID, fixedUUID(), "yandex plus", mock.Anything, mock.Anything).
+		Return(&existing, nil)
+
+	_, err := s.CreateSubscriptionWithShares(ctx, CreateAndShareRequest{
+		Subscription: validCreateRequest(),
+		ShareWith:    []ShareSubscriptionRequest{{UserID: uuid.New()}},
+	})
+
+	var dup *model.DuplicateError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, existing, dup.ExistingID)
+	mockRepo.AssertNotCalled(t, "Transactional", mock.Anything)
+}
+
+func TestCreateSubscriptionWithShares_Validation(t *testing.T) {
+	other := uuid.New()
+
+	tests := []struct {
+		name   string
+		shares []ShareSubscriptionRequest
+		field  string
+		msg    string
+	}{
+		{"empty user", []ShareSubscriptionRequest{{}}, "share_with[0].user_id", "must not be empty"},
+		{"owner", []ShareSubscriptionRequest{{UserID: fixedUUID()}}, "share_with[0].user_id", "must not be the owner"},
+		{"duplicate", []ShareSubscriptionRequest{{UserID: other}, {UserID: other}}, "share_with[1].user_id", "listed more than once"},
+		{"unknown permission", []ShareSubscriptionRequest{{UserID: other, Permission: "admin"}}, "share_with[0].permission", "must be read or write"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			_, err := s.CreateSubscriptionWithShares(testCtx(), CreateAndShareRequest{
+				Subscription: validCreateRequest(),
+				ShareWith:    tt.shares,
+			})
+
+			var verr *model.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, map[string]string{tt.field: tt.msg}, verr.Fields)
+			assert.Empty(t, mockRepo.Calls)
+		})
+	}
+}
+
+func TestUnshareSubscription_NotFound(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	mockRepo.On("UnshareSubscription", ctx, testTenantID, fixedUUID(), userID).Return(model.ErrNotFound)
+
+	err := s.UnshareSubscription(ctx, fixedUUID(), userID)
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetSharedUsers_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	expected := []model.ShareEntry{
+		{SubscriptionID: fixedUUID(), UserID: uuid.New(), Permission: model.PermissionRead, CreatedAt: fixedTime()},
+	}
+
+	mockRepo.On("GetSharedUsers", ctx, testTenantID, fixedUUID()).Return(expected, nil)
+
+	shares, err := s.GetSharedUsers(ctx, fixedUUID())
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, shares)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListSubscriptions_SharedWithMe(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := fixedUUID()
+
+	filter := model.SubscriptionFilter{UserID: &userID, SharedWithMe: true}
+	expected := &model.ListResult{
+		Items: []*model.Subscription{
+			{ID: uuid.New(), ServiceName: "Yandex Plus", Price: 599, UserID: uuid.New(), StartDate: fixedTime()},
+		},
+		TotalCount: 1,
+	}
+
+	mockRepo.On("List", ctx, scoped(filter)).Return(expected, nil)
+
+	result, err := s.ListSubscriptions(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPinSubscription_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	mockRepo.On("PinSubscription", ctx, testTenantID, mock.AnythingOfType("*model.Pin")).
+		Run(func(args mock.Arguments) { args.Get(2).(*model.Pin).PinnedAt = fixedTime() }).
+		Return(nil)
+
+	pin, err := s.PinSubscription(ctx, fixedUUID(), userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Pin{SubscriptionID: fixedUUID(), UserID: userID, PinnedAt: fixedTime()}, pin)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPinSubscription_NotFound(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("PinSubscription", ctx, testTenantID, mock.AnythingOfType("*model.Pin")).Return(model.ErrNotFound)
+
+	pin, err := s.PinSubscription(ctx, fixedUUID(), uuid.New())
+
+	assert.Nil(t, pin)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUnpinSubscription_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := uuid.New()
+
+	mockRepo.On("UnpinSubscription", ctx, testTenantID, fixedUUID(), userID).Return(nil)
+
+	err := s.UnpinSubscription(ctx, fixedUUID(), userID)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListSubscriptions_PinnedOnly(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := fixedUUID()
+
+	filter := model.SubscriptionFilter{UserID: &userID, PinnedOnly: true}
+	expected := &model.ListResult{
+		Items: []*model.Subscription{
+			{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: userID, StartDate: fixedTime(), Pinned: true},
+		},
+		TotalCount: 1,
+	}
+
+	mockRepo.On("List", ctx, scoped(filter)).Return(expected, nil)
+
+	result, err := s.ListSubscriptions(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListExpiredSubscriptions_ComputesExpiredForDays(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.now = func() time.Time { return time.Date(2025, 1, 15, 18, 30, 0, 0, time.UTC) }
+	ctx := testCtx()
+	userID := fixedUUID()
+
+	endedToday := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	endedLastMonth := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	filter := model.SubscriptionFilter{UserID: &userID}
+
+	mockRepo.On("ListExpired", ctx, scoped(filter)).Return([]*model.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", Price: 999, UserID: userID, StartDate: fixedTime(), EndDate: &endedLastMonth},
+		{ID: uuid.New(), ServiceName: "Yandex Plus", Price: 599, UserID: userID, StartDate: fixedTime(), EndDate: &endedToday},
+	}, nil)
+
+	subs, err := s.ListExpiredSubscriptions(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Len(t, subs, 2)
+	assert.Equal(t, 45, subs[0].ExpiredForDays)
+	assert.Equal(t, 0, subs[1].ExpiredForDays)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListExpiredSubscriptions_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("ListExpired", ctx, scoped(model.SubscriptionFilter{})).Return([]*model.Subscription(nil), errors.New("db error"))
+
+	subs, err := s.ListExpiredSubscriptions(ctx, model.SubscriptionFilter{})
+
+	assert.Nil(t, subs)
+	assert.Contains(t, err.Error(), "failed to list expired subscriptions")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCleanupExpiredSubscriptions_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("SoftDeleteExpired", ctx, testTenantID, fixedUUID()).Return(3, nil)
+
+	deleted, err := s.CleanupExpiredSubscriptions(ctx, fixedUUID())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+	mockRepo.AssertExpectations(t)
+}
+
+type fakeNotifier struct {
+	ch chan model.SubscriptionEvent
+}
+
+func (f *fakeNotifier) Subscribe(ctx context.Context) <-chan model.SubscriptionEvent {
+	return f.ch
+}
+
+func TestSubscribeToChanges_UsesNotifier(t *testing.T) {
+	notifier := &fakeNotifier{ch: make(chan model.SubscriptionEvent, 2)}
+	s := NewSubscriptionService(&mocks.SubscriptionRepository{}, slog.New(slog.NewTextHandler(io.Discard, nil)), WithChangeNotifier(notifier))
+	own := model.SubscriptionEvent{Event: model.EventCreated, ID: fixedUUID(), TenantID: testTenantID}
+
+	notifier.ch <- model.SubscriptionEvent{Event: model.EventDeleted, ID: uuid.New(), TenantID: uuid.New()}
+	notifier.ch <- own
+	changes, err := s.SubscribeToChanges(testCtx())
+
+	require.NoError(t, err)
+	assert.Equal(t, own, <-changes, "events of other tenants are skipped")
+}
+
+func TestSubscribeToChanges_NotConfigured(t *testing.T) {
+	s, _ := newTestService()
+
+	changes, err := s.SubscribeToChanges(testCtx())
+
+	assert.Nil(t, changes)
+	assert.ErrorIs(t, err, ErrChangesUnavailable)
+}
+
+func TestUpdateSubscription_NotFoundIsWrapped(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	txCtx := (&fakeTx{}).expect(mockRepo, ctx)
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID()}, nil)
+	mockRepo.On("Update", txCtx, mock.Anything).Return(fmt.Errorf("repository.postgresql.Update: %w", model.ErrNotFound))
+
+	sub, err := s.UpdateSubscription(ctx, UpdateSubscriptionRequest{
+		ID:          fixedUUID(),
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+		Version:     1,
+	})
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateSubscription_RequiresVersion(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	tx := &fakeTx{}
+	txCtx := tx.expect(mockRepo, ctx)
+
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID(), Version: 2}, nil)
+
+	req := validUpsertRequest()
+	req.Version = 0
+	sub, err := s.UpdateSubscription(ctx, req)
+
+	assert.Nil(t, sub)
+	var verr *model.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "version")
+	assert.True(t, tx.rolledBack)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateSubscription_StaleVersionKeepsCurrent(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	txCtx := (&fakeTx{}).expect(mockRepo, ctx)
+	mockRepo.On("LockSubscription", txCtx, testTenantID, fixedUUID()).Return(&model.Subscription{ID: fixedUUID(), Version: 2}, nil)
+	mockRepo.On("Update", txCtx, mock.MatchedBy(func(sub *model.Subscription) bool { return sub.Version == 1 })).
+		Return(fmt.Errorf("repository.postgresql.Update: %w", &model.VersionConflictError{Current: 2}))
+
+	sub, err := s.UpdateSubscription(ctx, validUpsertRequest())
+
+	assert.Nil(t, sub)
+	assert.ErrorIs(t, err, model.ErrVersionConflict)
+	var conflict *model.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, conflict.Current)
+}
+
+func TestDeleteSubscription_NotFoundIsWrapped(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetByID", ctx, testTenantID, fixedUUID()).
+		Return((*model.Subscription)(nil), fmt.Errorf("repository.postgresql.GetByID: %w", model.ErrNotFound))
+
+	err := s.DeleteSubscription(ctx, fixedUUID())
+
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateSubscription_DefaultsBillingCycle(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *model.Subscription) bool {
+		return sub.BillingCycle == model.CycleMonthly
+	})).Return(nil)
+
+	sub, err := s.CreateSubscription(ctx, validCreateRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, model.CycleMonthly, sub.BillingCycle)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetCostByCycle_SumsToGrandTotal(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	filter := model.SubscriptionFilter{UserID: &[]uuid.UUID{fixedUUID()}[0]}
+
+	mockRepo.On("GetCostByCycle", ctx, scoped(filter)).Return([]model.BillingCycleSummary{
+		{BillingCycle: model.CycleAnnual, Total: 2400, Count: 1},
+		{BillingCycle: model.CycleMonthly, Total: 1200, Count: 3},
+		{BillingCycle: model.CycleQuarterly, Total: 900, Count: 1},
+		{BillingCycle: model.CycleWeekly, Total: 300, Count: 2},
+	}, nil)
+	mockRepo.On("GetProratedTotalCost", ctx, scoped(filter)).Return(4800, nil)
+
+	summaries, err := s.GetCostByCycle(ctx, filter)
+	require.NoError(t, err)
+	total, err := s.GetTotalCost(ctx, TotalCostRequest{Filter: filter})
+	require.NoError(t, err)
+
+	var sum, count int
+	for _, summary := range summaries {
+		sum += summary.Total
+		count += summary.Count
+	}
+	assert.Equal(t, total.Total, sum)
+	assert.Equal(t, 7, count)
+
+	assert.Equal(t, []float64{200, 1200, 300, 1300}, []float64{
+		summaries[0].MonthlyEquivalent,
+		summaries[1].MonthlyEquivalent,
+		summaries[2].MonthlyEquivalent,
+		summaries[3].MonthlyEquivalent,
+	})
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetPriceStats_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	serviceName := "Netflix"
+	filter := model.SubscriptionFilter{ServiceName: &serviceName}
+	want := &model.PriceStats{Count: 4, MinPrice: 299, MaxPrice: 999, AvgPrice: 599, MedianPrice: 549}
+
+	mockRepo.On("GetPriceStats", ctx, scoped(filter)).Return(want, nil)
+
+	stats, err := s.GetPriceStats(ctx, filter)
+
+	require.NoError(t, err)
+	assert.Equal(t, want, stats)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetPriceStats_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetPriceStats", ctx, scoped(model.SubscriptionFilter{})).Return(nil, errors.New("db error"))
+
+	stats, err := s.GetPriceStats(ctx, model.SubscriptionFilter{})
+
+	assert.Nil(t, stats)
+	assert.ErrorContains(t, err, "failed to get price stats")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetCostByCycle_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetCostByCycle", ctx, scoped(model.SubscriptionFilter{})).Return([]model.BillingCycleSummary(nil), errors.New("db error"))
+
+	summaries, err := s.GetCostByCycle(ctx, model.SubscriptionFilter{})
+
+	assert.Nil(t, summaries)
+	assert.ErrorContains(t, err, "failed to get cost by billing cycle")
+}
+
+func TestFindOrCreateSubscription_ReturnsExisting(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	req := validCreateRequest()
+	existing := &model.Subscription{ID: uuid.New(), ServiceName: "yandex plus", UserID: req.UserID}
+
+	mockRepo.On("List", ctx, mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+		return *f.UserID == req.UserID && *f.ServiceName == "yandex plus"
+	})).Return(&model.ListResult{Items: []*model.Subscription{existing}, TotalCount: 1}, nil)
+
+	sub, created, err := s.FindOrCreateSubscription(ctx, req)
+
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Same(t, existing, sub)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestFindOrCreateSubscription_CreatesWhenMissing(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	req := validCreateRequest()
+
+	mockRepo.On("List", ctx, mock.Anything).Return(&model.ListResult{}, nil)
+	expectNoOverlap(mockRepo, ctx)
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *model.Subscription) bool {
+		return sub.ServiceName == "yandex plus" && sub.UserID == req.UserID
+	})).Return(nil)
+
+	sub, created, err := s.FindOrCreateSubscription(ctx, req)
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "yandex plus", sub.ServiceName)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFindOrCreateSubscription_AmbiguousIsConflict(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("List", ctx, mock.Anything).Return(&model.ListResult{
+		Items:      []*model.Subscription{{ID: uuid.New()}, {ID: uuid.New()}},
+		TotalCount: 2,
+	}, nil)
+
+	sub, created, err := s.FindOrCreateSubscription(ctx, validCreateRequest())
+
+	assert.Nil(t, sub)
+	assert.False(t, created)
+	assert.ErrorIs(t, err, model.ErrConflict)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestFindOrCreateSubscription_InvalidRequest(t *testing.T) {
+	s, mockRepo := newTestService()
+	req := validCreateRequest()
+	req.Price = -1
+
+	_, _, err := s.FindOrCreateSubscription(testCtx(), req)
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestFindOrCreateSubscription_ListError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("List", ctx, mock.Anything).Return((*model.ListResult)(nil), errors.New("db error"))
+
+	_, _, err := s.FindOrCreateSubscription(ctx, validCreateRequest())
+
+	assert.ErrorContains(t, err, "failed to find subscription")
+}
+
+func TestListExpiringSoonByService_Success(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := fixedUUID()
+
+	expected := []model.ExpiringServiceSummary{
+		{ServiceName: "Netflix", Count: 2, EarliestExpiry: fixedTime().AddDate(0, 0, 3)},
+	}
+	mockRepo.On("ListExpiringSoonByService", ctx, testTenantID, &userID, 7).Return(expected, nil)
+
+	summaries, err := s.ListExpiringSoonByService(ctx, &userID, 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, summaries)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListExpiringSoonByService_NoneIsEmptyList(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("ListExpiringSoonByService", ctx, testTenantID, (*uuid.UUID)(nil), 30).Return([]model.ExpiringServiceSummary(nil), nil)
+
+	summaries, err := s.ListExpiringSoonByService(ctx, nil, 30)
+
+	require.NoError(t, err)
+	assert.NotNil(t, summaries)
+	assert.Empty(t, summaries)
+}
+
+func TestListExpiringSoonByService_DaysOutOfRange(t *testing.T) {
+	for _, days := range []int{0, -1, 366} {
+		s, mockRepo := newTestService()
+
+		_, err := s.ListExpiringSoonByService(testCtx(), nil, days)
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		mockRepo.AssertNotCalled(t, "ListExpiringSoonByService", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestListUpcomingRenewals_SortedWithinWindow(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.now = func() time.Time { return time.Date(2025, 2, 20, 9, 30, 0, 0, time.UTC) }
+	ctx := testCtx()
+	userID := fixedUUID()
+	today := time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC)
+	ended := time.Date(2025, 2, 25, 0, 0, 0, 0, time.UTC)
+
+	monthEnd := &model.Subscription{ServiceName: "Netflix", StartDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), BillingCycle: model.CycleMonthly}
+	weekly := &model.Subscription{ServiceName: "Spotify", StartDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC), BillingCycle: model.CycleWeekly}
+	sameDay := &model.Subscription{ServiceName: "Kinopoisk", StartDate: time.Date(2025, 1, 24, 0, 0, 0, 0, time.UTC), BillingCycle: model.CycleMonthly}
+	tooLate := &model.Subscription{ServiceName: "iCloud", StartDate: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), BillingCycle: model.CycleMonthly}
+	endsFirst := &model.Subscription{ServiceName: "Okko", StartDate: time.Date(2025, 1, 26, 0, 0, 0, 0, time.UTC), EndDate: &ended, BillingCycle: model.CycleMonthly}
+
+	mockRepo.On("List", ctx, scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &today, ToDate: &until})).
+		Return(&model.ListResult{Items: []*model.Subscription{monthEnd, weekly, sameDay, tooLate, endsFirst}}, nil)
+
+	upcoming, err := s.ListUpcomingRenewals(ctx, &userID, 14)
+
+	require.NoError(t, err)
+	var names []string
+	var dates []time.Time
+	for _, sub := range upcoming {
+		names = append(names, sub.ServiceName)
+		dates = append(dates, *sub.NextRenewalDate)
+	}
+	assert.Equal(t, []string{"Kinopoisk", "Spotify", "Netflix"}, names)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+	}, dates)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListUpcomingRenewals_NoneIsEmptyList(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("List", ctx, mock.Anything).Return(&model.ListResult{}, nil)
+
+	upcoming, err := s.ListUpcomingRenewals(ctx, nil, 14)
+
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Subscription{}, upcoming)
+}
+
+func TestListUpcomingRenewals_DaysOutOfRange(t *testing.T) {
+	for _, days := range []int{0, 366} {
+		s, mockRepo := newTestService()
+
+		_, err := s.ListUpcomingRenewals(testCtx(), nil, days)
+
+		assert.ErrorIs(t, err, model.ErrValidation)
+		assert.Empty(t, mockRepo.Calls)
+	}
+}
+
+func TestGetCreationRate(t *testing.T) {
+	s, mockRepo := newTestService()
+	from := fixedTime()
+	to := from.AddDate(0, 0, 31)
+
+	mockRepo.On("CountCreatedBetween", mock.Anything, from, to).Return(310, nil)
+
+	rate, err := s.GetCreationRate(testCtx(), from, to)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.CreationRate{From: from, To: to, Count: 310, PerDay: 10}, rate)
+}
+
+func TestGetCreationRate_EmptyWindow(t *testing.T) {
+	s, mockRepo := newTestService()
+
+	_, err := s.GetCreationRate(testCtx(), fixedTime(), fixedTime())
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+	mockRepo.AssertNotCalled(t, "CountCreatedBetween", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetTotalCostByUser(t *testing.T) {
+	s, mockRepo := newTestService()
+	from := fixedTime()
+	filter := model.SubscriptionFilter{FromDate: &from}
+	expected := &model.UserCostResult{
+		Items: []model.UserCost{
+			{UserID: uuid.New(), SubscriptionCount: 4, Total: 14376},
+			{UserID: uuid.New(), SubscriptionCount: 1, Total: 599},
+		},
+		TotalCount: 12,
+	}
+
+	mockRepo.On("GetTotalCostByUser", mock.Anything, filter, 2, 0).Return(expected, nil)
+
+	result, err := s.GetTotalCostByUser(context.Background(), filter, 2, 0)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTotalCostByUser_PastLastPageIsEmpty(t *testing.T) {
+	s, mockRepo := newTestService()
+
+	mockRepo.On("GetTotalCostByUser", mock.Anything, model.SubscriptionFilter{}, 50, 100).
+		Return(&model.UserCostResult{TotalCount: 12}, nil)
+
+	result, err := s.GetTotalCostByUser(context.Background(), model.SubscriptionFilter{}, 50, 100)
+
+	require.NoError(t, err)
+	assert.NotNil(t, result.Items)
+	assert.Empty(t, result.Items)
+}
+
+func TestGetTotalCostByUser_Validation(t *testing.T) {
+	from := fixedTime()
+	to := from.AddDate(DefaultMaxTotalRangeYears+1, 0, 0)
+
+	tests := []struct {
+		name          string
+		filter        model.SubscriptionFilter
+		limit, offset int
+		field         string
+	}{
+		{"zero limit", model.SubscriptionFilter{}, 0, 0, "limit"},
+		{"limit over max", model.SubscriptionFilter{}, 501, 0, "limit"},
+		{"negative offset", model.SubscriptionFilter{}, 50, -1, "offset"},
+		{"range over cap", model.SubscriptionFilter{FromDate: &from, ToDate: &to}, 50, 0, "to_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+
+			_, err := s.GetTotalCostByUser(context.Background(), tt.filter, tt.limit, tt.offset)
+
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr), "got %v", err)
+			assert.Contains(t, verr.Fields, tt.field)
+			assert.Empty(t, mockRepo.Calls)
+		})
+	}
+}
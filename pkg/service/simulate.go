@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// maxSimulationChanges bounds the additions, and separately the
+// exclusions, of one simulation.
+const maxSimulationChanges = 50
+
+// SimulateCostRequest describes hypothetical changes to the subscriptions
+// of UserID.
+type SimulateCostRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Add lists subscriptions to pretend were created, checked like
+	// CreateSubscription checks them; user_id defaults to UserID.
+	Add []CreateSubscriptionRequest `json:"add"`
+	// ExcludeIDs lists subscriptions of UserID to pretend were cancelled.
+	ExcludeIDs []uuid.UUID `json:"exclude_ids"`
+}
+
+// SimulateCost works out what the subscriptions of req.UserID cost in the
+// current calendar month, prorated as GetTotalCost does, and what they
+// would cost with req.Add and without req.ExcludeIDs. Nothing is stored.
+func (s *subscriptionService) SimulateCost(ctx context.Context, req SimulateCostRequest) (*model.CostSimulation, error) {
+	verr := &model.ValidationError{}
+	if req.UserID == uuid.Nil {
+		verr.Add("user_id", "is required")
+	}
+	if len(req.Add) > maxSimulationChanges {
+		verr.Add("add", "must list at most 50 subscriptions")
+	}
+	if len(req.ExcludeIDs) > maxSimulationChanges {
+		verr.Add("exclude_ids", "must list at most 50 IDs")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := s.simulatedAdditions(ctx, verr, req)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := s.simulatedExclusions(ctx, verr, tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+	filter, err := scopeFilter(ctx, model.SubscriptionFilter{UserID: &req.UserID, FromDate: &from, ToDate: &to})
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	var current, simulated float64
+	for _, sub := range result.Items {
+		cost := sub.ProratedCost(&from, to)
+		current += cost
+		if !excluded[sub.ID] {
+			simulated += cost
+		}
+	}
+	for _, sub := range added {
+		simulated += sub.ProratedCost(&from, to)
+	}
+
+	sim := &model.CostSimulation{
+		Month:          from,
+		CurrentTotal:   int(math.Round(current)),
+		SimulatedTotal: int(math.Round(simulated)),
+		Currency:       s.baseCurrency(),
+	}
+	sim.Difference = sim.SimulatedTotal - sim.CurrentTotal
+	return sim, nil
+}
+
+// simulatedAdditions turns req.Add into subscriptions the way
+// CreateSubscription would, recording what is wrong with each in verr
+// under its add[i] prefix.
+func (s *subscriptionService) simulatedAdditions(ctx context.Context, verr *model.ValidationError, req SimulateCostRequest) ([]*model.Subscription, error) {
+	added := make([]*model.Subscription, 0, len(req.Add))
+	for i, add := range req.Add {
+		field := "add[" + strconv.Itoa(i) + "]"
+		if add.UserID == uuid.Nil {
+			add.UserID = req.UserID
+		} else if add.UserID != req.UserID {
+			verr.Add(field+".user_id", "must be the simulated user")
+		}
+
+		if err := s.fillFromCatalog(ctx, &add); err != nil {
+			if !addPrefixed(verr, field, err) {
+				return nil, err
+			}
+			continue
+		}
+		if add.BillingCycle == "" {
+			add.BillingCycle = model.DefaultBillingCycle
+		}
+		add.EndDate = s.defaultEndDate(add.StartDate, add.EndDate)
+		add.ServiceName = NormaliseServiceName(add.ServiceName)
+		if err := s.validateSubscription(add.ServiceName, add.Price, add.UserID, add.StartDate, add.EndDate, add.BillingCycle, add.Metadata); err != nil {
+			addPrefixed(verr, field, err)
+			continue
+		}
+
+		added = append(added, &model.Subscription{
+			ServiceName:  add.ServiceName,
+			Price:        add.Price,
+			UserID:       add.UserID,
+			StartDate:    add.StartDate,
+			EndDate:      add.EndDate,
+			BillingCycle: add.BillingCycle,
+		})
+	}
+	return added, nil
+}
+
+// simulatedExclusions looks up req.ExcludeIDs and records in verr those
+// that are not subscriptions of req.UserID.
+func (s *subscriptionService) simulatedExclusions(ctx context.Context, verr *model.ValidationError, tenantID uuid.UUID, req SimulateCostRequest) (map[uuid.UUID]bool, error) {
+	excluded := make(map[uuid.UUID]bool, len(req.ExcludeIDs))
+	if len(req.ExcludeIDs) == 0 {
+		return excluded, nil
+	}
+
+	unique := make([]uuid.UUID, 0, len(req.ExcludeIDs))
+	for _, id := range req.ExcludeIDs {
+		if !excluded[id] {
+			excluded[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	subs, err := s.repo.GetByIDs(ctx, tenantID, unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	owned := make(map[uuid.UUID]bool, len(subs))
+	for _, sub := range subs {
+		owned[sub.ID] = sub.UserID == req.UserID
+	}
+
+	for i, id := range req.ExcludeIDs {
+		if !owned[id] {
+			verr.Add("exclude_ids["+strconv.Itoa(i)+"]", "is not a subscription of user_id")
+		}
+	}
+	return excluded, nil
+}
+
+// addPrefixed copies the fields of err, if it is a *model.ValidationError,
+// into verr under prefix and reports whether it was one.
+func addPrefixed(verr *model.ValidationError, prefix string, err error) bool {
+	var fields *model.ValidationError
+	if !errors.As(err, &fields) {
+		return false
+	}
+	for name, msg := range fields.Fields {
+		verr.Add(prefix+"."+name, msg)
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// simulationFrom and simulationTo bound March 2025, the month
+// newSimulationService runs in.
+var (
+	simulationFrom = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	simulationTo   = time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func newSimulationService() (*subscriptionService, *mocks.SubscriptionRepository) {
+	s, mockRepo := newTestService()
+	s.now = func() time.Time { return time.Date(2025, 3, 18, 9, 30, 0, 0, time.UTC) }
+	return s, mockRepo
+}
+
+func expectMonth(mockRepo *mocks.SubscriptionRepository, userID uuid.UUID, subs ...*model.Subscription) {
+	mockRepo.On("List", testCtx(), scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &simulationFrom, ToDate: &simulationTo})).
+		Return(&model.ListResult{Items: subs, TotalCount: len(subs)}, nil).Once()
+}
+
+func TestSimulateCost_AddAndExclude(t *testing.T) {
+	s, mockRepo := newSimulationService()
+	userID := fixedUUID()
+	netflix := &model.Subscription{ID: uuid.New(), UserID: userID, ServiceName: "netflix", Price: 999,
+		StartDate: fixedTime(), BillingCycle: model.CycleMonthly}
+	spotify := &model.Subscription{ID: uuid.New(), UserID: userID, ServiceName: "spotify", Price: 299,
+		StartDate: fixedTime(), BillingCycle: model.CycleMonthly}
+	office := &model.Subscription{ID: uuid.New(), UserID: userID, ServiceName: "office", Price: 6000,
+		StartDate: fixedTime(), BillingCycle: model.CycleAnnual}
+
+	mockRepo.On("GetByIDs", mock.Anything, testTenantID, []uuid.UUID{netflix.ID, office.ID}).
+		Return([]*model.Subscription{netflix, office}, nil)
+	expectMonth(mockRepo, userID, netflix, spotify, office)
+
+	sim, err := s.SimulateCost(testCtx(), SimulateCostRequest{
+		UserID: userID,
+		Add: []CreateSubscriptionRequest{
+			{ServiceName: "Kinopoisk", Price: 1200, StartDate: time.Date(2025, 3, 25, 0, 0, 0, 0, time.UTC), BillingCycle: model.CycleQuarterly},
+			{ServiceName: "starts next month", Price: 500, StartDate: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		ExcludeIDs: []uuid.UUID{netflix.ID, office.ID, netflix.ID},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.CostSimulation{
+		Month:          simulationFrom,
+		CurrentTotal:   1798,
+		SimulatedTotal: 699,
+		Difference:     -1099,
+		Currency:       defaultCurrency,
+	}, sim)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSimulateCost_NoChanges(t *testing.T) {
+	s, mockRepo := newSimulationService()
+	userID := fixedUUID()
+	expectMonth(mockRepo, userID, &model.Subscription{ID: uuid.New(), UserID: userID, Price: 599,
+		StartDate: fixedTime(), BillingCycle: model.CycleMonthly})
+
+	sim, err := s.SimulateCost(testCtx(), SimulateCostRequest{UserID: userID})
+
+	require.NoError(t, err)
+	assert.Equal(t, 599, sim.CurrentTotal)
+	assert.Equal(t, 599, sim.SimulatedTotal)
+	assert.Zero(t, sim.Difference)
+	mockRepo.AssertNotCalled(t, "GetByIDs", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSimulateCost_Validation(t *testing.T) {
+	userID := fixedUUID()
+	other := uuid.New()
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		req   SimulateCostRequest
+		field string
+		want  string
+	}{
+		{"no user", SimulateCostRequest{}, "user_id", "is required"},
+		{"too many additions", SimulateCostRequest{UserID: userID, Add: make([]CreateSubscriptionRequest, maxSimulationChanges+1)},
+			"add", "must list at most 50 subscriptions"},
+		{"too many exclusions", SimulateCostRequest{UserID: userID, ExcludeIDs: make([]uuid.UUID, maxSimulationChanges+1)},
+			"exclude_ids", "must list at most 50 IDs"},
+		{"invalid addition", SimulateCostRequest{UserID: userID, Add: []CreateSubscriptionRequest{
+			{ServiceName: "netflix", Price: 999, StartDate: march},
+			{ServiceName: "netflix", StartDate: march},
+		}}, "add[1].price", "must be positive"},
+		{"addition for another user", SimulateCostRequest{UserID: userID, Add: []CreateSubscriptionRequest{
+			{ServiceName: "netflix", Price: 999, UserID: other, StartDate: march},
+		}}, "add[0].user_id", "must be the simulated user"},
+		{"missing catalog entry", SimulateCostRequest{UserID: userID, Add: []CreateSubscriptionRequest{
+			{CatalogServiceID: &other, StartDate: march},
+		}}, "add[0].catalog_service_id", "does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newSimulationService()
+
+			_, err := s.SimulateCost(testCtx(), tt.req)
+
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr), "got %v", err)
+			assert.Equal(t, tt.want, verr.Fields[tt.field], "fields: %v", verr.Fields)
+			assert.Empty(t, mockRepo.Calls)
+		})
+	}
+}
+
+func TestSimulateCost_ExcludesOnlyTheUsersSubscriptions(t *testing.T) {
+	s, mockRepo := newSimulationService()
+	userID := fixedUUID()
+	own, foreign, gone := uuid.New(), uuid.New(), uuid.New()
+	mockRepo.On("GetByIDs", mock.Anything, testTenantID, []uuid.UUID{own, foreign, gone}).Return([]*model.Subscription{
+		{ID: own, UserID: userID},
+		{ID: foreign, UserID: uuid.New()},
+	}, nil)
+
+	_, err := s.SimulateCost(testCtx(), SimulateCostRequest{UserID: userID, ExcludeIDs: []uuid.UUID{own, foreign, gone}})
+
+	var verr *model.ValidationError
+	require.True(t, errors.As(err, &verr), "got %v", err)
+	assert.Equal(t, map[string]string{
+		"exclude_ids[1]": "is not a subscription of user_id",
+		"exclude_ids[2]": "is not a subscription of user_id",
+	}, verr.Fields)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestSimulateCost_RepositoryErrors(t *testing.T) {
+	t.Run("exclusions", func(t *testing.T) {
+		s, mockRepo := newSimulationService()
+		mockRepo.On("GetByIDs", mock.Anything, testTenantID, mock.Anything).Return([]*model.Subscription(nil), errors.New("db error"))
+
+		_, err := s.SimulateCost(testCtx(), SimulateCostRequest{UserID: fixedUUID(), ExcludeIDs: []uuid.UUID{uuid.New()}})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get subscriptions")
+	})
+	t.Run("current subscriptions", func(t *testing.T) {
+		s, mockRepo := newSimulationService()
+		mockRepo.On("List", mock.Anything, mock.Anything).Return((*model.ListResult)(nil), errors.New("db error"))
+
+		_, err := s.SimulateCost(testCtx(), SimulateCostRequest{UserID: fixedUUID()})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to list subscriptions")
+	})
+}
+
+func TestSimulateCost_NoTenant(t *testing.T) {
+	s, mockRepo := newSimulationService()
+
+	_, err := s.SimulateCost(context.Background(), SimulateCostRequest{UserID: fixedUUID()})
+
+	assert.ErrorIs(t, err, ErrNoTenant)
+	assert.Empty(t, mockRepo.Calls)
+}
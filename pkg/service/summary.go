@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// GetUserSummary assembles model.UserSummary from independent queries run
+// in parallel. The first failing query cancels the others.
+func (s *subscriptionService) GetUserSummary(ctx context.Context, userID uuid.UUID) (*model.UserSummary, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary model.UserSummary
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		active, expired, err := s.repo.CountByStatus(ctx, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to count subscriptions: %w", err)
+		}
+		summary.ActiveCount, summary.ExpiredCount = active, expired
+		return nil
+	})
+	g.Go(func() error {
+		cycles, err := s.repo.GetActiveCostByCycle(ctx, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get monthly cost: %w", err)
+		}
+		var total float64
+		for _, c := range cycles {
+			total += c.BillingCycle.MonthlyEquivalent(c.Total)
+		}
+		summary.TotalMonthlyCost = math.Round(total*100) / 100
+		return nil
+	})
+	g.Go(func() error {
+		name, err := s.repo.GetMostExpensiveActive(ctx, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get most expensive service: %w", err)
+		}
+		summary.MostExpensiveService = name
+		return nil
+	})
+	g.Go(func() error {
+		next, err := s.repo.GetNextExpiry(ctx, tenantID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get next expiry: %w", err)
+		}
+		summary.NextExpiry = next
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// GetTopServices ranks the services of userID by prorated spend between
+// filter's FromDate and ToDate and sums up the rest in Other.
+func (s *subscriptionService) GetTopServices(ctx context.Context, userID uuid.UUID, filter model.SubscriptionFilter, limit int) (*model.TopServices, error) {
+	filter.UserID = &userID
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	verr := &model.ValidationError{}
+	if limit < 1 || limit > maxTopServicesLimit {
+		verr.Add("limit", "must be between 1 and 50")
+	}
+	if filter.FromDate != nil && filter.ToDate != nil {
+		s.checkRangeCap(verr, *filter.FromDate, *filter.ToDate)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	costs, err := s.repo.GetCostByService(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost by service: %w", err)
+	}
+
+	top := &model.TopServices{Services: costs[:min(limit, len(costs))]}
+	for _, cost := range costs[len(top.Services):] {
+		top.Other.ServiceCount++
+		top.Other.Total += cost.Total
+	}
+
+	totals := make([]int, 0, len(top.Services)+1)
+	for _, cost := range top.Services {
+		totals = append(totals, cost.Total)
+	}
+	totals = append(totals, top.Other.Total)
+	for _, t := range totals {
+		top.Total += t
+	}
+
+	percents := percentages(totals, top.Total)
+	for i := range top.Services {
+		top.Services[i].Percent = percents[i]
+	}
+	top.Other.Percent = percents[len(percents)-1]
+
+	if top.Services == nil {
+		top.Services = []model.ServiceCost{}
+	}
+	return top, nil
+}
+
+// GetForecast projects the spending of userID over the next months
+// calendar months, the current one included. Subscriptions ending within
+// the window stop counting after their last month and ones starting later
+// count from their first.
+func (s *subscriptionService) GetForecast(ctx context.Context, userID uuid.UUID, months int) (*model.Forecast, error) {
+	if months < 1 || months > maxForecastMonths {
+		verr := &model.ValidationError{}
+		verr.Add("months", "must be between 1 and 36")
+		return nil, verr
+	}
+
+	now := s.now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, months-1, 0)
+	filter, err := scopeFilter(ctx, model.SubscriptionFilter{UserID: &userID, FromDate: &from, ToDate: &to})
+	if err != nil {
+		return nil, err
+	}
+
+	costs, err := s.repo.GetMonthlyCost(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate forecast: %w", err)
+	}
+
+	forecast := &model.Forecast{Months: costs}
+	for _, month := range costs {
+		forecast.Total += month.Total
+	}
+	if forecast.Months == nil {
+		forecast.Months = []model.MonthlyCost{}
+	}
+	return forecast, nil
+}
+
+// ProjectCosts projects the spending of userID over the next months
+// calendar months, starting with the one after the current. The
+// subscriptions are loaded once and every month is worked out from them.
+func (s *subscriptionService) ProjectCosts(ctx context.Context, userID uuid.UUID, months int) ([]model.ProjectedCost, error) {
+	if months < 1 || months > maxForecastMonths {
+		verr := &model.ValidationError{}
+		verr.Add("months", "must be between 1 and 36")
+		return nil, verr
+	}
+
+	now := s.now().UTC()
+	from := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, months, -1)
+	filter, err := scopeFilter(ctx, model.SubscriptionFilter{UserID: &userID, FromDate: &from, ToDate: &to})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return projectCosts(result.Items, from, months), nil
+}
+
+// projectCosts sums the monthly equivalents of subs for months calendar
+// months starting at from. A subscription counts in a month only if it
+// runs all of it: it started by the first day and does not end before the
+// last.
+func projectCosts(subs []*model.Subscription, from time.Time, months int) []model.ProjectedCost {
+	projection := make([]model.ProjectedCost, months)
+	for i := range projection {
+		first := from.AddDate(0, i, 0)
+		last := first.AddDate(0, 1, -1)
+
+		var total float64
+		for _, sub := range subs {
+			if sub.StartDate.After(first) || (sub.EndDate != nil && sub.EndDate.Before(last)) {
+				continue
+			}
+			total += sub.BillingCycle.MonthlyEquivalent(sub.Price)
+		}
+		projection[i] = model.ProjectedCost{
+			Month:         first.Format("2006-01"),
+			ProjectedCost: math.Round(total*100) / 100,
+		}
+	}
+	return projection
+}
+
+// percentages gives each of totals its share of sum in percent, to two
+// decimals. Rounding is by largest remainder, so the shares add up to
+// exactly 100 instead of drifting to 99.99 or 100.01. All shares of a zero
+// sum are 0.
+func percentages(totals []int, sum int) []float64 {
+	const scale = 100 * 100 // hundredths of a percent
+
+	percents := make([]float64, len(totals))
+	if sum == 0 {
+		return percents
+	}
+
+	units := make([]int, len(totals))
+	order := make([]int, len(totals))
+	left := scale
+	for i, t := range totals {
+		units[i] = t * scale / sum
+		left -= units[i]
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return totals[order[a]]*scale%sum > totals[order[b]]*scale%sum
+	})
+	for _, i := range order[:left] {
+		units[i]++
+	}
+
+	for i, u := range units {
+		percents[i] = float64(u) / 100
+	}
+	return percents
+}
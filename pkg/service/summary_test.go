@@ -0,0 +1,341 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+func TestGetUserSummary_RunsQueriesInParallel(t *testing.T) {
+	svc, mockRepo := newTestService()
+	userID := fixedUUID()
+	next := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	// Every query waits until all four have started, which only happens if
+	// they run concurrently.
+	var started sync.WaitGroup
+	started.Add(4)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+	barrier := func(mock.Arguments) {
+		started.Done()
+		select {
+		case <-allStarted:
+		case <-time.After(time.Second):
+			t.Error("repository calls did not run in parallel")
+		}
+	}
+
+	mockRepo.On("CountByStatus", mock.Anything, testTenantID, userID).Run(barrier).Return(5, 2, nil)
+	mockRepo.On("GetActiveCostByCycle", mock.Anything, testTenantID, userID).Run(barrier).Return([]model.BillingCycleSummary{
+		{BillingCycle: model.CycleMonthly, Total: 1300, Count: 4},
+		{BillingCycle: model.CycleAnnual, Total: 2400, Count: 1},
+	}, nil)
+	mockRepo.On("GetMostExpensiveActive", mock.Anything, testTenantID, userID).Run(barrier).Return("Netflix", nil)
+	mockRepo.On("GetNextExpiry", mock.Anything, testTenantID, userID).Run(barrier).Return(&next, nil)
+
+	summary, err := svc.GetUserSummary(testCtx(), userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.UserSummary{
+		ActiveCount:          5,
+		TotalMonthlyCost:     1500,
+		MostExpensiveService: "Netflix",
+		NextExpiry:           &next,
+		ExpiredCount:         2,
+	}, summary)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetUserSummary_NoSubscriptionsIsZero(t *testing.T) {
+	svc, mockRepo := newTestService()
+	userID := fixedUUID()
+
+	mockRepo.On("CountByStatus", mock.Anything, testTenantID, userID).Return(0, 0, nil)
+	mockRepo.On("GetActiveCostByCycle", mock.Anything, testTenantID, userID).Return([]model.BillingCycleSummary(nil), nil)
+	mockRepo.On("GetMostExpensiveActive", mock.Anything, testTenantID, userID).Return("", nil)
+	mockRepo.On("GetNextExpiry", mock.Anything, testTenantID, userID).Return((*time.Time)(nil), nil)
+
+	summary, err := svc.GetUserSummary(testCtx(), userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.UserSummary{}, summary)
+}
+
+func TestGetUserSummary_QueryError(t *testing.T) {
+	svc, mockRepo := newTestService()
+	userID := fixedUUID()
+
+	mockRepo.On("CountByStatus", mock.Anything, testTenantID, userID).Return(0, 0, nil)
+	mockRepo.On("GetActiveCostByCycle", mock.Anything, testTenantID, userID).Return([]model.BillingCycleSummary(nil), nil)
+	mockRepo.On("GetMostExpensiveActive", mock.Anything, testTenantID, userID).Return("", errors.New("connection reset"))
+	mockRepo.On("GetNextExpiry", mock.Anything, testTenantID, userID).Return((*time.Time)(nil), nil)
+
+	summary, err := svc.GetUserSummary(testCtx(), userID)
+
+	assert.Nil(t, summary)
+	assert.EqualError(t, err, "failed to get most expensive service: connection reset")
+}
+
+func TestGetTopServices_GroupsTheRestIntoOther(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+	userID := fixedUUID()
+	from := fixedTime()
+
+	mockRepo.On("GetCostByService", ctx, scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &from})).Return([]model.ServiceCost{
+		{ServiceName: "Netflix", Total: 500},
+		{ServiceName: "Spotify", Total: 300},
+		{ServiceName: "Yandex Plus", Total: 100},
+		{ServiceName: "iCloud", Total: 100},
+	}, nil)
+
+	top, err := s.GetTopServices(ctx, userID, model.SubscriptionFilter{FromDate: &from}, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ServiceCost{
+		{ServiceName: "Netflix", Total: 500, Percent: 50},
+		{ServiceName: "Spotify", Total: 300, Percent: 30},
+	}, top.Services)
+	assert.Equal(t, model.OtherServicesCost{ServiceCount: 2, Total: 200, Percent: 20}, top.Other)
+	assert.Equal(t, 1000, top.Total)
+}
+
+func TestGetTopServices_FewerThanLimit(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetCostByService", ctx, mock.Anything).Return([]model.ServiceCost{
+		{ServiceName: "Netflix", Total: 100},
+		{ServiceName: "Spotify", Total: 100},
+		{ServiceName: "iCloud", Total: 100},
+	}, nil)
+
+	top, err := s.GetTopServices(ctx, fixedUUID(), model.SubscriptionFilter{}, 5)
+
+	require.NoError(t, err)
+	require.Len(t, top.Services, 3)
+	assert.Equal(t, model.OtherServicesCost{}, top.Other)
+	var sum float64
+	for _, svc := range top.Services {
+		sum += svc.Percent
+	}
+	assert.InDelta(t, 100, sum, 1e-9, "thirds still add up to 100")
+}
+
+func TestGetTopServices_NoSpendingIsZero(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetCostByService", ctx, mock.Anything).Return([]model.ServiceCost(nil), nil)
+
+	top, err := s.GetTopServices(ctx, fixedUUID(), model.SubscriptionFilter{}, 5)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.TopServices{Services: []model.ServiceCost{}}, top)
+}
+
+func TestGetTopServices_Validation(t *testing.T) {
+	for _, limit := range []int{0, 51} {
+		s, mockRepo := newTestService()
+
+		_, err := s.GetTopServices(testCtx(), fixedUUID(), model.SubscriptionFilter{}, limit)
+
+		var verr *model.ValidationError
+		require.True(t, errors.As(err, &verr), "limit %d", limit)
+		assert.Equal(t, "must be between 1 and 50", verr.Fields["limit"])
+		assert.Empty(t, mockRepo.Calls)
+	}
+}
+
+func TestGetForecast_StartsWithCurrentMonth(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.now = func() time.Time { return time.Date(2025, 8, 20, 15, 0, 0, 0, time.UTC) }
+	ctx := testCtx()
+	userID := fixedUUID()
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	months := []model.MonthlyCost{
+		{Month: from, Total: 1500},
+		{Month: from.AddDate(0, 1, 0), Total: 901},
+	}
+	mockRepo.On("GetMonthlyCost", ctx, scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &from, ToDate: &to})).
+		Return(months, nil)
+
+	forecast, err := s.GetForecast(ctx, userID, 12)
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.Forecast{Months: months, Total: 2401}, forecast)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetForecast_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("GetMonthlyCost", ctx, mock.Anything).Return([]model.MonthlyCost(nil), errors.New("db error"))
+
+	forecast, err := s.GetForecast(ctx, fixedUUID(), 12)
+
+	assert.Nil(t, forecast)
+	assert.Contains(t, err.Error(), "failed to calculate forecast")
+}
+
+func TestGetForecast_Validation(t *testing.T) {
+	for _, months := range []int{0, 37} {
+		s, mockRepo := newTestService()
+
+		_, err := s.GetForecast(testCtx(), fixedUUID(), months)
+
+		var verr *model.ValidationError
+		require.True(t, errors.As(err, &verr), "months %d", months)
+		assert.Equal(t, "must be between 1 and 36", verr.Fields["months"])
+		assert.Empty(t, mockRepo.Calls)
+	}
+}
+
+func TestProjectCosts_LoadsTheWindowOnce(t *testing.T) {
+	s, mockRepo := newTestService()
+	s.now = func() time.Time { return time.Date(2025, 7, 20, 15, 0, 0, 0, time.UTC) }
+	ctx := testCtx()
+	userID := fixedUUID()
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.On("List", ctx, scoped(model.SubscriptionFilter{UserID: &userID, FromDate: &from, ToDate: &to})).
+		Return(&model.ListResult{Items: []*model.Subscription{
+			{Price: 599, StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), BillingCycle: model.CycleMonthly},
+		}}, nil).Once()
+
+	projection, err := s.ProjectCosts(ctx, userID, 6)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ProjectedCost{
+		{Month: "2025-08", ProjectedCost: 599},
+		{Month: "2025-09", ProjectedCost: 599},
+		{Month: "2025-10", ProjectedCost: 599},
+		{Month: "2025-11", ProjectedCost: 599},
+		{Month: "2025-12", ProjectedCost: 599},
+		{Month: "2026-01", ProjectedCost: 599},
+	}, projection)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProjectCosts_RepositoryError(t *testing.T) {
+	s, mockRepo := newTestService()
+	ctx := testCtx()
+
+	mockRepo.On("List", ctx, mock.Anything).Return((*model.ListResult)(nil), errors.New("db error"))
+
+	projection, err := s.ProjectCosts(ctx, fixedUUID(), 6)
+
+	assert.Nil(t, projection)
+	assert.Contains(t, err.Error(), "failed to list subscriptions")
+}
+
+func TestProjectCosts_Validation(t *testing.T) {
+	for _, months := range []int{0, 37} {
+		s, mockRepo := newTestService()
+
+		_, err := s.ProjectCosts(testCtx(), fixedUUID(), months)
+
+		var verr *model.ValidationError
+		require.True(t, errors.As(err, &verr), "months %d", months)
+		assert.Equal(t, "must be between 1 and 36", verr.Fields["months"])
+		assert.Empty(t, mockRepo.Calls)
+	}
+}
+
+func TestProjectCostsArithmetic(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	endOn := func(year int, month time.Month, day int) *time.Time {
+		d := date(year, month, day)
+		return &d
+	}
+	from := date(2025, 1, 1)
+
+	tests := []struct {
+		name string
+		sub  model.Subscription
+		want []float64 // Jan to Apr 2025
+	}{
+		{"running throughout", model.Subscription{Price: 100, StartDate: date(2024, 6, 15)}, []float64{100, 100, 100, 100}},
+		{"starts on the first", model.Subscription{Price: 100, StartDate: date(2025, 2, 1)}, []float64{0, 100, 100, 100}},
+		{"starts mid-month", model.Subscription{Price: 100, StartDate: date(2025, 2, 2)}, []float64{0, 0, 100, 100}},
+		{"ends on the last day", model.Subscription{Price: 100, StartDate: date(2024, 1, 1), EndDate: endOn(2025, 2, 28)}, []float64{100, 100, 0, 0}},
+		{"ends mid-month", model.Subscription{Price: 100, StartDate: date(2024, 1, 1), EndDate: endOn(2025, 2, 27)}, []float64{100, 0, 0, 0}},
+		{"ends on the 31st", model.Subscription{Price: 100, StartDate: date(2024, 1, 1), EndDate: endOn(2025, 3, 31)}, []float64{100, 100, 100, 0}},
+		{"shorter than a month", model.Subscription{Price: 100, StartDate: date(2025, 1, 10), EndDate: endOn(2025, 2, 10)}, []float64{0, 0, 0, 0}},
+		{"annual", model.Subscription{Price: 1200, StartDate: date(2024, 1, 1), BillingCycle: model.CycleAnnual}, []float64{100, 100, 100, 100}},
+		{"weekly", model.Subscription{Price: 100, StartDate: date(2024, 1, 1), BillingCycle: model.CycleWeekly}, []float64{433.33, 433.33, 433.33, 433.33}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.sub.BillingCycle == "" {
+				tt.sub.BillingCycle = model.CycleMonthly
+			}
+
+			projection := projectCosts([]*model.Subscription{&tt.sub}, from, 4)
+
+			got := make([]float64, len(projection))
+			for i, p := range projection {
+				got[i] = p.ProjectedCost
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "2025-04", projection[3].Month)
+		})
+	}
+}
+
+func TestProjectCosts_SumsSubscriptions(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	subs := []*model.Subscription{
+		{Price: 599, StartDate: from, BillingCycle: model.CycleMonthly},
+		{Price: 299, StartDate: from, EndDate: &end, BillingCycle: model.CycleMonthly},
+		{Price: 1000, StartDate: from, BillingCycle: model.CycleQuarterly},
+	}
+
+	assert.Equal(t, []model.ProjectedCost{
+		{Month: "2025-01", ProjectedCost: 1231.33},
+		{Month: "2025-02", ProjectedCost: 932.33},
+	}, projectCosts(subs, from, 2))
+}
+
+func TestPercentages(t *testing.T) {
+	tests := []struct {
+		name   string
+		totals []int
+		want   []float64
+	}{
+		{"exact", []int{1, 1, 2}, []float64{25, 25, 50}},
+		{"thirds", []int{1, 1, 1}, []float64{33.34, 33.33, 33.33}},
+		{"largest remainder wins", []int{2, 2, 3}, []float64{28.57, 28.57, 42.86}},
+		{"zero total", []int{0, 0}, []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := 0
+			for _, total := range tt.totals {
+				sum += total
+			}
+
+			assert.Equal(t, tt.want, percentages(tt.totals, sum))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// ErrNoTenant is returned when ctx carries no tenant. The tenant middleware
+// rejects such requests, so reaching the service without one means a route
+// was registered outside it.
+var ErrNoTenant = errors.New("request has no tenant")
+
+// tenantFrom returns the tenant the request was authenticated for.
+func tenantFrom(ctx context.Context) (uuid.UUID, error) {
+	tenantID, ok := ctxkey.Get[uuid.UUID](ctx, ctxkey.KeyTenantID)
+	if !ok || tenantID == uuid.Nil {
+		return uuid.Nil, ErrNoTenant
+	}
+	return tenantID, nil
+}
+
+// scopeFilter confines filter to the request's tenant, replacing whatever
+// the caller set.
+func scopeFilter(ctx context.Context, filter model.SubscriptionFilter) (model.SubscriptionFilter, error) {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return filter, err
+	}
+	filter.TenantID = &tenantID
+	return filter, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/ctxkey"
+	"SubscriptionAggregator/pkg/model"
+)
+
+// A call without a tenant must never reach the repository, where it could
+// only be answered with data of some tenant.
+func TestService_RequiresTenant(t *testing.T) {
+	userID := fixedUUID()
+	calls := map[string]func(s *subscriptionService, ctx context.Context) error{
+		"create": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.CreateSubscription(ctx, validCreateRequest())
+			return err
+		},
+		"get": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.GetSubscription(ctx, fixedUUID())
+			return err
+		},
+		"delete": func(s *subscriptionService, ctx context.Context) error {
+			return s.DeleteSubscription(ctx, fixedUUID())
+		},
+		"list": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.ListSubscriptions(ctx, model.SubscriptionFilter{})
+			return err
+		},
+		"total": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.GetTotalCost(ctx, TotalCostRequest{})
+			return err
+		},
+		"services": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.ListServices(ctx, nil)
+			return err
+		},
+		"shares": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.GetSharedUsers(ctx, fixedUUID())
+			return err
+		},
+		"cleanup": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.CleanupExpiredSubscriptions(ctx, userID)
+			return err
+		},
+		"summary": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.GetUserSummary(ctx, userID)
+			return err
+		},
+		"changes": func(s *subscriptionService, ctx context.Context) error {
+			_, err := s.SubscribeToChanges(ctx)
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			for _, ctx := range []context.Context{
+				context.Background(),
+				ctxkey.With(context.Background(), ctxkey.KeyTenantID, uuid.Nil),
+			} {
+				s, mockRepo := newTestService()
+
+				err := call(s, ctx)
+
+				assert.ErrorIs(t, err, ErrNoTenant)
+				mockRepo.AssertExpectations(t)
+				assert.Empty(t, mockRepo.Calls)
+			}
+		})
+	}
+}
+
+func TestListSubscriptions_OverridesCallerTenant(t *testing.T) {
+	s, mockRepo := newTestService()
+	other := uuid.New()
+
+	mockRepo.On("List", testCtx(), mock.MatchedBy(func(f model.SubscriptionFilter) bool {
+		return f.TenantID != nil && *f.TenantID == testTenantID
+	})).Return(&model.ListResult{}, nil)
+
+	_, err := s.ListSubscriptions(testCtx(), model.SubscriptionFilter{TenantID: &other})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateReminder_RequiresTenant(t *testing.T) {
+	s, mockRepo := newTestReminderService()
+
+	_, err := s.CreateReminder(context.Background(), CreateReminderRequest{SubscriptionID: fixedUUID(), RemindDaysBefore: 3})
+
+	assert.ErrorIs(t, err, ErrNoTenant)
+	assert.Empty(t, mockRepo.Calls)
+}
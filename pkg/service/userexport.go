@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/logger"
+	"SubscriptionAggregator/pkg/model"
+	"SubscriptionAggregator/pkg/repository"
+)
+
+// UserExportService gives users a machine-readable copy of their data.
+type UserExportService interface {
+	ExportUserData(ctx context.Context, req UserExportRequest, w UserDataWriter) error
+}
+
+// UserDataWriter receives an export as it is read: the subscriptions, then
+// their price history, then the audit trail. Close is called once all of
+// it was written and never after a failure, so an export cut short stays
+// recognisably incomplete.
+type UserDataWriter interface {
+	WriteSubscription(sub *model.Subscription) error
+	WritePriceChange(change model.PriceChange) error
+	WriteAuditEntry(entry model.AuditEntry) error
+	Close() error
+}
+
+// UserExportRequest names whose data is exported and, for the audit
+// trail, who asked: model.AuditActorUser or model.AuditActorAdmin.
+type UserExportRequest struct {
+	UserID    uuid.UUID
+	Actor     string
+	RequestID string
+}
+
+type userExportService struct {
+	repo  repository.SubscriptionRepository
+	audit repository.AuditRepository
+}
+
+func NewUserExportService(repo repository.SubscriptionRepository, audit repository.AuditRepository) UserExportService {
+	return &userExportService{repo: repo, audit: audit}
+}
+
+// ExportUserData streams the user's subscriptions, their price history and
+// the user's audit trail to w row by row, without holding them in memory.
+// The export is recorded in the audit trail once it is over, as completed
+// or, if it could not be read or written in full, as failed. The trail it
+// writes therefore does not include the export itself.
+func (s *userExportService) ExportUserData(ctx context.Context, req UserExportRequest, w UserDataWriter) error {
+	tenantID, err := tenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	subs, err := s.export(ctx, tenantID, req.UserID, w)
+
+	entry := &model.AuditEntry{
+		UserID:    req.UserID,
+		Actor:     req.Actor,
+		Action:    model.AuditActionExport,
+		RequestID: req.RequestID,
+		Status:    model.AuditStatusCompleted,
+	}
+	if err != nil {
+		entry.Status = model.AuditStatusFailed
+	}
+	// A client that went away cancels ctx, and what it got must still be
+	// recorded.
+	if auditErr := s.audit.Record(context.WithoutCancel(ctx), tenantID, entry); auditErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to record export: %w", auditErr))
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("user data exported",
+		slog.String("user_id", req.UserID.String()),
+		slog.String("actor", req.Actor),
+		slog.Int("subscriptions", subs),
+	)
+	return nil
+}
+
+// export writes every section to w and closes it, returning how many
+// subscriptions it wrote.
+func (s *userExportService) export(ctx context.Context, tenantID, userID uuid.UUID, w UserDataWriter) (int, error) {
+	var subs int
+	err := s.repo.StreamUserSubscriptions(ctx, tenantID, userID, func(sub *model.Subscription) error {
+		subs++
+		return w.WriteSubscription(sub)
+	})
+	if err != nil {
+		return subs, fmt.Errorf("failed to export subscriptions: %w", err)
+	}
+	if err := s.repo.StreamPriceHistoryByUser(ctx, tenantID, userID, w.WritePriceChange); err != nil {
+		return subs, fmt.Errorf("failed to export price history: %w", err)
+	}
+	if err := s.audit.StreamByUser(ctx, tenantID, userID, w.WriteAuditEntry); err != nil {
+		return subs, fmt.Errorf("failed to export audit trail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return subs, fmt.Errorf("failed to finish export: %w", err)
+	}
+	return subs, nil
+}
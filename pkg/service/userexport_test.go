@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Record(ctx context.Context, tenantID uuid.UUID, entry *model.AuditEntry) error {
+	args := m.Called(ctx, tenantID, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) StreamByUser(ctx context.Context, tenantID, userID uuid.UUID, fn func(model.AuditEntry) error) error {
+	args := m.Called(ctx, tenantID, userID, fn)
+	if rf, ok := args.Get(0).(func(func(model.AuditEntry) error) error); ok {
+		return rf(fn)
+	}
+	return args.Error(0)
+}
+
+// streamRows is a mock answer to a Stream method that hands it every row.
+func streamRows[T any](rows ...T) func(fn func(T) error) error {
+	return func(fn func(T) error) error {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// streams answers the mocked repository's Stream methods with rf.
+func streams[T any](rf func(fn func(T) error) error) func(context.Context, uuid.UUID, uuid.UUID, func(T) error) error {
+	return func(_ context.Context, _, _ uuid.UUID, fn func(T) error) error { return rf(fn) }
+}
+
+// exportRecorder collects what it is written, failing with err once it
+// has been written failAfter rows.
+type exportRecorder struct {
+	model.UserDataExport
+	failAfter int
+	err       error
+	closed    bool
+}
+
+func (r *exportRecorder) write() error {
+	if r.err != nil && len(r.Subscriptions)+len(r.PriceHistory)+len(r.AuditLog) >= r.failAfter {
+		return r.err
+	}
+	return nil
+}
+
+func (r *exportRecorder) WriteSubscription(sub *model.Subscription) error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	r.Subscriptions = append(r.Subscriptions, sub)
+	return nil
+}
+
+func (r *exportRecorder) WritePriceChange(change model.PriceChange) error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	r.PriceHistory = append(r.PriceHistory, change)
+	return nil
+}
+
+func (r *exportRecorder) WriteAuditEntry(entry model.AuditEntry) error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	r.AuditLog = append(r.AuditLog, entry)
+	return nil
+}
+
+func (r *exportRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func newTestUserExportService() (UserExportService, *mocks.SubscriptionRepository, *MockAuditRepository) {
+	repo, audit := &mocks.SubscriptionRepository{}, &MockAuditRepository{}
+	return NewUserExportService(repo, audit), repo, audit
+}
+
+// auditedAs matches the export entry of the request ExportUserData is
+// given in these tests, recorded with status.
+func auditedAs(userID uuid.UUID, status string) any {
+	return mock.MatchedBy(func(e *model.AuditEntry) bool {
+		return e.UserID == userID && e.Actor == model.AuditActorUser && e.Action == model.AuditActionExport &&
+			e.RequestID == "req-1" && e.Status == status
+	})
+}
+
+func TestExportUserData_StreamsEverything(t *testing.T) {
+	s, repo, audit := newTestUserExportService()
+	ctx := testCtx()
+	userID := fixedUUID()
+	sub := &model.Subscription{ID: uuid.New(), UserID: userID, ServiceName: "netflix", Price: 799}
+	history := []model.PriceChange{{SubscriptionID: sub.ID, Price: 599, RecordedAt: fixedTime()}, {SubscriptionID: sub.ID, Price: 799, RecordedAt: fixedTime()}}
+	trail := []model.AuditEntry{{ID: 17, UserID: userID, Actor: model.AuditActorUser, Action: model.AuditActionExport, Status: model.AuditStatusCompleted, CreatedAt: fixedTime()}}
+	out := &exportRecorder{}
+
+	repo.On("StreamUserSubscriptions", ctx, testTenantID, userID, mock.Anything).Return(streams(streamRows(sub)))
+	repo.On("StreamPriceHistoryByUser", ctx, testTenantID, userID, mock.Anything).Return(streams(streamRows(history...)))
+	audit.On("StreamByUser", ctx, testTenantID, userID, mock.Anything).Return(streamRows(trail...))
+	audit.On("Record", mock.Anything, testTenantID, auditedAs(userID, model.AuditStatusCompleted)).Run(func(mock.Arguments) {
+		assert.True(t, out.closed, "the export is recorded once it is over")
+	}).Return(nil)
+
+	err := s.ExportUserData(ctx, UserExportRequest{UserID: userID, Actor: model.AuditActorUser, RequestID: "req-1"}, out)
+
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Subscription{sub}, out.Subscriptions)
+	assert.Equal(t, history, out.PriceHistory)
+	assert.Equal(t, trail, out.AuditLog)
+	audit.AssertExpectations(t)
+}
+
+func TestExportUserData_EmptySections(t *testing.T) {
+	s, repo, audit := newTestUserExportService()
+	ctx := testCtx()
+	out := &exportRecorder{}
+
+	repo.On("StreamUserSubscriptions", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	repo.On("StreamPriceHistoryByUser", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	audit.On("StreamByUser", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	audit.On("Record", mock.Anything, testTenantID, mock.Anything).Return(nil)
+
+	err := s.ExportUserData(ctx, UserExportRequest{UserID: fixedUUID(), Actor: model.AuditActorAdmin}, out)
+
+	require.NoError(t, err)
+	assert.True(t, out.closed)
+}
+
+func TestExportUserData_ReadFailureIsRecorded(t *testing.T) {
+	s, repo, audit := newTestUserExportService()
+	ctx := testCtx()
+	out := &exportRecorder{}
+
+	repo.On("StreamUserSubscriptions", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	repo.On("StreamPriceHistoryByUser", ctx, testTenantID, fixedUUID(), mock.Anything).Return(errors.New("connection reset"))
+	audit.On("Record", mock.Anything, testTenantID, auditedAs(fixedUUID(), model.AuditStatusFailed)).Return(nil)
+
+	err := s.ExportUserData(ctx, UserExportRequest{UserID: fixedUUID(), Actor: model.AuditActorUser, RequestID: "req-1"}, out)
+
+	assert.EqualError(t, err, "failed to export price history: connection reset")
+	assert.False(t, out.closed, "an export cut short is not closed")
+	audit.AssertNotCalled(t, "StreamByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	audit.AssertExpectations(t)
+}
+
+func TestExportUserData_WriteFailureIsRecorded(t *testing.T) {
+	s, repo, audit := newTestUserExportService()
+	ctx := testCtx()
+	broken := errors.New("broken pipe")
+	out := &exportRecorder{failAfter: 1, err: broken}
+	subs := []*model.Subscription{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	repo.On("StreamUserSubscriptions", ctx, testTenantID, fixedUUID(), mock.Anything).Return(streams(streamRows(subs...)))
+	audit.On("Record", mock.Anything, testTenantID, auditedAs(fixedUUID(), model.AuditStatusFailed)).Return(nil)
+
+	err := s.ExportUserData(ctx, UserExportRequest{UserID: fixedUUID(), Actor: model.AuditActorUser, RequestID: "req-1"}, out)
+
+	assert.ErrorIs(t, err, broken)
+	assert.Len(t, out.Subscriptions, 1)
+	repo.AssertNotCalled(t, "StreamPriceHistoryByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	audit.AssertExpectations(t)
+}
+
+func TestExportUserData_RecordedAfterClientLeft(t *testing.T) {
+	s, repo, audit := newTestUserExportService()
+	ctx, cancel := context.WithCancel(testCtx())
+	cancel()
+
+	repo.On("StreamUserSubscriptions", ctx, testTenantID, fixedUUID(), mock.Anything).Return(context.Canceled)
+	audit.On("Record", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), testTenantID,
+		auditedAs(fixedUUID(), model.AuditStatusFailed)).Return(nil)
+
+	err := s.ExportUserData(ctx, UserExportRequest{UserID: fixedUUID(), Actor: model.AuditActorUser, RequestID: "req-1"}, &exportRecorder{})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	audit.AssertExpectations(t)
+}
+
+func TestExportUserData_RecordError(t *testing.T) {
+	s, repo, audit := newTestUserExportService()
+	ctx := testCtx()
+
+	repo.On("StreamUserSubscriptions", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	repo.On("StreamPriceHistoryByUser", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	audit.On("StreamByUser", ctx, testTenantID, fixedUUID(), mock.Anything).Return(nil)
+	audit.On("Record", mock.Anything, testTenantID, mock.Anything).Return(errors.New("db error"))
+
+	err := s.ExportUserData(ctx, UserExportRequest{UserID: fixedUUID(), Actor: model.AuditActorUser}, &exportRecorder{})
+
+	assert.EqualError(t, err, "failed to record export: db error")
+}
+
+func TestExportUserData_NoTenant(t *testing.T) {
+	s, _, audit := newTestUserExportService()
+
+	err := s.ExportUserData(context.Background(), UserExportRequest{UserID: fixedUUID()}, &exportRecorder{})
+
+	assert.ErrorIs(t, err, ErrNoTenant)
+	audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything, mock.Anything)
+}
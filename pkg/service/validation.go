@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+const (
+	// DefaultMaxPrice is used when the service is built without WithMaxPrice.
+	DefaultMaxPrice = 1_000_000
+	// DefaultMaxTotalRangeYears is used when the service is built without
+	// WithMaxTotalRange.
+	DefaultMaxTotalRangeYears = 5
+
+	maxServiceNameLength = 255
+)
+
+// WithMaxPrice sets the exclusive upper bound for subscription prices.
+func WithMaxPrice(max int) ServiceOption {
+	return func(s *subscriptionService) {
+		s.maxPrice = max
+	}
+}
+
+// WithMaxTotalRange caps the from_date to to_date span, in years, that
+// GetTotalCost, GetTeamTotalCost, GetMonthlyCost, GetTotalCostByUser and
+// GetTopServices accept.
+func WithMaxTotalRange(years int) ServiceOption {
+	return func(s *subscriptionService) {
+		s.maxTotalRangeYears = years
+	}
+}
+
+// validateTotalRequest is SubscriptionFilter.Validate plus the source, the mode and the
+// range cap, which keeps a total over decades from scanning the whole
+// table. An open-ended range is not capped. A total of payments has no
+// mode.
+func (s *subscriptionService) validateTotalRequest(req TotalCostRequest) error {
+	if err := req.Filter.Validate(); err != nil {
+		return err
+	}
+
+	verr := &model.ValidationError{}
+	switch {
+	case !req.Source.Valid():
+		verr.Add("source", "must be prices or billing_history")
+	case req.Source == model.TotalFromBillingHistory:
+		if req.Mode != "" {
+			verr.Add("mode", "does not apply to source=billing_history")
+		}
+	case !req.Mode.Valid():
+		verr.Add("mode", "must be prorated or flat")
+	}
+	filter := req.Filter
+	if filter.FromDate != nil && filter.ToDate != nil {
+		s.checkRangeCap(verr, *filter.FromDate, *filter.ToDate)
+	}
+	return verr.OrNil()
+}
+
+// validateMonthlyFilter is SubscriptionFilter.Validate plus the bounds of the series:
+// every month between from_date and to_date becomes an entry, so both are
+// required and the range is capped like a total's.
+func (s *subscriptionService) validateMonthlyFilter(filter model.SubscriptionFilter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	verr := &model.ValidationError{}
+	if filter.FromDate == nil {
+		verr.Add("from_date", "must not be empty")
+	} else if filter.ToDate != nil {
+		s.checkRangeCap(verr, *filter.FromDate, *filter.ToDate)
+	}
+	return verr.OrNil()
+}
+
+func (s *subscriptionService) checkRangeCap(verr *model.ValidationError, from, to time.Time) {
+	if to.After(from.AddDate(s.maxTotalRangeYears, 0, 0)) {
+		verr.Add("to_date", "must be at most "+strconv.Itoa(s.maxTotalRangeYears)+
+			" years after from_date; split the period into several requests")
+	}
+}
+
+// validateSubscription checks the fields shared by create and update so that
+// a PUT cannot store what a POST would reject.
+func (s *subscriptionService) validateSubscription(serviceName string, price int, userID uuid.UUID, start time.Time, end *time.Time, cycle model.BillingCycle, metadata json.RawMessage) error {
+	verr := &model.ValidationError{}
+
+	switch {
+	case serviceName == "":
+		verr.Add("service_name", "must not be empty")
+	case utf8.RuneCountInString(serviceName) > maxServiceNameLength:
+		verr.Add("service_name", "must be at most 255 characters")
+	}
+
+	s.checkPrice(verr, price)
+
+	if userID == uuid.Nil {
+		verr.Add("user_id", "must not be empty")
+	}
+
+	if start.IsZero() {
+		verr.Add("start_date", "must not be empty")
+	} else if end != nil && !end.After(start) {
+		verr.Add("end_date", "must be after start_date")
+	}
+
+	if !cycle.Valid() {
+		verr.Add("billing_cycle", "must be one of weekly, monthly, quarterly, annual")
+	}
+
+	if metadata != nil && !json.Valid(metadata) {
+		verr.Add("metadata", "must be valid JSON")
+	}
+
+	return verr.OrNil()
+}
+
+// checkPrice adds to verr what is wrong with price, if anything.
+func (s *subscriptionService) checkPrice(verr *model.ValidationError, price int) {
+	switch {
+	case price <= 0:
+		verr.Add("price", "must be positive")
+	case price >= s.maxPrice:
+		verr.Add("price", "must be less than "+strconv.Itoa(s.maxPrice))
+	}
+}
+
+// validateShareTargets checks the users a new subscription of owner is
+// shared with. Each may appear once and none may be the owner.
+func validateShareTargets(owner uuid.UUID, targets []ShareSubscriptionRequest) error {
+	verr := &model.ValidationError{}
+	seen := make(map[uuid.UUID]bool, len(targets))
+
+	for i, target := range targets {
+		field := "share_with[" + strconv.Itoa(i) + "]"
+		switch {
+		case target.UserID == uuid.Nil:
+			verr.Add(field+".user_id", "must not be empty")
+		case target.UserID == owner:
+			verr.Add(field+".user_id", "must not be the owner")
+		case seen[target.UserID]:
+			verr.Add(field+".user_id", "listed more than once")
+		}
+		seen[target.UserID] = true
+
+		if target.Permission != "" && !target.Permission.Valid() {
+			verr.Add(field+".permission", "must be read or write")
+		}
+	}
+
+	return verr.OrNil()
+}
+
+// validateClientID rejects the nil UUID as a client-chosen subscription ID;
+// a missing ID is fine and is generated, see newSubscriptionID.
+func validateClientID(id *uuid.UUID) error {
+	if id != nil && *id == uuid.Nil {
+		verr := &model.ValidationError{}
+		verr.Add("id", "must not be the nil UUID")
+		return verr
+	}
+	return nil
+}
+
+// newSubscriptionID is the client's ID if it chose one and a fresh one
+// otherwise.
+func newSubscriptionID(id *uuid.UUID) uuid.UUID {
+	if id != nil {
+		return *id
+	}
+	return uuid.New()
+}
+
+// metadataOrNil treats a JSON null like a missing field, so that it is
+// stored as SQL NULL rather than as a jsonb null.
+func metadataOrNil(metadata json.RawMessage) json.RawMessage {
+	if bytes.Equal(bytes.TrimSpace(metadata), []byte("null")) {
+		return nil
+	}
+	return metadata
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/mocks"
+	"SubscriptionAggregator/pkg/model"
+)
+
+func validCreateRequest() CreateSubscriptionRequest {
+	return CreateSubscriptionRequest{
+		ServiceName: "Yandex Plus",
+		Price:       599,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+	}
+}
+
+func TestCreateSubscription_Validation(t *testing.T) {
+	before := fixedTime().AddDate(-1, 0, 0)
+	same := fixedTime()
+
+	tests := []struct {
+		name   string
+		modify func(*CreateSubscriptionRequest)
+		field  string
+	}{
+		{"negative price", func(r *CreateSubscriptionRequest) { r.Price = -500 }, "price"},
+		{"zero price", func(r *CreateSubscriptionRequest) { r.Price = 0 }, "price"},
+		{"price at max", func(r *CreateSubscriptionRequest) { r.Price = DefaultMaxPrice }, "price"},
+		{"empty service name", func(r *CreateSubscriptionRequest) { r.ServiceName = "" }, "service_name"},
+		{"long service name", func(r *CreateSubscriptionRequest) { r.ServiceName = strings.Repeat("я", 256) }, "service_name"},
+		{"zero user id", func(r *CreateSubscriptionRequest) { r.UserID = uuid.Nil }, "user_id"},
+		{"zero start date", func(r *CreateSubscriptionRequest) { r.StartDate = time.Time{} }, "start_date"},
+		{"end before start", func(r *CreateSubscriptionRequest) { r.EndDate = &before }, "end_date"},
+		{"end equals start", func(r *CreateSubscriptionRequest) { r.EndDate = &same }, "end_date"},
+		{"unknown billing cycle", func(r *CreateSubscriptionRequest) { r.BillingCycle = "daily" }, "billing_cycle"},
+		{"malformed metadata", func(r *CreateSubscriptionRequest) { r.Metadata = json.RawMessage(`{"card": `) }, "metadata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mockRepo := newTestService()
+			req := validCreateRequest()
+			tt.modify(&req)
+
+			sub, err := s.CreateSubscription(testCtx(), req)
+
+			assert.Nil(t, sub)
+			require.ErrorIs(t, err, model.ErrValidation)
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr))
+			assert.Contains(t, verr.Fields, tt.field)
+			assert.Len(t, verr.Fields, 1)
+			mockRepo.AssertNotCalled(t, "Create")
+		})
+	}
+}
+
+func TestCreateSubscription_ValidationMaxPriceOption(t *testing.T) {
+	s := NewSubscriptionService(&mocks.SubscriptionRepository{}, slog.New(slog.NewTextHandler(io.Discard, nil)), WithMaxPrice(1000))
+	req := validCreateRequest()
+	req.Price = 1000
+
+	_, err := s.CreateSubscription(testCtx(), req)
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+	assert.Contains(t, err.Error(), "price: must be less than 1000")
+}
+
+func TestUpdateSubscription_Validation(t *testing.T) {
+	s, mockRepo := newTestService()
+	before := fixedTime().AddDate(-1, 0, 0)
+
+	sub, err := s.UpdateSubscription(testCtx(), UpdateSubscriptionRequest{
+		ID:          fixedUUID(),
+		ServiceName: "Yandex Plus",
+		Price:       -500,
+		UserID:      fixedUUID(),
+		StartDate:   fixedTime(),
+		EndDate:     &before,
+	})
+
+	assert.Nil(t, sub)
+	var verr *model.ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, map[string]string{
+		"price":    "must be positive",
+		"end_date": "must be after start_date",
+	}, verr.Fields)
+	mockRepo.AssertNotCalled(t, "Update")
+}
+
+func TestFilterMethods_RejectReversedRange(t *testing.T) {
+	svc, mockRepo := newTestService()
+	ctx := testCtx()
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := model.SubscriptionFilter{FromDate: &from, ToDate: &to}
+
+	_, listErr := svc.ListSubscriptions(ctx, filter)
+	_, totalErr := svc.GetTotalCost(ctx, TotalCostRequest{Filter: filter})
+	_, expiredErr := svc.ListExpiredSubscriptions(ctx, filter)
+	_, cycleErr := svc.GetCostByCycle(ctx, filter)
+
+	for _, err := range []error{listErr, totalErr, expiredErr, cycleErr} {
+		var verr *model.ValidationError
+		require.True(t, errors.As(err, &verr), "got %v", err)
+		assert.Equal(t, "must not be after to_date", verr.Fields["from_date"])
+	}
+	assert.Empty(t, mockRepo.Calls)
+}
+
+func TestListSubscriptions_PinnedOnlyRequiresUser(t *testing.T) {
+	svc, mockRepo := newTestService()
+
+	_, err := svc.ListSubscriptions(testCtx(), model.SubscriptionFilter{PinnedOnly: true})
+
+	var verr *model.ValidationError
+	require.True(t, errors.As(err, &verr), "got %v", err)
+	assert.Equal(t, "requires user_id", verr.Fields["pinned_only"])
+	assert.Empty(t, mockRepo.Calls)
+}
+
+func TestListSubscriptions_PinsAndSharingNeedASingleUser(t *testing.T) {
+	svc, mockRepo := newTestService()
+	userID := uuid.New()
+
+	_, err := svc.ListSubscriptions(testCtx(), model.SubscriptionFilter{
+		UserID:       &userID,
+		UserIDs:      []uuid.UUID{uuid.New()},
+		PinnedOnly:   true,
+		SharedWithMe: true,
+	})
+
+	var verr *model.ValidationError
+	require.True(t, errors.As(err, &verr), "got %v", err)
+	assert.Equal(t, "requires a single user_id", verr.Fields["pinned_only"])
+	assert.Equal(t, "requires a single user_id", verr.Fields["shared_with_me"])
+	assert.Empty(t, mockRepo.Calls)
+}
+
+func TestGetTotalCost_RangeCap(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		to      time.Time
+		wantErr bool
+	}{
+		{"exactly the cap", from.AddDate(DefaultMaxTotalRangeYears, 0, 0), false},
+		{"one day over", from.AddDate(DefaultMaxTotalRangeYears, 0, 1), true},
+		{"fifty years", from.AddDate(50, 0, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mockRepo := newTestService()
+			filter := model.SubscriptionFilter{FromDate: &from, ToDate: &tt.to}
+			mockRepo.On("GetProratedTotalCost", testCtx(), scoped(filter)).Return(100, nil).Maybe()
+
+			_, err := svc.GetTotalCost(testCtx(), TotalCostRequest{Filter: filter})
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr))
+			assert.Contains(t, verr.Fields["to_date"], "at most 5 years after from_date")
+			mockRepo.AssertNotCalled(t, "GetProratedTotalCost", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGetMonthlyCost_Validation(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tooFar := from.AddDate(DefaultMaxTotalRangeYears, 1, 0)
+
+	tests := []struct {
+		name   string
+		filter model.SubscriptionFilter
+		field  string
+	}{
+		{"no from_date", model.SubscriptionFilter{ToDate: &from}, "from_date"},
+		{"over the cap", model.SubscriptionFilter{FromDate: &from, ToDate: &tooFar}, "to_date"},
+		{"open end over the cap", model.SubscriptionFilter{FromDate: &from}, "to_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mockRepo := newTestService()
+			svc.now = func() time.Time { return tooFar }
+
+			_, err := svc.GetMonthlyCost(testCtx(), tt.filter)
+
+			var verr *model.ValidationError
+			require.True(t, errors.As(err, &verr), "got %v", err)
+			assert.Contains(t, verr.Fields, tt.field)
+			assert.Empty(t, mockRepo.Calls)
+		})
+	}
+}
+
+func TestWithMaxTotalRange(t *testing.T) {
+	mockRepo := &mocks.SubscriptionRepository{}
+	svc := NewSubscriptionService(mockRepo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithMaxTotalRange(1))
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(2, 0, 0)
+
+	_, err := svc.GetTotalCost(testCtx(), TotalCostRequest{
+		Filter: model.SubscriptionFilter{FromDate: &from, ToDate: &to},
+	})
+
+	assert.ErrorIs(t, err, model.ErrValidation)
+}
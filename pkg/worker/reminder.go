@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// DefaultReminderInterval is how often ReminderWorker looks for due reminders.
+const DefaultReminderInterval = time.Hour
+
+// Notifier delivers a reminder to the subscription's owner.
+type Notifier interface {
+	Notify(ctx context.Context, reminder model.PendingReminder) error
+}
+
+// ReminderStore is the part of repository.ReminderRepository the worker needs.
+type ReminderStore interface {
+	ListPending(ctx context.Context, day time.Time) ([]model.PendingReminder, error)
+	MarkReminded(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// LogNotifier only logs reminders; it is used until a real delivery channel
+// such as email is configured.
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, reminder model.PendingReminder) error {
+	n.log.Info("subscription reminder",
+		slog.String("reminder_id", reminder.Reminder.ID.String()),
+		slog.String("subscription_id", reminder.Subscription.ID.String()),
+		slog.String("user_id", reminder.Subscription.UserID.String()),
+		slog.String("service_name", reminder.Subscription.ServiceName),
+		slog.Int("days_before", reminder.Reminder.RemindDaysBefore),
+	)
+	return nil
+}
+
+// ReminderWorker periodically notifies users whose reminders fall due today.
+// A reminder is marked after a successful notification so restarts and
+// repeated ticks on the same day do not send it twice.
+type ReminderWorker struct {
+	store    ReminderStore
+	notifier Notifier
+	log      *slog.Logger
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewReminderWorker(store ReminderStore, notifier Notifier, log *slog.Logger, interval time.Duration) *ReminderWorker {
+	if interval <= 0 {
+		interval = DefaultReminderInterval
+	}
+	return &ReminderWorker{
+		store:    store,
+		notifier: notifier,
+		log:      log,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run checks for due reminders immediately and then every interval until
+// ctx is done.
+func (w *ReminderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce notifies every reminder due today and returns how many were sent.
+func (w *ReminderWorker) RunOnce(ctx context.Context) int {
+	now := w.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	pending, err := w.store.ListPending(ctx, today)
+	if err != nil {
+		w.log.Error("failed to list pending reminders", slog.String("error", err.Error()))
+		return 0
+	}
+
+	sent := 0
+	for _, p := range pending {
+		if p.Subscription.EndDate == nil || !p.Reminder.DueAt(*p.Subscription.EndDate).Equal(today) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := w.notifier.Notify(ctx, p); err != nil {
+			w.log.Error("failed to send reminder",
+				slog.String("reminder_id", p.Reminder.ID.String()),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		if err := w.store.MarkReminded(ctx, p.Reminder.ID, now); err != nil {
+			w.log.Error("failed to mark reminder as sent",
+				slog.String("reminder_id", p.Reminder.ID.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		w.log.Info("reminders sent", slog.Int("count", sent))
+	}
+	return sent
+}
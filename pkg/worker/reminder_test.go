@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockReminderStore struct {
+	mock.Mock
+}
+
+func (m *MockReminderStore) ListPending(ctx context.Context, day time.Time) ([]model.PendingReminder, error) {
+	args := m.Called(ctx, day)
+	return args.Get(0).([]model.PendingReminder), args.Error(1)
+}
+
+func (m *MockReminderStore) MarkReminded(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, reminder model.PendingReminder) error {
+	args := m.Called(ctx, reminder)
+	return args.Error(0)
+}
+
+func newTestWorker(now time.Time) (*ReminderWorker, *MockReminderStore, *MockNotifier) {
+	store := &MockReminderStore{}
+	notifier := &MockNotifier{}
+	w := NewReminderWorker(store, notifier, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour)
+	w.now = func() time.Time { return now }
+	return w, store, notifier
+}
+
+func pendingReminder(daysBefore int, end time.Time) model.PendingReminder {
+	subID := uuid.New()
+	return model.PendingReminder{
+		Reminder:     model.Reminder{ID: uuid.New(), SubscriptionID: subID, RemindDaysBefore: daysBefore},
+		Subscription: model.Subscription{ID: subID, ServiceName: "Netflix", EndDate: &end},
+	}
+}
+
+func TestReminderWorker_NotifiesOnlyDueReminders(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	w, store, notifier := newTestWorker(now)
+
+	due := pendingReminder(3, time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC))
+	dueOnEndDay := pendingReminder(0, time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC))
+	past := pendingReminder(5, time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC))
+	future := pendingReminder(1, time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC))
+
+	store.On("ListPending", mock.Anything, today).
+		Return([]model.PendingReminder{due, past, future, dueOnEndDay}, nil)
+	notifier.On("Notify", mock.Anything, due).Return(nil)
+	notifier.On("Notify", mock.Anything, dueOnEndDay).Return(nil)
+	store.On("MarkReminded", mock.Anything, due.Reminder.ID, now).Return(nil)
+	store.On("MarkReminded", mock.Anything, dueOnEndDay.Reminder.ID, now).Return(nil)
+
+	sent := w.RunOnce(context.Background())
+
+	assert.Equal(t, 2, sent)
+	notifier.AssertNotCalled(t, "Notify", mock.Anything, past)
+	notifier.AssertNotCalled(t, "Notify", mock.Anything, future)
+	notifier.AssertExpectations(t)
+	store.AssertExpectations(t)
+}
+
+func TestReminderWorker_FailedNotificationIsNotMarked(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	w, store, notifier := newTestWorker(now)
+
+	due := pendingReminder(3, time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC))
+	store.On("ListPending", mock.Anything, mock.Anything).Return([]model.PendingReminder{due}, nil)
+	notifier.On("Notify", mock.Anything, due).Return(errors.New("smtp down"))
+
+	sent := w.RunOnce(context.Background())
+
+	assert.Equal(t, 0, sent)
+	store.AssertNotCalled(t, "MarkReminded", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReminderWorker_StoreError(t *testing.T) {
+	w, store, notifier := newTestWorker(time.Now())
+
+	store.On("ListPending", mock.Anything, mock.Anything).Return([]model.PendingReminder(nil), errors.New("db error"))
+
+	assert.Equal(t, 0, w.RunOnce(context.Background()))
+	notifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+}
+
+func TestReminderWorker_RunStopsWithContext(t *testing.T) {
+	w, store, _ := newTestWorker(time.Now())
+	store.On("ListPending", mock.Anything, mock.Anything).Return([]model.PendingReminder(nil), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop after context cancellation")
+	}
+}
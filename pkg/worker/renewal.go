@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+// DefaultRenewalInterval is how often RenewalProcessor looks for due
+// renewals when renewal.interval is not set.
+const DefaultRenewalInterval = 6 * time.Hour
+
+// RenewalStore is repository.RenewalRepository.
+type RenewalStore interface {
+	ListDue(ctx context.Context, day time.Time) ([]model.AutoRenewalConfig, error)
+	RenewSubscription(ctx context.Context, cfg model.AutoRenewalConfig) error
+}
+
+// RenewalProcessor periodically renews the subscriptions whose auto-renewal
+// falls due. A renewal missed while the service was down is caught up on
+// the next run, one period at a time, until the next one lies ahead.
+type RenewalProcessor struct {
+	store    RenewalStore
+	log      *slog.Logger
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewRenewalProcessor(store RenewalStore, log *slog.Logger, interval time.Duration) *RenewalProcessor {
+	if interval <= 0 {
+		interval = DefaultRenewalInterval
+	}
+	return &RenewalProcessor{
+		store:    store,
+		log:      log,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run renews what is due immediately and then every interval until ctx is
+// done.
+func (p *RenewalProcessor) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce renews every subscription due today or earlier and returns how
+// many renewals were made.
+func (p *RenewalProcessor) RunOnce(ctx context.Context) int {
+	now := p.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	due, err := p.store.ListDue(ctx, today)
+	if err != nil {
+		p.log.Error("failed to list due renewals", slog.String("error", err.Error()))
+		return 0
+	}
+
+	renewed := 0
+	for _, cfg := range due {
+		for cfg.DueOn(today) && ctx.Err() == nil {
+			if err := p.store.RenewSubscription(ctx, cfg); err != nil {
+				// ErrNotFound: renewed by another instance, disabled or
+				// deleted since it was listed.
+				if !errors.Is(err, model.ErrNotFound) {
+					p.log.Error("failed to renew subscription",
+						slog.String("subscription_id", cfg.SubscriptionID.String()),
+						slog.String("error", err.Error()),
+					)
+				}
+				break
+			}
+			renewed++
+			cfg = cfg.Renewed()
+		}
+	}
+
+	if renewed > 0 {
+		p.log.Info("subscriptions renewed", slog.Int("count", renewed))
+	}
+	return renewed
+}
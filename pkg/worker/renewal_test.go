@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type MockRenewalStore struct {
+	mock.Mock
+}
+
+func (m *MockRenewalStore) ListDue(ctx context.Context, day time.Time) ([]model.AutoRenewalConfig, error) {
+	args := m.Called(ctx, day)
+	return args.Get(0).([]model.AutoRenewalConfig), args.Error(1)
+}
+
+func (m *MockRenewalStore) RenewSubscription(ctx context.Context, cfg model.AutoRenewalConfig) error {
+	args := m.Called(ctx, cfg)
+	return args.Error(0)
+}
+
+func newTestRenewalProcessor(now time.Time) (*RenewalProcessor, *MockRenewalStore) {
+	store := &MockRenewalStore{}
+	p := NewRenewalProcessor(store, slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour)
+	p.now = func() time.Time { return now }
+	return p, store
+}
+
+func renewalDueOn(day time.Time, days int) model.AutoRenewalConfig {
+	return model.AutoRenewalConfig{SubscriptionID: uuid.New(), Enabled: true, RenewalDurationDays: days, NextRenewalDate: day}
+}
+
+func TestRenewalProcessor_RenewsOnlyDueSubscriptions(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	p, store := newTestRenewalProcessor(now)
+
+	dueToday := renewalDueOn(today, 30)
+	dueYesterday := renewalDueOn(today.AddDate(0, 0, -1), 30)
+	future := renewalDueOn(today.AddDate(0, 0, 1), 30)
+	disabled := renewalDueOn(today, 30)
+	disabled.Enabled = false
+
+	store.On("ListDue", mock.Anything, today).
+		Return([]model.AutoRenewalConfig{dueYesterday, dueToday, future, disabled}, nil)
+	store.On("RenewSubscription", mock.Anything, dueYesterday).Return(nil)
+	store.On("RenewSubscription", mock.Anything, dueToday).Return(nil)
+
+	renewed := p.RunOnce(context.Background())
+
+	assert.Equal(t, 2, renewed)
+	store.AssertNotCalled(t, "RenewSubscription", mock.Anything, future)
+	store.AssertNotCalled(t, "RenewSubscription", mock.Anything, disabled)
+	store.AssertExpectations(t)
+}
+
+func TestRenewalProcessor_CatchesUpMissedPeriods(t *testing.T) {
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	p, store := newTestRenewalProcessor(today)
+
+	// Weekly renewals missed on Feb 24 and Mar 3; Mar 10 is due as well,
+	// Mar 17 is not.
+	missed := renewalDueOn(time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC), 7)
+	store.On("ListDue", mock.Anything, today).Return([]model.AutoRenewalConfig{missed}, nil)
+	store.On("RenewSubscription", mock.Anything, mock.Anything).Return(nil)
+
+	renewed := p.RunOnce(context.Background())
+
+	assert.Equal(t, 3, renewed)
+	for _, day := range []int{24, 3, 10} {
+		month := time.March
+		if day == 24 {
+			month = time.February
+		}
+		cfg := missed
+		cfg.NextRenewalDate = time.Date(2025, month, day, 0, 0, 0, 0, time.UTC)
+		store.AssertCalled(t, "RenewSubscription", mock.Anything, cfg)
+	}
+}
+
+func TestRenewalProcessor_FailureSkipsToNextSubscription(t *testing.T) {
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	p, store := newTestRenewalProcessor(today)
+
+	failing := renewalDueOn(today.AddDate(0, 0, -14), 7)
+	gone := renewalDueOn(today, 7)
+	ok := renewalDueOn(today, 7)
+	store.On("ListDue", mock.Anything, today).Return([]model.AutoRenewalConfig{failing, gone, ok}, nil)
+	store.On("RenewSubscription", mock.Anything, failing).Return(errors.New("db error"))
+	store.On("RenewSubscription", mock.Anything, gone).Return(fmt.Errorf("renew: %w", model.ErrNotFound))
+	store.On("RenewSubscription", mock.Anything, ok).Return(nil)
+
+	renewed := p.RunOnce(context.Background())
+
+	assert.Equal(t, 1, renewed)
+	store.AssertNumberOfCalls(t, "RenewSubscription", 3)
+}
+
+func TestRenewalProcessor_StoreError(t *testing.T) {
+	p, store := newTestRenewalProcessor(time.Now())
+
+	store.On("ListDue", mock.Anything, mock.Anything).Return([]model.AutoRenewalConfig(nil), errors.New("db error"))
+
+	assert.Equal(t, 0, p.RunOnce(context.Background()))
+	store.AssertNotCalled(t, "RenewSubscription", mock.Anything, mock.Anything)
+}
+
+func TestRenewalProcessor_RunStopsWithContext(t *testing.T) {
+	p, store := newTestRenewalProcessor(time.Now())
+	store.On("ListDue", mock.Anything, mock.Anything).Return([]model.AutoRenewalConfig(nil), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("processor did not stop after context cancellation")
+	}
+}
@@ -0,0 +1,217 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+const (
+	// DefaultReportInterval is the period one summary report covers.
+	DefaultReportInterval = 24 * time.Hour
+	// SummaryReportName identifies the summary report in report_runs.
+	SummaryReportName = "summary"
+	// reportRetryDelay is how long ReportJob waits after a failed run.
+	reportRetryDelay = 5 * time.Minute
+)
+
+// Report sums up the subscriptions of every tenant over [From, To).
+// ActiveMonthlyCost is the monthly equivalent of what the subscriptions
+// active at To cost, in the base currency.
+type Report struct {
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"`
+	NewSubscriptions    int       `json:"new_subscriptions"`
+	Cancellations       int       `json:"cancellations"`
+	ActiveSubscriptions int       `json:"active_subscriptions"`
+	ActiveMonthlyCost   float64   `json:"active_monthly_cost"`
+}
+
+// Reporter delivers a report.
+type Reporter interface {
+	Report(ctx context.Context, report Report) error
+}
+
+// ReportStore is repository.ReportRepository.
+type ReportStore interface {
+	CountCreated(ctx context.Context, from, to time.Time) (int, error)
+	CountCancelled(ctx context.Context, from, to time.Time) (int, error)
+	ActiveCostByCycle(ctx context.Context, at time.Time) ([]model.BillingCycleSummary, error)
+	LastRun(ctx context.Context, report string) (time.Time, error)
+	RecordRun(ctx context.Context, report string, periodEnd time.Time) error
+}
+
+// LogReporter writes reports to the log.
+type LogReporter struct {
+	log *slog.Logger
+}
+
+func NewLogReporter(log *slog.Logger) *LogReporter {
+	return &LogReporter{log: log}
+}
+
+func (r *LogReporter) Report(_ context.Context, report Report) error {
+	r.log.Info("summary report",
+		slog.Time("from", report.From),
+		slog.Time("to", report.To),
+		slog.Int("new_subscriptions", report.NewSubscriptions),
+		slog.Int("cancellations", report.Cancellations),
+		slog.Int("active_subscriptions", report.ActiveSubscriptions),
+		slog.Float64("active_monthly_cost", report.ActiveMonthlyCost),
+	)
+	return nil
+}
+
+// WebhookReporter POSTs each report as JSON to a URL and expects a 2xx
+// answer.
+type WebhookReporter struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookReporter(url string) *WebhookReporter {
+	return &WebhookReporter{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (r *WebhookReporter) Report(ctx context.Context, report Report) error {
+	const op = "worker.WebhookReporter.Report"
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ReportJob sends a summary report for every interval-long period. Periods
+// are aligned to the zero time, so a 24h interval covers UTC days. The end
+// of the last period sent is recorded in the store, so a restart does not
+// send it twice; periods missed while the service was down are not sent
+// late, only the latest one is.
+type ReportJob struct {
+	store    ReportStore
+	reporter Reporter
+	log      *slog.Logger
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewReportJob(store ReportStore, reporter Reporter, log *slog.Logger, interval time.Duration) *ReportJob {
+	if interval <= 0 {
+		interval = DefaultReportInterval
+	}
+	return &ReportJob{
+		store:    store,
+		reporter: reporter,
+		log:      log,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run sends the report of the last complete period if it is still due,
+// then waits for the next period to end, until ctx is done. A failed run
+// is retried after reportRetryDelay.
+func (j *ReportJob) Run(ctx context.Context) {
+	for {
+		wait := j.periodEnd(j.now()).Add(j.interval).Sub(j.now())
+		if _, err := j.RunOnce(ctx); err != nil {
+			j.log.Error("failed to send summary report", slog.String("error", err.Error()))
+			wait = min(wait, reportRetryDelay)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// RunOnce sends the report of the last complete period unless it has
+// been sent already, and reports whether it did.
+func (j *ReportJob) RunOnce(ctx context.Context) (bool, error) {
+	to := j.periodEnd(j.now())
+	from := to.Add(-j.interval)
+
+	last, err := j.store.LastRun(ctx, SummaryReportName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get last run: %w", err)
+	}
+	if !last.Before(to) {
+		return false, nil
+	}
+
+	report, err := j.build(ctx, from, to)
+	if err != nil {
+		return false, err
+	}
+	if err := j.reporter.Report(ctx, report); err != nil {
+		return false, fmt.Errorf("failed to deliver report: %w", err)
+	}
+	// The report is out; failing to record it only risks sending it again.
+	if err := j.store.RecordRun(ctx, SummaryReportName, to); err != nil {
+		return true, fmt.Errorf("failed to record run: %w", err)
+	}
+
+	return true, nil
+}
+
+func (j *ReportJob) build(ctx context.Context, from, to time.Time) (Report, error) {
+	report := Report{From: from, To: to}
+
+	var err error
+	if report.NewSubscriptions, err = j.store.CountCreated(ctx, from, to); err != nil {
+		return Report{}, fmt.Errorf("failed to count new subscriptions: %w", err)
+	}
+	if report.Cancellations, err = j.store.CountCancelled(ctx, from, to); err != nil {
+		return Report{}, fmt.Errorf("failed to count cancellations: %w", err)
+	}
+
+	cycles, err := j.store.ActiveCostByCycle(ctx, to)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get active cost: %w", err)
+	}
+	var total float64
+	for _, c := range cycles {
+		report.ActiveSubscriptions += c.Count
+		total += c.BillingCycle.MonthlyEquivalent(c.Total)
+	}
+	report.ActiveMonthlyCost = math.Round(total*100) / 100
+
+	return report, nil
+}
+
+// periodEnd is the end of the last complete period at t.
+func (j *ReportJob) periodEnd(t time.Time) time.Time {
+	return t.UTC().Truncate(j.interval)
+}
@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"SubscriptionAggregator/pkg/model"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// memReportStore serves fixed aggregates and keeps report_runs in memory.
+type memReportStore struct {
+	created, cancelled int
+	cycles             []model.BillingCycleSummary
+	lastRun            time.Time
+	err                error
+}
+
+func (s *memReportStore) CountCreated(context.Context, time.Time, time.Time) (int, error) {
+	return s.created, s.err
+}
+
+func (s *memReportStore) CountCancelled(context.Context, time.Time, time.Time) (int, error) {
+	return s.cancelled, s.err
+}
+
+func (s *memReportStore) ActiveCostByCycle(context.Context, time.Time) ([]model.BillingCycleSummary, error) {
+	return s.cycles, s.err
+}
+
+func (s *memReportStore) LastRun(context.Context, string) (time.Time, error) {
+	return s.lastRun, nil
+}
+
+func (s *memReportStore) RecordRun(_ context.Context, _ string, periodEnd time.Time) error {
+	s.lastRun = periodEnd
+	return nil
+}
+
+type MockReporter struct {
+	mock.Mock
+}
+
+func (m *MockReporter) Report(ctx context.Context, report Report) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+func newTestReportJob(clock *fakeClock, store ReportStore) (*ReportJob, *MockReporter) {
+	reporter := &MockReporter{}
+	j := NewReportJob(store, reporter, slog.New(slog.NewTextHandler(io.Discard, nil)), 24*time.Hour)
+	j.now = clock.Now
+	return j, reporter
+}
+
+func TestReportJob_SendsOncePerDay(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 0, 5, 0, 0, time.UTC)}
+	store := &memReportStore{
+		created:   4,
+		cancelled: 1,
+		cycles: []model.BillingCycleSummary{
+			{BillingCycle: model.CycleMonthly, Total: 1500, Count: 2},
+			{BillingCycle: model.CycleAnnual, Total: 1200, Count: 1},
+		},
+	}
+	j, reporter := newTestReportJob(clock, store)
+
+	march9 := Report{
+		From:                time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+		To:                  time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		NewSubscriptions:    4,
+		Cancellations:       1,
+		ActiveSubscriptions: 3,
+		ActiveMonthlyCost:   1600,
+	}
+	reporter.On("Report", mock.Anything, march9).Return(nil).Once()
+
+	sent, err := j.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.True(t, sent)
+
+	// Later the same day, or after a restart, the day has been reported.
+	clock.Advance(20 * time.Hour)
+	sent, err = j.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.False(t, sent)
+
+	march10 := march9
+	march10.From, march10.To = march9.To, march9.To.Add(24*time.Hour)
+	reporter.On("Report", mock.Anything, march10).Return(nil).Once()
+
+	clock.Advance(4 * time.Hour)
+	sent, err = j.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.True(t, sent)
+	reporter.AssertExpectations(t)
+}
+
+func TestReportJob_SkipsPeriodAlreadyRecorded(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+	store := &memReportStore{lastRun: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)}
+	j, reporter := newTestReportJob(clock, store)
+
+	sent, err := j.RunOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, sent)
+	reporter.AssertNotCalled(t, "Report", mock.Anything, mock.Anything)
+}
+
+func TestReportJob_FailedDeliveryIsRetried(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 0, 5, 0, 0, time.UTC)}
+	store := &memReportStore{}
+	j, reporter := newTestReportJob(clock, store)
+
+	reporter.On("Report", mock.Anything, mock.Anything).Return(errors.New("webhook down")).Once()
+	reporter.On("Report", mock.Anything, mock.Anything).Return(nil).Once()
+
+	sent, err := j.RunOnce(context.Background())
+	require.Error(t, err)
+	assert.False(t, sent)
+	assert.True(t, store.lastRun.IsZero(), "a failed delivery must not be recorded")
+
+	clock.Advance(reportRetryDelay)
+	sent, err = j.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.True(t, sent)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), store.lastRun)
+}
+
+func TestReportJob_StoreError(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 0, 5, 0, 0, time.UTC)}
+	j, reporter := newTestReportJob(clock, &memReportStore{err: errors.New("db error")})
+
+	sent, err := j.RunOnce(context.Background())
+
+	require.Error(t, err)
+	assert.False(t, sent)
+	reporter.AssertNotCalled(t, "Report", mock.Anything, mock.Anything)
+}
+
+func TestReportJob_RunStopsWithContext(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 10, 0, 5, 0, 0, time.UTC)}
+	j, reporter := newTestReportJob(clock, &memReportStore{})
+	reporter.On("Report", mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		j.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after context cancellation")
+	}
+}
+
+func TestWebhookReporter(t *testing.T) {
+	report := Report{
+		From:             time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+		To:               time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		NewSubscriptions: 2,
+	}
+
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewWebhookReporter(srv.URL).Report(context.Background(), report))
+	assert.Equal(t, report, got)
+}
+
+func TestWebhookReporter_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookReporter(srv.URL).Report(context.Background(), Report{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 502")
+}